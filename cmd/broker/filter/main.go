@@ -20,10 +20,17 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/filtered"
 	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
@@ -42,18 +49,24 @@ import (
 	"knative.dev/eventing/pkg/apis/feature"
 	"knative.dev/eventing/pkg/auth"
 	"knative.dev/eventing/pkg/broker/filter"
+	eventingscheme "knative.dev/eventing/pkg/client/clientset/versioned/scheme"
 	eventingclient "knative.dev/eventing/pkg/client/injection/client"
 	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
 	triggerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/trigger"
 	eventtypeinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1beta2/eventtype"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/eventtype"
+	"knative.dev/eventing/pkg/kncloudevents"
 	"knative.dev/eventing/pkg/reconciler/names"
 )
 
 const (
 	defaultMetricsPort = 9092
 	component          = "mt_broker_filter"
+
+	// certificateExpiryCheckInterval is the interval of the checks of the
+	// expiry of the server certificates of the subscribers.
+	certificateExpiryCheckInterval = time.Hour
 )
 
 type envConfig struct {
@@ -145,6 +158,9 @@ func main() {
 	if err != nil {
 		logger.Fatal("Error setting up trace publishing", zap.Error(err))
 	}
+	kncloudevents.WatchNamespaceSampling(sl, configMapWatcher, tracingconfig.ConfigName)
+	kncloudevents.WatchDeliveryDefaults(sl, configMapWatcher)
+	kncloudevents.WatchRedactedExtensions(configMapWatcher)
 
 	reporter := filter.NewStatsReporter(env.ContainerName, kmeta.ChildName(env.PodName, uuid.New().String()))
 
@@ -153,7 +169,7 @@ func main() {
 	// the messages to the triggers' subscribers) in this binary.
 	oidcTokenVerifier := auth.NewOIDCTokenVerifier(ctx)
 	trustBundleConfigMapInformer := configmapinformer.Get(ctx, eventingtls.TrustBundleLabelSelector).Lister().ConfigMaps(system.Namespace())
-	handler, err = filter.NewHandler(logger, oidcTokenVerifier, oidcTokenProvider, triggerinformer.Get(ctx), brokerinformer.Get(ctx), reporter, trustBundleConfigMapInformer, ctxFunc)
+	handler, err = filter.NewHandler(logger, oidcTokenVerifier, oidcTokenProvider, triggerinformer.Get(ctx), brokerinformer.Get(ctx), reporter, createRecorder(ctx, kubeClient), trustBundleConfigMapInformer, ctxFunc)
 	if err != nil {
 		logger.Fatal("Error creating Handler", zap.Error(err))
 	}
@@ -173,6 +189,8 @@ func main() {
 		logger.Fatal("Failed to start informers", zap.Error(err))
 	}
 
+	go handler.RunCertificateExpiryCheck(ctx, certificateExpiryCheckInterval)
+
 	// Start the servers
 	logger.Info("Filter starting...")
 	err = serverManager.StartServers(ctx)
@@ -183,6 +201,31 @@ func main() {
 	logger.Info("Exiting...")
 }
 
+// createRecorder creates the recorder of the events on the Triggers.
+func createRecorder(ctx context.Context, kubeClient kubernetes.Interface) record.EventRecorder {
+	logger := logging.FromContext(ctx)
+
+	eventBroadcaster := record.NewBroadcaster()
+	watches := []watch.Interface{
+		eventBroadcaster.StartLogging(logger.Named("event-broadcaster").Infof),
+		eventBroadcaster.StartRecordingToSink(
+			&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")}),
+	}
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: names.BrokerFilterName})
+	go func() {
+		<-ctx.Done()
+		for _, w := range watches {
+			w.Stop()
+		}
+	}()
+
+	return recorder
+}
+
+func init() {
+	eventingscheme.AddToScheme(scheme.Scheme)
+}
+
 func flush(logger *zap.SugaredLogger) {
 	_ = logger.Sync()
 	metrics.FlushExporter()
@@ -27,6 +27,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/filtered"
 
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
@@ -52,6 +54,7 @@ import (
 	eventtypeinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1beta2/eventtype"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/eventtype"
+	"knative.dev/eventing/pkg/kncloudevents"
 	"knative.dev/eventing/pkg/reconciler/names"
 )
 
@@ -77,6 +80,10 @@ type envConfig struct {
 	MaxTTL        int    `envconfig:"MAX_TTL" default:"255"`
 	HTTPPort      int    `envconfig:"INGRESS_PORT" default:"8080"`
 	HTTPSPort     int    `envconfig:"INGRESS_PORT_HTTPS" default:"8443"`
+	// MaxEventSize is the maximum size in bytes of the accepted events, 0 disables the limit.
+	MaxEventSize int64 `envconfig:"MAX_EVENT_SIZE" default:"0"`
+	// MaxEventSizeOverrides overrides MaxEventSize per namespace, e.g. "ns1:1048576,ns2:0".
+	MaxEventSizeOverrides map[string]int64 `envconfig:"MAX_EVENT_SIZE_NAMESPACE_OVERRIDES"`
 }
 
 func main() {
@@ -158,6 +165,7 @@ func main() {
 		}
 	})
 	featureStore.WatchConfigs(configMapWatcher)
+	kncloudevents.WatchRedactedExtensions(configMapWatcher)
 
 	// Decorate contexts with the current state of the feature config.
 	ctxFunc := func(ctx context.Context) context.Context {
@@ -168,11 +176,19 @@ func main() {
 
 	oidcTokenProvider := auth.NewOIDCTokenProvider(ctx)
 	oidcTokenVerifier := auth.NewOIDCTokenVerifier(ctx)
+	// Watch the OIDC config map and dynamically update the trusted external issuers.
+	configMapWatcher.WatchWithDefault(corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: auth.OIDCConfigMapName},
+	}, oidcTokenVerifier.UpdateFromConfigMap)
 	trustBundleConfigMapInformer := configmapinformer.Get(ctx, eventingtls.TrustBundleLabelSelector).Lister().ConfigMaps(system.Namespace())
 	handler, err = ingress.NewHandler(logger, reporter, broker.TTLDefaulter(logger, int32(env.MaxTTL)), brokerInformer, oidcTokenVerifier, oidcTokenProvider, trustBundleConfigMapInformer, ctxFunc)
 	if err != nil {
 		logger.Fatal("Error creating Handler", zap.Error(err))
 	}
+	handler.MaxEventSize = ingress.EventSizeLimits{
+		Default:    env.MaxEventSize,
+		Namespaces: env.MaxEventSizeOverrides,
+	}
 
 	serverManager, err := ingress.NewServerManager(ctx, logger, configMapWatcher, env.HTTPPort, env.HTTPSPort, handler)
 	if err != nil {
@@ -43,6 +43,7 @@ import (
 	"knative.dev/eventing/pkg/reconciler/parallel"
 	"knative.dev/eventing/pkg/reconciler/pingsource"
 	"knative.dev/eventing/pkg/reconciler/sequence"
+	reconcilersource "knative.dev/eventing/pkg/reconciler/source"
 	sourcecrd "knative.dev/eventing/pkg/reconciler/source/crd"
 	"knative.dev/eventing/pkg/reconciler/subscription"
 	sugarnamespace "knative.dev/eventing/pkg/reconciler/sugar/namespace"
@@ -58,10 +59,19 @@ func main() {
 		port = "8080"
 	}
 
+	// The source adapters send their heartbeats to the probes server.
+	heartbeats := reconcilersource.NewHeartbeats()
+	go heartbeats.Run(ctx, time.Minute)
+	ctx = reconcilersource.WithHeartbeats(ctx, heartbeats)
+
+	mux := http.NewServeMux()
+	mux.Handle(reconcilersource.HeartbeatsPath, heartbeats)
+	mux.HandleFunc("/", handler)
+
 	// sets up liveness and readiness probes.
 	server := http.Server{
 		ReadTimeout: 5 * time.Second,
-		Handler:     http.HandlerFunc(handler),
+		Handler:     mux,
 		Addr:        ":" + port,
 	}
 
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing/pkg/apis/sinks"
+	sinksv "knative.dev/eventing/pkg/apis/sinks/v1alpha1"
+)
+
+// activeJobsRetryAfter is the Retry-After header, in seconds, of the
+// responses rejecting events while a JobSink runs its maximum number of Jobs.
+const activeJobsRetryAfter = "10"
+
+// atActiveJobsLimit returns whether the JobSink runs its maximum number of
+// Jobs. The Jobs are counted from the informer cache, so that events don't
+// list them from the API server.
+func (h *Handler) atActiveJobsLimit(js *sinksv.JobSink) (bool, error) {
+	maxActiveJobs := js.GetMaxActiveJobs()
+	if maxActiveJobs <= 0 {
+		return false, nil
+	}
+
+	jobs, err := h.jobLister.Jobs(js.GetNamespace()).List(labels.SelectorFromSet(labels.Set{
+		sinks.JobSinkNameLabel: js.GetName(),
+	}))
+	if err != nil {
+		return false, err
+	}
+
+	active := 0
+	for _, job := range jobs {
+		if !isJobFinished(job) {
+			active++
+		}
+	}
+	return active >= maxActiveJobs, nil
+}
+
+// setFinishedJobsTTL makes Kubernetes delete the Job once finished for the
+// TTL of the JobSink, unless the Job of the JobSink already sets one.
+func setFinishedJobsTTL(js *sinksv.JobSink, job *batchv1.Job) {
+	ttl := js.GetFinishedJobsTTL()
+	if ttl <= 0 || job.Spec.TTLSecondsAfterFinished != nil {
+		return
+	}
+	seconds := int64(ttl.Seconds())
+	if seconds > math.MaxInt32 {
+		seconds = math.MaxInt32
+	}
+	job.Spec.TTLSecondsAfterFinished = ptr.Int32(int32(seconds))
+}
+
+// ownSecretByJob makes the Job the owner of the Secret holding its event, so
+// that the Secret is garbage collected with the Job instead of staying until
+// the JobSink is deleted.
+func (h *Handler) ownSecretByJob(ctx context.Context, job *batchv1.Job) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []metav1.OwnerReference{{
+				APIVersion:         batchv1.SchemeGroupVersion.String(),
+				Kind:               "Job",
+				Name:               job.GetName(),
+				UID:                job.GetUID(),
+				Controller:         ptr.Bool(true),
+				BlockOwnerDeletion: ptr.Bool(false),
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = h.k8s.CoreV1().Secrets(job.GetNamespace()).Patch(ctx, job.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func isJobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
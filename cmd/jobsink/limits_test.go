@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing/pkg/apis/sinks"
+	sinksv "knative.dev/eventing/pkg/apis/sinks/v1alpha1"
+	sinkslister "knative.dev/eventing/pkg/client/listers/sinks/v1alpha1"
+)
+
+const (
+	testNS       = "test-namespace"
+	jobSinkName  = "test-jobsink"
+	jobSinkRoute = "/" + testNS + "/" + jobSinkName
+)
+
+func TestHandlerLimits(t *testing.T) {
+	testCases := map[string]struct {
+		annotations    map[string]string
+		jobs           []runtime.Object
+		data           string
+		wantStatusCode int
+		wantRetryAfter string
+		wantJobTTL     *int32
+	}{
+		"no limits": {
+			wantStatusCode: http.StatusAccepted,
+		},
+		"below max active jobs": {
+			annotations: map[string]string{sinks.JobSinkMaxActiveJobsAnnotation: "2"},
+			jobs: []runtime.Object{
+				job("active", nil),
+				job("complete", &batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+				job("failed", &batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}),
+			},
+			wantStatusCode: http.StatusAccepted,
+		},
+		"at max active jobs": {
+			annotations: map[string]string{sinks.JobSinkMaxActiveJobsAnnotation: "2"},
+			jobs: []runtime.Object{
+				job("active-1", nil),
+				job("active-2", nil),
+			},
+			wantStatusCode: http.StatusTooManyRequests,
+			wantRetryAfter: activeJobsRetryAfter,
+		},
+		"below max event size": {
+			annotations:    map[string]string{sinks.JobSinkMaxEventSizeAnnotation: "1Ki"},
+			data:           "small",
+			wantStatusCode: http.StatusAccepted,
+		},
+		"above max event size": {
+			annotations:    map[string]string{sinks.JobSinkMaxEventSizeAnnotation: "1Ki"},
+			data:           strings.Repeat("a", 2048),
+			wantStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		"finished jobs TTL": {
+			annotations:    map[string]string{sinks.JobSinkFinishedJobsTTLAnnotation: "1h"},
+			wantStatusCode: http.StatusAccepted,
+			wantJobTTL:     ptr.Int32(3600),
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			js := &sinksv.JobSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        jobSinkName,
+					Namespace:   testNS,
+					UID:         "jobsink-uid",
+					Annotations: tc.annotations,
+				},
+				Spec: sinksv.JobSinkSpec{
+					Job: &batchv1.Job{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									Containers: []corev1.Container{{Name: "job", Image: "image"}},
+								},
+							},
+						},
+					},
+				},
+			}
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(js); err != nil {
+				t.Fatal("Add =", err)
+			}
+			k8s := fake.NewSimpleClientset(tc.jobs...)
+			jobIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, j := range tc.jobs {
+				if err := jobIndexer.Add(j); err != nil {
+					t.Fatal("Add =", err)
+				}
+			}
+
+			h := &Handler{
+				k8s:         k8s,
+				lister:      sinkslister.NewJobSinkLister(indexer),
+				jobLister:   batchlisters.NewJobLister(jobIndexer),
+				withContext: func(ctx context.Context) context.Context { return ctx },
+			}
+
+			e := event.New()
+			e.SetID("id")
+			e.SetSource("/source")
+			e.SetType("dev.knative.test")
+			_ = e.SetData(event.TextPlain, tc.data)
+
+			req := httptest.NewRequest(http.MethodPost, jobSinkRoute, nil)
+			if err := cehttp.WriteRequest(context.Background(), binding.ToMessage(&e), req); err != nil {
+				t.Fatal("WriteRequest =", err)
+			}
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			if resp.Code != tc.wantStatusCode {
+				t.Fatalf("Unexpected status code, want %d, got %d", tc.wantStatusCode, resp.Code)
+			}
+			if got := resp.Header().Get("Retry-After"); got != tc.wantRetryAfter {
+				t.Errorf("Unexpected Retry-After header, want %q, got %q", tc.wantRetryAfter, got)
+			}
+			if resp.Code != http.StatusAccepted {
+				return
+			}
+
+			jobName := jobNameForEvent(e)
+			created, err := k8s.BatchV1().Jobs(testNS).Get(context.Background(), jobName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal("Failed to get job:", err)
+			}
+			if got := created.Spec.TTLSecondsAfterFinished; (got == nil) != (tc.wantJobTTL == nil) || (got != nil && *got != *tc.wantJobTTL) {
+				t.Errorf("Unexpected ttlSecondsAfterFinished, want %v, got %v", tc.wantJobTTL, got)
+			}
+
+			secret, err := k8s.CoreV1().Secrets(testNS).Get(context.Background(), jobName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal("Failed to get secret:", err)
+			}
+			if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Kind != "Job" || secret.OwnerReferences[0].Name != jobName {
+				t.Errorf("Secret isn't owned by the job, got owners %+v", secret.OwnerReferences)
+			}
+		})
+	}
+}
+
+func job(name string, condition *batchv1.JobCondition) *batchv1.Job {
+	j := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNS,
+			Labels:    map[string]string{sinks.JobSinkNameLabel: jobSinkName},
+		},
+	}
+	if condition != nil {
+		j.Status.Conditions = append(j.Status.Conditions, *condition)
+	}
+	return j
+}
+
+func jobNameForEvent(e event.Event) string {
+	return kmeta.ChildName(jobSinkName, toIdHashLabelValue(e.Source(), e.ID()))
+}
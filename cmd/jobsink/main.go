@@ -34,7 +34,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	jobinformer "knative.dev/pkg/client/injection/kube/informers/batch/v1/job/filtered"
+	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 	configmap "knative.dev/pkg/configmap/informer"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection"
@@ -70,6 +73,10 @@ func main() {
 	cfg := injection.ParseAndGetRESTConfigOrDie()
 	ctx = injection.WithConfig(ctx, cfg)
 
+	// The Jobs created for the events, which all carry the JobSink name label,
+	// are counted against the max active jobs of their JobSink.
+	ctx = filteredFactory.WithSelectors(ctx, sinks.JobSinkNameLabel)
+
 	ctx, informers := injection.Default.SetupInformers(ctx, cfg)
 	ctx = injection.WithConfig(ctx, cfg)
 	loggingConfig, err := cmdbroker.GetLoggingConfig(ctx, system.Namespace(), logging.ConfigMapName())
@@ -103,19 +110,20 @@ func main() {
 
 	logger.Info("Starting the JobSink Ingress")
 
-	featureStore := feature.NewStore(logging.FromContext(ctx).Named("feature-config-store"), func(name string, value interface{}) {
-		logger.Info("Updated", zap.String("name", name), zap.Any("value", value))
+	features := feature.NewClient(logging.FromContext(ctx).Named("feature-config-store"), configMapWatcher)
+	features.OnChange(func(flags feature.Flags) {
+		logger.Info("Updated", zap.String("name", feature.FlagsConfigName), zap.Any("value", flags))
 	})
-	featureStore.WatchConfigs(configMapWatcher)
 
 	// Decorate contexts with the current state of the feature config.
 	ctxFunc := func(ctx context.Context) context.Context {
-		return logging.WithLogger(featureStore.ToContext(ctx), sl)
+		return logging.WithLogger(features.ToContext(ctx), sl)
 	}
 
 	h := &Handler{
 		k8s:               kubeclient.Get(ctx),
 		lister:            jobsink.Get(ctx).Lister(),
+		jobLister:         jobinformer.Get(ctx, sinks.JobSinkNameLabel).Lister(),
 		withContext:       ctxFunc,
 		oidcTokenVerifier: auth.NewOIDCTokenVerifier(ctx),
 	}
@@ -160,6 +168,7 @@ func main() {
 type Handler struct {
 	k8s               kubernetes.Interface
 	lister            sinkslister.JobSinkLister
+	jobLister         batchlisters.JobLister
 	withContext       func(ctx context.Context) context.Context
 	oidcTokenVerifier *auth.OIDCTokenVerifier
 }
@@ -209,11 +218,28 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		logger.Debug("Request contained a valid JWT. Continuing...")
 	}
 
+	js, err := h.lister.JobSinks(ref.Namespace).Get(ref.Name)
+	if err != nil {
+		logger.Warn("Failed to retrieve jobsink", zap.String("ref", ref.String()), zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !kncloudevents.LimitRequestBody(w, r, js.GetMaxEventSize()) {
+		logger.Info("Request body exceeds the max event size of the jobsink", zap.String("ref", ref.String()))
+		return
+	}
+
 	message := cehttp.NewMessageFromHttpRequest(r)
 	defer message.Finish(nil)
 
 	event, err := binding.ToEvent(r.Context(), message)
 	if err != nil {
+		if kncloudevents.IsBodyTooLarge(err) {
+			logger.Info("Request body exceeds the max event size of the jobsink", zap.String("ref", ref.String()))
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
 		logger.Warn("failed to extract event from request", zap.Error(err))
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -225,13 +251,6 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	js, err := h.lister.JobSinks(ref.Namespace).Get(ref.Name)
-	if err != nil {
-		logger.Warn("Failed to retrieve jobsink", zap.String("ref", ref.String()), zap.Error(err))
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
 	id := toIdHashLabelValue(event.Source(), event.ID())
 	logger.Debug("Getting job for event", zap.String("URI", r.RequestURI), zap.String("id", id))
 
@@ -250,6 +269,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	atLimit, err := h.atActiveJobsLimit(js)
+	if err != nil {
+		logger.Warn("Failed to count active jobs", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if atLimit {
+		logger.Info("Jobsink runs its max active jobs, rejecting event", zap.String("ref", ref.String()))
+		w.Header().Set("Retry-After", activeJobsRetryAfter)
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
 	eventBytes, err := event.MarshalJSON()
 	if err != nil {
 		logger.Info("Failed to marshal event", zap.Error(err))
@@ -347,7 +379,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	_, err = h.k8s.BatchV1().Jobs(ref.Namespace).Create(r.Context(), job, metav1.CreateOptions{})
+	setFinishedJobsTTL(js, job)
+
+	job, err = h.k8s.BatchV1().Jobs(ref.Namespace).Create(r.Context(), job, metav1.CreateOptions{})
 	if err != nil {
 		logger.Warn("Failed to create job", zap.Error(err))
 
@@ -356,6 +390,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.ownSecretByJob(r.Context(), job); err != nil {
+		// The secret is still deleted with the jobsink.
+		logger.Warn("Failed to set the job as owner of the secret", zap.String("jobName", jobName), zap.Error(err))
+	}
+
 	w.Header().Add("Location", locationHeader(ref, event.Source(), event.ID()))
 	w.WriteHeader(http.StatusAccepted)
 }
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/signals"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	"knative.dev/eventing/pkg/replay"
+)
+
+/*
+Replays the events stored by a dead letter sink to the destinations they
+couldn't be delivered to, once the cause of the failures is fixed. It is meant
+to be run once, for instance as a Job, by an operator.
+
+The dead letter sink must implement the list/fetch contract of the
+knative.dev/eventing/pkg/replay package.
+*/
+
+type envConfig struct {
+	// DeadLetterSinkURL is the URL of the events of the dead letter sink.
+	DeadLetterSinkURL string `envconfig:"REPLAY_DLS_URL" required:"true"`
+
+	// PageSize is the number of events listed at once.
+	PageSize int `envconfig:"REPLAY_PAGE_SIZE" default:"100"`
+
+	// Limit is the maximum number of events replayed, 0 replays them all.
+	Limit int `envconfig:"REPLAY_LIMIT" default:"0"`
+
+	// DryRun only logs the events which would be replayed.
+	DryRun bool `envconfig:"REPLAY_DRY_RUN" default:"false"`
+
+	// Retries is the number of times sending an event is retried.
+	Retries int32 `envconfig:"REPLAY_RETRIES" default:"3"`
+
+	// BackoffDelay is the ISO 8601 delay before the first retry.
+	BackoffDelay string `envconfig:"REPLAY_BACKOFF_DELAY" default:"PT0.2S"`
+}
+
+func main() {
+	ctx := signals.NewContext()
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		log.Printf("[ERROR] Failed to process env var: %s", err)
+		os.Exit(1)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("failed to create logger, %v", err)
+	}
+	defer func() { _ = logger.Sync() }()
+
+	dlsURL, err := apis.ParseURL(env.DeadLetterSinkURL)
+	if err != nil {
+		logger.Fatal("Invalid dead letter sink URL", zap.String("url", env.DeadLetterSinkURL), zap.Error(err))
+	}
+
+	backoffPolicy := eventingduckv1.BackoffPolicyExponential
+	retryConfig, err := kncloudevents.RetryConfigFromDeliverySpec(eventingduckv1.DeliverySpec{
+		Retry:         &env.Retries,
+		BackoffPolicy: &backoffPolicy,
+		BackoffDelay:  &env.BackoffDelay,
+	})
+	if err != nil {
+		logger.Fatal("Invalid retry configuration", zap.Error(err))
+	}
+
+	replayer := replay.NewReplayer(
+		replay.NewClient(dlsURL, nil),
+		kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), nil),
+		logger,
+	)
+
+	logger.Info("Replaying dead lettered events", zap.Stringer("url", dlsURL), zap.Bool("dryRun", env.DryRun))
+	result, err := replayer.Replay(ctx, replay.Options{
+		PageSize:    env.PageSize,
+		Limit:       env.Limit,
+		DryRun:      env.DryRun,
+		SendOptions: []kncloudevents.SendOption{kncloudevents.WithRetryConfig(&retryConfig)},
+	})
+	logger.Info("Replay finished",
+		zap.Int("replayed", result.Replayed),
+		zap.Int("failed", result.Failed),
+		zap.Int("skipped", result.Skipped))
+	if err != nil {
+		logger.Fatal("Failed to replay dead lettered events", zap.Error(err))
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,13 +34,20 @@ import (
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
+	"knative.dev/eventing/pkg/adapter/apiserver/events"
 	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/eventing/pkg/adapter/v2/sinkbinding"
 	v1 "knative.dev/eventing/pkg/apis/sources/v1"
 	brokerfilter "knative.dev/eventing/pkg/broker/filter"
 	"knative.dev/eventing/pkg/eventfilter/subscriptionsapi"
 )
 
+// shutdownTimeout is the time given to the pending events to be sent when the
+// adapter stops, it is below the default pod termination grace period.
+const shutdownTimeout = 20 * time.Second
+
 type envConfig struct {
 	adapter.EnvConfig
 	Name string `envconfig:"NAME" required:"true"`
@@ -46,15 +56,25 @@ type envConfig struct {
 }
 
 type apiServerAdapter struct {
-	ce     cloudevents.Client
-	logger *zap.SugaredLogger
+	ce cloudevents.Client
+	// binding targets the sink with the OIDC token and the metric tag of the
+	// source, it is nil when ce targets the sink.
+	binding *sinkbinding.Binding
+	sink    url.URL
+	logger  *zap.SugaredLogger
 
 	config Config
 
-	discover discovery.DiscoveryInterface
-	k8s      dynamic.Interface
-	source   string // TODO: who dis?
-	name     string // TODO: who dis?
+	discover  discovery.DiscoveryInterface
+	k8s       dynamic.Interface
+	recorder  record.EventRecorder
+	source    string // TODO: who dis?
+	name      string // TODO: who dis?
+	namespace string
+
+	// replays tracks the replays of the existing objects, it is nil when they
+	// are not replayed.
+	replays *replayTracker
 }
 
 func (a *apiServerAdapter) Start(ctx context.Context) error {
@@ -67,14 +87,63 @@ func (a *apiServerAdapter) start(ctx context.Context, stopCh <-chan struct{}) er
 
 	resyncPeriod := 10 * time.Hour
 
-	var delegate cache.Store = &resourceDelegate{
+	reporter := &statsReporter{namespace: a.namespace, name: a.name}
+	rd := &resourceDelegate{
 		ce:                  a.ce,
+		binding:             a.binding,
+		sink:                a.sink,
 		source:              a.source,
 		logger:              a.logger,
+		reporter:            reporter,
 		ref:                 a.config.EventMode == v1.ReferenceMode,
 		apiServerSourceName: a.name,
-		filter:              subscriptionsapi.NewAllFilter(brokerfilter.MaterializeFiltersList(a.logger.Desugar(), a.config.Filters)...),
+		payloadMode:         a.config.PayloadMode,
+		additionalSinks:     a.config.AdditionalSinks,
+	}
+	if !rd.ref {
+		rd.projection = a.config.Projection
+	}
+	if !rd.ref && (rd.payloadMode == v1.PayloadModeDiff || rd.payloadMode == v1.PayloadModeBoth) {
+		rd.objects = newObjectCache()
+	}
+	// Filters are evaluated before the events are sent, so that events of
+	// high-churn resources not matching them never leave the adapter.
+	if len(a.config.Filters) > 0 {
+		rd.filter = subscriptionsapi.NewAllFilter(brokerfilter.MaterializeFiltersList(a.logger.Desugar(), a.config.Filters)...)
 	}
+	// The templated type and source are rendered before the filters are
+	// evaluated, so that the filters can match them.
+	eventTemplate, err := events.NewEventTemplate(a.config.EventTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid event template: %w", err)
+	}
+	rd.template = eventTemplate
+	d, err := newDelivery(a.config.Delivery)
+	if err != nil {
+		return fmt.Errorf("invalid delivery configuration: %w", err)
+	}
+	rd.delivery = d
+
+	if a.config.Dedup != nil {
+		rd.dedup, err = newDedupStore(a.config.Dedup, a.k8s, a.namespace)
+		if err != nil {
+			return fmt.Errorf("invalid dedup configuration: %w", err)
+		}
+		if err := rd.dedup.load(ctx); err != nil {
+			a.logger.Warnw("failed to load the dedup store, sending all the objects", zap.Error(err))
+		}
+		go a.persistDedup(rd.dedup, stop)
+	}
+
+	if a.config.Workers > 0 {
+		rd.workers = newWorkerPools(a.config.Workers, reporter, rd.sendCloudEvent)
+	}
+
+	if a.config.DebounceWindowMillis > 0 {
+		rd.debouncer = newDebouncer(time.Duration(a.config.DebounceWindowMillis)*time.Millisecond, reporter, rd.dispatch)
+	}
+
+	var delegate cache.Store = rd
 	if a.config.ResourceOwner != nil {
 		a.logger.Infow("will be filtered",
 			zap.String("APIVersion", a.config.ResourceOwner.APIVersion),
@@ -88,43 +157,41 @@ func (a *apiServerAdapter) start(ctx context.Context, stopCh <-chan struct{}) er
 
 	a.logger.Infof("STARTING -- %#v", a.config)
 
-	for _, configRes := range a.config.Resources {
+	a.replays = a.newReplays(ctx)
+
+	var reflectors sync.WaitGroup
+
+	var namespaces *namespaceWatcher
+	if a.config.NamespaceSelector != "" {
+		namespaces = newNamespaceWatcher(a.logger, stop)
+		a.watchNamespaces(ctx, namespaces, resyncPeriod, stop, &reflectors)
+	}
 
-		resources, err := a.discover.ServerResourcesForGroupVersion(configRes.GVR.GroupVersion().String())
+	for _, configRes := range a.config.Resources {
+		apires, err := a.findResource(configRes.GVR)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve information about resource %s: %v", configRes.GVR.String(), err)
 		}
 
-		exists := false
-		for _, apires := range resources.APIResources {
-			if apires.Name == configRes.GVR.Resource {
-				var resources []dynamic.ResourceInterface
-				if apires.Namespaced && !a.config.AllNamespaces {
-					for _, ns := range a.config.Namespaces {
-						resources = append(resources, a.k8s.Resource(configRes.GVR).Namespace(ns))
-					}
-				} else {
-					resources = append(resources, a.k8s.Resource(configRes.GVR))
-				}
-
-				for _, res := range resources {
-					lw := &cache.ListWatch{
-						ListFunc:  asUnstructuredLister(ctx, res.List, configRes.LabelSelector),
-						WatchFunc: asUnstructuredWatcher(ctx, res.Watch, configRes.LabelSelector),
-					}
-
-					reflector := cache.NewReflector(lw, &unstructured.Unstructured{}, delegate, resyncPeriod)
-					go reflector.Run(stop)
-				}
-
-				exists = true
-				break
-			}
+		watchResource := func(apires *metav1.APIResource) {
+			a.watchResource(ctx, configRes, apires, delegate, namespaces, resyncPeriod, stop, &reflectors)
 		}
-
-		if !exists {
-			a.logger.Errorf("could not retrieve information about resource %s: it doesn't exist", configRes.GVR.String())
+		if apires != nil {
+			watchResource(apires)
+			continue
 		}
+
+		// The resource may be served later on, e.g. once its CRD is installed.
+		// The goroutine is counted so that no reflector is added once the
+		// adapter waits for them to stop.
+		reflectors.Add(1)
+		go func() {
+			defer reflectors.Done()
+			a.awaitResource(configRes.GVR, stop, watchResource)
+		}()
+	}
+	if a.replays != nil {
+		a.replays.seal()
 	}
 
 	srv := &http.Server{
@@ -139,11 +206,154 @@ func (a *apiServerAdapter) start(ctx context.Context, stopCh <-chan struct{}) er
 	go srv.ListenAndServe()
 
 	<-stopCh
-	stop <- struct{}{}
+	a.shutdown(rd, stop, &reflectors)
 	srv.Shutdown(ctx)
 	return nil
 }
 
+// watchResource starts the reflectors of the resource, one per namespace for
+// namespaced resources. The reflectors of the namespaced resources are started
+// and stopped by namespaces, when set, as the namespaces come and go.
+func (a *apiServerAdapter) watchResource(ctx context.Context, configRes ResourceWatch, apires *metav1.APIResource, delegate cache.Store, namespaces *namespaceWatcher, resyncPeriod time.Duration, stop <-chan struct{}, reflectors *sync.WaitGroup) {
+	if apires.Namespaced && namespaces != nil {
+		namespaces.watch(func(ns string, nsStop <-chan struct{}) {
+			a.runReflector(ctx, a.k8s.Resource(configRes.GVR).Namespace(ns), configRes, delegate, resyncPeriod, nsStop, reflectors)
+		})
+		return
+	}
+
+	var resources []dynamic.ResourceInterface
+	if apires.Namespaced && !a.config.AllNamespaces {
+		for _, ns := range a.config.Namespaces {
+			resources = append(resources, a.k8s.Resource(configRes.GVR).Namespace(ns))
+		}
+	} else {
+		resources = append(resources, a.k8s.Resource(configRes.GVR))
+	}
+
+	for _, res := range resources {
+		a.runReflector(ctx, res, configRes, delegate, resyncPeriod, stop, reflectors)
+	}
+}
+
+// runReflector runs a reflector of the resource until stop is closed.
+func (a *apiServerAdapter) runReflector(ctx context.Context, res dynamic.ResourceInterface, configRes ResourceWatch, delegate cache.Store, resyncPeriod time.Duration, stop <-chan struct{}, reflectors *sync.WaitGroup) {
+	lw := &cache.ListWatch{
+		ListFunc:  asUnstructuredLister(ctx, res.List, configRes.LabelSelector),
+		WatchFunc: asUnstructuredWatcher(ctx, res.Watch, configRes.LabelSelector),
+	}
+
+	store := delegate
+	if a.replays != nil {
+		store = &initialEventsStore{Store: delegate, replayed: a.replays.add()}
+	}
+
+	reflector := cache.NewReflector(lw, &unstructured.Unstructured{}, store, resyncPeriod)
+	reflectors.Add(1)
+	go func() {
+		defer reflectors.Done()
+		reflector.Run(stop)
+	}()
+}
+
+// watchNamespaces runs the reflector of the namespaces matching the namespace
+// selector, which starts and stops the reflectors of the namespaced resources.
+func (a *apiServerAdapter) watchNamespaces(ctx context.Context, namespaces *namespaceWatcher, resyncPeriod time.Duration, stop <-chan struct{}, reflectors *sync.WaitGroup) {
+	res := a.k8s.Resource(corev1.SchemeGroupVersion.WithResource("namespaces"))
+	lw := &cache.ListWatch{
+		ListFunc:  asUnstructuredLister(ctx, res.List, a.config.NamespaceSelector),
+		WatchFunc: asUnstructuredWatcher(ctx, res.Watch, a.config.NamespaceSelector),
+	}
+
+	// The reflectors of the namespaced resources are started while the
+	// reflector of the namespaces runs, so that none is added once the
+	// adapter waits for them to stop. Their replays are tracked before the
+	// initial list of the namespaces is marked as replayed.
+	var store cache.Store = namespaces
+	if a.replays != nil {
+		store = &initialEventsStore{Store: namespaces, replayed: a.replays.add()}
+	}
+	reflector := cache.NewReflector(lw, &unstructured.Unstructured{}, store, resyncPeriod)
+	reflectors.Add(1)
+	go func() {
+		defer reflectors.Done()
+		reflector.Run(stop)
+	}()
+}
+
+// newReplays returns the tracker of the replays of the existing objects, nil
+// when they are not replayed: the initial events are not sent and the source
+// has no replay token, or its replay token was already replayed. The replay
+// token is persisted once the existing objects are replayed.
+func (a *apiServerAdapter) newReplays(ctx context.Context) *replayTracker {
+	if a.config.SendInitialEvents {
+		return &replayTracker{}
+	}
+	token := a.config.ReplayToken
+	if token == "" {
+		return nil
+	}
+
+	watermark := newReplayWatermark(a.k8s, a.namespace, a.name)
+	if replayed, err := watermark.replayed(ctx, token); err != nil {
+		a.logger.Warnw("failed to get the replay watermark, replaying the existing objects", zap.Error(err))
+	} else if replayed {
+		a.logger.Infow("the existing objects were already replayed", zap.String("replayToken", token))
+		return nil
+	}
+
+	return &replayTracker{
+		onDone: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), replayPersistTimeout)
+			defer cancel()
+			if err := watermark.persist(ctx, token); err != nil {
+				a.logger.Warnw("failed to persist the replay watermark", zap.Error(err))
+			}
+		},
+	}
+}
+
+// shutdown stops the reflectors, so that no new event is created, then sends the
+// pending events within shutdownTimeout before persisting the dedup store.
+func (a *apiServerAdapter) shutdown(rd *resourceDelegate, stop chan struct{}, reflectors *sync.WaitGroup) {
+	close(stop)
+	reflectors.Wait()
+
+	// The adapter context is done at this point, the shutdown has its own deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	a.logger.Info("sending the pending events")
+	rd.drain(ctx)
+
+	if rd.dedup != nil {
+		persistCtx, cancel := context.WithTimeout(context.Background(), dedupPersistTimeout)
+		defer cancel()
+		if err := rd.dedup.persist(persistCtx); err != nil {
+			a.logger.Warnw("failed to persist the dedup store", zap.Error(err))
+		}
+	}
+}
+
+// persistDedup persists the dedup store every dedupPersistPeriod until stop
+// is closed.
+func (a *apiServerAdapter) persistDedup(dedup *dedupStore, stop <-chan struct{}) {
+	ticker := time.NewTicker(dedupPersistPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), dedupPersistTimeout)
+			if err := dedup.persist(ctx); err != nil {
+				a.logger.Warnw("failed to persist the dedup store", zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
+
 type unstructuredLister func(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error)
 
 func asUnstructuredLister(ctx context.Context, ulist unstructuredLister, selector string) cache.ListFunc {
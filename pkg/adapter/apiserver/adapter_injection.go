@@ -19,10 +19,14 @@ package apiserver
 import (
 	"context"
 	"encoding/json"
+	"net/url"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/eventing/pkg/adapter/v2/sinkbinding"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/clients/dynamicclient"
@@ -57,7 +61,14 @@ func NewEnvConfig() adapter.EnvConfigAccessor {
 	return &envConfig{}
 }
 
-func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+// resourceGroup is the resource group of the ApiServerSources, the metrics of
+// the events sent are tagged with.
+const resourceGroup = "apiserversources.sources.knative.dev"
+
+// NewAdapter creates the adapter of the source. The events are sent with the
+// client of the sink binding of the source, see newBinding, rather than with
+// the client of the adapter main.
+func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, _ cloudevents.Client) adapter.Adapter {
 	logger := logging.FromContext(ctx)
 	env := processed.(*envConfig)
 
@@ -66,14 +77,57 @@ func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClie
 		panic("failed to create config from json")
 	}
 
+	var sink url.URL
+	if u, err := url.Parse(env.GetSink()); err == nil {
+		sink = *u
+	}
+
+	binding, err := newBinding(ctx, env)
+	if err != nil {
+		logger.Fatalw("failed to create the sink binding", zap.Error(err))
+	}
+
 	return &apiServerAdapter{
-		discover: kubeclient.Get(ctx).Discovery(),
-		k8s:      dynamicclient.Get(ctx),
-		ce:       ceClient,
-		source:   Get(ctx),
-		name:     env.Name,
-		config:   config,
+		binding:   binding,
+		sink:      sink,
+		discover:  kubeclient.Get(ctx).Discovery(),
+		k8s:       dynamicclient.Get(ctx),
+		recorder:  createRecorder(ctx, kubeclient.Get(ctx), env.Namespace, "apiserversource-adapter"),
+		ce:        binding.Client(),
+		source:    Get(ctx),
+		name:      env.Name,
+		namespace: env.Namespace,
+		config:    config,
 
 		logger: logger,
 	}
 }
+
+// newBinding returns the binding sending the events of the source to its sink,
+// with the OIDC token and the metric tag of the source.
+func newBinding(ctx context.Context, env *envConfig) (*sinkbinding.Binding, error) {
+	ceOverrides, err := env.GetCloudEventOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceAccountName *string
+	if sa := env.GetOIDCServiceAccountName(); sa != nil {
+		serviceAccountName = &sa.Name
+	}
+
+	return sinkbinding.New(sinkbinding.Config{
+		Source:        types.NamespacedName{Namespace: env.Namespace, Name: env.Name},
+		ResourceGroup: resourceGroup,
+		Sink: sinkbinding.Sink{
+			URI:      env.GetSink(),
+			CACerts:  env.GetCACerts(),
+			Audience: env.GetAudience(),
+		},
+		ServiceAccountName: serviceAccountName,
+		CeOverrides:        ceOverrides,
+		// The retries are those of the delivery configuration, which applies
+		// to the dead letter sink too, see delivery.
+		Client: adapter.GetClientConfig(ctx),
+	})
+}
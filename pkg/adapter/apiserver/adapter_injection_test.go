@@ -18,12 +18,18 @@ package apiserver
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
 	fakekubeclientset "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	"knative.dev/eventing/pkg/adapter/v2"
 	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+	"knative.dev/eventing/pkg/apis/sources"
 	reconcilertesting "knative.dev/eventing/pkg/reconciler/testing"
 	"knative.dev/pkg/injection"
 	logtesting "knative.dev/pkg/logging/testing"
@@ -89,3 +95,43 @@ func TestNewAdaptor(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAdapterSendsToSink(t *testing.T) {
+	received := make(chan string, 1)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Ce-Type")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer sink.Close()
+
+	ctx, _ := SetupFakeContextWithCancel(t, nil)
+	a := NewAdapter(ctx, &envConfig{
+		EnvConfig: adapter.EnvConfig{
+			Namespace: "test",
+			Sink:      sink.URL,
+		},
+		Name:       "unittest",
+		ConfigJson: "{}",
+	}, nil).(*apiServerAdapter)
+	if a.binding == nil {
+		t.Fatal("Expected the adapter to send with the sink binding")
+	}
+
+	d := &resourceDelegate{
+		ce:                  a.ce,
+		binding:             a.binding,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              zap.NewExample().Sugar(),
+	}
+	d.Add(simplePod("unit", "test"))
+
+	select {
+	case got := <-received:
+		if got != sources.ApiServerSourceAddEventType {
+			t.Errorf("Expected an event of type %s, got %s", sources.ApiServerSourceAddEventType, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the event to be sent to the sink")
+	}
+}
@@ -18,6 +18,7 @@ package apiserver
 import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	v1 "knative.dev/eventing/pkg/apis/sources/v1"
 )
@@ -41,6 +42,13 @@ type Config struct {
 	// existing namespaces
 	AllNamespaces bool `json:"allNamespaces"`
 
+	// NamespaceSelector is the label selector of the namespaces where the
+	// namespaced Resources[] exist. When set, Namespaces is ignored and the
+	// namespaces are watched, so that the namespaced resources are watched in
+	// the namespaces as they start or stop matching the selector.
+	// +optional
+	NamespaceSelector string `json:"namespaceSelector,omitempty"`
+
 	// Resource is the resource this source will track and send related
 	// lifecycle events from the Kubernetes ApiServer.
 	// +required
@@ -59,6 +67,22 @@ type Config struct {
 	// +optional
 	EventMode string `json:"mode,omitempty"`
 
+	// PayloadMode controls the payload of the update events.
+	// `New` sends the new state of the resource.
+	// `Diff` sends the JSON patch from the previous to the new state of the resource.
+	// `Both` sends the previous and the new state of the resource.
+	// Defaults to `New`
+	// +optional
+	PayloadMode string `json:"payloadMode,omitempty"`
+
+	// Projection selects the fields of the resources sent in the events.
+	// +optional
+	Projection *v1.ApiServerSourceProjection `json:"projection,omitempty"`
+
+	// EventTemplate renders the type and the source of the events.
+	// +optional
+	EventTemplate *v1.ApiServerSourceEventTemplate `json:"eventTemplate,omitempty"`
+
 	// Filters is an experimental field that conforms to the CNCF CloudEvents Subscriptions
 	// API. It's an array of filter expressions that evaluate to true or false.
 	// If any filter expression in the array evaluates to false, the event MUST
@@ -68,4 +92,90 @@ type Config struct {
 	//
 	// +optional
 	Filters []eventingv1.SubscriptionsAPIFilter `json:"filters,omitempty"`
+
+	// DebounceWindowMillis is the window, in milliseconds, during which the events
+	// for the same object are coalesced into a single event carrying the latest
+	// state of the object. Zero disables debouncing.
+	// +optional
+	DebounceWindowMillis int64 `json:"debounceWindowMillis,omitempty"`
+
+	// Workers is the number of workers sending the events of each resource.
+	// The events of the same object are always sent by the same worker,
+	// preserving their order, while the events of different objects are sent
+	// in parallel. Zero sends the events synchronously.
+	// +optional
+	Workers int `json:"workers,omitempty"`
+
+	// AdditionalSinks are the sinks receiving a copy of every event sent to
+	// the sink.
+	// +optional
+	AdditionalSinks []SinkConfig `json:"additionalSinks,omitempty"`
+
+	// Dedup enables the deduplication of the events of the object versions
+	// already sent, such as the objects listed again after a restart.
+	// +optional
+	Dedup *DedupConfig `json:"dedup,omitempty"`
+
+	// Delivery is the delivery configuration for the events sent to the sink.
+	// +optional
+	Delivery *DeliveryConfig `json:"delivery,omitempty"`
+
+	// SendInitialEvents controls whether the objects returned by the initial
+	// list of the resources are sent as add events.
+	// +optional
+	SendInitialEvents bool `json:"sendInitialEvents,omitempty"`
+
+	// ReplayToken is the value of the replay annotation of the source. When set,
+	// the objects returned by the initial list are sent as add events, once per
+	// token: the replayed token is persisted in the <source>-replay ConfigMap,
+	// so that the service account of the source needs to get, create and
+	// update ConfigMaps in its namespace.
+	// +optional
+	ReplayToken string `json:"replayToken,omitempty"`
+}
+
+type DedupConfig struct {
+	// Size is the number of object versions remembered, defaults to 10000.
+	// +optional
+	Size int `json:"size,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap, in the namespace of the
+	// source, persisting the object versions across restarts. The versions are
+	// kept in memory only when empty.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+type SinkConfig struct {
+	// URI is the resolved URI of the sink.
+	// +required
+	URI string `json:"uri"`
+
+	// Audience is the OIDC audience of the sink.
+	// +optional
+	Audience *string `json:"audience,omitempty"`
+}
+
+type DeliveryConfig struct {
+	// Retry is the minimum number of retries the adapter should attempt when
+	// sending an event before moving it to the dead letter sink.
+	// +optional
+	Retry int32 `json:"retry,omitempty"`
+
+	// BackoffPolicy is the retry backoff policy (linear, exponential).
+	// +optional
+	BackoffPolicy *duckv1.BackoffPolicyType `json:"backoffPolicy,omitempty"`
+
+	// BackoffDelay is the delay before retrying, in ISO 8601 duration format.
+	// +optional
+	BackoffDelay *string `json:"backoffDelay,omitempty"`
+
+	// DeadLetterSink is the resolved URI of the sink receiving the events that
+	// could not be sent to the sink.
+	// +optional
+	DeadLetterSink string `json:"deadLetterSink,omitempty"`
+
+	// DeadLetterSinkAudience is the OIDC audience of the dead letter sink.
+	// +optional
+	DeadLetterSinkAudience *string `json:"deadLetterSinkAudience,omitempty"`
 }
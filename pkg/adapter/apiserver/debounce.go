@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// debouncer coalesces the events of the same type for the same object key
+// received within window into a single event carrying the latest state of the
+// object.
+type debouncer struct {
+	window   time.Duration
+	send     func(ctx context.Context, event cloudevents.Event)
+	reporter *statsReporter
+
+	mu      sync.Mutex
+	pending map[string]*pendingEvent
+}
+
+type pendingEvent struct {
+	ctx   context.Context
+	event cloudevents.Event
+	// kind is the type of event before its type is templated.
+	kind string
+}
+
+func newDebouncer(window time.Duration, reporter *statsReporter, send func(ctx context.Context, event cloudevents.Event)) *debouncer {
+	return &debouncer{
+		window:   window,
+		send:     send,
+		reporter: reporter,
+		pending:  make(map[string]*pendingEvent),
+	}
+}
+
+// enqueue schedules event to be sent at the end of the debounce window of key,
+// replacing the event already pending for the same key if it is of the same
+// kind. kind is the type of the event before it is templated, as a template may
+// give the same type to the events of different verbs. A pending event of
+// another kind is sent right away, so that e.g. the add of an object isn't
+// hidden by its update, nor its delete sent without its add.
+func (d *debouncer) enqueue(ctx context.Context, key, kind string, event cloudevents.Event) {
+	d.mu.Lock()
+	prev, ok := d.pending[key]
+	if ok && prev.kind == kind {
+		prev.ctx = ctx
+		prev.event = event
+		d.mu.Unlock()
+		d.reporter.reportCoalescedEvent()
+		return
+	}
+
+	p := &pendingEvent{ctx: ctx, event: event, kind: kind}
+	d.pending[key] = p
+	d.mu.Unlock()
+
+	if ok {
+		d.send(prev.ctx, prev.event)
+	}
+	time.AfterFunc(d.window, func() {
+		d.flush(key, p)
+	})
+}
+
+// flush sends p, unless it isn't pending for key anymore.
+func (d *debouncer) flush(key string, p *pendingEvent) {
+	d.mu.Lock()
+	if d.pending[key] != p {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	d.mu.Unlock()
+
+	d.send(p.ctx, p.event)
+}
+
+// flushAll sends the pending events without waiting for the end of their
+// debounce window, until ctx is done. The events left at that point are dropped.
+func (d *debouncer) flushAll(ctx context.Context) {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[string]*pendingEvent)
+	d.mu.Unlock()
+
+	dropped := 0
+	for _, p := range pending {
+		if ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		d.send(p.ctx, p.event)
+	}
+	if dropped > 0 {
+		d.reporter.reportShutdownDroppedEvents(dropped)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"knative.dev/eventing/pkg/adapter/apiserver/events"
+	"knative.dev/eventing/pkg/apis/sources"
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func TestDebounceCoalescesSameObject(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.debouncer = newDebouncer(50*time.Millisecond, &statsReporter{}, d.sendCloudEvent)
+
+	d.Update(simplePod("unit", "test"))
+	d.Update(simplePod("unit", "test"))
+	d.Update(simplePod("unit", "test"))
+
+	if got := len(ce.Sent()); got != 0 {
+		t.Fatal("Expected no event to be sent before the window elapsed, got:", got)
+	}
+
+	waitForSent(t, ce, 1)
+	validateSent(t, ce, sources.ApiServerSourceUpdateEventType)
+}
+
+func TestDebounceDoesNotCoalesceOtherTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		apply func(d *resourceDelegate)
+		want  []string
+	}{{
+		name: "add then update",
+		apply: func(d *resourceDelegate) {
+			d.Add(simplePod("unit", "test"))
+			d.Update(simplePod("unit", "test"))
+			d.Update(simplePod("unit", "test"))
+		},
+		want: []string{sources.ApiServerSourceAddEventType, sources.ApiServerSourceUpdateEventType},
+	}, {
+		name: "update then delete",
+		apply: func(d *resourceDelegate) {
+			d.Update(simplePod("unit", "test"))
+			d.Delete(simplePod("unit", "test"))
+		},
+		want: []string{sources.ApiServerSourceUpdateEventType, sources.ApiServerSourceDeleteEventType},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ce := makeResourceAndTestingClient()
+			d.debouncer = newDebouncer(50*time.Millisecond, &statsReporter{}, d.sendCloudEvent)
+
+			tt.apply(d)
+
+			waitForSent(t, ce, len(tt.want))
+			// give coalesced events the time to be sent wrongly
+			time.Sleep(100 * time.Millisecond)
+			sent := ce.Sent()
+			if len(sent) != len(tt.want) {
+				t.Fatalf("got %d events, want %d", len(sent), len(tt.want))
+			}
+			for i, event := range sent {
+				if event.Type() != tt.want[i] {
+					t.Errorf("got event %d of type %q, want %q", i, event.Type(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDebounceDoesNotCoalesceTemplatedTypes(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	template, err := events.NewEventTemplate(&v1.ApiServerSourceEventTemplate{Type: "com.example.{{.Kind | lower}}"})
+	if err != nil {
+		t.Fatal("Failed to create the event template:", err)
+	}
+	d.template = template
+	d.debouncer = newDebouncer(50*time.Millisecond, &statsReporter{}, d.sendCloudEvent)
+
+	d.Add(simplePod("unit", "test"))
+	d.Delete(simplePod("unit", "test"))
+
+	waitForSent(t, ce, 2)
+	// give coalesced events the time to be sent wrongly
+	time.Sleep(100 * time.Millisecond)
+	sent := ce.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("got %d events, want 2", len(sent))
+	}
+	for i, event := range sent {
+		if event.Type() != "com.example.pod" {
+			t.Errorf("got event %d of type %q, want %q", i, event.Type(), "com.example.pod")
+		}
+	}
+	if data := string(sent[1].Data()); !strings.Contains(data, `"name":"unit"`) {
+		t.Errorf("got delete event data %s, want the pod", data)
+	}
+}
+
+func TestDebounceDistinctObjects(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.debouncer = newDebouncer(50*time.Millisecond, &statsReporter{}, d.sendCloudEvent)
+
+	d.Update(simplePod("unit", "test"))
+	d.Update(simplePod("other", "test"))
+	d.Update(simpleNamespace("test"))
+
+	waitForSent(t, ce, 3)
+}
+
+func TestObjectKey(t *testing.T) {
+	key, ok := objectKey(simplePod("unit", "test"))
+	if !ok {
+		t.Fatal("Expected a key for a pod")
+	}
+	if want := "/v1, Kind=Pod/test/unit"; key != want {
+		t.Errorf("Expected key %q, got %q", want, key)
+	}
+
+	if _, ok := objectKey(nil); ok {
+		t.Error("Expected no key for nil")
+	}
+}
+
+func waitForSent(t *testing.T, ce interface{ Sent() []cloudevents.Event }, n int) {
+	t.Helper()
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 2*time.Second, true, func(context.Context) (bool, error) {
+		return len(ce.Sent()) >= n, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected %d events to be sent, got %d", n, len(ce.Sent()))
+	}
+}
+
+func TestDebounceFlushAll(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.debouncer = newDebouncer(time.Hour, nil, d.sendCloudEvent)
+
+	d.Add(simplePod("a", "test"))
+	d.Add(simplePod("b", "test"))
+	d.debouncer.flushAll(context.Background())
+
+	if got := len(ce.Sent()); got != 2 {
+		t.Errorf("got %d events, want 2", got)
+	}
+}
+
+func TestDebounceFlushAllDeadline(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.debouncer = newDebouncer(time.Hour, nil, d.sendCloudEvent)
+
+	d.Add(simplePod("a", "test"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.debouncer.flushAll(ctx)
+
+	if got := len(ce.Sent()); got != 0 {
+		t.Errorf("got %d events, want 0", got)
+	}
+}
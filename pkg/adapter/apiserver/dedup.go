@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// defaultDedupSize is the number of object versions remembered when the
+	// size of the dedup store is not set.
+	defaultDedupSize = 10000
+
+	// dedupConfigMapKey is the key of the ConfigMap data holding the object
+	// versions, one per line from the least to the most recently seen.
+	dedupConfigMapKey = "seen"
+	// dedupMaxConfigMapSize bounds the size of the persisted object versions,
+	// ConfigMaps are limited to 1 MiB.
+	dedupMaxConfigMapSize = 768 * 1024
+
+	// dedupPersistPeriod is the period at which the store is persisted.
+	dedupPersistPeriod = 30 * time.Second
+	// dedupPersistTimeout bounds the time spent persisting the store.
+	dedupPersistTimeout = 10 * time.Second
+)
+
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// dedupStore remembers the versions of the objects already sent, so that the
+// objects listed again after a restart of the adapter are not sent twice.
+// Versions are only remembered once their event is delivered, so that the
+// events which failed are sent again after a restart.
+type dedupStore struct {
+	versions *lru.Cache
+
+	// configMaps persists the store in the ConfigMap name, it is nil when the
+	// store is kept in memory only.
+	configMaps dynamic.ResourceInterface
+	name       string
+	// maxSize bounds the size of the persisted object versions, see
+	// dedupMaxConfigMapSize.
+	maxSize int
+}
+
+func newDedupStore(cfg *DedupConfig, k8s dynamic.Interface, namespace string) (*dedupStore, error) {
+	size := cfg.Size
+	if size <= 0 {
+		size = defaultDedupSize
+	}
+	versions, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &dedupStore{versions: versions, maxSize: dedupMaxConfigMapSize}
+	if cfg.ConfigMapName != "" {
+		d.configMaps = k8s.Resource(configMapGVR).Namespace(namespace)
+		d.name = cfg.ConfigMapName
+	}
+	return d, nil
+}
+
+// dedupKey returns the key of the version of obj.
+func dedupKey(obj interface{}) (string, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || u == nil || u.GetUID() == "" || u.GetResourceVersion() == "" {
+		return "", false
+	}
+	return string(u.GetUID()) + "/" + u.GetResourceVersion(), true
+}
+
+// seen reports whether the version of obj was already sent.
+func (d *dedupStore) seen(obj interface{}) bool {
+	key, ok := dedupKey(obj)
+	if !ok {
+		return false
+	}
+	return d.versions.Contains(key)
+}
+
+// markSeen records the version with the given key as sent.
+func (d *dedupStore) markSeen(key string) {
+	d.versions.Add(key, struct{}{})
+}
+
+type dedupKeyKey struct{}
+
+func contextWithDedupKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, dedupKeyKey{}, key)
+}
+
+func dedupKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(dedupKeyKey{}).(string)
+	return key, ok
+}
+
+// load restores the object versions persisted in the ConfigMap.
+func (d *dedupStore) load(ctx context.Context) error {
+	if d.configMaps == nil {
+		return nil
+	}
+
+	cm, err := d.configMaps.Get(ctx, d.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %w", d.name, err)
+	}
+
+	data, _, _ := unstructured.NestedString(cm.Object, "data", dedupConfigMapKey)
+	for _, key := range strings.Split(data, "\n") {
+		if key != "" {
+			d.versions.Add(key, struct{}{})
+		}
+	}
+	return nil
+}
+
+// persist saves the object versions in the ConfigMap. Only the most recently
+// seen versions fitting in maxSize are saved when they don't all fit, the
+// objects of the others may be sent again after a restart.
+func (d *dedupStore) persist(ctx context.Context) error {
+	if d.configMaps == nil {
+		return nil
+	}
+
+	// the keys are ordered from the least to the most recently seen
+	keys := d.versions.Keys()
+	first, size := len(keys), 0
+	for first > 0 {
+		size += len(keys[first-1].(string)) + len("\n")
+		if size > d.maxSize {
+			break
+		}
+		first--
+	}
+	lines := make([]string, 0, len(keys)-first)
+	for _, key := range keys[first:] {
+		lines = append(lines, key.(string))
+	}
+	data := strings.Join(lines, "\n")
+
+	cm, err := d.configMaps.Get(ctx, d.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName(d.name)
+		if err := unstructured.SetNestedField(cm.Object, data, "data", dedupConfigMapKey); err != nil {
+			return err
+		}
+		if _, err := d.configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", d.name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %w", d.name, err)
+	}
+
+	if err := unstructured.SetNestedField(cm.Object, data, "data", dedupConfigMapKey); err != nil {
+		return err
+	}
+	if _, err := d.configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %w", d.name, err)
+	}
+	return nil
+}
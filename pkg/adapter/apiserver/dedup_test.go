@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/eventing/pkg/apis/sources"
+)
+
+func versionedPod(uid, resourceVersion string) *unstructured.Unstructured {
+	pod := simplePod("unit", "test")
+	pod.SetUID(types.UID(uid))
+	pod.SetResourceVersion(resourceVersion)
+	return pod
+}
+
+func TestDedupSkipsSeenVersions(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	dedup, err := newDedupStore(&DedupConfig{}, nil, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	d.dedup = dedup
+
+	d.Add(versionedPod("uid", "1"))
+	d.Add(versionedPod("uid", "1"))
+	d.Update(versionedPod("uid", "2"))
+
+	sent := ce.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("got %d events, want 2", len(sent))
+	}
+	if sent[1].Type() != sources.ApiServerSourceUpdateEventType {
+		t.Errorf("got event type %q, want %q", sent[1].Type(), sources.ApiServerSourceUpdateEventType)
+	}
+}
+
+func TestDedupWithoutVersion(t *testing.T) {
+	dedup, err := newDedupStore(&DedupConfig{}, nil, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+
+	if _, ok := dedupKey(simplePod("unit", "test")); ok || dedup.seen(simplePod("unit", "test")) {
+		t.Error("objects without uid and resourceVersion must not be deduplicated")
+	}
+}
+
+func TestDedupPersistence(t *testing.T) {
+	ctx := context.Background()
+	k8s := makeDynamicClient()
+	cfg := &DedupConfig{Size: 2, ConfigMapName: "dedup"}
+
+	dedup, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	for _, uid := range []string{"a", "b", "c"} {
+		key, _ := dedupKey(versionedPod(uid, "1"))
+		dedup.markSeen(key)
+	}
+
+	// Both the creation and the update of the ConfigMap are exercised.
+	for i := 0; i < 2; i++ {
+		if err := dedup.persist(ctx); err != nil {
+			t.Fatal("persist() =", err)
+		}
+	}
+
+	restarted, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	if err := restarted.load(ctx); err != nil {
+		t.Fatal("load() =", err)
+	}
+	if !restarted.seen(versionedPod("b", "1")) || !restarted.seen(versionedPod("c", "1")) {
+		t.Error("the persisted versions were not loaded")
+	}
+	if restarted.seen(versionedPod("a", "1")) {
+		t.Error("the evicted version was loaded")
+	}
+}
+
+func TestDedupPersistenceBounded(t *testing.T) {
+	ctx := context.Background()
+	k8s := makeDynamicClient()
+	cfg := &DedupConfig{Size: 100, ConfigMapName: "dedup"}
+
+	dedup, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	dedup.maxSize = 64
+	for i := 0; i < 100; i++ {
+		key, _ := dedupKey(versionedPod(fmt.Sprintf("uid-%02d", i), "1"))
+		dedup.markSeen(key)
+	}
+	if err := dedup.persist(ctx); err != nil {
+		t.Fatal("persist() =", err)
+	}
+
+	cm, err := k8s.Resource(configMapGVR).Namespace("test").Get(ctx, "dedup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("failed to get the ConfigMap:", err)
+	}
+	data, _, _ := unstructured.NestedString(cm.Object, "data", dedupConfigMapKey)
+	if len(data) > dedup.maxSize {
+		t.Errorf("got %d bytes persisted, want at most %d", len(data), dedup.maxSize)
+	}
+
+	restarted, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	if err := restarted.load(ctx); err != nil {
+		t.Fatal("load() =", err)
+	}
+	// the keys take 9 bytes with their separator, the 7 most recently seen fit
+	for i := 93; i < 100; i++ {
+		if !restarted.seen(versionedPod(fmt.Sprintf("uid-%02d", i), "1")) {
+			t.Errorf("the recent version %d was not persisted", i)
+		}
+	}
+	if restarted.seen(versionedPod("uid-92", "1")) {
+		t.Error("the version not fitting was persisted")
+	}
+}
+
+func TestDedupFailedDeliveryIsSentAgainAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	k8s := makeDynamicClient()
+	cfg := &DedupConfig{ConfigMapName: "dedup"}
+
+	d, _ := makeResourceAndTestingClient()
+	ce := &failingSinkClient{}
+	d.ce = ce
+	dedup, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	d.dedup = dedup
+
+	// the sink answers with 500
+	d.Add(versionedPod("uid", "1"))
+	if err := dedup.persist(ctx); err != nil {
+		t.Fatal("persist() =", err)
+	}
+
+	restarted, err := newDedupStore(cfg, k8s, "test")
+	if err != nil {
+		t.Fatal("newDedupStore() =", err)
+	}
+	if err := restarted.load(ctx); err != nil {
+		t.Fatal("load() =", err)
+	}
+
+	replayed, replayedCE := makeResourceAndTestingClient()
+	replayed.dedup = restarted
+	replayed.Add(versionedPod("uid", "1"))
+	if sent := replayedCE.Sent(); len(sent) != 1 {
+		t.Fatalf("got %d events after the restart, want 1", len(sent))
+	}
+
+	// the delivered version is skipped from now on
+	replayed.Add(versionedPod("uid", "1"))
+	if sent := replayedCE.Sent(); len(sent) != 1 {
+		t.Errorf("got %d events after the replay, want 1", len(sent))
+	}
+}
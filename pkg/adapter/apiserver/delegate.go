@@ -18,36 +18,93 @@ package apiserver
 
 import (
 	"context"
+	"net/url"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/tools/cache"
 	"knative.dev/eventing/pkg/adapter/apiserver/events"
+	"knative.dev/eventing/pkg/adapter/v2/sinkbinding"
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
 	"knative.dev/eventing/pkg/eventfilter"
 )
 
 type resourceDelegate struct {
-	ce                  cloudevents.Client
+	ce cloudevents.Client
+	// binding targets the sink with the OIDC token and the metric tag of the
+	// source, it is nil when ce targets the sink.
+	binding *sinkbinding.Binding
+	// sink is the URL of the sink the client sends to, unless the context
+	// targets another sink.
+	sink                url.URL
 	source              string
 	ref                 bool
 	apiServerSourceName string
 	filter              eventfilter.Filter
 
-	logger *zap.SugaredLogger
+	// debouncer coalesces the events of the same object, it is nil when
+	// debouncing is disabled.
+	debouncer *debouncer
+
+	// workers sends the events of the same object in order, through a pool per
+	// resource. It is nil when the events are sent synchronously.
+	workers *workerPools
+
+	// delivery holds the retries and dead letter sink configuration, it is nil
+	// when no delivery options are set.
+	delivery *delivery
+
+	// additionalSinks receive a copy of every event sent to the sink.
+	additionalSinks []SinkConfig
+
+	// dedup skips the object versions already sent, it is nil when
+	// deduplication is disabled.
+	dedup *dedupStore
+
+	// projection selects the fields of the objects sent in the events, it is
+	// nil when the objects are sent as is.
+	projection *v1.ApiServerSourceProjection
+
+	// template renders the type and the source of the events, it is nil when
+	// the events have the default type and source.
+	template *events.EventTemplate
+
+	// payloadMode is the payload mode of the update events.
+	payloadMode string
+	// objects keeps the previous state of the objects, it is nil when the
+	// update events only carry the new state of the objects.
+	objects *objectCache
+
+	reporter *statsReporter
+	logger   *zap.SugaredLogger
 }
 
 var _ cache.Store = (*resourceDelegate)(nil)
 
 func (a *resourceDelegate) Add(obj interface{}) error {
+	if a.objects != nil {
+		a.objects.swap(obj)
+	}
 	return a.handleKubernetesObject(events.MakeAddEvent, obj)
 }
 
 func (a *resourceDelegate) Update(obj interface{}) error {
+	if a.objects != nil {
+		// Without a previous state the update event carries the new state only.
+		if old := a.objects.swap(obj); old != nil {
+			return a.handleKubernetesObject(a.makeUpdateEventWithOld(old), obj)
+		}
+	}
 	return a.handleKubernetesObject(events.MakeUpdateEvent, obj)
 }
 
 func (a *resourceDelegate) Delete(obj interface{}) error {
+	if a.objects != nil {
+		a.objects.delete(obj)
+	}
 	return a.handleKubernetesObject(events.MakeDeleteEvent, obj)
 
 }
@@ -56,38 +113,184 @@ func (a *resourceDelegate) Delete(obj interface{}) error {
 // be passed as a parameter
 type makeEventFunc func(string, string, interface{}, bool) (context.Context, cloudevents.Event, error)
 
+// makeUpdateEventWithOld returns a makeEventFunc building the update events
+// carrying the previous state old, according to the payload mode.
+func (a *resourceDelegate) makeUpdateEventWithOld(old interface{}) makeEventFunc {
+	old = events.Project(old, a.projection)
+	return func(source, apiServerSourceName string, obj interface{}, _ bool) (context.Context, cloudevents.Event, error) {
+		if a.payloadMode == v1.PayloadModeDiff {
+			return events.MakeUpdatePatchEvent(source, apiServerSourceName, old, obj)
+		}
+		return events.MakeUpdateBothEvent(source, apiServerSourceName, old, obj)
+	}
+}
+
 func (a *resourceDelegate) handleKubernetesObject(makeEvent makeEventFunc, obj interface{}) error {
-	ctx, event, err := makeEvent(a.source, a.apiServerSourceName, obj, a.ref)
+	if a.dedup != nil && a.dedup.seen(obj) {
+		a.logger.Debug("object version already sent, skipping")
+		return nil
+	}
+
+	ctx, event, err := makeEvent(a.source, a.apiServerSourceName, events.Project(obj, a.projection), a.ref)
+	// The events are coalesced by their type before templating, which may
+	// give the same type to the events of different verbs.
+	kind := event.Type()
+	if err == nil {
+		err = a.template.Apply(&event, obj)
+	}
 
 	if err != nil {
 		a.logger.Infow("event creation failed", zap.Error(err))
 		return err
 	}
+	a.reporter.reportEventCreated(eventResource(event))
 
-	filterResult := a.filter.Filter(ctx, event)
-	if filterResult == eventfilter.FailFilter {
-		a.logger.Debugf("event type %s filtered out", event.Type())
-		return nil
+	// The last change of the object is read before the projection may drop it.
+	if changed, ok := lastChangeTime(obj); ok {
+		ctx = contextWithChangeTime(ctx, changed)
+	}
+	// The version is remembered once the event is delivered.
+	if a.dedup != nil {
+		if key, ok := dedupKey(obj); ok {
+			ctx = contextWithDedupKey(ctx, key)
+		}
 	}
 
-	a.sendCloudEvent(ctx, event)
+	if a.filter != nil {
+		if filterResult := a.filter.Filter(ctx, event); filterResult == eventfilter.FailFilter {
+			a.logger.Debugf("event type %s filtered out", event.Type())
+			a.reporter.reportFilteredEvent()
+			return nil
+		}
+	}
+
+	if a.debouncer != nil {
+		if key, ok := objectKey(obj); ok {
+			a.debouncer.enqueue(ctx, key, kind, event)
+			return nil
+		}
+	}
+
+	a.dispatch(ctx, event)
 	return nil
 }
 
+// dispatch sends event, through the workers of its resource when they are
+// enabled. The subject identifies the object of the event, so the events of
+// the same object are sent in order.
+func (a *resourceDelegate) dispatch(ctx context.Context, event cloudevents.Event) {
+	if a.workers != nil {
+		a.workers.enqueue(ctx, eventResource(event), event.Subject(), event)
+		return
+	}
+	a.sendCloudEvent(ctx, event)
+}
+
+// drain sends the events pending in the debouncer and the workers until ctx is
+// done, the events left at that point are dropped.
+func (a *resourceDelegate) drain(ctx context.Context) {
+	if a.debouncer != nil {
+		a.debouncer.flushAll(ctx)
+	}
+	if a.workers != nil {
+		a.workers.drain(ctx)
+	}
+}
+
+// objectKey returns a key uniquely identifying obj across the watched resources.
+func objectKey(obj interface{}) (string, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", false
+	}
+	key, err := cache.MetaNamespaceKeyFunc(u)
+	if err != nil {
+		return "", false
+	}
+	return u.GroupVersionKind().String() + "/" + key, true
+}
+
+// eventResource returns the apiVersion and kind of the object of event.
+func eventResource(event cloudevents.Event) string {
+	apiVersion, _ := event.Extensions()["apiversion"].(string)
+	kind, _ := event.Extensions()["kind"].(string)
+	return apiVersion + "/" + kind
+}
+
 // sendCloudEvent sends a cloudevent everytime k8s api event is created, updated or deleted.
 func (a *resourceDelegate) sendCloudEvent(ctx context.Context, event cloudevents.Event) {
+	if a.binding != nil {
+		// The retries are those of the delivery, see sendToSink.
+		ctx = a.binding.Context(ctx)
+	}
 	event.SetID(uuid.New().String()) // provide an ID here so we can track it with logging
 	defer a.logger.Debug("Finished sending cloudevent id: ", event.ID())
 	source := event.Context.GetSource()
 	subject := event.Context.GetSubject()
 	a.logger.Debugf("sending cloudevent id: %s, source: %s, subject: %s", event.ID(), source, subject)
 
-	if result := a.ce.Send(ctx, event); !cloudevents.IsACK(result) {
+	resource := eventResource(event)
+	if changed, ok := changeTimeFromContext(ctx); ok {
+		if lag := time.Since(changed); lag >= 0 {
+			a.reporter.reportLag(resource, lag)
+		}
+	}
+
+	if delivered := a.sendToSink(ctx, event, resource); delivered && a.dedup != nil {
+		if key, ok := dedupKeyFromContext(ctx); ok {
+			a.dedup.markSeen(key)
+		}
+	}
+	for _, sink := range a.additionalSinks {
+		a.sendToSink(sinkContext(ctx, sink), event, resource)
+	}
+}
+
+// sendToSink sends event to the sink targeted by ctx, an event that could not
+// be delivered is sent to the dead letter sink. It reports whether the event
+// was delivered to either of them.
+func (a *resourceDelegate) sendToSink(ctx context.Context, event cloudevents.Event, resource string) bool {
+	source := event.Context.GetSource()
+	subject := event.Context.GetSubject()
+
+	start := time.Now()
+	if result := a.ce.Send(a.delivery.withRetries(ctx), event); !cloudevents.IsACK(result) {
+		a.reporter.reportSendFailure(resource, time.Since(start))
 		a.logger.Errorw("failed to send cloudevent", zap.Error(result), zap.String("source", source),
 			zap.String("subject", subject), zap.String("id", event.ID()))
-	} else {
-		a.logger.Debugf("cloudevent sent id: %s, source: %s, subject: %s", event.ID(), source, subject)
+
+		if a.delivery.hasDeadLetterSink() {
+			return a.sendToDeadLetterSink(ctx, event, result)
+		}
+		return false
 	}
+
+	a.reporter.reportEventSent(resource, time.Since(start))
+	a.logger.Debugf("cloudevent sent id: %s, source: %s, subject: %s", event.ID(), source, subject)
+	return true
+}
+
+// sinkContext returns a context targeting sink with its OIDC audience.
+func sinkContext(ctx context.Context, sink SinkConfig) context.Context {
+	return sinkbinding.ContextWithSink(ctx, sinkbinding.Sink{URI: sink.URI, Audience: sink.Audience})
+}
+
+// sendToDeadLetterSink sends an event that could not be delivered to the sink
+// to the dead letter sink. It reports whether the event was delivered.
+func (a *resourceDelegate) sendToDeadLetterSink(ctx context.Context, event cloudevents.Event, result error) bool {
+	destination := a.sink
+	if target := cloudevents.TargetFromContext(ctx); target != nil {
+		destination = *target
+	}
+	ctx, event = a.delivery.deadLetterEvent(ctx, event, destination, result)
+
+	if result := a.ce.Send(a.delivery.withRetries(ctx), event); !cloudevents.IsACK(result) {
+		a.logger.Errorw("failed to send cloudevent to the dead letter sink", zap.Error(result),
+			zap.String("id", event.ID()))
+		return false
+	}
+	a.logger.Debugf("cloudevent sent to the dead letter sink id: %s", event.ID())
+	return true
 }
 
 // Stub cache.Store impl
@@ -113,7 +316,13 @@ func (a *resourceDelegate) GetByKey(key string) (item interface{}, exists bool,
 }
 
 // Implements cache.Store
-func (a *resourceDelegate) Replace([]interface{}, string) error {
+func (a *resourceDelegate) Replace(items []interface{}, _ string) error {
+	// The listed objects are the previous state of their next update.
+	if a.objects != nil {
+		for _, item := range items {
+			a.objects.swap(item)
+		}
+	}
 	return nil
 }
 
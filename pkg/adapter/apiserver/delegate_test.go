@@ -16,6 +16,8 @@ limitations under the License.
 package apiserver
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"go.uber.org/zap"
@@ -110,3 +112,76 @@ func TestEmptyFiltersList(t *testing.T) {
 	delegate.Update(simplePod("unit", "test"))
 	validateSent(t, ce, sources.ApiServerSourceUpdateEventType)
 }
+
+func TestCESQLFilter(t *testing.T) {
+	ce := adaptertest.NewTestClient()
+	filters := []eventingv1.SubscriptionsAPIFilter{{
+		CESQL: "kind = 'Pod' AND name LIKE 'keep-%'",
+	}}
+
+	logger := zap.NewExample().Sugar()
+	delegate := &resourceDelegate{
+		ce:                  ce,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              logger,
+		filter:              subscriptionsapi.NewAllFilter(brokerfilter.MaterializeFiltersList(logger.Desugar(), filters)...),
+	}
+
+	delegate.Update(simplePod("drop-me", "test"))
+	validateNotSent(t, ce, sources.ApiServerSourceUpdateEventType)
+
+	delegate.Update(simplePod("keep-me", "test"))
+	validateSent(t, ce, sources.ApiServerSourceUpdateEventType)
+}
+
+func TestCESQLFilterConcurrent(t *testing.T) {
+	ce := adaptertest.NewTestClient()
+	filters := []eventingv1.SubscriptionsAPIFilter{{
+		Exact: map[string]string{"type": sources.ApiServerSourceUpdateEventType},
+	}, {
+		CESQL: "kind = 'Pod' AND name LIKE 'keep-%'",
+	}}
+
+	logger := zap.NewNop().Sugar()
+	delegate := &resourceDelegate{
+		ce:                  ce,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              logger,
+		filter:              subscriptionsapi.NewAllFilter(brokerfilter.MaterializeFiltersList(logger.Desugar(), filters)...),
+	}
+	defer delegate.filter.Cleanup()
+
+	// The workers of the adapter evaluate the filter concurrently, while it
+	// reorders its filters.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				delegate.Update(simplePod(fmt.Sprintf("drop-%d-%d", i, j), "test"))
+			}
+			delegate.Update(simplePod(fmt.Sprintf("keep-%d", i), "test"))
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(ce.Sent()); got != 10 {
+		t.Errorf("Expected 10 events to be sent, got %d", got)
+	}
+}
+
+func TestNilFilter(t *testing.T) {
+	ce := adaptertest.NewTestClient()
+	delegate := &resourceDelegate{
+		ce:                  ce,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              zap.NewExample().Sugar(),
+	}
+
+	delegate.Update(simplePod("unit", "test"))
+	validateSent(t, ce, sources.ApiServerSourceUpdateEventType)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/rickb777/date/period"
+
+	"knative.dev/eventing/pkg/adapter/v2/sinkbinding"
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+// delivery applies the retries and dead letter sink of a DeliveryConfig to
+// the events sent by the adapter.
+type delivery struct {
+	retry          sinkbinding.Retry
+	deadLetterSink string
+	// deadLetterSinkAudience is the OIDC audience of the dead letter sink, it
+	// is nil when the dead letter sink does not require authentication.
+	deadLetterSinkAudience *string
+}
+
+func newDelivery(cfg *DeliveryConfig) (*delivery, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	d := &delivery{
+		retry:          sinkbinding.Retry{Count: int(cfg.Retry), BackoffPolicy: duckv1.BackoffPolicyExponential},
+		deadLetterSink: cfg.DeadLetterSink,
+
+		deadLetterSinkAudience: cfg.DeadLetterSinkAudience,
+	}
+	if cfg.BackoffPolicy != nil {
+		d.retry.BackoffPolicy = *cfg.BackoffPolicy
+	}
+	if cfg.BackoffDelay != nil {
+		delay, err := period.Parse(*cfg.BackoffDelay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse backoffDelay: %w", err)
+		}
+		d.retry.BackoffDelay, _ = delay.Duration()
+	}
+	return d, nil
+}
+
+// withRetries returns a context carrying the retry parameters used by the
+// CloudEvents client.
+func (d *delivery) withRetries(ctx context.Context) context.Context {
+	if d == nil {
+		return ctx
+	}
+	return d.retry.ContextWithRetries(ctx)
+}
+
+func (d *delivery) hasDeadLetterSink() bool {
+	return d != nil && d.deadLetterSink != ""
+}
+
+// deadLetterEvent returns a copy of event carrying the knative error extensions
+// describing the failed delivery to destination, like the events dead lettered
+// by the dispatchers, and the context targeting the dead letter sink with its
+// OIDC audience.
+func (d *delivery) deadLetterEvent(ctx context.Context, event cloudevents.Event, destination url.URL, result error) (context.Context, cloudevents.Event) {
+	// the result of the last attempt, when the delivery was retried
+	var retries *cehttp.RetriesResult
+	if errors.As(result, &retries) {
+		result = retries.Result
+	}
+
+	// the deliveries failing without a response are reported as the
+	// dispatchers report them
+	code := http.StatusInternalServerError
+	body := []byte(fmt.Sprintf("dispatch error: %v", result))
+	var httpResult *cehttp.Result
+	if cloudevents.ResultAs(result, &httpResult) {
+		code = httpResult.StatusCode
+		body = responseBody(httpResult)
+	}
+
+	// the response body is base64 encoded, truncated to the max length
+	data := base64.StdEncoding.EncodeToString(body)
+	dlEvent, err := binding.ToEvent(ctx, binding.ToMessage(&event), attributes.KnativeErrorTransformers(destination, code, data)...)
+	if err != nil {
+		clone := event.Clone()
+		dlEvent = &clone
+	}
+
+	return sinkbinding.ContextWithSink(ctx, sinkbinding.Sink{URI: d.deadLetterSink, Audience: d.deadLetterSinkAudience}), *dlEvent
+}
+
+// responseBody returns the response body of a non-2xx result, which the
+// CloudEvents client appends as the last argument of its message.
+func responseBody(result *cehttp.Result) []byte {
+	if len(result.Args) == 0 || !strings.HasSuffix(result.Format, "%s") {
+		return nil
+	}
+	body, _ := result.Args[len(result.Args)-1].(string)
+	return []byte(body)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/zap"
+
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/apis/sources"
+	brokerfilter "knative.dev/eventing/pkg/broker/filter"
+	"knative.dev/eventing/pkg/eventfilter/subscriptionsapi"
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+	"knative.dev/pkg/ptr"
+)
+
+func TestNewDelivery(t *testing.T) {
+	linear := duckv1.BackoffPolicyLinear
+
+	d, err := newDelivery(nil)
+	if err != nil || d != nil {
+		t.Fatalf("Expected nil delivery without error, got %v, %v", d, err)
+	}
+
+	d, err = newDelivery(&DeliveryConfig{
+		Retry:          3,
+		BackoffPolicy:  &linear,
+		BackoffDelay:   ptr.String("PT0.5S"),
+		DeadLetterSink: "http://dls.example.com",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	params := cecontext.RetriesFrom(d.withRetries(context.Background()))
+	if params.Strategy != cecontext.BackoffStrategyLinear || params.MaxTries != 3 || params.Period != 500*time.Millisecond {
+		t.Errorf("Unexpected retry params %+v", params)
+	}
+	if !d.hasDeadLetterSink() {
+		t.Error("Expected a dead letter sink")
+	}
+
+	if _, err := newDelivery(&DeliveryConfig{BackoffDelay: ptr.String("5 seconds")}); err == nil {
+		t.Error("Expected an error for an invalid backoff delay")
+	}
+}
+
+func TestDeliveryNoRetries(t *testing.T) {
+	var d *delivery
+	ctx := context.Background()
+	if got := d.withRetries(ctx); got != ctx {
+		t.Error("Expected the context to be unchanged")
+	}
+	if d.hasDeadLetterSink() {
+		t.Error("Expected no dead letter sink")
+	}
+}
+
+func TestSendToDeadLetterSink(t *testing.T) {
+	ce := &failingSinkClient{deadLetterSink: "http://dls.example.com"}
+	logger := zap.NewExample().Sugar()
+	d := &resourceDelegate{
+		ce:                  ce,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              logger,
+		filter:              subscriptionsapi.NewAllFilter(brokerfilter.MaterializeFiltersList(logger.Desugar(), []eventingv1.SubscriptionsAPIFilter{})...),
+		delivery:            &delivery{deadLetterSink: "http://dls.example.com"},
+		sink:                url.URL{Scheme: "http", Host: "sink.example.com"},
+	}
+
+	d.Update(simplePod("unit", "test"))
+
+	if len(ce.deadLettered) != 1 {
+		t.Fatal("Expected 1 event to be sent to the dead letter sink, got:", len(ce.deadLettered))
+	}
+	event := ce.deadLettered[0]
+	if event.Type() != sources.ApiServerSourceUpdateEventType {
+		t.Errorf("Expected %q event, got %q", sources.ApiServerSourceUpdateEventType, event.Type())
+	}
+	if code := event.Extensions()[attributes.KnativeErrorCodeExtensionKey]; code != int32(500) {
+		t.Errorf("Expected error code 500, got %v", code)
+	}
+	if dest, _ := types.Format(event.Extensions()[attributes.KnativeErrorDestExtensionKey]); dest != "http://sink.example.com" {
+		t.Errorf("Expected error destination %q, got %v", "http://sink.example.com", dest)
+	}
+	// the response body is base64 encoded, as by the dispatchers
+	if data := event.Extensions()[attributes.KnativeErrorDataExtensionKey]; data != base64.StdEncoding.EncodeToString([]byte("sink failed")) {
+		t.Errorf("Expected base64 encoded response body, got %v", data)
+	}
+}
+
+func TestSendToDeadLetterSinkResultCode(t *testing.T) {
+	transportErr := &url.Error{Op: "Post", URL: "http://sink.example.com", Err: errors.New("connection refused")}
+
+	tests := map[string]struct {
+		result   error
+		wantCode int32
+		wantData string
+	}{
+		"transport error": {
+			result:   transportErr,
+			wantCode: 500,
+			wantData: "dispatch error: " + transportErr.Error(),
+		},
+		"retried transport error": {
+			result:   &cehttp.RetriesResult{Result: transportErr, Retries: 2},
+			wantCode: 500,
+			wantData: "dispatch error: " + transportErr.Error(),
+		},
+		"retried response": {
+			result:   &cehttp.RetriesResult{Result: cehttp.NewResult(503, "%w%s", protocol.ResultNACK, "unavailable"), Retries: 2},
+			wantCode: 503,
+			wantData: "unavailable",
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			event := cloudevents.NewEvent()
+			event.SetID("1")
+			event.SetType(sources.ApiServerSourceUpdateEventType)
+			event.SetSource("unit-test")
+
+			d := &delivery{deadLetterSink: "http://dls.example.com"}
+			_, event = d.deadLetterEvent(context.Background(), event, url.URL{Scheme: "http", Host: "sink.example.com"}, tc.result)
+
+			if code := event.Extensions()[attributes.KnativeErrorCodeExtensionKey]; code != tc.wantCode {
+				t.Errorf("Expected error code %d, got %v", tc.wantCode, code)
+			}
+			if data := event.Extensions()[attributes.KnativeErrorDataExtensionKey]; data != base64.StdEncoding.EncodeToString([]byte(tc.wantData)) {
+				t.Errorf("Expected base64 encoded %q, got %v", tc.wantData, data)
+			}
+		})
+	}
+}
+
+// failingSinkClient NACKs every event, except the ones sent to deadLetterSink.
+type failingSinkClient struct {
+	cloudevents.Client
+
+	mu             sync.Mutex
+	deadLetterSink string
+	deadLettered   []cloudevents.Event
+}
+
+func (c *failingSinkClient) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if target := cloudevents.TargetFromContext(ctx); target != nil && target.String() == c.deadLetterSink {
+		c.deadLettered = append(c.deadLettered, event)
+		return cehttp.NewResult(202, "%w", protocol.ResultACK)
+	}
+	// like the http protocol, which appends the response body
+	return cehttp.NewResult(500, "%w%s", protocol.ResultNACK, "sink failed")
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/logging"
+
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+const (
+	// resourceNotFoundReason is the reason of the events emitted while a
+	// resource isn't served, e.g. because its CRD isn't installed yet.
+	resourceNotFoundReason = "ResourceNotFound"
+	// resourceFoundReason is the reason of the events emitted once a resource
+	// which wasn't served at startup is watched.
+	resourceFoundReason = "ResourceFound"
+)
+
+// discoveryRetryPeriod is the period the discovery API is polled at for the
+// resources which aren't served yet.
+var discoveryRetryPeriod = 10 * time.Second
+
+// findResource returns the API resource of the GVR, nil if the API server
+// doesn't serve it.
+func (a *apiServerAdapter) findResource(gvr schema.GroupVersionResource) (*metav1.APIResource, error) {
+	resources, err := a.discover.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range resources.APIResources {
+		if resources.APIResources[i].Name == gvr.Resource {
+			return &resources.APIResources[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// awaitResource polls the discovery API until the API server serves the
+// resource of the GVR, then watches it with the given function. It returns
+// early when stop is closed.
+func (a *apiServerAdapter) awaitResource(gvr schema.GroupVersionResource, stop <-chan struct{}, watchResource func(*metav1.APIResource)) {
+	a.logger.Warnw("resource doesn't exist, waiting for it to be served", zap.String("resource", gvr.String()))
+	a.emitEvent(corev1.EventTypeWarning, resourceNotFoundReason, "Resource %s doesn't exist, waiting for it to be served", gvr.String())
+
+	ticker := time.NewTicker(discoveryRetryPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			apires, err := a.findResource(gvr)
+			if err != nil {
+				a.logger.Warnw("failed to retrieve information about resource", zap.String("resource", gvr.String()), zap.Error(err))
+				continue
+			}
+			if apires == nil {
+				continue
+			}
+			a.logger.Infow("resource is served, watching it", zap.String("resource", gvr.String()))
+			a.emitEvent(corev1.EventTypeNormal, resourceFoundReason, "Resource %s is served, watching it", gvr.String())
+			watchResource(apires)
+			return
+		}
+	}
+}
+
+// emitEvent emits a K8s event for the ApiServerSource, if the adapter has an
+// event recorder.
+func (a *apiServerAdapter) emitEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if a.recorder == nil {
+		return
+	}
+	a.recorder.Eventf(&corev1.ObjectReference{
+		APIVersion: v1.SchemeGroupVersion.String(),
+		Kind:       "ApiServerSource",
+		Namespace:  a.namespace,
+		Name:       a.name,
+	}, eventType, reason, messageFmt, args...)
+}
+
+func createRecorder(ctx context.Context, kubeClient kubernetes.Interface, namespace, component string) record.EventRecorder {
+	logger := logging.FromContext(ctx)
+
+	eventBroadcaster := record.NewBroadcaster()
+	watches := []watch.Interface{
+		eventBroadcaster.StartLogging(logger.Named("event-broadcaster").Infof),
+		eventBroadcaster.StartRecordingToSink(
+			&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events(namespace)}),
+	}
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+	go func() {
+		<-ctx.Done()
+		for _, w := range watches {
+			w.Stop()
+		}
+	}()
+
+	return recorder
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+	"knative.dev/pkg/logging"
+	pkgtesting "knative.dev/pkg/reconciler/testing"
+)
+
+// lateDiscovery serves the resources of the discovery client once served is
+// called, as if their CRD was installed after the adapter started.
+type lateDiscovery struct {
+	discovery.DiscoveryInterface
+
+	mu        sync.Mutex
+	resources *metav1.APIResourceList
+}
+
+func (d *lateDiscovery) serve(resources *metav1.APIResourceList) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.resources = resources
+}
+
+func (d *lateDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resources != nil && d.resources.GroupVersion == groupVersion {
+		return d.resources, nil
+	}
+	return d.DiscoveryInterface.ServerResourcesForGroupVersion(groupVersion)
+}
+
+func TestAdapter_StartResourceServedLater(t *testing.T) {
+	discoveryRetryPeriod = 10 * time.Millisecond
+	t.Cleanup(func() {
+		discoveryRetryPeriod = 10 * time.Second
+	})
+
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "foo",
+		},
+	}}
+
+	ce := adaptertest.NewTestClient()
+	recorder := record.NewFakeRecorder(10)
+	disco := &lateDiscovery{DiscoveryInterface: makeDiscoveryClient()}
+	ctx, _ := pkgtesting.SetupFakeContext(t)
+
+	a := &apiServerAdapter{
+		ce:     ce,
+		logger: logging.FromContext(ctx),
+		config: Config{
+			Namespaces: []string{"default"},
+			Resources:  []ResourceWatch{{GVR: gvr}},
+			EventMode:  "Resource",
+			// the widgets created before the resource is watched are sent
+			SendInitialEvents: true,
+		},
+
+		discover: disco,
+		k8s: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "WidgetList"}, widget),
+		recorder:  recorder,
+		source:    "unit-test",
+		name:      "unittest",
+		namespace: "default",
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		done <- a.Start(ctx)
+	}()
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, resourceNotFoundReason) {
+			t.Error("expected a ResourceNotFound event, got:", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a ResourceNotFound event")
+	}
+	if got := len(ce.Sent()); got != 0 {
+		t.Error("expected no event to be sent before the resource is served, got:", got)
+	}
+
+	disco.serve(&metav1.APIResourceList{
+		GroupVersion: gvr.GroupVersion().String(),
+		APIResources: []metav1.APIResource{{Name: gvr.Resource, Namespaced: true, Kind: "Widget"}},
+	})
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, resourceFoundReason) {
+			t.Error("expected a ResourceFound event, got:", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a ResourceFound event")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ce.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(ce.Sent()); got != 1 {
+		t.Error("expected the existing widget to be sent once served, got:", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error("Did not expect an error, but got:", err)
+	}
+}
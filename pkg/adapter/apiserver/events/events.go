@@ -18,6 +18,7 @@ package events
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	ceobs "github.com/cloudevents/sdk-go/v2/observability"
 	"go.opentelemetry.io/otel/trace"
+	"gomodules.xyz/jsonpatch/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -35,8 +37,19 @@ import (
 
 const (
 	resourceGroup = "apiserversources.sources.knative.dev"
+
+	// applicationJSONPatch is the content type of the update events carrying
+	// a JSON patch.
+	applicationJSONPatch = "application/json-patch+json"
 )
 
+// ResourceUpdate is the payload of the update events carrying both the old
+// and the new state of a resource.
+type ResourceUpdate struct {
+	Old *unstructured.Unstructured `json:"old"`
+	New *unstructured.Unstructured `json:"new"`
+}
+
 // MakeAddEvent returns a cloudevent when a k8s api event is created.
 func MakeAddEvent(source string, apiServerSourceName string, obj interface{}, ref bool) (context.Context, cloudevents.Event, error) {
 	if obj == nil {
@@ -77,6 +90,52 @@ func MakeUpdateEvent(source string, apiServerSourceName string, obj interface{},
 	return makeEvent(source, apiServerSourceName, eventType, object, data)
 }
 
+// MakeUpdatePatchEvent returns a cloudevent carrying the JSON patch from old to
+// obj when a k8s api event is updated.
+func MakeUpdatePatchEvent(source string, apiServerSourceName string, old, obj interface{}) (context.Context, cloudevents.Event, error) {
+	if old == nil || obj == nil {
+		return nil, cloudevents.Event{}, fmt.Errorf("resource can not be nil")
+	}
+	oldObject := old.(*unstructured.Unstructured)
+	object := obj.(*unstructured.Unstructured)
+
+	oldJSON, err := oldObject.MarshalJSON()
+	if err != nil {
+		return nil, cloudevents.Event{}, err
+	}
+	newJSON, err := object.MarshalJSON()
+	if err != nil {
+		return nil, cloudevents.Event{}, err
+	}
+	patch, err := jsonpatch.CreatePatch(oldJSON, newJSON)
+	if err != nil {
+		return nil, cloudevents.Event{}, fmt.Errorf("failed to create patch: %w", err)
+	}
+	// There is no data codec for JSON patches, the patch is set as raw bytes.
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, cloudevents.Event{}, err
+	}
+
+	return makeEventWithContentType(source, apiServerSourceName, sources.ApiServerSourceUpdateEventType, object, applicationJSONPatch, data)
+}
+
+// MakeUpdateBothEvent returns a cloudevent carrying both old and obj when a k8s
+// api event is updated.
+func MakeUpdateBothEvent(source string, apiServerSourceName string, old, obj interface{}) (context.Context, cloudevents.Event, error) {
+	if old == nil || obj == nil {
+		return nil, cloudevents.Event{}, fmt.Errorf("resource can not be nil")
+	}
+	object := obj.(*unstructured.Unstructured)
+
+	data := ResourceUpdate{
+		Old: old.(*unstructured.Unstructured),
+		New: object,
+	}
+
+	return makeEvent(source, apiServerSourceName, sources.ApiServerSourceUpdateEventType, object, data)
+}
+
 // MakeDeleteEvent returns a cloudevent when a k8s api event is deleted.
 func MakeDeleteEvent(source string, apiServerSourceName string, obj interface{}, ref bool) (context.Context, cloudevents.Event, error) {
 	if obj == nil {
@@ -107,6 +166,10 @@ func getRef(object *unstructured.Unstructured) corev1.ObjectReference {
 }
 
 func makeEvent(source, apiServerSourceName, eventType string, obj *unstructured.Unstructured, data interface{}) (context.Context, cloudevents.Event, error) {
+	return makeEventWithContentType(source, apiServerSourceName, eventType, obj, cloudevents.ApplicationJSON, data)
+}
+
+func makeEventWithContentType(source, apiServerSourceName, eventType string, obj *unstructured.Unstructured, contentType string, data interface{}) (context.Context, cloudevents.Event, error) {
 	resourceName := obj.GetName()
 	kind := obj.GetKind()
 	namespace := obj.GetNamespace()
@@ -126,7 +189,7 @@ func makeEvent(source, apiServerSourceName, eventType string, obj *unstructured.
 	event.SetExtension("apiversion", obj.GetAPIVersion())
 	event.SetExtension("name", resourceName)
 	event.SetExtension("namespace", namespace)
-	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+	if err := event.SetData(contentType, data); err != nil {
 		return nil, event, err
 	}
 
@@ -325,3 +325,98 @@ func validate(t *testing.T, got cloudevents.Event, err error, want *cloudevents.
 		t.Error("unexpected data diff (-want, +got) =", diff)
 	}
 }
+
+func TestMakeUpdatePatchEvent(t *testing.T) {
+	patchContentType := "application/json-patch+json"
+	testCases := map[string]struct {
+		old    interface{}
+		obj    interface{}
+		source string
+
+		want     *cloudevents.Event
+		wantData string
+		wantErr  string
+	}{
+		"nil old object": {
+			source:  "unit-test",
+			obj:     simplePod("unit", "test"),
+			want:    nil,
+			wantErr: "resource can not be nil",
+		},
+		"labeled pod": {
+			source: "unit-test",
+			old:    simplePod("unit", "test"),
+			obj: func() *unstructured.Unstructured {
+				pod := simplePod("unit", "test")
+				pod.SetLabels(map[string]string{"app": "unit"})
+				return pod
+			}(),
+			want: &cloudevents.Event{
+				Context: cloudevents.EventContextV1{
+					Type:            "dev.knative.apiserver.resource.update",
+					Source:          *cloudevents.ParseURIRef("unit-test"),
+					Subject:         simpleSubject("unit", "test"),
+					DataContentType: &patchContentType,
+					Extensions: map[string]interface{}{
+						"apiversion": "v1",
+						"kind":       "Pod",
+						"name":       "unit",
+						"namespace":  "test",
+					},
+				}.AsV1(),
+			},
+			wantData: `[{"op":"add","path":"/metadata/labels","value":{"app":"unit"}}]`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			_, got, err := events.MakeUpdatePatchEvent(tc.source, apiServerSourceNameTest, tc.old, tc.obj)
+			validate(t, got, err, tc.want, tc.wantData, tc.wantErr)
+		})
+	}
+}
+
+func TestMakeUpdateBothEvent(t *testing.T) {
+	testCases := map[string]struct {
+		old    interface{}
+		obj    interface{}
+		source string
+
+		want     *cloudevents.Event
+		wantData string
+		wantErr  string
+	}{
+		"nil object": {
+			source:  "unit-test",
+			old:     simplePod("unit", "test"),
+			want:    nil,
+			wantErr: "resource can not be nil",
+		},
+		"simple pod": {
+			source: "unit-test",
+			old:    simplePod("unit", "test"),
+			obj:    simplePod("unit", "test"),
+			want: &cloudevents.Event{
+				Context: cloudevents.EventContextV1{
+					Type:            "dev.knative.apiserver.resource.update",
+					Source:          *cloudevents.ParseURIRef("unit-test"),
+					Subject:         simpleSubject("unit", "test"),
+					DataContentType: &contentType,
+					Extensions: map[string]interface{}{
+						"apiversion": "v1",
+						"kind":       "Pod",
+						"name":       "unit",
+						"namespace":  "test",
+					},
+				}.AsV1(),
+			},
+			wantData: `{"old":{"apiVersion":"v1","kind":"Pod","metadata":{"name":"unit","namespace":"test"}},"new":{"apiVersion":"v1","kind":"Pod","metadata":{"name":"unit","namespace":"test"}}}`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			_, got, err := events.MakeUpdateBothEvent(tc.source, apiServerSourceNameTest, tc.old, tc.obj)
+			validate(t, got, err, tc.want, tc.wantData, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+// identityFields are the fields always kept by a projection, they identify the
+// resource of the events.
+var identityFields = [][]string{
+	{"apiVersion"},
+	{"kind"},
+	{"metadata", "name"},
+	{"metadata", "namespace"},
+}
+
+// Project returns obj with the fields selected by p only. obj is returned as is
+// when p is nil or obj is not an unstructured object.
+func Project(obj interface{}, p *v1.ApiServerSourceProjection) interface{} {
+	object, ok := obj.(*unstructured.Unstructured)
+	if p == nil || !ok || object == nil {
+		return obj
+	}
+
+	var projected *unstructured.Unstructured
+	if p.MetadataOnly || len(p.Include) > 0 {
+		projected = &unstructured.Unstructured{Object: map[string]interface{}{}}
+		for _, fields := range identityFields {
+			copyField(object, projected, fields)
+		}
+		if p.MetadataOnly {
+			copyField(object, projected, []string{"metadata"})
+		}
+		for _, path := range p.Include {
+			copyField(object, projected, splitPath(path))
+		}
+	} else {
+		projected = object.DeepCopy()
+	}
+
+	for _, path := range p.Exclude {
+		unstructured.RemoveNestedField(projected.Object, splitPath(path)...)
+	}
+	return projected
+}
+
+func copyField(from, to *unstructured.Unstructured, fields []string) {
+	value, found, err := unstructured.NestedFieldCopy(from.Object, fields...)
+	if err != nil || !found {
+		return
+	}
+	_ = unstructured.SetNestedField(to.Object, value, fields...)
+}
+
+// splitPath splits a path such as `.status.images` in its fields.
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative.dev/eventing/pkg/adapter/apiserver/events"
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func node() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Node",
+			"metadata": map[string]interface{}{
+				"name":   "unit",
+				"labels": map[string]interface{}{"zone": "a"},
+			},
+			"spec": map[string]interface{}{
+				"podCIDR": "10.0.0.0/24",
+			},
+			"status": map[string]interface{}{
+				"images": []interface{}{"a", "b"},
+				"phase":  "Running",
+			},
+		},
+	}
+}
+
+func TestProject(t *testing.T) {
+	testCases := map[string]struct {
+		projection *v1.ApiServerSourceProjection
+		want       map[string]interface{}
+	}{
+		"no projection": {
+			want: node().Object,
+		},
+		"metadata only": {
+			projection: &v1.ApiServerSourceProjection{MetadataOnly: true},
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Node",
+				"metadata": map[string]interface{}{
+					"name":   "unit",
+					"labels": map[string]interface{}{"zone": "a"},
+				},
+			},
+		},
+		"include": {
+			projection: &v1.ApiServerSourceProjection{Include: []string{".status.phase", ".spec.missing"}},
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Node",
+				"metadata": map[string]interface{}{
+					"name": "unit",
+				},
+				"status": map[string]interface{}{
+					"phase": "Running",
+				},
+			},
+		},
+		"exclude": {
+			projection: &v1.ApiServerSourceProjection{Exclude: []string{".status.images", ".spec"}},
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Node",
+				"metadata": map[string]interface{}{
+					"name":   "unit",
+					"labels": map[string]interface{}{"zone": "a"},
+				},
+				"status": map[string]interface{}{
+					"phase": "Running",
+				},
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			obj := node()
+			got := events.Project(obj, tc.projection).(*unstructured.Unstructured)
+			if diff := cmp.Diff(tc.want, got.Object); diff != "" {
+				t.Error("unexpected projection (-want, +got) =", diff)
+			}
+			if diff := cmp.Diff(node().Object, obj.Object); diff != "" {
+				t.Error("projection modified the object (-want, +got) =", diff)
+			}
+		})
+	}
+}
+
+func TestProjectNil(t *testing.T) {
+	if got := events.Project(nil, &v1.ApiServerSourceProjection{MetadataOnly: true}); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	sources "knative.dev/eventing/pkg/apis/sources"
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+// eventTypeVerbs maps the default event types to the change of the resource
+// they are sent for.
+var eventTypeVerbs = map[string]string{
+	sources.ApiServerSourceAddEventType:       "add",
+	sources.ApiServerSourceUpdateEventType:    "update",
+	sources.ApiServerSourceDeleteEventType:    "delete",
+	sources.ApiServerSourceAddRefEventType:    "add",
+	sources.ApiServerSourceUpdateRefEventType: "update",
+	sources.ApiServerSourceDeleteRefEventType: "delete",
+}
+
+// EventTemplate renders the type and the source of the events from the
+// templates of an ApiServerSourceEventTemplate.
+type EventTemplate struct {
+	eventType *template.Template
+	source    *template.Template
+}
+
+// NewEventTemplate parses the templates, it returns nil when t is nil or has
+// no template.
+func NewEventTemplate(t *v1.ApiServerSourceEventTemplate) (*EventTemplate, error) {
+	if t == nil || (t.Type == "" && t.Source == "") {
+		return nil, nil
+	}
+
+	et := &EventTemplate{}
+	var err error
+	if t.Type != "" {
+		if et.eventType, err = v1.ParseEventTemplate("type", t.Type); err != nil {
+			return nil, fmt.Errorf("failed to parse type template: %w", err)
+		}
+	}
+	if t.Source != "" {
+		if et.source, err = v1.ParseEventTemplate("source", t.Source); err != nil {
+			return nil, fmt.Errorf("failed to parse source template: %w", err)
+		}
+	}
+	return et, nil
+}
+
+// Apply renders the type and the source of event, made by one of the
+// `Make*Event` functions for obj, with the templates. The data is taken from
+// obj, so that the fields dropped by a projection are still available.
+func (t *EventTemplate) Apply(event *cloudevents.Event, obj interface{}) error {
+	if t == nil {
+		return nil
+	}
+	object := obj.(*unstructured.Unstructured)
+	gvk := object.GroupVersionKind()
+	data := v1.EventTemplateData{
+		Type:      event.Type(),
+		Source:    event.Source(),
+		Verb:      eventTypeVerbs[event.Type()],
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+		Labels:    object.GetLabels(),
+	}
+
+	if t.eventType != nil {
+		eventType, err := render(t.eventType, data)
+		if err != nil {
+			return err
+		}
+		event.SetType(eventType)
+	}
+	if t.source != nil {
+		source, err := render(t.source, data)
+		if err != nil {
+			return err
+		}
+		event.SetSource(source)
+	}
+	return nil
+}
+
+func render(tmpl *template.Template, data v1.EventTemplateData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("%s template rendered empty", tmpl.Name())
+	}
+	return b.String(), nil
+}
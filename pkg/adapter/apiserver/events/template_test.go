@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative.dev/eventing/pkg/adapter/apiserver/events"
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func TestEventTemplate(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"namespace": "prod",
+				"name":      "web",
+				"labels":    map[string]interface{}{"team": "payments"},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		template   *v1.ApiServerSourceEventTemplate
+		ref        bool
+		wantType   string
+		wantSource string
+		wantErr    bool
+	}{
+		"type from the group, kind and verb": {
+			template:   &v1.ApiServerSourceEventTemplate{Type: "{{.Type}}.{{.Group}}.{{.Kind | lower}}"},
+			wantType:   "dev.knative.apiserver.resource.update.apps.deployment",
+			wantSource: "https://kubernetes.default.svc",
+		},
+		"source from the namespace and labels": {
+			template:   &v1.ApiServerSourceEventTemplate{Source: `{{.Source}}/{{.Namespace}}/{{index .Labels "team"}}`},
+			wantType:   "dev.knative.apiserver.resource.update",
+			wantSource: "https://kubernetes.default.svc/prod/payments",
+		},
+		"reference mode": {
+			template:   &v1.ApiServerSourceEventTemplate{Type: "com.example.{{.Kind | lower}}.{{.Verb}}"},
+			ref:        true,
+			wantType:   "com.example.deployment.update",
+			wantSource: "https://kubernetes.default.svc",
+		},
+		"empty type": {
+			template: &v1.ApiServerSourceEventTemplate{Type: `{{index .Labels "missing"}}`},
+			wantErr:  true,
+		},
+	}
+
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			tmpl, err := events.NewEventTemplate(tc.template)
+			if err != nil {
+				t.Fatal("unexpected error parsing the template:", err)
+			}
+			_, event, err := events.MakeUpdateEvent("https://kubernetes.default.svc", "source", deployment, tc.ref)
+			if err != nil {
+				t.Fatal("unexpected error making the event:", err)
+			}
+
+			err = tmpl.Apply(&event, deployment)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected error applying the template:", err)
+			}
+			if got := event.Type(); got != tc.wantType {
+				t.Errorf("unexpected type, want %q, got %q", tc.wantType, got)
+			}
+			if got := event.Source(); got != tc.wantSource {
+				t.Errorf("unexpected source, want %q, got %q", tc.wantSource, got)
+			}
+		})
+	}
+}
+
+func TestNewEventTemplateEmpty(t *testing.T) {
+	for _, template := range []*v1.ApiServerSourceEventTemplate{nil, {}} {
+		tmpl, err := events.NewEventTemplate(template)
+		if err != nil || tmpl != nil {
+			t.Errorf("expected no template for %v, got %v, %v", template, tmpl, err)
+		}
+	}
+}
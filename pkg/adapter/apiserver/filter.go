@@ -87,8 +87,14 @@ func (c *controllerFilter) GetByKey(key string) (item interface{}, exists bool,
 }
 
 // Implements cache.Store
-func (c *controllerFilter) Replace([]interface{}, string) error {
-	return nil
+func (c *controllerFilter) Replace(items []interface{}, resourceVersion string) error {
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if !c.filtered(item) {
+			kept = append(kept, item)
+		}
+	}
+	return c.delegate.Replace(kept, resourceVersion)
 }
 
 // Implements cache.Store
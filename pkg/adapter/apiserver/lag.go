@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type changeTimeKey struct{}
+
+func contextWithChangeTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, changeTimeKey{}, t)
+}
+
+func changeTimeFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(changeTimeKey{}).(time.Time)
+	return t, ok
+}
+
+// lastChangeTime returns the time of the last change of obj, that is the latest
+// of its managed fields and creation times. The API server records these times
+// with a precision of one second. The deletion time is not used as it includes
+// the grace period of the deletion.
+func lastChangeTime(obj interface{}) (time.Time, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || u == nil {
+		return time.Time{}, false
+	}
+
+	last := u.GetCreationTimestamp().Time
+	for _, mf := range u.GetManagedFields() {
+		if mf.Time != nil && mf.Time.After(last) {
+			last = mf.Time.Time
+		}
+	}
+	return last, !last.IsZero()
+}
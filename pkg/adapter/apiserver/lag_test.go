@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLastChangeTime(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	pod := simplePod("unit", "test")
+	if _, ok := lastChangeTime(pod); ok {
+		t.Error("lastChangeTime() found a change time for an object without timestamps")
+	}
+
+	pod.SetCreationTimestamp(metav1.NewTime(created))
+	if got, _ := lastChangeTime(pod); !got.Equal(created) {
+		t.Errorf("lastChangeTime() = %v, want %v", got, created)
+	}
+
+	pod.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager: "unit",
+		Time:    &metav1.Time{Time: updated},
+	}})
+	if got, _ := lastChangeTime(pod); !got.Equal(updated) {
+		t.Errorf("lastChangeTime() = %v, want %v", got, updated)
+	}
+
+	if _, ok := lastChangeTime(nil); ok {
+		t.Error("lastChangeTime() found a change time for a nil object")
+	}
+}
+
+func TestChangeTimeContext(t *testing.T) {
+	if _, ok := changeTimeFromContext(context.Background()); ok {
+		t.Error("changeTimeFromContext() found a change time in an empty context")
+	}
+
+	now := time.Now()
+	got, ok := changeTimeFromContext(contextWithChangeTime(context.Background(), now))
+	if !ok || !got.Equal(now) {
+		t.Errorf("changeTimeFromContext() = %v, %v, want %v, true", got, ok, now)
+	}
+}
+
+func TestInMsec(t *testing.T) {
+	if got := inMsec(1500 * time.Microsecond); got != 1.5 {
+		t.Errorf("inMsec() = %v, want 1.5", got)
+	}
+}
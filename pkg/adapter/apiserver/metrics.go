@@ -0,0 +1,237 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+
+	eventingmetrics "knative.dev/eventing/pkg/metrics"
+)
+
+var (
+	// coalescedEventCountM is a counter which records the number of events
+	// dropped because a newer event for the same object superseded them.
+	coalescedEventCountM = stats.Int64(
+		"apiserversource_coalesced_event_count",
+		"Number of events coalesced by the debounce window",
+		stats.UnitDimensionless,
+	)
+
+	// filteredEventCountM is a counter which records the number of events
+	// not sent because they did not pass the source filters.
+	filteredEventCountM = stats.Int64(
+		"apiserversource_filtered_event_count",
+		"Number of events filtered out by the source filters",
+		stats.UnitDimensionless,
+	)
+
+	// eventCreatedCountM is a counter which records the number of events
+	// created from the changes of the watched resources.
+	eventCreatedCountM = stats.Int64(
+		"apiserversource_event_created_count",
+		"Number of events created from the changes of the watched resources",
+		stats.UnitDimensionless,
+	)
+
+	// eventSentCountM is a counter which records the number of events
+	// successfully sent to the sink.
+	eventSentCountM = stats.Int64(
+		"apiserversource_event_sent_count",
+		"Number of events sent to the sink",
+		stats.UnitDimensionless,
+	)
+
+	// sendFailureCountM is a counter which records the number of events
+	// that could not be sent to the sink.
+	sendFailureCountM = stats.Int64(
+		"apiserversource_send_failure_count",
+		"Number of events that could not be sent to the sink",
+		stats.UnitDimensionless,
+	)
+
+	// sendLatencyInMsecM records the time spent sending an event to the sink.
+	sendLatencyInMsecM = stats.Float64(
+		"apiserversource_send_latencies",
+		"The time spent sending an event to the sink",
+		stats.UnitMilliseconds,
+	)
+
+	// lagInMsecM records the time between the last change of an object and
+	// the dispatch of its event.
+	lagInMsecM = stats.Float64(
+		"apiserversource_lag_latencies",
+		"The time between the last change of an object and the dispatch of its event",
+		stats.UnitMilliseconds,
+	)
+
+	// queueDepthM records the number of events of a resource waiting to be
+	// sent by the workers.
+	queueDepthM = stats.Int64(
+		"apiserversource_queue_depth",
+		"Number of events waiting to be sent",
+		stats.UnitDimensionless,
+	)
+
+	// shutdownDroppedEventCountM is a counter which records the number of
+	// events not sent because the adapter shutdown deadline was exceeded.
+	shutdownDroppedEventCountM = stats.Int64(
+		"apiserversource_shutdown_dropped_event_count",
+		"Number of events dropped at shutdown",
+		stats.UnitDimensionless,
+	)
+
+	namespaceKey = tag.MustNewKey(eventingmetrics.LabelNamespaceName)
+	nameKey      = tag.MustNewKey(eventingmetrics.LabelName)
+	resourceKey  = tag.MustNewKey("resource")
+)
+
+func init() {
+	registerMetrics()
+}
+
+// statsReporter records the ApiServerSource adapter specific metrics.
+type statsReporter struct {
+	namespace string
+	name      string
+}
+
+func (r *statsReporter) reportCoalescedEvent() {
+	r.record(coalescedEventCountM.M(1))
+}
+
+func (r *statsReporter) reportFilteredEvent() {
+	r.record(filteredEventCountM.M(1))
+}
+
+func (r *statsReporter) reportEventCreated(resource string) {
+	r.record(eventCreatedCountM.M(1), tag.Insert(resourceKey, resource))
+}
+
+func (r *statsReporter) reportEventSent(resource string, latency time.Duration) {
+	r.record(eventSentCountM.M(1), tag.Insert(resourceKey, resource))
+	r.record(sendLatencyInMsecM.M(inMsec(latency)), tag.Insert(resourceKey, resource))
+}
+
+func (r *statsReporter) reportSendFailure(resource string, latency time.Duration) {
+	r.record(sendFailureCountM.M(1), tag.Insert(resourceKey, resource))
+	r.record(sendLatencyInMsecM.M(inMsec(latency)), tag.Insert(resourceKey, resource))
+}
+
+func (r *statsReporter) reportLag(resource string, lag time.Duration) {
+	r.record(lagInMsecM.M(inMsec(lag)), tag.Insert(resourceKey, resource))
+}
+
+func (r *statsReporter) reportQueueDepth(resource string, depth int) {
+	r.record(queueDepthM.M(int64(depth)), tag.Insert(resourceKey, resource))
+}
+
+func (r *statsReporter) reportShutdownDroppedEvents(count int) {
+	r.record(shutdownDroppedEventCountM.M(int64(count)))
+}
+
+// inMsec returns the duration in milliseconds, keeping the fraction of a
+// millisecond of fast sends.
+func inMsec(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (r *statsReporter) record(ms stats.Measurement, mutators ...tag.Mutator) {
+	if r == nil {
+		return
+	}
+	ctx, err := tag.New(context.Background(), append([]tag.Mutator{
+		tag.Insert(namespaceKey, r.namespace),
+		tag.Insert(nameKey, r.name),
+	}, mutators...)...)
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, ms)
+}
+
+func registerMetrics() {
+	tagKeys := []tag.Key{
+		namespaceKey,
+		nameKey,
+	}
+	resourceTagKeys := append([]tag.Key{resourceKey}, tagKeys...)
+
+	if err := view.Register(
+		&view.View{
+			Description: coalescedEventCountM.Description(),
+			Measure:     coalescedEventCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: filteredEventCountM.Description(),
+			Measure:     filteredEventCountM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		},
+		&view.View{
+			Description: eventCreatedCountM.Description(),
+			Measure:     eventCreatedCountM,
+			Aggregation: view.Count(),
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: eventSentCountM.Description(),
+			Measure:     eventSentCountM,
+			Aggregation: view.Count(),
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: sendFailureCountM.Description(),
+			Measure:     sendFailureCountM,
+			Aggregation: view.Count(),
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: sendLatencyInMsecM.Description(),
+			Measure:     sendLatencyInMsecM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: lagInMsecM.Description(),
+			Measure:     lagInMsecM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 100000)...), // 1, 2, 5, 10, ..., 50000, 100000
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: queueDepthM.Description(),
+			Measure:     queueDepthM,
+			Aggregation: view.LastValue(),
+			TagKeys:     resourceTagKeys,
+		},
+		&view.View{
+			Description: shutdownDroppedEventCountM.Description(),
+			Measure:     shutdownDroppedEventCountM,
+			Aggregation: view.Sum(),
+			TagKeys:     tagKeys,
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// namespacedWatch starts the reflectors of a namespaced resource in a
+// namespace, they run until stop is closed.
+type namespacedWatch func(namespace string, stop <-chan struct{})
+
+// namespaceWatcher is the cache.Store of the reflector of the namespaces
+// matching the namespace selector. It starts the watches of the namespaced
+// resources in the namespaces as they start matching the selector, and stops
+// them as the namespaces stop matching it or are deleted.
+type namespaceWatcher struct {
+	logger *zap.SugaredLogger
+	// stop stops the watches of all the namespaces.
+	stop <-chan struct{}
+
+	mu sync.Mutex
+	// namespaces are the watched namespaces, closing their channel stops their
+	// watches.
+	namespaces map[string]chan struct{}
+	watches    []namespacedWatch
+}
+
+func newNamespaceWatcher(logger *zap.SugaredLogger, stop <-chan struct{}) *namespaceWatcher {
+	return &namespaceWatcher{
+		logger:     logger,
+		stop:       stop,
+		namespaces: make(map[string]chan struct{}),
+	}
+}
+
+// watch starts the watch in the watched namespaces, and in the namespaces
+// watched later on.
+func (w *namespaceWatcher) watch(watch namespacedWatch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.watches = append(w.watches, watch)
+	for ns, removed := range w.namespaces {
+		watch(ns, w.namespaceStop(removed))
+	}
+}
+
+// addNamespace starts the watches in the namespace, unless it is already
+// watched. It must be called with the lock held.
+func (w *namespaceWatcher) addNamespace(ns string) {
+	if _, ok := w.namespaces[ns]; ok {
+		return
+	}
+	w.logger.Infow("watching namespace", zap.String("namespace", ns))
+
+	removed := make(chan struct{})
+	w.namespaces[ns] = removed
+	for _, watch := range w.watches {
+		watch(ns, w.namespaceStop(removed))
+	}
+}
+
+// removeNamespace stops the watches in the namespace. It must be called with
+// the lock held.
+func (w *namespaceWatcher) removeNamespace(ns string) {
+	removed, ok := w.namespaces[ns]
+	if !ok {
+		return
+	}
+	w.logger.Infow("no longer watching namespace", zap.String("namespace", ns))
+
+	close(removed)
+	delete(w.namespaces, ns)
+}
+
+// namespaceStop returns a channel closed once the namespace is removed or
+// all the watches are stopped.
+func (w *namespaceWatcher) namespaceStop(removed <-chan struct{}) <-chan struct{} {
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		select {
+		case <-removed:
+		case <-w.stop:
+		}
+	}()
+	return stop
+}
+
+func namespaceName(obj interface{}) (string, error) {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return m.GetName(), nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Add(obj interface{}) error {
+	ns, err := namespaceName(obj)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.addNamespace(ns)
+	return nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Update(obj interface{}) error {
+	// The API server sends the namespaces starting or stopping to match the
+	// selector as added or deleted.
+	return w.Add(obj)
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Delete(obj interface{}) error {
+	ns, err := namespaceName(obj)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeNamespace(ns)
+	return nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) List() []interface{} {
+	return nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) ListKeys() []string {
+	return nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	return nil, false, nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) GetByKey(key string) (item interface{}, exists bool, err error) {
+	return nil, false, nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Replace(items []interface{}, _ string) error {
+	listed := make(map[string]bool, len(items))
+	for _, item := range items {
+		ns, err := namespaceName(item)
+		if err != nil {
+			return err
+		}
+		listed[ns] = true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// The namespaces deleted while the watch was down are no longer listed.
+	for ns := range w.namespaces {
+		if !listed[ns] {
+			w.removeNamespace(ns)
+		}
+	}
+	for ns := range listed {
+		w.addNamespace(ns)
+	}
+	return nil
+}
+
+// Implements cache.Store
+func (w *namespaceWatcher) Resync() error {
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/logging"
+	pkgtesting "knative.dev/pkg/reconciler/testing"
+
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestNamespaceWatcher(t *testing.T) {
+	stop := make(chan struct{})
+	w := newNamespaceWatcher(logging.FromContext(context.Background()), stop)
+
+	started := make(map[string][]<-chan struct{})
+	watch := func(ns string, nsStop <-chan struct{}) {
+		started[ns] = append(started[ns], nsStop)
+	}
+
+	w.Add(simpleNamespace("a"))
+	w.watch(watch)
+	w.Add(simpleNamespace("b"))
+	// Namespaces are only watched once.
+	w.Update(simpleNamespace("b"))
+	w.watch(watch)
+
+	if got := len(started["a"]); got != 2 {
+		t.Errorf("Expected 2 watches started in namespace a, got %d", got)
+	}
+	if got := len(started["b"]); got != 2 {
+		t.Errorf("Expected 2 watches started in namespace b, got %d", got)
+	}
+
+	w.Delete(simpleNamespace("a"))
+	for _, s := range started["a"] {
+		assertStopped(t, s)
+	}
+
+	// The namespaces which are no longer listed are removed.
+	w.Replace([]interface{}{simpleNamespace("c")}, "")
+	for _, s := range started["b"] {
+		assertStopped(t, s)
+	}
+	if got := len(started["c"]); got != 2 {
+		t.Errorf("Expected 2 watches started in namespace c, got %d", got)
+	}
+
+	close(stop)
+	for _, s := range started["c"] {
+		assertStopped(t, s)
+	}
+}
+
+func TestAdapter_NamespaceSelector(t *testing.T) {
+	ce := adaptertest.NewTestClient()
+
+	config := Config{
+		NamespaceSelector: "target=yes",
+		Resources: []ResourceWatch{{
+			GVR: schema.GroupVersionResource{
+				Version:  "v1",
+				Resource: "pods",
+			},
+		}},
+		EventMode:         "Resource",
+		SendInitialEvents: true,
+	}
+	ctx, _ := pkgtesting.SetupFakeContext(t)
+
+	k8s := makeDynamicClient(
+		labeledNamespace("a", "yes"),
+		labeledNamespace("b", "no"),
+		simplePod("foo", "a"),
+		simplePod("foo", "b"),
+		simplePod("foo", "c"),
+	)
+	a := &apiServerAdapter{
+		ce:     ce,
+		logger: logging.FromContext(ctx),
+		config: config,
+
+		discover: makeDiscoveryClient(),
+		k8s:      k8s,
+		source:   "unit-test",
+		name:     "unittest",
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		a.Start(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	awaitNamespaces(t, ce, "a")
+
+	// The pods of a namespace starting to match the selector are watched.
+	namespaces := k8s.Resource(corev1.SchemeGroupVersion.WithResource("namespaces"))
+	if _, err := namespaces.Create(ctx, labeledNamespace("c", "yes"), metav1.CreateOptions{}); err != nil {
+		t.Fatal("Failed to create namespace:", err)
+	}
+	awaitNamespaces(t, ce, "a", "c")
+}
+
+func labeledNamespace(name, target string) *unstructured.Unstructured {
+	ns := simpleNamespace(name)
+	ns.SetLabels(map[string]string{"target": target})
+	return ns
+}
+
+// awaitNamespaces waits for the events sent to be the events of the given
+// namespaces, in order.
+func awaitNamespaces(t *testing.T, ce *adaptertest.TestCloudEventsClient, want ...string) {
+	t.Helper()
+
+	var got []string
+	err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		got = nil
+		for _, e := range ce.Sent() {
+			got = append(got, e.Extensions()["namespace"].(string))
+		}
+		return len(got) == len(want), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the events of namespaces %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected the events of namespaces %v, got %v", want, got)
+		}
+	}
+}
+
+func assertStopped(t *testing.T, stop <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-stop:
+	case <-time.After(time.Second):
+		t.Error("Expected the watch to be stopped")
+	}
+}
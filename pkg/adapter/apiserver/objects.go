@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"sync"
+)
+
+// objectCache keeps the last seen state of the watched objects, so that the
+// update events can carry the previous state of an object.
+type objectCache struct {
+	mu      sync.Mutex
+	objects map[string]interface{}
+}
+
+func newObjectCache() *objectCache {
+	return &objectCache{objects: make(map[string]interface{})}
+}
+
+// swap stores obj and returns the previously stored state of the object, if any.
+func (c *objectCache) swap(obj interface{}) interface{} {
+	key, ok := objectKey(obj)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.objects[key]
+	c.objects[key] = obj
+	return old
+}
+
+// delete forgets the state of obj.
+func (c *objectCache) delete(obj interface{}) {
+	key, ok := objectKey(obj)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.objects, key)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"testing"
+
+	v1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func TestUpdateEventPayloadMode(t *testing.T) {
+	testCases := map[string]struct {
+		payloadMode     string
+		seed            bool
+		wantContentType string
+		wantData        string
+	}{
+		"diff": {
+			payloadMode:     v1.PayloadModeDiff,
+			seed:            true,
+			wantContentType: "application/json-patch+json",
+			wantData:        `[{"op":"add","path":"/metadata/labels","value":{"app":"unit"}}]`,
+		},
+		"both": {
+			payloadMode:     v1.PayloadModeBoth,
+			seed:            true,
+			wantContentType: "application/json",
+			wantData:        `{"old":{"apiVersion":"v1","kind":"Pod","metadata":{"name":"unit","namespace":"test"}},"new":{"apiVersion":"v1","kind":"Pod","metadata":{"labels":{"app":"unit"},"name":"unit","namespace":"test"}}}`,
+		},
+		"diff without previous state": {
+			payloadMode:     v1.PayloadModeDiff,
+			wantContentType: "application/json",
+			wantData:        `{"apiVersion":"v1","kind":"Pod","metadata":{"labels":{"app":"unit"},"name":"unit","namespace":"test"}}`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			d, ce := makeResourceAndTestingClient()
+			d.payloadMode = tc.payloadMode
+			d.objects = newObjectCache()
+
+			if tc.seed {
+				d.Replace([]interface{}{simplePod("unit", "test")}, "1")
+			}
+			pod := simplePod("unit", "test")
+			pod.SetLabels(map[string]string{"app": "unit"})
+			d.Update(pod)
+
+			sent := ce.Sent()
+			if len(sent) != 1 {
+				t.Fatalf("got %d events, want 1", len(sent))
+			}
+			if got := sent[0].DataContentType(); got != tc.wantContentType {
+				t.Errorf("got content type %q, want %q", got, tc.wantContentType)
+			}
+			if got := string(sent[0].Data()); got != tc.wantData {
+				t.Errorf("got data %s, want %s", got, tc.wantData)
+			}
+		})
+	}
+}
+
+func TestObjectCacheDelete(t *testing.T) {
+	c := newObjectCache()
+	pod := simplePod("unit", "test")
+
+	c.swap(pod)
+	c.delete(pod)
+	if old := c.swap(pod); old != nil {
+		t.Errorf("got previous state %v, want none", old)
+	}
+}
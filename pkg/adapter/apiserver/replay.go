@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/kmeta"
+)
+
+const (
+	// replayConfigMapKey is the key of the ConfigMap data holding the replay
+	// token of the last replay.
+	replayConfigMapKey = "replayToken"
+
+	// replayPersistTimeout bounds the time spent persisting the watermark.
+	replayPersistTimeout = 10 * time.Second
+)
+
+// initialEventsStore sends an add event for each of the objects returned by
+// the initial list of a reflector. The reflector lists again when its watch
+// expires, those lists are passed through so that the existing objects are
+// only replayed once per adapter start.
+type initialEventsStore struct {
+	cache.Store
+
+	once sync.Once
+	// replayed, when set, is called once the objects of the initial list
+	// are replayed.
+	replayed func()
+}
+
+var _ cache.Store = (*initialEventsStore)(nil)
+
+// Implements cache.Store
+func (s *initialEventsStore) Replace(items []interface{}, resourceVersion string) error {
+	replayed := false
+	var err error
+	s.once.Do(func() {
+		replayed = true
+		for _, item := range items {
+			if addErr := s.Store.Add(item); addErr != nil {
+				err = addErr
+			}
+		}
+	})
+	if !replayed {
+		return s.Store.Replace(items, resourceVersion)
+	}
+	if s.replayed != nil {
+		s.replayed()
+	}
+	return err
+}
+
+// replayTracker calls onDone once the initial lists of the reflectors started
+// with the adapter are replayed. The reflectors started later on, e.g. in the
+// namespaces starting to match the namespace selector, replay their initial
+// list on their own.
+type replayTracker struct {
+	mu      sync.Mutex
+	pending int
+	sealed  bool
+	done    bool
+	onDone  func()
+}
+
+// add tracks the replay of the initial list of a reflector, the returned
+// function marks it as replayed.
+func (t *replayTracker) add() func() {
+	t.mu.Lock()
+	t.pending++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			t.pending--
+			t.mu.Unlock()
+			t.check()
+		})
+	}
+}
+
+// seal marks that all the reflectors started with the adapter are tracked.
+func (t *replayTracker) seal() {
+	t.mu.Lock()
+	t.sealed = true
+	t.mu.Unlock()
+	t.check()
+}
+
+func (t *replayTracker) check() {
+	t.mu.Lock()
+	done := t.sealed && t.pending == 0 && !t.done
+	if done {
+		t.done = true
+	}
+	t.mu.Unlock()
+
+	if done && t.onDone != nil {
+		t.onDone()
+	}
+}
+
+// replayWatermark persists the replay token of the last replay of the existing
+// objects in a ConfigMap, so that restarts of the adapter don't replay them
+// again.
+type replayWatermark struct {
+	configMaps dynamic.ResourceInterface
+	name       string
+}
+
+func newReplayWatermark(k8s dynamic.Interface, namespace, sourceName string) *replayWatermark {
+	return &replayWatermark{
+		configMaps: k8s.Resource(configMapGVR).Namespace(namespace),
+		name:       kmeta.ChildName(sourceName, "-replay"),
+	}
+}
+
+// replayed reports whether the existing objects were replayed for token.
+func (w *replayWatermark) replayed(ctx context.Context, token string) (bool, error) {
+	cm, err := w.configMaps.Get(ctx, w.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get ConfigMap %s: %w", w.name, err)
+	}
+	replayed, _, _ := unstructured.NestedString(cm.Object, "data", replayConfigMapKey)
+	return replayed == token, nil
+}
+
+// persist records that the existing objects were replayed for token.
+func (w *replayWatermark) persist(ctx context.Context, token string) error {
+	cm, err := w.configMaps.Get(ctx, w.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &unstructured.Unstructured{}
+		cm.SetAPIVersion("v1")
+		cm.SetKind("ConfigMap")
+		cm.SetName(w.name)
+		if err := unstructured.SetNestedField(cm.Object, token, "data", replayConfigMapKey); err != nil {
+			return err
+		}
+		if _, err := w.configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", w.name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %w", w.name, err)
+	}
+
+	if err := unstructured.SetNestedField(cm.Object, token, "data", replayConfigMapKey); err != nil {
+		return err
+	}
+	if _, err := w.configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %w", w.name, err)
+	}
+	return nil
+}
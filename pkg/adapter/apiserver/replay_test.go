@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/logging"
+	pkgtesting "knative.dev/pkg/reconciler/testing"
+
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+	"knative.dev/eventing/pkg/apis/sources"
+)
+
+func TestInitialEventsStoreReplace(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	s := &initialEventsStore{Store: d}
+
+	items := []interface{}{simplePod("a", "test"), simplePod("b", "test")}
+	if err := s.Replace(items, "1"); err != nil {
+		t.Fatal("Replace() =", err)
+	}
+	if got := len(ce.Sent()); got != 2 {
+		t.Fatalf("got %d events, want 2", got)
+	}
+	for _, e := range ce.Sent() {
+		if e.Type() != sources.ApiServerSourceAddEventType {
+			t.Errorf("got event type %q, want %q", e.Type(), sources.ApiServerSourceAddEventType)
+		}
+	}
+
+	// Relists must not replay the existing objects again.
+	ce.Reset()
+	if err := s.Replace(items, "2"); err != nil {
+		t.Fatal("Replace() =", err)
+	}
+	validateNotSent(t, ce, sources.ApiServerSourceAddEventType)
+}
+
+func TestInitialEventsStoreForwards(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	s := &initialEventsStore{Store: d}
+
+	s.Update(simplePod("unit", "test"))
+	validateSent(t, ce, sources.ApiServerSourceUpdateEventType)
+}
+
+func TestInitialEventsStoreReplayed(t *testing.T) {
+	d, _ := makeResourceAndTestingClient()
+	replayed := 0
+	s := &initialEventsStore{Store: d, replayed: func() { replayed++ }}
+
+	items := []interface{}{simplePod("a", "test")}
+	s.Replace(items, "1")
+	s.Replace(items, "2")
+	if replayed != 1 {
+		t.Errorf("Expected the initial list to be marked as replayed once, got %d", replayed)
+	}
+}
+
+func TestReplayTracker(t *testing.T) {
+	done := 0
+	tracker := &replayTracker{onDone: func() { done++ }}
+
+	first, second := tracker.add(), tracker.add()
+	first()
+	// Marking a reflector twice as replayed counts once.
+	first()
+	tracker.seal()
+	if done != 0 {
+		t.Fatal("Expected the replay to be pending")
+	}
+
+	second()
+	if done != 1 {
+		t.Errorf("Expected the replay to be done once, got %d", done)
+	}
+	// The reflectors started later on don't replay again.
+	tracker.add()()
+	if done != 1 {
+		t.Errorf("Expected the replay to be done once, got %d", done)
+	}
+}
+
+func TestReplayWatermark(t *testing.T) {
+	ctx := context.Background()
+	w := newReplayWatermark(makeDynamicClient(), "test", "source")
+
+	assertReplayed := func(token string, want bool) {
+		t.Helper()
+		got, err := w.replayed(ctx, token)
+		if err != nil {
+			t.Fatal("replayed() =", err)
+		}
+		if got != want {
+			t.Errorf("replayed(%q) = %t, want %t", token, got, want)
+		}
+	}
+
+	assertReplayed("a", false)
+	if err := w.persist(ctx, "a"); err != nil {
+		t.Fatal("persist() =", err)
+	}
+	assertReplayed("a", true)
+	if err := w.persist(ctx, "b"); err != nil {
+		t.Fatal("persist() =", err)
+	}
+	assertReplayed("a", false)
+	assertReplayed("b", true)
+}
+
+func TestAdapter_ReplayToken(t *testing.T) {
+	ctx, _ := pkgtesting.SetupFakeContext(t)
+	k8s := makeDynamicClient(simplePod("foo", "default"))
+
+	start := func() *adaptertest.TestCloudEventsClient {
+		t.Helper()
+
+		ce := adaptertest.NewTestClient()
+		a := &apiServerAdapter{
+			ce:     ce,
+			logger: logging.FromContext(ctx),
+			config: Config{
+				Namespaces: []string{"default"},
+				Resources: []ResourceWatch{{
+					GVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+				}},
+				EventMode:   "Resource",
+				ReplayToken: "1",
+			},
+			discover:  makeDiscoveryClient(),
+			k8s:       k8s,
+			source:    "unit-test",
+			name:      "unittest",
+			namespace: "default",
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			a.Start(ctx)
+			close(done)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		w := newReplayWatermark(k8s, "default", "unittest")
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+			return w.replayed(ctx, "1")
+		})
+		if err != nil {
+			t.Fatal("The replay watermark wasn't persisted:", err)
+		}
+		return ce
+	}
+
+	if got := len(start().Sent()); got != 1 {
+		t.Errorf("Expected the existing pod to be replayed, got %d events", got)
+	}
+	// Restarts don't replay the existing objects again.
+	if got := len(start().Sent()); got != 0 {
+		t.Errorf("Expected no event after a restart, got %d events", got)
+	}
+}
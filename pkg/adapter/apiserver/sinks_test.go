@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+)
+
+func TestAdditionalSinks(t *testing.T) {
+	ce := &targetRecordingClient{failing: "http://audit.example.com"}
+	d := &resourceDelegate{
+		ce:                  ce,
+		source:              "unit-test",
+		apiServerSourceName: apiServerSourceNameTest,
+		logger:              zap.NewExample().Sugar(),
+		additionalSinks: []SinkConfig{
+			{URI: "http://audit.example.com"},
+			{URI: "http://mirror.example.com"},
+		},
+		delivery: &delivery{deadLetterSink: "http://dls.example.com"},
+	}
+
+	d.Add(simplePod("unit", "test"))
+
+	// The failure of an additional sink does not prevent sending to the other
+	// sinks and is dead lettered on its own.
+	want := []string{"", "http://audit.example.com", "http://dls.example.com", "http://mirror.example.com"}
+	if diff := cmp.Diff(want, ce.targets); diff != "" {
+		t.Error("unexpected targets (-want, +got) =", diff)
+	}
+}
+
+// targetRecordingClient records the target of every event, it NACKs the events
+// sent to failing.
+type targetRecordingClient struct {
+	cloudevents.Client
+
+	mu      sync.Mutex
+	failing string
+	targets []string
+}
+
+func (c *targetRecordingClient) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var target string
+	if u := cloudevents.TargetFromContext(ctx); u != nil {
+		target = u.String()
+	}
+	c.targets = append(c.targets, target)
+	if target == c.failing {
+		return cehttp.NewResult(500, "%w", protocol.ResultNACK)
+	}
+	return cehttp.NewResult(202, "%w", protocol.ResultACK)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// workerQueueSize is the number of events a worker buffers before enqueue blocks.
+const workerQueueSize = 100
+
+// workerPools sends the events of each resource through its own keyedWorkers,
+// so that the events of a high-churn resource, or of a resource whose events
+// are slow to send, don't delay the events of the other resources.
+type workerPools struct {
+	workers  int
+	send     func(ctx context.Context, event cloudevents.Event)
+	reporter *statsReporter
+
+	// mu guards pools and stopped.
+	mu      sync.Mutex
+	pools   map[string]*keyedWorkers
+	stopped bool
+}
+
+func newWorkerPools(workers int, reporter *statsReporter, send func(ctx context.Context, event cloudevents.Event)) *workerPools {
+	return &workerPools{
+		workers:  workers,
+		send:     send,
+		reporter: reporter,
+		pools:    make(map[string]*keyedWorkers),
+	}
+}
+
+// enqueue queues event on the pool of resource, the pool is started on the
+// first event of the resource. Once the pools are stopped, event is sent
+// synchronously.
+func (p *workerPools) enqueue(ctx context.Context, resource, key string, event cloudevents.Event) {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		p.send(ctx, event)
+		return
+	}
+	pool, ok := p.pools[resource]
+	if !ok {
+		pool = newKeyedWorkers(resource, p.workers, p.reporter, p.send)
+		p.pools[resource] = pool
+	}
+	p.mu.Unlock()
+
+	pool.enqueue(ctx, key, event)
+}
+
+// drain stops the pools and waits for the queued events to be sent until ctx
+// is done. The events still queued at that point are dropped.
+func (p *workerPools) drain(ctx context.Context) {
+	p.mu.Lock()
+	p.stopped = true
+	pools := make([]*keyedWorkers, 0, len(p.pools))
+	for _, pool := range p.pools {
+		pools = append(pools, pool)
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool *keyedWorkers) {
+			defer wg.Done()
+			pool.drain(ctx)
+		}(pool)
+	}
+	wg.Wait()
+}
+
+// keyedWorkers sends the events through a fixed number of workers. The events
+// with the same key are always sent by the same worker, so they are delivered
+// in order, while the events of different keys are sent in parallel.
+type keyedWorkers struct {
+	// resource is the resource of the events sent by the workers.
+	resource string
+	send     func(ctx context.Context, event cloudevents.Event)
+	reporter *statsReporter
+	queues   []chan pendingEvent
+	wg       sync.WaitGroup
+
+	// depth is the number of events queued across the workers.
+	depth atomic.Int64
+
+	// mu guards stopped, enqueue holds it for reading while queueing.
+	mu      sync.RWMutex
+	stopped bool
+
+	// aborted is set when the drain deadline is exceeded, the queued events
+	// are then dropped.
+	aborted atomic.Bool
+}
+
+func newKeyedWorkers(resource string, workers int, reporter *statsReporter, send func(ctx context.Context, event cloudevents.Event)) *keyedWorkers {
+	w := &keyedWorkers{
+		resource: resource,
+		send:     send,
+		reporter: reporter,
+		queues:   make([]chan pendingEvent, workers),
+	}
+	for i := range w.queues {
+		w.queues[i] = make(chan pendingEvent, workerQueueSize)
+		w.wg.Add(1)
+		go w.run(w.queues[i])
+	}
+	return w
+}
+
+func (w *keyedWorkers) run(queue <-chan pendingEvent) {
+	defer w.wg.Done()
+	for p := range queue {
+		w.reporter.reportQueueDepth(w.resource, int(w.depth.Add(-1)))
+		if w.aborted.Load() {
+			continue
+		}
+		w.send(p.ctx, p.event)
+	}
+}
+
+// enqueue queues event on the worker owning key, it blocks while the queue of
+// that worker is full. Once the workers are stopped, event is sent synchronously.
+func (w *keyedWorkers) enqueue(ctx context.Context, key string, event cloudevents.Event) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		w.send(ctx, event)
+		return
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	w.reporter.reportQueueDepth(w.resource, int(w.depth.Add(1)))
+	w.queues[h.Sum32()%uint32(len(w.queues))] <- pendingEvent{ctx: ctx, event: event}
+}
+
+// drain stops the workers and waits for the queued events to be sent until ctx
+// is done. The events still queued at that point are dropped.
+func (w *keyedWorkers) drain(ctx context.Context) {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+
+	for _, queue := range w.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		w.aborted.Store(true)
+		w.reporter.reportShutdownDroppedEvents(int(w.depth.Load()))
+	}
+}
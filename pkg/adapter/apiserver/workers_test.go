@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"knative.dev/eventing/pkg/apis/sources"
+)
+
+func TestWorkersPreserveOrderPerObject(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.workers = newWorkerPools(4, nil, d.sendCloudEvent)
+
+	for _, name := range []string{"a", "b", "c"} {
+		d.Add(simplePod(name, "test"))
+		d.Update(simplePod(name, "test"))
+		d.Delete(simplePod(name, "test"))
+	}
+	d.workers.drain(context.Background())
+
+	sent := ce.Sent()
+	if len(sent) != 9 {
+		t.Fatalf("got %d events, want 9", len(sent))
+	}
+
+	want := []string{
+		sources.ApiServerSourceAddEventType,
+		sources.ApiServerSourceUpdateEventType,
+		sources.ApiServerSourceDeleteEventType,
+	}
+	got := make(map[string][]string)
+	for _, e := range sent {
+		got[e.Subject()] = append(got[e.Subject()], e.Type())
+	}
+	for subject, types := range got {
+		for i := range want {
+			if types[i] != want[i] {
+				t.Errorf("got event types %v for %s, want %v", types, subject, want)
+				break
+			}
+		}
+	}
+}
+
+func TestWorkersSendAfterStop(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	d.workers = newWorkerPools(1, nil, d.sendCloudEvent)
+	d.workers.drain(context.Background())
+
+	d.Add(simplePod("unit", "test"))
+	validateSent(t, ce, sources.ApiServerSourceAddEventType)
+}
+
+func TestWorkersDrainDeadline(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	started := make(chan struct{}, 2)
+	block := make(chan struct{})
+	d.workers = newWorkerPools(1, nil, func(ctx context.Context, event cloudevents.Event) {
+		started <- struct{}{}
+		<-block
+		d.sendCloudEvent(ctx, event)
+	})
+
+	d.Add(simplePod("a", "test"))
+	<-started
+	d.Add(simplePod("b", "test"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.workers.drain(ctx)
+	close(block)
+	for _, pool := range d.workers.pools {
+		pool.wg.Wait()
+	}
+
+	// The event being sent when the deadline is exceeded completes, the queued
+	// one is dropped.
+	if got := len(ce.Sent()); got != 1 {
+		t.Errorf("got %d events, want 1", got)
+	}
+}
+
+func TestWorkersPoolPerResource(t *testing.T) {
+	d, ce := makeResourceAndTestingClient()
+	block := make(chan struct{})
+	d.workers = newWorkerPools(1, nil, func(ctx context.Context, event cloudevents.Event) {
+		if event.Extensions()["kind"] == "Pod" {
+			<-block
+		}
+		d.sendCloudEvent(ctx, event)
+	})
+
+	// The pod events being stuck doesn't delay the namespace events.
+	d.Add(simplePod("a", "test"))
+	d.Add(simplePod("b", "test"))
+	d.Add(simpleNamespace("test"))
+	if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return len(ce.Sent()) == 1, nil
+	}); err != nil {
+		t.Fatal("Expected the namespace event to be sent")
+	}
+
+	close(block)
+	d.workers.drain(context.Background())
+	if got := len(ce.Sent()); got != 3 {
+		t.Errorf("got %d events, want 3", got)
+	}
+	if got := len(d.workers.pools); got != 2 {
+		t.Errorf("got %d worker pools, want 2", got)
+	}
+}
@@ -33,6 +33,8 @@ import (
 
 	"knative.dev/eventing/pkg/adapter/v2"
 	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	eventingclient "knative.dev/eventing/pkg/client/injection/client"
+	"knative.dev/eventing/pkg/eventingtls"
 )
 
 const (
@@ -45,6 +47,10 @@ type mtpingAdapter struct {
 	runner    CronJobRunner
 	entryidMu sync.RWMutex
 	entryids  map[string]cron.EntryID // key: resource namespace/name
+
+	// serverManager serves the adapter health endpoints, it is nil
+	// when no ConfigMap watcher is available.
+	serverManager *eventingtls.ServerManager
 }
 
 var (
@@ -59,22 +65,38 @@ func NewEnvConfig() adapter.EnvConfigAccessor {
 func NewAdapter(ctx context.Context, env adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
 	logger := logging.FromContext(ctx)
 
-	opts := cron.WithParser(cron.NewParser(
-		cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
-	))
+	runner := NewCronJobsRunner(adapter.GetClientConfig(ctx), kubeclient.Get(ctx), logging.FromContext(ctx), cron.WithParser(ScheduleParser))
+	runner.lastFires = newLastFires(kubeclient.Get(ctx), env.GetNamespace(), logger)
+	runner.sources = eventingclient.Get(ctx).SourcesV1()
 
-	runner := NewCronJobsRunner(adapter.GetClientConfig(ctx), kubeclient.Get(ctx), logging.FromContext(ctx), opts)
+	var sm *eventingtls.ServerManager
+	if cmw := adapter.ConfigWatcherFromContext(ctx); cmw != nil {
+		var err error
+		sm, err = NewServerManager(ctx, logger, cmw, healthPort, healthPortHTTPS)
+		if err != nil {
+			logger.Fatalw("Failed to create the health server manager", zap.Error(err))
+		}
+	}
 
 	return &mtpingAdapter{
-		logger:    logger,
-		runner:    runner,
-		entryidMu: sync.RWMutex{},
-		entryids:  make(map[string]cron.EntryID),
+		logger:        logger,
+		runner:        runner,
+		entryidMu:     sync.RWMutex{},
+		entryids:      make(map[string]cron.EntryID),
+		serverManager: sm,
 	}
 }
 
 // Start implements adapter.Adapter
 func (a *mtpingAdapter) Start(ctx context.Context) error {
+	if a.serverManager != nil {
+		go func() {
+			if err := a.serverManager.StartServers(ctx); err != nil {
+				a.logger.Errorw("Health servers failed", zap.Error(err))
+			}
+		}()
+	}
+
 	a.logger.Info("Starting job runner...")
 	a.runner.Start(ctx.Done())
 	defer a.runner.Stop()
@@ -142,4 +164,5 @@ func (a *mtpingAdapter) RemoveAll(ctx context.Context) {
 		a.runner.RemoveSchedule(id)
 	}
 	a.entryids = make(map[string]cron.EntryID)
+	a.runner.Demote()
 }
@@ -100,3 +100,4 @@ func (*testRunner) AddSchedule(*sourcesv1.PingSource) cron.EntryID {
 	return cron.EntryID(1)
 }
 func (*testRunner) RemoveSchedule(cron.EntryID) {}
+func (*testRunner) Demote()                     {}
@@ -0,0 +1,296 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+const (
+	// LastFiresLeaseName is the name of the Lease the scheduled time of the
+	// last tick of each PingSource is persisted in.
+	LastFiresLeaseName = "pingsource-mt-adapter-last-fires"
+	// LastFiresAnnotation is the annotation of the Lease holding the scheduled
+	// time of the last tick per PingSource, as a JSON object.
+	LastFiresAnnotation = "sources.knative.dev/last-fires"
+
+	lastFiresFlushInterval = 10 * time.Second
+	// lastFiresRetention is how long the last tick of a PingSource is kept,
+	// ticks which fired earlier can't be fired again by a restarted adapter.
+	lastFiresRetention = time.Hour
+	// lastFiresMaxSize bounds the size of the annotation, the annotations of
+	// an object are limited to 256 KiB in total.
+	lastFiresMaxSize = 128 * 1024
+)
+
+// lastFires keeps the scheduled time of the last tick of each PingSource and
+// persists them in a Lease, so that a restarted adapter, or the adapter
+// promoted to leader, doesn't fire a tick the previous leader already fired.
+type lastFires struct {
+	leases coordinationv1client.LeaseInterface
+	logger *zap.SugaredLogger
+	now    func() time.Time
+	// maxSize bounds the size of the persisted ticks, see lastFiresMaxSize.
+	maxSize int
+
+	mu     sync.Mutex
+	fires  map[string]time.Time
+	dirty  bool
+	loaded bool
+}
+
+func newLastFires(kubeClient kubernetes.Interface, namespace string, logger *zap.SugaredLogger) *lastFires {
+	return &lastFires{
+		leases:  kubeClient.CoordinationV1().Leases(namespace),
+		logger:  logger,
+		now:     time.Now,
+		maxSize: lastFiresMaxSize,
+		fires:   make(map[string]time.Time),
+	}
+}
+
+// load reads the last ticks persisted by the previous leader.
+func (l *lastFires) load(ctx context.Context) error {
+	lease, err := l.leases.Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		l.mu.Lock()
+		l.loaded = true
+		l.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get lease %s: %w", LastFiresLeaseName, err)
+	}
+
+	fires, err := parseLastFires(lease)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	mergeLastFires(l.fires, fires)
+	l.loaded = true
+	return nil
+}
+
+// ensureLoaded reads the last ticks persisted by the previous leader, unless
+// they were read since the adapter was promoted.
+func (l *lastFires) ensureLoaded(ctx context.Context) error {
+	l.mu.Lock()
+	loaded := l.loaded
+	l.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	return l.load(ctx)
+}
+
+// reset persists the last ticks and forgets them, as the adapter stopped
+// leading and the next leader fires the next ticks. They are read again when
+// the adapter is promoted.
+func (l *lastFires) reset(ctx context.Context) error {
+	err := l.flush(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fires = make(map[string]time.Time)
+	l.dirty = false
+	l.loaded = false
+	return err
+}
+
+// fire records the tick of the PingSource scheduled at the given time. It
+// returns false if the tick, or a later one, was already fired.
+func (l *lastFires) fire(key string, scheduled time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if last, ok := l.fires[key]; ok && !scheduled.After(last) {
+		return false
+	}
+	l.fires[key] = scheduled
+	l.dirty = true
+	return true
+}
+
+// flush persists the last ticks, if any was fired since the last flush.
+func (l *lastFires) flush(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.dirty {
+		l.mu.Unlock()
+		return nil
+	}
+	cutoff := l.now().Add(-lastFiresRetention)
+	fires := make(map[string]time.Time, len(l.fires))
+	for key, scheduled := range l.fires {
+		if scheduled.Before(cutoff) {
+			delete(l.fires, key)
+			continue
+		}
+		fires[key] = scheduled
+	}
+	l.dirty = false
+	l.mu.Unlock()
+
+	if err := l.write(ctx, fires); err != nil {
+		l.mu.Lock()
+		l.dirty = true
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write persists the fires, merged with the ones already persisted, e.g. by
+// the adapter leading before, and bounded to maxSize.
+func (l *lastFires) write(ctx context.Context, fires map[string]time.Time) error {
+	renewTime := metav1.NewMicroTime(l.now())
+	lease, err := l.leases.Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		raw, err := l.encode(fires)
+		if err != nil {
+			return err
+		}
+		_, err = l.leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        LastFiresLeaseName,
+				Annotations: map[string]string{LastFiresAnnotation: raw},
+			},
+			Spec: coordinationv1.LeaseSpec{RenewTime: &renewTime},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create lease %s: %w", LastFiresLeaseName, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get lease %s: %w", LastFiresLeaseName, err)
+	}
+
+	persisted, err := parseLastFires(lease)
+	if err != nil {
+		l.logger.Warnw("Overwriting the unreadable last ticks", zap.Error(err))
+	}
+	cutoff := l.now().Add(-lastFiresRetention)
+	for key, scheduled := range persisted {
+		if scheduled.Before(cutoff) {
+			delete(persisted, key)
+		}
+	}
+	mergeLastFires(persisted, fires)
+	raw, err := l.encode(persisted)
+	if err != nil {
+		return err
+	}
+
+	lease = lease.DeepCopy()
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string, 1)
+	}
+	lease.Annotations[LastFiresAnnotation] = raw
+	lease.Spec.RenewTime = &renewTime
+	if _, err := l.leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update lease %s: %w", LastFiresLeaseName, err)
+	}
+	return nil
+}
+
+// encode returns the fires as a JSON object of at most maxSize bytes. The
+// latest fires are kept when they don't all fit, the others may fire again
+// after a restart.
+func (l *lastFires) encode(fires map[string]time.Time) (string, error) {
+	raw, err := json.Marshal(fires)
+	if err != nil || len(raw) <= l.maxSize {
+		return string(raw), err
+	}
+
+	keys := make([]string, 0, len(fires))
+	for key := range fires {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fires[keys[i]].After(fires[keys[j]])
+	})
+
+	kept := make(map[string]time.Time, len(keys))
+	size := len("{}")
+	for _, key := range keys {
+		entry, err := json.Marshal(map[string]time.Time{key: fires[key]})
+		if err != nil {
+			return "", err
+		}
+		// the entry without its braces, and its separator
+		size += len(entry) - len("{}") + len(",")
+		if size > l.maxSize {
+			break
+		}
+		kept[key] = fires[key]
+	}
+	l.logger.Warnw("Too many PingSources to persist all their last ticks, the earliest ticks may fire again after a restart",
+		zap.Int("persisted", len(kept)), zap.Int("total", len(fires)))
+
+	raw, err = json.Marshal(kept)
+	return string(raw), err
+}
+
+// parseLastFires returns the fires persisted in the lease.
+func parseLastFires(lease *coordinationv1.Lease) (map[string]time.Time, error) {
+	fires := make(map[string]time.Time)
+	if raw, ok := lease.Annotations[LastFiresAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &fires); err != nil {
+			return make(map[string]time.Time), fmt.Errorf("failed to parse annotation %s: %w", LastFiresAnnotation, err)
+		}
+	}
+	return fires, nil
+}
+
+// mergeLastFires merges the fires from into the fires into, keeping the latest
+// tick of each PingSource.
+func mergeLastFires(into, from map[string]time.Time) {
+	for key, scheduled := range from {
+		if scheduled.After(into[key]) {
+			into[key] = scheduled
+		}
+	}
+}
+
+// run flushes the last ticks periodically, until the stop channel is closed.
+func (l *lastFires) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(lastFiresFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := l.flush(context.Background()); err != nil {
+				l.logger.Warnw("Failed to persist the last ticks", zap.Error(err))
+			}
+		}
+	}
+}
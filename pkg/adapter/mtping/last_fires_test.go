@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func TestLastFires(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	fires := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	fires.now = func() time.Time { return now }
+
+	// nothing persisted yet
+	require.NoError(t, fires.load(ctx))
+	require.True(t, fires.fire("ns/ping", now))
+	require.False(t, fires.fire("ns/ping", now), "the same tick must not fire twice")
+	require.True(t, fires.fire("ns/ping", now.Add(time.Minute)))
+	require.True(t, fires.fire("ns/old", now.Add(-2*time.Hour)))
+	require.NoError(t, fires.flush(ctx))
+
+	lease, err := kubeclient.Get(ctx).CoordinationV1().Leases("knative-eventing").Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ns/ping":"2024-05-01T12:01:00Z"}`, lease.Annotations[LastFiresAnnotation])
+
+	// a restarted adapter skips the ticks fired before the restart
+	restarted := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	restarted.now = fires.now
+	require.NoError(t, restarted.load(ctx))
+	require.False(t, restarted.fire("ns/ping", now.Add(time.Minute)))
+	require.True(t, restarted.fire("ns/ping", now.Add(2*time.Minute)))
+	require.True(t, restarted.fire("ns/other", now.Add(time.Minute)))
+	require.NoError(t, restarted.flush(ctx))
+
+	lease, err = kubeclient.Get(ctx).CoordinationV1().Leases("knative-eventing").Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ns/ping":"2024-05-01T12:02:00Z","ns/other":"2024-05-01T12:01:00Z"}`, lease.Annotations[LastFiresAnnotation])
+}
+
+func TestLastFiresReloadedOnPromotion(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// the passive replica started while the other replica was leading
+	passive := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	passive.now = func() time.Time { return now }
+	require.NoError(t, passive.ensureLoaded(ctx))
+
+	leader := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	leader.now = passive.now
+	require.NoError(t, leader.ensureLoaded(ctx))
+	require.True(t, leader.fire("ns/ping", now))
+	require.NoError(t, leader.reset(ctx))
+
+	// the passive replica is demoted and promoted again, it must not fire the
+	// tick fired by the leader in between
+	require.NoError(t, passive.reset(ctx))
+	require.NoError(t, passive.ensureLoaded(ctx))
+	require.False(t, passive.fire("ns/ping", now))
+	require.True(t, passive.fire("ns/ping", now.Add(time.Minute)))
+}
+
+func TestLastFiresMergedOnFlush(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	demoted := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	demoted.now = func() time.Time { return now }
+	leader := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	leader.now = demoted.now
+
+	require.True(t, leader.fire("ns/ping", now.Add(time.Minute)))
+	require.NoError(t, leader.flush(ctx))
+	// a tick of the demoted adapter still running doesn't overwrite the ticks
+	// fired by the leader
+	require.True(t, demoted.fire("ns/ping", now))
+	require.True(t, demoted.fire("ns/other", now))
+	require.NoError(t, demoted.flush(ctx))
+
+	lease, err := kubeclient.Get(ctx).CoordinationV1().Leases("knative-eventing").Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ns/ping":"2024-05-01T12:01:00Z","ns/other":"2024-05-01T12:00:00Z"}`, lease.Annotations[LastFiresAnnotation])
+}
+
+func TestLastFiresBounded(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	fires := newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	fires.now = func() time.Time { return now }
+	fires.maxSize = 1024
+
+	const sources = 100
+	for i := 0; i < sources; i++ {
+		require.True(t, fires.fire(fmt.Sprintf("ns/ping-%03d", i), now.Add(-time.Duration(i)*time.Second)))
+	}
+	require.NoError(t, fires.flush(ctx))
+
+	lease, err := kubeclient.Get(ctx).CoordinationV1().Leases("knative-eventing").Get(ctx, LastFiresLeaseName, metav1.GetOptions{})
+	require.NoError(t, err)
+	raw := lease.Annotations[LastFiresAnnotation]
+	require.LessOrEqual(t, len(raw), fires.maxSize)
+
+	persisted := make(map[string]time.Time)
+	require.NoError(t, json.Unmarshal([]byte(raw), &persisted))
+	require.NotEmpty(t, persisted)
+	require.Less(t, len(persisted), sources)
+	// the latest ticks are kept
+	for i := 0; i < len(persisted); i++ {
+		require.Contains(t, persisted, fmt.Sprintf("ns/ping-%03d", i))
+	}
+}
+
+func TestRunnerSkipsTicksFiredBeforeRestart(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+
+	var received atomic.Int32
+	h, _ := eventsAccumulator()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		h.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+	url, _ := apis.ParseURL(s.URL)
+
+	src := &sourcesv1.PingSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-name", Namespace: "test-ns"},
+		Spec:       sourcesv1.PingSourceSpec{Schedule: "* * * * * *"},
+		Status:     sourcesv1.PingSourceStatus{SourceStatus: duckv1.SourceStatus{SinkURI: url}},
+	}
+
+	runner := NewCronJobsRunner(adapter.ClientConfig{}, kubeclient.Get(ctx), logger, cron.WithSeconds())
+	runner.lastFires = newLastFires(kubeclient.Get(ctx), "knative-eventing", logger)
+	// the previous adapter fired the ticks of the next few seconds already
+	runner.lastFires.fire("test-ns/test-name", time.Now().Add(3*time.Second))
+	runner.AddSchedule(src)
+
+	stopCh := make(chan struct{})
+	go runner.Start(stopCh)
+	defer func() {
+		close(stopCh)
+		runner.Stop()
+	}()
+
+	time.Sleep(2 * time.Second)
+	require.Zero(t, received.Load(), "ticks fired before the restart must be skipped")
+
+	require.Eventually(t, func() bool {
+		return received.Load() > 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+// fireCounts counts the events delivered per PingSource with MaxFires. The
+// PingSources are keyed by UID, so that a recreated PingSource starts over.
+type fireCounts struct {
+	mu     sync.Mutex
+	counts map[types.UID]*fireCount
+}
+
+type fireCount struct {
+	delivered int32
+	inFlight  int32
+}
+
+func newFireCounts() *fireCounts {
+	return &fireCounts{counts: make(map[types.UID]*fireCount)}
+}
+
+// observe updates the count of the PingSource with the count of its status,
+// which may be ahead of the count of a restarted adapter, and returns the
+// count.
+func (c *fireCounts) observe(src *sourcesv1.PingSource) int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := c.countFor(src.UID)
+	if src.Status.Fires > count.delivered {
+		count.delivered = src.Status.Fires
+	}
+	return count.delivered
+}
+
+// reserve reserves the delivery of an event, it returns false if the events
+// delivered and in flight already reach max.
+func (c *fireCounts) reserve(uid types.UID, max int32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := c.countFor(uid)
+	if count.delivered+count.inFlight >= max {
+		return false
+	}
+	count.inFlight++
+	return true
+}
+
+// release releases a reservation and counts the event if it was delivered. It
+// returns the count.
+func (c *fireCounts) release(uid types.UID, delivered bool) int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := c.countFor(uid)
+	count.inFlight--
+	if delivered {
+		count.delivered++
+	}
+	return count.delivered
+}
+
+// countFor returns the count of the PingSource. The caller must hold mu.
+func (c *fireCounts) countFor(uid types.UID) *fireCount {
+	count, ok := c.counts[uid]
+	if !ok {
+		count = &fireCount{}
+		c.counts[uid] = count
+	}
+	return count
+}
+
+// updateFires patches the count of the PingSource in its status, and marks it
+// Completed once it reached MaxFires. The patch is conditional on the version
+// of the PingSource it was computed from, and computed again on conflicts, so
+// that concurrent updates of the status aren't overwritten.
+func (a *cronJobsRunner) updateFires(ctx context.Context, src *sourcesv1.PingSource, fires int32) error {
+	if a.sources == nil {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := a.sources.PingSources(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get PingSource: %w", err)
+		}
+		if current.UID != src.UID || current.Status.Fires >= fires {
+			return nil
+		}
+
+		status := current.Status.DeepCopy()
+		status.Fires = fires
+		patch := map[string]interface{}{"fires": fires}
+		if current.Spec.MaxFires != nil && fires >= *current.Spec.MaxFires {
+			status.MarkCompleted(fires)
+			patch["conditions"] = status.Conditions
+		} else if status.IsCompleted() {
+			// MaxFires was raised since the PingSource completed
+			status.ClearCompleted()
+			patch["conditions"] = status.Conditions
+		}
+
+		raw, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": current.ResourceVersion,
+			},
+			"status": patch,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = a.sources.PingSources(src.Namespace).Patch(ctx, src.Name, types.MergePatchType, raw, metav1.PatchOptions{}, "status")
+		if err != nil {
+			// keep the conflicts detectable by RetryOnConflict
+			return fmt.Errorf("failed to patch PingSource status: %w", err)
+		}
+		return nil
+	})
+}
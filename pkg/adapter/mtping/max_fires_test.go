@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/logging"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	fakeeventingclientset "knative.dev/eventing/pkg/client/clientset/versioned/fake"
+	fakeeventingclient "knative.dev/eventing/pkg/client/injection/client/fake"
+)
+
+func TestFireCounts(t *testing.T) {
+	counts := newFireCounts()
+	src := &sourcesv1.PingSource{ObjectMeta: metav1.ObjectMeta{UID: "uid"}}
+
+	require.Zero(t, counts.observe(src))
+	require.True(t, counts.reserve("uid", 2))
+	require.True(t, counts.reserve("uid", 2))
+	require.False(t, counts.reserve("uid", 2), "the events in flight must count")
+
+	require.Equal(t, int32(0), counts.release("uid", false))
+	require.True(t, counts.reserve("uid", 2), "failed deliveries must not count")
+	require.Equal(t, int32(1), counts.release("uid", true))
+	require.Equal(t, int32(2), counts.release("uid", true))
+	require.False(t, counts.reserve("uid", 2))
+
+	// the status of a restarted adapter is ahead of its count
+	restarted := newFireCounts()
+	src.Status.Fires = 2
+	require.Equal(t, int32(2), restarted.observe(src))
+	require.False(t, restarted.reserve("uid", 2))
+	require.True(t, restarted.reserve("uid", 3))
+}
+
+func TestRunnerMaxFires(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	logger := logging.FromContext(ctx)
+
+	var received atomic.Int32
+	h, _ := eventsAccumulator()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		h.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+	url, _ := apis.ParseURL(s.URL)
+
+	src := &sourcesv1.PingSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-name", Namespace: "test-ns", UID: "test-uid"},
+		Spec: sourcesv1.PingSourceSpec{
+			Schedule: "* * * * * *",
+			MaxFires: pointer.Int32(2),
+		},
+		Status: sourcesv1.PingSourceStatus{SourceStatus: duckv1.SourceStatus{SinkURI: url}},
+	}
+	sources := fakeeventingclient.Get(ctx).SourcesV1()
+	_, err := sources.PingSources(src.Namespace).Create(ctx, src, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	runner := NewCronJobsRunner(adapter.ClientConfig{}, kubeclient.Get(ctx), logger, cron.WithSeconds())
+	runner.sources = sources
+	id := runner.AddSchedule(src)
+
+	stopCh := make(chan struct{})
+	go runner.Start(stopCh)
+	defer func() {
+		close(stopCh)
+		runner.Stop()
+	}()
+
+	require.Eventually(t, func() bool {
+		got, err := sources.PingSources(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
+		return err == nil && got.Status.IsCompleted()
+	}, 10*time.Second, 100*time.Millisecond)
+
+	got, err := sources.PingSources(src.Namespace).Get(ctx, src.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), got.Status.Fires)
+	require.Equal(t, cron.Entry{}, runner.cron.Entry(id), "the schedule must be removed")
+
+	time.Sleep(2 * time.Second)
+	require.Equal(t, int32(2), received.Load())
+
+	// a completed PingSource isn't scheduled again
+	require.Equal(t, cron.EntryID(-1), runner.AddSchedule(got))
+}
+
+func TestUpdateFiresConflict(t *testing.T) {
+	src := &sourcesv1.PingSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-name", Namespace: "test-ns", UID: "test-uid", ResourceVersion: "1"},
+		Spec:       sourcesv1.PingSourceSpec{MaxFires: pointer.Int32(2)},
+	}
+	client := fakeeventingclientset.NewSimpleClientset(src)
+
+	// the status is updated concurrently before the first patch
+	var versions []string
+	client.PrependReactor("patch", "pingsources", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		var patch struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}
+		if err := json.Unmarshal(action.(clientgotesting.PatchAction).GetPatch(), &patch); err != nil {
+			return true, nil, err
+		}
+		versions = append(versions, patch.Metadata.ResourceVersion)
+		if len(versions) == 1 {
+			return true, nil, apierrs.NewConflict(sourcesv1.Resource("pingsources"), src.Name, nil)
+		}
+		return false, nil, nil
+	})
+
+	runner := &cronJobsRunner{sources: client.SourcesV1()}
+	require.NoError(t, runner.updateFires(context.Background(), src, 2))
+	require.Equal(t, []string{"1", "1"}, versions)
+
+	got, err := client.SourcesV1().PingSources(src.Namespace).Get(context.Background(), src.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), got.Status.Fires)
+	require.True(t, got.Status.IsCompleted())
+}
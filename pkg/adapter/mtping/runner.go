@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -28,14 +29,17 @@ import (
 	"github.com/robfig/cron/v3"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
 
 	"knative.dev/eventing/pkg/adapter/v2"
 	kncloudevents "knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/eventing/pkg/adapter/v2/sinkbinding"
 	"knative.dev/eventing/pkg/adapter/v2/util/crstatusevent"
 	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+	sourcesv1client "knative.dev/eventing/pkg/client/clientset/versioned/typed/sources/v1"
 	"knative.dev/eventing/pkg/observability"
 )
 
@@ -44,6 +48,9 @@ type CronJobRunner interface {
 	Stop()
 	AddSchedule(source *sourcesv1.PingSource) cron.EntryID
 	RemoveSchedule(id cron.EntryID)
+	// Demote is called when the adapter stopped leading, once the schedules
+	// are removed.
+	Demote()
 }
 
 type cronJobsRunner struct {
@@ -57,68 +64,95 @@ type cronJobsRunner struct {
 	kubeClient kubernetes.Interface
 
 	clientConfig kncloudevents.ClientConfig
+
+	// lastFires skips the ticks already fired before a restart, if not nil
+	lastFires *lastFires
+
+	// sources updates the count of events delivered in the status of the
+	// PingSources with MaxFires, if not nil
+	sources sourcesv1client.PingSourcesGetter
+	fires   *fireCounts
 }
 
 const (
 	resourceGroup = "pingsources.sources.knative.dev"
 )
 
+// ScheduleParser parses the schedules of the PingSources, whose seconds are
+// optional.
+var ScheduleParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
 func NewCronJobsRunner(cfg adapter.ClientConfig, kubeClient kubernetes.Interface, logger *zap.SugaredLogger, opts ...cron.Option) *cronJobsRunner {
 	return &cronJobsRunner{
 		cron:         *cron.New(opts...),
 		Logger:       logger,
 		kubeClient:   kubeClient,
 		clientConfig: cfg,
+		fires:        newFireCounts(),
 	}
 }
 
 func (a *cronJobsRunner) AddSchedule(source *sourcesv1.PingSource) cron.EntryID {
+	if source.Spec.MaxFires != nil && a.fires.observe(source) >= *source.Spec.MaxFires {
+		// the PingSource completed, its schedule isn't added again
+		return -1
+	}
+
+	if a.lastFires != nil {
+		// the ticks fired by the previous leader must be known before the
+		// first tick after the adapter was promoted
+		if err := a.lastFires.ensureLoaded(context.Background()); err != nil {
+			a.Logger.Warnw("Failed to load the last ticks, ticks fired by the previous leader may fire again", zap.Error(err))
+		}
+	}
+
 	event, err := makeEvent(source)
 	if err != nil {
 		a.Logger.Error("failed to makeEvent: ", zap.Error(err))
 	}
 
+	binding, err := a.newPingSourceBinding(source)
+	if err != nil {
+		a.Logger.Desugar().Error("Failed to create client",
+			zap.String("name", source.GetName()),
+			zap.String("namespace", source.GetNamespace()),
+			zap.Error(err),
+		)
+		return -1
+	}
+
 	ctx := context.Background()
-	ctx = cloudevents.ContextWithTarget(ctx, source.Status.SinkURI.String())
 
 	var kubeEventSink record.EventSink = &typedcorev1.EventSinkImpl{Interface: a.kubeClient.CoreV1().Events(source.Namespace)}
 	ctx = crstatusevent.ContextWithCRStatus(ctx, &kubeEventSink, "ping-source-mt-adapter", source, a.Logger.Infof)
 
-	// Simple retry configuration to be less than 1mn.
-	// We might want to retry more times for less-frequent schedule.
-	ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, 50*time.Millisecond, 5)
-
-	metricTag := &kncloudevents.MetricTag{
-		Namespace:     source.Namespace,
-		Name:          source.Name,
-		ResourceGroup: resourceGroup,
-	}
-
 	// See https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/trace/semantic_conventions/messaging.md#span-name
 	spanName := source.Status.SinkURI.String() + " send"
 
-	ctx = observability.WithSpanData(ctx, spanName, int(trace.SpanKindProducer),
-		observability.K8sAttributes(source.Name, source.Namespace, sourcesv1.Resource("pingsource").String()))
+	// Every tick starts its own trace, linked to the PingSource, so that the
+	// traces of the pings are separable.
+	k8sAttributes := observability.K8sAttributes(source.Name, source.Namespace, sourcesv1.Resource("pingsource").String())
+	ctx = observability.WithRootSpanData(ctx, spanName, int(trace.SpanKindProducer), k8sAttributes,
+		observability.ResourceLink(source.UID, k8sAttributes...))
 
-	schedule := source.Spec.Schedule
-	if source.Spec.Timezone != "" {
-		schedule = "CRON_TZ=" + source.Spec.Timezone + " " + schedule
-	}
+	ctx = binding.Context(ctx)
+	client := binding.Client()
 
-	ctx = kncloudevents.ContextWithMetricTag(ctx, metricTag)
+	entry := &scheduledEntry{}
+	id, _ := a.AddJob(source.Spec.Schedule, source.Spec.Timezone, a.cronTick(ctx, client, source, event, entry))
+	entry.set(id)
+	return id
+}
 
-	client, err := a.newPingSourceClient(source)
-	if err != nil {
-		a.Logger.Desugar().Error("Failed to create client",
-			zap.String("name", source.GetName()),
-			zap.String("namespace", source.GetNamespace()),
-			zap.Error(err),
-		)
-		return -1
+// AddJob runs the job on the schedule, evaluated in the timezone if not
+// empty, until it is removed with RemoveSchedule.
+func (a *cronJobsRunner) AddJob(schedule, timezone string, job func()) (cron.EntryID, error) {
+	if timezone != "" {
+		schedule = "CRON_TZ=" + timezone + " " + schedule
 	}
-
-	id, _ := a.cron.AddFunc(schedule, a.cronTick(ctx, client, source, event))
-	return id
+	return a.cron.AddFunc(schedule, job)
 }
 
 func (a *cronJobsRunner) RemoveSchedule(id cron.EntryID) {
@@ -126,6 +160,11 @@ func (a *cronJobsRunner) RemoveSchedule(id cron.EntryID) {
 }
 
 func (a *cronJobsRunner) Start(stopCh <-chan struct{}) {
+	if a.lastFires != nil {
+		// the ticks fired before are loaded by the first AddSchedule, once
+		// the adapter leads
+		go a.lastFires.run(stopCh)
+	}
 	a.cron.Start()
 	<-stopCh
 }
@@ -136,12 +175,66 @@ func (a *cronJobsRunner) Stop() {
 		// Wait for all jobs to be done.
 		<-ctx.Done()
 	}
+	if a.lastFires != nil {
+		if err := a.lastFires.flush(context.Background()); err != nil {
+			a.Logger.Warnw("Failed to persist the last ticks", zap.Error(err))
+		}
+	}
+}
+
+// Demote forgets the last ticks, so that they are loaded again when the
+// adapter is promoted, as the next leader fires the ticks in between.
+func (a *cronJobsRunner) Demote() {
+	if a.lastFires != nil {
+		if err := a.lastFires.reset(context.Background()); err != nil {
+			a.Logger.Warnw("Failed to persist the last ticks", zap.Error(err))
+		}
+	}
+}
+
+// scheduledEntry is the entry of a schedule, which is only known once the
+// schedule was added.
+type scheduledEntry struct {
+	mu sync.Mutex
+	id cron.EntryID
+}
+
+func (e *scheduledEntry) set(id cron.EntryID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.id = id
+}
+
+func (e *scheduledEntry) get() cron.EntryID {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.id
+}
+
+// alreadyFired returns whether the current tick of the entry was already fired,
+// e.g. by the adapter running before a restart.
+func (a *cronJobsRunner) alreadyFired(src *sourcesv1.PingSource, entry *scheduledEntry) bool {
+	if a.lastFires == nil {
+		return false
+	}
+	// the scheduled time of the running tick, zero when the job isn't run by
+	// the cron
+	scheduled := a.cron.Entry(entry.get()).Prev
+	if scheduled.IsZero() {
+		return false
+	}
+	return !a.lastFires.fire(src.Namespace+"/"+src.Name, scheduled)
 }
 
-func (a *cronJobsRunner) cronTick(ctx context.Context, client kncloudevents.Client, src *sourcesv1.PingSource, event cloudevents.Event) func() {
+func (a *cronJobsRunner) cronTick(ctx context.Context, client kncloudevents.Client, src *sourcesv1.PingSource, event cloudevents.Event, entry *scheduledEntry) func() {
 	target := src.Status.SinkURI.String()
 
 	return func() {
+		if a.alreadyFired(src, entry) {
+			a.Logger.Debugw("Skipping tick fired before the restart", zap.String("namespace", src.Namespace), zap.String("name", src.Name))
+			return
+		}
+
 		event := event.Clone()
 		event.SetID(uuid.New().String()) // provide an ID here so we can track it with logging
 		defer a.Logger.Debug("Finished sending cloudevent id: ", event.ID())
@@ -150,22 +243,55 @@ func (a *cronJobsRunner) cronTick(ctx context.Context, client kncloudevents.Clie
 		// Provide a delay so not all ping fired instantaneously distribute load on resources.
 		time.Sleep(time.Duration(rand.Intn(500)) * time.Millisecond) //nolint:gosec // Cryptographic randomness not necessary here.
 
+		if src.Spec.MaxFires != nil && !a.fires.reserve(src.UID, *src.Spec.MaxFires) {
+			// the events delivered and in flight already reach MaxFires
+			return
+		}
+
 		a.Logger.Debugf("sending cloudevent id: %s, source: %s, target: %s", event.ID(), source, target)
 
-		if result := client.Send(ctx, event); !cloudevents.IsACK(result) {
+		result := client.Send(ctx, event)
+		if !cloudevents.IsACK(result) {
 			// Exhausted number of retries. Event is lost.
 			a.Logger.Error("failed to send cloudevent result: ", zap.Any("result", result),
 				zap.String("source", source), zap.String("target", src.Status.SinkURI.String()), zap.String("id", event.ID()))
 		}
+		if src.Spec.MaxFires != nil {
+			a.countFire(src, entry, cloudevents.IsACK(result))
+		}
 
 		client.CloseIdleConnections()
 	}
 }
 
+// countFire counts the tick of a PingSource with MaxFires, and removes its
+// schedule once MaxFires events were delivered.
+func (a *cronJobsRunner) countFire(src *sourcesv1.PingSource, entry *scheduledEntry, delivered bool) {
+	fires := a.fires.release(src.UID, delivered)
+	if !delivered {
+		return
+	}
+	if fires >= *src.Spec.MaxFires {
+		a.Logger.Infow("PingSource delivered its maximum number of events, removing its schedule",
+			zap.String("namespace", src.Namespace), zap.String("name", src.Name), zap.Int32("maxFires", *src.Spec.MaxFires))
+		a.cron.Remove(entry.get())
+	}
+	if err := a.updateFires(context.Background(), src, fires); err != nil {
+		a.Logger.Warnw("Failed to update the number of events delivered", zap.String("namespace", src.Namespace),
+			zap.String("name", src.Name), zap.Error(err))
+	}
+}
+
 func makeEvent(source *sourcesv1.PingSource) (cloudevents.Event, error) {
 	event := cloudevents.NewEvent()
-	event.SetType(sourcesv1.PingSourceEventType)
+	event.SetType(sourcesv1.PingSourceType(source.Spec.TypeSuffix))
 	event.SetSource(sourcesv1.PingSourceSource(source.Namespace, source.Name))
+	if source.Spec.Subject != "" {
+		event.SetSubject(source.Spec.Subject)
+	}
+	for key, value := range source.Spec.Extensions {
+		event.SetExtension(key, value)
+	}
 	if source.Spec.CloudEventOverrides != nil && source.Spec.CloudEventOverrides.Extensions != nil {
 		for key, override := range source.Spec.CloudEventOverrides.Extensions {
 			event.SetExtension(key, override)
@@ -188,40 +314,35 @@ func makeEvent(source *sourcesv1.PingSource) (cloudevents.Event, error) {
 	return event, nil
 }
 
-func (a *cronJobsRunner) newPingSourceClient(source *sourcesv1.PingSource) (adapter.Client, error) {
-	var env adapter.EnvConfig
-	if a.clientConfig.Env != nil {
-		env = adapter.EnvConfig{
-			Namespace:      source.GetNamespace(),
-			Name:           a.clientConfig.Env.GetName(),
-			EnvSinkTimeout: fmt.Sprintf("%d", a.clientConfig.Env.GetSinktimeout()),
-			Audience:       source.Status.SinkAudience,
-		}
-
-		if source.Status.Auth != nil {
-			env.OIDCServiceAccountName = source.Status.Auth.ServiceAccountName
-		}
+// newPingSourceBinding returns the binding sending the events of the
+// PingSource to its sink.
+func (a *cronJobsRunner) newPingSourceBinding(source *sourcesv1.PingSource) (*sinkbinding.Binding, error) {
+	sink, err := sinkbinding.SinkFromStatus(source.Status.SourceStatus)
+	if err != nil {
+		return nil, err
 	}
 
-	env.Sink = source.Status.SinkURI.String()
-	env.CACerts = source.Status.SinkCACerts
-
 	a.Logger.Debugw("Creating client",
 		"namespace", source.Namespace,
 		"name", source.Name,
-		"env", env,
+		"sink", sink.URI,
 		"source", source,
 	)
 
-	cfg := adapter.ClientConfig{
-		Env:                        &env,
-		CeOverrides:                source.Spec.CloudEventOverrides,
-		Reporter:                   a.clientConfig.Reporter,
-		CrStatusEventClient:        a.clientConfig.CrStatusEventClient,
-		Options:                    a.clientConfig.Options,
-		TrustBundleConfigMapLister: a.clientConfig.TrustBundleConfigMapLister,
-		TokenProvider:              a.clientConfig.TokenProvider,
+	var serviceAccountName *string
+	if source.Status.Auth != nil {
+		serviceAccountName = source.Status.Auth.ServiceAccountName
 	}
 
-	return adapter.NewClient(cfg)
+	return sinkbinding.New(sinkbinding.Config{
+		Source:             types.NamespacedName{Namespace: source.Namespace, Name: source.Name},
+		ResourceGroup:      resourceGroup,
+		Sink:               sink,
+		ServiceAccountName: serviceAccountName,
+		CeOverrides:        source.Spec.CloudEventOverrides,
+		// Simple retry configuration to be less than 1mn.
+		// We might want to retry more times for less-frequent schedule.
+		Retry:  &sinkbinding.Retry{Count: 5, BackoffDelay: 50 * time.Millisecond},
+		Client: a.clientConfig,
+	})
 }
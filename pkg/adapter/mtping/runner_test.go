@@ -308,6 +308,35 @@ func TestSendEventsTLS(t *testing.T) {
 	require.Equal(t, sourcesv1.PingSourceSource("test-ns", "test-name1"), event.Source())
 }
 
+func TestMakeEventAttributes(t *testing.T) {
+	src := &sourcesv1.PingSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-name",
+			Namespace: "test-ns",
+		},
+		Spec: sourcesv1.PingSourceSpec{
+			SourceSpec: duckv1.SourceSpec{
+				CloudEventOverrides: &duckv1.CloudEventOverrides{
+					Extensions: map[string]string{"team": "override"},
+				},
+			},
+			Schedule:   "* * * * ?",
+			TypeSuffix: "billing",
+			Subject:    "invoices",
+			Extensions: map[string]string{"team": "billing", "tier": "gold"},
+		},
+	}
+
+	event, err := makeEvent(src)
+	require.NoError(t, err)
+
+	require.Equal(t, "dev.knative.sources.ping.billing", event.Type())
+	require.Equal(t, sourcesv1.PingSourceSource("test-ns", "test-name"), event.Source())
+	require.Equal(t, "invoices", event.Subject())
+	require.Equal(t, "override", event.Extensions()["team"])
+	require.Equal(t, "gold", event.Extensions()["tier"])
+}
+
 func TestStartStopCron(t *testing.T) {
 	ctx, _ := rectesting.SetupFakeContext(t)
 	logger := logging.FromContext(ctx)
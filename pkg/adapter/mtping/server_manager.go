@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/system"
+
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	secretinformer "knative.dev/pkg/injection/clients/namespacedkube/informers/core/v1/secret"
+
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+const (
+	// healthPort is the port of the plain HTTP health server.
+	healthPort = 8080
+	// healthPortHTTPS is the port of the TLS health server.
+	healthPortHTTPS = 8443
+)
+
+// NewServerManager returns a ServerManager serving the adapter health endpoints
+// over HTTP and HTTPS, following the transport-encryption feature flag.
+func NewServerManager(ctx context.Context, logger *zap.SugaredLogger, cmw configmap.Watcher, httpPort, httpsPort int) (*eventingtls.ServerManager, error) {
+	tlsConfig, err := getServerTLSConfig(ctx)
+	if err != nil {
+		logger.Infow("failed to get TLS server config", zap.Error(err))
+	}
+
+	httpReceiver := kncloudevents.NewHTTPEventReceiver(httpPort)
+	httpsReceiver := kncloudevents.NewHTTPEventReceiver(httpsPort, kncloudevents.WithTLSConfig(tlsConfig))
+
+	return eventingtls.NewServerManager(ctx, httpReceiver, httpsReceiver, healthHandler(), cmw)
+}
+
+// healthHandler responds OK on the health endpoints. Kubelet probes are answered by
+// the receiver drainer before reaching this handler.
+func healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	mux.HandleFunc("/healthz", ok)
+	mux.HandleFunc("/readyz", ok)
+	return mux
+}
+
+func getServerTLSConfig(ctx context.Context) (*tls.Config, error) {
+	secret := types.NamespacedName{
+		Namespace: system.Namespace(),
+		Name:      eventingtls.PingSourceAdapterServerTLSSecretName,
+	}
+
+	// The namespaced secret informer only watches the system namespace, where
+	// the adapter is allowed to read the secrets.
+	serverTLSConfig := eventingtls.NewDefaultServerConfig()
+	serverTLSConfig.GetCertificate = eventingtls.GetCertificateFromSecret(ctx, secretinformer.Get(ctx), kubeclient.Get(ctx), secret)
+	return eventingtls.GetTLSServerConfig(serverTLSConfig)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mtping
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/logging"
+	rectesting "knative.dev/pkg/reconciler/testing"
+	"knative.dev/pkg/system"
+
+	_ "knative.dev/pkg/client/injection/kube/client/fake"
+	secretinformer "knative.dev/pkg/injection/clients/namespacedkube/informers/core/v1/secret/fake"
+
+	"knative.dev/eventing/pkg/apis/feature"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/eventingtls/eventingtlstesting"
+)
+
+func TestHealthHandler(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		wantCode int
+	}{
+		"liveness": {
+			path:     "/healthz",
+			wantCode: http.StatusOK,
+		},
+		"readiness": {
+			path:     "/readyz",
+			wantCode: http.StatusOK,
+		},
+		"unknown path": {
+			path:     "/unknown",
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			healthHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if resp.Code != tc.wantCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.wantCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestServerManager(t *testing.T) {
+	testCases := map[string]struct {
+		transportEncryption feature.Flag
+		wantHTTPCode        int
+	}{
+		"disabled: health served over http": {
+			transportEncryption: feature.Disabled,
+			wantHTTPCode:        http.StatusOK,
+		},
+		"strict: health not served over http": {
+			transportEncryption: feature.Strict,
+			wantHTTPCode:        http.StatusNotFound,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ctx, _ := rectesting.SetupFakeContext(t)
+			ctx, cancel := context.WithCancel(ctx)
+
+			httpPort, httpsPort := freePort(t), freePort(t)
+			sm, err := NewServerManager(ctx, logging.FromContext(ctx), newFeatureWatcher(tc.transportEncryption), httpPort, httpsPort)
+			if err != nil {
+				t.Fatal("NewServerManager() =", err)
+			}
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- sm.StartServers(ctx)
+			}()
+			defer func() {
+				cancel()
+				if err := <-errs; err != nil {
+					t.Error("StartServers() =", err)
+				}
+			}()
+
+			var code int
+			err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+				resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/healthz", httpPort))
+				if err != nil {
+					// not listening yet
+					return false, nil
+				}
+				resp.Body.Close()
+				code = resp.StatusCode
+				return true, nil
+			})
+			if err != nil {
+				t.Fatal("Health server didn't start:", err)
+			}
+			if code != tc.wantHTTPCode {
+				t.Errorf("Unexpected status code, want %d, got %d", tc.wantHTTPCode, code)
+			}
+		})
+	}
+}
+
+func TestGetServerTLSConfig(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	// The secret is read from the system namespace only.
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: system.Namespace(),
+			Name:      eventingtls.PingSourceAdapterServerTLSSecretName,
+		},
+		Data: map[string][]byte{
+			eventingtls.TLSCrt: eventingtlstesting.Crt,
+			eventingtls.TLSKey: eventingtlstesting.Key,
+		},
+	}
+	if err := secretinformer.Get(ctx).Informer().GetIndexer().Add(secret); err != nil {
+		t.Fatal("Add =", err)
+	}
+
+	tlsConfig, err := getServerTLSConfig(ctx)
+	if err != nil {
+		t.Fatal("getServerTLSConfig() =", err)
+	}
+	cert, err := tlsConfig.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal("GetCertificate() =", err)
+	}
+	if cert == nil {
+		t.Error("Expected the certificate of the secret")
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to find a free port:", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newFeatureWatcher(transportEncryption feature.Flag) configmap.Watcher {
+	return configmap.NewStaticWatcher(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: feature.FlagsConfigName,
+		},
+		Data: map[string]string{
+			feature.TransportEncryption: string(transportEncryption),
+		},
+	})
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package poller implements a source adapter which periodically polls an
+// HTTP endpoint and sends the responses as CloudEvents to its sink.
+//
+// The adapter is configured through environment variables and is meant to be
+// run by a ContainerSource or bound to its sink by a SinkBinding.
+package poller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing/pkg/adapter/mtping"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+const (
+	// ModeBody sends the whole response body as the data of a single event.
+	ModeBody = "body"
+	// ModeJSONItems expects the response body to be a JSON array and sends
+	// one event per array item.
+	ModeJSONItems = "json-items"
+
+	// DefaultEventType is the type of the events sent when no type is
+	// configured or found in the response.
+	DefaultEventType = "dev.knative.sources.poll"
+
+	// maxResponseSize bounds the size of the response bodies read from the
+	// polled endpoint.
+	maxResponseSize = 10 << 20
+)
+
+type envConfig struct {
+	adapter.EnvConfig
+
+	// URL is the HTTP endpoint to poll.
+	URL string `envconfig:"POLL_URL" required:"true"`
+
+	// Schedule is the cron schedule at which the endpoint is polled, it
+	// accepts the same syntax as PingSource schedules.
+	Schedule string `envconfig:"POLL_SCHEDULE" default:"* * * * *"`
+
+	// Timezone is the timezone the schedule is evaluated in.
+	Timezone string `envconfig:"POLL_TIMEZONE"`
+
+	// Headers are added to the poll requests.
+	Headers map[string]string `envconfig:"POLL_HEADERS"`
+
+	// Timeout bounds the duration of a single poll request.
+	Timeout time.Duration `envconfig:"POLL_TIMEOUT" default:"30s"`
+
+	// Mode is either ModeBody or ModeJSONItems.
+	Mode string `envconfig:"POLL_MODE" default:"body"`
+
+	// EventType is the type of the sent events.
+	EventType string `envconfig:"POLL_EVENT_TYPE"`
+
+	// EventSource is the source of the sent events, it defaults to the
+	// polled URL.
+	EventSource string `envconfig:"POLL_EVENT_SOURCE"`
+
+	// TypeField, SourceField, SubjectField and IDField name the top-level
+	// fields of JSON items whose values override the corresponding event
+	// attributes.
+	TypeField    string `envconfig:"POLL_TYPE_FIELD"`
+	SourceField  string `envconfig:"POLL_SOURCE_FIELD"`
+	SubjectField string `envconfig:"POLL_SUBJECT_FIELD"`
+	IDField      string `envconfig:"POLL_ID_FIELD"`
+
+	// Retries is the number of times sending an event is retried.
+	Retries int `envconfig:"POLL_RETRIES" default:"5"`
+}
+
+// jobRunner runs jobs on cron schedules, see mtping.NewCronJobsRunner.
+type jobRunner interface {
+	AddJob(schedule, timezone string, job func()) (cron.EntryID, error)
+	Start(stopCh <-chan struct{})
+	Stop()
+}
+
+type pollerAdapter struct {
+	ce         cloudevents.Client
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+	config     *envConfig
+	// runner polls the endpoint on the schedule, like the PingSource adapter
+	// sends its events.
+	runner jobRunner
+}
+
+var _ adapter.Adapter = (*pollerAdapter)(nil)
+
+func NewEnvConfig() adapter.EnvConfigAccessor {
+	return &envConfig{}
+}
+
+func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+	logger := logging.FromContext(ctx)
+	env := processed.(*envConfig)
+
+	a, err := newPollerAdapter(env, ceClient, logger)
+	if err != nil {
+		logger.Fatalw("Failed to create the poller adapter", zap.Error(err))
+	}
+	return a
+}
+
+func newPollerAdapter(env *envConfig, ceClient cloudevents.Client, logger *zap.SugaredLogger) (*pollerAdapter, error) {
+	if env.Mode != ModeBody && env.Mode != ModeJSONItems {
+		return nil, fmt.Errorf("unsupported poll mode %q, must be one of %q or %q", env.Mode, ModeBody, ModeJSONItems)
+	}
+
+	return &pollerAdapter{
+		ce:         ceClient,
+		httpClient: &http.Client{Timeout: env.Timeout},
+		logger:     logger,
+		config:     env,
+		// the runner only runs the poll job, it doesn't send the events
+		runner: mtping.NewCronJobsRunner(adapter.ClientConfig{}, nil, logger, cron.WithParser(mtping.ScheduleParser)),
+	}, nil
+}
+
+// Start implements adapter.Adapter
+func (a *pollerAdapter) Start(ctx context.Context) error {
+	if _, err := a.runner.AddJob(a.config.Schedule, a.config.Timezone, func() { a.poll(ctx) }); err != nil {
+		return fmt.Errorf("invalid poll schedule %q: %w", a.config.Schedule, err)
+	}
+
+	a.logger.Infow("Starting poller", zap.String("url", a.config.URL), zap.String("schedule", a.config.Schedule))
+	a.runner.Start(ctx.Done())
+
+	// Wait for the in-flight poll to finish.
+	a.runner.Stop()
+	a.logger.Info("Poller stopped")
+	return nil
+}
+
+// poll fetches the endpoint once and sends the resulting events.
+func (a *pollerAdapter) poll(ctx context.Context) {
+	contentType, body, err := a.fetch(ctx)
+	if err != nil {
+		a.logger.Errorw("Failed to poll endpoint", zap.String("url", a.config.URL), zap.Error(err))
+		return
+	}
+
+	events, err := a.makeEvents(contentType, body)
+	if err != nil {
+		a.logger.Errorw("Failed to convert response into events", zap.String("url", a.config.URL), zap.Error(err))
+		return
+	}
+
+	// Retry with the same backoff as the PingSource adapter.
+	ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, 50*time.Millisecond, a.config.Retries)
+	for _, event := range events {
+		if result := a.ce.Send(ctx, event); !cloudevents.IsACK(result) {
+			// Exhausted number of retries. Event is lost.
+			a.logger.Errorw("Failed to send cloudevent", zap.Any("result", result),
+				zap.String("source", event.Source()), zap.String("id", event.ID()))
+		}
+	}
+}
+
+func (a *pollerAdapter) fetch(ctx context.Context) (string, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.config.URL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	for k, v := range a.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", nil, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(body) > maxResponseSize {
+		return "", nil, fmt.Errorf("response body exceeds %d bytes", maxResponseSize)
+	}
+	return resp.Header.Get("Content-Type"), body, nil
+}
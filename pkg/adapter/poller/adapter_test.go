@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestNewPollerAdapterValidation(t *testing.T) {
+	tests := map[string]struct {
+		env     envConfig
+		wantErr bool
+	}{
+		"valid": {
+			env: envConfig{Schedule: "* * * * *", Mode: ModeBody},
+		},
+		"valid with timezone": {
+			env: envConfig{Schedule: "*/10 * * * *", Timezone: "Europe/Berlin", Mode: ModeJSONItems},
+		},
+		"invalid schedule": {
+			env:     envConfig{Schedule: "every minute", Mode: ModeBody},
+			wantErr: true,
+		},
+		"invalid mode": {
+			env:     envConfig{Schedule: "* * * * *", Mode: "xml"},
+			wantErr: true,
+		},
+	}
+
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			env := tc.env
+			a, err := newPollerAdapter(&env, adaptertest.NewTestClient(), zap.NewNop().Sugar())
+			if err == nil {
+				// the schedule is added, and validated, as the adapter starts
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				err = a.Start(ctx)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("newPollerAdapter() and Start() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	tests := map[string]struct {
+		env         envConfig
+		status      int
+		contentType string
+		body        string
+		wantTypes   []string
+		wantSources []string
+		wantIDs     []string
+	}{
+		"whole body": {
+			env:         envConfig{Mode: ModeBody},
+			status:      http.StatusOK,
+			contentType: "text/plain",
+			body:        "hello",
+			wantTypes:   []string{DefaultEventType},
+		},
+		"whole body with configured type and source": {
+			env:         envConfig{Mode: ModeBody, EventType: "com.example.poll", EventSource: "/example"},
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"hello":"world"}`,
+			wantTypes:   []string{"com.example.poll"},
+			wantSources: []string{"/example"},
+		},
+		"json items with field mapping": {
+			env:         envConfig{Mode: ModeJSONItems, TypeField: "kind", IDField: "id"},
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `[{"kind":"created","id":1},{"id":"b"}]`,
+			wantTypes:   []string{"created", DefaultEventType},
+			wantIDs:     []string{"1", "b"},
+		},
+		"json items not an array": {
+			env:    envConfig{Mode: ModeJSONItems},
+			status: http.StatusOK,
+			body:   `{"kind":"created"}`,
+		},
+		"error status": {
+			env:    envConfig{Mode: ModeBody},
+			status: http.StatusInternalServerError,
+			body:   "boom",
+		},
+	}
+
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer token" {
+					t.Errorf("expected Authorization header to be set, got %q", got)
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer s.Close()
+
+			env := tc.env
+			env.URL = s.URL
+			env.Schedule = "* * * * *"
+			env.Timeout = time.Second
+			env.Headers = map[string]string{"Authorization": "Bearer token"}
+
+			ce := adaptertest.NewTestClient()
+			a, err := newPollerAdapter(&env, ce, zap.NewNop().Sugar())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a.poll(context.Background())
+
+			sent := ce.Sent()
+			if len(sent) != len(tc.wantTypes) {
+				t.Fatalf("expected %d events, got %d", len(tc.wantTypes), len(sent))
+			}
+			for i, e := range sent {
+				if e.Type() != tc.wantTypes[i] {
+					t.Errorf("event %d: expected type %q, got %q", i, tc.wantTypes[i], e.Type())
+				}
+				wantSource := s.URL
+				if tc.wantSources != nil {
+					wantSource = tc.wantSources[i]
+				}
+				if e.Source() != wantSource {
+					t.Errorf("event %d: expected source %q, got %q", i, wantSource, e.Source())
+				}
+				if tc.wantIDs != nil && e.ID() != tc.wantIDs[i] {
+					t.Errorf("event %d: expected id %q, got %q", i, tc.wantIDs[i], e.ID())
+				}
+				if tc.env.Mode == ModeBody && string(e.Data()) != tc.body {
+					t.Errorf("event %d: expected data %q, got %q", i, tc.body, string(e.Data()))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+)
+
+// makeEvents converts a response body into the events to send.
+func (a *pollerAdapter) makeEvents(contentType string, body []byte) ([]cloudevents.Event, error) {
+	if a.config.Mode == ModeBody {
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		event := a.newEvent()
+		if err := event.SetData(contentType, body); err != nil {
+			return nil, err
+		}
+		return []cloudevents.Event{event}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("response body is not a JSON array: %w", err)
+	}
+
+	events := make([]cloudevents.Event, 0, len(items))
+	for i, item := range items {
+		event := a.newEvent()
+		if err := a.mapAttributes(&event, item); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		if err := event.SetData(cloudevents.ApplicationJSON, []byte(item)); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (a *pollerAdapter) newEvent() cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+
+	event.SetType(DefaultEventType)
+	if a.config.EventType != "" {
+		event.SetType(a.config.EventType)
+	}
+	event.SetSource(a.config.URL)
+	if a.config.EventSource != "" {
+		event.SetSource(a.config.EventSource)
+	}
+	return event
+}
+
+// mapAttributes overrides the event attributes with the values of the
+// configured fields of a JSON item. Missing fields are ignored.
+func (a *pollerAdapter) mapAttributes(event *cloudevents.Event, item json.RawMessage) error {
+	if a.config.TypeField == "" && a.config.SourceField == "" && a.config.SubjectField == "" && a.config.IDField == "" {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(item))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil {
+		return fmt.Errorf("item is not a JSON object: %w", err)
+	}
+
+	if v, ok := stringField(fields, a.config.TypeField); ok {
+		event.SetType(v)
+	}
+	if v, ok := stringField(fields, a.config.SourceField); ok {
+		event.SetSource(v)
+	}
+	if v, ok := stringField(fields, a.config.SubjectField); ok {
+		event.SetSubject(v)
+	}
+	if v, ok := stringField(fields, a.config.IDField); ok {
+		event.SetID(v)
+	}
+	return nil
+}
+
+func stringField(fields map[string]interface{}, name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	switch v := fields[name].(type) {
+	case string:
+		return v, v != ""
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
@@ -23,9 +23,9 @@ import (
 	"net"
 	nethttp "net/http"
 	"net/url"
+	"sync"
 	"time"
 
-	"k8s.io/apimachinery/pkg/types"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"knative.dev/pkg/network"
 
@@ -38,6 +38,7 @@ import (
 	"github.com/cloudevents/sdk-go/v2/protocol/http"
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opentelemetry.io/otel/metric"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/tracing/propagation/tracecontextb3"
 
@@ -55,6 +56,11 @@ type closeIdler interface {
 type Client interface {
 	cloudevents.Client
 	closeIdler
+
+	// SetCredentialProvider sets the provider of the credentials of the
+	// requests sent by the client, replacing the previous one. A nil provider
+	// sends the requests without credentials.
+	SetCredentialProvider(provider CredentialProvider)
 }
 
 var newClientHTTPObserved = NewClientHTTPObserved
@@ -119,6 +125,13 @@ type ClientConfig struct {
 	Options             []http.Option
 	TokenProvider       *auth.OIDCTokenProvider
 
+	// Heartbeat, when set, records the results of the sent events.
+	Heartbeat *Heartbeat
+
+	// MeterProvider, when set, records the metrics of the sent events with
+	// OpenTelemetry instead of the Reporter, see source.NewOTelStatsReporter.
+	MeterProvider metric.MeterProvider
+
 	TrustBundleConfigMapLister corev1listers.ConfigMapNamespaceLister
 }
 
@@ -203,19 +216,29 @@ func NewClient(cfg ClientConfig) (Client, error) {
 		return nil, err
 	}
 
+	reporter := cfg.Reporter
+	if cfg.MeterProvider != nil {
+		reporter, err = source.NewOTelStatsReporter(cfg.MeterProvider)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	client := &client{
 		ceClient:            ceClient,
 		closeIdler:          transport.Base.(*nethttp.Transport),
 		ceOverrides:         ceOverrides,
-		reporter:            cfg.Reporter,
+		reporter:            reporter,
 		crStatusEventClient: cfg.CrStatusEventClient,
-		oidcTokenProvider:   cfg.TokenProvider,
+		heartbeat:           cfg.Heartbeat,
 		scheme:              "http",
 	}
 
 	if cfg.Env != nil {
 		client.audience = cfg.Env.GetAudience()
-		client.oidcServiceAccountName = cfg.Env.GetOIDCServiceAccountName()
+		if serviceAccount := cfg.Env.GetOIDCServiceAccountName(); serviceAccount != nil && cfg.TokenProvider != nil {
+			client.credentialProvider = NewOIDCCredentialProvider(cfg.TokenProvider, *serviceAccount)
+		}
 		sinkURI := cfg.Env.GetSink()
 		if sinkURI != "" {
 			parsedUrl, err := url.Parse(sinkURI)
@@ -242,54 +265,59 @@ func setTimeOut(duration time.Duration) http.Option {
 }
 
 type client struct {
-	ceClient               cloudevents.Client
-	ceOverrides            *duckv1.CloudEventOverrides
-	reporter               source.StatsReporter
-	crStatusEventClient    *crstatusevent.CRStatusEventClient
-	closeIdler             closeIdler
-	scheme                 string
-	oidcTokenProvider      *auth.OIDCTokenProvider
-	audience               *string
-	oidcServiceAccountName *types.NamespacedName
+	ceClient            cloudevents.Client
+	ceOverrides         *duckv1.CloudEventOverrides
+	reporter            source.StatsReporter
+	crStatusEventClient *crstatusevent.CRStatusEventClient
+	heartbeat           *Heartbeat
+	closeIdler          closeIdler
+	scheme              string
+	audience            *string
+
+	credentialProviderMu sync.RWMutex
+	credentialProvider   CredentialProvider
 }
 
 func (c *client) CloseIdleConnections() {
 	c.closeIdler.CloseIdleConnections()
 }
 
+// SetCredentialProvider implements Client.SetCredentialProvider
+func (c *client) SetCredentialProvider(provider CredentialProvider) {
+	c.credentialProviderMu.Lock()
+	defer c.credentialProviderMu.Unlock()
+	c.credentialProvider = provider
+}
+
 var _ cloudevents.Client = (*client)(nil)
 
 // Send implements client.Send
 func (c *client) Send(ctx context.Context, out event.Event) protocol.Result {
 	c.applyOverrides(&out)
-	var err error
 
-	if c.audience != nil && c.oidcServiceAccountName != nil {
-		ctx, err = c.withAuthHeader(ctx)
-		if err != nil {
-			return err
-		}
+	ctx, err := c.withCredentials(ctx)
+	if err != nil {
+		return err
 	}
 
 	res := c.ceClient.Send(ctx, out)
 	c.reportMetrics(ctx, out, res)
+	c.recordHeartbeat(res)
 	return res
 }
 
 // Request implements client.Request
 func (c *client) Request(ctx context.Context, out event.Event) (*event.Event, protocol.Result) {
 	c.applyOverrides(&out)
-	var err error
 
-	if c.audience != nil && c.oidcServiceAccountName != nil {
-		ctx, err = c.withAuthHeader(ctx)
-		if err != nil {
-			return nil, err
-		}
+	ctx, err := c.withCredentials(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, res := c.ceClient.Request(ctx, out)
 	c.reportMetrics(ctx, out, res)
+	c.recordHeartbeat(res)
 	return resp, res
 }
 
@@ -306,6 +334,12 @@ func (c *client) applyOverrides(event *cloudevents.Event) {
 	}
 }
 
+func (c *client) recordHeartbeat(result protocol.Result) {
+	if c.heartbeat != nil {
+		c.heartbeat.Record(result)
+	}
+}
+
 func (c *client) reportMetrics(ctx context.Context, event cloudevents.Event, result protocol.Result) {
 	if c.reporter == nil {
 		return
@@ -367,6 +401,28 @@ func (c *client) reportError(reportArgs *source.ReportArgs, result protocol.Resu
 	c.reporter.ReportEventCount(reportArgs, 0)
 }
 
+type audienceKey struct{}
+
+type audienceOverride struct {
+	audience *string
+}
+
+// ContextWithAudience returns a copy of parent context in which the OIDC
+// audience of the requests is audience, overriding the audience of the sink.
+// This is used when the requests target another destination than the sink,
+// a nil audience sends the requests without an OIDC token.
+func ContextWithAudience(ctx context.Context, audience *string) context.Context {
+	return context.WithValue(ctx, audienceKey{}, audienceOverride{audience: audience})
+}
+
+// audienceFor returns the OIDC audience of the requests sent with ctx.
+func (c *client) audienceFor(ctx context.Context) *string {
+	if override, ok := ctx.Value(audienceKey{}).(audienceOverride); ok {
+		return override.audience
+	}
+	return c.audience
+}
+
 // MetricTag context
 type MetricTag struct {
 	Name          string
@@ -416,19 +472,34 @@ func tracecontextMiddleware(h nethttp.Handler) nethttp.Handler {
 	}
 }
 
-// When OIDC is enabled, withAuthHeader will request the JWT token from the tokenProvider and append it to every request
-// it has interaction with, if source's OIDC service account (source.Status.Auth.ServiceAccountName) and destination's
-// audience are present.
-func (c *client) withAuthHeader(ctx context.Context) (context.Context, error) {
-	// Request the JWT token for the given service account
-	jwt, err := c.oidcTokenProvider.GetJWT(*c.oidcServiceAccountName, *c.audience)
+// withCredentials asks the credential provider for the credentials of the
+// audience of the request and adds them to the headers of the request. By
+// default, the credential provider adds the JWT of the source's OIDC service
+// account (source.Status.Auth.ServiceAccountName), if it's present.
+func (c *client) withCredentials(ctx context.Context) (context.Context, error) {
+	c.credentialProviderMu.RLock()
+	provider := c.credentialProvider
+	c.credentialProviderMu.RUnlock()
+	if provider == nil {
+		return ctx, nil
+	}
+
+	var audience string
+	if a := c.audienceFor(ctx); a != nil {
+		audience = *a
+	}
+	credentials, err := provider.Credentials(ctx, audience)
 	if err != nil {
-		return ctx, protocol.NewResult("Failed when appending the Authorization header to the outgoing request %w", err)
+		return ctx, protocol.NewResult("Failed when adding the credentials to the outgoing request %w", err)
+	}
+	if len(credentials) == 0 {
+		return ctx, nil
 	}
 
-	// Appending the auth token to the outgoing request
 	headers := http.HeaderFrom(ctx)
-	headers.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+	for key, values := range credentials {
+		headers[nethttp.CanonicalHeaderKey(key)] = values
+	}
 	ctx = http.WithCustomHeader(ctx, headers)
 
 	return ctx, nil
@@ -18,7 +18,9 @@ package adapter
 
 import (
 	"context"
+	"errors"
 	nethttp "net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"testing"
@@ -29,6 +31,7 @@ import (
 	"github.com/cloudevents/sdk-go/v2/protocol/http"
 	cetest "github.com/cloudevents/sdk-go/v2/test"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric/noop"
 	"k8s.io/utils/pointer"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 
@@ -401,3 +404,101 @@ func validateMetric(t *testing.T, reporter source.StatsReporter, want int, wantR
 		t.Errorf("Expected %d for metric, got %d", want, mockReporter.retryEventCount)
 	}
 }
+
+func TestContextWithAudience(t *testing.T) {
+	sinkAudience := "sink"
+	dlsAudience := "dls"
+	c := &client{audience: &sinkAudience}
+
+	if got := c.audienceFor(context.Background()); got == nil || *got != sinkAudience {
+		t.Errorf("audienceFor() = %v, want %q", got, sinkAudience)
+	}
+	if got := c.audienceFor(ContextWithAudience(context.Background(), &dlsAudience)); got == nil || *got != dlsAudience {
+		t.Errorf("audienceFor() = %v, want %q", got, dlsAudience)
+	}
+	if got := c.audienceFor(ContextWithAudience(context.Background(), nil)); got != nil {
+		t.Errorf("audienceFor() = %q, want nil", *got)
+	}
+}
+
+func TestSetCredentialProvider(t *testing.T) {
+	received := make(chan nethttp.Header, 10)
+	server := httptest.NewServer(nethttp.HandlerFunc(func(writer nethttp.ResponseWriter, request *nethttp.Request) {
+		received <- request.Header
+		writer.WriteHeader(nethttp.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	sinkAudience := "sink"
+	c, err := NewCloudEventsClientCRStatus(&EnvConfig{Sink: server.URL, Audience: &sinkAudience}, &mockReporter{}, nil)
+	assert.Nil(t, err)
+	t.Cleanup(c.CloseIdleConnections)
+
+	c.SetCredentialProvider(CredentialProviderFunc(func(_ context.Context, audience string) (nethttp.Header, error) {
+		if audience == "fail" {
+			return nil, errors.New("no credentials")
+		}
+		return nethttp.Header{"X-Api-Key": []string{"key-for-" + audience}}, nil
+	}))
+
+	event := cetest.MinEvent()
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantKey string
+		wantErr bool
+	}{{
+		name:    "sink audience",
+		ctx:     context.Background(),
+		wantKey: "key-for-sink",
+	}, {
+		name:    "audience from context",
+		ctx:     ContextWithAudience(context.Background(), pointer.String("dls")),
+		wantKey: "key-for-dls",
+	}, {
+		name:    "no audience",
+		ctx:     ContextWithAudience(context.Background(), nil),
+		wantKey: "key-for-",
+	}, {
+		name:    "provider error",
+		ctx:     ContextWithAudience(context.Background(), pointer.String("fail")),
+		wantErr: true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := c.Send(tc.ctx, event)
+			if tc.wantErr {
+				assert.False(t, cloudevents.IsACK(result), "result = %v", result)
+				assert.Len(t, received, 0)
+				return
+			}
+			assert.True(t, cloudevents.IsACK(result), "result = %v", result)
+			assert.Equal(t, tc.wantKey, (<-received).Get("X-Api-Key"))
+		})
+	}
+
+	c.SetCredentialProvider(nil)
+	assert.True(t, cloudevents.IsACK(c.Send(context.Background(), event)))
+	assert.Empty(t, (<-received).Get("X-Api-Key"))
+}
+
+func TestNewClientWithMeterProvider(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(writer nethttp.ResponseWriter, _ *nethttp.Request) {
+		writer.WriteHeader(nethttp.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	reporter := &mockReporter{}
+	c, err := NewClient(ClientConfig{
+		Env:           &EnvConfig{Sink: server.URL},
+		Reporter:      reporter,
+		MeterProvider: noop.NewMeterProvider(),
+	})
+	assert.Nil(t, err)
+	t.Cleanup(c.CloseIdleConnections)
+
+	result := c.Send(context.Background(), cetest.MinEvent())
+	assert.True(t, cloudevents.IsACK(result), "result = %v", result)
+	assert.NotNil(t, c.(*client).reporter)
+	assert.Zero(t, reporter.eventCount, "the OpenCensus reporter must not be used with a MeterProvider")
+}
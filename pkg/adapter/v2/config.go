@@ -50,6 +50,8 @@ const (
 	EnvConfigTracingConfig        = "K_TRACING_CONFIG"
 	EnvConfigLeaderElectionConfig = "K_LEADER_ELECTION_CONFIG"
 	EnvSinkTimeout                = "K_SINK_TIMEOUT"
+	EnvConfigHeartbeatSink        = "K_HEARTBEAT_SINK"
+	EnvConfigHeartbeatInterval    = "K_HEARTBEAT_INTERVAL"
 )
 
 // EnvConfig is the minimal set of configuration parameters
@@ -107,6 +109,13 @@ type EnvConfig struct {
 	// Time in seconds to wait for sink to respond
 	EnvSinkTimeout string `envconfig:"K_SINK_TIMEOUT"`
 
+	// HeartbeatSink is the URI heartbeat events are sent to. Heartbeats are
+	// disabled when empty.
+	HeartbeatSink string `envconfig:"K_HEARTBEAT_SINK"`
+
+	// HeartbeatInterval is the interval at which heartbeat events are sent.
+	HeartbeatInterval time.Duration `envconfig:"K_HEARTBEAT_INTERVAL" default:"30s"`
+
 	// cached zap logger
 	logger *zap.SugaredLogger
 }
@@ -222,6 +231,41 @@ func (e *EnvConfig) GetSinktimeout() int {
 	return -1
 }
 
+// heartbeatEnvConfig is implemented by configurations supporting heartbeats.
+type heartbeatEnvConfig interface {
+	GetHeartbeatSink() string
+	GetHeartbeatInterval() time.Duration
+}
+
+var _ heartbeatEnvConfig = (*EnvConfig)(nil)
+
+// heartbeatEnv is the configuration of the client sending the heartbeats, the
+// configuration of the adapter with the heartbeat sink as its sink.
+type heartbeatEnv struct {
+	EnvConfigAccessor
+	sink string
+}
+
+func (e *heartbeatEnv) GetSink() string {
+	return e.sink
+}
+
+// GetAudience returns no audience, so that the tokens for the sink of the
+// adapter are not sent to the heartbeat sink.
+func (e *heartbeatEnv) GetAudience() *string {
+	return nil
+}
+
+// GetHeartbeatSink returns the URI heartbeat events are sent to.
+func (e *EnvConfig) GetHeartbeatSink() string {
+	return e.HeartbeatSink
+}
+
+// GetHeartbeatInterval returns the interval at which heartbeat events are sent.
+func (e *EnvConfig) GetHeartbeatInterval() time.Duration {
+	return e.HeartbeatInterval
+}
+
 func (e *EnvConfig) SetupTracing(logger *zap.SugaredLogger) (tracing.Tracer, error) {
 	config, err := tracingconfig.JSONToTracingConfig(e.TracingConfigJson)
 	if err != nil {
@@ -240,3 +240,25 @@ func TestCACerts(t *testing.T) {
 		})
 	}
 }
+
+func TestHeartbeatEnv(t *testing.T) {
+	t.Setenv("K_SINK", "https://sink")
+	t.Setenv("K_AUDIENCE", "sink-audience")
+	t.Setenv("K_CA_CERTS", "certs")
+
+	var env myEnvConfig
+	if err := envconfig.Process("", &env); err != nil {
+		t.Error("Expected no error:", err)
+	}
+
+	hb := &heartbeatEnv{EnvConfigAccessor: &env, sink: "https://heartbeats"}
+	if got := hb.GetSink(); got != "https://heartbeats" {
+		t.Errorf("Expected the heartbeat sink, got %q", got)
+	}
+	if got := hb.GetAudience(); got != nil {
+		t.Errorf("Expected no audience, got %q", *got)
+	}
+	if got := hb.GetCACerts(); got == nil || *got != "certs" {
+		t.Errorf("Expected the CA certs of the adapter, got %v", got)
+	}
+}
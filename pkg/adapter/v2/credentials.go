@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+
+	"k8s.io/apimachinery/pkg/types"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+// CredentialProvider provides the credentials of the requests a Client sends,
+// as headers added to the requests, e.g. an Authorization header. It's
+// consulted before each request with the audience of the target, which is
+// empty if the target has no audience.
+type CredentialProvider interface {
+	Credentials(ctx context.Context, audience string) (nethttp.Header, error)
+}
+
+// CredentialProviderFunc is a function implementing CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context, audience string) (nethttp.Header, error)
+
+// Credentials implements CredentialProvider.
+func (f CredentialProviderFunc) Credentials(ctx context.Context, audience string) (nethttp.Header, error) {
+	return f(ctx, audience)
+}
+
+// NewOIDCCredentialProvider returns a CredentialProvider authenticating the
+// requests with a JWT of the given service account for the audience of the
+// target. Requests to targets without an audience are sent without a JWT.
+func NewOIDCCredentialProvider(tokenProvider *auth.OIDCTokenProvider, serviceAccount types.NamespacedName) CredentialProvider {
+	withToken := kncloudevents.WithOIDCToken(tokenProvider, serviceAccount)
+	return CredentialProviderFunc(func(_ context.Context, audience string) (nethttp.Header, error) {
+		if audience == "" {
+			return nil, nil
+		}
+
+		req := &nethttp.Request{Header: nethttp.Header{}}
+		if err := withToken(req, duckv1.Addressable{Audience: &audience}); err != nil {
+			return nil, fmt.Errorf("failed to get JWT for audience %q: %w", audience, err)
+		}
+
+		return req.Header, nil
+	})
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeartbeatEventType is the type of the heartbeat events emitted by
+	// adapters.
+	HeartbeatEventType = "dev.knative.sources.adapter.heartbeat"
+
+	defaultHeartbeatInterval = 30 * time.Second
+
+	// maxHealthyFailureRatio is the ratio of failed events above which an
+	// adapter is unhealthy.
+	maxHealthyFailureRatio = 0.5
+)
+
+// HeartbeatData is the data of heartbeat events. It captures the outcome of
+// the events sent by the adapter since the previous heartbeat, so that the
+// control plane can tell whether the data plane is able to deliver events.
+type HeartbeatData struct {
+	Component string `json:"component"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Sent is the number of events sent successfully.
+	Sent int64 `json:"sent"`
+	// Failed is the number of events which could not be sent.
+	Failed int64 `json:"failed"`
+	// SuccessRate is the ratio of successfully sent events, it is 1 when no
+	// events were sent.
+	SuccessRate float64 `json:"successRate"`
+}
+
+// Healthy reports whether the adapter was able to send most of its events
+// since the previous heartbeat, that is at most half of them failed. An
+// adapter which did not send any event is healthy.
+func (d HeartbeatData) Healthy() bool {
+	total := d.Sent + d.Failed
+	if total == 0 {
+		return true
+	}
+	return float64(d.Failed)/float64(total) <= maxHealthyFailureRatio
+}
+
+// Heartbeat counts the results of the events sent by an adapter and
+// periodically reports them as heartbeat events.
+type Heartbeat struct {
+	component string
+	namespace string
+	name      string
+
+	sent   atomic.Int64
+	failed atomic.Int64
+}
+
+// NewHeartbeat creates a Heartbeat with no recorded results for the adapter
+// identified by component, namespace and name.
+func NewHeartbeat(component, namespace, name string) *Heartbeat {
+	return &Heartbeat{
+		component: component,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Record records the result of sending an event.
+func (h *Heartbeat) Record(result protocol.Result) {
+	if cloudevents.IsACK(result) {
+		h.sent.Add(1)
+	} else {
+		h.failed.Add(1)
+	}
+}
+
+// snapshot returns the results recorded since the previous snapshot.
+func (h *Heartbeat) snapshot() (int64, int64) {
+	return h.sent.Swap(0), h.failed.Swap(0)
+}
+
+// Run emits a heartbeat event to the client target every interval, until
+// the context is done.
+func (h *Heartbeat) Run(ctx context.Context, ceClient cloudevents.Client, interval time.Duration, logger *zap.SugaredLogger) {
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			event, err := h.makeEvent()
+			if err != nil {
+				logger.Errorw("Failed to create heartbeat event", zap.Error(err))
+				continue
+			}
+			if result := ceClient.Send(ctx, event); !cloudevents.IsACK(result) {
+				logger.Warnw("Failed to send heartbeat event", zap.Any("result", result))
+			}
+		}
+	}
+}
+
+func (h *Heartbeat) makeEvent() (cloudevents.Event, error) {
+	sent, failed := h.snapshot()
+
+	data := HeartbeatData{
+		Component:   h.component,
+		Namespace:   h.namespace,
+		Name:        h.name,
+		Sent:        sent,
+		Failed:      failed,
+		SuccessRate: 1,
+	}
+	if total := sent + failed; total > 0 {
+		data.SuccessRate = float64(sent) / float64(total)
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+	event.SetType(HeartbeatEventType)
+	event.SetSource("/apis/v1/namespaces/" + data.Namespace + "/adapters/" + data.Name)
+	event.SetTime(time.Now())
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return event, err
+	}
+	return event, nil
+}
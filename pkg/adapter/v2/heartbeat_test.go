@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestHeartbeat(t *testing.T) {
+	hb := NewHeartbeat("ping", "ns", "adapter")
+	hb.Record(cloudevents.ResultACK)
+	hb.Record(cloudevents.ResultACK)
+	hb.Record(cloudevents.ResultACK)
+	hb.Record(errors.New("boom"))
+
+	ce := test.NewTestClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hb.Run(ctx, ce, 10*time.Millisecond, zap.NewNop().Sugar())
+
+	events, err := ce.WaitForEvents(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data HeartbeatData
+	if err := events[0].DataAs(&data); err != nil {
+		t.Fatal(err)
+	}
+	if events[0].Type() != HeartbeatEventType {
+		t.Errorf("expected type %q, got %q", HeartbeatEventType, events[0].Type())
+	}
+	want := HeartbeatData{Component: "ping", Namespace: "ns", Name: "adapter", Sent: 3, Failed: 1, SuccessRate: 0.75}
+	if data != want {
+		t.Errorf("expected heartbeat %+v, got %+v", want, data)
+	}
+	if !data.Healthy() {
+		t.Error("expected heartbeat to be healthy")
+	}
+
+	// The counters are reset after each heartbeat.
+	if err := events[1].DataAs(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Sent != 0 || data.Failed != 0 || data.SuccessRate != 1 {
+		t.Errorf("expected empty heartbeat, got %+v", data)
+	}
+}
+
+func TestHeartbeatDataHealthy(t *testing.T) {
+	testCases := map[string]struct {
+		data HeartbeatData
+		want bool
+	}{
+		"idle": {
+			data: HeartbeatData{},
+			want: true,
+		},
+		"only failures": {
+			data: HeartbeatData{Failed: 2},
+			want: false,
+		},
+		"half failed": {
+			data: HeartbeatData{Sent: 2, Failed: 2},
+			want: true,
+		},
+		"most failed": {
+			data: HeartbeatData{Sent: 1, Failed: 99},
+			want: false,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := tc.data.Healthy(); got != tc.want {
+				t.Errorf("Healthy() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClientRecordsHeartbeat(t *testing.T) {
+	hb := NewHeartbeat("test", "ns", "name")
+	c := &client{
+		ceClient:  test.NewTestClient(),
+		heartbeat: hb,
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID("1")
+	event.SetType("type")
+	event.SetSource("source")
+	c.Send(context.Background(), event)
+
+	if sent, failed := hb.snapshot(); sent != 1 || failed != 0 {
+		t.Errorf("expected 1 sent and 0 failed events, got %d and %d", sent, failed)
+	}
+}
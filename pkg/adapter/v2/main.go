@@ -31,6 +31,7 @@ import (
 	"go.uber.org/zap"
 	"k8s.io/client-go/informers"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/tracing"
 
 	"knative.dev/eventing/pkg/auth"
@@ -257,6 +258,21 @@ func MainWithInformers(ctx context.Context, component string, env EnvConfigAcces
 		TokenProvider:              auth.NewOIDCTokenProvider(ctx),
 		TrustBundleConfigMapLister: trustBundleConfigMapLister,
 	}
+	if hb, ok := env.(heartbeatEnvConfig); ok && hb.GetHeartbeatSink() != "" {
+		// The heartbeats are sent with the TLS and OIDC configuration of the
+		// adapter, but without its CloudEvents overrides and statistics.
+		heartbeatClient, err := NewClient(ClientConfig{
+			Env:                        &heartbeatEnv{EnvConfigAccessor: env, sink: hb.GetHeartbeatSink()},
+			CeOverrides:                &duckv1.CloudEventOverrides{},
+			TokenProvider:              clientConfig.TokenProvider,
+			TrustBundleConfigMapLister: trustBundleConfigMapLister,
+		})
+		if err != nil {
+			logger.Fatalw("Error building heartbeat client", zap.Error(err))
+		}
+		clientConfig.Heartbeat = NewHeartbeat(component, env.GetNamespace(), env.GetName())
+		go clientConfig.Heartbeat.Run(ctx, heartbeatClient, hb.GetHeartbeatInterval(), logger)
+	}
 	ctx = withClientConfig(ctx, clientConfig)
 
 	eventsClient, err := NewClient(clientConfig)
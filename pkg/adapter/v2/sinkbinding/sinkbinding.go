@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sinkbinding sets up the sending of events by source adapters to
+// their sinks: the target and its CA certs, the OIDC tokens of the source, the
+// retries and the metrics, so that adapters sending on behalf of many sources,
+// like the PingSource and the ApiServerSource adapters, and third party
+// adapters send alike.
+package sinkbinding
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"k8s.io/apimachinery/pkg/types"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+)
+
+// Sink is a resolved sink events are sent to.
+type Sink struct {
+	// URI is the URI of the sink.
+	URI string
+	// CACerts are the PEM encoded CA certs of the sink, trusted in addition
+	// to the system and Knative trust bundles.
+	CACerts *string
+	// Audience is the OIDC audience of the sink, nil if it doesn't require
+	// authentication.
+	Audience *string
+}
+
+// SinkFromStatus returns the sink resolved in the status of a source.
+func SinkFromStatus(status duckv1.SourceStatus) (Sink, error) {
+	if status.SinkURI == nil {
+		return Sink{}, fmt.Errorf("sink is not resolved")
+	}
+	return Sink{
+		URI:      status.SinkURI.String(),
+		CACerts:  status.SinkCACerts,
+		Audience: status.SinkAudience,
+	}, nil
+}
+
+// ContextWithSink returns a copy of the context targeting the sink with its
+// OIDC audience, e.g. to send an event to another sink than the one of the
+// client. The CA certs of the sink are those of the client.
+func ContextWithSink(ctx context.Context, sink Sink) context.Context {
+	ctx = adapter.ContextWithAudience(ctx, sink.Audience)
+	return cloudevents.ContextWithTarget(ctx, sink.URI)
+}
+
+// Retry configures the retries of the events sent.
+type Retry struct {
+	// Count is the number of retries, zero disables retries.
+	Count int
+	// BackoffPolicy is the backoff policy, exponential by default.
+	BackoffPolicy eventingduckv1.BackoffPolicyType
+	// BackoffDelay is the delay of the backoff policy.
+	BackoffDelay time.Duration
+}
+
+// ContextWithRetries returns a copy of the context carrying the retries of
+// the CloudEvents client, the context itself if r is nil or has no retries.
+func (r *Retry) ContextWithRetries(ctx context.Context) context.Context {
+	if r == nil || r.Count <= 0 {
+		return ctx
+	}
+	if r.BackoffPolicy == eventingduckv1.BackoffPolicyLinear {
+		return cloudevents.ContextWithRetriesLinearBackoff(ctx, r.BackoffDelay, r.Count)
+	}
+	return cloudevents.ContextWithRetriesExponentialBackoff(ctx, r.BackoffDelay, r.Count)
+}
+
+// Config configures a Binding.
+type Config struct {
+	// Source is the namespace and the name of the source the events are sent
+	// for.
+	Source types.NamespacedName
+	// ResourceGroup is the resource group of the source, e.g.
+	// pingsources.sources.knative.dev, the metrics are tagged with.
+	ResourceGroup string
+	// Sink is the sink the events are sent to.
+	Sink Sink
+	// ServiceAccountName is the OIDC service account of the source, in its
+	// namespace. Without it, or without the TokenProvider of the Client config,
+	// the events are sent without OIDC token.
+	ServiceAccountName *string
+	// CeOverrides are applied to the events sent.
+	CeOverrides *duckv1.CloudEventOverrides
+	// Retry configures the retries, the events aren't retried if nil.
+	Retry *Retry
+	// Client is the config of the clients of the adapter, shared by its
+	// sources: its Reporter, CrStatusEventClient, Options, Heartbeat,
+	// TrustBundleConfigMapLister and TokenProvider are used, as well as the
+	// name and the sink timeout of its Env, if not nil.
+	Client adapter.ClientConfig
+}
+
+// Binding sends the events of a source to its sink.
+type Binding struct {
+	client    adapter.Client
+	sink      Sink
+	retry     *Retry
+	metricTag *adapter.MetricTag
+}
+
+// New creates the Binding of a source.
+func New(cfg Config) (*Binding, error) {
+	env := adapter.EnvConfig{
+		Namespace: cfg.Source.Namespace,
+		Sink:      cfg.Sink.URI,
+		CACerts:   cfg.Sink.CACerts,
+		Audience:  cfg.Sink.Audience,
+	}
+	if cfg.Client.Env != nil {
+		env.Name = cfg.Client.Env.GetName()
+		env.EnvSinkTimeout = strconv.Itoa(cfg.Client.Env.GetSinktimeout())
+	}
+
+	client, err := adapter.NewClient(adapter.ClientConfig{
+		Env:                        &env,
+		CeOverrides:                cfg.CeOverrides,
+		Reporter:                   cfg.Client.Reporter,
+		CrStatusEventClient:        cfg.Client.CrStatusEventClient,
+		Options:                    cfg.Client.Options,
+		Heartbeat:                  cfg.Client.Heartbeat,
+		MeterProvider:              cfg.Client.MeterProvider,
+		TrustBundleConfigMapLister: cfg.Client.TrustBundleConfigMapLister,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if cfg.ServiceAccountName != nil && cfg.Client.TokenProvider != nil {
+		client.SetCredentialProvider(adapter.NewOIDCCredentialProvider(cfg.Client.TokenProvider, types.NamespacedName{
+			Namespace: cfg.Source.Namespace,
+			Name:      *cfg.ServiceAccountName,
+		}))
+	}
+
+	return &Binding{
+		client: client,
+		sink:   cfg.Sink,
+		retry:  cfg.Retry,
+		metricTag: &adapter.MetricTag{
+			Namespace:     cfg.Source.Namespace,
+			Name:          cfg.Source.Name,
+			ResourceGroup: cfg.ResourceGroup,
+		},
+	}, nil
+}
+
+// Client returns the client of the Binding, which sends to the sink by
+// default. Events sent with it directly aren't retried, and their metrics
+// aren't tagged with the source, see Context.
+func (b *Binding) Client() adapter.Client {
+	return b.client
+}
+
+// Context returns a copy of the context targeting the sink, carrying the
+// retries and the metric tag of the source.
+func (b *Binding) Context(ctx context.Context) context.Context {
+	ctx = cloudevents.ContextWithTarget(ctx, b.sink.URI)
+	ctx = adapter.ContextWithMetricTag(ctx, b.metricTag)
+	return b.retry.ContextWithRetries(ctx)
+}
+
+// Send sends the event to the sink, see Context.
+func (b *Binding) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	return b.client.Send(b.Context(ctx), event)
+}
+
+// CloseIdleConnections closes the idle connections to the sink.
+func (b *Binding) CloseIdleConnections() {
+	b.client.CloseIdleConnections()
+}
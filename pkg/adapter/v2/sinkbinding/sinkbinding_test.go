@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinkbinding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cecontext "github.com/cloudevents/sdk-go/v2/context"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	eventingapis "knative.dev/eventing/pkg/apis"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/metrics/source"
+)
+
+func TestSinkFromStatus(t *testing.T) {
+	if _, err := SinkFromStatus(duckv1.SourceStatus{}); err == nil {
+		t.Error("Expected an error for an unresolved sink")
+	}
+
+	sink, err := SinkFromStatus(duckv1.SourceStatus{
+		SinkURI:      apis.HTTPS("sink.example.com"),
+		SinkCACerts:  ptr.String("certs"),
+		SinkAudience: ptr.String("audience"),
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if sink.URI != "https://sink.example.com" || *sink.CACerts != "certs" || *sink.Audience != "audience" {
+		t.Errorf("Unexpected sink %+v", sink)
+	}
+}
+
+func TestRetryContextWithRetries(t *testing.T) {
+	var r *Retry
+	if params := cecontext.RetriesFrom(r.ContextWithRetries(context.Background())); params.MaxTries != 0 {
+		t.Errorf("Unexpected retries without retry config %+v", params)
+	}
+
+	r = &Retry{Count: 3, BackoffPolicy: eventingduckv1.BackoffPolicyLinear, BackoffDelay: time.Second}
+	params := cecontext.RetriesFrom(r.ContextWithRetries(context.Background()))
+	if params.Strategy != cecontext.BackoffStrategyLinear || params.MaxTries != 3 || params.Period != time.Second {
+		t.Errorf("Unexpected retry params %+v", params)
+	}
+}
+
+func TestBindingSend(t *testing.T) {
+	var requests atomic.Int32
+	var namespace atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace.Store(r.Header.Get(eventingapis.KnNamespaceHeader))
+		// the first request fails, so that it's retried
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reporter := &mockReporter{}
+	binding, err := New(Config{
+		Source:        types.NamespacedName{Namespace: "ns", Name: "source"},
+		ResourceGroup: "pingsources.sources.knative.dev",
+		Sink:          Sink{URI: server.URL},
+		CeOverrides:   &duckv1.CloudEventOverrides{Extensions: map[string]string{"ext": "value"}},
+		Retry:         &Retry{Count: 2, BackoffDelay: time.Millisecond},
+		Client:        adapter.ClientConfig{Reporter: reporter},
+	})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if result := binding.Send(context.Background(), test.FullEvent()); !cloudevents.IsACK(result) {
+		t.Fatal("Unexpected result:", result)
+	}
+	binding.CloseIdleConnections()
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected the event to be retried once, got %d requests", got)
+	}
+	if got := namespace.Load(); got != "ns" {
+		t.Errorf("Unexpected namespace header %v", got)
+	}
+	if reporter.args == nil || reporter.args.Namespace != "ns" || reporter.args.Name != "source" ||
+		reporter.args.ResourceGroup != "pingsources.sources.knative.dev" {
+		t.Errorf("Unexpected metric tags %+v", reporter.args)
+	}
+}
+
+type mockReporter struct {
+	mu   sync.Mutex
+	args *source.ReportArgs
+}
+
+func (r *mockReporter) ReportEventCount(args *source.ReportArgs, _ int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.args = args
+	return nil
+}
+
+func (r *mockReporter) ReportRetryEventCount(*source.ReportArgs, int) error {
+	return nil
+}
@@ -33,6 +33,7 @@ import (
 type TestCloudEventsClient struct {
 	lock          sync.Mutex
 	sent          []cloudevents.Event
+	sentChanged   chan struct{}
 	delay         time.Duration
 	resultSend    []protocol.Result
 	resultRequest []struct {
@@ -49,17 +50,19 @@ type EventData struct {
 	Type string `json:"type"`
 }
 
-var eventData EventData
-
 // Send_AppendResult will enqueue a response for the following Send call.
 // For testing.
 func (c *TestCloudEventsClient) Send_AppendResult(r protocol.Result) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.resultSend = append(c.resultSend, r)
 }
 
 // Request_AppendResult will enqueue a response for the following Request call.
 // For testing.
 func (c *TestCloudEventsClient) Request_AppendResult(e *event.Event, r protocol.Result) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	c.resultRequest = append(c.resultRequest, struct {
 		event  *event.Event
 		result protocol.Result
@@ -73,11 +76,12 @@ func (c *TestCloudEventsClient) Send(ctx context.Context, out event.Event) proto
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	// TODO: improve later.
+	var eventData EventData
 	bytes, _ := json.Marshal(out)
 	if err := json.Unmarshal(bytes, &eventData); err != nil {
 		fmt.Printf("json unmarshal error %s:", err)
 	}
-	c.sent = append(c.sent, out)
+	c.record(out)
 	if eventData.Type == "unit.type" {
 		return http.NewResult(200, "%w", protocol.ResultACK)
 	} else if eventData.Type == "unit.retries" {
@@ -103,11 +107,12 @@ func (c *TestCloudEventsClient) Request(ctx context.Context, out event.Event) (*
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	// TODO: improve later.
+	var eventData EventData
 	bytes, _ := json.Marshal(out)
 	if err := json.Unmarshal(bytes, &eventData); err != nil {
 		fmt.Printf("json unmarshal error %s:", err)
 	}
-	c.sent = append(c.sent, out)
+	c.record(out)
 	if eventData.Type == "unit.type" {
 		return nil, http.NewResult(200, "%w", protocol.ResultACK)
 	} else if eventData.Type == "unit.retries" {
@@ -128,10 +133,25 @@ func (c *TestCloudEventsClient) StartReceiver(ctx context.Context, fn interface{
 	return nil
 }
 
+// record appends the sent event. c.lock must be held.
+func (c *TestCloudEventsClient) record(out event.Event) {
+	c.sent = append(c.sent, out)
+	c.notifySentChanged()
+}
+
+// notifySentChanged wakes up WaitForEvents callers. c.lock must be held.
+func (c *TestCloudEventsClient) notifySentChanged() {
+	if c.sentChanged != nil {
+		close(c.sentChanged)
+		c.sentChanged = nil
+	}
+}
+
 func (c *TestCloudEventsClient) Reset() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.sent = make([]cloudevents.Event, 0)
+	c.notifySentChanged()
 }
 
 func (c *TestCloudEventsClient) Sent() []cloudevents.Event {
@@ -142,6 +162,44 @@ func (c *TestCloudEventsClient) Sent() []cloudevents.Event {
 	return r
 }
 
+// WaitForEvents waits until at least n events have been sent and returns the sent
+// events, or returns an error when they haven't been sent within the timeout.
+func (c *TestCloudEventsClient) WaitForEvents(n int, timeout time.Duration) ([]cloudevents.Event, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		c.lock.Lock()
+		sent := len(c.sent)
+		if c.sentChanged == nil {
+			c.sentChanged = make(chan struct{})
+		}
+		changed := c.sentChanged
+		c.lock.Unlock()
+
+		if sent >= n {
+			return c.Sent(), nil
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return c.Sent(), fmt.Errorf("timed out after %v waiting for %d events, got %d", timeout, n, sent)
+		}
+	}
+}
+
+// EventsMatching returns the sent events for which the predicate returns true.
+func (c *TestCloudEventsClient) EventsMatching(predicate func(cloudevents.Event) bool) []cloudevents.Event {
+	var matching []cloudevents.Event
+	for _, e := range c.Sent() {
+		if predicate(e) {
+			matching = append(matching, e)
+		}
+	}
+	return matching
+}
+
 func NewTestClient() *TestCloudEventsClient {
 	return NewTestClientWithDelay(0)
 }
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestTestCloudEventsClientConcurrentSends(t *testing.T) {
+	const n = 50
+
+	c := &TestCloudEventsClient{}
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			e := cloudevents.NewEvent()
+			e.SetID(strconv.Itoa(i))
+			e.SetSource("test")
+			e.SetType("unit.type")
+			if i%2 == 0 {
+				e.SetType("unit.other")
+			}
+			c.Send(context.Background(), e)
+		}(i)
+	}
+
+	sent, err := c.WaitForEvents(n, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != n {
+		t.Errorf("Got %d events, want %d", len(sent), n)
+	}
+
+	other := c.EventsMatching(func(e cloudevents.Event) bool {
+		return e.Type() == "unit.other"
+	})
+	if len(other) != n/2 {
+		t.Errorf("Got %d matching events, want %d", len(other), n/2)
+	}
+
+	c.Reset()
+	if _, err := c.WaitForEvents(1, 10*time.Millisecond); err == nil {
+		t.Error("Want WaitForEvents to time out after Reset")
+	}
+}
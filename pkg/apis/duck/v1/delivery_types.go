@@ -68,11 +68,8 @@ type DeliverySpec struct {
 	// value only takes effect if "Retry" is configured, and also depends on specific implementations
 	// (Channels, Sources, etc.) choosing to provide this capability.
 	//
-	// Note: This API is EXPERIMENTAL and might be changed at anytime. While this experimental
-	//       feature is in the Alpha/Beta stage, you must provide a valid value to opt-in for
-	//       supporting "Retry-After" headers.  When the feature becomes Stable/GA "Retry-After"
-	//       headers will be respected by default, and you can choose to specify "PT0S" to
-	//       opt-out of supporting "Retry-After" headers.
+	// Note: "Retry-After" headers are respected by default, specify "PT0S" to opt-out of
+	//       supporting "Retry-After" headers.
 	//       For more details: https://github.com/knative/eventing/issues/5811
 	//
 	// More information on Duration format:
@@ -81,6 +78,31 @@ type DeliverySpec struct {
 	//
 	// +optional
 	RetryAfterMax *string `json:"retryAfterMax,omitempty"`
+
+	// PreferReply controls whether the sender adds the "Prefer: reply" header
+	// to the requests to the destination. Defaults to true, set it to false
+	// for destinations generating replies only because they see the header.
+	// +optional
+	PreferReply *bool `json:"preferReply,omitempty"`
+
+	// RetryPermanentErrors controls whether the requests failing with a
+	// permanent error, such as an unknown host, a certificate signed by an
+	// unknown authority or a malformed URL, are retried. Defaults to false.
+	// +optional
+	RetryPermanentErrors *bool `json:"retryPermanentErrors,omitempty"`
+
+	// FailFastOnUnknownHost controls whether the requests failing since the
+	// host of the destination doesn't exist (NXDOMAIN) are not retried.
+	// Defaults to true, set it to false when the DNS record of a new
+	// destination may not be propagated yet.
+	// +optional
+	FailFastOnUnknownHost *bool `json:"failFastOnUnknownHost,omitempty"`
+}
+
+// ShouldPreferReply returns whether the "Prefer: reply" header is sent to the
+// destination, which is the default.
+func (ds *DeliverySpec) ShouldPreferReply() bool {
+	return ds == nil || ds.PreferReply == nil || *ds.PreferReply
 }
 
 func (ds *DeliverySpec) Validate(ctx context.Context) *apis.FieldError {
@@ -156,3 +156,22 @@ func TestDeliverySpecValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestDeliverySpecShouldPreferReply(t *testing.T) {
+	tests := map[string]struct {
+		spec *DeliverySpec
+		want bool
+	}{
+		"nil spec":           {spec: nil, want: true},
+		"unset":              {spec: &DeliverySpec{}, want: true},
+		"prefer reply":       {spec: &DeliverySpec{PreferReply: pointer.Bool(true)}, want: true},
+		"don't prefer reply": {spec: &DeliverySpec{PreferReply: pointer.Bool(false)}, want: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.spec.ShouldPreferReply(); got != tc.want {
+				t.Errorf("ShouldPreferReply() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
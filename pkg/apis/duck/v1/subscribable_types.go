@@ -73,6 +73,10 @@ type SubscriberSpec struct {
 	// Auth contains the service account name for the subscription
 	// +optional
 	Auth *duckv1.AuthStatus `json:"auth,omitempty"`
+	// Ordered requests the events to be delivered to the subscriber one at
+	// a time, in the order they were received by the channel.
+	// +optional
+	Ordered bool `json:"ordered,omitempty"`
 }
 
 // SubscriberStatus defines the status of a single subscriber to a Channel.
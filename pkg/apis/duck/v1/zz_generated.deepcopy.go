@@ -201,6 +201,21 @@ func (in *DeliverySpec) DeepCopyInto(out *DeliverySpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PreferReply != nil {
+		in, out := &in.PreferReply, &out.PreferReply
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RetryPermanentErrors != nil {
+		in, out := &in.RetryPermanentErrors, &out.RetryPermanentErrors
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FailFastOnUnknownHost != nil {
+		in, out := &in.FailFastOnUnknownHost, &out.FailFastOnUnknownHost
+		*out = new(bool)
+		**out = **in
+	}
 	return
 }
 
@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"strconv"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"knative.dev/pkg/apis"
@@ -34,6 +36,11 @@ const (
 	// InjectionAnnotation is the annotation key used to enable knative eventing
 	// injection for a namespace to automatically create a broker.
 	InjectionAnnotation = "eventing.knative.dev/injection"
+
+	// MaxInFlightAnnotation is the annotation key used to limit the number of
+	// events delivered to the subscriber of the Trigger concurrently, per
+	// broker filter replica. The value is a positive integer.
+	MaxInFlightAnnotation = "delivery.knative.dev/maxInFlight"
 )
 
 // +genclient
@@ -234,3 +241,13 @@ func (t *Trigger) GetCrossNamespaceRef() duckv1.KReference {
 	}
 	return duckv1.KReference{}
 }
+
+// MaxInFlight returns the limit of the events delivered to the subscriber of
+// the Trigger concurrently, see MaxInFlightAnnotation, 0 for no limit.
+func (t *Trigger) MaxInFlight() int {
+	maxInFlight, err := strconv.Atoi(t.GetAnnotations()[MaxInFlightAnnotation])
+	if err != nil || maxInFlight <= 0 {
+		return 0
+	}
+	return maxInFlight
+}
@@ -35,3 +35,15 @@ func TestTrigger_GetGroupVersionKind(t *testing.T) {
 		t.Errorf("Should be Trigger.")
 	}
 }
+
+func TestTriggerMaxInFlight(t *testing.T) {
+	for value, want := range map[string]int{"": 0, "10": 10, "0": 0, "-1": 0, "ten": 0} {
+		tr := Trigger{}
+		if value != "" {
+			tr.Annotations = map[string]string{MaxInFlightAnnotation: value}
+		}
+		if got := tr.MaxInFlight(); got != want {
+			t.Errorf("MaxInFlight() with annotation %q = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 
 	cesqlparser "github.com/cloudevents/sdk-go/sql/v2/parser"
 	"go.uber.org/zap"
@@ -43,6 +44,7 @@ func (t *Trigger) Validate(ctx context.Context) *apis.FieldError {
 	errs := t.Spec.Validate(apis.WithinSpec(ctx)).ViaField("spec")
 	errs = t.validateAnnotation(errs, DependencyAnnotation, t.validateDependencyAnnotation)
 	errs = t.validateAnnotation(errs, InjectionAnnotation, t.validateInjectionAnnotation)
+	errs = t.validateAnnotation(errs, MaxInFlightAnnotation, validateMaxInFlightAnnotation)
 	if apis.IsInUpdate(ctx) {
 		original := apis.GetBaseline(ctx).(*Trigger)
 		errs = errs.Also(t.CheckImmutableFields(ctx, original))
@@ -169,6 +171,16 @@ func (t *Trigger) validateInjectionAnnotation(injectionAnnotation string) *apis.
 	return nil
 }
 
+func validateMaxInFlightAnnotation(maxInFlightAnnotation string) *apis.FieldError {
+	if maxInFlight, err := strconv.Atoi(maxInFlightAnnotation); err != nil || maxInFlight <= 0 {
+		return &apis.FieldError{
+			Message: fmt.Sprintf("The provided maxInFlight annotation value must be a positive integer, not %q", maxInFlightAnnotation),
+			Paths:   []string{""},
+		}
+	}
+	return nil
+}
+
 func ValidateAttributeFilters(filter *TriggerFilter) (errs *apis.FieldError) {
 	if filter == nil {
 		return nil
@@ -238,6 +238,37 @@ func TestTriggerValidation(t *testing.T) {
 				Paths:   []string{injectionAnnotationPath},
 				Message: `The provided injection annotation value can only be "enabled" or "disabled", not "wut"`,
 			},
+		}, {
+			name: "valid maxInFlight annotation",
+			t: &Trigger{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "test-ns",
+					Annotations: map[string]string{
+						MaxInFlightAnnotation: "10",
+					}},
+				Spec: TriggerSpec{
+					Broker:     "test_broker",
+					Filter:     validEmptyTriggerFilter,
+					Subscriber: validSubscriber,
+				}},
+			want: nil,
+		}, {
+			name: "invalid maxInFlight annotation value",
+			t: &Trigger{
+				ObjectMeta: v1.ObjectMeta{
+					Namespace: "test-ns",
+					Annotations: map[string]string{
+						MaxInFlightAnnotation: "0",
+					}},
+				Spec: TriggerSpec{
+					Broker:     "test_broker",
+					Filter:     validEmptyTriggerFilter,
+					Subscriber: validSubscriber,
+				}},
+			want: &apis.FieldError{
+				Paths:   []string{fmt.Sprintf("metadata.annotations[%s]", MaxInFlightAnnotation)},
+				Message: `The provided maxInFlight annotation value must be a positive integer, not "0"`,
+			},
 		},
 		{
 			name: "invalid trigger spec, invalid dependency annotation(missing kind, name, apiVersion) and invalid injection",
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"knative.dev/pkg/configmap"
+)
+
+// Client gives data-plane components, such as dispatchers and adapters, typed
+// access to the feature flags of the config-features ConfigMap, kept up to
+// date by a ConfigMap watcher, and notifies them when the flags change.
+// +k8s:deepcopy-gen=false
+type Client struct {
+	store *Store
+
+	mu        sync.Mutex
+	last      Flags
+	callbacks []func(Flags)
+}
+
+// NewClient creates a Client watching the config-features ConfigMap with the
+// given watcher, which must be started by the caller.
+func NewClient(logger configmap.Logger, cmw configmap.Watcher) *Client {
+	c := &Client{}
+	c.store = NewStore(logger, func(_ string, value interface{}) {
+		c.notify(value.(Flags))
+	})
+	c.store.WatchConfigs(cmw)
+	return c
+}
+
+// OnChange registers a function called with the new flags whenever they
+// change. Callbacks are called sequentially, in the order they were
+// registered, and must not block.
+func (c *Client) OnChange(callback func(Flags)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callbacks = append(c.callbacks, callback)
+}
+
+func (c *Client) notify(flags Flags) {
+	c.mu.Lock()
+	if c.last != nil && maps.Equal(c.last, flags) {
+		c.mu.Unlock()
+		return
+	}
+	c.last = flags
+	// the callbacks are called without the lock held, so that they can use
+	// the client, e.g. to register more callbacks
+	callbacks := slices.Clone(c.callbacks)
+	c.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback(flags)
+	}
+}
+
+// Flags returns the current flags, the default flags until the ConfigMap is
+// loaded.
+func (c *Client) Flags() Flags {
+	if flags := c.store.Load(); flags != nil {
+		return flags
+	}
+	return newDefaults()
+}
+
+// ToContext attaches the current flags to the provided context.
+func (c *Client) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, c.Flags())
+}
+
+// IsEnabled is a shortcut for Flags().IsEnabled(featureName)
+func (c *Client) IsEnabled(featureName string) bool {
+	return c.Flags().IsEnabled(featureName)
+}
+
+// IsOIDCAuthentication returns true if the OIDC authentication is enabled.
+func (c *Client) IsOIDCAuthentication() bool {
+	return c.Flags().IsOIDCAuthentication()
+}
+
+// TransportEncryption returns the transport-encryption mode, Disabled,
+// Permissive or Strict.
+func (c *Client) TransportEncryption() Flag {
+	return c.Flags()[TransportEncryption]
+}
+
+// DeliveryRetryAfter returns the stage of the delivery-retryafter feature,
+// Disabled, Allowed or Enabled.
+func (c *Client) DeliveryRetryAfter() Flag {
+	return c.Flags()[DeliveryRetryAfter]
+}
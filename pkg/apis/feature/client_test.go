@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package feature_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/configmap"
+	logtesting "knative.dev/pkg/logging/testing"
+
+	. "knative.dev/eventing/pkg/apis/feature"
+)
+
+func TestClient(t *testing.T) {
+	cmw := &configmap.ManualWatcher{Namespace: "knative-eventing"}
+	client := NewClient(logtesting.TestLogger(t), cmw)
+
+	var changes []Flags
+	client.OnChange(func(flags Flags) {
+		changes = append(changes, flags)
+	})
+
+	// the default flags until the ConfigMap is loaded
+	require.False(t, client.IsOIDCAuthentication())
+	require.Equal(t, Disabled, client.TransportEncryption())
+	require.Equal(t, Enabled, client.DeliveryRetryAfter())
+
+	featuresConfig := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: FlagsConfigName},
+			Data:       data,
+		}
+	}
+
+	cmw.OnChange(featuresConfig(map[string]string{
+		OIDCAuthentication:  "enabled",
+		TransportEncryption: "strict",
+		DeliveryRetryAfter:  "allowed",
+	}))
+	require.True(t, client.IsOIDCAuthentication())
+	require.Equal(t, Strict, client.TransportEncryption())
+	require.Equal(t, Allowed, client.DeliveryRetryAfter())
+	require.True(t, FromContext(client.ToContext(context.Background())).IsOIDCAuthentication())
+	require.Len(t, changes, 1)
+
+	// an update without change isn't notified
+	cmw.OnChange(featuresConfig(map[string]string{
+		OIDCAuthentication:  "Enabled",
+		TransportEncryption: "Strict",
+		DeliveryRetryAfter:  "Allowed",
+	}))
+	require.Len(t, changes, 1)
+
+	cmw.OnChange(featuresConfig(map[string]string{
+		TransportEncryption: "permissive",
+	}))
+	require.Len(t, changes, 2)
+	require.True(t, changes[1].IsPermissiveTransportEncryption())
+	require.False(t, client.IsOIDCAuthentication())
+	require.Equal(t, Permissive, client.TransportEncryption())
+}
+
+func TestClientCallbackUsesClient(t *testing.T) {
+	cmw := &configmap.ManualWatcher{Namespace: "knative-eventing"}
+	client := NewClient(logtesting.TestLogger(t), cmw)
+
+	var nested []Flags
+	client.OnChange(func(Flags) {
+		// registering a callback from a callback doesn't deadlock
+		client.OnChange(func(flags Flags) {
+			nested = append(nested, flags)
+		})
+	})
+
+	cmw.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: FlagsConfigName},
+		Data:       map[string]string{OIDCAuthentication: "enabled"},
+	})
+	require.Empty(t, nested)
+
+	cmw.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing", Name: FlagsConfigName},
+		Data:       map[string]string{OIDCAuthentication: "disabled"},
+	})
+	require.Len(t, nested, 1)
+}
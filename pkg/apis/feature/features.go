@@ -72,7 +72,7 @@ type Flags map[string]Flag
 func newDefaults() Flags {
 	return map[string]Flag{
 		KReferenceGroup:          Disabled,
-		DeliveryRetryAfter:       Disabled,
+		DeliveryRetryAfter:       Enabled,
 		DeliveryTimeout:          Enabled,
 		KReferenceMapping:        Disabled,
 		NewTriggerFilters:        Enabled,
@@ -79,6 +79,12 @@ type ParallelSpec struct {
 	// when the case does not have a Reply
 	// +optional
 	Reply *duckv1.Destination `json:"reply,omitempty"`
+
+	// Delivery is the delivery specification for events to the filters, and
+	// to the subscribers of the branches not specifying their own delivery.
+	// This includes things like retries, DLS, etc.
+	// +optional
+	Delivery *eventingduckv1.DeliverySpec `json:"delivery,omitempty"`
 }
 
 type ParallelBranch struct {
@@ -47,6 +47,12 @@ func (ps *ParallelSpec) Validate(ctx context.Context) *apis.FieldError {
 		}
 	}
 
+	if ps.Delivery != nil {
+		if de := ps.Delivery.Validate(ctx); de != nil {
+			errs = errs.Also(de.ViaField("delivery"))
+		}
+	}
+
 	if ps.ChannelTemplate == nil {
 		errs = errs.Also(apis.ErrMissingField("channelTemplate"))
 		return errs
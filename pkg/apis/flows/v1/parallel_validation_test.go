@@ -194,6 +194,15 @@ func TestParallelSpecValidate(t *testing.T) {
 			},
 			want: apis.ErrMissingField("reply.ref.apiVersion"),
 		},
+		{
+			name: "invalid delivery",
+			ps: &ParallelSpec{
+				Branches:        getValidBranches(),
+				ChannelTemplate: getValidChannelTemplate(),
+				Delivery:        getInvalidDelivery(),
+			},
+			want: apis.ErrInvalidValue("invalid delay", "delivery.backoffDelay"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -80,6 +80,12 @@ type SequenceSpec struct {
 	// Reply is a Reference to where the result of the last Subscriber gets sent to.
 	// +optional
 	Reply *duckv1.Destination `json:"reply,omitempty"`
+
+	// Delivery is the delivery specification for events to the subscribers
+	// of the steps not specifying their own delivery.
+	// This includes things like retries, DLS, etc.
+	// +optional
+	Delivery *eventingduckv1.DeliverySpec `json:"delivery,omitempty"`
 }
 
 type SequenceStep struct {
@@ -52,6 +52,12 @@ func (ps *SequenceSpec) Validate(ctx context.Context) *apis.FieldError {
 		errs = errs.Also(err.ViaField("reply"))
 	}
 
+	if ps.Delivery != nil {
+		if de := ps.Delivery.Validate(ctx); de != nil {
+			errs = errs.Also(de.ViaField("delivery"))
+		}
+	}
+
 	return errs
 }
 
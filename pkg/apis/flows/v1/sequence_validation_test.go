@@ -237,6 +237,15 @@ func TestSequenceSpecValidate(t *testing.T) {
 			},
 			want: apis.ErrMissingField("channelTemplate", "reply.ref.apiVersion"),
 		},
+		{
+			name: "invalid delivery",
+			ss: &SequenceSpec{
+				Steps:           getValidSteps(),
+				ChannelTemplate: getValidChannelTemplate(),
+				Delivery:        getInvalidDelivery(),
+			},
+			want: apis.ErrInvalidValue("invalid delay", "delivery.backoffDelay"),
+		},
 	}
 
 	for _, test := range tests {
@@ -178,6 +178,11 @@ func (in *ParallelSpec) DeepCopyInto(out *ParallelSpec) {
 		*out = new(duckv1.Destination)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(apisduckv1.DeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -340,6 +345,11 @@ func (in *SequenceSpec) DeepCopyInto(out *SequenceSpec) {
 		*out = new(duckv1.Destination)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(apisduckv1.DeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -23,6 +23,30 @@ const (
 	// SubscribableDuckVersionAnnotation is the annotation we use to declare
 	// which Subscribable duck version type we conform to.
 	SubscribableDuckVersionAnnotation = "messaging.knative.dev/subscribable"
+	// DeliveryOrderAnnotation is the annotation of a Subscription selecting
+	// whether the channel delivers the events to the subscriber in order.
+	DeliveryOrderAnnotation = "messaging.knative.dev/delivery-order"
+	// DeliveryOrderOrdered is the DeliveryOrderAnnotation value delivering
+	// the events one at a time, in the order they were received.
+	DeliveryOrderOrdered = "ordered"
+	// DeliveryOrderUnordered is the default DeliveryOrderAnnotation value
+	// delivering the events in parallel.
+	DeliveryOrderUnordered = "unordered"
+	// BufferSizeAnnotation is the annotation of an InMemoryChannel bounding
+	// the number of events it holds in memory while waiting to dispatch them.
+	BufferSizeAnnotation = "messaging.knative.dev/buffer-size"
+	// BufferOverflowPolicyAnnotation is the annotation of an InMemoryChannel
+	// selecting what happens to the events received while its buffer is full.
+	BufferOverflowPolicyAnnotation = "messaging.knative.dev/buffer-overflow-policy"
+	// BufferOverflowBlock is the default BufferOverflowPolicyAnnotation value
+	// rejecting the events with a 429 response.
+	BufferOverflowBlock = "block"
+	// BufferOverflowDropOldest is the BufferOverflowPolicyAnnotation value
+	// dropping the oldest buffered event to make room for the new one.
+	BufferOverflowDropOldest = "drop-oldest"
+	// BufferOverflowDeadLetter is the BufferOverflowPolicyAnnotation value
+	// sending the events to the dead letter sinks of the subscriptions.
+	BufferOverflowDeadLetter = "dead-letter"
 )
 
 var (
@@ -19,11 +19,13 @@ package v1
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/kmp"
 
 	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/apis/messaging"
 )
 
 const eventingControllerSAName = "system:serviceaccount:knative-eventing:eventing-controller"
@@ -40,6 +42,7 @@ func (imc *InMemoryChannel) Validate(ctx context.Context) *apis.FieldError {
 				errs = errs.Also(iv.ViaFieldKey("annotations", eventing.ScopeAnnotationKey).ViaField("metadata"))
 			}
 		}
+		errs = errs.Also(validateBufferAnnotations(imc.Annotations).ViaField("metadata"))
 	}
 
 	if apis.IsInUpdate(ctx) {
@@ -51,6 +54,27 @@ func (imc *InMemoryChannel) Validate(ctx context.Context) *apis.FieldError {
 	return errs
 }
 
+func validateBufferAnnotations(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	if size, ok := annotations[messaging.BufferSizeAnnotation]; ok {
+		if n, err := strconv.Atoi(size); err != nil || n < 1 {
+			iv := apis.ErrInvalidValue(size, "")
+			iv.Details = "expected a positive integer"
+			errs = errs.Also(iv.ViaFieldKey("annotations", messaging.BufferSizeAnnotation))
+		}
+	}
+	if policy, ok := annotations[messaging.BufferOverflowPolicyAnnotation]; ok {
+		switch policy {
+		case messaging.BufferOverflowBlock, messaging.BufferOverflowDropOldest, messaging.BufferOverflowDeadLetter:
+		default:
+			iv := apis.ErrInvalidValue(policy, "")
+			iv.Details = fmt.Sprintf("expected one of %q, %q or %q", messaging.BufferOverflowBlock, messaging.BufferOverflowDropOldest, messaging.BufferOverflowDeadLetter)
+			errs = errs.Also(iv.ViaFieldKey("annotations", messaging.BufferOverflowPolicyAnnotation))
+		}
+	}
+	return errs
+}
+
 func (imcs *InMemoryChannelSpec) Validate(ctx context.Context) *apis.FieldError {
 	var errs *apis.FieldError
 	for i, subscriber := range imcs.SubscribableSpec.Subscribers {
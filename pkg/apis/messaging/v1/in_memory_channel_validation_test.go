@@ -27,6 +27,7 @@ import (
 
 	eventingduck "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/apis/messaging"
 )
 
 var (
@@ -133,6 +134,48 @@ func TestInMemoryChannelValidation(t *testing.T) {
 			fe.Details = "expected either 'cluster' or 'namespace'"
 			return fe
 		}(),
+	}, {
+		name: "valid buffer annotations",
+		cr: &InMemoryChannel{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					messaging.BufferSizeAnnotation:           "100",
+					messaging.BufferOverflowPolicyAnnotation: messaging.BufferOverflowDropOldest,
+				},
+			},
+			Spec: InMemoryChannelSpec{},
+		},
+		want: nil,
+	}, {
+		name: "invalid buffer size annotation",
+		cr: &InMemoryChannel{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					messaging.BufferSizeAnnotation: "0",
+				},
+			},
+			Spec: InMemoryChannelSpec{},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("0", "metadata.annotations.[messaging.knative.dev/buffer-size]")
+			fe.Details = "expected a positive integer"
+			return fe
+		}(),
+	}, {
+		name: "invalid buffer overflow policy annotation",
+		cr: &InMemoryChannel{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					messaging.BufferOverflowPolicyAnnotation: "spill",
+				},
+			},
+			Spec: InMemoryChannelSpec{},
+		},
+		want: func() *apis.FieldError {
+			fe := apis.ErrInvalidValue("spill", "metadata.annotations.[messaging.knative.dev/buffer-overflow-policy]")
+			fe.Details = `expected one of "block", "drop-oldest" or "dead-letter"`
+			return fe
+		}(),
 	}, {
 		name: "invalid user for spec.subscribers update",
 		cr:   validIMCTwoSubscribers,
@@ -20,4 +20,16 @@ const (
 	JobSinkJobsLabelSelector = "sinks.knative.dev/job-sink=true"
 	JobSinkNameLabel         = "sinks.knative.dev/job-sink-name"
 	JobSinkIDLabel           = "sinks.knative.dev/job-sink-id"
+
+	// JobSinkMaxActiveJobsAnnotation is the annotation of a JobSink bounding
+	// the number of its Jobs running at once, the events received while the
+	// limit is reached are rejected with a 429 response.
+	JobSinkMaxActiveJobsAnnotation = "sinks.knative.dev/max-active-jobs"
+	// JobSinkMaxEventSizeAnnotation is the annotation of a JobSink bounding
+	// the size of the requests it accepts, as a quantity like "256Ki".
+	JobSinkMaxEventSizeAnnotation = "sinks.knative.dev/max-event-size"
+	// JobSinkFinishedJobsTTLAnnotation is the annotation of a JobSink setting
+	// how long its finished Jobs, and the Secrets holding their event, are
+	// kept, as a duration like "1h".
+	JobSinkFinishedJobsTTLAnnotation = "sinks.knative.dev/finished-jobs-ttl"
 )
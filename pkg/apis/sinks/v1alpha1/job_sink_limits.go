@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/apis/sinks"
+)
+
+// GetMaxActiveJobs returns the maximum number of Jobs of the JobSink running
+// at once, 0 if there is no limit.
+func (sink *JobSink) GetMaxActiveJobs() int {
+	n, err := parseMaxActiveJobs(sink.Annotations[sinks.JobSinkMaxActiveJobsAnnotation])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetMaxEventSize returns the maximum size in bytes of the requests accepted
+// by the JobSink, 0 if there is no limit.
+func (sink *JobSink) GetMaxEventSize() int64 {
+	n, err := parseMaxEventSize(sink.Annotations[sinks.JobSinkMaxEventSizeAnnotation])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetFinishedJobsTTL returns how long the finished Jobs of the JobSink are
+// kept, 0 if they are kept until the JobSink is deleted.
+func (sink *JobSink) GetFinishedJobsTTL() time.Duration {
+	ttl, err := parseFinishedJobsTTL(sink.Annotations[sinks.JobSinkFinishedJobsTTLAnnotation])
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+func validateLimitAnnotations(annotations map[string]string) *apis.FieldError {
+	var errs *apis.FieldError
+	if v, ok := annotations[sinks.JobSinkMaxActiveJobsAnnotation]; ok {
+		if _, err := parseMaxActiveJobs(v); err != nil || v == "" {
+			iv := apis.ErrInvalidValue(v, "")
+			iv.Details = "expected a positive integer"
+			errs = errs.Also(iv.ViaFieldKey("annotations", sinks.JobSinkMaxActiveJobsAnnotation))
+		}
+	}
+	if v, ok := annotations[sinks.JobSinkMaxEventSizeAnnotation]; ok {
+		if _, err := parseMaxEventSize(v); err != nil || v == "" {
+			iv := apis.ErrInvalidValue(v, "")
+			iv.Details = "expected a positive quantity"
+			errs = errs.Also(iv.ViaFieldKey("annotations", sinks.JobSinkMaxEventSizeAnnotation))
+		}
+	}
+	if v, ok := annotations[sinks.JobSinkFinishedJobsTTLAnnotation]; ok {
+		if _, err := parseFinishedJobsTTL(v); err != nil || v == "" {
+			iv := apis.ErrInvalidValue(v, "")
+			iv.Details = "expected a duration of at least 1s"
+			errs = errs.Also(iv.ViaFieldKey("annotations", sinks.JobSinkFinishedJobsTTLAnnotation))
+		}
+	}
+	return errs
+}
+
+func parseMaxActiveJobs(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, strconv.ErrRange
+	}
+	return n, nil
+}
+
+func parseMaxEventSize(v string) (int64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(v)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := q.AsInt64()
+	if !ok || n < 1 {
+		return 0, strconv.ErrRange
+	}
+	return n, nil
+}
+
+func parseFinishedJobsTTL(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < time.Second {
+		return 0, strconv.ErrRange
+	}
+	return ttl, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"knative.dev/eventing/pkg/apis/sinks"
+)
+
+func TestJobSinkLimits(t *testing.T) {
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		wantMaxActiveJobs int
+		wantMaxEventSize  int64
+		wantTTL           time.Duration
+	}{{
+		name: "no annotations",
+	}, {
+		name: "valid annotations",
+		annotations: map[string]string{
+			sinks.JobSinkMaxActiveJobsAnnotation:   "10",
+			sinks.JobSinkMaxEventSizeAnnotation:    "256Ki",
+			sinks.JobSinkFinishedJobsTTLAnnotation: "1h30m",
+		},
+		wantMaxActiveJobs: 10,
+		wantMaxEventSize:  256 * 1024,
+		wantTTL:           90 * time.Minute,
+	}, {
+		name: "invalid annotations are ignored",
+		annotations: map[string]string{
+			sinks.JobSinkMaxActiveJobsAnnotation:   "-1",
+			sinks.JobSinkMaxEventSizeAnnotation:    "big",
+			sinks.JobSinkFinishedJobsTTLAnnotation: "forever",
+		},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &JobSink{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			if got := sink.GetMaxActiveJobs(); got != test.wantMaxActiveJobs {
+				t.Errorf("GetMaxActiveJobs() = %d, want %d", got, test.wantMaxActiveJobs)
+			}
+			if got := sink.GetMaxEventSize(); got != test.wantMaxEventSize {
+				t.Errorf("GetMaxEventSize() = %d, want %d", got, test.wantMaxEventSize)
+			}
+			if got := sink.GetFinishedJobsTTL(); got != test.wantTTL {
+				t.Errorf("GetFinishedJobsTTL() = %v, want %v", got, test.wantTTL)
+			}
+		})
+	}
+}
@@ -28,7 +28,8 @@ import (
 
 func (sink *JobSink) Validate(ctx context.Context) *apis.FieldError {
 	ctx = apis.WithinParent(ctx, sink.ObjectMeta)
-	return sink.Spec.Validate(ctx).ViaField("spec")
+	errs := validateLimitAnnotations(sink.Annotations).ViaField("metadata")
+	return errs.Also(sink.Spec.Validate(ctx).ViaField("spec"))
 }
 
 func (sink *JobSinkSpec) Validate(ctx context.Context) *apis.FieldError {
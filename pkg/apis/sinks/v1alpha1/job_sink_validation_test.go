@@ -21,7 +21,10 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/apis/sinks"
 )
 
 func TestValidation(t *testing.T) {
@@ -30,7 +33,31 @@ func TestValidation(t *testing.T) {
 		source JobSink
 		ctx    func(ctx context.Context) context.Context
 		want   *apis.FieldError
-	}{}
+	}{{
+		name: "invalid limit annotations",
+		source: JobSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					sinks.JobSinkMaxActiveJobsAnnotation:   "0",
+					sinks.JobSinkMaxEventSizeAnnotation:    "big",
+					sinks.JobSinkFinishedJobsTTLAnnotation: "1ms",
+				},
+			},
+		},
+		want: func() *apis.FieldError {
+			maxActiveJobs := apis.ErrInvalidValue("0", "")
+			maxActiveJobs.Details = "expected a positive integer"
+			maxEventSize := apis.ErrInvalidValue("big", "")
+			maxEventSize.Details = "expected a positive quantity"
+			ttl := apis.ErrInvalidValue("1ms", "")
+			ttl.Details = "expected a duration of at least 1s"
+			return maxActiveJobs.ViaFieldKey("annotations", sinks.JobSinkMaxActiveJobsAnnotation).
+				Also(maxEventSize.ViaFieldKey("annotations", sinks.JobSinkMaxEventSizeAnnotation)).
+				Also(ttl.ViaFieldKey("annotations", sinks.JobSinkFinishedJobsTTLAnnotation)).
+				ViaField("metadata").
+				Also(apis.ErrMissingOneOf("job").ViaField("spec"))
+		}(),
+	}}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
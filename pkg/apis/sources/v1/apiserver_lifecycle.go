@@ -40,6 +40,10 @@ const (
 
 	// ApiServerConditionOIDCIdentityCreated has status True when the ApiServerSource has created an OIDC identity.
 	ApiServerConditionOIDCIdentityCreated apis.ConditionType = "OIDCIdentityCreated"
+
+	// ApiServerConditionDataPlaneHealthy has status True when the heartbeats of the
+	// receive adapter report that it is able to send events. It doesn't affect Ready.
+	ApiServerConditionDataPlaneHealthy apis.ConditionType = "DataPlaneHealthy"
 )
 
 var apiserverCondSet = apis.NewLivingConditionSet(
@@ -147,3 +151,18 @@ func (s *ApiServerSourceStatus) MarkOIDCIdentityCreatedFailed(reason, messageFor
 func (s *ApiServerSourceStatus) MarkOIDCIdentityCreatedUnknown(reason, messageFormat string, messageA ...interface{}) {
 	apiserverCondSet.Manage(s).MarkUnknown(ApiServerConditionOIDCIdentityCreated, reason, messageFormat, messageA...)
 }
+
+// MarkDataPlaneHealthy sets the condition that the receive adapter is able to send events.
+func (s *ApiServerSourceStatus) MarkDataPlaneHealthy() {
+	apiserverCondSet.Manage(s).MarkTrue(ApiServerConditionDataPlaneHealthy)
+}
+
+// MarkDataPlaneUnhealthy sets the condition that the receive adapter fails to send events.
+func (s *ApiServerSourceStatus) MarkDataPlaneUnhealthy(reason, messageFormat string, messageA ...interface{}) {
+	apiserverCondSet.Manage(s).MarkFalse(ApiServerConditionDataPlaneHealthy, reason, messageFormat, messageA...)
+}
+
+// MarkDataPlaneHealthUnknown sets the condition that the health of the receive adapter is unknown.
+func (s *ApiServerSourceStatus) MarkDataPlaneHealthUnknown(reason, messageFormat string, messageA ...interface{}) {
+	apiserverCondSet.Manage(s).MarkUnknown(ApiServerConditionDataPlaneHealthy, reason, messageFormat, messageA...)
+}
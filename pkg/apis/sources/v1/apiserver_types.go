@@ -19,12 +19,23 @@ package v1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/kmeta"
 )
 
+const (
+	// ApiServerSourceReplayAnnotation is the annotation key used to request a
+	// replay of the existing resources. Changing its value restarts the adapter,
+	// which then sends an add event for each of the existing resources. Each
+	// value is replayed once, the replayed value is persisted in the
+	// <source>-replay ConfigMap: the service account of the source must be
+	// allowed to get, create and update it.
+	ApiServerSourceReplayAnnotation = "sources.knative.dev/replay"
+)
+
 // +genclient
 // +genreconciler
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -83,7 +94,10 @@ type ApiServerSourceSpec struct {
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
 	// NamespaceSelector is a label selector to capture the namespaces that
-	// should be watched by the source.
+	// should be watched by the source. The namespaces starting or stopping to
+	// match a non-empty selector are watched or no longer watched without
+	// redeploying the source, its ServiceAccount must be allowed to list and
+	// watch the namespaces.
 	// +optional
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 
@@ -96,6 +110,108 @@ type ApiServerSourceSpec struct {
 	//
 	// +optional
 	Filters []eventingv1.SubscriptionsAPIFilter `json:"filters,omitempty"`
+
+	// Delivery contains the delivery options for the events sent to the sink,
+	// such as retries, backoff and the dead letter sink.
+	// +optional
+	Delivery *eventingduckv1.DeliverySpec `json:"delivery,omitempty"`
+
+	// SendInitialEvents controls whether the resources existing when the source
+	// starts are sent as add events. Defaults to false.
+	// +optional
+	SendInitialEvents *bool `json:"sendInitialEvents,omitempty"`
+
+	// PayloadMode controls the payload of the update events, it requires the
+	// `Resource` EventMode.
+	// `New` sends the new state of the resource.
+	// `Diff` sends the JSON patch from the previous to the new state of the resource.
+	// `Both` sends the previous and the new state of the resource.
+	// Defaults to `New`
+	// +optional
+	PayloadMode string `json:"payloadMode,omitempty"`
+
+	// Projection selects the fields of the resources sent in the event
+	// payloads, it requires the `Resource` EventMode.
+	// +optional
+	Projection *ApiServerSourceProjection `json:"projection,omitempty"`
+
+	// AdditionalSinks are sinks receiving a copy of every event sent to the
+	// sink. The delivery options apply to each sink independently.
+	// +optional
+	AdditionalSinks []duckv1.Destination `json:"additionalSinks,omitempty"`
+
+	// EventTemplate renders the type and the source of the events from the
+	// resources they are sent for, so that Triggers can route them by
+	// attribute.
+	// +optional
+	EventTemplate *ApiServerSourceEventTemplate `json:"eventTemplate,omitempty"`
+
+	// DebounceWindowMillis is the window, in milliseconds, during which the
+	// events of the same object are coalesced into a single event carrying
+	// the latest state of the object. Zero, the default, disables debouncing.
+	// +optional
+	DebounceWindowMillis *int64 `json:"debounceWindowMillis,omitempty"`
+
+	// Workers is the number of workers sending the events of each resource.
+	// The events of the same object are sent in order by the same worker,
+	// while the events of different objects are sent in parallel. Zero, the
+	// default, sends the events one at a time.
+	// +optional
+	Workers *int32 `json:"workers,omitempty"`
+
+	// Dedup enables the deduplication of the events of the object versions
+	// already sent, such as the objects listed again after a restart.
+	// +optional
+	Dedup *ApiServerSourceDedup `json:"dedup,omitempty"`
+}
+
+// ApiServerSourceDedup configures the deduplication of the events of the
+// object versions already sent.
+type ApiServerSourceDedup struct {
+	// Size is the number of object versions remembered, defaults to 10000.
+	// +optional
+	Size *int32 `json:"size,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap, in the namespace of the
+	// source, persisting the object versions across restarts. The service
+	// account of the source must be allowed to get, create and update it.
+	// Only the most recently seen versions fitting in the ConfigMap are
+	// persisted. The versions are kept in memory only when empty.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// ApiServerSourceEventTemplate renders the type and the source of the events
+// from Go templates, evaluated per event with the fields of EventTemplateData,
+// such as `{{.Type}}.{{.Kind | lower}}` or `{{.Source}}/{{.Namespace}}`.
+type ApiServerSourceEventTemplate struct {
+	// Type is the template of the event type, the default type is kept when
+	// empty.
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Source is the template of the event source, the default source is kept
+	// when empty.
+	// +optional
+	Source string `json:"source,omitempty"`
+}
+
+// ApiServerSourceProjection selects the fields of the resources sent in the
+// event payloads. Fields are referenced by paths such as `.status.images`.
+// The apiVersion, kind, name and namespace of the resources are always sent.
+type ApiServerSourceProjection struct {
+	// MetadataOnly sends the apiVersion, kind and metadata of the resources only.
+	// +optional
+	MetadataOnly bool `json:"metadataOnly,omitempty"`
+
+	// Include is the list of the fields to send, all the fields are sent when
+	// empty.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude is the list of the fields not to send.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // ApiServerSourceStatus defines the observed state of ApiServerSource
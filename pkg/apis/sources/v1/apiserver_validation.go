@@ -18,9 +18,13 @@ package v1
 
 import (
 	"context"
+	"io"
+	"regexp"
 	"strings"
+	"text/template"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/eventing/pkg/apis/feature"
@@ -32,8 +36,49 @@ const (
 	ReferenceMode = "Reference"
 	// ResourceMode produces payloads of ResourceEvent
 	ResourceMode = "Resource"
+
+	// PayloadModeNew produces update payloads of the new resource
+	PayloadModeNew = "New"
+	// PayloadModeDiff produces update payloads of the JSON patch between the old and new resource
+	PayloadModeDiff = "Diff"
+	// PayloadModeBoth produces update payloads of both the old and new resource
+	PayloadModeBoth = "Both"
 )
 
+// projectionPathRegexp matches the field paths of projections, such as `.status.images`.
+var projectionPathRegexp = regexp.MustCompile(`^(\.[^.\s]+)+$`)
+
+// EventTemplateFuncs are the functions the templates of
+// ApiServerSourceEventTemplate can call.
+var EventTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// EventTemplateData is the data the templates of ApiServerSourceEventTemplate
+// are evaluated with.
+// +k8s:deepcopy-gen=false
+type EventTemplateData struct {
+	// Type is the default type of the event.
+	Type string
+	// Source is the default source of the event.
+	Source string
+	// Verb is the change of the resource, add, update or delete.
+	Verb string
+
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string
+	Name      string
+	Labels    map[string]string
+}
+
+// ParseEventTemplate parses a template of ApiServerSourceEventTemplate.
+func ParseEventTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(EventTemplateFuncs).Option("missingkey=zero").Parse(text)
+}
+
 func (c *ApiServerSource) Validate(ctx context.Context) *apis.FieldError {
 	return c.Spec.Validate(ctx).ViaField("spec")
 }
@@ -49,8 +94,45 @@ func (cs *ApiServerSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 		errs = errs.Also(apis.ErrInvalidValue(cs.EventMode, "mode"))
 	}
 
+	// Validate payload mode, the old resource is only sent in Resource mode
+	switch cs.PayloadMode {
+	case "", PayloadModeNew:
+	// PayloadMode is valid.
+	case PayloadModeDiff, PayloadModeBoth:
+		if cs.EventMode == ReferenceMode {
+			errs = errs.Also(apis.ErrGeneric("payloadMode "+cs.PayloadMode+" requires mode "+ResourceMode, "payloadMode"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(cs.PayloadMode, "payloadMode"))
+	}
+
+	// Validate projection, only resources are projected
+	if cs.Projection != nil {
+		if cs.EventMode == ReferenceMode {
+			errs = errs.Also(apis.ErrGeneric("projection requires mode "+ResourceMode, "projection"))
+		}
+		errs = errs.Also(cs.Projection.Validate(ctx).ViaField("projection"))
+	}
+
+	if cs.EventTemplate != nil {
+		errs = errs.Also(cs.EventTemplate.Validate(ctx).ViaField("eventTemplate"))
+	}
+
+	if cs.DebounceWindowMillis != nil && *cs.DebounceWindowMillis < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(*cs.DebounceWindowMillis, "debounceWindowMillis"))
+	}
+	if cs.Workers != nil && *cs.Workers < 0 {
+		errs = errs.Also(apis.ErrInvalidValue(*cs.Workers, "workers"))
+	}
+	if cs.Dedup != nil {
+		errs = errs.Also(cs.Dedup.Validate(ctx).ViaField("dedup"))
+	}
+
 	// Validate sink
 	errs = errs.Also(cs.Sink.Validate(ctx).ViaField("sink"))
+	for i, sink := range cs.AdditionalSinks {
+		errs = errs.Also(sink.Validate(ctx).ViaFieldIndex("additionalSinks", i))
+	}
 
 	if len(cs.Resources) == 0 {
 		errs = errs.Also(apis.ErrMissingField("resources"))
@@ -76,9 +158,65 @@ func (cs *ApiServerSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 	}
 	errs = errs.Also(cs.SourceSpec.Validate(ctx))
 	errs = errs.Also(validateSubscriptionAPIFiltersList(ctx, cs.Filters).ViaField("filters"))
+	errs = errs.Also(cs.Delivery.Validate(ctx).ViaField("delivery"))
+	return errs
+}
+
+func (p *ApiServerSourceProjection) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	for i, path := range p.Include {
+		if !projectionPathRegexp.MatchString(path) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(path, "include", i))
+		}
+	}
+	for i, path := range p.Exclude {
+		if !projectionPathRegexp.MatchString(path) {
+			errs = errs.Also(apis.ErrInvalidArrayValue(path, "exclude", i))
+		}
+	}
+	return errs
+}
+
+func (d *ApiServerSourceDedup) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	if d.Size != nil && *d.Size < 1 {
+		errs = errs.Also(apis.ErrInvalidValue(*d.Size, "size"))
+	}
+	if d.ConfigMapName != "" {
+		if msgs := validation.IsDNS1123Subdomain(d.ConfigMapName); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(d.ConfigMapName, "configMapName", strings.Join(msgs, ", ")))
+		}
+	}
+	return errs
+}
+
+func (t *ApiServerSourceEventTemplate) Validate(ctx context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+	errs = errs.Also(validateEventTemplate("type", t.Type))
+	errs = errs.Also(validateEventTemplate("source", t.Source))
 	return errs
 }
 
+// validateEventTemplate parses the template and evaluates it with sample data,
+// so that references to unknown fields are rejected too.
+func validateEventTemplate(field, text string) *apis.FieldError {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := ParseEventTemplate(field, text)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, EventTemplateData{Labels: map[string]string{}})
+	}
+	if err != nil {
+		return &apis.FieldError{
+			Message: "invalid template",
+			Paths:   []string{field},
+			Details: err.Error(),
+		}
+	}
+	return nil
+}
+
 func validateSubscriptionAPIFiltersList(ctx context.Context, filters []eventingv1.SubscriptionsAPIFilter) (errs *apis.FieldError) {
 	if !feature.FromContext(ctx).IsEnabled(feature.NewAPIServerFilters) {
 		if len(filters) != 0 {
@@ -29,6 +29,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"knative.dev/eventing/pkg/apis/feature"
 	"knative.dev/pkg/apis"
+	"knative.dev/pkg/ptr"
 )
 
 func TestAPIServerValidation(t *testing.T) {
@@ -92,6 +93,233 @@ func TestAPIServerValidation(t *testing.T) {
 			errs = errs.Also(apis.ErrInvalidValue("Test", "mode"))
 			return errs
 		}(),
+	}, {
+		name: "diff payload mode",
+		spec: ApiServerSourceSpec{
+			EventMode:   "Resource",
+			PayloadMode: "Diff",
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid payload mode",
+		spec: ApiServerSourceSpec{
+			EventMode:   "Resource",
+			PayloadMode: "Test",
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("invalid value: Test: payloadMode"),
+	}, {
+		name: "diff payload mode requires resource mode",
+		spec: ApiServerSourceSpec{
+			EventMode:   "Reference",
+			PayloadMode: "Diff",
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("payloadMode Diff requires mode Resource: payloadMode"),
+	}, {
+		name: "valid projection",
+		spec: ApiServerSourceSpec{
+			EventMode:  "Resource",
+			Projection: &ApiServerSourceProjection{Include: []string{".status.phase"}, Exclude: []string{".metadata.managedFields"}},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "invalid projection path",
+		spec: ApiServerSourceSpec{
+			EventMode:  "Resource",
+			Projection: &ApiServerSourceProjection{Include: []string{"status..phase"}},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("invalid value: status..phase: projection.include[0]"),
+	}, {
+		name: "projection requires resource mode",
+		spec: ApiServerSourceSpec{
+			EventMode:  "Reference",
+			Projection: &ApiServerSourceProjection{MetadataOnly: true},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("projection requires mode Resource: projection"),
+	}, {
+		name: "valid event template",
+		spec: ApiServerSourceSpec{
+			EventMode:     "Resource",
+			EventTemplate: &ApiServerSourceEventTemplate{Type: "{{.Type}}.{{.Kind | lower}}.{{.Verb}}", Source: `{{.Source}}/{{.Namespace}}/{{index .Labels "app"}}`},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: nil,
+	}, {
+		name: "event template with unknown field",
+		spec: ApiServerSourceSpec{
+			EventMode:     "Resource",
+			EventTemplate: &ApiServerSourceEventTemplate{Type: "{{.Type}}.{{.Resource}}"},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("invalid template: eventTemplate.type\n" + `template: type:1:12: executing "type" at <.Resource>: can't evaluate field Resource in type v1.EventTemplateData`),
+	}, {
+		name: "negative debounce window",
+		spec: ApiServerSourceSpec{
+			EventMode:            "Resource",
+			DebounceWindowMillis: ptr.Int64(-1),
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("invalid value: -1: debounceWindowMillis"),
+	}, {
+		name: "negative workers",
+		spec: ApiServerSourceSpec{
+			EventMode: "Resource",
+			Workers:   ptr.Int32(-1),
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: errors.New("invalid value: -1: workers"),
+	}, {
+		name: "invalid dedup",
+		spec: ApiServerSourceSpec{
+			EventMode: "Resource",
+			Dedup: &ApiServerSourceDedup{
+				Size:          ptr.Int32(0),
+				ConfigMapName: "Dedup",
+			},
+			Resources: []APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Foo",
+			}},
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{
+					Ref: &duckv1.KReference{
+						APIVersion: "v1",
+						Kind:       "broker",
+						Name:       "default",
+					},
+				},
+			},
+		},
+		want: (&apis.FieldError{
+			Message: "invalid value: Dedup",
+			Paths:   []string{"dedup.configMapName"},
+			Details: "a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+		}).Also(apis.ErrInvalidValue(0, "dedup.size")),
 	}, {
 		name: "invalid apiVersion",
 		spec: ApiServerSourceSpec{
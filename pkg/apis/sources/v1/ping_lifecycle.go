@@ -38,6 +38,14 @@ const (
 
 	// PingSourceConditionOIDCIdentityCreated has status True when the PingSource has had it's OIDC identity created.
 	PingSourceConditionOIDCIdentityCreated apis.ConditionType = "OIDCIdentityCreated"
+
+	// PingSourceConditionCompleted has status True when the PingSource delivered
+	// MaxFires events, and its schedule was removed. It doesn't affect Ready.
+	PingSourceConditionCompleted apis.ConditionType = "Completed"
+
+	// PingSourceConditionDataPlaneHealthy has status True when the heartbeats of the
+	// receive adapter report that it is able to send events. It doesn't affect Ready.
+	PingSourceConditionDataPlaneHealthy apis.ConditionType = "DataPlaneHealthy"
 )
 
 var PingSourceCondSet = apis.NewLivingConditionSet(
@@ -60,6 +68,14 @@ func PingSourceSource(namespace, name string) string {
 	return fmt.Sprintf("/apis/v1/namespaces/%s/pingsources/%s", namespace, name)
 }
 
+// PingSourceType returns the PingSource CloudEvent type for the given type suffix.
+func PingSourceType(suffix string) string {
+	if suffix == "" {
+		return PingSourceEventType
+	}
+	return PingSourceEventType + "." + suffix
+}
+
 // GetUntypedSpec returns the spec of the PingSource.
 func (s *PingSource) GetUntypedSpec() interface{} {
 	return s.Spec
@@ -143,3 +159,34 @@ func (s *PingSourceStatus) MarkOIDCIdentityCreatedFailed(reason, messageFormat s
 func (s *PingSourceStatus) MarkOIDCIdentityCreatedUnknown(reason, messageFormat string, messageA ...interface{}) {
 	PingSourceCondSet.Manage(s).MarkUnknown(PingSourceConditionOIDCIdentityCreated, reason, messageFormat, messageA...)
 }
+
+// IsCompleted returns true if the PingSource delivered MaxFires events.
+func (s *PingSourceStatus) IsCompleted() bool {
+	return PingSourceCondSet.Manage(s).GetCondition(PingSourceConditionCompleted).IsTrue()
+}
+
+// MarkCompleted sets the condition that the PingSource delivered MaxFires events.
+func (s *PingSourceStatus) MarkCompleted(fires int32) {
+	PingSourceCondSet.Manage(s).MarkTrueWithReason(PingSourceConditionCompleted, "MaxFiresReached", "Delivered %d events", fires)
+}
+
+// ClearCompleted removes the condition that the PingSource delivered MaxFires
+// events, e.g. once MaxFires was raised.
+func (s *PingSourceStatus) ClearCompleted() {
+	_ = PingSourceCondSet.Manage(s).ClearCondition(PingSourceConditionCompleted)
+}
+
+// MarkDataPlaneHealthy sets the condition that the receive adapter is able to send events.
+func (s *PingSourceStatus) MarkDataPlaneHealthy() {
+	PingSourceCondSet.Manage(s).MarkTrue(PingSourceConditionDataPlaneHealthy)
+}
+
+// MarkDataPlaneUnhealthy sets the condition that the receive adapter fails to send events.
+func (s *PingSourceStatus) MarkDataPlaneUnhealthy(reason, messageFormat string, messageA ...interface{}) {
+	PingSourceCondSet.Manage(s).MarkFalse(PingSourceConditionDataPlaneHealthy, reason, messageFormat, messageA...)
+}
+
+// MarkDataPlaneHealthUnknown sets the condition that the health of the receive adapter is unknown.
+func (s *PingSourceStatus) MarkDataPlaneHealthUnknown(reason, messageFormat string, messageA ...interface{}) {
+	PingSourceCondSet.Manage(s).MarkUnknown(PingSourceConditionDataPlaneHealthy, reason, messageFormat, messageA...)
+}
@@ -52,6 +52,16 @@ func TestPingSource_PingSourceSource(t *testing.T) {
 	}
 }
 
+func TestPingSource_PingSourceType(t *testing.T) {
+	if got := PingSourceType(""); got != PingSourceEventType {
+		t.Errorf("Should be %q, got %q", PingSourceEventType, got)
+	}
+
+	if got := PingSourceType("billing"); got != "dev.knative.sources.ping.billing" {
+		t.Errorf("Should be 'dev.knative.sources.ping.billing', got %q", got)
+	}
+}
+
 func TestPingSourceStatusIsReady(t *testing.T) {
 	exampleUri, _ := apis.ParseURL("uri://example")
 	exampleAddr := &duckv1.Addressable{
@@ -328,3 +338,39 @@ func TestPingSourceStatusGetCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestPingSourceStatusCompleted(t *testing.T) {
+	exampleUri, _ := apis.ParseURL("uri://example")
+	exampleAddr := &duckv1.Addressable{
+		URL: exampleUri,
+	}
+
+	s := &PingSourceStatus{}
+	s.InitializeConditions()
+	s.MarkOIDCIdentityCreatedSucceeded()
+	s.MarkSink(exampleAddr)
+	s.PropagateDeploymentAvailability(availableDeployment)
+
+	if s.IsCompleted() {
+		t.Error("unexpected completion before MarkCompleted")
+	}
+
+	s.MarkCompleted(3)
+	if !s.IsCompleted() {
+		t.Error("expected completion after MarkCompleted")
+	}
+	if got := s.GetCondition(PingSourceConditionCompleted); got.Reason != "MaxFiresReached" || got.Message != "Delivered 3 events" {
+		t.Errorf("unexpected Completed condition: %+v", got)
+	}
+	if !s.IsReady() {
+		t.Error("Completed must not affect readiness")
+	}
+
+	s.ClearCompleted()
+	if s.IsCompleted() || s.GetCondition(PingSourceConditionCompleted) != nil {
+		t.Error("expected no Completed condition after ClearCompleted")
+	}
+	if !s.IsReady() {
+		t.Error("ClearCompleted must not affect readiness")
+	}
+}
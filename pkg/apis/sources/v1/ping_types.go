@@ -81,6 +81,26 @@ type PingSourceSpec struct {
 	// Mutually exclusive with Data.
 	// +optional
 	DataBase64 string `json:"dataBase64,omitempty"`
+
+	// TypeSuffix is appended to the default CloudEvent type of the events posted
+	// to the sink, e.g. `dev.knative.sources.ping.<typeSuffix>`. Default is empty.
+	// +optional
+	TypeSuffix string `json:"typeSuffix,omitempty"`
+
+	// Subject is the CloudEvent subject of the events posted to the sink. Default is empty.
+	// +optional
+	Subject string `json:"subject,omitempty"`
+
+	// Extensions are static CloudEvent extension attributes set on the events
+	// posted to the sink. Extensions in CloudEventOverrides take precedence.
+	// +optional
+	Extensions map[string]string `json:"extensions,omitempty"`
+
+	// MaxFires is the number of events delivered successfully after which the
+	// schedule is removed and the PingSource is marked Completed. Default is
+	// unlimited.
+	// +optional
+	MaxFires *int32 `json:"maxFires,omitempty"`
 }
 
 // PingSourceStatus defines the observed state of PingSource.
@@ -93,6 +113,11 @@ type PingSourceStatus struct {
 	// * SinkURI - the current active sink URI that has been configured for the
 	//   Source.
 	duckv1.SourceStatus `json:",inline"`
+
+	// Fires is the number of events delivered successfully, counted when
+	// MaxFires is set.
+	// +optional
+	Fires int32 `json:"fires,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
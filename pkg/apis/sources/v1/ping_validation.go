@@ -22,16 +22,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 
 	"github.com/robfig/cron/v3"
 	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 
 	"knative.dev/eventing/pkg/apis/sources/config"
 )
 
+// typeSuffixRegexp matches dot separated segments of alphanumeric characters,
+// dashes and underscores.
+var typeSuffixRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
 func (c *PingSource) Validate(ctx context.Context) *apis.FieldError {
 	return c.Spec.Validate(ctx).ViaField("spec")
 }
@@ -98,6 +105,19 @@ func (cs *PingSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 			}
 		}
 	}
+	if cs.TypeSuffix != "" && !typeSuffixRegexp.MatchString(cs.TypeSuffix) {
+		errs = errs.Also(apis.ErrInvalidValue(cs.TypeSuffix, "typeSuffix"))
+	}
+
+	if len(cs.Extensions) > 0 {
+		overrides := duckv1.CloudEventOverrides{Extensions: cs.Extensions}
+		errs = errs.Also(overrides.Validate(ctx))
+	}
+
+	if cs.MaxFires != nil && *cs.MaxFires < 1 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*cs.MaxFires, 1, math.MaxInt32, "maxFires"))
+	}
+
 	errs = errs.Also(cs.SourceSpec.Validate(ctx))
 	return errs
 }
@@ -19,6 +19,7 @@ package v1
 import (
 	"context"
 	"encoding/base64"
+	"math"
 	"strings"
 	"testing"
 
@@ -27,6 +28,7 @@ import (
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/pointer"
 	"knative.dev/pkg/apis"
 
 	"knative.dev/eventing/pkg/apis/sources/config"
@@ -475,6 +477,117 @@ func TestPingSourceValidation(t *testing.T) {
 				errs = errs.Also(fe)
 				return errs
 			}(),
+		}, {
+			name: "valid spec with type suffix, subject and extensions",
+			source: PingSource{
+				Spec: PingSourceSpec{
+					Schedule:   "*/2 * * * *",
+					TypeSuffix: "billing.daily",
+					Subject:    "invoices",
+					Extensions: map[string]string{"team": "billing"},
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{
+							Ref: &duckv1.KReference{
+								APIVersion: "v1",
+								Kind:       "broker",
+								Name:       "default",
+							},
+						},
+					},
+				},
+			},
+			want: nil,
+		}, {
+			name: "invalid type suffix",
+			source: PingSource{
+				Spec: PingSourceSpec{
+					Schedule:   "*/2 * * * *",
+					TypeSuffix: ".billing daily",
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{
+							Ref: &duckv1.KReference{
+								APIVersion: "v1",
+								Kind:       "broker",
+								Name:       "default",
+							},
+						},
+					},
+				},
+			},
+			want: func() *apis.FieldError {
+				var errs *apis.FieldError
+				fe := apis.ErrInvalidValue(".billing daily", "spec.typeSuffix")
+				errs = errs.Also(fe)
+				return errs
+			}(),
+		}, {
+			name: "invalid extensions",
+			source: PingSource{
+				Spec: PingSourceSpec{
+					Schedule:   "*/2 * * * *",
+					Extensions: map[string]string{"Invalid_type": "any value"},
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{
+							Ref: &duckv1.KReference{
+								APIVersion: "v1",
+								Kind:       "broker",
+								Name:       "default",
+							},
+						},
+					},
+				},
+			},
+			want: func() *apis.FieldError {
+				var errs *apis.FieldError
+				fe := apis.ErrInvalidKeyName(
+					"Invalid_type",
+					"spec.extensions",
+					"keys are expected to be alphanumeric",
+				)
+				errs = errs.Also(fe)
+				return errs
+			}(),
+		}, {
+			name: "valid max fires",
+			source: PingSource{
+				Spec: PingSourceSpec{
+					Schedule: "*/2 * * * *",
+					MaxFires: pointer.Int32(3),
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{
+							Ref: &duckv1.KReference{
+								APIVersion: "v1",
+								Kind:       "broker",
+								Name:       "default",
+							},
+						},
+					},
+				},
+			},
+			want: nil,
+		}, {
+			name: "invalid max fires",
+			source: PingSource{
+				Spec: PingSourceSpec{
+					Schedule: "*/2 * * * *",
+					MaxFires: pointer.Int32(0),
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{
+							Ref: &duckv1.KReference{
+								APIVersion: "v1",
+								Kind:       "broker",
+								Name:       "default",
+							},
+						},
+					},
+				},
+			},
+			want: func() *apis.FieldError {
+				var errs *apis.FieldError
+				fe := apis.ErrOutOfBoundsValue(0, 1, math.MaxInt32, "spec.maxFires")
+				errs = errs.Also(fe)
+				return errs
+			}(),
 		},
 	}
 
@@ -175,6 +175,12 @@ func (sb *SinkBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
 				Value: *addr.CACerts,
 			})
 		}
+		if addr.Audience != nil {
+			ps.Spec.Template.Spec.InitContainers[i].Env = append(ps.Spec.Template.Spec.InitContainers[i].Env, corev1.EnvVar{
+				Name:  "K_SINK_AUDIENCE",
+				Value: *addr.Audience,
+			})
+		}
 		ps.Spec.Template.Spec.InitContainers[i].Env = append(ps.Spec.Template.Spec.InitContainers[i].Env, corev1.EnvVar{
 			Name:  "K_CE_OVERRIDES",
 			Value: ceOverrides,
@@ -191,6 +197,12 @@ func (sb *SinkBinding) Do(ctx context.Context, ps *duckv1.WithPod) {
 				Value: *addr.CACerts,
 			})
 		}
+		if addr.Audience != nil {
+			ps.Spec.Template.Spec.Containers[i].Env = append(ps.Spec.Template.Spec.Containers[i].Env, corev1.EnvVar{
+				Name:  "K_SINK_AUDIENCE",
+				Value: *addr.Audience,
+			})
+		}
 		ps.Spec.Template.Spec.Containers[i].Env = append(ps.Spec.Template.Spec.Containers[i].Env, corev1.EnvVar{
 			Name:  "K_CE_OVERRIDES",
 			Value: ceOverrides,
@@ -243,7 +255,7 @@ func (sb *SinkBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
 			env := make([]corev1.EnvVar, 0, len(ps.Spec.Template.Spec.InitContainers[i].Env))
 			for j, ev := range c.Env {
 				switch ev.Name {
-				case "K_SINK", "K_CE_OVERRIDES", "K_CA_CERTS":
+				case "K_SINK", "K_CE_OVERRIDES", "K_CA_CERTS", "K_SINK_AUDIENCE":
 					continue
 				default:
 					env = append(env, ps.Spec.Template.Spec.InitContainers[i].Env[j])
@@ -271,7 +283,7 @@ func (sb *SinkBinding) Undo(ctx context.Context, ps *duckv1.WithPod) {
 			env := make([]corev1.EnvVar, 0, len(ps.Spec.Template.Spec.Containers[i].Env))
 			for j, ev := range c.Env {
 				switch ev.Name {
-				case "K_SINK", "K_CE_OVERRIDES", "K_CA_CERTS":
+				case "K_SINK", "K_CE_OVERRIDES", "K_CA_CERTS", "K_SINK_AUDIENCE":
 					continue
 				default:
 					env = append(env, ps.Spec.Template.Spec.Containers[i].Env[j])
@@ -519,6 +519,7 @@ func TestSinkBindingDo(t *testing.T) {
 		name       string
 		in         *duckv1.WithPod
 		configMaps []*corev1.ConfigMap
+		sink       *duckv1.Destination
 		sbStatus   *SinkBindingStatus
 		want       *duckv1.WithPod
 		ctx        context.Context
@@ -891,6 +892,50 @@ func TestSinkBindingDo(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "adds sink audience",
+		in: &duckv1.WithPod{
+			Spec: duckv1.WithPodSpec{
+				Template: duckv1.PodSpecable{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:  "blah",
+							Image: "busybox",
+						}},
+					},
+				},
+			},
+		},
+		sink: &duckv1.Destination{
+			URI:      destination.URI,
+			CACerts:  &caCert,
+			Audience: pointer.String("my-audience"),
+		},
+		want: &duckv1.WithPod{
+			Spec: duckv1.WithPodSpec{
+				Template: duckv1.PodSpecable{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:  "blah",
+							Image: "busybox",
+							Env: []corev1.EnvVar{{
+								Name:  "K_SINK",
+								Value: destination.URI.String(),
+							}, {
+								Name:  "K_CA_CERTS",
+								Value: caCert,
+							}, {
+								Name:  "K_SINK_AUDIENCE",
+								Value: "my-audience",
+							}, {
+								Name:  "K_CE_OVERRIDES",
+								Value: `{"extensions":{"foo":"bar"}}`,
+							}},
+						}},
+					},
+				},
+			},
+		},
 	}}
 
 	for _, test := range tests {
@@ -911,10 +956,15 @@ func TestSinkBindingDo(t *testing.T) {
 				_ = configmapinformer.Get(ctx).Informer().GetIndexer().Add(cm)
 			}
 
+			sink := destination
+			if test.sink != nil {
+				sink = *test.sink
+			}
+
 			sb := &SinkBinding{
 				Spec: SinkBindingSpec{
 					SourceSpec: duckv1.SourceSpec{
-						Sink:                destination,
+						Sink:                sink,
 						CloudEventOverrides: &overrides,
 					}},
 			}
@@ -24,7 +24,9 @@ package v1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	apisduckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -92,6 +94,43 @@ func (in *ApiServerSource) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApiServerSourceDedup) DeepCopyInto(out *ApiServerSourceDedup) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApiServerSourceDedup.
+func (in *ApiServerSourceDedup) DeepCopy() *ApiServerSourceDedup {
+	if in == nil {
+		return nil
+	}
+	out := new(ApiServerSourceDedup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApiServerSourceEventTemplate) DeepCopyInto(out *ApiServerSourceEventTemplate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApiServerSourceEventTemplate.
+func (in *ApiServerSourceEventTemplate) DeepCopy() *ApiServerSourceEventTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ApiServerSourceEventTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApiServerSourceList) DeepCopyInto(out *ApiServerSourceList) {
 	*out = *in
@@ -125,6 +164,32 @@ func (in *ApiServerSourceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApiServerSourceProjection) DeepCopyInto(out *ApiServerSourceProjection) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApiServerSourceProjection.
+func (in *ApiServerSourceProjection) DeepCopy() *ApiServerSourceProjection {
+	if in == nil {
+		return nil
+	}
+	out := new(ApiServerSourceProjection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApiServerSourceSpec) DeepCopyInto(out *ApiServerSourceSpec) {
 	*out = *in
@@ -153,6 +218,48 @@ func (in *ApiServerSourceSpec) DeepCopyInto(out *ApiServerSourceSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(duckv1.DeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SendInitialEvents != nil {
+		in, out := &in.SendInitialEvents, &out.SendInitialEvents
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Projection != nil {
+		in, out := &in.Projection, &out.Projection
+		*out = new(ApiServerSourceProjection)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalSinks != nil {
+		in, out := &in.AdditionalSinks, &out.AdditionalSinks
+		*out = make([]apisduckv1.Destination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EventTemplate != nil {
+		in, out := &in.EventTemplate, &out.EventTemplate
+		*out = new(ApiServerSourceEventTemplate)
+		**out = **in
+	}
+	if in.DebounceWindowMillis != nil {
+		in, out := &in.DebounceWindowMillis, &out.DebounceWindowMillis
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Dedup != nil {
+		in, out := &in.Dedup, &out.Dedup
+		*out = new(ApiServerSourceDedup)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -349,6 +456,18 @@ func (in *PingSourceList) DeepCopyObject() runtime.Object {
 func (in *PingSourceSpec) DeepCopyInto(out *PingSourceSpec) {
 	*out = *in
 	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MaxFires != nil {
+		in, out := &in.MaxFires, &out.MaxFires
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
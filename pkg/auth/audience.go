@@ -37,3 +37,77 @@ func GetAudienceDirect(gvk schema.GroupVersionKind, ns, name string) string {
 
 	return strings.ToLower(aud)
 }
+
+// AdditionalAudiencesAnnotationKey is the annotation on a resource listing comma separated
+// audiences, which are accepted for the resource in addition to its own audience, e.g. its
+// previous audience during a migration.
+const AdditionalAudiencesAnnotationKey = "eventing.knative.dev/additional-audiences"
+
+// AudienceMatcher holds the audiences accepted for a resource. The first audience is the
+// audience of the resource. Audiences ending with "*" accept all audiences with the given
+// prefix (e.g. eventing.knative.dev/broker/my-ns/*).
+type AudienceMatcher []string
+
+// NewAudienceMatcher returns an AudienceMatcher accepting the audience and the additional audiences.
+func NewAudienceMatcher(audience string, additional ...string) AudienceMatcher {
+	return append(AudienceMatcher{audience}, additional...)
+}
+
+// AudienceMatcherFor returns an AudienceMatcher accepting the audience of a resource and
+// the audiences of its AdditionalAudiencesAnnotationKey annotation. It returns nil if the
+// resource has no audience.
+func AudienceMatcherFor(audience *string, objectMeta metav1.ObjectMeta) AudienceMatcher {
+	if audience == nil {
+		return nil
+	}
+
+	var additional []string
+	for _, aud := range strings.Split(objectMeta.Annotations[AdditionalAudiencesAnnotationKey], ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			additional = append(additional, aud)
+		}
+	}
+
+	return NewAudienceMatcher(*audience, additional...)
+}
+
+// Audience returns the audience of the resource.
+func (m AudienceMatcher) Audience() string {
+	if len(m) == 0 {
+		return ""
+	}
+	return m[0]
+}
+
+// Matches checks whether one of the given token audiences is accepted.
+func (m AudienceMatcher) Matches(tokenAudiences []string) bool {
+	for _, aud := range tokenAudiences {
+		if m.matches(aud) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m AudienceMatcher) matches(audience string) bool {
+	for _, accepted := range m {
+		if accepted == audience {
+			return true
+		}
+		if strings.HasSuffix(accepted, "*") && strings.HasPrefix(audience, strings.TrimSuffix(accepted, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// exact returns the accepted audiences which are no prefix patterns.
+func (m AudienceMatcher) exact() []string {
+	exact := make([]string, 0, len(m))
+	for _, aud := range m {
+		if !strings.HasSuffix(aud, "*") {
+			exact = append(exact, aud)
+		}
+	}
+	return exact
+}
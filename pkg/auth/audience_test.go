@@ -19,6 +19,7 @@ package auth
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	v1 "knative.dev/eventing/pkg/apis/eventing/v1"
@@ -63,3 +64,78 @@ func TestGetAudience(t *testing.T) {
 		})
 	}
 }
+
+func TestAudienceMatcherFor(t *testing.T) {
+	audience := "eventing.knative.dev/broker/ns/name"
+
+	tests := []struct {
+		name       string
+		audience   *string
+		objectMeta metav1.ObjectMeta
+		want       AudienceMatcher
+	}{{
+		name: "no audience",
+	}, {
+		name:     "audience",
+		audience: &audience,
+		want:     AudienceMatcher{audience},
+	}, {
+		name:     "additional audiences",
+		audience: &audience,
+		objectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				AdditionalAudiencesAnnotationKey: "eventing.knative.dev/broker/ns/old-name, ,vanity ",
+			},
+		},
+		want: AudienceMatcher{audience, "eventing.knative.dev/broker/ns/old-name", "vanity"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AudienceMatcherFor(tt.audience, tt.objectMeta)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("Unexpected matcher (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestAudienceMatcherMatches(t *testing.T) {
+	matcher := NewAudienceMatcher("eventing.knative.dev/broker/ns/name", "legacy", "eventing.knative.dev/channel/ns/*")
+
+	tests := []struct {
+		name           string
+		tokenAudiences []string
+		want           bool
+	}{{
+		name:           "audience",
+		tokenAudiences: []string{"eventing.knative.dev/broker/ns/name"},
+		want:           true,
+	}, {
+		name:           "additional audience",
+		tokenAudiences: []string{"other", "legacy"},
+		want:           true,
+	}, {
+		name:           "prefix",
+		tokenAudiences: []string{"eventing.knative.dev/channel/ns/my-channel"},
+		want:           true,
+	}, {
+		name:           "other namespace",
+		tokenAudiences: []string{"eventing.knative.dev/channel/other/my-channel"},
+	}, {
+		name: "no audiences",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matcher.Matches(tt.tokenAudiences); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.tokenAudiences, got, tt.want)
+			}
+		})
+	}
+
+	if got := matcher.Audience(); got != "eventing.knative.dev/broker/ns/name" {
+		t.Errorf("Audience() = %q, want the first audience", got)
+	}
+	if diff := cmp.Diff([]string{"eventing.knative.dev/broker/ns/name", "legacy"}, matcher.exact()); diff != "" {
+		t.Error("Unexpected exact audiences (-want, +got):", diff)
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"knative.dev/pkg/logging"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/feature"
+	listerseventingv1alpha1 "knative.dev/eventing/pkg/client/listers/eventing/v1alpha1"
+)
+
+const (
+	// AuthorizationReasonOIDCDisabled is the reason for allowing requests when OIDC authentication is disabled.
+	AuthorizationReasonOIDCDisabled = "OIDCDisabled"
+	// AuthorizationReasonDefaultMode is the reason for decisions based on the default authorization mode.
+	AuthorizationReasonDefaultMode = "DefaultAuthorizationMode"
+	// AuthorizationReasonEventPolicy is the reason for decisions based on the applied EventPolicies.
+	AuthorizationReasonEventPolicy = "EventPolicy"
+	// AuthorizationReasonUnauthenticated is the reason for denying requests without a verified token.
+	AuthorizationReasonUnauthenticated = "Unauthenticated"
+	// AuthorizationReasonSubjectAccessReview is the reason for decisions based on a SubjectAccessReview.
+	AuthorizationReasonSubjectAccessReview = "SubjectAccessReview"
+
+	// SendVerb is the verb checked by SubjectAccessReviews for sending events to a resource.
+	SendVerb = "send"
+
+	// subjectAccessReviewCacheSize is the number of SubjectAccessReview decisions cached.
+	subjectAccessReviewCacheSize = 4096
+	// subjectAccessReviewAllowedTTL and subjectAccessReviewDeniedTTL are how long the
+	// SubjectAccessReview decisions are cached, so that RBAC changes apply shortly.
+	subjectAccessReviewAllowedTTL = 30 * time.Second
+	subjectAccessReviewDeniedTTL  = 10 * time.Second
+)
+
+// AuthorizationTarget is the resource receiving an event.
+type AuthorizationTarget struct {
+	// GroupResource of the resource. It is required to authorize with SubjectAccessReviews.
+	GroupResource schema.GroupResource
+	Namespace     string
+	Name          string
+	// Policies are the EventPolicies applied to the resource, as found in its status.
+	Policies []eventingduckv1.AppliedEventPolicyRef
+}
+
+// AuthorizationDecision is the result of authorizing a sender for a target.
+type AuthorizationDecision struct {
+	Allowed bool
+	// Reason is a CamelCase reason for the decision.
+	Reason string
+	// Message is a human readable explanation of the decision.
+	Message string
+}
+
+// Authorizer decides whether a verified OIDC identity is allowed to send events
+// to a resource, based on the EventPolicies applied to the resource. When no
+// EventPolicy applies, it falls back to a SubjectAccessReview if enabled, or to
+// the default authorization mode.
+type Authorizer struct {
+	eventPolicyLister     listerseventingv1alpha1.EventPolicyLister
+	reporter              AuthorizationStatsReporter
+	subjectAccessReviewer authorizationv1client.SubjectAccessReviewInterface
+	// subjectAccessReviews caches the decisions of the SubjectAccessReviews per
+	// subjectAccessReviewKey, so that a review isn't issued for every event.
+	subjectAccessReviews *cache.LRUExpireCache
+}
+
+// subjectAccessReviewKey identifies the SubjectAccessReviews of a subject for a target.
+type subjectAccessReviewKey struct {
+	subject   string
+	groups    string
+	group     string
+	resource  string
+	namespace string
+	name      string
+}
+
+// AuthorizerOption enables further configuration of an Authorizer.
+type AuthorizerOption func(*Authorizer)
+
+// WithSubjectAccessReviews authorizes subjects for targets without applied EventPolicies
+// by issuing a SubjectAccessReview for the SendVerb on the target resource. This allows
+// granting senders access with RBAC, e.g. with a Role with the rule
+//
+//	apiGroups: ["eventing.knative.dev"], resources: ["brokers"], verbs: ["send"]
+//
+// The decisions are cached per subject and target for a short time.
+func WithSubjectAccessReviews(reviewer authorizationv1client.SubjectAccessReviewInterface) AuthorizerOption {
+	return func(a *Authorizer) {
+		a.subjectAccessReviewer = reviewer
+		a.subjectAccessReviews = cache.NewLRUExpireCache(subjectAccessReviewCacheSize)
+	}
+}
+
+func NewAuthorizer(eventPolicyLister listerseventingv1alpha1.EventPolicyLister, reporter AuthorizationStatsReporter, opts ...AuthorizerOption) *Authorizer {
+	a := &Authorizer{
+		eventPolicyLister: eventPolicyLister,
+		reporter:          reporter,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Authorize authorizes the subject of the given token for the target.
+func (a *Authorizer) Authorize(ctx context.Context, features feature.Flags, target AuthorizationTarget, token *IDToken) (AuthorizationDecision, error) {
+	if !features.IsOIDCAuthentication() {
+		return AuthorizationDecision{
+			Allowed: true,
+			Reason:  AuthorizationReasonOIDCDisabled,
+			Message: fmt.Sprintf("feature %q is disabled", feature.OIDCAuthentication),
+		}, nil
+	}
+
+	if token == nil {
+		return AuthorizationDecision{
+			Reason:  AuthorizationReasonUnauthenticated,
+			Message: "no verified token",
+		}, nil
+	}
+
+	if len(target.Policies) == 0 {
+		if a.subjectAccessReviewer != nil && !target.GroupResource.Empty() {
+			return a.authorizeWithSubjectAccessReview(ctx, target, token)
+		}
+		return authorizeWithDefaultMode(features, target, token), nil
+	}
+
+	for _, ref := range target.Policies {
+		policy, err := a.eventPolicyLister.EventPolicies(target.Namespace).Get(ref.Name)
+		if apierrors.IsNotFound(err) {
+			// the policy got deleted since it was applied to the target
+			continue
+		} else if err != nil {
+			return AuthorizationDecision{}, fmt.Errorf("failed to get eventpolicy %s/%s: %w", target.Namespace, ref.Name, err)
+		}
+
+		if SubjectContained(token.Subject, policy.Status.From) {
+			return AuthorizationDecision{
+				Allowed: true,
+				Reason:  AuthorizationReasonEventPolicy,
+				Message: fmt.Sprintf("subject %q is allowed by eventpolicy %q", token.Subject, policy.Name),
+			}, nil
+		}
+	}
+
+	return AuthorizationDecision{
+		Reason:  AuthorizationReasonEventPolicy,
+		Message: fmt.Sprintf("subject %q is not allowed by any of the applied eventpolicies", token.Subject),
+	}, nil
+}
+
+func authorizeWithDefaultMode(features feature.Flags, target AuthorizationTarget, token *IDToken) AuthorizationDecision {
+	subject := token.Subject
+	decision := AuthorizationDecision{
+		Reason: AuthorizationReasonDefaultMode,
+	}
+
+	switch {
+	case features.IsAuthorizationDefaultModeAllowAll():
+		decision.Allowed = true
+	case features.IsAuthorizationDefaultModeDenyAll():
+		decision.Allowed = false
+	default:
+		// Allow-Same-Namespace, only ServiceAccounts can be in a namespace
+		decision.Allowed = token.IssuedByCluster &&
+			strings.HasPrefix(subject, fmt.Sprintf("system:serviceaccount:%s:", target.Namespace))
+	}
+
+	if decision.Allowed {
+		decision.Message = fmt.Sprintf("subject %q is allowed by default authorization mode %q", subject, features[feature.AuthorizationDefaultMode])
+	} else {
+		decision.Message = fmt.Sprintf("subject %q is not allowed by default authorization mode %q", subject, features[feature.AuthorizationDefaultMode])
+	}
+
+	return decision
+}
+
+func (a *Authorizer) authorizeWithSubjectAccessReview(ctx context.Context, target AuthorizationTarget, token *IDToken) (AuthorizationDecision, error) {
+	subject := token.Subject
+	groups := subjectGroups(token)
+	key := subjectAccessReviewKey{
+		subject:   subject,
+		groups:    strings.Join(groups, "\n"),
+		group:     target.GroupResource.Group,
+		resource:  target.GroupResource.Resource,
+		namespace: target.Namespace,
+		name:      target.Name,
+	}
+	if cached, ok := a.subjectAccessReviews.Get(key); ok {
+		return cached.(AuthorizationDecision), nil
+	}
+
+	review, err := a.subjectAccessReviewer.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   subject,
+			Groups: groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: target.Namespace,
+				Verb:      SendVerb,
+				Group:     target.GroupResource.Group,
+				Resource:  target.GroupResource.Resource,
+				Name:      target.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return AuthorizationDecision{}, fmt.Errorf("failed to create subjectaccessreview: %w", err)
+	}
+
+	decision := AuthorizationDecision{
+		Allowed: review.Status.Allowed && !review.Status.Denied,
+		Reason:  AuthorizationReasonSubjectAccessReview,
+	}
+	if decision.Allowed {
+		decision.Message = fmt.Sprintf("subject %q is allowed to %s %s: %s", subject, SendVerb, target.GroupResource, review.Status.Reason)
+	} else {
+		decision.Message = fmt.Sprintf("subject %q is not allowed to %s %s: %s", subject, SendVerb, target.GroupResource, review.Status.Reason)
+	}
+
+	ttl := subjectAccessReviewDeniedTTL
+	if decision.Allowed {
+		ttl = subjectAccessReviewAllowedTTL
+	}
+	a.subjectAccessReviews.Add(key, decision, ttl)
+
+	return decision, nil
+}
+
+// subjectGroups returns the groups the API server assigns to the subject of the
+// token, so that RBAC bindings to service account groups apply. Subjects of
+// tokens not issued by the cluster aren't ServiceAccounts, whatever they look like.
+func subjectGroups(token *IDToken) []string {
+	if !token.IssuedByCluster {
+		return nil
+	}
+	parts := strings.Split(token.Subject, ":")
+	if len(parts) != 4 || parts[0] != "system" || parts[1] != "serviceaccount" {
+		return nil
+	}
+
+	return []string{
+		"system:serviceaccounts",
+		"system:serviceaccounts:" + parts[2],
+		"system:authenticated",
+	}
+}
+
+type idTokenKey struct{}
+
+// ContextWithIDToken returns a copy of the context carrying the verified token of the sender.
+func ContextWithIDToken(ctx context.Context, token *IDToken) context.Context {
+	return context.WithValue(ctx, idTokenKey{}, token)
+}
+
+// IDTokenFromContext returns the verified token of the sender or nil if there is none.
+func IDTokenFromContext(ctx context.Context) *IDToken {
+	token, _ := ctx.Value(idTokenKey{}).(*IDToken)
+	return token
+}
+
+// AuthorizeMiddleware returns an http.Handler which authorizes the sender for the
+// target returned by getTarget, before passing the request to next. The verified
+// token of the sender is taken from the request context (see ContextWithIDToken).
+// Requests which are not authorized are answered with 403 Forbidden.
+func (a *Authorizer) AuthorizeMiddleware(getTarget func(*http.Request) (*AuthorizationTarget, error), next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := logging.FromContext(ctx)
+
+		target, err := getTarget(r)
+		if err != nil {
+			logger.Warnw("Failed to get authorization target", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		decision, err := a.Authorize(ctx, feature.FromContext(ctx), *target, IDTokenFromContext(ctx))
+		if err != nil {
+			logger.Warnw("Failed to authorize request", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if a.reporter != nil {
+			if err := a.reporter.ReportAuthorizationDecision(target, decision); err != nil {
+				logger.Warnw("Failed to record authorization metrics", zap.Error(err))
+			}
+		}
+
+		if !decision.Allowed {
+			logger.Infow("Request not authorized",
+				zap.String("namespace", target.Namespace),
+				zap.String("name", target.Name),
+				zap.String("reason", decision.Reason),
+				zap.String("message", decision.Message))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
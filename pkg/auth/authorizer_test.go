@@ -0,0 +1,473 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1alpha1"
+	"knative.dev/eventing/pkg/apis/feature"
+	eventpolicyinformerfake "knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/eventpolicy/fake"
+	reconcilertesting "knative.dev/pkg/reconciler/testing"
+
+	eventingmetrics "knative.dev/eventing/pkg/metrics"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	oidcEnabled := func(mode feature.Flag) feature.Flags {
+		return feature.Flags{
+			feature.OIDCAuthentication:       feature.Enabled,
+			feature.AuthorizationDefaultMode: mode,
+		}
+	}
+	policies := []eventingduckv1.AppliedEventPolicyRef{{
+		Name:       "policy-1",
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+	}, {
+		Name:       "policy-2",
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+	}}
+
+	tests := []struct {
+		name        string
+		features    feature.Flags
+		policies    []eventingduckv1.AppliedEventPolicyRef
+		token       *IDToken
+		wantAllowed bool
+		wantReason  string
+	}{{
+		name:        "OIDC disabled",
+		features:    feature.Flags{},
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonOIDCDisabled,
+	}, {
+		name:       "no token",
+		features:   oidcEnabled(feature.AuthorizationAllowAll),
+		wantReason: AuthorizationReasonUnauthenticated,
+	}, {
+		name:        "default mode allow all",
+		features:    oidcEnabled(feature.AuthorizationAllowAll),
+		token:       &IDToken{Subject: "system:serviceaccount:other:sa"},
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonDefaultMode,
+	}, {
+		name:       "default mode deny all",
+		features:   oidcEnabled(feature.AuthorizationDenyAll),
+		token:      &IDToken{Subject: "system:serviceaccount:ns:sa"},
+		wantReason: AuthorizationReasonDefaultMode,
+	}, {
+		name:        "default mode same namespace",
+		features:    oidcEnabled(feature.AuthorizationAllowSameNamespace),
+		token:       &IDToken{Subject: "system:serviceaccount:ns:sa", IssuedByCluster: true},
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonDefaultMode,
+	}, {
+		name:       "default mode same namespace, not issued by the cluster",
+		features:   oidcEnabled(feature.AuthorizationAllowSameNamespace),
+		token:      &IDToken{Subject: "system:serviceaccount:ns:sa"},
+		wantReason: AuthorizationReasonDefaultMode,
+	}, {
+		name:       "default mode same namespace, other namespace",
+		features:   oidcEnabled(feature.AuthorizationAllowSameNamespace),
+		token:      &IDToken{Subject: "system:serviceaccount:other:sa", IssuedByCluster: true},
+		wantReason: AuthorizationReasonDefaultMode,
+	}, {
+		name:        "allowed by event policy",
+		features:    oidcEnabled(feature.AuthorizationDenyAll),
+		policies:    policies,
+		token:       &IDToken{Subject: "system:serviceaccount:other:sa"},
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonEventPolicy,
+	}, {
+		name:        "allowed by event policy with prefix",
+		features:    oidcEnabled(feature.AuthorizationDenyAll),
+		policies:    policies,
+		token:       &IDToken{Subject: "system:serviceaccount:prefixed:sa"},
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonEventPolicy,
+	}, {
+		name:       "not allowed by event policies",
+		features:   oidcEnabled(feature.AuthorizationAllowAll),
+		policies:   policies,
+		token:      &IDToken{Subject: "system:serviceaccount:ns:sa"},
+		wantReason: AuthorizationReasonEventPolicy,
+	}, {
+		name:     "deleted event policy",
+		features: oidcEnabled(feature.AuthorizationAllowAll),
+		policies: []eventingduckv1.AppliedEventPolicyRef{{
+			Name:       "deleted",
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		}},
+		token:      &IDToken{Subject: "system:serviceaccount:ns:sa"},
+		wantReason: AuthorizationReasonEventPolicy,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := reconcilertesting.SetupFakeContext(t)
+			addEventPolicies(t, ctx)
+
+			authorizer := NewAuthorizer(eventpolicyinformerfake.Get(ctx).Lister(), nil)
+
+			decision, err := authorizer.Authorize(ctx, tt.features, AuthorizationTarget{
+				Namespace: "ns",
+				Name:      "broker",
+				Policies:  tt.policies,
+			}, tt.token)
+			if err != nil {
+				t.Fatal("Authorize() =", err)
+			}
+			if decision.Allowed != tt.wantAllowed {
+				t.Errorf("Got allowed %v, want %v (%s)", decision.Allowed, tt.wantAllowed, decision.Message)
+			}
+			if decision.Reason != tt.wantReason {
+				t.Errorf("Got reason %q, want %q", decision.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWithSubjectAccessReview(t *testing.T) {
+	features := feature.Flags{
+		feature.OIDCAuthentication:       feature.Enabled,
+		feature.AuthorizationDefaultMode: feature.AuthorizationDenyAll,
+	}
+	brokers := schema.GroupResource{Group: "eventing.knative.dev", Resource: "brokers"}
+
+	tests := []struct {
+		name          string
+		groupResource schema.GroupResource
+		policies      []eventingduckv1.AppliedEventPolicyRef
+		subject       string
+		external      bool
+		allowed       bool
+		reviewErr     error
+		wantAllowed   bool
+		wantReason    string
+		wantErr       bool
+		wantReview    *authorizationv1.SubjectAccessReviewSpec
+	}{{
+		name:          "allowed by RBAC",
+		groupResource: brokers,
+		subject:       "system:serviceaccount:other:sa",
+		allowed:       true,
+		wantAllowed:   true,
+		wantReason:    AuthorizationReasonSubjectAccessReview,
+		wantReview: &authorizationv1.SubjectAccessReviewSpec{
+			User:   "system:serviceaccount:other:sa",
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:other", "system:authenticated"},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: "ns",
+				Verb:      SendVerb,
+				Group:     "eventing.knative.dev",
+				Resource:  "brokers",
+				Name:      "broker",
+			},
+		},
+	}, {
+		name:          "not allowed by RBAC",
+		groupResource: brokers,
+		subject:       "https://issuer.example.com#user",
+		external:      true,
+		wantReason:    AuthorizationReasonSubjectAccessReview,
+		wantReview: &authorizationv1.SubjectAccessReviewSpec{
+			User: "https://issuer.example.com#user",
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: "ns",
+				Verb:      SendVerb,
+				Group:     "eventing.knative.dev",
+				Resource:  "brokers",
+				Name:      "broker",
+			},
+		},
+	}, {
+		name:          "service account subject not issued by the cluster",
+		groupResource: brokers,
+		subject:       "system:serviceaccount:other:sa",
+		external:      true,
+		wantReason:    AuthorizationReasonSubjectAccessReview,
+		wantReview: &authorizationv1.SubjectAccessReviewSpec{
+			User: "system:serviceaccount:other:sa",
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: "ns",
+				Verb:      SendVerb,
+				Group:     "eventing.knative.dev",
+				Resource:  "brokers",
+				Name:      "broker",
+			},
+		},
+	}, {
+		name:          "review fails",
+		groupResource: brokers,
+		subject:       "system:serviceaccount:other:sa",
+		reviewErr:     errors.New("unavailable"),
+		wantErr:       true,
+	}, {
+		name:       "no group resource uses default mode",
+		subject:    "system:serviceaccount:other:sa",
+		allowed:    true,
+		wantReason: AuthorizationReasonDefaultMode,
+	}, {
+		name:          "event policies take precedence",
+		groupResource: brokers,
+		policies: []eventingduckv1.AppliedEventPolicyRef{{
+			Name:       "policy-1",
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		}},
+		subject:     "system:serviceaccount:other:sa",
+		wantAllowed: true,
+		wantReason:  AuthorizationReasonEventPolicy,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _ := reconcilertesting.SetupFakeContext(t)
+			addEventPolicies(t, ctx)
+
+			var gotReview *authorizationv1.SubjectAccessReviewSpec
+			kubeClient := fake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				review := action.(clientgotesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				gotReview = review.Spec.DeepCopy()
+				review.Status.Allowed = tt.allowed
+				return true, review, tt.reviewErr
+			})
+
+			authorizer := NewAuthorizer(eventpolicyinformerfake.Get(ctx).Lister(), nil,
+				WithSubjectAccessReviews(kubeClient.AuthorizationV1().SubjectAccessReviews()))
+
+			decision, err := authorizer.Authorize(ctx, features, AuthorizationTarget{
+				GroupResource: tt.groupResource,
+				Namespace:     "ns",
+				Name:          "broker",
+				Policies:      tt.policies,
+			}, &IDToken{Subject: tt.subject, IssuedByCluster: !tt.external})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if decision.Allowed != tt.wantAllowed {
+				t.Errorf("Got allowed %v, want %v (%s)", decision.Allowed, tt.wantAllowed, decision.Message)
+			}
+			if decision.Reason != tt.wantReason {
+				t.Errorf("Got reason %q, want %q", decision.Reason, tt.wantReason)
+			}
+			if diff := cmp.Diff(tt.wantReview, gotReview); diff != "" {
+				t.Error("Unexpected SubjectAccessReview (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWithSubjectAccessReviewCached(t *testing.T) {
+	ctx, _ := reconcilertesting.SetupFakeContext(t)
+	features := feature.Flags{
+		feature.OIDCAuthentication:       feature.Enabled,
+		feature.AuthorizationDefaultMode: feature.AuthorizationDenyAll,
+	}
+	target := AuthorizationTarget{
+		GroupResource: schema.GroupResource{Group: "eventing.knative.dev", Resource: "brokers"},
+		Namespace:     "ns",
+		Name:          "broker",
+	}
+
+	reviews := 0
+	allowed := true
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(clientgotesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = allowed
+		return true, review, nil
+	})
+
+	authorizer := NewAuthorizer(eventpolicyinformerfake.Get(ctx).Lister(), nil,
+		WithSubjectAccessReviews(kubeClient.AuthorizationV1().SubjectAccessReviews()))
+	clock := clocktesting.NewFakeClock(time.Now())
+	authorizer.subjectAccessReviews = cache.NewLRUExpireCacheWithClock(subjectAccessReviewCacheSize, clock)
+
+	authorize := func(subject string, target AuthorizationTarget) bool {
+		t.Helper()
+		decision, err := authorizer.Authorize(ctx, features, target, &IDToken{Subject: subject, IssuedByCluster: true})
+		if err != nil {
+			t.Fatal("Authorize() =", err)
+		}
+		return decision.Allowed
+	}
+
+	for i := 0; i < 3; i++ {
+		if !authorize("system:serviceaccount:other:sa", target) {
+			t.Fatal("Expected the subject to be allowed")
+		}
+	}
+	if reviews != 1 {
+		t.Errorf("Got %d SubjectAccessReviews, want the cached decision to be reused", reviews)
+	}
+
+	// other subjects and targets are reviewed on their own
+	authorize("system:serviceaccount:other:other-sa", target)
+	otherTarget := target
+	otherTarget.Name = "other-broker"
+	authorize("system:serviceaccount:other:sa", otherTarget)
+	if reviews != 3 {
+		t.Errorf("Got %d SubjectAccessReviews, want 3", reviews)
+	}
+
+	// the decision is reviewed again once expired, e.g. after a RBAC change
+	allowed = false
+	clock.Step(subjectAccessReviewAllowedTTL + time.Second)
+	if authorize("system:serviceaccount:other:sa", target) {
+		t.Error("Expected the expired decision to be reviewed again")
+	}
+	if reviews != 4 {
+		t.Errorf("Got %d SubjectAccessReviews, want 4", reviews)
+	}
+}
+
+func TestAuthorizeMiddleware(t *testing.T) {
+	target := &AuthorizationTarget{
+		Namespace: "ns",
+		Name:      "broker",
+		Policies: []eventingduckv1.AppliedEventPolicyRef{{
+			Name:       "policy-1",
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		token       *IDToken
+		targetErr   error
+		wantStatus  int
+		wantAllowed string
+		wantReason  string
+	}{{
+		name:        "allowed",
+		token:       &IDToken{Subject: "system:serviceaccount:other:sa"},
+		wantStatus:  http.StatusAccepted,
+		wantAllowed: "true",
+		wantReason:  AuthorizationReasonEventPolicy,
+	}, {
+		name:        "forbidden",
+		token:       &IDToken{Subject: "system:serviceaccount:ns:sa"},
+		wantStatus:  http.StatusForbidden,
+		wantAllowed: "false",
+		wantReason:  AuthorizationReasonEventPolicy,
+	}, {
+		name:        "no token",
+		wantStatus:  http.StatusForbidden,
+		wantAllowed: "false",
+		wantReason:  AuthorizationReasonUnauthenticated,
+	}, {
+		name:       "no target",
+		token:      &IDToken{Subject: "system:serviceaccount:other:sa"},
+		targetErr:  errors.New("unknown broker"),
+		wantStatus: http.StatusBadRequest,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetMetrics()
+
+			ctx, _ := reconcilertesting.SetupFakeContext(t)
+			addEventPolicies(t, ctx)
+			ctx = feature.ToContext(ctx, feature.Flags{
+				feature.OIDCAuthentication:       feature.Enabled,
+				feature.AuthorizationDefaultMode: feature.AuthorizationAllowAll,
+			})
+
+			authorizer := NewAuthorizer(eventpolicyinformerfake.Get(ctx).Lister(), NewAuthorizationStatsReporter())
+			handler := authorizer.AuthorizeMiddleware(func(*http.Request) (*AuthorizationTarget, error) {
+				return target, tt.targetErr
+			}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}))
+
+			if tt.token != nil {
+				ctx = ContextWithIDToken(ctx, tt.token)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/ns/broker", nil).WithContext(ctx)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantStatus {
+				t.Errorf("Got status %d, want %d", resp.Code, tt.wantStatus)
+			}
+
+			if tt.wantAllowed != "" {
+				metricstest.CheckCountData(t, "authorization_decision_count", map[string]string{
+					eventingmetrics.LabelNamespaceName: "ns",
+					eventingmetrics.LabelName:          "broker",
+					"allowed":                          tt.wantAllowed,
+					"reason":                           tt.wantReason,
+				}, 1)
+			} else {
+				metricstest.AssertNoMetric(t, "authorization_decision_count")
+			}
+		})
+	}
+}
+
+func addEventPolicies(t *testing.T, ctx context.Context) {
+	t.Helper()
+
+	policies := []*v1alpha1.EventPolicy{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "policy-1"},
+		Status: v1alpha1.EventPolicyStatus{
+			From: []string{"system:serviceaccount:other:sa"},
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "policy-2"},
+		Status: v1alpha1.EventPolicyStatus{
+			From: []string{"system:serviceaccount:prefixed:*"},
+		},
+	}}
+
+	for _, policy := range policies {
+		if err := eventpolicyinformerfake.Get(ctx).Informer().GetStore().Add(policy); err != nil {
+			t.Fatal("error adding policy:", err)
+		}
+	}
+}
+
+func resetMetrics() {
+	// OpenCensus metrics carry global state that need to be reset between unit tests.
+	metricstest.Unregister(
+		"authorization_decision_count",
+		"oidc_verification_count",
+		"oidc_verification_latencies")
+	register()
+}
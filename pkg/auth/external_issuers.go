@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OIDCConfigMapName is the name of the ConfigMap configuring OIDC authentication.
+	OIDCConfigMapName = "config-oidc"
+
+	// ExternalIssuersKey is the key in the OIDC ConfigMap listing the trusted
+	// external OIDC issuers.
+	ExternalIssuersKey = "external-issuers"
+)
+
+// ExternalIssuer is an OIDC issuer, besides the cluster issuer, whose tokens are trusted.
+type ExternalIssuer struct {
+	// Issuer is the issuer URL as found in the iss claim of the tokens.
+	Issuer string `json:"issuer"`
+
+	// DiscoveryURL is the URL of the OpenID configuration of the issuer.
+	// Defaults to <issuer>/.well-known/openid-configuration.
+	// +optional
+	DiscoveryURL string `json:"discoveryURL,omitempty"`
+
+	// AudienceMappings maps audiences issued by the issuer to audiences of
+	// Knative resources, e.g. to accept tokens for a corporate application
+	// at a Broker.
+	// +optional
+	AudienceMappings map[string]string `json:"audienceMappings,omitempty"`
+}
+
+// NewExternalIssuersFromConfigMap returns the external issuers configured in the given ConfigMap.
+func NewExternalIssuersFromConfigMap(cm *corev1.ConfigMap) ([]ExternalIssuer, error) {
+	raw, ok := cm.Data[ExternalIssuersKey]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var issuers []ExternalIssuer
+	if err := yaml.Unmarshal([]byte(raw), &issuers); err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", ExternalIssuersKey, err)
+	}
+
+	seen := make(map[string]struct{}, len(issuers))
+	for i, issuer := range issuers {
+		if issuer.Issuer == "" {
+			return nil, fmt.Errorf("%s[%d]: issuer is required", ExternalIssuersKey, i)
+		}
+		if _, ok := seen[issuer.Issuer]; ok {
+			return nil, fmt.Errorf("%s[%d]: duplicate issuer %q", ExternalIssuersKey, i, issuer.Issuer)
+		}
+		seen[issuer.Issuer] = struct{}{}
+	}
+
+	return issuers, nil
+}
+
+// UpdateFromConfigMap updates the trusted external issuers from the OIDC ConfigMap.
+// It can be used as a configmap.Observer. Invalid configurations are logged and
+// the previous configuration is kept.
+func (c *OIDCTokenVerifier) UpdateFromConfigMap(cm *corev1.ConfigMap) {
+	issuers, err := NewExternalIssuersFromConfigMap(cm)
+	if err != nil {
+		c.logger.Errorw("Failed to parse OIDC config, keeping previous configuration", zap.Error(err))
+		return
+	}
+
+	external := make(map[string]*externalIssuer, len(issuers))
+	for _, issuer := range issuers {
+		external[issuer.Issuer] = &externalIssuer{ExternalIssuer: issuer}
+	}
+
+	c.externalIssuersMu.Lock()
+	defer c.externalIssuersMu.Unlock()
+	c.externalIssuers = external
+	if c.verificationCache != nil {
+		// cached results may stem from issuers or mappings which are not trusted anymore
+		c.verificationCache.RemoveAll(func(any) bool { return true })
+	}
+}
+
+// externalIssuer is a configured external issuer with its lazily created verifier.
+type externalIssuer struct {
+	ExternalIssuer
+	verifier *oidc.IDTokenVerifier
+}
+
+// externalIssuerFor returns the configured external issuer of the given token or nil,
+// if the token isn't issued by an external issuer.
+func (c *OIDCTokenVerifier) externalIssuerFor(token string) *externalIssuer {
+	c.externalIssuersMu.RLock()
+	defer c.externalIssuersMu.RUnlock()
+	if len(c.externalIssuers) == 0 {
+		return nil
+	}
+
+	// the claims are only used to select the issuer to verify the token with
+	t, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil
+	}
+	var claims jwt.Claims
+	if err := t.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil
+	}
+
+	return c.externalIssuers[claims.Issuer]
+}
+
+// ExternalSubject returns the subject of tokens of an external issuer, as used
+// in EventPolicies. Subjects are prefixed with their issuer, so that identities
+// of different issuers can't be mistaken for each other.
+func ExternalSubject(issuer, subject string) string {
+	return issuer + "#" + subject
+}
+
+// verifyExternalJWT verifies the given JWT of an external issuer for the expected
+// audiences, either directly or through the audience mappings of the issuer.
+// Tokens with subjects reserved for Kubernetes, e.g. system:serviceaccount:*, are
+// rejected.
+func (c *OIDCTokenVerifier) verifyExternalJWT(ctx context.Context, issuer *externalIssuer, jwt string, audiences AudienceMatcher) (*IDToken, error) {
+	verifier, err := c.externalVerifier(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := verifier.Verify(ctx, jwt)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify JWT: %w", verificationError(err))
+	}
+
+	if !issuer.allowsAudience(token.Audience, audiences) {
+		return nil, fmt.Errorf("%w: expected one of the audiences %q got %q", ErrWrongAudience, audiences, token.Audience)
+	}
+
+	// subjects of external issuers must not pass for Kubernetes identities
+	if strings.HasPrefix(token.Subject, "system:") {
+		return nil, fmt.Errorf("%w: subject %q of external issuer %q is reserved for Kubernetes", ErrNotAuthenticated, token.Subject, issuer.Issuer)
+	}
+
+	return &IDToken{
+		Issuer:          token.Issuer,
+		Audience:        token.Audience,
+		Subject:         ExternalSubject(token.Issuer, token.Subject),
+		Expiry:          token.Expiry,
+		IssuedAt:        token.IssuedAt,
+		AccessTokenHash: token.AccessTokenHash,
+	}, nil
+}
+
+// externalVerifier returns the verifier for tokens of the issuer, discovering the
+// issuer's keys on first use.
+func (c *OIDCTokenVerifier) externalVerifier(ctx context.Context, issuer *externalIssuer) (*oidc.IDTokenVerifier, error) {
+	c.externalIssuersMu.RLock()
+	verifier := issuer.verifier
+	c.externalIssuersMu.RUnlock()
+	if verifier != nil {
+		return verifier, nil
+	}
+
+	discoveryURL := issuer.DiscoveryURL
+	if discoveryURL == "" {
+		discoveryURL = strings.TrimSuffix(issuer.Issuer, "/") + "/.well-known/openid-configuration"
+	}
+
+	discovery, err := getOIDCDiscovery(ctx, http.DefaultClient, discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not load OIDC discovery information of issuer %q: %w", issuer.Issuer, err)
+	}
+	if discovery.Issuer != issuer.Issuer {
+		return nil, fmt.Errorf("issuer %q does not match the issuer %q of its discovery information", issuer.Issuer, discovery.Issuer)
+	}
+
+	// the key set must outlive the request context, as it fetches the keys of the issuer later on
+	keySet := oidc.NewRemoteKeySet(context.Background(), discovery.JWKSURI)
+	verifier = oidc.NewVerifier(issuer.Issuer, keySet, &oidc.Config{
+		// audiences are checked separately, taking the audience mappings into account
+		SkipClientIDCheck:    true,
+		SupportedSigningAlgs: discovery.SigningAlgs,
+	})
+
+	c.externalIssuersMu.Lock()
+	defer c.externalIssuersMu.Unlock()
+	if issuer.verifier == nil {
+		issuer.verifier = verifier
+	}
+
+	return issuer.verifier, nil
+}
+
+// allowsAudience checks whether one of the token audiences is, or is mapped to, an accepted audience.
+func (i *externalIssuer) allowsAudience(tokenAudiences []string, audiences AudienceMatcher) bool {
+	for _, aud := range tokenAudiences {
+		if audiences.matches(aud) {
+			return true
+		}
+		if mapped, ok := i.AudienceMappings[aud]; ok && audiences.matches(mapped) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewExternalIssuersFromConfigMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    []ExternalIssuer
+		wantErr bool
+	}{{
+		name: "no issuers",
+		data: map[string]string{},
+	}, {
+		name: "empty issuers",
+		data: map[string]string{ExternalIssuersKey: ""},
+	}, {
+		name: "issuers",
+		data: map[string]string{ExternalIssuersKey: `
+- issuer: https://idp.example.com
+  audienceMappings:
+    api://knative: eventing.knative.dev/broker/ns/name
+- issuer: https://other.example.com
+  discoveryURL: https://other.example.com/tenant/.well-known/openid-configuration
+`},
+		want: []ExternalIssuer{{
+			Issuer: "https://idp.example.com",
+			AudienceMappings: map[string]string{
+				"api://knative": "eventing.knative.dev/broker/ns/name",
+			},
+		}, {
+			Issuer:       "https://other.example.com",
+			DiscoveryURL: "https://other.example.com/tenant/.well-known/openid-configuration",
+		}},
+	}, {
+		name:    "missing issuer",
+		data:    map[string]string{ExternalIssuersKey: `- discoveryURL: https://idp.example.com`},
+		wantErr: true,
+	}, {
+		name: "duplicate issuer",
+		data: map[string]string{ExternalIssuersKey: `
+- issuer: https://idp.example.com
+- issuer: https://idp.example.com
+`},
+		wantErr: true,
+	}, {
+		name:    "invalid",
+		data:    map[string]string{ExternalIssuersKey: `issuer: https://idp.example.com`},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewExternalIssuersFromConfigMap(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: OIDCConfigMapName},
+				Data:       tt.data,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewExternalIssuersFromConfigMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error("Unexpected issuers (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTExternalIssuer(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	key := issuerKeys.add(t, "key")
+	jwks := issuerKeys.serve(t)
+
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(openIDMetadata{
+			Issuer:      idp.URL,
+			JWKSURI:     jwks.URL,
+			SigningAlgs: []string{string(jose.RS256)},
+		}); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(idp.Close)
+
+	brokerAudience := "eventing.knative.dev/broker/ns/name"
+	signSubject := func(issuer string, audience string, subject string) string {
+		return signClaims(t, key, jwt.Claims{
+			Issuer:   issuer,
+			Subject:  subject,
+			Audience: jwt.Audience{audience},
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+	}
+	sign := func(issuer string, audience string) string {
+		return signSubject(issuer, audience, "alice@example.com")
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{{
+		name:  "audience of the resource",
+		token: sign(idp.URL, brokerAudience),
+	}, {
+		name:  "mapped audience",
+		token: sign(idp.URL, "api://knative"),
+	}, {
+		name:    "unmapped audience",
+		token:   sign(idp.URL, "api://other"),
+		wantErr: true,
+	}, {
+		name:    "issuer not trusted",
+		token:   sign("https://untrusted.example.com", brokerAudience),
+		wantErr: true,
+	}, {
+		name:    "service account subject",
+		token:   signSubject(idp.URL, brokerAudience, "system:serviceaccount:ns:sa"),
+		wantErr: true,
+	}}
+
+	verifier := &OIDCTokenVerifier{
+		logger: zap.NewNop().Sugar(),
+	}
+	verifier.UpdateFromConfigMap(&corev1.ConfigMap{
+		Data: map[string]string{ExternalIssuersKey: fmt.Sprintf(`
+- issuer: %s
+  audienceMappings:
+    api://knative: %s
+`, idp.URL, brokerAudience)},
+	})
+
+	// invalid configurations keep the previous one
+	verifier.UpdateFromConfigMap(&corev1.ConfigMap{
+		Data: map[string]string{ExternalIssuersKey: `- discoveryURL: https://idp.example.com`},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := verifier.VerifyJWT(context.Background(), tt.token, brokerAudience)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyJWT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// subjects are prefixed with their issuer
+			wantSubject := idp.URL + "#alice@example.com"
+			if err == nil && token.Subject != wantSubject {
+				t.Errorf("Got subject %q, want %q", token.Subject, wantSubject)
+			}
+		})
+	}
+}
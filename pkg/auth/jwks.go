@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+const (
+	// jwksRefreshPeriod is the maximum age of the cached JSON Web Key Set
+	// before it gets fetched again.
+	jwksRefreshPeriod = 5 * time.Minute
+	// jwksMinRefreshInterval limits how often tokens with an unknown key ID
+	// can trigger a fetch of the JSON Web Key Set.
+	jwksMinRefreshInterval = 10 * time.Second
+)
+
+var (
+	// errUnknownKeyID is returned when a token is signed with a key that is not
+	// part of the issuer's JSON Web Key Set.
+	errUnknownKeyID = errors.New("token is signed with an unknown key id")
+	// errKeySetUnavailable is returned when the JSON Web Key Set can't be fetched
+	// and there is no cached key to verify the token with.
+	errKeySetUnavailable = errors.New("JSON Web Key Set is unavailable")
+)
+
+// jwksKeySet is an oidc.KeySet verifying token signatures against a locally
+// cached copy of the issuer's JSON Web Key Set.
+type jwksKeySet struct {
+	jwksURI string
+	client  *http.Client
+	now     func() time.Time
+
+	// refreshMu serializes fetches of the key set.
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	keys        map[string]jose.JSONWebKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func newJWKSKeySet(jwksURI string, client *http.Client) *jwksKeySet {
+	return &jwksKeySet{
+		jwksURI: jwksURI,
+		client:  client,
+		now:     time.Now,
+		keys:    map[string]jose.JSONWebKey{},
+	}
+}
+
+// VerifySignature implements oidc.KeySet.
+func (s *jwksKeySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	jws, key, err := s.keyFor(ctx, jwt)
+	if err != nil {
+		return nil, err
+	}
+
+	return jws.Verify(&key)
+}
+
+// keyFor returns the parsed token and the key it claims to be signed with.
+// Unknown key IDs trigger a (rate limited) refresh of the key set before
+// errUnknownKeyID is returned.
+func (s *jwksKeySet) keyFor(ctx context.Context, jwt string) (*jose.JSONWebSignature, jose.JSONWebKey, error) {
+	jws, err := jose.ParseSigned(jwt)
+	if err != nil {
+		return nil, jose.JSONWebKey{}, fmt.Errorf("malformed jwt: %w", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, jose.JSONWebKey{}, fmt.Errorf("expected exactly one signature, got %d", len(jws.Signatures))
+	}
+	kid := jws.Signatures[0].Header.KeyID
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	now := s.now()
+	stale := now.Sub(s.fetchedAt) > jwksRefreshPeriod
+	canRefresh := now.Sub(s.attemptedAt) > jwksMinRefreshInterval
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return jws, key, nil
+	}
+	if !canRefresh {
+		if ok {
+			return jws, key, nil
+		}
+		return nil, jose.JSONWebKey{}, errUnknownKeyID
+	}
+
+	if err := s.refresh(ctx, now); err != nil && !ok {
+		return nil, jose.JSONWebKey{}, fmt.Errorf("%w: could not refresh: %v", errKeySetUnavailable, err)
+	}
+
+	s.mu.RLock()
+	key, ok = s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, jose.JSONWebKey{}, errUnknownKeyID
+	}
+
+	return jws, key, nil
+}
+
+// refresh fetches the key set, unless another caller already attempted it
+// after since. On failure the previously cached keys are kept.
+func (s *jwksKeySet) refresh(ctx context.Context, since time.Time) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.Lock()
+	if s.attemptedAt.After(since) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.attemptedAt = s.now()
+	s.mu.Unlock()
+
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.fetchedAt = s.now()
+
+	return nil
+}
+
+func (s *jwksKeySet) fetch(ctx context.Context) (map[string]jose.JSONWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+
+	keySet := jose.JSONWebKeySet{}
+	if err := json.Unmarshal(body, &keySet); err != nil {
+		return nil, fmt.Errorf("could not unmarshal JSON Web Key Set: %w", err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(keySet.Keys))
+	for _, key := range keySet.Keys {
+		keys[key.KeyID] = key
+	}
+
+	return keys, nil
+}
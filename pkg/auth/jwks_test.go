@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	"knative.dev/pkg/metrics/metricstest"
+)
+
+const testIssuer = "https://kubernetes.default.svc.cluster.local"
+
+type testIssuerKeys struct {
+	mu      sync.Mutex
+	keys    []jose.JSONWebKey
+	fetches atomic.Int32
+}
+
+func (k *testIssuerKeys) serve(t *testing.T) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		k.fetches.Add(1)
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		keySet := jose.JSONWebKeySet{}
+		for _, key := range k.keys {
+			keySet.Keys = append(keySet.Keys, key.Public())
+		}
+		if err := json.NewEncoder(w).Encode(keySet); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (k *testIssuerKeys) add(t *testing.T, kid string) jose.JSONWebKey {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := jose.JSONWebKey{Key: priv, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = append(k.keys, key)
+	return key
+}
+
+func signToken(t *testing.T, key jose.JSONWebKey, audience string) string {
+	return signTokenWithExpiry(t, key, audience, time.Now().Add(time.Hour))
+}
+
+func signTokenWithExpiry(t *testing.T, key jose.JSONWebKey, audience string, expiry time.Time) string {
+	return signClaims(t, key, jwt.Claims{
+		Issuer:   testIssuer,
+		Subject:  "system:serviceaccount:ns:sa",
+		Audience: jwt.Audience{audience},
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+		Expiry:   jwt.NewNumericDate(expiry),
+	})
+}
+
+func signClaims(t *testing.T, key jose.JSONWebKey, claims jwt.Claims) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestJWKSKeySetVerifySignature(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	first := issuerKeys.add(t, "first")
+	server := issuerKeys.serve(t)
+
+	now := time.Now()
+	keySet := newJWKSKeySet(server.URL, server.Client())
+	keySet.now = func() time.Time { return now }
+
+	if _, err := keySet.VerifySignature(context.Background(), signToken(t, first, "aud")); err != nil {
+		t.Fatal("VerifySignature() =", err)
+	}
+	if _, err := keySet.VerifySignature(context.Background(), signToken(t, first, "aud")); err != nil {
+		t.Fatal("VerifySignature() =", err)
+	}
+	if got := issuerKeys.fetches.Load(); got != 1 {
+		t.Errorf("Want the key set to be fetched once, got %d", got)
+	}
+
+	// a token signed with a key of the issuer the key set has not seen yet
+	second := issuerKeys.add(t, "second")
+	token := signToken(t, second, "aud")
+
+	now = now.Add(jwksMinRefreshInterval / 2)
+	if _, err := keySet.VerifySignature(context.Background(), token); !errors.Is(err, errUnknownKeyID) {
+		t.Errorf("Want errUnknownKeyID while refreshes are rate limited, got %v", err)
+	}
+
+	now = now.Add(jwksMinRefreshInterval)
+	if _, err := keySet.VerifySignature(context.Background(), token); err != nil {
+		t.Error("VerifySignature() after refresh =", err)
+	}
+
+	unknown := jose.JSONWebKey{Key: first.Key, KeyID: "unknown"}
+	now = now.Add(2 * jwksMinRefreshInterval)
+	if _, err := keySet.VerifySignature(context.Background(), signToken(t, unknown, "aud")); !errors.Is(err, errUnknownKeyID) {
+		t.Errorf("Want errUnknownKeyID, got %v", err)
+	}
+
+	if got := issuerKeys.fetches.Load(); got != 3 {
+		t.Errorf("Want the key set to be fetched 3 times, got %d", got)
+	}
+}
+
+func TestJWKSKeySetPeriodicRefresh(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	key := issuerKeys.add(t, "key")
+	server := issuerKeys.serve(t)
+
+	now := time.Now()
+	keySet := newJWKSKeySet(server.URL, server.Client())
+	keySet.now = func() time.Time { return now }
+
+	token := signToken(t, key, "aud")
+	if _, err := keySet.VerifySignature(context.Background(), token); err != nil {
+		t.Fatal("VerifySignature() =", err)
+	}
+
+	now = now.Add(jwksRefreshPeriod + time.Second)
+	server.Close()
+
+	// the cached keys are still used when the key set can't be refreshed
+	if _, err := keySet.VerifySignature(context.Background(), token); err != nil {
+		t.Error("VerifySignature() with failing refresh =", err)
+	}
+	if got := issuerKeys.fetches.Load(); got != 1 {
+		t.Errorf("Want the key set to be fetched once, got %d", got)
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	known := issuerKeys.add(t, "known")
+	server := issuerKeys.serve(t)
+
+	unknown := jose.JSONWebKey{Key: known.Key, KeyID: "unknown"}
+
+	tests := []struct {
+		name            string
+		token           string
+		authenticated   bool
+		wantErr         error
+		wantResult      string
+		wantTokenReview bool
+	}{{
+		name:       "verified locally",
+		token:      signToken(t, known, "aud"),
+		wantResult: "success",
+	}, {
+		name:       "wrong audience",
+		token:      signToken(t, known, "other"),
+		wantErr:    ErrWrongAudience,
+		wantResult: "wrong_audience",
+	}, {
+		name:       "expired",
+		token:      signTokenWithExpiry(t, known, "aud", time.Now().Add(-time.Hour)),
+		wantErr:    ErrTokenExpired,
+		wantResult: "expired",
+	}, {
+		name: "wrong issuer",
+		token: signClaims(t, known, jwt.Claims{
+			Issuer:   "https://other.example.com",
+			Audience: jwt.Audience{"aud"},
+			Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}),
+		wantErr:    ErrNotAuthenticated,
+		wantResult: "not_authenticated",
+	}, {
+		name:            "unknown key id is verified with token review",
+		token:           signToken(t, unknown, "aud"),
+		authenticated:   true,
+		wantResult:      "success",
+		wantTokenReview: true,
+	}, {
+		name:            "unknown key id not authenticated",
+		token:           signToken(t, unknown, "aud"),
+		wantErr:         ErrNotAuthenticated,
+		wantResult:      "not_authenticated",
+		wantTokenReview: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			tokenReviews := 0
+			kubeClient.PrependReactor("create", "tokenreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				tokenReviews++
+				review := action.(clientgotesting.CreateAction).GetObject().(*authv1.TokenReview)
+				review.Status = authv1.TokenReviewStatus{
+					Authenticated: tc.authenticated,
+					Audiences:     review.Spec.Audiences,
+					User:          authv1.UserInfo{Username: "system:serviceaccount:ns:sa"},
+				}
+				return true, review, nil
+			})
+
+			verifier := &OIDCTokenVerifier{
+				kubeClient:  kubeClient,
+				issuer:      testIssuer,
+				signingAlgs: []string{string(jose.RS256)},
+				keySet:      newJWKSKeySet(server.URL, server.Client()),
+			}
+
+			resetMetrics()
+
+			token, err := verifier.VerifyJWT(context.Background(), tc.token, "aud")
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Fatalf("VerifyJWT() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			metricstest.CheckCountData(t, "oidc_verification_count", map[string]string{
+				"audience": "aud",
+				"result":   tc.wantResult,
+			}, 1)
+			if (tokenReviews > 0) != tc.wantTokenReview {
+				t.Errorf("Got %d token reviews, want token review %v", tokenReviews, tc.wantTokenReview)
+			}
+			if err != nil {
+				return
+			}
+			if token.Issuer != testIssuer || token.Subject != "system:serviceaccount:ns:sa" || len(token.Audience) != 1 || token.Audience[0] != "aud" {
+				t.Errorf("Unexpected token %+v", token)
+			}
+			if token.Expiry.IsZero() {
+				t.Error("Want token expiry to be set")
+			}
+		})
+	}
+}
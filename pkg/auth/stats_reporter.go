@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+
+	eventingmetrics "knative.dev/eventing/pkg/metrics"
+)
+
+var (
+	// authorizationDecisionCountM is a counter which records the number of
+	// authorization decisions taken for requests.
+	authorizationDecisionCountM = stats.Int64(
+		"authorization_decision_count",
+		"Number of authorization decisions taken for requests",
+		stats.UnitDimensionless,
+	)
+
+	// verificationCountM is a counter which records the number of token
+	// verifications by result.
+	verificationCountM = stats.Int64(
+		"oidc_verification_count",
+		"Number of OIDC token verifications",
+		stats.UnitDimensionless,
+	)
+
+	// verificationLatencyInMsecM records the time spent verifying tokens, in milliseconds.
+	verificationLatencyInMsecM = stats.Float64(
+		"oidc_verification_latencies",
+		"The time spent verifying OIDC tokens",
+		stats.UnitMilliseconds,
+	)
+
+	namespaceKey = tag.MustNewKey(eventingmetrics.LabelNamespaceName)
+	nameKey      = tag.MustNewKey(eventingmetrics.LabelName)
+	allowedKey   = tag.MustNewKey("allowed")
+	reasonKey    = tag.MustNewKey("reason")
+	audienceKey  = tag.MustNewKey("audience")
+	resultKey    = tag.MustNewKey("result")
+)
+
+func init() {
+	register()
+}
+
+// AuthorizationStatsReporter reports authorization decisions for auditing.
+type AuthorizationStatsReporter interface {
+	ReportAuthorizationDecision(target *AuthorizationTarget, decision AuthorizationDecision) error
+}
+
+var _ AuthorizationStatsReporter = (*reporter)(nil)
+
+type reporter struct{}
+
+// NewAuthorizationStatsReporter creates a reporter that collects and reports authorization metrics.
+func NewAuthorizationStatsReporter() AuthorizationStatsReporter {
+	return &reporter{}
+}
+
+func register() {
+	err := metrics.RegisterResourceView(
+		&view.View{
+			Description: authorizationDecisionCountM.Description(),
+			Measure:     authorizationDecisionCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, allowedKey, reasonKey},
+		},
+		&view.View{
+			Description: verificationCountM.Description(),
+			Measure:     verificationCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{audienceKey, resultKey},
+		},
+		&view.View{
+			Description: verificationLatencyInMsecM.Description(),
+			Measure:     verificationLatencyInMsecM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
+			TagKeys:     []tag.Key{audienceKey, resultKey},
+		},
+	)
+	if err != nil {
+		log.Print("failed to register opencensus views, " + err.Error())
+	}
+}
+
+// ReportAuthorizationDecision captures an authorization decision.
+func (r *reporter) ReportAuthorizationDecision(target *AuthorizationTarget, decision AuthorizationDecision) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(namespaceKey, target.Namespace),
+		tag.Insert(nameKey, target.Name),
+		tag.Insert(allowedKey, strconv.FormatBool(decision.Allowed)),
+		tag.Insert(reasonKey, decision.Reason))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, authorizationDecisionCountM.M(1))
+	return nil
+}
+
+// reportVerification captures the result and latency of a token verification.
+func reportVerification(audience, result string, d time.Duration) {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(audienceKey, audience),
+		tag.Insert(resultKey, result))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, verificationCountM.M(1))
+	// convert time.Duration in nanoseconds to milliseconds.
+	metrics.Record(ctx, verificationLatencyInMsecM.M(float64(d/time.Millisecond)))
+}
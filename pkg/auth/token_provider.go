@@ -19,6 +19,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -41,6 +42,17 @@ type OIDCTokenProvider struct {
 	logger     *zap.SugaredLogger
 	kubeClient kubernetes.Interface
 	tokenCache cache.Expiring
+
+	// inflight holds the token requests in progress by cache key, so that
+	// concurrent senders missing the cache share a single TokenRequest.
+	inflightMu sync.Mutex
+	inflight   map[string]*tokenRequest
+}
+
+type tokenRequest struct {
+	done  chan struct{}
+	token string
+	err   error
 }
 
 func NewOIDCTokenProvider(ctx context.Context) *OIDCTokenProvider {
@@ -48,6 +60,7 @@ func NewOIDCTokenProvider(ctx context.Context) *OIDCTokenProvider {
 		logger:     logging.FromContext(ctx).With("component", "oidc-token-provider"),
 		kubeClient: kubeclient.Get(ctx),
 		tokenCache: *cache.NewExpiring(),
+		inflight:   map[string]*tokenRequest{},
 	}
 
 	return tokenProvider
@@ -59,8 +72,31 @@ func (c *OIDCTokenProvider) GetJWT(serviceAccount types.NamespacedName, audience
 		return val.(string), nil
 	}
 
-	// if not found in cache: request new token
-	return c.GetNewJWT(serviceAccount, audience)
+	// if not found in cache: request new token, unless it's already requested
+	key := cacheKey(serviceAccount, audience)
+	c.inflightMu.Lock()
+	// the token might have been cached since, by a request which completed meanwhile
+	if val, ok := c.tokenCache.Get(key); ok {
+		c.inflightMu.Unlock()
+		return val.(string), nil
+	}
+	if req, ok := c.inflight[key]; ok {
+		c.inflightMu.Unlock()
+		<-req.done
+		return req.token, req.err
+	}
+	req := &tokenRequest{done: make(chan struct{})}
+	c.inflight[key] = req
+	c.inflightMu.Unlock()
+
+	req.token, req.err = c.GetNewJWT(serviceAccount, audience)
+
+	c.inflightMu.Lock()
+	delete(c.inflight, key)
+	c.inflightMu.Unlock()
+	close(req.done)
+
+	return req.token, req.err
 }
 
 // GetNewJWT returns a new JWT from the given service account for the given audience without using the token cache.
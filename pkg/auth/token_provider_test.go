@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+)
+
+func TestGetJWTConcurrent(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+
+	var requests atomic.Int32
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	kubeClient.PrependReactor("create", "serviceaccounts", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		if requests.Add(1) == 1 {
+			close(requested)
+		}
+		<-release
+		return true, &authv1.TokenRequest{
+			Status: authv1.TokenRequestStatus{
+				Token:               "token",
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(TokenExpirationTime)),
+			},
+		}, nil
+	})
+
+	provider := &OIDCTokenProvider{
+		logger:     zap.NewNop().Sugar(),
+		kubeClient: kubeClient,
+		tokenCache: *cache.NewExpiring(),
+		inflight:   map[string]*tokenRequest{},
+	}
+
+	serviceAccount := types.NamespacedName{Namespace: "ns", Name: "sa"}
+
+	const senders = 10
+	wg := sync.WaitGroup{}
+	wg.Add(senders)
+	for i := 0; i < senders; i++ {
+		go func() {
+			defer wg.Done()
+			token, err := provider.GetJWT(serviceAccount, "aud")
+			if err != nil {
+				t.Error("GetJWT() =", err)
+			}
+			if token != "token" {
+				t.Errorf("Got token %q, want %q", token, "token")
+			}
+		}()
+	}
+
+	<-requested
+	close(release)
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Got %d token requests, want 1", got)
+	}
+
+	if _, err := provider.GetJWT(serviceAccount, "other"); err != nil {
+		t.Error("GetJWT() =", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Got %d token requests after requesting another audience, want 2", got)
+	}
+}
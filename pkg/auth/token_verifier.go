@@ -18,28 +18,84 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"go.uber.org/zap"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"knative.dev/eventing/pkg/apis/feature"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/logging"
 )
 
 const (
 	kubernetesOIDCDiscoveryBaseURL = "https://kubernetes.default.svc"
+
+	// DefaultVerificationCacheSize is the default maximum number of cached verification results.
+	DefaultVerificationCacheSize = 1000
+	// DefaultVerificationCacheTTL is the default maximum time a verification result is cached.
+	DefaultVerificationCacheTTL = 5 * time.Minute
 )
 
 type OIDCTokenVerifier struct {
 	logger     *zap.SugaredLogger
 	restConfig *rest.Config
-	provider   *oidc.Provider
+	kubeClient kubernetes.Interface
+
+	issuer      string
+	signingAlgs []string
+	keySet      *jwksKeySet
+
+	cacheSize         int
+	cacheTTL          time.Duration
+	verificationCache *cache.LRUExpireCache
+
+	externalIssuersMu sync.RWMutex
+	externalIssuers   map[string]*externalIssuer
+}
+
+var (
+	// ErrTokenExpired is returned when the token is expired.
+	ErrTokenExpired = errors.New("token is expired")
+	// ErrWrongAudience is returned when the token is not issued for the expected audience.
+	ErrWrongAudience = errors.New("token has the wrong audience")
+	// ErrNotAuthenticated is returned when the token could not be authenticated, e.g. due
+	// to an invalid signature or an untrusted issuer.
+	ErrNotAuthenticated = errors.New("token is not authenticated")
+)
+
+// OIDCTokenVerifierOption enables further configuration of an OIDCTokenVerifier.
+type OIDCTokenVerifierOption func(*OIDCTokenVerifier)
+
+// WithVerificationCacheSize sets the maximum number of cached verification results.
+// A size of 0 disables the cache.
+func WithVerificationCacheSize(size int) OIDCTokenVerifierOption {
+	return func(v *OIDCTokenVerifier) {
+		v.cacheSize = size
+	}
+}
+
+// WithVerificationCacheTTL sets the maximum time a verification result is cached.
+// Results are never cached beyond the expiry of the token.
+func WithVerificationCacheTTL(ttl time.Duration) OIDCTokenVerifierOption {
+	return func(v *OIDCTokenVerifier) {
+		v.cacheTTL = ttl
+	}
 }
 
 type IDToken struct {
@@ -49,12 +105,26 @@ type IDToken struct {
 	Expiry          time.Time
 	IssuedAt        time.Time
 	AccessTokenHash string
+	// IssuedByCluster is true for tokens verified against the cluster issuer,
+	// whose subjects are Kubernetes identities, e.g. ServiceAccounts.
+	IssuedByCluster bool
 }
 
-func NewOIDCTokenVerifier(ctx context.Context) *OIDCTokenVerifier {
+func NewOIDCTokenVerifier(ctx context.Context, opts ...OIDCTokenVerifierOption) *OIDCTokenVerifier {
 	tokenHandler := &OIDCTokenVerifier{
 		logger:     logging.FromContext(ctx).With("component", "oidc-token-handler"),
 		restConfig: injection.GetConfig(ctx),
+		kubeClient: kubeclient.Get(ctx),
+		cacheSize:  DefaultVerificationCacheSize,
+		cacheTTL:   DefaultVerificationCacheTTL,
+	}
+
+	for _, opt := range opts {
+		opt(tokenHandler)
+	}
+
+	if tokenHandler.cacheSize > 0 && tokenHandler.cacheTTL > 0 {
+		tokenHandler.verificationCache = cache.NewLRUExpireCache(tokenHandler.cacheSize)
 	}
 
 	if err := tokenHandler.initOIDCProvider(ctx); err != nil {
@@ -65,18 +135,86 @@ func NewOIDCTokenVerifier(ctx context.Context) *OIDCTokenVerifier {
 }
 
 // VerifyJWT verifies the given JWT for the expected audience and returns the parsed ID token.
+// Tokens are verified locally against the cached keys of the issuer. Only tokens signed with
+// a key unknown to the issuer's key set are verified through the TokenReview API.
+// Successful verifications are cached until the token expires, at most for the cache TTL.
+//
+// Rejected tokens result in an ErrTokenExpired, ErrWrongAudience or ErrNotAuthenticated error.
+// Other errors point to a misconfiguration or an unavailable issuer.
 func (c *OIDCTokenVerifier) VerifyJWT(ctx context.Context, jwt, audience string) (*IDToken, error) {
-	if c.provider == nil {
-		return nil, fmt.Errorf("provider is nil. Is the OIDC provider config correct?")
+	return c.VerifyJWTForAudiences(ctx, jwt, NewAudienceMatcher(audience))
+}
+
+// VerifyJWTForAudiences verifies the given JWT to be issued for one of the audiences accepted
+// by the matcher, see VerifyJWT.
+func (c *OIDCTokenVerifier) VerifyJWTForAudiences(ctx context.Context, jwt string, audiences AudienceMatcher) (*IDToken, error) {
+	start := time.Now()
+	token, err := c.verifyJWTCached(ctx, jwt, audiences)
+	reportVerification(audiences.Audience(), verificationResult(err), time.Since(start))
+
+	return token, err
+}
+
+func (c *OIDCTokenVerifier) verifyJWTCached(ctx context.Context, jwt string, audiences AudienceMatcher) (*IDToken, error) {
+	if c.verificationCache == nil {
+		return c.verifyJWT(ctx, jwt, audiences)
+	}
+
+	key := verificationCacheKey(jwt, audiences)
+	if cached, ok := c.verificationCache.Get(key); ok {
+		token := cached.(IDToken)
+		return &token, nil
 	}
 
-	verifier := c.provider.Verifier(&oidc.Config{
-		ClientID: audience,
+	token, err := c.verifyJWT(ctx, jwt, audiences)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := c.cacheTTL
+	if !token.Expiry.IsZero() {
+		if untilExpiry := time.Until(token.Expiry); untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	if ttl > 0 {
+		c.verificationCache.Add(key, *token, ttl)
+	}
+
+	return token, nil
+}
+
+func (c *OIDCTokenVerifier) verifyJWT(ctx context.Context, jwt string, audiences AudienceMatcher) (*IDToken, error) {
+	if issuer := c.externalIssuerFor(jwt); issuer != nil {
+		return c.verifyExternalJWT(ctx, issuer, jwt, audiences)
+	}
+
+	if c.keySet == nil {
+		return nil, fmt.Errorf("key set is nil. Is the OIDC provider config correct?")
+	}
+
+	_, _, err := c.keySet.keyFor(ctx, jwt)
+	switch {
+	case errors.Is(err, errUnknownKeyID):
+		return c.verifyJWTWithTokenReview(ctx, jwt, audiences)
+	case errors.Is(err, errKeySetUnavailable):
+		// the token can't be verified, but isn't known to be invalid either
+		return nil, err
+	}
+
+	verifier := oidc.NewVerifier(c.issuer, c.keySet, &oidc.Config{
+		// the audience is checked separately to return ErrWrongAudience
+		SkipClientIDCheck:    true,
+		SupportedSigningAlgs: c.signingAlgs,
 	})
 
 	token, err := verifier.Verify(ctx, jwt)
 	if err != nil {
-		return nil, fmt.Errorf("could not verify JWT: %w", err)
+		return nil, fmt.Errorf("could not verify JWT: %w", verificationError(err))
+	}
+
+	if !audiences.Matches(token.Audience) {
+		return nil, fmt.Errorf("%w: expected one of the audiences %q got %q", ErrWrongAudience, audiences, token.Audience)
 	}
 
 	return &IDToken{
@@ -86,30 +224,107 @@ func (c *OIDCTokenVerifier) VerifyJWT(ctx context.Context, jwt, audience string)
 		Expiry:          token.Expiry,
 		IssuedAt:        token.IssuedAt,
 		AccessTokenHash: token.AccessTokenHash,
+		IssuedByCluster: true,
 	}, nil
 }
 
+// verificationError classifies the error of an oidc.IDTokenVerifier.
+func verificationError(err error) error {
+	var expired *oidc.TokenExpiredError
+	if errors.As(err, &expired) {
+		return fmt.Errorf("%w: %v", ErrTokenExpired, err)
+	}
+	return fmt.Errorf("%w: %v", ErrNotAuthenticated, err)
+}
+
+// verificationResult returns the result of a verification for metrics.
+func verificationResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, ErrWrongAudience):
+		return "wrong_audience"
+	case errors.Is(err, ErrNotAuthenticated):
+		return "not_authenticated"
+	default:
+		return "error"
+	}
+}
+
+// verificationCacheKey keys verification results by a hash of the token, so that
+// the cache doesn't hold credentials.
+func verificationCacheKey(jwt string, audiences AudienceMatcher) string {
+	hash := sha256.Sum256([]byte(jwt))
+	return hex.EncodeToString(hash[:]) + "/" + strings.Join(audiences, ",")
+}
+
+// verifyJWTWithTokenReview lets the API server verify the given JWT for the expected audiences.
+// Prefix patterns of the matcher can't be verified by the API server and are ignored.
+func (c *OIDCTokenVerifier) verifyJWTWithTokenReview(ctx context.Context, token string, audiences AudienceMatcher) (*IDToken, error) {
+	exact := audiences.exact()
+	if len(exact) == 0 {
+		return nil, fmt.Errorf("%w: no exact audience to review the token for in %q", ErrWrongAudience, audiences)
+	}
+
+	review, err := c.kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authv1.TokenReview{
+		Spec: authv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: exact,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not create token review: %w", err)
+	}
+
+	if !review.Status.Authenticated {
+		return nil, fmt.Errorf("could not verify JWT: %w: %s", ErrNotAuthenticated, review.Status.Error)
+	}
+
+	// the API server verified the token, so its claims can be trusted
+	t, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse JWT: %w", err)
+	}
+	var claims jwt.Claims
+	if err := t.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, fmt.Errorf("could not parse JWT claims: %w", err)
+	}
+
+	idToken := &IDToken{
+		Issuer:          claims.Issuer,
+		Audience:        review.Status.Audiences,
+		Subject:         review.Status.User.Username,
+		IssuedByCluster: true,
+	}
+	if claims.Expiry != nil {
+		idToken.Expiry = claims.Expiry.Time()
+	}
+	if claims.IssuedAt != nil {
+		idToken.IssuedAt = claims.IssuedAt.Time()
+	}
+
+	return idToken, nil
+}
+
 func (c *OIDCTokenVerifier) initOIDCProvider(ctx context.Context) error {
 	discovery, err := c.getKubernetesOIDCDiscovery()
 	if err != nil {
 		return fmt.Errorf("could not load Kubernetes OIDC discovery information: %w", err)
 	}
 
-	if discovery.Issuer != kubernetesOIDCDiscoveryBaseURL {
-		// in case we have another issuer as the api server:
-		ctx = oidc.InsecureIssuerURLContext(ctx, discovery.Issuer)
-	}
-
 	httpClient, err := c.getHTTPClientForKubeAPIServer()
 	if err != nil {
 		return fmt.Errorf("could not get HTTP client with TLS certs of API server: %w", err)
 	}
-	ctx = oidc.ClientContext(ctx, httpClient)
 
-	// get OIDC provider
-	c.provider, err = oidc.NewProvider(ctx, kubernetesOIDCDiscoveryBaseURL)
-	if err != nil {
-		return fmt.Errorf("could not get OIDC provider: %w", err)
+	c.issuer = discovery.Issuer
+	c.signingAlgs = discovery.SigningAlgs
+	c.keySet = newJWKSKeySet(discovery.JWKSURI, httpClient)
+
+	if err := c.keySet.refresh(ctx, time.Time{}); err != nil {
+		c.logger.Warn("could not load JSON Web Key Set, retrying on first request", zap.Error(err))
 	}
 
 	c.logger.Debug("updated OIDC provider config", zap.Any("discovery-config", discovery))
@@ -132,7 +347,16 @@ func (c *OIDCTokenVerifier) getKubernetesOIDCDiscovery() (*openIDMetadata, error
 		return nil, fmt.Errorf("could not get HTTP client for API server: %w", err)
 	}
 
-	resp, err := client.Get(kubernetesOIDCDiscoveryBaseURL + "/.well-known/openid-configuration")
+	return getOIDCDiscovery(context.Background(), client, kubernetesOIDCDiscoveryBaseURL+"/.well-known/openid-configuration")
+}
+
+func getOIDCDiscovery(ctx context.Context, client *http.Client, discoveryURL string) (*openIDMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get response: %w", err)
 	}
@@ -153,25 +377,93 @@ func (c *OIDCTokenVerifier) getKubernetesOIDCDiscovery() (*openIDMetadata, error
 
 // VerifyJWTFromRequest will verify the incoming request contains the correct JWT token
 func (tokenVerifier *OIDCTokenVerifier) VerifyJWTFromRequest(ctx context.Context, r *http.Request, audience *string, response http.ResponseWriter) error {
-	token := GetJWTFromHeader(r.Header)
-	if token == "" {
-		response.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("no JWT token found in request")
+	var audiences AudienceMatcher
+	if audience != nil {
+		audiences = NewAudienceMatcher(*audience)
 	}
 
-	if audience == nil {
-		response.WriteHeader(http.StatusInternalServerError)
-		return fmt.Errorf("no audience is provided")
-	}
+	return tokenVerifier.VerifyJWTFromRequestForAudiences(ctx, r, audiences, response)
+}
 
-	if _, err := tokenVerifier.VerifyJWT(ctx, token, *audience); err != nil {
-		response.WriteHeader(http.StatusUnauthorized)
-		return fmt.Errorf("failed to verify JWT: %w", err)
+// VerifyJWTFromRequestForAudiences will verify the incoming request contains a JWT token
+// for one of the audiences accepted by the matcher.
+func (tokenVerifier *OIDCTokenVerifier) VerifyJWTFromRequestForAudiences(ctx context.Context, r *http.Request, audiences AudienceMatcher, response http.ResponseWriter) error {
+	if _, status, err := tokenVerifier.verifyRequest(ctx, r, audiences); err != nil {
+		response.WriteHeader(status)
+		return err
 	}
 
 	return nil
 }
 
+// VerifyMiddleware returns an http.Handler which verifies the JWT of incoming requests
+// for the audience returned by audience, before passing them to next with the verified
+// token in the request context (see IDTokenFromContext). Requests without a valid token
+// are answered with 401 Unauthorized, and requests whose token couldn't be verified,
+// e.g. as the issuer is unavailable, with 503 Service Unavailable. When the OIDC
+// authentication feature is disabled, requests are passed to next without verification.
+func (tokenVerifier *OIDCTokenVerifier) VerifyMiddleware(audience func(*http.Request) string, next http.Handler) http.Handler {
+	return tokenVerifier.VerifyMiddlewareForAudiences(func(r *http.Request) AudienceMatcher {
+		if aud := audience(r); aud != "" {
+			return NewAudienceMatcher(aud)
+		}
+		return nil
+	}, next)
+}
+
+// VerifyMiddlewareForAudiences is like VerifyMiddleware, but accepts the tokens for any
+// of the audiences returned by audiences.
+func (tokenVerifier *OIDCTokenVerifier) VerifyMiddlewareForAudiences(audiences func(*http.Request) AudienceMatcher, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !feature.FromContext(ctx).IsOIDCAuthentication() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, status, err := tokenVerifier.verifyRequest(ctx, r, audiences(r))
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Error when validating the JWT token in the request", zap.Error(err))
+			w.WriteHeader(status)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithIDToken(ctx, token)))
+	})
+}
+
+// verifyRequest verifies the JWT of the request for the given audience. On failure it
+// returns the HTTP status code to respond with.
+func (tokenVerifier *OIDCTokenVerifier) verifyRequest(ctx context.Context, r *http.Request, audiences AudienceMatcher) (*IDToken, int, error) {
+	jwt := GetJWTFromHeader(r.Header)
+	if jwt == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("no JWT token found in request")
+	}
+
+	if len(audiences) == 0 {
+		return nil, http.StatusInternalServerError, fmt.Errorf("no audience is provided")
+	}
+
+	token, err := tokenVerifier.VerifyJWTForAudiences(ctx, jwt, audiences)
+	if err != nil {
+		return nil, verificationStatus(err), fmt.Errorf("failed to verify JWT: %w", err)
+	}
+
+	return token, 0, nil
+}
+
+// verificationStatus returns the HTTP status code to respond with when the
+// verification failed with the given error. Rejected tokens are answered with
+// 401 Unauthorized, while verifications which couldn't be completed, e.g. as
+// the issuer's keys or the TokenReview API are unavailable, are answered with
+// 503 Service Unavailable, so that senders retry.
+func verificationStatus(err error) int {
+	if errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrWrongAudience) || errors.Is(err, ErrNotAuthenticated) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusServiceUnavailable
+}
+
 type openIDMetadata struct {
 	Issuer        string   `json:"issuer"`
 	JWKSURI       string   `json:"jwks_uri"`
@@ -0,0 +1,310 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/google/go-cmp/cmp"
+	authv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+
+	"knative.dev/eventing/pkg/apis/feature"
+)
+
+func TestVerifyJWTCache(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	key := issuerKeys.add(t, "key")
+	server := issuerKeys.serve(t)
+
+	// tokens signed with an unknown key id are verified with a token review,
+	// which lets the test count the verifications which weren't cached.
+	unknown := jose.JSONWebKey{Key: key.Key, KeyID: "unknown"}
+	token := signToken(t, unknown, "aud")
+
+	tests := []struct {
+		name        string
+		cacheSize   int
+		verify      []string
+		token       string
+		wantReviews int
+	}{{
+		name:        "cached",
+		cacheSize:   DefaultVerificationCacheSize,
+		token:       token,
+		verify:      []string{"aud", "aud", "aud"},
+		wantReviews: 1,
+	}, {
+		name:        "cached per audience",
+		cacheSize:   DefaultVerificationCacheSize,
+		token:       token,
+		verify:      []string{"aud", "other", "aud", "other"},
+		wantReviews: 2,
+	}, {
+		name:        "cache disabled",
+		cacheSize:   0,
+		token:       token,
+		verify:      []string{"aud", "aud"},
+		wantReviews: 2,
+	}, {
+		name:        "expired token not cached",
+		cacheSize:   DefaultVerificationCacheSize,
+		token:       signTokenWithExpiry(t, unknown, "aud", time.Now().Add(-time.Minute)),
+		verify:      []string{"aud", "aud"},
+		wantReviews: 2,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			reviews := 0
+			kubeClient.PrependReactor("create", "tokenreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				reviews++
+				review := action.(clientgotesting.CreateAction).GetObject().(*authv1.TokenReview)
+				review.Status = authv1.TokenReviewStatus{
+					Authenticated: true,
+					Audiences:     review.Spec.Audiences,
+				}
+				return true, review, nil
+			})
+
+			verifier := &OIDCTokenVerifier{
+				kubeClient: kubeClient,
+				issuer:     testIssuer,
+				keySet:     newJWKSKeySet(server.URL, server.Client()),
+				cacheTTL:   DefaultVerificationCacheTTL,
+			}
+			if tc.cacheSize > 0 {
+				verifier.verificationCache = cache.NewLRUExpireCache(tc.cacheSize)
+			}
+
+			for _, audience := range tc.verify {
+				got, err := verifier.VerifyJWT(context.Background(), tc.token, audience)
+				if err != nil {
+					t.Fatal("VerifyJWT() =", err)
+				}
+				if len(got.Audience) != 1 || got.Audience[0] != audience {
+					t.Errorf("Got audience %v, want %s", got.Audience, audience)
+				}
+			}
+
+			if reviews != tc.wantReviews {
+				t.Errorf("Got %d token reviews, want %d", reviews, tc.wantReviews)
+			}
+		})
+	}
+}
+
+func TestVerificationCacheKey(t *testing.T) {
+	key := verificationCacheKey("token", NewAudienceMatcher("aud"))
+	if key == verificationCacheKey("token", NewAudienceMatcher("other")) {
+		t.Error("Want different keys for different audiences")
+	}
+	if key == verificationCacheKey("token", NewAudienceMatcher("aud", "legacy")) {
+		t.Error("Want different keys for different additional audiences")
+	}
+	if key == verificationCacheKey("other", NewAudienceMatcher("aud")) {
+		t.Error("Want different keys for different tokens")
+	}
+	if len(key) != 64+len("/aud") {
+		t.Errorf("Want key to contain the hex encoded token hash, got %q", key)
+	}
+}
+
+func TestVerifyMiddleware(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	key := issuerKeys.add(t, "key")
+	server := issuerKeys.serve(t)
+
+	unknown := jose.JSONWebKey{Key: key.Key, KeyID: "unknown"}
+
+	tests := []struct {
+		name            string
+		features        feature.Flags
+		token           string
+		audience        string
+		jwksUnavailable bool
+		tokenReviewErr  error
+		wantStatus      int
+		wantSubject     string
+	}{{
+		name:       "OIDC disabled",
+		features:   feature.Flags{},
+		audience:   "aud",
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:        "valid token",
+		features:    feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:       signToken(t, key, "aud"),
+		audience:    "aud",
+		wantStatus:  http.StatusAccepted,
+		wantSubject: "system:serviceaccount:ns:sa",
+	}, {
+		name:       "no token",
+		features:   feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		audience:   "aud",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "wrong audience",
+		features:   feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:      signToken(t, key, "other"),
+		audience:   "aud",
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "no audience",
+		features:   feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:      signToken(t, key, "aud"),
+		wantStatus: http.StatusInternalServerError,
+	}, {
+		name:            "key set unavailable",
+		features:        feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:           signToken(t, key, "aud"),
+		audience:        "aud",
+		jwksUnavailable: true,
+		wantStatus:      http.StatusServiceUnavailable,
+	}, {
+		name:           "token review fails",
+		features:       feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:          signToken(t, unknown, "aud"),
+		audience:       "aud",
+		tokenReviewErr: errors.New("unavailable"),
+		wantStatus:     http.StatusServiceUnavailable,
+	}, {
+		name:       "token review not authenticated",
+		features:   feature.Flags{feature.OIDCAuthentication: feature.Enabled},
+		token:      signToken(t, unknown, "aud"),
+		audience:   "aud",
+		wantStatus: http.StatusUnauthorized,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "tokenreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				review := action.(clientgotesting.CreateAction).GetObject().(*authv1.TokenReview)
+				return true, review, tc.tokenReviewErr
+			})
+
+			jwksURL := server.URL
+			if tc.jwksUnavailable {
+				jwksURL = "http://127.0.0.1:0/jwks"
+			}
+			verifier := &OIDCTokenVerifier{
+				kubeClient: kubeClient,
+				issuer:     testIssuer,
+				keySet:     newJWKSKeySet(jwksURL, server.Client()),
+			}
+
+			var gotSubject string
+			handler := verifier.VerifyMiddleware(func(*http.Request) string {
+				return tc.audience
+			}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if token := IDTokenFromContext(r.Context()); token != nil {
+					gotSubject = token.Subject
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(feature.ToContext(req.Context(), tc.features))
+			if tc.token != "" {
+				SetAuthHeader(tc.token, req.Header)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			if resp.Code != tc.wantStatus {
+				t.Errorf("Got status %d, want %d", resp.Code, tc.wantStatus)
+			}
+			if gotSubject != tc.wantSubject {
+				t.Errorf("Got subject %q in context, want %q", gotSubject, tc.wantSubject)
+			}
+		})
+	}
+}
+
+func TestVerifyJWTForAudiences(t *testing.T) {
+	issuerKeys := &testIssuerKeys{}
+	key := issuerKeys.add(t, "key")
+	server := issuerKeys.serve(t)
+
+	unknown := jose.JSONWebKey{Key: key.Key, KeyID: "unknown"}
+	audiences := NewAudienceMatcher("new", "legacy", "prefix/*")
+
+	tests := []struct {
+		name         string
+		token        string
+		wantErr      bool
+		wantReviewed []string
+	}{{
+		name:  "audience",
+		token: signToken(t, key, "new"),
+	}, {
+		name:  "additional audience",
+		token: signToken(t, key, "legacy"),
+	}, {
+		name:  "prefix",
+		token: signToken(t, key, "prefix/aud"),
+	}, {
+		name:    "other audience",
+		token:   signToken(t, key, "other"),
+		wantErr: true,
+	}, {
+		name:         "token review for exact audiences",
+		token:        signToken(t, unknown, "legacy"),
+		wantReviewed: []string{"new", "legacy"},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			var reviewed []string
+			kubeClient.PrependReactor("create", "tokenreviews", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				review := action.(clientgotesting.CreateAction).GetObject().(*authv1.TokenReview)
+				reviewed = review.Spec.Audiences
+				review.Status = authv1.TokenReviewStatus{
+					Authenticated: true,
+					Audiences:     []string{"legacy"},
+				}
+				return true, review, nil
+			})
+
+			verifier := &OIDCTokenVerifier{
+				kubeClient: kubeClient,
+				issuer:     testIssuer,
+				keySet:     newJWKSKeySet(server.URL, server.Client()),
+			}
+
+			_, err := verifier.VerifyJWTForAudiences(context.Background(), tc.token, audiences)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("VerifyJWTForAudiences() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantReviewed, reviewed); diff != "" {
+				t.Error("Unexpected reviewed audiences (-want, +got):", diff)
+			}
+		})
+	}
+}
@@ -33,9 +33,11 @@ import (
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/logging"
 
@@ -78,6 +80,13 @@ type Handler struct {
 	reporter StatsReporter
 
 	eventDispatcher *kncloudevents.Dispatcher
+	// certificateExpiry records the expiry of the server certificates of the
+	// subscribers, the expiring ones are reported on their Triggers.
+	certificateExpiry *kncloudevents.CertificateExpiryMonitor
+	// recorder records the events on the Triggers, it may be nil.
+	recorder record.EventRecorder
+	// inFlight limits the events in flight per trigger, see eventingv1.MaxInFlightAnnotation
+	inFlight *kncloudevents.InFlightLimits
 
 	triggerLister    eventinglisters.TriggerLister
 	brokerLister     eventinglisters.BrokerLister
@@ -88,8 +97,37 @@ type Handler struct {
 	EventTypeCreator *eventtype.EventTypeAutoHandler
 }
 
+// shouldPrewarm returns whether the subscriber of trigger is to be prewarmed,
+// that is when the trigger becomes ready or its subscriber changes. old is nil
+// for added triggers.
+func shouldPrewarm(old, trigger *eventingv1.Trigger) bool {
+	if !trigger.Status.IsReady() || trigger.Status.SubscriberURI == nil || trigger.Status.SubscriberURI.Scheme != "https" {
+		return false
+	}
+	return old == nil || !old.Status.IsReady() || old.Status.SubscriberURI.String() != trigger.Status.SubscriberURI.String()
+}
+
+// prewarmSubscriber performs the TLS handshake with the subscriber of a
+// trigger ahead of its first event, once the trigger becomes ready or its
+// subscriber changes, see shouldPrewarm.
+func prewarmSubscriber(logger *zap.Logger, clientConfig eventingtls.ClientConfig, old, trigger *eventingv1.Trigger) {
+	if !shouldPrewarm(old, trigger) {
+		return
+	}
+	addressable := duckv1.Addressable{
+		URL:     trigger.Status.SubscriberURI,
+		CACerts: trigger.Status.SubscriberCACerts,
+	}
+	go func() {
+		err := kncloudevents.PrewarmAddressable(context.Background(), clientConfig, addressable, kncloudevents.PrewarmOptions{Handshake: true})
+		if err != nil {
+			logger.Debug("Failed to prewarm subscriber", zap.String("trigger", trigger.Name), zap.Error(err))
+		}
+	}()
+}
+
 // NewHandler creates a new Handler and its associated EventReceiver.
-func NewHandler(logger *zap.Logger, tokenVerifier *auth.OIDCTokenVerifier, oidcTokenProvider *auth.OIDCTokenProvider, triggerInformer v1.TriggerInformer, brokerInformer v1.BrokerInformer, reporter StatsReporter, trustBundleConfigMapLister corev1listers.ConfigMapNamespaceLister, wc func(ctx context.Context) context.Context) (*Handler, error) {
+func NewHandler(logger *zap.Logger, tokenVerifier *auth.OIDCTokenVerifier, oidcTokenProvider *auth.OIDCTokenProvider, triggerInformer v1.TriggerInformer, brokerInformer v1.BrokerInformer, reporter StatsReporter, recorder record.EventRecorder, trustBundleConfigMapLister corev1listers.ConfigMapNamespaceLister, wc func(ctx context.Context) context.Context) (*Handler, error) {
 	kncloudevents.ConfigureConnectionArgs(&kncloudevents.ConnectionArgs{
 		MaxIdleConns:        defaultMaxIdleConnections,
 		MaxIdleConnsPerHost: defaultMaxIdleConnectionsPerHost,
@@ -113,18 +151,21 @@ func NewHandler(logger *zap.Logger, tokenVerifier *auth.OIDCTokenVerifier, oidcT
 				URL:     trigger.Status.SubscriberURI,
 				CACerts: trigger.Status.SubscriberCACerts,
 			})
+			prewarmSubscriber(logger, clientConfig, nil, trigger)
 		},
-		UpdateFunc: func(_, obj interface{}) {
+		UpdateFunc: func(oldObj, obj interface{}) {
 			trigger, ok := obj.(*eventingv1.Trigger)
 			if !ok {
 				return
 			}
+			old, _ := oldObj.(*eventingv1.Trigger)
 			logger.Debug("Updating filter in filtersMap")
 			fm.Set(trigger, createSubscriptionsAPIFilters(logger, trigger))
 			kncloudevents.AddOrUpdateAddressableHandler(clientConfig, duckv1.Addressable{
 				URL:     trigger.Status.SubscriberURI,
 				CACerts: trigger.Status.SubscriberCACerts,
 			})
+			prewarmSubscriber(logger, clientConfig, old, trigger)
 		},
 		DeleteFunc: func(obj interface{}) {
 			trigger, ok := obj.(*eventingv1.Trigger)
@@ -140,16 +181,54 @@ func NewHandler(logger *zap.Logger, tokenVerifier *auth.OIDCTokenVerifier, oidcT
 		},
 	})
 
-	return &Handler{
-		reporter:        reporter,
-		eventDispatcher: kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider),
-		triggerLister:   triggerInformer.Lister(),
-		brokerLister:    brokerInformer.Lister(),
-		logger:          logger,
-		tokenVerifier:   tokenVerifier,
-		withContext:     wc,
-		filtersMap:      fm,
-	}, nil
+	h := &Handler{
+		reporter:      reporter,
+		inFlight:      kncloudevents.NewInFlightLimits(),
+		triggerLister: triggerInformer.Lister(),
+		brokerLister:  brokerInformer.Lister(),
+		recorder:      recorder,
+		logger:        logger,
+		tokenVerifier: tokenVerifier,
+		withContext:   wc,
+		filtersMap:    fm,
+	}
+	h.certificateExpiry = kncloudevents.NewCertificateExpiryMonitor(kncloudevents.DefaultCertificateExpiryThreshold, h.certificateExpiring)
+	h.eventDispatcher = kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider,
+		kncloudevents.WithCertificateExpiryMonitor(h.certificateExpiry),
+		kncloudevents.WithDeliveryFailureLogger(kncloudevents.NewDeliveryFailureLogger(logger)),
+		// the triggers of a broker often share their subscriber, bound their
+		// retries against it together
+		kncloudevents.WithRetryBudget(kncloudevents.RetryBudgetConfig{}),
+	)
+
+	return h, nil
+}
+
+// RunCertificateExpiryCheck checks the expiry of the server certificates of
+// the subscribers every interval, so that the certificates crossing the
+// expiry threshold while in use are reported, until the context is done.
+func (h *Handler) RunCertificateExpiryCheck(ctx context.Context, interval time.Duration) {
+	h.certificateExpiry.Run(ctx, interval)
+}
+
+// certificateExpiring reports the expiring server certificate of the
+// subscribers at destination with a warning event on their Triggers.
+func (h *Handler) certificateExpiring(destination string, notAfter time.Time) {
+	h.logger.Warn("Server certificate of subscriber expires soon", zap.String("destination", destination), zap.Time("notAfter", notAfter))
+	if h.recorder == nil {
+		return
+	}
+
+	triggers, err := h.triggerLister.List(labels.Everything())
+	if err != nil {
+		h.logger.Warn("Failed to list the triggers of the expiring certificate", zap.String("destination", destination), zap.Error(err))
+		return
+	}
+	for _, trigger := range triggers {
+		if trigger.Status.SubscriberURI != nil && trigger.Status.SubscriberURI.Host == destination {
+			kncloudevents.ExpiringCertificateEventRecorder(h.recorder, trigger)(destination, notAfter)
+		}
+	}
 }
 
 func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
@@ -392,6 +471,19 @@ func (h *Handler) send(ctx context.Context, writer http.ResponseWriter, headers
 
 	opts := []kncloudevents.SendOption{
 		kncloudevents.WithHeader(additionalHeaders),
+		kncloudevents.WithFlowEdge(kncloudevents.FlowEdge{
+			Sender: kncloudevents.FlowComponentBrokerFilter,
+			SenderResource: kncloudevents.FlowResource{
+				Kind:      "Broker",
+				Namespace: t.Namespace,
+				Name:      t.Spec.Broker,
+			},
+			Receiver: kncloudevents.FlowResource{
+				Kind:      "Trigger",
+				Namespace: t.Namespace,
+				Name:      t.Name,
+			},
+		}),
 	}
 
 	if h.EventTypeCreator != nil {
@@ -414,10 +506,27 @@ func (h *Handler) send(ctx context.Context, writer http.ResponseWriter, headers
 		}))
 	}
 
+	if !t.Spec.Delivery.ShouldPreferReply() {
+		opts = append(opts, kncloudevents.WithoutPreferReply())
+	}
+
+	if h.inFlight != nil {
+		opts = append(opts, kncloudevents.WithInFlightLimit(h.inFlight, string(t.UID), t.MaxInFlight(), func(inFlight int) {
+			_ = h.reporter.ReportInFlight(reportArgs, inFlight)
+		}))
+	}
+
 	dispatchInfo, err := h.eventDispatcher.SendEvent(ctx, *event, target, opts...)
+	reportArgs.spanContext = dispatchInfo.SpanContext
+	if errors.Is(err, kncloudevents.ErrConcurrencyLimitExceeded) {
+		// the sender retries the event later, like for any throttling subscriber
+		h.logger.Debug("Too many events in flight for trigger", zap.String("trigger", t.Name), zap.Int("maxInFlight", t.MaxInFlight()))
+		writer.Header().Set("Retry-After", "1")
+		writer.WriteHeader(http.StatusTooManyRequests)
+		_ = h.reporter.ReportEventCount(reportArgs, http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
-		h.logger.Error("failed to send event", zap.Error(err))
-
 		// If error is not because of the response, it should respond with http.StatusInternalServerError
 		if dispatchInfo.ResponseCode <= 0 {
 			writer.WriteHeader(http.StatusInternalServerError)
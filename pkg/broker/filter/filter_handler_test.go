@@ -35,9 +35,12 @@ import (
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/fake"
 	"knative.dev/pkg/logging"
 	reconcilertesting "knative.dev/pkg/reconciler/testing"
@@ -48,6 +51,7 @@ import (
 	"knative.dev/eventing/pkg/auth"
 	"knative.dev/eventing/pkg/broker"
 	"knative.dev/eventing/pkg/eventfilter/subscriptionsapi"
+	"knative.dev/eventing/pkg/kncloudevents"
 
 	brokerinformerfake "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker/fake"
 	triggerinformerfake "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/trigger/fake"
@@ -133,6 +137,14 @@ func TestReceiver(t *testing.T) {
 			expectedEventCount:        true,
 			expectedEventDispatchTime: true,
 		},
+		"Trigger with maxInFlight": {
+			triggers: []*eventingv1.Trigger{
+				makeTrigger(withMaxInFlight("1")),
+			},
+			expectedDispatch:          true,
+			expectedEventCount:        true,
+			expectedEventDispatchTime: true,
+		},
 		"No TTL": {
 			triggers: []*eventingv1.Trigger{
 				makeTrigger(withAttributesFilter(&eventingv1.TriggerFilter{
@@ -470,6 +482,7 @@ func TestReceiver(t *testing.T) {
 				triggerinformerfake.Get(ctx),
 				brokerinformerfake.Get(ctx),
 				reporter,
+				nil,
 				configmapinformer.Get(ctx).Lister().ConfigMaps("ns"),
 				func(ctx context.Context) context.Context {
 					return ctx
@@ -669,6 +682,7 @@ func TestReceiver_WithSubscriptionsAPI(t *testing.T) {
 				triggerinformerfake.Get(ctx),
 				brokerinformerfake.Get(ctx),
 				reporter,
+				nil,
 				configmapinformer.Get(ctx).Lister().ConfigMaps("ns"),
 				func(ctx context.Context) context.Context {
 					return feature.ToContext(context.TODO(), feature.Flags{
@@ -762,6 +776,10 @@ func (r *mockReporter) ReportEventProcessingTime(args *ReportArgs, d time.Durati
 	return nil
 }
 
+func (r *mockReporter) ReportInFlight(args *ReportArgs, inFlight int) error {
+	return nil
+}
+
 type fakeHandler struct {
 	t *testing.T
 
@@ -837,6 +855,118 @@ func (h *fakeHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	}
 }
 
+func TestCompileMatchesTriggerFilters(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	events := []*cloudevents.Event{makeEvent(), makeDifferentEvent(), makeEventWithExtension(extensionName, extensionValue)}
+
+	tests := map[string][]eventingv1.SubscriptionsAPIFilter{
+		"no filters": nil,
+		"exact":      {{Exact: map[string]string{"type": eventType}}},
+		"prefix and suffix": {
+			{Prefix: map[string]string{"source": "/my"}},
+			{Suffix: map[string]string{"id": "34"}},
+		},
+		"any and not": {{
+			Any: []eventingv1.SubscriptionsAPIFilter{
+				{Exact: map[string]string{extensionName: extensionValue}},
+				{Not: &eventingv1.SubscriptionsAPIFilter{Exact: map[string]string{"source": eventSource}}},
+			},
+		}},
+		"cesql":                  {{CESQL: "source LIKE '%context' OR id = 'another-id'"}},
+		"filter without dialect": {{}, {Exact: map[string]string{"id": "1234"}}},
+	}
+	for n, filters := range tests {
+		t.Run(n, func(t *testing.T) {
+			trigger := makeTrigger()
+			trigger.Spec.Filters = filters
+			want := createSubscriptionsAPIFilters(logger, trigger)
+			defer want.Cleanup()
+
+			got, err := subscriptionsapi.Compile(filters)
+			if err != nil {
+				t.Fatal("Compile() =", err)
+			}
+			defer got.Cleanup()
+
+			for _, e := range events {
+				if w, g := want.Filter(context.TODO(), *e), got.Filter(context.TODO(), *e); w != g {
+					t.Errorf("event %s: got %s, want %s like the trigger", e.ID(), g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestShouldPrewarm(t *testing.T) {
+	ready := func(subscriber string) *eventingv1.Trigger {
+		return makeTrigger(withReadySubscriber(subscriber))
+	}
+	notReady := makeTrigger(withReadySubscriber("https://subscriber.example.com"))
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+
+	tests := map[string]struct {
+		old     *eventingv1.Trigger
+		trigger *eventingv1.Trigger
+		want    bool
+	}{
+		"added ready trigger": {
+			trigger: ready("https://subscriber.example.com"),
+			want:    true,
+		},
+		"added trigger not ready": {
+			trigger: notReady,
+		},
+		"plain http subscriber": {
+			trigger: ready("http://subscriber.example.com"),
+		},
+		"trigger becomes ready": {
+			old:     notReady,
+			trigger: ready("https://subscriber.example.com"),
+			want:    true,
+		},
+		"subscriber changes": {
+			old:     ready("https://subscriber.example.com"),
+			trigger: ready("https://other.example.com"),
+			want:    true,
+		},
+		"resync of a ready trigger": {
+			old:     ready("https://subscriber.example.com"),
+			trigger: ready("https://subscriber.example.com"),
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			if got := shouldPrewarm(tc.old, tc.trigger); got != tc.want {
+				t.Errorf("shouldPrewarm() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCertificateExpiringRecordsTriggerEvents(t *testing.T) {
+	ctx, _ := reconcilertesting.SetupFakeContext(t)
+	triggers := triggerinformerfake.Get(ctx).Informer().GetStore()
+	triggers.Add(makeTrigger(withReadySubscriber("https://subscriber.example.com")))
+	other := makeTrigger(withUID("other"), withReadySubscriber("https://other.example.com"))
+	other.Name = "other"
+	triggers.Add(other)
+
+	recorder := record.NewFakeRecorder(10)
+	h := &Handler{
+		logger:        zaptest.NewLogger(t),
+		triggerLister: triggerinformerfake.Get(ctx).Lister(),
+		recorder:      recorder,
+	}
+	h.certificateExpiring("subscriber.example.com", time.Now())
+
+	if got := len(recorder.Events); got != 1 {
+		t.Fatalf("Expected 1 event on the trigger of the subscriber, got %d", got)
+	}
+	if e := <-recorder.Events; !strings.Contains(e, kncloudevents.CertificateExpiringReason) {
+		t.Errorf("Expected a %s event, got %q", kncloudevents.CertificateExpiringReason, e)
+	}
+}
+
 func makeTrigger(options ...TriggerOption) *eventingv1.Trigger {
 	t := &eventingv1.Trigger{
 		TypeMeta: metav1.TypeMeta{
@@ -865,12 +995,28 @@ func withUID(uid string) TriggerOption {
 	}
 }
 
+func withMaxInFlight(maxInFlight string) TriggerOption {
+	return func(t *eventingv1.Trigger) {
+		t.Annotations = map[string]string{eventingv1.MaxInFlightAnnotation: maxInFlight}
+	}
+}
+
 func withAttributesFilter(filter *eventingv1.TriggerFilter) TriggerOption {
 	return func(t *eventingv1.Trigger) {
 		t.Spec.Filter = filter
 	}
 }
 
+func withReadySubscriber(subscriber string) TriggerOption {
+	return func(t *eventingv1.Trigger) {
+		t.Status.SubscriberURI, _ = apis.ParseURL(subscriber)
+		t.Status.Conditions = duckv1.Conditions{{
+			Type:   apis.ConditionReady,
+			Status: corev1.ConditionTrue,
+		}}
+	}
+}
+
 func withoutSubscriberURI() TriggerOption {
 	return func(t *eventingv1.Trigger) {
 		t.Status.SubscriberURI = nil
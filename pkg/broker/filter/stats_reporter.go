@@ -26,6 +26,7 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
 	broker "knative.dev/eventing/pkg/broker"
 	eventingmetrics "knative.dev/eventing/pkg/metrics"
 	"knative.dev/pkg/metrics"
@@ -62,6 +63,14 @@ var (
 		stats.UnitMilliseconds,
 	)
 
+	// inFlightM records the number of events being delivered to the
+	// subscriber of a Trigger.
+	inFlightM = stats.Int64(
+		"event_in_flight",
+		"Number of events being delivered to a Trigger subscriber",
+		stats.UnitDimensionless,
+	)
+
 	// Create the tag keys that will be used to add tags to our measurements.
 	// Tag keys must conform to the restrictions described in
 	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
@@ -81,6 +90,8 @@ type ReportArgs struct {
 	filterType    string
 	requestType   string
 	requestScheme string
+	// spanContext is the span of the dispatch, attached as exemplar to the dispatch time.
+	spanContext trace.SpanContext
 }
 
 func init() {
@@ -92,6 +103,7 @@ type StatsReporter interface {
 	ReportEventCount(args *ReportArgs, responseCode int) error
 	ReportEventDispatchTime(args *ReportArgs, responseCode int, d time.Duration) error
 	ReportEventProcessingTime(args *ReportArgs, d time.Duration) error
+	ReportInFlight(args *ReportArgs, inFlight int) error
 }
 
 var _ StatsReporter = (*reporter)(nil)
@@ -132,6 +144,12 @@ func register() {
 			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 1000, 5000, 10000
 			TagKeys:     []tag.Key{triggerFilterTypeKey, triggerFilterRequestTypeKey, triggerFilterRequestSchemeKey, broker.UniqueTagKey, broker.ContainerTagKey},
 		},
+		&view.View{
+			Description: inFlightM.Description(),
+			Measure:     inFlightM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{broker.UniqueTagKey, broker.ContainerTagKey},
+		},
 	)
 	if err != nil {
 		log.Printf("failed to register opencensus views, %s", err)
@@ -159,7 +177,7 @@ func (r *reporter) ReportEventDispatchTime(args *ReportArgs, responseCode int, d
 		return err
 	}
 	// convert time.Duration in nanoseconds to milliseconds.
-	metrics.Record(ctx, dispatchTimeInMsecM.M(float64(d/time.Millisecond)))
+	metrics.Record(ctx, dispatchTimeInMsecM.M(float64(d/time.Millisecond)), eventingmetrics.ExemplarOptions(args.spanContext)...)
 	return nil
 }
 
@@ -175,6 +193,28 @@ func (r *reporter) ReportEventProcessingTime(args *ReportArgs, d time.Duration)
 	return nil
 }
 
+// ReportInFlight captures the number of events in flight to the subscriber of
+// the trigger. It's tagged by trigger only, since the events in flight have
+// different filter and request types.
+func (r *reporter) ReportInFlight(args *ReportArgs, inFlight int) error {
+	ctx := metricskey.WithResource(emptyContext, resource.Resource{
+		Type: eventingmetrics.ResourceTypeKnativeTrigger,
+		Labels: map[string]string{
+			eventingmetrics.LabelNamespaceName: args.ns,
+			eventingmetrics.LabelBrokerName:    args.broker,
+			eventingmetrics.LabelTriggerName:   args.trigger,
+		},
+	})
+	ctx, err := tag.New(ctx,
+		tag.Insert(broker.ContainerTagKey, r.container),
+		tag.Insert(broker.UniqueTagKey, r.uniqueName))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, inFlightM.M(int64(inFlight)))
+	return nil
+}
+
 func (r *reporter) generateTag(args *ReportArgs, tags ...tag.Mutator) (context.Context, error) {
 	ctx := metricskey.WithResource(emptyContext, resource.Resource{
 		Type: eventingmetrics.ResourceTypeKnativeTrigger,
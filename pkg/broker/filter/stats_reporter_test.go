@@ -90,6 +90,19 @@ func TestStatsReporter(t *testing.T) {
 	})
 	metricstest.AssertMetric(t, metricstest.DistributionCountOnlyMetric("event_processing_latencies", 2, wantTags))
 	metricstest.CheckDistributionData(t, "event_processing_latencies", wantTags, 2, 1000.0, 8000.0)
+
+	// test ReportInFlight
+	expectSuccess(t, func() error {
+		return r.ReportInFlight(args, 3)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportInFlight(args, 2)
+	})
+	wantInFlightTags := map[string]string{
+		broker.LabelContainerName: "testcontainer",
+		broker.LabelUniqueName:    "testpod",
+	}
+	metricstest.AssertMetric(t, metricstest.IntMetric("event_in_flight", 2, wantInFlightTags).WithResource(&resource))
 }
 
 func TestReporterEmptySourceAndTypeFilter(t *testing.T) {
@@ -155,6 +168,7 @@ func resetMetrics() {
 	metricstest.Unregister(
 		"event_count",
 		"event_dispatch_latencies",
-		"event_processing_latencies")
+		"event_processing_latencies",
+		"event_in_flight")
 	register()
 }
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/types"
+
+	"knative.dev/eventing/pkg/kncloudevents"
+	"knative.dev/eventing/pkg/tracing"
+	"knative.dev/eventing/pkg/utils"
+)
+
+// BatchResult is the result of an event of a batch, see BatchResponse.
+type BatchResult struct {
+	// ID is the id of the event, empty if the event has none.
+	ID string `json:"id"`
+	// StatusCode is the status code the event would have been answered with,
+	// if it had been sent on its own.
+	StatusCode int `json:"statusCode"`
+	// Accepted is true if the event was accepted by the broker.
+	Accepted bool `json:"accepted"`
+	// Error describes why the event was rejected.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchResponse is the body of the response to a batch of events, sent in the
+// application/cloudevents-batch+json format. It has a result per event, in the
+// order of the events of the batch. The response status is 202 Accepted if all
+// the events were accepted, 207 Multi-Status otherwise.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// isBatch returns whether the request carries a batch of events.
+func isBatch(request *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get(cehttp.ContentType))
+	return err == nil && mediaType == cloudevents.ApplicationCloudEventsBatchJSON
+}
+
+// readBatch reads the events of the batch of the request, without parsing
+// them, so that invalid events are rejected one by one.
+func readBatch(request *http.Request) ([]json.RawMessage, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	var events []json.RawMessage
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("invalid batch: %w", err)
+	}
+	return events, nil
+}
+
+// batchEventID returns the id of an event of a batch, which isn't necessarily
+// a valid event, empty if it has none.
+func batchEventID(raw json.RawMessage) string {
+	var event struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &event)
+	return event.ID
+}
+
+// serveBatch splits the batch of events of the request and sends each event
+// to the broker. The events share the span of the request, and the limit of
+// the size of the events applies to the batch as a whole.
+func (h *Handler) serveBatch(ctx context.Context, writer http.ResponseWriter, request *http.Request, brokerNamespacedName types.NamespacedName) {
+	events, err := readBatch(request)
+	if err != nil {
+		if kncloudevents.IsBodyTooLarge(err) {
+			h.Logger.Info("Rejected oversized batch", zap.Error(err))
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.Logger.Warn("failed to extract events from batch request", zap.Error(err))
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	broker, err := h.getBroker(brokerNamespacedName.Name, brokerNamespacedName.Namespace)
+	if err != nil {
+		h.Logger.Warn("Failed to retrieve broker", zap.Error(err))
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !h.verifyToken(ctx, writer, request, broker) {
+		return
+	}
+
+	ctx, span := trace.StartSpan(ctx, tracing.BrokerMessagingDestination(brokerNamespacedName))
+	defer span.End()
+
+	if span.IsRecordingEvents() {
+		span.AddAttributes(
+			tracing.MessagingSystemAttribute,
+			tracing.MessagingProtocolHTTP,
+			tracing.BrokerMessagingDestinationAttribute(brokerNamespacedName),
+			trace.Int64Attribute("messaging.batch.message_count", int64(len(events))),
+		)
+	}
+
+	eventScheme := "http"
+	if request.TLS != nil {
+		eventScheme = "https"
+	}
+	headers := utils.PassThroughHeaders(request.Header)
+
+	response := BatchResponse{Results: make([]BatchResult, 0, len(events))}
+	allAccepted := true
+	for _, raw := range events {
+		var event cloudevents.Event
+		err := json.Unmarshal(raw, &event)
+		if err == nil {
+			err = event.Validate()
+		}
+		if err != nil {
+			result := BatchResult{ID: batchEventID(raw)}
+			h.Logger.Warn("failed to extract event from batch", zap.Error(err))
+			result.StatusCode = http.StatusBadRequest
+			result.Error = err.Error()
+			response.Results = append(response.Results, result)
+			allAccepted = false
+			continue
+		}
+
+		result := BatchResult{ID: event.ID()}
+		reporterArgs := &ReportArgs{
+			ns:          brokerNamespacedName.Namespace,
+			broker:      brokerNamespacedName.Name,
+			eventType:   event.Type(),
+			eventScheme: eventScheme,
+		}
+		statusCode, dispatchTime := h.receive(ctx, headers, &event, broker, nil)
+		if dispatchTime > kncloudevents.NoDuration {
+			_ = h.Reporter.ReportEventDispatchTime(reporterArgs, statusCode, dispatchTime)
+		}
+		_ = h.Reporter.ReportEventCount(reporterArgs, statusCode)
+
+		result.StatusCode = statusCode
+		result.Accepted = statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+		if !result.Accepted {
+			result.Error = http.StatusText(statusCode)
+			allAccepted = false
+		}
+		response.Results = append(response.Results, result)
+
+		if h.EvenTypeHandler != nil {
+			h.EvenTypeHandler.AutoCreateEventType(ctx, &event, toKReference(broker), broker.GetUID())
+		}
+	}
+
+	writer.Header().Set(cehttp.ContentType, "application/json")
+	if allAccepted {
+		writer.WriteHeader(http.StatusAccepted)
+	} else {
+		writer.WriteHeader(http.StatusMultiStatus)
+	}
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		h.Logger.Warn("failed to write batch response", zap.Error(err))
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.uber.org/zap"
+	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/fake"
+	reconcilertesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/apis/eventing"
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/broker"
+	brokerinformerfake "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker/fake"
+)
+
+func TestHandler_ServeBatch(t *testing.T) {
+	logger := zap.NewNop()
+
+	valid := func(id string) event.Event {
+		e := event.New()
+		e.SetType("type")
+		e.SetSource("source")
+		e.SetID(id)
+		return e
+	}
+	invalid := event.New()
+	invalid.SetType("type")
+	invalid.SetID("invalid")
+
+	tt := []struct {
+		name        string
+		events      []event.Event
+		statusCode  int
+		wantResults []BatchResult
+		wantSent    int32
+	}{{
+		name:       "all events accepted",
+		events:     []event.Event{valid("1"), valid("2")},
+		statusCode: nethttp.StatusAccepted,
+		wantResults: []BatchResult{
+			{ID: "1", StatusCode: senderResponseStatusCode, Accepted: true},
+			{ID: "2", StatusCode: senderResponseStatusCode, Accepted: true},
+		},
+		wantSent: 2,
+	}, {
+		name:       "invalid event rejected",
+		events:     []event.Event{valid("1"), invalid, valid("2")},
+		statusCode: nethttp.StatusMultiStatus,
+		wantResults: []BatchResult{
+			{ID: "1", StatusCode: senderResponseStatusCode, Accepted: true},
+			{ID: "invalid", StatusCode: nethttp.StatusBadRequest},
+			{ID: "2", StatusCode: senderResponseStatusCode, Accepted: true},
+		},
+		wantSent: 2,
+	}, {
+		name:        "empty batch",
+		events:      []event.Event{},
+		statusCode:  nethttp.StatusAccepted,
+		wantResults: []BatchResult{},
+	}}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, _ := reconcilertesting.SetupFakeContext(t)
+
+			var sent atomic.Int32
+			s := httptest.NewServer(nethttp.HandlerFunc(func(writer nethttp.ResponseWriter, request *nethttp.Request) {
+				sent.Add(1)
+				writer.WriteHeader(senderResponseStatusCode)
+			}))
+			defer s.Close()
+
+			b := makeBroker("name", "ns")
+			b.Status.Annotations = map[string]string{
+				eventing.BrokerChannelAddressStatusAnnotationKey: s.URL,
+			}
+			brokerinformerfake.Get(ctx).Informer().GetStore().Add(b)
+
+			h, err := NewHandler(logger,
+				&mockReporter{},
+				broker.TTLDefaulter(logger, 100),
+				brokerinformerfake.Get(ctx),
+				auth.NewOIDCTokenVerifier(ctx),
+				auth.NewOIDCTokenProvider(ctx),
+				configmapinformer.Get(ctx).Lister().ConfigMaps("ns"),
+				func(ctx context.Context) context.Context {
+					return ctx
+				})
+			if err != nil {
+				t.Fatal("Unable to create receiver:", err)
+			}
+
+			body, err := json.Marshal(tc.events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewReader(body))
+			request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsBatchJSON)
+
+			h.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.statusCode {
+				t.Errorf("expected status code %d got %d", tc.statusCode, recorder.Code)
+			}
+			var response BatchResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal("Failed to unmarshal batch response:", err)
+			}
+			if diff := cmp.Diff(tc.wantResults, response.Results, cmpopts.IgnoreFields(BatchResult{}, "Error")); diff != "" {
+				t.Error("unexpected results (-want +got)", diff)
+			}
+			for _, result := range response.Results {
+				if !result.Accepted && result.Error == "" {
+					t.Errorf("expected an error for the rejected event %q", result.ID)
+				}
+			}
+			if got := sent.Load(); got != tc.wantSent {
+				t.Errorf("expected %d events sent to the channel, got %d", tc.wantSent, got)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeBatchMalformed(t *testing.T) {
+	ctx, _ := reconcilertesting.SetupFakeContext(t)
+	logger := zap.NewNop()
+
+	brokerinformerfake.Get(ctx).Informer().GetStore().Add(makeBroker("name", "ns"))
+	h, err := NewHandler(logger,
+		&mockReporter{},
+		nil,
+		brokerinformerfake.Get(ctx),
+		auth.NewOIDCTokenVerifier(ctx),
+		auth.NewOIDCTokenProvider(ctx),
+		configmapinformer.Get(ctx).Lister().ConfigMaps("ns"),
+		func(ctx context.Context) context.Context {
+			return ctx
+		})
+	if err != nil {
+		t.Fatal("Unable to create receiver:", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", bytes.NewReader([]byte(`{"not": "a batch"}`)))
+	request.Header.Set(cehttp.ContentType, event.ApplicationCloudEventsBatchJSON+"; charset=utf-8")
+
+	h.ServeHTTP(recorder, request)
+
+	if recorder.Code != nethttp.StatusBadRequest {
+		t.Errorf("expected status code %d got %d", nethttp.StatusBadRequest, recorder.Code)
+	}
+}
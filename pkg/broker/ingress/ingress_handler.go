@@ -67,6 +67,9 @@ type Handler struct {
 
 	EvenTypeHandler *eventtype.EventTypeAutoHandler
 
+	// MaxEventSize limits the size of the events accepted by the ingress.
+	MaxEventSize EventSizeLimits
+
 	Logger *zap.Logger
 
 	eventDispatcher *kncloudevents.Dispatcher
@@ -120,12 +123,16 @@ func NewHandler(logger *zap.Logger, reporter StatsReporter, defaulter client.Eve
 		},
 	})
 
+	eventDispatcher := kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider,
+		kncloudevents.WithDeliveryFailureLogger(kncloudevents.NewDeliveryFailureLogger(logger)),
+	)
+
 	return &Handler{
 		Defaulter:       defaulter,
 		Reporter:        reporter,
 		Logger:          logger,
 		BrokerLister:    brokerInformer.Lister(),
-		eventDispatcher: kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider),
+		eventDispatcher: eventDispatcher,
 		tokenVerifier:   tokenVerifier,
 		withContext:     withContext,
 	}, nil
@@ -196,13 +203,38 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if !kncloudevents.LimitRequestBody(writer, request, h.MaxEventSize.ForNamespace(nsBrokerName[1])) {
+		h.Logger.Info("Rejected oversized event", zap.Int64("contentLength", request.ContentLength))
+		return
+	}
+
 	ctx := h.withContext(request.Context())
 
+	if isBatch(request) {
+		h.serveBatch(ctx, writer, request, types.NamespacedName{Namespace: nsBrokerName[1], Name: nsBrokerName[2]})
+		return
+	}
+
 	message := cehttp.NewMessageFromHttpRequest(request)
 	defer message.Finish(nil)
 
-	event, err := binding.ToEvent(ctx, message)
+	// The data of binary mode events is proxied to the channel without being
+	// decoded, unless the event types are created from the events.
+	var proxied *http.Request
+	var event *cloudevents.Event
+	var err error
+	if message.ReadEncoding() == binding.EncodingBinary && h.EvenTypeHandler == nil {
+		proxied = request
+		event, err = binding.ToEvent(ctx, cehttp.NewMessage(request.Header, nil))
+	} else {
+		event, err = binding.ToEvent(ctx, message)
+	}
 	if err != nil {
+		if kncloudevents.IsBodyTooLarge(err) {
+			h.Logger.Info("Rejected oversized event", zap.Error(err))
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
 		h.Logger.Warn("failed to extract event from request", zap.Error(err))
 		writer.WriteHeader(http.StatusBadRequest)
 		return
@@ -230,17 +262,8 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	features := feature.FromContext(ctx)
-	if features.IsOIDCAuthentication() {
-		h.Logger.Debug("OIDC authentication is enabled")
-
-		err = h.tokenVerifier.VerifyJWTFromRequest(ctx, request, broker.Status.Address.Audience, writer)
-		if err != nil {
-			h.Logger.Warn("Error when validating the JWT token in the request", zap.Error(err))
-			return
-		}
-
-		h.Logger.Debug("Request contained a valid JWT. Continuing...")
+	if !h.verifyToken(ctx, writer, request, broker) {
+		return
 	}
 
 	ctx, span := trace.StartSpan(ctx, tracing.BrokerMessagingDestination(brokerNamespacedName))
@@ -268,7 +291,7 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 		reporterArgs.eventScheme = "http"
 	}
 
-	statusCode, dispatchTime := h.receive(ctx, utils.PassThroughHeaders(request.Header), event, broker)
+	statusCode, dispatchTime := h.receive(ctx, utils.PassThroughHeaders(request.Header), event, broker, proxied)
 	if dispatchTime > kncloudevents.NoDuration {
 		_ = h.Reporter.ReportEventDispatchTime(reporterArgs, statusCode, dispatchTime)
 	}
@@ -282,6 +305,45 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// verifyToken verifies the OIDC token of the request, if OIDC authentication
+// is enabled, and returns whether the request may be handled. Otherwise the
+// response was written.
+func (h *Handler) verifyToken(ctx context.Context, writer http.ResponseWriter, request *http.Request, broker *eventingv1.Broker) bool {
+	features := feature.FromContext(ctx)
+	if !features.IsOIDCAuthentication() {
+		return true
+	}
+	h.Logger.Debug("OIDC authentication is enabled")
+
+	audiences := auth.AudienceMatcherFor(broker.Status.Address.Audience, broker.ObjectMeta)
+	err := h.tokenVerifier.VerifyJWTFromRequestForAudiences(ctx, request, audiences, writer)
+	if err != nil {
+		h.Logger.Warn("Error when validating the JWT token in the request", zap.Error(err))
+		return false
+	}
+
+	h.Logger.Debug("Request contained a valid JWT. Continuing...")
+	return true
+}
+
+// EventSizeLimits are the maximum sizes in bytes of the events accepted by
+// the ingress. Zero disables the limit.
+type EventSizeLimits struct {
+	// Default applies to namespaces without override.
+	Default int64
+	// Namespaces overrides the default per namespace.
+	Namespaces map[string]int64
+}
+
+// ForNamespace returns the limit of the events sent to the brokers of the
+// namespace.
+func (l EventSizeLimits) ForNamespace(namespace string) int64 {
+	if limit, ok := l.Namespaces[namespace]; ok {
+		return limit
+	}
+	return l.Default
+}
+
 func toKReference(broker *eventingv1.Broker) *duckv1.KReference {
 	kref := &duckv1.KReference{
 		Kind:       broker.Kind,
@@ -299,7 +361,10 @@ func toKReference(broker *eventingv1.Broker) *duckv1.KReference {
 	return kref
 }
 
-func (h *Handler) receive(ctx context.Context, headers http.Header, event *cloudevents.Event, brokerObj *eventingv1.Broker) (int, time.Duration) {
+// receive sends the event to the channel of the broker. The event of the
+// proxied request, if not nil, is sent from the request with the context
+// attributes of event, see proxy.
+func (h *Handler) receive(ctx context.Context, headers http.Header, event *cloudevents.Event, brokerObj *eventingv1.Broker, proxied *http.Request) (int, time.Duration) {
 	// Setting the extension as a string as the CloudEvents sdk does not support non-string extensions.
 	event.SetExtension(broker.EventArrivalTime, cloudevents.Timestamp{Time: time.Now()})
 	if h.Defaulter != nil {
@@ -319,18 +384,55 @@ func (h *Handler) receive(ctx context.Context, headers http.Header, event *cloud
 	}
 
 	opts := []kncloudevents.SendOption{
-		kncloudevents.WithHeader(headers),
 		kncloudevents.WithOIDCAuthentication(&types.NamespacedName{
 			Name:      "mt-broker-ingress-oidc",
 			Namespace: system.Namespace(),
 		}),
+		kncloudevents.WithFlowEdge(kncloudevents.FlowEdge{
+			Sender: kncloudevents.FlowComponentBrokerIngress,
+			SenderResource: kncloudevents.FlowResource{
+				Kind:      "Broker",
+				Namespace: brokerObj.Namespace,
+				Name:      brokerObj.Name,
+			},
+			Receiver: kncloudevents.FlowResource{
+				Kind:      brokerObj.Status.Annotations[eventing.BrokerChannelKindStatusAnnotationKey],
+				Namespace: brokerObj.Status.Annotations[eventing.BrokerChannelNamespaceStatusAnnotationKey],
+				Name:      brokerObj.Status.Annotations[eventing.BrokerChannelNameStatusAnnotationKey],
+			},
+		}),
 	}
 
-	dispatchInfo, err := h.eventDispatcher.SendEvent(ctx, *event, *channelAddress, opts...)
+	var dispatchInfo *kncloudevents.DispatchInfo
+	if proxied != nil {
+		dispatchInfo, err = h.proxy(ctx, proxied, event, *channelAddress, opts)
+	} else {
+		opts = append([]kncloudevents.SendOption{kncloudevents.WithHeader(headers)}, opts...)
+		dispatchInfo, err = h.eventDispatcher.SendEvent(ctx, *event, *channelAddress, opts...)
+	}
 	if err != nil {
-		h.Logger.Error("failed to dispatch event", zap.Error(err))
+		if kncloudevents.IsBodyTooLarge(err) {
+			h.Logger.Info("Rejected oversized event", zap.Error(err))
+			return http.StatusRequestEntityTooLarge, kncloudevents.NoDuration
+		}
 		return http.StatusInternalServerError, kncloudevents.NoDuration
 	}
 
 	return dispatchInfo.ResponseCode, dispatchInfo.Duration
 }
+
+// proxy sends the binary mode event of the request to the channel without
+// decoding its data, see kncloudevents.NewRequestFromHTTP. The context
+// attributes are rendered from event, which holds the arrival time and the
+// defaulted attributes.
+func (h *Handler) proxy(ctx context.Context, request *http.Request, event *cloudevents.Event, channelAddress duckv1.Addressable, opts []kncloudevents.SendOption) (*kncloudevents.DispatchInfo, error) {
+	req, err := kncloudevents.NewRequestFromHTTP(request.WithContext(ctx), channelAddress)
+	if err != nil {
+		return nil, err
+	}
+	// The event has no data, so only the headers of the request are written.
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(event), req.Request); err != nil {
+		return nil, fmt.Errorf("failed to write the event attributes: %w", err)
+	}
+	return h.eventDispatcher.SendRequest(req, opts...)
+}
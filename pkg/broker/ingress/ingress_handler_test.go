@@ -70,6 +70,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 		reporter        StatsReporter
 		defaulter       client.EventDefaulter
 		brokers         []*eventingv1.Broker
+		maxEventSize    EventSizeLimits
 	}{
 		{
 			name:       "invalid method PATCH",
@@ -159,6 +160,37 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				makeBroker("name", "ns"),
 			},
 		},
+		{
+			name:         "oversized event",
+			method:       nethttp.MethodPost,
+			uri:          "/ns/name",
+			body:         getValidEvent(),
+			statusCode:   nethttp.StatusRequestEntityTooLarge,
+			handler:      handler(),
+			reporter:     &mockReporter{},
+			defaulter:    broker.TTLDefaulter(logger, 100),
+			maxEventSize: EventSizeLimits{Default: 10},
+			brokers: []*eventingv1.Broker{
+				makeBroker("name", "ns"),
+			},
+		},
+		{
+			name:   "event size limit overridden for namespace",
+			method: nethttp.MethodPost,
+			uri:    "/ns/name",
+			body:   getValidEvent(),
+			expectedHeaders: nethttp.Header{
+				"Allow": []string{"PUT, OPTIONS"},
+			},
+			statusCode:   senderResponseStatusCode,
+			handler:      handler(),
+			reporter:     &mockReporter{StatusCode: senderResponseStatusCode, EventDispatchTimeReported: true},
+			defaulter:    broker.TTLDefaulter(logger, 100),
+			maxEventSize: EventSizeLimits{Default: 10, Namespaces: map[string]int64{"ns": 1 << 20}},
+			brokers: []*eventingv1.Broker{
+				makeBroker("name", "ns"),
+			},
+		},
 		{
 			name:       "invalid event",
 			method:     nethttp.MethodPost,
@@ -304,6 +336,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			if err != nil {
 				t.Fatal("Unable to create receiver:", err)
 			}
+			h.MaxEventSize = tc.maxEventSize
 
 			h.ServeHTTP(recorder, request)
 
@@ -329,6 +362,63 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestHandler_ServeHTTPBinaryEvent(t *testing.T) {
+	ctx, _ := reconcilertesting.SetupFakeContext(t)
+	logger := zap.NewNop()
+
+	var received nethttp.Header
+	var body []byte
+	s := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		received = req.Header
+		body, _ = io.ReadAll(req.Body)
+		w.WriteHeader(senderResponseStatusCode)
+	}))
+	defer s.Close()
+
+	b := makeBroker("name", "ns")
+	b.Status.Annotations[eventing.BrokerChannelAddressStatusAnnotationKey] = s.URL
+	brokerinformerfake.Get(ctx).Informer().GetStore().Add(b)
+
+	h, err := NewHandler(logger,
+		&mockReporter{},
+		broker.TTLDefaulter(logger, 100),
+		brokerinformerfake.Get(ctx),
+		auth.NewOIDCTokenVerifier(ctx),
+		auth.NewOIDCTokenProvider(ctx),
+		configmapinformer.Get(ctx).Lister().ConfigMaps("ns"),
+		func(ctx context.Context) context.Context {
+			return ctx
+		})
+	if err != nil {
+		t.Fatal("Unable to create receiver:", err)
+	}
+
+	request := httptest.NewRequest(nethttp.MethodPost, "/ns/name", strings.NewReader(`{"hello":"world"}`))
+	request.Header.Set("Ce-Specversion", "1.0")
+	request.Header.Set("Ce-Id", "1234")
+	request.Header.Set("Ce-Type", "type")
+	request.Header.Set("Ce-Source", "source")
+	request.Header.Set(cehttp.ContentType, "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, request)
+
+	if recorder.Code != senderResponseStatusCode {
+		t.Fatalf("expected status code %d got %d", senderResponseStatusCode, recorder.Code)
+	}
+	// The data is proxied as is, with the attributes set by the ingress.
+	if got := string(body); got != `{"hello":"world"}` {
+		t.Errorf("expected the data of the event, got %q", got)
+	}
+	for _, header := range []string{"Ce-Id", "Ce-Knativearrivaltime", "Ce-Knativebrokerttl"} {
+		if received.Get(header) == "" {
+			t.Errorf("expected header %s, got %v", header, received)
+		}
+	}
+	if got := received.Get(cehttp.ContentType); got != "application/json" {
+		t.Errorf("expected content type application/json, got %q", got)
+	}
+}
+
 type svc struct {
 	receivedHeaders nethttp.Header
 }
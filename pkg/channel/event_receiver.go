@@ -60,6 +60,10 @@ func (e BadRequestError) Error() string {
 	return "malformed request: " + string(e)
 }
 
+// ErrBufferFull is returned by an EventReceiverFunc rejecting an event because the channel holds
+// as many events as it can, the sender is expected to retry later.
+var ErrBufferFull = errors.New("channel buffer is full")
+
 // EventReceiver starts a server to receive new events for the channel dispatcher. The new
 // event is emitted via the receiver function.
 type EventReceiver struct {
@@ -99,7 +103,8 @@ func ResolveChannelFromHostHeader(hostToChannelFunc ResolveChannelFromHostFunc)
 type ResolveChannelFromPathFunc func(string) (ChannelReference, error)
 
 // ResolveChannelFromPath is a ReceiverOption for NewEventReceiver which enables the caller to overwrite the
-// default behaviour defined by ParseChannelFromPath function.
+// default behaviour defined by ParseChannelFromPath function. The channel of the requests to "/" is still
+// resolved from their host, so that one receiver serves both the host and the path based references.
 func ResolveChannelFromPath(PathToChannelFunc ResolveChannelFromPathFunc) EventReceiverOptions {
 	return func(r *EventReceiver) error {
 		r.pathToChannelFunc = PathToChannelFunc
@@ -107,6 +112,9 @@ func ResolveChannelFromPath(PathToChannelFunc ResolveChannelFromPathFunc) EventR
 	}
 }
 
+// OIDCTokenVerification is a ReceiverOption for NewEventReceiver which verifies the OIDC token of the
+// requests for the audience. The requests aren't verified by the receiver without it, e.g. when a
+// kncloudevents.AddressableRouter verifies them.
 func OIDCTokenVerification(tokenVerifier *auth.OIDCTokenVerifier, audience string) EventReceiverOptions {
 	return func(r *EventReceiver) error {
 		r.tokenVerifier = tokenVerifier
@@ -197,13 +205,14 @@ func (r *EventReceiver) ServeHTTP(response nethttp.ResponseWriter, request *neth
 	//   202 - the event was sent to subscribers
 	//   400 - the request was malformed
 	//   404 - the request was for an unknown channel
+	//   429 - the channel buffer is full
 	//   500 - an error occurred processing the request
 	args := ReportArgs{}
 	var channel ChannelReference
 	var err error
 
 	// prefer using pathToChannelFunc if available
-	if r.pathToChannelFunc != nil {
+	if r.pathToChannelFunc != nil && request.URL.Path != "/" {
 		channel, err = r.pathToChannelFunc(request.URL.Path)
 	} else {
 		if request.URL.Path != "/" {
@@ -254,7 +263,7 @@ func (r *EventReceiver) ServeHTTP(response nethttp.ResponseWriter, request *neth
 
 	/// Here we do the OIDC audience verification
 	features := feature.FromContext(ctx)
-	if features.IsOIDCAuthentication() {
+	if features.IsOIDCAuthentication() && r.tokenVerifier != nil {
 		r.logger.Debug("OIDC authentication is enabled")
 		err = r.tokenVerifier.VerifyJWTFromRequest(ctx, request, &r.audience, response)
 		if err != nil {
@@ -268,6 +277,10 @@ func (r *EventReceiver) ServeHTTP(response nethttp.ResponseWriter, request *neth
 	if err != nil {
 		if _, ok := err.(*UnknownChannelError); ok {
 			response.WriteHeader(nethttp.StatusNotFound)
+		} else if errors.Is(err, ErrBufferFull) {
+			r.logger.Debug("Channel buffer is full", zap.String("channel", channel.String()))
+			response.Header().Set("Retry-After", "1")
+			response.WriteHeader(nethttp.StatusTooManyRequests)
 		} else {
 			r.logger.Info("Error in receiver", zap.Error(err))
 			response.WriteHeader(nethttp.StatusInternalServerError)
@@ -80,6 +80,18 @@ func TestEventReceiver_ServeHTTP(t *testing.T) {
 			},
 			expected: nethttp.StatusNotFound,
 		},
+		"buffer full": {
+			receiverFunc: func(_ context.Context, _ ChannelReference, _ event.Event, _ nethttp.Header) error {
+				return fmt.Errorf("test induced overflow: %w", ErrBufferFull)
+			},
+			expected: nethttp.StatusTooManyRequests,
+			responseValidator: func(res httptest.ResponseRecorder) error {
+				if got := res.Header().Get("Retry-After"); got != "1" {
+					return fmt.Errorf("unexpected Retry-After header %q", got)
+				}
+				return nil
+			},
+		},
 		"other receiver function error": {
 			receiverFunc: func(_ context.Context, _ ChannelReference, _ event.Event, _ nethttp.Header) error {
 				return errors.New("test induced receiver function error")
@@ -98,6 +110,17 @@ func TestEventReceiver_ServeHTTP(t *testing.T) {
 			expected: nethttp.StatusAccepted,
 			opts:     []EventReceiverOptions{ResolveChannelFromPath(ParseChannelFromPath)},
 		},
+		"path based receiver with host based channel reference": {
+			host: "test-name.test-namespace.svc." + network.GetClusterDomainName(),
+			receiverFunc: func(ctx context.Context, r ChannelReference, m event.Event, additionalHeaders nethttp.Header) error {
+				if r.Namespace != "test-namespace" || r.Name != "test-name" {
+					return fmt.Errorf("bad channel reference %v", r)
+				}
+				return nil
+			},
+			expected: nethttp.StatusAccepted,
+			opts:     []EventReceiverOptions{ResolveChannelFromPath(ParseChannelFromPath)},
+		},
 		"headers and body pass through": {
 			// The header, body, and host values set here are verified in the receiverFunc. Altering
 			// them here will require the same alteration in the receiverFunc.
@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing/pkg/apis"
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+// bufferDispatchers is the number of events of a buffer dispatched
+// concurrently.
+const bufferDispatchers = 32
+
+// OverflowPolicy selects what happens to an event received while the buffer
+// of the channel is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock rejects the event, the sender gets a 429 response and is
+	// expected to retry later.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest accepts the event and drops the oldest buffered
+	// event in its place.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDeadLetter accepts the event and sends it straight to the dead
+	// letter sinks of the subscriptions, without buffering it.
+	OverflowDeadLetter OverflowPolicy = "dead-letter"
+)
+
+// BufferConfig bounds the events a fanout.EventHandler holds in memory while
+// the subscribers are slow.
+type BufferConfig struct {
+	// Size is the maximum number of events waiting to be dispatched.
+	Size int `json:"size"`
+	// OverflowPolicy is applied to the events received while the buffer is
+	// full, it defaults to OverflowBlock.
+	OverflowPolicy OverflowPolicy `json:"overflowPolicy,omitempty"`
+}
+
+// bufferedEvent is an event accepted by the channel, waiting to be fanned
+// out to the subscriptions.
+type bufferedEvent struct {
+	ctx     context.Context
+	subs    []Subscription
+	event   event.Event
+	headers nethttp.Header
+}
+
+// eventBuffer is a bounded FIFO queue of events.
+type eventBuffer struct {
+	config BufferConfig
+
+	mu      sync.Mutex
+	ready   *sync.Cond
+	pending []bufferedEvent
+	closed  bool
+
+	// takeMu serializes taking an event and starting its fanout, so that
+	// ordered subscriptions receive the events in the order of the buffer.
+	takeMu sync.Mutex
+}
+
+func newEventBuffer(config BufferConfig) *eventBuffer {
+	if config.OverflowPolicy == "" {
+		config.OverflowPolicy = OverflowBlock
+	}
+	b := &eventBuffer{
+		config:  config,
+		pending: make([]bufferedEvent, 0, config.Size),
+	}
+	b.ready = sync.NewCond(&b.mu)
+	return b
+}
+
+// push adds the event to the buffer. When the buffer is full the event is
+// only added with OverflowDropOldest, which evicts and returns the oldest
+// event. It returns false if the event wasn't added.
+func (b *eventBuffer) push(e bufferedEvent) (*bufferedEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, false
+	}
+
+	var dropped *bufferedEvent
+	if len(b.pending) >= b.config.Size {
+		if b.config.OverflowPolicy != OverflowDropOldest || len(b.pending) == 0 {
+			return nil, false
+		}
+		oldest := b.pending[0]
+		b.pending[0] = bufferedEvent{}
+		b.pending = b.pending[1:]
+		dropped = &oldest
+	}
+	b.pending = append(b.pending, e)
+	b.ready.Signal()
+	return dropped, true
+}
+
+// pop waits for the oldest event of the buffer and removes it. It returns
+// false once the buffer is closed and empty.
+func (b *eventBuffer) pop() (bufferedEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.pending) == 0 && !b.closed {
+		b.ready.Wait()
+	}
+	if len(b.pending) == 0 {
+		return bufferedEvent{}, false
+	}
+	e := b.pending[0]
+	b.pending[0] = bufferedEvent{}
+	b.pending = b.pending[1:]
+	return e, true
+}
+
+// close stops accepting events. The events already buffered are still
+// returned by pop.
+func (b *eventBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.ready.Broadcast()
+}
+
+// bufferEvent adds the event to the buffer to be dispatched by
+// dispatchBuffered, applying the overflow policy when the buffer is full.
+func (f *FanoutEventHandler) bufferEvent(ctx context.Context, b *eventBuffer, ref channel.ChannelReference, subs []Subscription, evnt event.Event, additionalHeaders nethttp.Header) error {
+	dropped, ok := b.push(bufferedEvent{
+		// The dispatch outlives the request.
+		ctx:     trace.NewContext(context.Background(), trace.FromContext(ctx)),
+		subs:    subs,
+		event:   evnt,
+		headers: additionalHeaders,
+	})
+	if dropped != nil {
+		f.logger.Warn("Channel buffer is full, dropped the oldest event",
+			zap.String("channel", ref.String()), zap.String("id", dropped.event.ID()))
+		f.reportOverflow(ref, dropped.event, OverflowDropOldest)
+	}
+	if ok {
+		return nil
+	}
+
+	if b.config.OverflowPolicy == OverflowDeadLetter && hasDeadLetterSink(subs) {
+		f.reportOverflow(ref, evnt, OverflowDeadLetter)
+		return f.sendToDeadLetterSinks(ctx, subs, evnt, additionalHeaders)
+	}
+	f.reportOverflow(ref, evnt, OverflowBlock)
+	return channel.ErrBufferFull
+}
+
+// dispatchBuffered dispatches the events of the buffer until it is closed.
+func (f *FanoutEventHandler) dispatchBuffered(b *eventBuffer) {
+	for {
+		b.takeMu.Lock()
+		e, ok := b.pop()
+		if !ok {
+			b.takeMu.Unlock()
+			return
+		}
+		results := f.send(e.ctx, e.subs, e.event, e.headers)
+		b.takeMu.Unlock()
+
+		// Any returned error is already logged in f.collect().
+		_ = f.collect(e.subs, e.event, results)
+	}
+}
+
+// sendToDeadLetterSinks sends the event to the dead letter sink of each
+// subscription having one.
+func (f *FanoutEventHandler) sendToDeadLetterSinks(ctx context.Context, subs []Subscription, evnt event.Event, additionalHeaders nethttp.Header) error {
+	var err error
+	for _, sub := range subs {
+		if sub.DeadLetter == nil {
+			continue
+		}
+		h := additionalHeaders.Clone()
+		h.Set(apis.KnNamespaceHeader, sub.Namespace)
+
+		options := []kncloudevents.SendOption{kncloudevents.WithHeader(h)}
+		if sub.ServiceAccount != nil {
+			options = append(options, kncloudevents.WithOIDCAuthentication(sub.ServiceAccount))
+		}
+		if _, sendErr := f.eventDispatcher.SendEvent(ctx, evnt, *sub.DeadLetter, options...); sendErr != nil {
+			err = fmt.Errorf("failed to send overflowing event to the dead letter sink of %q: %w", sub.Name, sendErr)
+		}
+	}
+	return err
+}
+
+func (f *FanoutEventHandler) reportOverflow(ref channel.ChannelReference, evnt event.Event, policy OverflowPolicy) {
+	args := channel.ReportArgs{
+		Ns:        ref.Namespace,
+		EventType: evnt.Type(),
+	}
+	_ = f.reporter.ReportEventBufferOverflow(&args, string(policy))
+}
+
+func hasDeadLetterSink(subs []Subscription) bool {
+	for _, sub := range subs {
+		if sub.DeadLetter != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fanout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	bindingshttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/injection"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/channel"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+func TestEventBuffer(t *testing.T) {
+	testCases := map[string]struct {
+		policy     OverflowPolicy
+		push       []string
+		wantPushed []bool
+		wantDrops  []string
+		wantPopped []string
+	}{
+		"block rejects the overflowing events": {
+			policy:     OverflowBlock,
+			push:       []string{"1", "2", "3"},
+			wantPushed: []bool{true, true, false},
+			wantPopped: []string{"1", "2"},
+		},
+		"dead-letter rejects the overflowing events": {
+			policy:     OverflowDeadLetter,
+			push:       []string{"1", "2", "3"},
+			wantPushed: []bool{true, true, false},
+			wantPopped: []string{"1", "2"},
+		},
+		"drop-oldest evicts the oldest events": {
+			policy:     OverflowDropOldest,
+			push:       []string{"1", "2", "3", "4"},
+			wantPushed: []bool{true, true, true, true},
+			wantDrops:  []string{"1", "2"},
+			wantPopped: []string{"3", "4"},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			b := newEventBuffer(BufferConfig{Size: 2, OverflowPolicy: tc.policy})
+
+			var drops []string
+			for i, id := range tc.push {
+				dropped, ok := b.push(bufferedEvent{event: makeEventWithID(id)})
+				if ok != tc.wantPushed[i] {
+					t.Errorf("push(%s) = %v, want %v", id, ok, tc.wantPushed[i])
+				}
+				if dropped != nil {
+					drops = append(drops, dropped.event.ID())
+				}
+			}
+			if diff := cmp.Diff(tc.wantDrops, drops); diff != "" {
+				t.Error("unexpected dropped events (-want, +got):", diff)
+			}
+
+			b.close()
+			if _, ok := b.push(bufferedEvent{event: makeEventWithID("closed")}); ok {
+				t.Error("push() succeeded on a closed buffer")
+			}
+
+			var popped []string
+			for {
+				e, ok := b.pop()
+				if !ok {
+					break
+				}
+				popped = append(popped, e.event.ID())
+			}
+			if diff := cmp.Diff(tc.wantPopped, popped); diff != "" {
+				t.Error("unexpected popped events (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestFanoutEventHandler_BufferEvent(t *testing.T) {
+	testCases := map[string]struct {
+		policy         OverflowPolicy
+		deadLetterSink bool
+		wantErr        error
+		wantBuffered   []string
+		wantDeadLetter []string
+	}{
+		"block": {
+			policy:       OverflowBlock,
+			wantErr:      channel.ErrBufferFull,
+			wantBuffered: []string{"1"},
+		},
+		"drop-oldest": {
+			policy:       OverflowDropOldest,
+			wantBuffered: []string{"2"},
+		},
+		"dead-letter": {
+			policy:         OverflowDeadLetter,
+			deadLetterSink: true,
+			wantBuffered:   []string{"1"},
+			wantDeadLetter: []string{"2"},
+		},
+		"dead-letter without dead letter sink": {
+			policy:       OverflowDeadLetter,
+			wantErr:      channel.ErrBufferFull,
+			wantBuffered: []string{"1"},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			deadLetter := newRecordingServer()
+			defer deadLetter.Close()
+
+			sub := Subscription{
+				Subscriber: duckv1.Addressable{URL: apis.HTTP("subscriber.example.com")},
+				Name:       "sub",
+				Namespace:  "ns",
+			}
+			if tc.deadLetterSink {
+				sub.DeadLetter = &duckv1.Addressable{URL: apis.HTTP(deadLetter.URL[7:])}
+			}
+
+			h := newTestFanoutEventHandler(t, Config{})
+			b := newEventBuffer(BufferConfig{Size: 1, OverflowPolicy: tc.policy})
+			ref := channel.ChannelReference{Namespace: "ns", Name: "channel"}
+
+			for _, id := range []string{"1", "2"} {
+				err := h.bufferEvent(context.Background(), b, ref, []Subscription{sub}, makeEventWithID(id), http.Header{})
+				if id == "2" && !errors.Is(err, tc.wantErr) {
+					t.Errorf("bufferEvent(%s) = %v, want %v", id, err, tc.wantErr)
+				}
+			}
+
+			b.close()
+			var buffered []string
+			for {
+				e, ok := b.pop()
+				if !ok {
+					break
+				}
+				buffered = append(buffered, e.event.ID())
+			}
+			if diff := cmp.Diff(tc.wantBuffered, buffered); diff != "" {
+				t.Error("unexpected buffered events (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(tc.wantDeadLetter, deadLetter.ids()); diff != "" {
+				t.Error("unexpected dead lettered events (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestFanoutEventHandler_Buffered(t *testing.T) {
+	subscriber := newRecordingServer()
+	defer subscriber.Close()
+
+	h := newTestFanoutEventHandler(t, Config{
+		Subscriptions: []Subscription{{
+			Subscriber: duckv1.Addressable{URL: apis.HTTP(subscriber.URL[7:])},
+			Name:       "sub",
+			Namespace:  "ns",
+			UID:        "sub-uid",
+			Ordered:    true,
+		}},
+		Buffer: &BufferConfig{Size: 10},
+	})
+	defer h.Close()
+
+	if diff := cmp.Diff(&BufferConfig{Size: 10, OverflowPolicy: OverflowBlock}, h.GetBufferConfig(context.Background())); diff != "" {
+		t.Error("unexpected buffer config (-want, +got):", diff)
+	}
+
+	want := []string{"1", "2", "3", "4", "5"}
+	for _, id := range want {
+		e := makeEventWithID(id)
+		req := httptest.NewRequest(http.MethodPost, "http://channelname.channelnamespace/", nil)
+		if err := bindingshttp.WriteRequest(context.Background(), binding.ToMessage(&e), req); err != nil {
+			t.Fatal("WriteRequest =", err)
+		}
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		if resp.Code != http.StatusAccepted {
+			t.Fatalf("Unexpected status code. Expected %v, Actual %v", http.StatusAccepted, resp.Code)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(subscriber.ids()) < len(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if diff := cmp.Diff(want, subscriber.ids()); diff != "" {
+		t.Error("unexpected delivered events (-want, +got):", diff)
+	}
+}
+
+func newTestFanoutEventHandler(t *testing.T, config Config) *FanoutEventHandler {
+	t.Helper()
+
+	ctx, _ := fakekubeclient.With(context.Background())
+	ctx = injection.WithConfig(ctx, &rest.Config{})
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	h, err := NewFanoutEventHandler(zap.NewNop(), config, channel.NewStatsReporter("testcontainer", "testpod"), nil, nil, nil, dispatcher)
+	if err != nil {
+		t.Fatal("NewFanoutEventHandler failed =", err)
+	}
+	return h
+}
+
+func makeEventWithID(id string) event.Event {
+	e := makeCloudEvent()
+	e.SetID(id)
+	return e
+}
+
+// recordingServer records the ids of the events it receives.
+type recordingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []string
+}
+
+func newRecordingServer() *recordingServer {
+	s := &recordingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e, err := bindingshttp.NewEventFromHTTPRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.received = append(s.received, e.ID())
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return s
+}
+
+func (s *recordingServer) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.received) == 0 {
+		return nil
+	}
+	return append([]string(nil), s.received...)
+}
@@ -55,6 +55,12 @@ type Subscription struct {
 	Name           string
 	Namespace      string
 	UID            types.UID
+	// Ordered delivers the events to the subscriber one at a time, in the
+	// order they were received by the channel.
+	Ordered bool
+	// WithoutPreferReply omits the Prefer: reply header on the requests to
+	// the subscriber.
+	WithoutPreferReply bool
 }
 
 // Config for a fanout.EventHandler.
@@ -63,6 +69,9 @@ type Config struct {
 	// Deprecated: AsyncHandler controls whether the Subscriptions are called synchronous or asynchronously.
 	// It is expected to be false when used as a sidecar.
 	AsyncHandler bool `json:"asyncHandler,omitempty"`
+	// Buffer, when set, accepts the events without waiting for the Subscriptions and holds at most
+	// Buffer.Size of them in memory until they are dispatched.
+	Buffer *BufferConfig `json:"buffer,omitempty"`
 }
 
 // EventHandler is an http.Handler but has methods for managing
@@ -73,6 +82,8 @@ type EventHandler interface {
 	nethttp.Handler
 	SetSubscriptions(ctx context.Context, subs []Subscription)
 	GetSubscriptions(ctx context.Context) []Subscription
+	SetBufferConfig(ctx context.Context, config *BufferConfig)
+	GetBufferConfig(ctx context.Context) *BufferConfig
 }
 
 // FanoutEventHandler is a http.Handler that takes a single request in and fans it out to N other servers.
@@ -84,9 +95,13 @@ type FanoutEventHandler struct {
 	subscriptionsMutex sync.RWMutex
 	subscriptions      []Subscription
 
+	bufferMutex sync.RWMutex
+	buffer      *eventBuffer
+
 	receiver *channel.EventReceiver
 
 	eventDispatcher *kncloudevents.Dispatcher
+	fanoutSender    *kncloudevents.FanoutSender
 
 	// TODO: Plumb context through the receiver and dispatcher and use that to store the timeout,
 	// rather than a member variable.
@@ -121,9 +136,11 @@ func NewFanoutEventHandler(
 		channelRef:       channelRef,
 		channelUID:       channelUID,
 		eventDispatcher:  eventDispatcher,
+		fanoutSender:     kncloudevents.NewFanoutSender(eventDispatcher),
 	}
 
 	handler.SetSubscriptions(context.Background(), config.Subscriptions)
+	handler.SetBufferConfig(context.Background(), config.Buffer)
 
 	// The receiver function needs to point back at the handler itself, so set it up after
 	// initialization.
@@ -171,7 +188,10 @@ func SubscriberSpecToFanoutConfig(sub eventingduckv1.SubscriberSpec) (*Subscript
 		}
 	}
 
-	s := &Subscription{Subscriber: destination, Reply: reply, DeadLetter: deadLetter, RetryConfig: retryConfig, UID: sub.UID}
+	s := &Subscription{Subscriber: destination, Reply: reply, DeadLetter: deadLetter, RetryConfig: retryConfig, UID: sub.UID, Ordered: sub.Ordered}
+	if !sub.Delivery.ShouldPreferReply() {
+		s.WithoutPreferReply = true
+	}
 
 	if sub.Name != nil {
 		s.Name = *sub.Name
@@ -204,6 +224,50 @@ func (f *FanoutEventHandler) GetSubscriptions(ctx context.Context) []Subscriptio
 	return ret
 }
 
+// SetBufferConfig replaces the buffer of the handler. The events held by the
+// previous buffer are still dispatched. A nil config, or a config without a
+// size, dispatches the events without buffering them.
+func (f *FanoutEventHandler) SetBufferConfig(ctx context.Context, config *BufferConfig) {
+	f.bufferMutex.Lock()
+	old := f.buffer
+	if config != nil && config.Size > 0 {
+		f.buffer = newEventBuffer(*config)
+		for i := 0; i < bufferDispatchers; i++ {
+			go f.dispatchBuffered(f.buffer)
+		}
+	} else {
+		f.buffer = nil
+	}
+	f.bufferMutex.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+}
+
+func (f *FanoutEventHandler) GetBufferConfig(ctx context.Context) *BufferConfig {
+	f.bufferMutex.RLock()
+	defer f.bufferMutex.RUnlock()
+	if f.buffer == nil {
+		return nil
+	}
+	config := f.buffer.config
+	return &config
+}
+
+// Close stops the dispatchers of the buffer once the buffered events are
+// dispatched. The events received afterwards aren't buffered.
+func (f *FanoutEventHandler) Close() error {
+	f.SetBufferConfig(context.Background(), nil)
+	return nil
+}
+
+func (f *FanoutEventHandler) getBuffer() *eventBuffer {
+	f.bufferMutex.RLock()
+	defer f.bufferMutex.RUnlock()
+	return f.buffer
+}
+
 func (f *FanoutEventHandler) autoCreateEventType(ctx context.Context, evnt event.Event) {
 	if f.channelRef == nil {
 		f.logger.Warn("No addressable for channel")
@@ -230,6 +294,10 @@ func createEventReceiverFunction(f *FanoutEventHandler) func(context.Context, ch
 				return nil
 			}
 
+			if b := f.getBuffer(); b != nil {
+				return f.bufferEvent(ctx, b, ref, subs, evnt, additionalHeaders)
+			}
+
 			parentSpan := trace.FromContext(ctx)
 
 			go func(e event.Event, h nethttp.Header, s *trace.Span) {
@@ -253,6 +321,10 @@ func createEventReceiverFunction(f *FanoutEventHandler) func(context.Context, ch
 			return nil
 		}
 
+		if b := f.getBuffer(); b != nil {
+			return f.bufferEvent(ctx, b, ref, subs, event, additionalHeaders)
+		}
+
 		// Any returned error is already logged in f.dispatch().
 		dispatchResultForFanout := f.dispatch(ctx, subs, event, additionalHeaders)
 		return dispatchResultForFanout.err
@@ -281,28 +353,32 @@ func ParseDispatchResultAndReportMetrics(result DispatchResult, reporter channel
 	return err
 }
 
-// dispatch takes the event, fans it out to each subscription in subs. If all the fanned out
-// events return successfully, then return nil. Else, return an error.
+// dispatch takes the event, fans it out to each subscription in subs. The subscriptions
+// retry and dead letter the event on their own, so an error is only returned if the
+// event couldn't be sent to all of them in time.
 func (f *FanoutEventHandler) dispatch(ctx context.Context, subs []Subscription, event event.Event, additionalHeaders nethttp.Header) DispatchResult {
-	results := make(chan DispatchResult, len(subs))
+	return f.collect(subs, event, f.send(ctx, subs, event, additionalHeaders))
+}
+
+// send starts the fanout of the event to each subscription in subs.
+func (f *FanoutEventHandler) send(ctx context.Context, subs []Subscription, event event.Event, additionalHeaders nethttp.Header) <-chan kncloudevents.FanoutResult {
+	targets := make([]kncloudevents.FanoutTarget, 0, len(subs))
 	for _, sub := range subs {
-		go func(s Subscription) {
-			h := additionalHeaders.Clone()
-			h.Set(apis.KnNamespaceHeader, s.Namespace)
-
-			dispatchedResultPerSub, err := f.makeFanoutRequest(ctx, event, h, s)
-			r := DispatchResult{err: err, info: dispatchedResultPerSub}
-			results <- r
-
-			args := channel.ReportArgs{
-				Ns:          s.Namespace,
-				EventType:   event.Type(),
-				EventScheme: r.info.Scheme,
-			}
-			_ = ParseDispatchResultAndReportMetrics(r, f.reporter, args)
-		}(sub)
+		h := additionalHeaders.Clone()
+		h.Set(apis.KnNamespaceHeader, sub.Namespace)
+
+		targets = append(targets, kncloudevents.FanoutTarget{
+			Key:         subscriptionKey(sub),
+			Destination: sub.Subscriber,
+			Ordered:     sub.Ordered,
+			Options:     f.sendOptions(h, sub),
+		})
 	}
+	return f.fanoutSender.Send(ctx, event, targets)
+}
 
+// collect waits for the results of the fanout of the event and reports them.
+func (f *FanoutEventHandler) collect(subs []Subscription, event event.Event, results <-chan kncloudevents.FanoutResult) DispatchResult {
 	var totalDispatchTimeForFanout time.Duration = kncloudevents.NoDuration
 	dispatchResultForFanout := DispatchResult{
 		info: &kncloudevents.DispatchInfo{
@@ -310,9 +386,10 @@ func (f *FanoutEventHandler) dispatch(ctx context.Context, subs []Subscription,
 			ResponseCode: kncloudevents.NoResponse,
 		},
 	}
-	for range subs {
+	for i := range subs {
 		select {
-		case dispatchResult := <-results:
+		case result := <-results:
+			dispatchResult := f.reportResult(subs[result.Index], event, result)
 			if dispatchResult.info != nil {
 				if dispatchResult.info.Duration > kncloudevents.NoDuration {
 					if totalDispatchTimeForFanout > kncloudevents.NoDuration {
@@ -324,28 +401,81 @@ func (f *FanoutEventHandler) dispatch(ctx context.Context, subs []Subscription,
 				dispatchResultForFanout.info.Duration = totalDispatchTimeForFanout
 				dispatchResultForFanout.info.ResponseCode = dispatchResult.info.ResponseCode
 			}
-			if dispatchResult.err != nil {
-				f.logger.Error("Fanout had an error", zap.Error(dispatchResult.err))
+			// the failed deliveries went through the retries and the dead letter sink of
+			// their subscription, failing the fanout would resend the event to all of them
+			if dispatchResult.err != nil && !result.Sent {
 				dispatchResultForFanout.err = dispatchResult.err
 			}
 		case <-time.After(f.timeout):
 			f.logger.Error("Fanout timed out")
+			// Keep reporting the metrics of the pending deliveries.
+			go func(pending int) {
+				for j := 0; j < pending; j++ {
+					result := <-results
+					f.reportResult(subs[result.Index], event, result)
+				}
+			}(len(subs) - i)
 			dispatchResultForFanout.err = errors.New("fanout timed out")
 			return dispatchResultForFanout
 		}
 	}
-	// All Subscriptions returned err = nil.
+	// The event was sent to all the Subscriptions.
 	return dispatchResultForFanout
 }
 
-// makeFanoutRequest sends the request to exactly one subscription. It handles both the `call` and
-// the `sink` portions of the subscription.
-func (f *FanoutEventHandler) makeFanoutRequest(ctx context.Context, event event.Event, additionalHeaders nethttp.Header, sub Subscription) (*kncloudevents.DispatchInfo, error) {
+// reportResult records the metrics of the delivery of an event to a single
+// subscription.
+func (f *FanoutEventHandler) reportResult(sub Subscription, event event.Event, result kncloudevents.FanoutResult) DispatchResult {
+	r := DispatchResult{err: result.Err, info: result.Info}
+	if r.info == nil {
+		r.info = &kncloudevents.DispatchInfo{
+			Duration:     kncloudevents.NoDuration,
+			ResponseCode: kncloudevents.NoResponse,
+		}
+	}
+
+	args := channel.ReportArgs{
+		Ns:          sub.Namespace,
+		EventType:   event.Type(),
+		EventScheme: r.info.Scheme,
+	}
+	_ = ParseDispatchResultAndReportMetrics(r, f.reporter, args)
+
+	if r.info.Duration > kncloudevents.NoDuration {
+		responseCode := r.info.ResponseCode
+		if responseCode <= kncloudevents.NoResponse {
+			responseCode = nethttp.StatusInternalServerError
+		}
+		_ = f.reporter.ReportSubscriberDispatchTime(&args, sub.Name, responseCode, r.info.Duration)
+	}
+	return r
+}
+
+// subscriptionKey identifies the subscription across fanouts.
+func subscriptionKey(sub Subscription) string {
+	if sub.UID != "" {
+		return string(sub.UID)
+	}
+	return sub.Namespace + "/" + sub.Name + "/" + sub.Subscriber.URL.String()
+}
+
+// sendOptions returns the options to send the request to exactly one subscription. They handle
+// both the `call` and the `sink` portions of the subscription.
+func (f *FanoutEventHandler) sendOptions(additionalHeaders nethttp.Header, sub Subscription) []kncloudevents.SendOption {
 	dispatchOptions := []kncloudevents.SendOption{
 		kncloudevents.WithHeader(additionalHeaders),
 		kncloudevents.WithReply(sub.Reply),
 		kncloudevents.WithDeadLetterSink(sub.DeadLetter),
 		kncloudevents.WithRetryConfig(sub.RetryConfig),
+		kncloudevents.WithFlowEdge(kncloudevents.FlowEdge{
+			Sender:         kncloudevents.FlowComponentChannelDispatcher,
+			SenderResource: f.channelResource(),
+			Receiver: kncloudevents.FlowResource{
+				Kind:      "Subscription",
+				Namespace: sub.Namespace,
+				Name:      sub.Name,
+			},
+		}),
 	}
 
 	if f.eventTypeHandler != nil && sub.Name != "" && sub.Namespace != "" && sub.UID != types.UID("") {
@@ -365,7 +495,23 @@ func (f *FanoutEventHandler) makeFanoutRequest(ctx context.Context, event event.
 		dispatchOptions = append(dispatchOptions, kncloudevents.WithOIDCAuthentication(sub.ServiceAccount))
 	}
 
-	return f.eventDispatcher.SendEvent(ctx, event, sub.Subscriber, dispatchOptions...)
+	if sub.WithoutPreferReply {
+		dispatchOptions = append(dispatchOptions, kncloudevents.WithoutPreferReply())
+	}
+
+	return dispatchOptions
+}
+
+// channelResource returns the channel as the sender of the topology metrics.
+func (f *FanoutEventHandler) channelResource() kncloudevents.FlowResource {
+	if f.channelRef == nil {
+		return kncloudevents.FlowResource{Kind: "Channel"}
+	}
+	return kncloudevents.FlowResource{
+		Kind:      f.channelRef.Kind,
+		Namespace: f.channelRef.Namespace,
+		Name:      f.channelRef.Name,
+	}
 }
 
 type DispatchResult struct {
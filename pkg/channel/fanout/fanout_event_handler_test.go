@@ -89,7 +89,9 @@ func TestSubscriberSpecToFanoutConfig(t *testing.T) {
 			Retry:         pointer.Int32(3),
 			BackoffPolicy: &linear,
 			BackoffDelay:  &delay,
+			PreferReply:   pointer.Bool(false),
 		},
+		Ordered: true,
 	}
 	want := Subscription{
 		Subscriber: duckv1.Addressable{
@@ -109,6 +111,8 @@ func TestSubscriberSpecToFanoutConfig(t *testing.T) {
 			BackoffPolicy: &linear,
 			BackoffDelay:  &delay,
 		},
+		Ordered:            true,
+		WithoutPreferReply: true,
 	}
 	got, err := SubscriberSpecToFanoutConfig(*spec)
 	if err != nil {
@@ -224,7 +228,7 @@ func TestFanoutEventHandler_ServeHTTP(t *testing.T) {
 				writer.WriteHeader(http.StatusNotFound)
 			},
 			replierReqs:         1,
-			expectedStatus:      http.StatusInternalServerError,
+			expectedStatus:      http.StatusAccepted,
 			asyncExpectedStatus: http.StatusAccepted,
 		},
 		"subscriber fails": {
@@ -237,7 +241,7 @@ func TestFanoutEventHandler_ServeHTTP(t *testing.T) {
 				writer.WriteHeader(http.StatusNotFound)
 			},
 			subscriberReqs:      1,
-			expectedStatus:      http.StatusInternalServerError,
+			expectedStatus:      http.StatusAccepted,
 			asyncExpectedStatus: http.StatusAccepted,
 		},
 		"subscriber succeeds, result fails": {
@@ -253,7 +257,7 @@ func TestFanoutEventHandler_ServeHTTP(t *testing.T) {
 			},
 			subscriberReqs:      1,
 			replierReqs:         1,
-			expectedStatus:      http.StatusInternalServerError,
+			expectedStatus:      http.StatusAccepted,
 			asyncExpectedStatus: http.StatusAccepted,
 		},
 		"one sub succeeds": {
@@ -287,7 +291,7 @@ func TestFanoutEventHandler_ServeHTTP(t *testing.T) {
 			replier:             (&succeedOnce{}).handler,
 			subscriberReqs:      2,
 			replierReqs:         2,
-			expectedStatus:      http.StatusInternalServerError,
+			expectedStatus:      http.StatusAccepted,
 			asyncExpectedStatus: http.StatusAccepted,
 		},
 		"all subs succeed": {
@@ -325,6 +329,28 @@ func TestFanoutEventHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestFanoutEventHandler_CollectNotSent(t *testing.T) {
+	h := &FanoutEventHandler{
+		logger:   zap.NewNop(),
+		reporter: channel.NewStatsReporter("testcontainer", "testpod"),
+		timeout:  time.Second,
+	}
+	subs := []Subscription{{Namespace: "ns", Name: "delivered"}, {Namespace: "ns", Name: "not-sent"}}
+
+	results := make(chan kncloudevents.FanoutResult, len(subs))
+	results <- kncloudevents.FanoutResult{Index: 0, Err: errors.New("delivery failed"), Sent: true}
+	results <- kncloudevents.FanoutResult{Index: 1, Err: context.Canceled}
+	if got := h.collect(subs, test.FullEvent(), results); !errors.Is(got.err, context.Canceled) {
+		t.Errorf("Expected the error of the event not sent, got %v", got.err)
+	}
+
+	results <- kncloudevents.FanoutResult{Index: 0, Err: errors.New("delivery failed"), Sent: true}
+	results <- kncloudevents.FanoutResult{Index: 1, Sent: true}
+	if got := h.collect(subs, test.FullEvent(), results); got.err != nil {
+		t.Errorf("Expected the failed delivery to be left to its subscription, got %v", got.err)
+	}
+}
+
 func testFanoutEventHandler(t *testing.T, async bool, receiverFunc channel.EventReceiverFunc, timeout time.Duration, inSubs []Subscription, subscriberHandler func(http.ResponseWriter, *http.Request), subscriberReqs int, replierHandler func(http.ResponseWriter, *http.Request), replierReqs int, expectedStatus int) {
 	ctx := context.Background()
 	ctx, _ = fakekubeclient.With(ctx)
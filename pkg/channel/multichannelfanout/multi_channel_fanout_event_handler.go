@@ -28,6 +28,7 @@ package multichannelfanout
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 
@@ -92,16 +93,39 @@ func NewEventHandlerWithConfig(_ context.Context, logger *zap.Logger, conf Confi
 	}, nil
 }
 
+// SetChannelHandler sets the handler of the channel key. A handler may be set for several keys,
+// e.g. the host and the path of a channel, so that they share its buffer.
 func (h *EventHandler) SetChannelHandler(host string, handler fanout.EventHandler) {
 	h.handlersLock.Lock()
 	defer h.handlersLock.Unlock()
+	old, ok := h.handlers[host]
 	h.handlers[host] = handler
+	if ok && old != handler {
+		h.closeUnusedHandler(old)
+	}
 }
 
 func (h *EventHandler) DeleteChannelHandler(host string) {
 	h.handlersLock.Lock()
 	defer h.handlersLock.Unlock()
+	old, ok := h.handlers[host]
 	delete(h.handlers, host)
+	if ok {
+		h.closeUnusedHandler(old)
+	}
+}
+
+// closeUnusedHandler releases the resources of a handler that is no longer set for any key, e.g.
+// the dispatchers of its buffer. It must be called with the lock held.
+func (h *EventHandler) closeUnusedHandler(handler fanout.EventHandler) {
+	for _, used := range h.handlers {
+		if used == handler {
+			return
+		}
+	}
+	if c, ok := handler.(io.Closer); ok {
+		_ = c.Close()
+	}
 }
 
 func (h *EventHandler) GetChannelHandler(host string) fanout.EventHandler {
@@ -141,6 +141,33 @@ func TestNewEventHandler(t *testing.T) {
 
 }
 
+// closeCountingHandler counts the calls to Close.
+type closeCountingHandler struct {
+	fanout.EventHandler
+	closed int
+}
+
+func (h *closeCountingHandler) Close() error {
+	h.closed++
+	return nil
+}
+
+func TestSharedChannelHandler(t *testing.T) {
+	handler := NewEventHandler(context.TODO(), zaptest.NewLogger(t))
+	shared := &closeCountingHandler{}
+	handler.SetChannelHandler("channel.example.com", shared)
+	handler.SetChannelHandler("ns/channel", shared)
+
+	handler.DeleteChannelHandler("channel.example.com")
+	if shared.closed != 0 {
+		t.Fatal("Closed the handler while still set for the path of the channel")
+	}
+	handler.SetChannelHandler("ns/channel", &closeCountingHandler{})
+	if shared.closed != 1 {
+		t.Errorf("Expected the handler to be closed once no longer set, got %d closes", shared.closed)
+	}
+}
+
 func TestServeHTTPEventHandler(t *testing.T) {
 	testCases := map[string]struct {
 		name               string
@@ -204,7 +231,7 @@ func TestServeHTTPEventHandler(t *testing.T) {
 			pathKey:            "missing-forward-slash",
 			expectedStatusCode: http.StatusBadRequest,
 		},
-		"pass through failure": {
+		"subscriber failure not passed through": {
 			config: Config{
 				ChannelConfigs: []ChannelConfig{
 					{
@@ -221,9 +248,10 @@ func TestServeHTTPEventHandler(t *testing.T) {
 					},
 				},
 			},
-			respStatusCode:     http.StatusInternalServerError,
-			hostKey:            "first-channel.default",
-			expectedStatusCode: http.StatusInternalServerError,
+			respStatusCode: http.StatusInternalServerError,
+			hostKey:        "first-channel.default",
+			// the subscription retries and dead letters the event on its own
+			expectedStatusCode: http.StatusAccepted,
 		},
 		"invalid event": {
 			config: Config{
@@ -47,6 +47,22 @@ var (
 		stats.UnitMilliseconds,
 	)
 
+	// subscriberDispatchTimeInMsecM records the time spent by the channel
+	// dispatching an event to a single subscriber, in milliseconds.
+	subscriberDispatchTimeInMsecM = stats.Float64(
+		"event_subscriber_dispatch_latencies",
+		"The time spent by the channel dispatching an event to a single subscriber",
+		stats.UnitMilliseconds,
+	)
+
+	// eventBufferOverflowCountM is a counter which records the number of
+	// events that didn't fit in the buffer of the channel.
+	eventBufferOverflowCountM = stats.Int64(
+		"event_buffer_overflow_count",
+		"Number of events that overflowed the buffer of the channel",
+		stats.UnitDimensionless,
+	)
+
 	// Create the tag keys that will be used to add tags to our measurements.
 	// Tag keys must conform to the restrictions described in
 	// go.opencensus.io/tag/validate.go. Currently those restrictions are:
@@ -57,6 +73,8 @@ var (
 	eventScheme          = tag.MustNewKey(eventingmetrics.LabelEventScheme)
 	responseCodeKey      = tag.MustNewKey(eventingmetrics.LabelResponseCode)
 	responseCodeClassKey = tag.MustNewKey(eventingmetrics.LabelResponseCodeClass)
+	subscriptionNameKey  = tag.MustNewKey(eventingmetrics.LabelSubscriptionName)
+	overflowPolicyKey    = tag.MustNewKey(eventingmetrics.LabelOverflowPolicy)
 )
 
 type ReportArgs struct {
@@ -72,6 +90,8 @@ func init() {
 type StatsReporter interface {
 	ReportEventCount(args *ReportArgs, responseCode int) error
 	ReportEventDispatchTime(args *ReportArgs, responseCode int, d time.Duration) error
+	ReportSubscriberDispatchTime(args *ReportArgs, subscription string, responseCode int, d time.Duration) error
+	ReportEventBufferOverflow(args *ReportArgs, policy string) error
 }
 
 var _ StatsReporter = (*reporter)(nil)
@@ -116,6 +136,18 @@ func register() {
 			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
 			TagKeys:     tagKeys,
 		},
+		&view.View{
+			Description: subscriberDispatchTimeInMsecM.Description(),
+			Measure:     subscriberDispatchTimeInMsecM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...), // 1, 2, 5, 10, 20, 50, 100, 500, 1000, 5000, 10000
+			TagKeys:     append([]tag.Key{subscriptionNameKey}, tagKeys...),
+		},
+		&view.View{
+			Description: eventBufferOverflowCountM.Description(),
+			Measure:     eventBufferOverflowCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, eventTypeKey, overflowPolicyKey, UniqueTagKey, ContainerTagKey},
+		},
 	)
 	if err != nil {
 		log.Print("failed to register opencensus views, " + err.Error())
@@ -143,6 +175,38 @@ func (r *reporter) ReportEventDispatchTime(args *ReportArgs, responseCode int, d
 	return nil
 }
 
+// ReportSubscriberDispatchTime captures the dispatch times to a single subscriber.
+func (r *reporter) ReportSubscriberDispatchTime(args *ReportArgs, subscription string, responseCode int, d time.Duration) error {
+	ctx, err := r.generateTag(args, responseCode)
+	if err != nil {
+		return err
+	}
+	ctx, err = tag.New(ctx, tag.Insert(subscriptionNameKey, subscription))
+	if err != nil {
+		return err
+	}
+	// convert Time.Duration in nanoseconds to milliseconds.
+	metrics.Record(ctx, subscriberDispatchTimeInMsecM.M(float64(d/time.Millisecond)))
+	return nil
+}
+
+// ReportEventBufferOverflow captures an event overflowing the buffer of the
+// channel, handled with the given overflow policy.
+func (r *reporter) ReportEventBufferOverflow(args *ReportArgs, policy string) error {
+	ctx, err := tag.New(
+		emptyContext,
+		tag.Insert(namespaceKey, args.Ns),
+		tag.Insert(eventTypeKey, args.EventType),
+		tag.Insert(overflowPolicyKey, policy),
+		tag.Insert(ContainerTagKey, r.container),
+		tag.Insert(UniqueTagKey, r.uniqueName))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, eventBufferOverflowCountM.M(1))
+	return nil
+}
+
 func (r *reporter) generateTag(args *ReportArgs, responseCode int) (context.Context, error) {
 	return tag.New(
 		emptyContext,
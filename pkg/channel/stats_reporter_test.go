@@ -63,6 +63,25 @@ func TestStatsReporter(t *testing.T) {
 		return r.ReportEventDispatchTime(args, http.StatusAccepted, 9100*time.Millisecond)
 	})
 	metricstest.CheckDistributionData(t, "event_dispatch_latencies", wantTags, 2, 1100.0, 9100.0)
+
+	// test ReportSubscriberDispatchTime
+	expectSuccess(t, func() error {
+		return r.ReportSubscriberDispatchTime(args, "testsub", http.StatusAccepted, 1100*time.Millisecond)
+	})
+	wantTags[metrics.LabelSubscriptionName] = "testsub"
+	metricstest.CheckDistributionData(t, "event_subscriber_dispatch_latencies", wantTags, 1, 1100.0, 1100.0)
+
+	// test ReportEventBufferOverflow
+	expectSuccess(t, func() error {
+		return r.ReportEventBufferOverflow(args, "drop-oldest")
+	})
+	metricstest.CheckCountData(t, "event_buffer_overflow_count", map[string]string{
+		metrics.LabelNamespaceName:  "testns",
+		metrics.LabelEventType:      "testeventtype",
+		metrics.LabelOverflowPolicy: "drop-oldest",
+		LabelUniqueName:             "testpod",
+		LabelContainerName:          "testcontainer",
+	}, 1)
 }
 
 func expectSuccess(t *testing.T, f func() error) {
@@ -80,6 +99,8 @@ func resetMetrics() {
 	// OpenCensus metrics carry global state that need to be reset between unit tests.
 	metricstest.Unregister(
 		"event_count",
-		"event_dispatch_latencies")
+		"event_dispatch_latencies",
+		"event_subscriber_dispatch_latencies",
+		"event_buffer_overflow_count")
 	register()
 }
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package defaults resolves the settings of Brokers and Channels. Each
+// setting is resolved on its own, the first one set wins:
+//
+//  1. the resource itself, through its annotations or spec,
+//  2. the defaults of its namespace,
+//  3. the cluster-wide defaults.
+//
+// The defaults are read from the config-br-defaults and default-ch-webhook
+// ConfigMaps, which are watched so that the changes apply without restarts.
+package defaults
+
+import (
+	"encoding/json"
+
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/configmap"
+
+	"knative.dev/eventing/pkg/apis/config"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingconfig "knative.dev/eventing/pkg/apis/messaging/config"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
+)
+
+// Store is a typed wrapper around configmap.UntypedStore watching the
+// ConfigMaps holding the Broker and Channel defaults.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a new Store and optionally calls functions when the
+// ConfigMaps are updated.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"defaults",
+			logger,
+			configmap.Constructors{
+				config.DefaultsConfigName:                 config.NewDefaultsConfigFromConfigMap,
+				messagingconfig.ChannelDefaultsConfigName: messagingconfig.NewChannelDefaultsConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// Load returns a Resolver using the current defaults. A nil Store returns a
+// Resolver using the settings of the resources only.
+func (s *Store) Load() *Resolver {
+	if s == nil {
+		return &Resolver{}
+	}
+	// The ConfigMaps failing to parse aren't stored, leaving the defaults unset.
+	brokerDefaults, _ := s.UntypedLoad(config.DefaultsConfigName).(*config.Defaults)
+	channelDefaults, _ := s.UntypedLoad(messagingconfig.ChannelDefaultsConfigName).(*messagingconfig.ChannelDefaults)
+	return &Resolver{
+		BrokerDefaults:  brokerDefaults.DeepCopy(),
+		ChannelDefaults: channelDefaults.DeepCopy(),
+	}
+}
+
+// Resolver resolves the settings of Brokers and Channels from a snapshot of
+// the defaults.
+type Resolver struct {
+	BrokerDefaults  *config.Defaults
+	ChannelDefaults *messagingconfig.ChannelDefaults
+}
+
+// BrokerSettings are the resolved settings of a Broker.
+type BrokerSettings struct {
+	// Class is the class of the Broker, empty if none is set.
+	Class string
+	// Config references the configuration of the Broker, nil if none is set.
+	Config *duckv1.KReference
+	// Delivery is the delivery of the Broker, nil if none is set.
+	Delivery *eventingduckv1.DeliverySpec
+}
+
+// Broker resolves the settings of the Broker. The class is read from the
+// eventing.BrokerClassKey annotation, the config and the delivery from the
+// spec of the Broker.
+func (r *Resolver) Broker(b *eventingv1.Broker) BrokerSettings {
+	settings := BrokerSettings{
+		Class:    b.GetAnnotations()[eventing.BrokerClassKey],
+		Config:   b.Spec.Config,
+		Delivery: b.Spec.Delivery,
+	}
+
+	for _, d := range r.brokerDefaults(b.Namespace) {
+		if settings.Class == "" {
+			settings.Class = d.BrokerClass
+		}
+		if d.BrokerConfig == nil {
+			continue
+		}
+		if settings.Config == nil && d.KReference != nil {
+			settings.Config = d.KReference.DeepCopy()
+			if settings.Config.Namespace == "" {
+				settings.Config.Namespace = b.Namespace
+			}
+		}
+		if settings.Delivery == nil && d.Delivery != nil {
+			settings.Delivery = d.Delivery.DeepCopy()
+		}
+	}
+	return settings
+}
+
+// brokerDefaults returns the defaults applying to the namespace, from the
+// most specific to the least specific.
+func (r *Resolver) brokerDefaults(namespace string) []*config.ClassAndBrokerConfig {
+	if r.BrokerDefaults == nil {
+		return nil
+	}
+	defaults := make([]*config.ClassAndBrokerConfig, 0, 2)
+	if d, ok := r.BrokerDefaults.NamespaceDefaultsConfig[namespace]; ok && d != nil {
+		defaults = append(defaults, d)
+	}
+	if r.BrokerDefaults.ClusterDefault != nil {
+		defaults = append(defaults, r.BrokerDefaults.ClusterDefault)
+	}
+	return defaults
+}
+
+// ChannelSettings are the resolved settings of a Channel.
+type ChannelSettings struct {
+	// Template is the template of the backing channel, nil if none is set.
+	Template *messagingv1.ChannelTemplateSpec
+	// Delivery is the delivery of the Channel, nil if none is set.
+	Delivery *eventingduckv1.DeliverySpec
+}
+
+// Channel resolves the settings of the Channel. The template and the
+// delivery are read from the spec of the Channel, the default delivery from
+// the spec of the default templates.
+func (r *Resolver) Channel(c *messagingv1.Channel) ChannelSettings {
+	settings := ChannelSettings{
+		Template: c.Spec.ChannelTemplate,
+		Delivery: c.Spec.Delivery,
+	}
+
+	for _, t := range r.channelDefaults(c.Namespace) {
+		if settings.Template == nil {
+			settings.Template = &messagingv1.ChannelTemplateSpec{
+				TypeMeta: t.TypeMeta,
+				Spec:     t.Spec.DeepCopy(),
+			}
+		}
+		if settings.Delivery == nil {
+			settings.Delivery = templateDelivery(t)
+		}
+	}
+	return settings
+}
+
+// channelDefaults returns the default templates applying to the namespace,
+// from the most specific to the least specific.
+func (r *Resolver) channelDefaults(namespace string) []*messagingconfig.ChannelTemplateSpec {
+	if r.ChannelDefaults == nil {
+		return nil
+	}
+	defaults := make([]*messagingconfig.ChannelTemplateSpec, 0, 2)
+	if t, ok := r.ChannelDefaults.NamespaceDefaults[namespace]; ok && t != nil {
+		defaults = append(defaults, t)
+	}
+	if r.ChannelDefaults.ClusterDefault != nil {
+		defaults = append(defaults, r.ChannelDefaults.ClusterDefault)
+	}
+	return defaults
+}
+
+// templateDelivery returns the delivery of the spec of the template, nil if
+// none is set or the spec can't be parsed.
+func templateDelivery(t *messagingconfig.ChannelTemplateSpec) *eventingduckv1.DeliverySpec {
+	if t.Spec == nil || len(t.Spec.Raw) == 0 {
+		return nil
+	}
+	var spec struct {
+		Delivery *eventingduckv1.DeliverySpec `json:"delivery,omitempty"`
+	}
+	if err := json.Unmarshal(t.Spec.Raw, &spec); err != nil {
+		return nil
+	}
+	return spec.Delivery
+}
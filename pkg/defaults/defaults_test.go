@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaults
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	logtesting "knative.dev/pkg/logging/testing"
+
+	"knative.dev/eventing/pkg/apis/config"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/eventing"
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	messagingconfig "knative.dev/eventing/pkg/apis/messaging/config"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
+)
+
+const testNS = "test-namespace"
+
+var (
+	imcTypeMeta = metav1.TypeMeta{
+		APIVersion: "messaging.knative.dev/v1",
+		Kind:       "InMemoryChannel",
+	}
+	kafkaTypeMeta = metav1.TypeMeta{
+		APIVersion: "messaging.knative.dev/v1beta1",
+		Kind:       "KafkaChannel",
+	}
+)
+
+func TestStoreLoad(t *testing.T) {
+	store := NewStore(logtesting.TestLogger(t))
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DefaultsConfigName},
+		Data: map[string]string{
+			config.BrokerDefaultsKey: `
+clusterDefault:
+  brokerClass: MTChannelBasedBroker
+`,
+		},
+	})
+	store.OnConfigChanged(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: messagingconfig.ChannelDefaultsConfigName},
+		Data: map[string]string{
+			messagingconfig.ChannelDefaulterKey: `
+clusterDefault:
+  apiVersion: messaging.knative.dev/v1
+  kind: InMemoryChannel
+`,
+		},
+	})
+
+	r := store.Load()
+	if got := r.Broker(&eventingv1.Broker{}).Class; got != eventing.MTChannelBrokerClassValue {
+		t.Errorf("Broker().Class = %q, want %q", got, eventing.MTChannelBrokerClassValue)
+	}
+	if got := r.Channel(&messagingv1.Channel{}).Template; got == nil || got.TypeMeta != imcTypeMeta {
+		t.Errorf("Channel().Template = %v, want %v", got, imcTypeMeta)
+	}
+
+	// Mutating the snapshot doesn't change the store.
+	r.BrokerDefaults.ClusterDefault.BrokerClass = "other"
+	if got := store.Load().BrokerDefaults.ClusterDefault.BrokerClass; got != eventing.MTChannelBrokerClassValue {
+		t.Errorf("BrokerDefaults.ClusterDefault.BrokerClass = %q, want %q", got, eventing.MTChannelBrokerClassValue)
+	}
+}
+
+func TestStoreLoadNil(t *testing.T) {
+	var store *Store
+	delivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(3)}
+
+	got := store.Load().Broker(&eventingv1.Broker{
+		Spec: eventingv1.BrokerSpec{Delivery: delivery},
+	})
+	if diff := cmp.Diff(BrokerSettings{Delivery: delivery}, got); diff != "" {
+		t.Error("Unexpected broker settings (-want, +got):", diff)
+	}
+}
+
+func TestResolverBroker(t *testing.T) {
+	resourceConfig := &duckv1.KReference{Kind: "ConfigMap", APIVersion: "v1", Name: "resource", Namespace: testNS}
+	namespaceConfig := &duckv1.KReference{Kind: "ConfigMap", APIVersion: "v1", Name: "namespace"}
+	clusterConfig := &duckv1.KReference{Kind: "ConfigMap", APIVersion: "v1", Name: "cluster", Namespace: "knative-eventing"}
+
+	resourceDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(1)}
+	namespaceDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(2)}
+	clusterDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(3)}
+
+	namespaceConfigWithNS := namespaceConfig.DeepCopy()
+	namespaceConfigWithNS.Namespace = testNS
+
+	testCases := map[string]struct {
+		defaults *config.Defaults
+		broker   *eventingv1.Broker
+		want     BrokerSettings
+	}{
+		"no defaults": {
+			broker: broker("", nil, nil),
+			want:   BrokerSettings{},
+		},
+		"resource settings win": {
+			defaults: &config.Defaults{
+				NamespaceDefaultsConfig: map[string]*config.ClassAndBrokerConfig{
+					testNS: {
+						BrokerClass:  "namespace",
+						BrokerConfig: &config.BrokerConfig{KReference: namespaceConfig, Delivery: namespaceDelivery},
+					},
+				},
+				ClusterDefault: &config.ClassAndBrokerConfig{
+					BrokerClass:  "cluster",
+					BrokerConfig: &config.BrokerConfig{KReference: clusterConfig, Delivery: clusterDelivery},
+				},
+			},
+			broker: broker("resource", resourceConfig, resourceDelivery),
+			want:   BrokerSettings{Class: "resource", Config: resourceConfig, Delivery: resourceDelivery},
+		},
+		"namespace defaults win over the cluster defaults": {
+			defaults: &config.Defaults{
+				NamespaceDefaultsConfig: map[string]*config.ClassAndBrokerConfig{
+					testNS: {
+						BrokerClass:  "namespace",
+						BrokerConfig: &config.BrokerConfig{KReference: namespaceConfig, Delivery: namespaceDelivery},
+					},
+				},
+				ClusterDefault: &config.ClassAndBrokerConfig{
+					BrokerClass:  "cluster",
+					BrokerConfig: &config.BrokerConfig{KReference: clusterConfig, Delivery: clusterDelivery},
+				},
+			},
+			broker: broker("", nil, nil),
+			want:   BrokerSettings{Class: "namespace", Config: namespaceConfigWithNS, Delivery: namespaceDelivery},
+		},
+		"other namespaces defaults are ignored": {
+			defaults: &config.Defaults{
+				NamespaceDefaultsConfig: map[string]*config.ClassAndBrokerConfig{
+					"other": {
+						BrokerClass:  "namespace",
+						BrokerConfig: &config.BrokerConfig{KReference: namespaceConfig, Delivery: namespaceDelivery},
+					},
+				},
+				ClusterDefault: &config.ClassAndBrokerConfig{
+					BrokerClass:  "cluster",
+					BrokerConfig: &config.BrokerConfig{KReference: clusterConfig, Delivery: clusterDelivery},
+				},
+			},
+			broker: broker("", nil, nil),
+			want:   BrokerSettings{Class: "cluster", Config: clusterConfig, Delivery: clusterDelivery},
+		},
+		"each setting is resolved on its own": {
+			defaults: &config.Defaults{
+				NamespaceDefaultsConfig: map[string]*config.ClassAndBrokerConfig{
+					testNS: {
+						BrokerConfig: &config.BrokerConfig{Delivery: namespaceDelivery},
+					},
+				},
+				ClusterDefault: &config.ClassAndBrokerConfig{
+					BrokerClass:  "cluster",
+					BrokerConfig: &config.BrokerConfig{KReference: clusterConfig, Delivery: clusterDelivery},
+				},
+			},
+			broker: broker("resource", nil, nil),
+			want:   BrokerSettings{Class: "resource", Config: clusterConfig, Delivery: namespaceDelivery},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := &Resolver{BrokerDefaults: tc.defaults}
+			if diff := cmp.Diff(tc.want, r.Broker(tc.broker)); diff != "" {
+				t.Error("Unexpected broker settings (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestResolverChannel(t *testing.T) {
+	delivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(3)}
+	channelDefaults := &messagingconfig.ChannelDefaults{
+		NamespaceDefaults: map[string]*messagingconfig.ChannelTemplateSpec{
+			testNS: {TypeMeta: kafkaTypeMeta},
+		},
+		ClusterDefault: &messagingconfig.ChannelTemplateSpec{TypeMeta: imcTypeMeta},
+	}
+	namespaceDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(5)}
+	namespaceSpec := &runtime.RawExtension{Raw: []byte(`{"delivery":{"retry":5}}`)}
+	deliveryDefaults := &messagingconfig.ChannelDefaults{
+		NamespaceDefaults: map[string]*messagingconfig.ChannelTemplateSpec{
+			testNS: {TypeMeta: kafkaTypeMeta, Spec: namespaceSpec},
+		},
+		ClusterDefault: &messagingconfig.ChannelTemplateSpec{TypeMeta: imcTypeMeta},
+	}
+
+	testCases := map[string]struct {
+		defaults *messagingconfig.ChannelDefaults
+		channel  *messagingv1.Channel
+		want     ChannelSettings
+	}{
+		"no defaults": {
+			channel: channel(testNS, nil, delivery),
+			want:    ChannelSettings{Delivery: delivery},
+		},
+		"resource template wins": {
+			defaults: channelDefaults,
+			channel:  channel(testNS, &messagingv1.ChannelTemplateSpec{TypeMeta: imcTypeMeta}, nil),
+			want:     ChannelSettings{Template: &messagingv1.ChannelTemplateSpec{TypeMeta: imcTypeMeta}},
+		},
+		"namespace default": {
+			defaults: channelDefaults,
+			channel:  channel(testNS, nil, delivery),
+			want:     ChannelSettings{Template: &messagingv1.ChannelTemplateSpec{TypeMeta: kafkaTypeMeta}, Delivery: delivery},
+		},
+		"cluster default": {
+			defaults: channelDefaults,
+			channel:  channel("other", nil, nil),
+			want:     ChannelSettings{Template: &messagingv1.ChannelTemplateSpec{TypeMeta: imcTypeMeta}},
+		},
+		"namespace default delivery": {
+			defaults: deliveryDefaults,
+			channel:  channel(testNS, &messagingv1.ChannelTemplateSpec{TypeMeta: imcTypeMeta}, nil),
+			want:     ChannelSettings{Template: &messagingv1.ChannelTemplateSpec{TypeMeta: imcTypeMeta}, Delivery: namespaceDelivery},
+		},
+		"resource delivery wins": {
+			defaults: deliveryDefaults,
+			channel:  channel(testNS, nil, delivery),
+			want:     ChannelSettings{Template: &messagingv1.ChannelTemplateSpec{TypeMeta: kafkaTypeMeta, Spec: namespaceSpec}, Delivery: delivery},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := &Resolver{ChannelDefaults: tc.defaults}
+			if diff := cmp.Diff(tc.want, r.Channel(tc.channel)); diff != "" {
+				t.Error("Unexpected channel settings (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func broker(class string, config *duckv1.KReference, delivery *eventingduckv1.DeliverySpec) *eventingv1.Broker {
+	b := &eventingv1.Broker{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: testNS},
+		Spec: eventingv1.BrokerSpec{
+			Config:   config,
+			Delivery: delivery,
+		},
+	}
+	if class != "" {
+		b.Annotations = map[string]string{eventing.BrokerClassKey: class}
+	}
+	return b
+}
+
+func channel(namespace string, template *messagingv1.ChannelTemplateSpec, delivery *eventingduckv1.DeliverySpec) *messagingv1.Channel {
+	return &messagingv1.Channel{
+		ObjectMeta: metav1.ObjectMeta{Name: "channel", Namespace: namespace},
+		Spec: messagingv1.ChannelSpec{
+			ChannelTemplate: template,
+			ChannelableSpec: eventingduckv1.ChannelableSpec{Delivery: delivery},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmarks
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cetest "github.com/cloudevents/sdk-go/v2/test"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/eventfilter"
+	"knative.dev/eventing/pkg/eventfilter/subscriptionsapi"
+)
+
+func BenchmarkCompile(b *testing.B) {
+	event := cetest.FullEvent()
+
+	RunFilterBenchmarks(b,
+		func(i interface{}) eventfilter.Filter {
+			f, err := subscriptionsapi.Compile(i.([]eventingv1.SubscriptionsAPIFilter))
+			if err != nil {
+				b.Fatal(err)
+			}
+			return f
+		},
+		FilterBenchmark{
+			name:   "Pass with no filters",
+			arg:    []eventingv1.SubscriptionsAPIFilter(nil),
+			events: []cloudevents.Event{event},
+		},
+		FilterBenchmark{
+			name: "Pass with exact, prefix and suffix",
+			arg: []eventingv1.SubscriptionsAPIFilter{
+				{Exact: map[string]string{"type": event.Type()}},
+				{Prefix: map[string]string{"source": event.Source()[:5]}},
+				{Suffix: map[string]string{"id": event.ID()[len(event.ID())-3:]}},
+			},
+			events: []cloudevents.Event{event},
+		},
+		FilterBenchmark{
+			name: "Pass with any of not and exact",
+			arg: []eventingv1.SubscriptionsAPIFilter{{
+				Any: []eventingv1.SubscriptionsAPIFilter{
+					{Not: &eventingv1.SubscriptionsAPIFilter{Exact: map[string]string{"type": event.Type()}}},
+					{Exact: map[string]string{"id": event.ID()}},
+				},
+			}},
+			events: []cloudevents.Event{event},
+		},
+		FilterBenchmark{
+			name: "No pass with CESQL",
+			arg: []eventingv1.SubscriptionsAPIFilter{
+				{CESQL: "type = 'other' OR source LIKE '%nothing%'"},
+			},
+			events: []cloudevents.Event{event},
+		},
+	)
+}
@@ -61,11 +61,13 @@ func NewAllFilter(filters ...eventfilter.Filter) eventfilter.Filter {
 
 func (filter *allFilter) Filter(ctx context.Context, event cloudevents.Event) eventfilter.FilterResult {
 	res := eventfilter.NoFilter
-	logging.FromContext(ctx).Debugw("Performing an ALL match ", zap.Any("filters", filter), zap.Any("event", event))
+	// The filters are swapped concurrently, only their number is logged.
+	logging.FromContext(ctx).Debugw("Performing an ALL match ", zap.Int("filters", len(filter.filters)), zap.Any("event", event))
 	filter.rwMutex.RLock()
 	defer filter.rwMutex.RUnlock()
-	for i, f := range filter.filters {
-		res = f.filter.Filter(ctx, event)
+	// The filters are not copied, their count is incremented concurrently.
+	for i := range filter.filters {
+		res = filter.filters[i].filter.Filter(ctx, event)
 		// Short circuit to optimize it
 		if res == eventfilter.FailFilter {
 			select {
@@ -86,8 +88,11 @@ func (filter *allFilter) optimizeLoop() {
 			filter.doneChan <- true
 			return
 		}
+		filter.rwMutex.RLock()
 		val := filter.filters[i].count.Inc()
-		if i != 0 && val > filter.filters[i-1].count.Load()*2 {
+		swap := i != 0 && val > filter.filters[i-1].count.Load()*2
+		filter.rwMutex.RUnlock()
+		if swap {
 			go filter.swapWithEarlierFilter(i)
 		}
 	}
@@ -102,8 +107,11 @@ func (filter *allFilter) swapWithEarlierFilter(swapIdx int) {
 func (filter *allFilter) Cleanup() {
 	close(filter.indexChan)
 	<-filter.doneChan
-	for _, f := range filter.filters {
-		f.filter.Cleanup()
+	// The filters may still be swapped by a pending swapWithEarlierFilter.
+	filter.rwMutex.RLock()
+	defer filter.rwMutex.RUnlock()
+	for i := range filter.filters {
+		filter.filters[i].filter.Cleanup()
 	}
 }
 
@@ -36,9 +36,16 @@ type ceSQLFilter struct {
 
 // NewCESQLFilter returns an event filter which passes if the provided CESQL expression
 // evaluates.
-func NewCESQLFilter(expr string) (eventfilter.Filter, error) {
+func NewCESQLFilter(expr string) (filter eventfilter.Filter, err error) {
+	// Need to recover in case Parse panics
+	defer func() {
+		if r := recover(); r != nil {
+			filter = nil
+			err = fmt.Errorf("error while parsing expression %s. Parser panicked: %v", expr, r)
+		}
+	}()
+
 	var parsed cesql.Expression
-	var err error
 	if expr != "" {
 		parsed, err = cesqlparser.Parse(expr)
 		if err != nil {
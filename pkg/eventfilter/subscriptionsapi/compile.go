@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriptionsapi
+
+import (
+	"fmt"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+// Compile compiles the given filters into a single Filter, with the same
+// semantics as the filters of a Trigger: an event passes if it passes all of
+// the filters, and no filters pass every event. Filters without a dialect are
+// ignored, like they are by Triggers.
+//
+// Unlike the broker, which skips invalid filters, Compile fails on the first
+// invalid filter, so that sources and channels can surface the error to users.
+// The returned filter should be cleaned up once it's not used anymore.
+func Compile(filters []eventingv1.SubscriptionsAPIFilter) (eventfilter.Filter, error) {
+	if len(filters) == 0 {
+		return NewNoFilter(), nil
+	}
+
+	compiled, err := compileList(filters)
+	if err != nil {
+		return nil, err
+	}
+	return NewAllFilter(compiled...), nil
+}
+
+func compileList(filters []eventingv1.SubscriptionsAPIFilter) ([]eventfilter.Filter, error) {
+	compiled := make([]eventfilter.Filter, 0, len(filters))
+	for i, f := range filters {
+		c, err := compile(f)
+		if err != nil {
+			for _, c := range compiled {
+				c.Cleanup()
+			}
+			return nil, fmt.Errorf("invalid filter at index %d: %w", i, err)
+		}
+		if c != nil {
+			compiled = append(compiled, c)
+		}
+	}
+	return compiled, nil
+}
+
+// compile compiles a single filter, returning nil for a filter without a dialect.
+func compile(filter eventingv1.SubscriptionsAPIFilter) (eventfilter.Filter, error) {
+	switch {
+	case len(filter.Exact) > 0:
+		return NewExactFilter(filter.Exact)
+	case len(filter.Prefix) > 0:
+		return NewPrefixFilter(filter.Prefix)
+	case len(filter.Suffix) > 0:
+		return NewSuffixFilter(filter.Suffix)
+	case len(filter.All) > 0:
+		compiled, err := compileList(filter.All)
+		if err != nil {
+			return nil, fmt.Errorf("all: %w", err)
+		}
+		return NewAllFilter(compiled...), nil
+	case len(filter.Any) > 0:
+		compiled, err := compileList(filter.Any)
+		if err != nil {
+			return nil, fmt.Errorf("any: %w", err)
+		}
+		return NewAnyFilter(compiled...), nil
+	case filter.Not != nil:
+		compiled, err := compile(*filter.Not)
+		if err != nil {
+			return nil, fmt.Errorf("not: %w", err)
+		}
+		if compiled == nil {
+			return nil, fmt.Errorf("not: filter has no dialect")
+		}
+		return NewNotFilter(compiled), nil
+	case filter.CESQL != "":
+		return NewCESQLFilter(filter.CESQL)
+	default:
+		return nil, nil
+	}
+}
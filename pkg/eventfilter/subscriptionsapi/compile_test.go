@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subscriptionsapi
+
+import (
+	"context"
+	"testing"
+
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
+	"knative.dev/eventing/pkg/eventfilter"
+)
+
+func TestCompile(t *testing.T) {
+	tests := map[string]struct {
+		filters []eventingv1.SubscriptionsAPIFilter
+		want    eventfilter.FilterResult
+		wantErr bool
+	}{
+		"No filters": {
+			want: eventfilter.NoFilter,
+		},
+		"Exact pass": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{Exact: map[string]string{"type": eventType}}},
+			want:    eventfilter.PassFilter,
+		},
+		"Prefix and suffix pass": {
+			filters: []eventingv1.SubscriptionsAPIFilter{
+				{Prefix: map[string]string{"type": "dev.knative"}},
+				{Suffix: map[string]string{"source": "source"}},
+			},
+			want: eventfilter.PassFilter,
+		},
+		"Exact and prefix fail": {
+			filters: []eventingv1.SubscriptionsAPIFilter{
+				{Exact: map[string]string{"type": eventType}},
+				{Prefix: map[string]string{"source": "other"}},
+			},
+			want: eventfilter.FailFilter,
+		},
+		"Any with one match passes": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{
+				Any: []eventingv1.SubscriptionsAPIFilter{
+					{Exact: map[string]string{"type": "other"}},
+					{Exact: map[string]string{"id": eventID}},
+				},
+			}},
+			want: eventfilter.PassFilter,
+		},
+		"Nested all fails": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{
+				All: []eventingv1.SubscriptionsAPIFilter{
+					{Exact: map[string]string{"type": eventType}},
+					{Exact: map[string]string{"id": "other"}},
+				},
+			}},
+			want: eventfilter.FailFilter,
+		},
+		"Not fails": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{
+				Not: &eventingv1.SubscriptionsAPIFilter{Exact: map[string]string{"type": eventType}},
+			}},
+			want: eventfilter.FailFilter,
+		},
+		"CESQL passes": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{CESQL: "type = '" + eventType + "' AND knativebrokerttl > 10"}},
+			want:    eventfilter.PassFilter,
+		},
+		"Filter without dialect is ignored": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{}, {Exact: map[string]string{"type": eventType}}},
+			want:    eventfilter.PassFilter,
+		},
+		"Invalid exact": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{Exact: map[string]string{"type": ""}}},
+			wantErr: true,
+		},
+		"Invalid CESQL": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{CESQL: "type = "}},
+			wantErr: true,
+		},
+		"Invalid nested in any": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{
+				Any: []eventingv1.SubscriptionsAPIFilter{
+					{Exact: map[string]string{"type": eventType}},
+					{Suffix: map[string]string{"": "x"}},
+				},
+			}},
+			wantErr: true,
+		},
+		"Not without dialect": {
+			filters: []eventingv1.SubscriptionsAPIFilter{{Not: &eventingv1.SubscriptionsAPIFilter{}}},
+			wantErr: true,
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			f, err := Compile(tc.filters)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Compile() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			defer f.Cleanup()
+
+			if got := f.Filter(context.TODO(), *makeEvent()); got != tc.want {
+				t.Errorf("Filter() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -57,6 +57,8 @@ const (
 	BrokerFilterServerTLSSecretName = "mt-broker-filter-server-tls" //nolint:gosec // This is not a hardcoded credential
 	// BrokerIngressServerTLSSecretName is the name of the tls secret for the broker ingress server
 	BrokerIngressServerTLSSecretName = "mt-broker-ingress-server-tls" //nolint:gosec // This is not a hardcoded credential
+	// PingSourceAdapterServerTLSSecretName is the name of the tls secret for the pingsource mt adapter health server
+	PingSourceAdapterServerTLSSecretName = "pingsource-mt-adapter-server-tls" //nolint:gosec // This is not a hardcoded credential
 )
 
 type ClientConfig struct {
@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventingtlstesting
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// CertificateAuthority is a CA generated for a test, which issues server and client certificates.
+type CertificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	// PEM is the PEM encoded CA certificate, e.g. to be used as CACerts of an Addressable.
+	PEM string
+}
+
+// NewCertificateAuthority generates a new CA, valid for the duration of the test.
+func NewCertificateAuthority(t testing.TB) *CertificateAuthority {
+	t.Helper()
+
+	key := generateKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(t),
+		Subject:               pkix.Name{CommonName: "Knative-Test-Root-CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("failed to create CA certificate:", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("failed to parse CA certificate:", err)
+	}
+
+	return &CertificateAuthority{
+		cert: cert,
+		key:  key,
+		PEM:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
+
+// ServerCertificate issues a server certificate for the given DNS names or IP addresses.
+func (ca *CertificateAuthority) ServerCertificate(t testing.TB, hosts ...string) tls.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: hosts[0]},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	return ca.issue(t, template)
+}
+
+// ClientCertificate issues a client certificate with the given common name.
+func (ca *CertificateAuthority) ClientCertificate(t testing.TB, commonName string) tls.Certificate {
+	t.Helper()
+
+	return ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+// CertPool returns a pool containing the CA certificate.
+func (ca *CertificateAuthority) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *CertificateAuthority) issue(t testing.TB, template *x509.Certificate) tls.Certificate {
+	t.Helper()
+
+	key := generateKey(t)
+	template.SerialNumber = serialNumber(t)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal("failed to create certificate:", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("failed to parse certificate:", err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func generateKey(t testing.TB) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	return key
+}
+
+func serialNumber(t testing.TB) *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		t.Fatal("failed to generate serial number:", err)
+	}
+	return serial
+}
+
+// TLSServer is a TLS server for tests, whose certificates can be rotated while it is running.
+type TLSServer struct {
+	*httptest.Server
+
+	mu sync.RWMutex
+	// ca issued the current default certificate.
+	ca          *CertificateAuthority
+	certificate tls.Certificate
+	sni         map[string]tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+// TLSServerOption configures a TLSServer.
+type TLSServerOption func(*TLSServer)
+
+// WithClientCertificates requires clients to present a certificate issued by the given CA.
+func WithClientCertificates(ca *CertificateAuthority) TLSServerOption {
+	return func(s *TLSServer) {
+		s.clientCAs = ca.CertPool()
+	}
+}
+
+// WithSNICertificate serves the given certificate to clients requesting the given server name.
+func WithSNICertificate(serverName string, cert tls.Certificate) TLSServerOption {
+	return func(s *TLSServer) {
+		s.sni[serverName] = cert
+	}
+}
+
+// StartTLSServer starts a TLS server with a certificate for localhost issued by a
+// newly generated CA. The server doesn't keep connections alive, so every request
+// does a TLS handshake. The server is closed when the test finishes.
+func StartTLSServer(t testing.TB, handler http.Handler, opts ...TLSServerOption) *TLSServer {
+	t.Helper()
+
+	ca := NewCertificateAuthority(t)
+	s := &TLSServer{
+		Server:      httptest.NewUnstartedServer(handler),
+		ca:          ca,
+		certificate: ca.ServerCertificate(t, "localhost", "127.0.0.1"),
+		sni:         make(map[string]tls.Certificate),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// httptest adds its own certificate to the config, which would be served to
+	// clients not using SNI, so the certificate is selected per connection.
+	s.TLS = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: s.getConfigForClient,
+	}
+	// every request does a handshake, so that rotated certificates are used right away
+	s.Config.SetKeepAlivesEnabled(false)
+
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// CA returns the PEM encoded certificate of the CA which issued the current server certificate.
+func (s *TLSServer) CA() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ca.PEM
+}
+
+// RotateCertificate replaces the server certificate with a new one issued by
+// the same CA. Clients trusting the CA keep working.
+func (s *TLSServer) RotateCertificate(t testing.TB) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certificate = s.ca.ServerCertificate(t, "localhost", "127.0.0.1")
+}
+
+// RotateCA replaces the server certificate with one issued by a newly generated
+// CA, and returns the new CA. Clients need to trust the new CA to keep working.
+func (s *TLSServer) RotateCA(t testing.TB) *CertificateAuthority {
+	t.Helper()
+
+	ca := NewCertificateAuthority(t)
+	cert := ca.ServerCertificate(t, "localhost", "127.0.0.1")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ca = ca
+	s.certificate = cert
+	return ca
+}
+
+func (s *TLSServer) getConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cert, ok := s.sni[hello.ServerName]
+	if !ok {
+		cert = s.certificate
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"http/1.1"},
+	}
+	if s.clientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = s.clientCAs
+	}
+	return config, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventingtlstesting
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTLSServerSNI(t *testing.T) {
+	ca := NewCertificateAuthority(t)
+	server := StartTLSServer(t, http.NotFoundHandler(),
+		WithSNICertificate("sink.example.com", ca.ServerCertificate(t, "sink.example.com")))
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.CertPool(), ServerName: "sink.example.com"})
+	if err != nil {
+		t.Fatal("failed to connect with SNI:", err)
+	}
+	conn.Close()
+
+	// without SNI the default certificate is served, which isn't issued by ca
+	if _, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.CertPool(), ServerName: "localhost"}); err == nil {
+		t.Error("want the default certificate not to be trusted")
+	}
+}
+
+func TestTLSServerClientCertificates(t *testing.T) {
+	clientCA := NewCertificateAuthority(t)
+	server := StartTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}), WithClientCertificates(clientCA))
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM([]byte(server.CA()))
+
+	tests := []struct {
+		name    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{{
+		name:  "trusted client certificate",
+		certs: []tls.Certificate{clientCA.ClientCertificate(t, "client")},
+	}, {
+		name:    "no client certificate",
+		wantErr: true,
+	}, {
+		name:    "untrusted client certificate",
+		certs:   []tls.Certificate{NewCertificateAuthority(t).ClientCertificate(t, "client")},
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      rootCAs,
+				Certificates: tc.certs,
+			}}}
+			resp, err := client.Get(server.URL)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
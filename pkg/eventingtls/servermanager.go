@@ -38,7 +38,7 @@ type ServerManager struct {
 	httpsReceiver Receiver
 	handler       http.Handler
 	cmw           configmap.Watcher
-	featureStore  *feature.Store
+	features      *feature.Client
 }
 
 type Receiver interface {
@@ -50,15 +50,14 @@ func NewServerManager(ctx context.Context, httpReceiver, httpsReceiver Receiver,
 		return nil, fmt.Errorf("message receiver not provided")
 	}
 
-	featureStore := feature.NewStore(logging.FromContext(ctx).Named("feature-config-store"))
-	featureStore.WatchConfigs(cmw)
+	features := feature.NewClient(logging.FromContext(ctx).Named("feature-config-store"), cmw)
 
 	return &ServerManager{
 		httpReceiver:  httpReceiver,
 		httpsReceiver: httpsReceiver,
 		handler:       handler,
 		cmw:           cmw,
-		featureStore:  featureStore,
+		features:      features,
 	}, nil
 }
 
@@ -91,8 +90,7 @@ func (s *ServerManager) StartServers(ctx context.Context) error {
 
 func (s *ServerManager) httpHandler() http.Handler {
 	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-		flags := s.featureStore.Load()
-		if flags.IsStrictTransportEncryption() {
+		if s.features.TransportEncryption() == feature.Strict {
 			// As flag updates are eventually consistent across all components,
 			// we want a retryable error. A 404 seemed the most reasonable (400
 			// is not retryable).
@@ -105,8 +103,7 @@ func (s *ServerManager) httpHandler() http.Handler {
 
 func (s *ServerManager) httpsHandler() http.Handler {
 	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-		flags := s.featureStore.Load()
-		if flags.IsDisabledTransportEncryption() {
+		if s.features.TransportEncryption() == feature.Disabled {
 			// As flag updates are eventually consistent across all components,
 			// we want a retryable error. A 404 seemed the most reasonable (400
 			// is not retryable).
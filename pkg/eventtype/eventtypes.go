@@ -19,10 +19,14 @@ package eventtype
 import (
 	"context"
 	"crypto/md5" //nolint:gosec
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cloudevents/sdk-go/v2/event"
+	lru "github.com/hashicorp/golang-lru"
 	"go.uber.org/zap"
 
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
@@ -38,11 +42,46 @@ import (
 	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
+const (
+	// ObservedCountAnnotation is the annotation holding the number of events
+	// of an auto-created EventType that have been observed by the data plane.
+	ObservedCountAnnotation = "eventing.knative.dev/observed-count"
+	// LastSeenAnnotation is the annotation holding the RFC3339 timestamp at
+	// which an event of an auto-created EventType was last observed.
+	LastSeenAnnotation = "eventing.knative.dev/last-seen"
+
+	defaultObservationSyncInterval = time.Minute
+	defaultMaxObservations         = 10000
+)
+
 type EventTypeAutoHandler struct {
 	EventTypeLister v1beta22.EventTypeLister
 	EventingClient  eventingv1beta2.EventingV1beta2Interface
 	FeatureStore    *feature.Store
 	Logger          *zap.Logger
+
+	// ObservationSyncInterval is the minimum time between two updates of the
+	// observation annotations of the same EventType. Observations made in
+	// between are aggregated in memory. Defaults to one minute.
+	ObservationSyncInterval time.Duration
+	// MaxObservations is the maximum number of EventTypes whose observations
+	// are aggregated in memory, the least recently observed ones are evicted
+	// first, losing the events observed since their last update. Defaults to
+	// 10000.
+	MaxObservations int
+
+	mu sync.Mutex
+	// observations maps the types.NamespacedName of the EventTypes to their
+	// *observation.
+	observations *lru.Cache
+}
+
+// observation aggregates the events seen for a single EventType since the
+// last time its annotations were updated.
+type observation struct {
+	pending  int64
+	lastSeen time.Time
+	lastSync time.Time
 }
 
 // generateEventTypeName is a pseudo unique name for EvenType object based on the input params
@@ -53,6 +92,7 @@ func generateEventTypeName(name, namespace, eventType, eventSource string) strin
 }
 
 // AutoCreateEventType creates EventType object based on processed event's types from addressable KReference objects
+// and keeps track of how often and when events of the type have been observed.
 func (h *EventTypeAutoHandler) AutoCreateEventType(ctx context.Context, event *event.Event, addressable *duckv1.KReference, ownerUID types.UID) {
 	// Feature flag gate
 	if !h.FeatureStore.IsEnabled(feature.EvenTypeAutoCreate) {
@@ -62,18 +102,27 @@ func (h *EventTypeAutoHandler) AutoCreateEventType(ctx context.Context, event *e
 
 	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*30)
 	go func() {
+		defer cancel()
 		h.Logger.Debug("Event Types auto creation is enabled")
 
-		eventTypeName := generateEventTypeName(addressable.Name, addressable.Namespace, event.Type(), event.Source())
+		key := types.NamespacedName{
+			Namespace: addressable.Namespace,
+			Name:      generateEventTypeName(addressable.Name, addressable.Namespace, event.Type(), event.Source()),
+		}
+
+		count, lastSeen, due := h.observe(key, time.Now())
+		if !due {
+			return
+		}
 
-		exists, err := h.EventTypeLister.EventTypes(addressable.Namespace).Get(eventTypeName)
+		exists, err := h.EventTypeLister.EventTypes(key.Namespace).Get(key.Name)
 		if err != nil && !apierrs.IsNotFound(err) {
 			h.Logger.Error("Failed to retrieve Even Type", zap.Error(err))
-			cancel()
+			h.restore(key, count)
 			return
 		}
 		if exists != nil {
-			cancel()
+			h.updateObservations(ctx, key, exists, count, lastSeen)
 			return
 		}
 
@@ -82,8 +131,12 @@ func (h *EventTypeAutoHandler) AutoCreateEventType(ctx context.Context, event *e
 
 		et := &v1beta2.EventType{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      eventTypeName,
-				Namespace: addressable.Namespace,
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Annotations: map[string]string{
+					ObservedCountAnnotation: strconv.FormatInt(count, 10),
+					LastSeenAnnotation:      lastSeen.UTC().Format(time.RFC3339),
+				},
 				OwnerReferences: []metav1.OwnerReference{
 					{
 						APIVersion: addressable.APIVersion,
@@ -104,11 +157,108 @@ func (h *EventTypeAutoHandler) AutoCreateEventType(ctx context.Context, event *e
 		}
 
 		_, err = h.EventingClient.EventTypes(et.Namespace).Create(ctx, et, metav1.CreateOptions{})
-		if err != nil && !apierrs.IsAlreadyExists(err) {
+		if err == nil {
+			return
+		}
+		if !apierrs.IsAlreadyExists(err) {
 			h.Logger.Error("Failed to create Event Type", zap.Error(err))
-			cancel()
+			h.restore(key, count)
 			return
 		}
-		cancel()
+
+		// The lister has not caught up with the EventType yet, fetch it to
+		// update its observations.
+		exists, err = h.EventingClient.EventTypes(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+		if err != nil {
+			h.Logger.Error("Failed to retrieve Even Type", zap.Error(err))
+			h.restore(key, count)
+			return
+		}
+		h.updateObservations(ctx, key, exists, count, lastSeen)
 	}()
 }
+
+// observe records an event for the EventType identified by key. It reports
+// whether the observation annotations are due for an update and, if so,
+// returns the number of events observed since the last update.
+func (h *EventTypeAutoHandler) observe(key types.NamespacedName, now time.Time) (int64, time.Time, bool) {
+	interval := h.ObservationSyncInterval
+	if interval <= 0 {
+		interval = defaultObservationSyncInterval
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.observations == nil {
+		size := h.MaxObservations
+		if size <= 0 {
+			size = defaultMaxObservations
+		}
+		// lru.New only fails for non-positive sizes
+		h.observations, _ = lru.New(size)
+	}
+	var o *observation
+	if cached, ok := h.observations.Get(key); ok {
+		o = cached.(*observation)
+	} else {
+		o = &observation{}
+		h.observations.Add(key, o)
+	}
+	o.pending++
+	o.lastSeen = now
+
+	if !o.lastSync.IsZero() && now.Sub(o.lastSync) < interval {
+		return 0, time.Time{}, false
+	}
+
+	count := o.pending
+	o.pending = 0
+	o.lastSync = now
+	return count, o.lastSeen, true
+}
+
+// restore gives back observations which could not be written, so that they
+// are retried with the next event of the same EventType.
+func (h *EventTypeAutoHandler) restore(key types.NamespacedName, count int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.observations == nil {
+		return
+	}
+	if cached, ok := h.observations.Peek(key); ok {
+		o := cached.(*observation)
+		o.pending += count
+		o.lastSync = time.Time{}
+	}
+}
+
+// updateObservations adds count to the observed count of the EventType and
+// bumps its last seen timestamp.
+func (h *EventTypeAutoHandler) updateObservations(ctx context.Context, key types.NamespacedName, et *v1beta2.EventType, count int64, lastSeen time.Time) {
+	// Ignore malformed counts, they are overwritten by this update.
+	observed, _ := strconv.ParseInt(et.GetAnnotations()[ObservedCountAnnotation], 10, 64)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			// Fail on concurrent updates instead of losing observations.
+			"resourceVersion": et.GetResourceVersion(),
+			"annotations": map[string]string{
+				ObservedCountAnnotation: strconv.FormatInt(observed+count, 10),
+				LastSeenAnnotation:      lastSeen.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		h.Logger.Error("Failed to create Event Type observations patch", zap.Error(err))
+		h.restore(key, count)
+		return
+	}
+
+	_, err = h.EventingClient.EventTypes(key.Namespace).Patch(ctx, key.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		h.Logger.Error("Failed to update Event Type observations", zap.Error(err))
+		h.restore(key, count)
+	}
+}
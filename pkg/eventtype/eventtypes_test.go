@@ -111,6 +111,80 @@ func TestEventTypeAutoHandler_AutoCreateEventType(t *testing.T) {
 
 }
 
+func TestEventTypeAutoHandler_AutoCreateEventTypeObservations(t *testing.T) {
+	ctx := context.TODO()
+	listers := reconcilertestingv1beta2.NewListers(nil)
+	eventingClient := fakeeventingclientset.NewSimpleClientset()
+
+	handler := &EventTypeAutoHandler{
+		EventTypeLister:         listers.GetEventTypeLister(),
+		EventingClient:          eventingClient.EventingV1beta2(),
+		FeatureStore:            initFeatureStore(t, "enabled"),
+		Logger:                  zap.NewNop(),
+		ObservationSyncInterval: time.Nanosecond,
+	}
+	addressable := &duckv1.KReference{
+		APIVersion: "eventing.knative.dev/v1",
+		Kind:       "Broker",
+		Namespace:  "default",
+		Name:       "broker",
+	}
+
+	event := initEvent("")
+	for i := 0; i < 3; i++ {
+		handler.AutoCreateEventType(ctx, &event, addressable, types.UID("owner-uid"))
+		time.Sleep(time.Millisecond * 500) // autocreate runs in a different goroutine, need to wait for it to finish
+	}
+
+	etName := generateEventTypeName(addressable.Name, addressable.Namespace, event.Type(), event.Source())
+	et, err := eventingClient.EventingV1beta2().EventTypes(addressable.Namespace).Get(ctx, etName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := et.GetAnnotations()[ObservedCountAnnotation]; got != "3" {
+		t.Errorf("expected observed count '3', got '%s'", got)
+	}
+	if _, err := time.Parse(time.RFC3339, et.GetAnnotations()[LastSeenAnnotation]); err != nil {
+		t.Errorf("expected valid last seen timestamp: %v", err)
+	}
+}
+
+func TestEventTypeAutoHandler_Observe(t *testing.T) {
+	handler := &EventTypeAutoHandler{ObservationSyncInterval: time.Minute}
+	key := types.NamespacedName{Namespace: "default", Name: "et"}
+	now := time.Now()
+
+	if count, _, due := handler.observe(key, now); !due || count != 1 {
+		t.Errorf("expected first observation to be synced with count 1, got due=%v count=%d", due, count)
+	}
+	if _, _, due := handler.observe(key, now.Add(time.Second)); due {
+		t.Error("expected observation within sync interval to be aggregated")
+	}
+	handler.restore(key, 1)
+	if count, _, due := handler.observe(key, now.Add(2*time.Second)); !due || count != 3 {
+		t.Errorf("expected restored observations to be synced with count 3, got due=%v count=%d", due, count)
+	}
+}
+
+func TestEventTypeAutoHandler_ObserveEviction(t *testing.T) {
+	handler := &EventTypeAutoHandler{ObservationSyncInterval: time.Minute, MaxObservations: 2}
+	now := time.Now()
+
+	for _, name := range []string{"a", "b", "a", "c"} {
+		handler.observe(types.NamespacedName{Namespace: "default", Name: name}, now)
+	}
+	if got := handler.observations.Len(); got != 2 {
+		t.Errorf("expected 2 observations kept in memory, got %d", got)
+	}
+	// b was the least recently observed, it is synced again as it was evicted
+	if _, _, due := handler.observe(types.NamespacedName{Namespace: "default", Name: "b"}, now.Add(time.Second)); !due {
+		t.Error("expected evicted observation to be synced")
+	}
+	if _, _, due := handler.observe(types.NamespacedName{Namespace: "default", Name: "c"}, now.Add(time.Second)); due {
+		t.Error("expected kept observation within sync interval to be aggregated")
+	}
+}
+
 func TestEventTypeAutoHandler_GenerateEventTypeName(t *testing.T) {
 	testCases := []struct {
 		name         string
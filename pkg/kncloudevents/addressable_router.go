@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+// AddressableRoute is an Addressable hosted by an AddressableRouter.
+type AddressableRoute struct {
+	// Hosts are the host names of the Addressable, e.g. the host of its
+	// HTTP and of its HTTPS address.
+	Hosts []string
+	// Paths are the paths of the Addressable on a host shared by multiple
+	// Addressables, e.g. the path of the HTTPS address of a channel on the
+	// host of its dispatcher.
+	Paths []string
+	// Handler handles the requests to the Addressable.
+	Handler http.Handler
+	// Audiences are the OIDC audiences accepted for requests to the
+	// Addressable. Requests aren't verified if empty.
+	Audiences auth.AudienceMatcher
+	// GetCertificate returns the server certificate of the Addressable. The
+	// default certificate of the router is used if nil.
+	GetCertificate eventingtls.GetCertificate
+}
+
+// AddressableRouter hosts multiple Addressables on one port, e.g. the
+// channels of a multi-tenant channel dispatcher. It selects the server
+// certificate by SNI, verifies the OIDC token of requests for the audience
+// of the Addressable of their path or Host header and routes them to its
+// handler.
+type AddressableRouter struct {
+	tokenVerifier      *auth.OIDCTokenVerifier
+	defaultCertificate eventingtls.GetCertificate
+
+	mu     sync.RWMutex
+	routes map[string]*AddressableRoute
+	paths  map[string]*AddressableRoute
+}
+
+// NewAddressableRouter returns an AddressableRouter without routes. The
+// token verifier may be nil if no route has audiences, and the default
+// certificate may be nil if all routes have a certificate or TLS isn't used.
+func NewAddressableRouter(tokenVerifier *auth.OIDCTokenVerifier, defaultCertificate eventingtls.GetCertificate) *AddressableRouter {
+	return &AddressableRouter{
+		tokenVerifier:      tokenVerifier,
+		defaultCertificate: defaultCertificate,
+		routes:             make(map[string]*AddressableRoute),
+		paths:              make(map[string]*AddressableRoute),
+	}
+}
+
+// Register adds the route for its hosts and paths, replacing the routes
+// previously registered for them.
+func (r *AddressableRouter) Register(route AddressableRoute) error {
+	if len(route.Hosts) == 0 && len(route.Paths) == 0 {
+		return fmt.Errorf("route must have at least one host or path")
+	}
+	if route.Handler == nil {
+		return fmt.Errorf("route handler must not be nil")
+	}
+	if len(route.Audiences) > 0 && r.tokenVerifier == nil {
+		return fmt.Errorf("route with audiences requires a token verifier")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, host := range route.Hosts {
+		r.routes[normalizeHost(host)] = &route
+	}
+	for _, path := range route.Paths {
+		r.paths[normalizePath(path)] = &route
+	}
+	return nil
+}
+
+// Unregister removes the routes of the given hosts and paths.
+func (r *AddressableRouter) Unregister(hostsOrPaths ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range hostsOrPaths {
+		delete(r.routes, normalizeHost(key))
+		delete(r.paths, normalizePath(key))
+	}
+}
+
+func (r *AddressableRouter) route(host string) (*AddressableRoute, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	route, ok := r.routes[normalizeHost(host)]
+	return route, ok
+}
+
+// routeRequest returns the route of the request path, falling back to the
+// route of its Host header.
+func (r *AddressableRouter) routeRequest(req *http.Request) (*AddressableRoute, bool) {
+	if path := normalizePath(req.URL.Path); path != "" {
+		r.mu.RLock()
+		route, ok := r.paths[path]
+		r.mu.RUnlock()
+		if ok {
+			return route, true
+		}
+	}
+	return r.route(req.Host)
+}
+
+// GetCertificate returns the certificate of the Addressable of the SNI
+// server name, falling back to the default certificate.
+func (r *AddressableRouter) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if route, ok := r.route(hello.ServerName); ok && route.GetCertificate != nil {
+		return route.GetCertificate(hello)
+	}
+	if r.defaultCertificate == nil {
+		return nil, fmt.Errorf("no certificate for server name %q", hello.ServerName)
+	}
+	return r.defaultCertificate(hello)
+}
+
+// TLSConfig returns the server TLS config selecting the certificates with
+// GetCertificate, e.g. for WithTLSConfig.
+func (r *AddressableRouter) TLSConfig() (*tls.Config, error) {
+	serverConfig := eventingtls.NewDefaultServerConfig()
+	serverConfig.GetCertificate = r.GetCertificate
+	return eventingtls.GetTLSServerConfig(serverConfig)
+}
+
+// ServeHTTP routes the request to the Addressable of its path or Host header.
+// Requests to unknown Addressables are answered with 404 Not Found.
+func (r *AddressableRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route, ok := r.routeRequest(req)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if len(route.Audiences) == 0 {
+		route.Handler.ServeHTTP(w, req)
+		return
+	}
+	r.tokenVerifier.VerifyMiddlewareForAudiences(func(*http.Request) auth.AudienceMatcher {
+		return route.Audiences
+	}, route.Handler).ServeHTTP(w, req)
+}
+
+// normalizeHost strips the port and the trailing dot of the host, and
+// lower cases it.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// normalizePath strips the leading and trailing slashes of the path.
+func normalizePath(path string) string {
+	return strings.Trim(path, "/")
+}
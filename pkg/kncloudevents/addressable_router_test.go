@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/apis/feature"
+	"knative.dev/eventing/pkg/auth"
+)
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func TestAddressableRouterServeHTTP(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	router := NewAddressableRouter(auth.NewOIDCTokenVerifier(ctx), nil)
+
+	require.NoError(t, router.Register(AddressableRoute{
+		Hosts:   []string{"channel-a.ns.svc.cluster.local"},
+		Handler: statusHandler(http.StatusAccepted),
+	}))
+	require.NoError(t, router.Register(AddressableRoute{
+		Hosts:     []string{"channel-b.ns.svc.cluster.local"},
+		Paths:     []string{"/ns/channel-b"},
+		Handler:   statusHandler(http.StatusAccepted),
+		Audiences: auth.NewAudienceMatcher("channel-b"),
+	}))
+
+	tests := []struct {
+		name       string
+		host       string
+		path       string
+		oidc       bool
+		wantStatus int
+	}{{
+		name:       "route without audience",
+		host:       "channel-a.ns.svc.cluster.local",
+		oidc:       true,
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "host with port and upper case",
+		host:       "Channel-A.ns.svc.cluster.local:8080",
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "unknown host",
+		host:       "channel-c.ns.svc.cluster.local",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "route with audience and OIDC disabled",
+		host:       "channel-b.ns.svc.cluster.local",
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "route with audience without token",
+		host:       "channel-b.ns.svc.cluster.local",
+		oidc:       true,
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "path on a shared host",
+		host:       "dispatcher.knative-eventing.svc.cluster.local",
+		path:       "/ns/channel-b",
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "path on a shared host without token",
+		host:       "dispatcher.knative-eventing.svc.cluster.local",
+		path:       "/ns/channel-b",
+		oidc:       true,
+		wantStatus: http.StatusUnauthorized,
+	}, {
+		name:       "unknown path on a shared host",
+		host:       "dispatcher.knative-eventing.svc.cluster.local",
+		path:       "/ns/channel-c",
+		wantStatus: http.StatusNotFound,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			flags := feature.Flags{}
+			if tc.oidc {
+				flags[feature.OIDCAuthentication] = feature.Enabled
+			}
+			req := httptest.NewRequest(http.MethodPost, "http://"+tc.host+tc.path, nil)
+			req = req.WithContext(feature.ToContext(ctx, flags))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tc.wantStatus, w.Code)
+		})
+	}
+
+	router.Unregister("channel-a.ns.svc.cluster.local", "ns/channel-b")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://channel-a.ns.svc.cluster.local", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://dispatcher.knative-eventing.svc.cluster.local/ns/channel-b", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAddressableRouterGetCertificate(t *testing.T) {
+	defaultCert, routeCert := &tls.Certificate{}, &tls.Certificate{}
+	router := NewAddressableRouter(nil, func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return defaultCert, nil
+	})
+	require.NoError(t, router.Register(AddressableRoute{
+		Hosts:   []string{"channel-a.ns.svc.cluster.local"},
+		Handler: statusHandler(http.StatusAccepted),
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return routeCert, nil
+		},
+	}))
+
+	cert, err := router.GetCertificate(&tls.ClientHelloInfo{ServerName: "channel-a.ns.svc.cluster.local"})
+	require.NoError(t, err)
+	require.Same(t, routeCert, cert)
+
+	cert, err = router.GetCertificate(&tls.ClientHelloInfo{ServerName: "channel-b.ns.svc.cluster.local"})
+	require.NoError(t, err)
+	require.Same(t, defaultCert, cert)
+}
+
+func TestAddressableRouterRegisterValidation(t *testing.T) {
+	router := NewAddressableRouter(nil, nil)
+	require.Error(t, router.Register(AddressableRoute{Handler: statusHandler(http.StatusOK)}))
+	require.Error(t, router.Register(AddressableRoute{Hosts: []string{"host"}}))
+	require.Error(t, router.Register(AddressableRoute{
+		Hosts:     []string{"host"},
+		Handler:   statusHandler(http.StatusOK),
+		Audiences: auth.NewAudienceMatcher("aud"),
+	}))
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attributes
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/transformer"
+)
+
+const (
+	KnativeDispatchDestExtensionKey    = "knativedispatchdest"
+	KnativeDispatchCodeExtensionKey    = "knativedispatchcode"
+	KnativeDispatchLatencyExtensionKey = "knativedispatchlatency"
+)
+
+// KnativeDispatchTransformers returns Transformers which add the destination,
+// the response code and the latency in milliseconds of the hop which produced
+// the event, e.g. of the request whose reply the event is.
+func KnativeDispatchTransformers(destination url.URL, code int, latency time.Duration) binding.Transformers {
+	return binding.Transformers{
+		transformer.AddExtension(KnativeDispatchDestExtensionKey, destination),
+		transformer.AddExtension(KnativeDispatchCodeExtensionKey, code),
+		transformer.AddExtension(KnativeDispatchLatencyExtensionKey, int(latency/time.Millisecond)),
+	}
+}
@@ -28,6 +28,30 @@ const (
 	KnativeErrorCodeExtensionKey       = "knativeerrorcode"
 	KnativeErrorDataExtensionKey       = "knativeerrordata"
 	KnativeErrorDataExtensionMaxLength = 1024
+	KnativeErrorReasonExtensionKey     = "knativeerrorreason"
+	KnativeErrorAttemptsExtensionKey   = "knativeerrorattempts"
+)
+
+// KnativeErrorReason is the class of the failure of a delivery, carried in the
+// knativeerrorreason extension.
+type KnativeErrorReason string
+
+const (
+	// KnativeErrorReasonTimeout means the request timed out.
+	KnativeErrorReasonTimeout KnativeErrorReason = "timeout"
+	// KnativeErrorReasonConnectionRefused means the destination refused the connection.
+	KnativeErrorReasonConnectionRefused KnativeErrorReason = "connection-refused"
+	// KnativeErrorReasonDNS means the host of the destination couldn't be resolved.
+	KnativeErrorReasonDNS KnativeErrorReason = "dns"
+	// KnativeErrorReasonTLS means the TLS handshake with the destination failed.
+	KnativeErrorReasonTLS KnativeErrorReason = "tls"
+	// KnativeErrorReasonNon2xx means the destination responded with a non-2xx status code.
+	KnativeErrorReasonNon2xx KnativeErrorReason = "non-2xx"
+	// KnativeErrorReasonCircuitOpen means the request wasn't sent to protect an
+	// overloaded destination.
+	KnativeErrorReasonCircuitOpen KnativeErrorReason = "circuit-open"
+	// KnativeErrorReasonUnknown means the failure couldn't be classified.
+	KnativeErrorReasonUnknown KnativeErrorReason = "unknown"
 )
 
 // KnativeErrorTransformers returns Transformers which add the specified destination and error code/data extensions.
@@ -40,3 +64,13 @@ func KnativeErrorTransformers(destination url.URL, code int, data string) bindin
 	dataTransformer := transformer.AddExtension(KnativeErrorDataExtensionKey, data)
 	return binding.Transformers{destTransformer, codeTransformer, dataTransformer}
 }
+
+// KnativeErrorReasonTransformers returns Transformers which add the specified
+// error reason and the number of attempts, if any, extensions.
+func KnativeErrorReasonTransformers(reason KnativeErrorReason, attempts int) binding.Transformers {
+	transformers := binding.Transformers{transformer.AddExtension(KnativeErrorReasonExtensionKey, string(reason))}
+	if attempts > 0 {
+		transformers = append(transformers, transformer.AddExtension(KnativeErrorAttemptsExtensionKey, attempts))
+	}
+	return transformers
+}
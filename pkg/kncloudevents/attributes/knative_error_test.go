@@ -116,6 +116,52 @@ func TestKnativeErrorTransformers(t *testing.T) {
 	}
 }
 
+func TestKnativeErrorReasonTransformers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reason   KnativeErrorReason
+		attempts int
+	}{
+		{
+			name:     "With Attempts",
+			reason:   KnativeErrorReasonTimeout,
+			attempts: 3,
+		},
+		{
+			name:   "Without Attempts",
+			reason: KnativeErrorReasonCircuitOpen,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			transformers := KnativeErrorReasonTransformers(testCase.reason, testCase.attempts)
+
+			inputEvent := cetest.MinEvent()
+			wantEvent := inputEvent.Clone()
+			wantEvent.SetExtension(KnativeErrorReasonExtensionKey, string(testCase.reason))
+			if testCase.attempts > 0 {
+				wantEvent.SetExtension(KnativeErrorAttemptsExtensionKey, testCase.attempts)
+			}
+
+			cebindingtest.RunTransformerTests(t, context.Background(), []cebindingtest.TransformerTestArgs{
+				{
+					Name:         "Add Extensions To Event",
+					InputEvent:   inputEvent,
+					WantEvent:    wantEvent,
+					Transformers: transformers,
+				},
+				{
+					Name:         "Add Extensions To Message",
+					InputMessage: binding.ToMessage(&inputEvent),
+					WantEvent:    wantEvent,
+					Transformers: transformers,
+				},
+			})
+		})
+	}
+}
+
 // randomString returns a randomly generated string of the specified length
 func randomString(t *testing.T, length int) string {
 	bytes := make([]byte, length)
@@ -0,0 +1,253 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/spec"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/logging"
+)
+
+// AuditEventType is the type of the events sent by the audit sink of
+// NewAddressableAuditSink.
+const AuditEventType = "dev.knative.eventing.delivery.audit"
+
+// AuditResult is the result of an audited delivery.
+type AuditResult string
+
+const (
+	// AuditResultDelivered means the event, and its reply if any, were delivered.
+	AuditResultDelivered AuditResult = "delivered"
+	// AuditResultDeadLettered means the event, or its reply, couldn't be
+	// delivered and was sent to the dead letter sink.
+	AuditResultDeadLettered AuditResult = "dead-lettered"
+	// AuditResultFailed means the event couldn't be delivered.
+	AuditResultFailed AuditResult = "failed"
+	// AuditResultFiltered means the event didn't pass the filter and wasn't sent.
+	AuditResultFiltered AuditResult = "filtered"
+)
+
+// AuditRecord is the audit record of a completed delivery.
+type AuditRecord struct {
+	Time        time.Time   `json:"time"`
+	EventID     string      `json:"eventId"`
+	EventType   string      `json:"eventType"`
+	EventSource string      `json:"eventSource"`
+	Destination string      `json:"destination"`
+	Result      AuditResult `json:"result"`
+	// ResponseCode is the response code of the last request of the delivery.
+	ResponseCode int `json:"responseCode"`
+	// Attempts is the number of requests sent by the delivery, including
+	// retries, the reply and the dead letter sink.
+	Attempts int           `json:"attempts"`
+	Latency  time.Duration `json:"latency"`
+	// Identity is the OIDC service account the delivery authenticated as, if any.
+	Identity string `json:"identity,omitempty"`
+}
+
+// AuditSink receives the audit records of an AuditLogger.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditSinkFunc is a function implementing AuditSink.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord)
+
+// Audit implements AuditSink.
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// AuditLogger records the deliveries of the sends with the WithAuditLogger
+// send option to an AuditSink.
+type AuditLogger struct {
+	sink       AuditSink
+	sampleRate float64
+}
+
+// AuditLoggerOption configures an AuditLogger.
+type AuditLoggerOption func(*AuditLogger)
+
+// WithAuditSampleRate records only the given fraction, between 0 and 1, of the
+// successful deliveries. Deliveries which failed or were dead lettered are
+// always recorded.
+func WithAuditSampleRate(rate float64) AuditLoggerOption {
+	return func(l *AuditLogger) {
+		l.sampleRate = rate
+	}
+}
+
+// NewAuditLogger returns an AuditLogger recording all deliveries to the sink.
+func NewAuditLogger(sink AuditSink, opts ...AuditLoggerOption) *AuditLogger {
+	l := &AuditLogger{
+		sink:       sink,
+		sampleRate: 1,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *AuditLogger) record(ctx context.Context, record AuditRecord) {
+	switch record.Result {
+	case AuditResultDelivered, AuditResultFiltered:
+		if l.sampleRate < 1 && rand.Float64() >= l.sampleRate { //nolint:gosec // no need for a secure random number for sampling
+			return
+		}
+	}
+	l.sink.Audit(ctx, record)
+}
+
+// NewZapAuditSink returns an AuditSink logging every record as a single
+// entry of the given logger, e.g. a dedicated logger with a JSON encoder,
+// writing one JSON line per delivery.
+func NewZapAuditSink(logger *zap.Logger) AuditSink {
+	return AuditSinkFunc(func(_ context.Context, record AuditRecord) {
+		logger.Info("delivery",
+			zap.Time("time", record.Time),
+			zap.String("eventId", record.EventID),
+			zap.String("eventType", record.EventType),
+			zap.String("eventSource", record.EventSource),
+			zap.String("destination", record.Destination),
+			zap.String("result", string(record.Result)),
+			zap.Int("responseCode", record.ResponseCode),
+			zap.Int("attempts", record.Attempts),
+			zap.Duration("latency", record.Latency),
+			zap.String("identity", record.Identity),
+		)
+	})
+}
+
+// auditSinkBufferSize is the number of records an addressable audit sink buffers.
+const auditSinkBufferSize = 1000
+
+// NewAddressableAuditSink returns an AuditSink sending every record as an
+// event of type AuditEventType to the addressable, with the given source.
+// Records are sent asynchronously, so that auditing doesn't delay deliveries,
+// until the context is done. Records are dropped while the buffer is full.
+func NewAddressableAuditSink(ctx context.Context, dispatcher *Dispatcher, addressable duckv1.Addressable, source string) AuditSink {
+	records := make(chan AuditRecord, auditSinkBufferSize)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case record := <-records:
+				e := event.New()
+				e.SetID(uuid.New().String())
+				e.SetType(AuditEventType)
+				e.SetSource(source)
+				e.SetTime(record.Time)
+				if err := e.SetData(event.ApplicationJSON, record); err != nil {
+					logging.FromContext(ctx).Warnw("Failed to encode audit record", zap.Error(err))
+					continue
+				}
+				if _, err := dispatcher.SendEvent(ctx, e, addressable); err != nil {
+					logging.FromContext(ctx).Warnw("Failed to send audit record", zap.Error(err), zap.String("eventId", record.EventID))
+				}
+			}
+		}
+	}()
+
+	return AuditSinkFunc(func(ctx context.Context, record AuditRecord) {
+		select {
+		case records <- record:
+		default:
+			logging.FromContext(ctx).Warnw("Dropping audit record, buffer is full", zap.String("eventId", record.EventID))
+		}
+	})
+}
+
+// deliveryAudit collects the audit record of a delivery.
+type deliveryAudit struct {
+	start  time.Time
+	record AuditRecord
+}
+
+func newDeliveryAudit(message binding.Message, destination duckv1.Addressable, config *senderConfig) *deliveryAudit {
+	url, _ := withoutUserinfo(destination.URL)
+	a := &deliveryAudit{
+		start: time.Now(),
+		record: AuditRecord{
+			Destination: url.String(),
+		},
+	}
+	if reader, ok := message.(binding.MessageMetadataReader); ok {
+		a.record.EventID = attributeString(reader, spec.ID)
+		a.record.EventType = attributeString(reader, spec.Type)
+		a.record.EventSource = attributeString(reader, spec.Source)
+	}
+	if config.oidcServiceAccount != nil {
+		a.record.Identity = config.oidcServiceAccount.String()
+	}
+	return a
+}
+
+func attributeString(reader binding.MessageMetadataReader, kind spec.Kind) string {
+	_, value := reader.GetAttribute(kind)
+	if value == nil {
+		return ""
+	}
+	s, _ := types.ToString(value)
+	return s
+}
+
+// attempted records the requests of a leg of the delivery.
+func (a *deliveryAudit) attempted(info *DispatchInfo) {
+	if a == nil || info == nil {
+		return
+	}
+	a.record.Attempts += info.Attempts
+}
+
+// deadLettered records that the delivery was dead lettered.
+func (a *deliveryAudit) deadLettered() {
+	if a == nil {
+		return
+	}
+	a.record.Result = AuditResultDeadLettered
+}
+
+// complete returns the audit record of the completed delivery.
+func (a *deliveryAudit) complete(info *DispatchInfo, err error) AuditRecord {
+	record := a.record
+	record.Time = a.start
+	record.Latency = time.Since(a.start)
+	if info != nil {
+		record.ResponseCode = info.ResponseCode
+	}
+	switch {
+	case err != nil:
+		record.Result = AuditResultFailed
+	case info != nil && info.Filtered:
+		record.Result = AuditResultFiltered
+	case record.Result == "":
+		record.Result = AuditResultDelivered
+	}
+	return record
+}
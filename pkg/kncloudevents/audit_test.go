@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventfilter/attributes"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+type auditRecorder struct {
+	mu      sync.Mutex
+	records []kncloudevents.AuditRecord
+}
+
+func (r *auditRecorder) Audit(_ context.Context, record kncloudevents.AuditRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *auditRecorder) Records() []kncloudevents.AuditRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]kncloudevents.AuditRecord(nil), r.records...)
+}
+
+func TestDispatchWithAuditLogger(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+	e := test.FullEvent()
+	retryConfig := &kncloudevents.RetryConfig{
+		RetryMax:   2,
+		CheckRetry: kncloudevents.SelectiveRetry,
+		Backoff:    func(int, *http.Response) time.Duration { return time.Millisecond },
+	}
+
+	tests := []struct {
+		name         string
+		responses    []kncloudeventstest.Response
+		options      []kncloudevents.SendOption
+		wantResult   kncloudevents.AuditResult
+		wantCode     int
+		wantAttempts int
+		wantIdentity string
+	}{{
+		name:         "delivered",
+		responses:    []kncloudeventstest.Response{kncloudeventstest.Status(http.StatusAccepted)},
+		wantResult:   kncloudevents.AuditResultDelivered,
+		wantCode:     http.StatusAccepted,
+		wantAttempts: 1,
+	}, {
+		name: "delivered after retry",
+		responses: []kncloudeventstest.Response{
+			kncloudeventstest.Status(http.StatusServiceUnavailable),
+			kncloudeventstest.Status(http.StatusAccepted),
+		},
+		options:      []kncloudevents.SendOption{kncloudevents.WithRetryConfig(retryConfig)},
+		wantResult:   kncloudevents.AuditResultDelivered,
+		wantCode:     http.StatusAccepted,
+		wantAttempts: 2,
+	}, {
+		name:         "dead lettered",
+		responses:    []kncloudeventstest.Response{kncloudeventstest.Status(http.StatusBadRequest)},
+		options:      []kncloudevents.SendOption{kncloudevents.WithDeadLetterSink(&dls)},
+		wantResult:   kncloudevents.AuditResultDeadLettered,
+		wantCode:     http.StatusAccepted,
+		wantAttempts: 2,
+	}, {
+		name:         "failed",
+		responses:    []kncloudeventstest.Response{kncloudeventstest.Status(http.StatusBadRequest)},
+		wantResult:   kncloudevents.AuditResultFailed,
+		wantCode:     http.StatusBadRequest,
+		wantAttempts: 1,
+	}, {
+		name:       "filtered",
+		options:    []kncloudevents.SendOption{kncloudevents.WithEventFilter(attributes.NewAttributesFilter(map[string]string{"type": "other"}))},
+		wantResult: kncloudevents.AuditResultFiltered,
+		wantCode:   kncloudevents.NoResponse,
+	}, {
+		name:         "with identity",
+		responses:    []kncloudeventstest.Response{kncloudeventstest.Status(http.StatusAccepted)},
+		options:      []kncloudevents.SendOption{kncloudevents.WithOIDCAuthentication(&types.NamespacedName{Namespace: "ns", Name: "sa"})},
+		wantResult:   kncloudevents.AuditResultDelivered,
+		wantCode:     http.StatusAccepted,
+		wantAttempts: 1,
+		wantIdentity: "ns/sa",
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().
+				On(destination.URL.String(), tc.responses...).
+				On(dls.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+			recorder := &auditRecorder{}
+
+			options := append([]kncloudevents.SendOption{kncloudevents.WithAuditLogger(kncloudevents.NewAuditLogger(recorder))}, tc.options...)
+			_, _ = dispatcher.SendEvent(ctx, e, destination, options...)
+
+			records := recorder.Records()
+			require.Len(t, records, 1)
+			record := records[0]
+			require.Equal(t, e.ID(), record.EventID)
+			require.Equal(t, e.Type(), record.EventType)
+			require.Equal(t, e.Source(), record.EventSource)
+			require.Equal(t, destination.URL.String(), record.Destination)
+			require.Equal(t, tc.wantResult, record.Result)
+			require.Equal(t, tc.wantCode, record.ResponseCode)
+			require.Equal(t, tc.wantAttempts, record.Attempts)
+			require.Equal(t, tc.wantIdentity, record.Identity)
+			require.False(t, record.Time.IsZero())
+			require.Positive(t, record.Latency)
+		})
+	}
+}
+
+func TestAuditLoggerSampling(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Status(http.StatusAccepted), kncloudeventstest.Status(http.StatusBadRequest))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	recorder := &auditRecorder{}
+	logger := kncloudevents.NewAuditLogger(recorder, kncloudevents.WithAuditSampleRate(0))
+
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithAuditLogger(logger))
+	require.Nil(t, err)
+	require.Empty(t, recorder.Records())
+
+	// failures are always recorded
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithAuditLogger(logger))
+	require.Error(t, err)
+	records := recorder.Records()
+	require.Len(t, records, 1)
+	require.Equal(t, kncloudevents.AuditResultFailed, records[0].Result)
+}
+
+func TestZapAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.InfoLevel)
+	sink := kncloudevents.NewZapAuditSink(zap.New(core))
+
+	sink.Audit(context.Background(), kncloudevents.AuditRecord{
+		EventID:      "id",
+		EventType:    "type",
+		EventSource:  "source",
+		Destination:  "http://destination.example.com",
+		Result:       kncloudevents.AuditResultDelivered,
+		ResponseCode: http.StatusAccepted,
+		Attempts:     1,
+		Latency:      time.Second,
+		Identity:     "ns/sa",
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var fields map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &fields))
+	require.Equal(t, "id", fields["eventId"])
+	require.Equal(t, "delivered", fields["result"])
+	require.Equal(t, float64(http.StatusAccepted), fields["responseCode"])
+	require.Equal(t, float64(1), fields["attempts"])
+	require.Equal(t, float64(1), fields["latency"])
+	require.Equal(t, "ns/sa", fields["identity"])
+}
+
+func TestAddressableAuditSink(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	auditAddressable := duckv1.Addressable{URL: apis.HTTP("audit.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	sink := kncloudevents.NewAddressableAuditSink(ctx, dispatcher, auditAddressable, "/audit")
+
+	sink.Audit(ctx, kncloudevents.AuditRecord{
+		EventID: "id",
+		Result:  kncloudevents.AuditResultDelivered,
+	})
+
+	require.Eventually(t, func() bool {
+		return len(fakeClient.RequestsTo(auditAddressable.URL.String())) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	request := fakeClient.RequestsTo(auditAddressable.URL.String())[0]
+	require.Equal(t, kncloudevents.AuditEventType, request.Header.Get("Ce-Type"))
+	require.Equal(t, "/audit", request.Header.Get("Ce-Source"))
+	require.Equal(t, event.ApplicationJSON, request.Header.Get("Content-Type"))
+	var record kncloudevents.AuditRecord
+	require.Nil(t, json.Unmarshal(request.Body, &record))
+	require.Equal(t, "id", record.EventID)
+	require.Equal(t, kncloudevents.AuditResultDelivered, record.Result)
+}
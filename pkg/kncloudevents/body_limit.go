@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"errors"
+	"net/http"
+)
+
+// BodySizeLimiter returns the maximum size in bytes of the body of a request,
+// zero or a negative value disables the limit.
+type BodySizeLimiter func(r *http.Request) int64
+
+// LimitRequestBody enforces the limit on the body of the request. Requests
+// announcing a larger body are rejected with 413 Request Entity Too Large
+// before reading the body, in which case false is returned. Otherwise the
+// body is replaced by a reader failing once more than limit bytes were read,
+// see IsBodyTooLarge.
+func LimitRequestBody(w http.ResponseWriter, r *http.Request, limit int64) bool {
+	if limit <= 0 {
+		return true
+	}
+	if r.ContentLength > limit {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return true
+}
+
+// IsBodyTooLarge returns whether the error was caused by reading a request
+// body exceeding the limit set by LimitRequestBody.
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// NewMaxBodySizeHandler returns a handler enforcing the limit on the bodies of
+// the requests passed to next, see LimitRequestBody. next is expected to
+// answer with 413 Request Entity Too Large when IsBodyTooLarge reports the
+// errors reading the body.
+func NewMaxBodySizeHandler(next http.Handler, limit BodySizeLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if LimitRequestBody(w, r, limit(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// WithMaxBodySize rejects requests with bodies larger than the limit with
+// 413 Request Entity Too Large, see NewMaxBodySizeHandler.
+func WithMaxBodySize(limit BodySizeLimiter) HTTPEventReceiverOption {
+	return func(h *HTTPEventReceiver) {
+		h.maxBodySize = limit
+	}
+}
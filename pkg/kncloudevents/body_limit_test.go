@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewMaxBodySizeHandler(t *testing.T) {
+	tests := map[string]struct {
+		body          string
+		contentLength int64
+		limit         int64
+		want          int
+	}{
+		"no limit": {
+			body:          "0123456789",
+			contentLength: 10,
+			want:          http.StatusAccepted,
+		},
+		"within limit": {
+			body:          "0123456789",
+			contentLength: 10,
+			limit:         10,
+			want:          http.StatusAccepted,
+		},
+		"announced body too large": {
+			body:          "0123456789",
+			contentLength: 10,
+			limit:         5,
+			want:          http.StatusRequestEntityTooLarge,
+		},
+		"streamed body too large": {
+			body:          "0123456789",
+			contentLength: -1,
+			limit:         5,
+			want:          http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if _, err := io.ReadAll(r.Body); err != nil {
+					if !IsBodyTooLarge(err) {
+						t.Errorf("expected body too large error, got %v", err)
+					}
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			h := NewMaxBodySizeHandler(next, func(*http.Request) int64 { return tc.limit })
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.ContentLength = tc.contentLength
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("expected status %d, got %d", tc.want, rec.Code)
+			}
+			if tc.contentLength > tc.limit && tc.limit > 0 && called {
+				t.Error("expected the request to be rejected before calling the handler")
+			}
+		})
+	}
+}
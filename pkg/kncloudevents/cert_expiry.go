@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// CertificateExpiryViewName is the name of the view of the expiry of the
+	// server certificates of the destinations, in seconds since the epoch.
+	CertificateExpiryViewName = "destination_certificate_expiry_timestamp"
+
+	// DefaultCertificateExpiryThreshold is the remaining validity under which
+	// a server certificate is reported as expiring.
+	DefaultCertificateExpiryThreshold = 14 * 24 * time.Hour
+
+	// CertificateExpiringReason is the reason of the warning events of
+	// ExpiringCertificateEventRecorder.
+	CertificateExpiringReason = "DestinationCertificateExpiring"
+)
+
+var (
+	certificateNotAfterM = stats.Int64(
+		"destination_certificate_not_after",
+		"Expiry of the server certificate of the destination, in seconds since the epoch",
+		stats.UnitSeconds,
+	)
+
+	certificateDestinationKey = tag.MustNewKey("destination")
+)
+
+func init() {
+	registerCertificateExpiryViews()
+}
+
+func registerCertificateExpiryViews() {
+	err := metrics.RegisterResourceView(&view.View{
+		Name:        CertificateExpiryViewName,
+		Description: certificateNotAfterM.Description(),
+		Measure:     certificateNotAfterM,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{certificateDestinationKey},
+	})
+	if err != nil {
+		log.Print("failed to register opencensus views, " + err.Error())
+	}
+}
+
+// ExpiringCertificateFunc is called by a CertificateExpiryMonitor for the
+// server certificates of destinations expiring within its threshold, once per
+// certificate.
+type ExpiringCertificateFunc func(destination string, notAfter time.Time)
+
+// ExpiringCertificateEventRecorder returns an ExpiringCertificateFunc
+// recording a warning event on the given object, e.g. the resource the
+// component dispatches events for.
+func ExpiringCertificateEventRecorder(recorder record.EventRecorder, object runtime.Object) ExpiringCertificateFunc {
+	return func(destination string, notAfter time.Time) {
+		recorder.Eventf(object, corev1.EventTypeWarning, CertificateExpiringReason,
+			"Server certificate of %s expires at %s", destination, notAfter.UTC().Format(time.RFC3339))
+	}
+}
+
+type certificateExpiry struct {
+	notAfter time.Time
+	warned   bool
+}
+
+// CertificateExpiryMonitor records the expiry of the server certificates of
+// the destinations a Dispatcher sends to over TLS, and reports the
+// certificates expiring within a threshold, so that expiring sink
+// certificates are caught before deliveries start failing.
+type CertificateExpiryMonitor struct {
+	threshold  time.Duration
+	onExpiring ExpiringCertificateFunc
+	now        func() time.Time
+
+	mu           sync.Mutex
+	destinations map[string]*certificateExpiry
+}
+
+// NewCertificateExpiryMonitor returns a CertificateExpiryMonitor calling
+// onExpiring, if not nil, for certificates expiring within the threshold.
+func NewCertificateExpiryMonitor(threshold time.Duration, onExpiring ExpiringCertificateFunc) *CertificateExpiryMonitor {
+	return &CertificateExpiryMonitor{
+		threshold:    threshold,
+		onExpiring:   onExpiring,
+		now:          time.Now,
+		destinations: make(map[string]*certificateExpiry),
+	}
+}
+
+// WithCertificateExpiryMonitor records the server certificates of the https
+// destinations of the Dispatcher with the given monitor.
+func WithCertificateExpiryMonitor(monitor *CertificateExpiryMonitor) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.certificateExpiry = monitor
+	}
+}
+
+// observe records the leaf certificate of the connection to the destination.
+func (m *CertificateExpiryMonitor) observe(destination string, state *tls.ConnectionState) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+	notAfter := state.PeerCertificates[0].NotAfter
+
+	m.mu.Lock()
+	expiry, ok := m.destinations[destination]
+	if ok && expiry.notAfter.Equal(notAfter) {
+		m.mu.Unlock()
+		return
+	}
+	// the certificate was rotated, warn again if the new one expires soon
+	expiry = &certificateExpiry{notAfter: notAfter}
+	m.destinations[destination] = expiry
+	warn := m.shouldWarn(expiry)
+	m.mu.Unlock()
+
+	recordCertificateExpiry(destination, notAfter)
+	if warn {
+		m.onExpiring(destination, notAfter)
+	}
+}
+
+// shouldWarn marks the expiry as warned if it's within the threshold. The
+// lock must be held.
+func (m *CertificateExpiryMonitor) shouldWarn(expiry *certificateExpiry) bool {
+	if m.onExpiring == nil || expiry.warned || expiry.notAfter.Sub(m.now()) > m.threshold {
+		return false
+	}
+	expiry.warned = true
+	return true
+}
+
+// Check reports the recorded certificates which expire within the threshold
+// by now and weren't reported yet, and records their expiry again.
+func (m *CertificateExpiryMonitor) Check() {
+	type expiring struct {
+		destination string
+		notAfter    time.Time
+		warn        bool
+	}
+
+	m.mu.Lock()
+	all := make([]expiring, 0, len(m.destinations))
+	for destination, expiry := range m.destinations {
+		all = append(all, expiring{destination: destination, notAfter: expiry.notAfter, warn: m.shouldWarn(expiry)})
+	}
+	m.mu.Unlock()
+
+	for _, e := range all {
+		recordCertificateExpiry(e.destination, e.notAfter)
+		if e.warn {
+			m.onExpiring(e.destination, e.notAfter)
+		}
+	}
+}
+
+// Run calls Check every interval, until the context is done. The expiry of a
+// certificate is checked when it's first seen, so Run catches the certificates
+// crossing the threshold while they are in use.
+func (m *CertificateExpiryMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Expiries returns the expiry of the server certificate per destination.
+func (m *CertificateExpiryMonitor) Expiries() map[string]time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiries := make(map[string]time.Time, len(m.destinations))
+	for destination, expiry := range m.destinations {
+		expiries[destination] = expiry.notAfter
+	}
+	return expiries
+}
+
+func recordCertificateExpiry(destination string, notAfter time.Time) {
+	ctx, err := tag.New(context.Background(), tag.Insert(certificateDestinationKey, destination))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, certificateNotAfterM.M(notAfter.Unix()))
+}
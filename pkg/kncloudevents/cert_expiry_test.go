@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestDispatchWithCertificateExpiryMonitor(t *testing.T) {
+	metricstest.Unregister(CertificateExpiryViewName)
+	registerCertificateExpiryViews()
+	t.Cleanup(func() {
+		metricstest.Unregister(CertificateExpiryViewName)
+		registerCertificateExpiryViews()
+	})
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	notAfter := server.Certificate().NotAfter
+
+	caCerts := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	destination := duckv1.Addressable{URL: apis.HTTPS(server.Listener.Addr().String()), CACerts: &caCerts}
+
+	type warning struct {
+		destination string
+		notAfter    time.Time
+	}
+	var warnings []warning
+	monitor := NewCertificateExpiryMonitor(DefaultCertificateExpiryThreshold, func(destination string, notAfter time.Time) {
+		warnings = append(warnings, warning{destination: destination, notAfter: notAfter})
+	})
+	now := notAfter.Add(-2 * DefaultCertificateExpiryThreshold)
+	monitor.now = func() time.Time { return now }
+
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithCertificateExpiryMonitor(monitor))
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.NoError(t, err)
+
+	host := destination.URL.Host
+	require.Equal(t, map[string]time.Time{host: notAfter}, monitor.Expiries())
+	metricstest.CheckLastValueData(t, CertificateExpiryViewName, map[string]string{"destination": host}, float64(notAfter.Unix()))
+	require.Empty(t, warnings)
+
+	// the certificate gets close to its expiry while in use
+	now = notAfter.Add(-DefaultCertificateExpiryThreshold / 2)
+	monitor.Check()
+	require.Equal(t, []warning{{destination: host, notAfter: notAfter}}, warnings)
+
+	// expiring certificates are reported once
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.NoError(t, err)
+	monitor.Check()
+	require.Len(t, warnings, 1)
+}
+
+func TestCertificateExpiryMonitorRotation(t *testing.T) {
+	var warned []time.Time
+	monitor := NewCertificateExpiryMonitor(time.Hour, func(_ string, notAfter time.Time) {
+		warned = append(warned, notAfter)
+	})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+
+	state := func(notAfter time.Time) *tls.ConnectionState {
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{NotAfter: notAfter}}}
+	}
+
+	expiring := now.Add(time.Minute)
+	monitor.observe("sink.example.com", state(expiring))
+	monitor.observe("sink.example.com", state(expiring))
+	require.Equal(t, []time.Time{expiring}, warned)
+
+	// a rotated certificate expiring soon is reported again
+	rotated := now.Add(30 * time.Minute)
+	monitor.observe("sink.example.com", state(rotated))
+	require.Equal(t, []time.Time{expiring, rotated}, warned)
+
+	// connections without certificates are ignored
+	monitor.observe("other.example.com", &tls.ConnectionState{})
+	require.Equal(t, map[string]time.Time{"sink.example.com": rotated}, monitor.Expiries())
+}
+
+func TestExpiringCertificateEventRecorder(t *testing.T) {
+	recorder := record.NewFakeRecorder(1)
+	notAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ExpiringCertificateEventRecorder(recorder, nil)("sink.example.com:443", notAfter)
+
+	require.Equal(t, "Warning DestinationCertificateExpiring Server certificate of sink.example.com:443 expires at 2024-01-01T00:00:00Z", <-recorder.Events)
+}
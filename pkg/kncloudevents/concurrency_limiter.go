@@ -0,0 +1,323 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// ErrConcurrencyLimitExceeded is returned when a request isn't sent, since the
+// adaptive concurrency limit of its destination, or the in-flight limit of its
+// delivery, see WithInFlightLimit, was reached.
+var ErrConcurrencyLimitExceeded = errors.New("concurrency limit of destination exceeded")
+
+// AdaptiveConcurrencyConfig configures the adaptive concurrency limits of a
+// Dispatcher. Zero values use the defaults.
+type AdaptiveConcurrencyConfig struct {
+	// InitialLimit is the concurrency limit of new destinations, 20 by default.
+	InitialLimit int
+	// MinLimit is the lowest concurrency limit, 1 by default.
+	MinLimit int
+	// MaxLimit is the highest concurrency limit, 1000 by default.
+	MaxLimit int
+	// Tolerance is the factor the latency of a request may exceed the
+	// baseline latency of the destination by, before the limit is decreased.
+	// 2 by default.
+	Tolerance float64
+	// BackoffRatio is the factor the limit is multiplied with when a request
+	// failed or was too slow, 0.9 by default.
+	BackoffRatio float64
+	// MaxWait is how long requests wait for the concurrency of the destination
+	// to drop below the limit, before they're shed. Requests are shed right away
+	// by default.
+	MaxWait time.Duration
+	// IdleTimeout is how long the limiter of a destination without requests is
+	// kept, 10 minutes by default. Destinations start over with InitialLimit
+	// afterwards.
+	IdleTimeout time.Duration
+}
+
+func (c AdaptiveConcurrencyConfig) withDefaults() AdaptiveConcurrencyConfig {
+	if c.InitialLimit <= 0 {
+		c.InitialLimit = 20
+	}
+	if c.MinLimit <= 0 {
+		c.MinLimit = 1
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = 1000
+	}
+	if c.Tolerance <= 1 {
+		c.Tolerance = 2
+	}
+	if c.BackoffRatio <= 0 || c.BackoffRatio >= 1 {
+		c.BackoffRatio = 0.9
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = 10 * time.Minute
+	}
+	return c
+}
+
+// WithAdaptiveConcurrency limits the concurrent requests to each destination.
+// The limit is increased additively while the destination responds in time,
+// and decreased multiplicatively when the latency exceeds the baseline of the
+// destination, or when it fails or throttles. Requests over the limit fail with
+// ErrConcurrencyLimitExceeded, e.g. to be dead lettered, so that retries don't
+// overload a recovering destination. Requests to dead letter sinks are never
+// shed, since the event would be lost otherwise.
+func WithAdaptiveConcurrency(config AdaptiveConcurrencyConfig) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.concurrency = &adaptiveConcurrency{
+			config:   config.withDefaults(),
+			limiters: make(map[string]*concurrencyLimiter),
+			now:      time.Now,
+		}
+	}
+}
+
+type adaptiveConcurrency struct {
+	config AdaptiveConcurrencyConfig
+
+	limitersMu sync.Mutex
+	limiters   map[string]*concurrencyLimiter
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+func (a *adaptiveConcurrency) limiterFor(destination string) *concurrencyLimiter {
+	a.limitersMu.Lock()
+	defer a.limitersMu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.lastSweep) >= a.config.IdleTimeout {
+		a.evictIdle(now)
+		a.lastSweep = now
+	}
+
+	l, ok := a.limiters[destination]
+	if !ok {
+		l = newConcurrencyLimiter(destination, a.config)
+		a.limiters[destination] = l
+	}
+	l.lastUsed = now
+	return l
+}
+
+// evictIdle deletes the limiters of the destinations without requests for
+// IdleTimeout. The caller must hold limitersMu.
+func (a *adaptiveConcurrency) evictIdle(now time.Time) {
+	for destination, l := range a.limiters {
+		if now.Sub(l.lastUsed) < a.config.IdleTimeout {
+			continue
+		}
+		l.mu.Lock()
+		idle := l.inFlight == 0
+		l.mu.Unlock()
+		if idle {
+			delete(a.limiters, destination)
+		}
+	}
+}
+
+// intercept limits the requests of dispatch. It's the innermost interceptor,
+// so that it limits the targets the requests are actually sent to.
+func (a *adaptiveConcurrency) intercept(dispatch DispatchFunc) DispatchFunc {
+	return func(ctx context.Context, request DispatchRequest) (binding.Message, *DispatchInfo, error) {
+		limiter := a.limiterFor(request.Target.URL.String())
+		if isDeadLetter(ctx) {
+			limiter.acquireUnlimited()
+		} else if err := limiter.acquire(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		ctx, backoff := contextWithBackoffRecorder(ctx)
+		start := time.Now()
+		response, info, err := dispatch(ctx, request)
+		limiter.release(attemptLatency(time.Since(start), backoff, info), isDegraded(info, err))
+		return response, info, err
+	}
+}
+
+// attemptLatency returns the average latency of the attempts of a request,
+// without the time spent waiting between retries.
+func attemptLatency(elapsed time.Duration, backoff *backoffRecorder, info *DispatchInfo) time.Duration {
+	latency := elapsed - backoff.total
+	if latency < 0 {
+		latency = 0
+	}
+	if info != nil && info.Attempts > 1 {
+		latency /= time.Duration(info.Attempts)
+	}
+	return latency
+}
+
+type deadLetterKey struct{}
+
+// contextWithDeadLetter marks the requests sent with the context as requests to
+// a dead letter sink, which aren't shed.
+func contextWithDeadLetter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deadLetterKey{}, true)
+}
+
+func isDeadLetter(ctx context.Context) bool {
+	deadLetter, _ := ctx.Value(deadLetterKey{}).(bool)
+	return deadLetter
+}
+
+// backoffRecorder sums up the backoff between the retries of a request.
+type backoffRecorder struct {
+	total time.Duration
+}
+
+type backoffRecorderKey struct{}
+
+func contextWithBackoffRecorder(ctx context.Context) (context.Context, *backoffRecorder) {
+	recorder := &backoffRecorder{}
+	return context.WithValue(ctx, backoffRecorderKey{}, recorder), recorder
+}
+
+// recordBackoff adds the backoff chosen to the backoff recorder of the
+// context, if there is one.
+func recordBackoff(ctx context.Context, backoff retryablehttp.Backoff) retryablehttp.Backoff {
+	recorder, ok := ctx.Value(backoffRecorderKey{}).(*backoffRecorder)
+	if !ok {
+		return backoff
+	}
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		wait := backoff(min, max, attemptNum, resp)
+		recorder.total += wait
+		return wait
+	}
+}
+
+// isDegraded returns whether the response indicates an overloaded destination.
+func isDegraded(info *DispatchInfo, err error) bool {
+	if err == nil {
+		return false
+	}
+	if info == nil || info.ResponseCode == NoResponse {
+		return true
+	}
+	return info.ResponseCode >= http.StatusInternalServerError || info.ResponseCode == http.StatusTooManyRequests
+}
+
+// baselineDrift is the fraction the baseline latency moves towards slower
+// latencies with every request, so that the baseline adapts to destinations
+// getting slower permanently.
+const baselineDrift = 0.01
+
+// concurrencyLimiter is the AIMD concurrency limiter of a destination.
+type concurrencyLimiter struct {
+	destination string
+	config      AdaptiveConcurrencyConfig
+
+	// lastUsed is when the limiter was last handed out, guarded by the
+	// limitersMu of adaptiveConcurrency
+	lastUsed time.Time
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// baseline is the latency of the destination when it's not overloaded
+	baseline time.Duration
+	// released is closed when a request is released, to wake up waiting requests
+	released chan struct{}
+}
+
+func newConcurrencyLimiter(destination string, config AdaptiveConcurrencyConfig) *concurrencyLimiter {
+	l := &concurrencyLimiter{
+		destination: destination,
+		config:      config,
+		limit:       float64(config.InitialLimit),
+		released:    make(chan struct{}),
+	}
+	reportConcurrencyLimit(destination, config.InitialLimit)
+	return l
+}
+
+func (l *concurrencyLimiter) acquire(ctx context.Context) error {
+	var timeout <-chan time.Time
+	if l.config.MaxWait > 0 {
+		timer := time.NewTimer(l.config.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		if timeout == nil {
+			reportShed(l.destination)
+			return ErrConcurrencyLimitExceeded
+		}
+		select {
+		case <-released:
+		case <-timeout:
+			reportShed(l.destination)
+			return ErrConcurrencyLimitExceeded
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// acquireUnlimited counts the request as in flight, even if the limit is
+// reached.
+func (l *concurrencyLimiter) acquireUnlimited() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight++
+}
+
+func (l *concurrencyLimiter) release(latency time.Duration, degraded bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if degraded || (l.baseline > 0 && float64(latency) > float64(l.baseline)*l.config.Tolerance) {
+		l.limit = math.Max(float64(l.config.MinLimit), l.limit*l.config.BackoffRatio)
+	} else {
+		l.limit = math.Min(float64(l.config.MaxLimit), l.limit+1/l.limit)
+	}
+	if !degraded {
+		if l.baseline == 0 || latency < l.baseline {
+			l.baseline = latency
+		} else {
+			l.baseline += time.Duration(float64(latency-l.baseline) * baselineDrift)
+		}
+	}
+	reportConcurrencyLimit(l.destination, int(l.limit))
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
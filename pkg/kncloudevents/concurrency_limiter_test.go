@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func resetConcurrencyMetrics(t *testing.T) {
+	// OpenCensus metrics carry global state that need to be reset between unit tests.
+	metricstest.Unregister("dispatcher_concurrency_limit", "dispatcher_shed_count")
+	register()
+	t.Cleanup(func() {
+		metricstest.Unregister("dispatcher_concurrency_limit", "dispatcher_shed_count")
+		register()
+	})
+}
+
+func TestConcurrencyLimiterLimit(t *testing.T) {
+	resetConcurrencyMetrics(t)
+
+	config := AdaptiveConcurrencyConfig{InitialLimit: 10, MinLimit: 2, MaxLimit: 11}.withDefaults()
+	l := newConcurrencyLimiter("http://destination.example.com", config)
+	wantTags := map[string]string{"destination": "http://destination.example.com"}
+
+	release := func(latency time.Duration, degraded bool) {
+		require.Nil(t, l.acquire(context.Background()))
+		l.release(latency, degraded)
+	}
+
+	// the limit grows additively while the latency is around the baseline
+	for i := 0; i < 10; i++ {
+		release(10*time.Millisecond, false)
+	}
+	require.InDelta(t, 10.95, l.limit, 0.05)
+	for i := 0; i < 10; i++ {
+		release(15*time.Millisecond, false)
+	}
+	require.Equal(t, 11.0, l.limit)
+	metricstest.CheckLastValueData(t, "dispatcher_concurrency_limit", wantTags, 11)
+
+	// and shrinks multiplicatively when the destination is slow or fails
+	release(50*time.Millisecond, false)
+	require.InDelta(t, 9.9, l.limit, 0.001)
+	release(10*time.Millisecond, true)
+	require.InDelta(t, 8.91, l.limit, 0.001)
+	metricstest.CheckLastValueData(t, "dispatcher_concurrency_limit", wantTags, 8)
+
+	for i := 0; i < 50; i++ {
+		release(time.Millisecond, true)
+	}
+	require.Equal(t, 2.0, l.limit)
+	require.Equal(t, 0, l.inFlight)
+}
+
+func TestConcurrencyLimiterShed(t *testing.T) {
+	resetConcurrencyMetrics(t)
+
+	ctx := context.Background()
+	l := newConcurrencyLimiter("http://destination.example.com", AdaptiveConcurrencyConfig{InitialLimit: 1}.withDefaults())
+
+	require.Nil(t, l.acquire(ctx))
+	require.ErrorIs(t, l.acquire(ctx), ErrConcurrencyLimitExceeded)
+	metricstest.CheckCountData(t, "dispatcher_shed_count", map[string]string{"destination": "http://destination.example.com"}, 1)
+
+	// with MaxWait, requests wait for a slot, a degraded release keeps the limit at 1
+	l.config.MaxWait = time.Minute
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		l.release(time.Millisecond, true)
+	}()
+	require.Nil(t, l.acquire(ctx))
+
+	l.config.MaxWait = 10 * time.Millisecond
+	require.ErrorIs(t, l.acquire(ctx), ErrConcurrencyLimitExceeded)
+
+	l.config.MaxWait = time.Minute
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, l.acquire(cancelled), context.Canceled)
+}
+
+func TestDispatchWithAdaptiveConcurrency(t *testing.T) {
+	resetConcurrencyMetrics(t)
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	received := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	destination := duckv1.Addressable{URL: apis.HTTP(server.Listener.Addr().String())}
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithAdaptiveConcurrency(AdaptiveConcurrencyConfig{InitialLimit: 1}))
+
+	errs := make(chan error)
+	go func() {
+		_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+		errs <- err
+	}()
+	<-received
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.True(t, errors.Is(err, ErrConcurrencyLimitExceeded), err)
+	require.Equal(t, NoResponse, info.ResponseCode)
+
+	close(unblock)
+	require.Nil(t, <-errs)
+}
+
+func TestAdaptiveConcurrencyEvictsIdleLimiters(t *testing.T) {
+	resetConcurrencyMetrics(t)
+
+	now := time.Now()
+	a := &adaptiveConcurrency{
+		config:   AdaptiveConcurrencyConfig{IdleTimeout: time.Minute}.withDefaults(),
+		limiters: make(map[string]*concurrencyLimiter),
+		now:      func() time.Time { return now },
+	}
+
+	idle := a.limiterFor("http://idle.example.com")
+	busy := a.limiterFor("http://busy.example.com")
+	require.Nil(t, busy.acquire(context.Background()))
+
+	now = now.Add(2 * time.Minute)
+	a.limiterFor("http://other.example.com")
+	require.NotContains(t, a.limiters, "http://idle.example.com")
+	require.Same(t, busy, a.limiters["http://busy.example.com"])
+	require.NotSame(t, idle, a.limiterFor("http://idle.example.com"))
+}
+
+func TestDispatchWithAdaptiveConcurrencyExcludesBackoff(t *testing.T) {
+	resetConcurrencyMetrics(t)
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	destination := duckv1.Addressable{URL: apis.HTTP(server.Listener.Addr().String())}
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithAdaptiveConcurrency(AdaptiveConcurrencyConfig{}))
+
+	backoff := 200 * time.Millisecond
+	retryConfig := &RetryConfig{
+		RetryMax:   1,
+		CheckRetry: SelectiveRetry,
+		Backoff: func(int, *http.Response) time.Duration {
+			return backoff
+		},
+	}
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithRetryConfig(retryConfig))
+	require.Nil(t, err)
+	require.Equal(t, 2, info.Attempts)
+
+	// the baseline latency of the destination doesn't include the backoff
+	limiter := dispatcher.concurrency.limiterFor(destination.URL.String())
+	require.Less(t, limiter.baseline, backoff/2)
+}
+
+func TestDispatchWithAdaptiveConcurrencyNeverShedsDeadLetters(t *testing.T) {
+	resetConcurrencyMetrics(t)
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	received := make(chan struct{})
+	unblock := make(chan struct{})
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(sink.Close)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(failing.Close)
+
+	sinkAddressable := duckv1.Addressable{URL: apis.HTTP(sink.Listener.Addr().String())}
+	destination := duckv1.Addressable{URL: apis.HTTP(failing.Listener.Addr().String())}
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithAdaptiveConcurrency(AdaptiveConcurrencyConfig{InitialLimit: 1}))
+
+	// the only slot of the sink is taken
+	errs := make(chan error, 2)
+	go func() {
+		_, err := dispatcher.SendEvent(ctx, test.FullEvent(), sinkAddressable)
+		errs <- err
+	}()
+	<-received
+
+	// but the dead lettered event is sent to it anyway
+	go func() {
+		_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithDeadLetterSink(&sinkAddressable))
+		errs <- err
+	}()
+	<-received
+
+	close(unblock)
+	require.Nil(t, <-errs)
+	require.Nil(t, <-errs)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/network"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+const (
+	// ProxyTokenKey is the key of the bearer token in the secrets of
+	// ProxyConfigFromSecret.
+	ProxyTokenKey = "token"
+
+	proxyAuthorizationHeader = "Proxy-Authorization"
+)
+
+// ProxyConfig is the proxy the requests to a destination are sent through.
+// Requests to https destinations are tunneled with CONNECT requests, and the
+// TLS handshake with the destination happens within the tunnel. Requests to
+// http destinations are sent to the proxy in absolute form.
+type ProxyConfig struct {
+	// URL is the URL of the proxy, http or https.
+	URL *url.URL
+	// Header is sent with the CONNECT requests, and with the requests to http
+	// destinations, e.g. a Proxy-Authorization header.
+	Header nethttp.Header
+	// CACerts are the PEM encoded CA certs trusted for https proxies, in
+	// addition to the system and Knative trust bundles. TLS-intercepting
+	// gateways usually need their CA to be trusted for the destinations too,
+	// see the CACerts of the destination.
+	CACerts *string
+}
+
+// ProxyResolver returns the proxy to send the requests to the destination
+// through, nil to send them directly.
+type ProxyResolver func(destination duckv1.Addressable) (*ProxyConfig, error)
+
+// ConfigureProxyResolver configures the proxies of the clients, nil sends all
+// requests directly, or through the proxy of the environment, see
+// http.ProxyFromEnvironment. Use sparingly, because it recreates all clients.
+func ConfigureProxyResolver(resolver ProxyResolver) {
+	clients.clientsMu.Lock()
+	defer unlockClients()
+
+	resetClients()
+
+	clients.proxyResolver = resolver
+}
+
+// ProxyConfigFromSecret returns the config of the proxy with the given URL,
+// with the credentials and the CA certs of the given secret, if not nil. The
+// credentials are sent in the Proxy-Authorization header, either as basic
+// credentials from the username and password keys, as in secrets of type
+// kubernetes.io/basic-auth, or as a bearer token from the token key. The CA
+// certs are read from the ca.crt key.
+func ProxyConfigFromSecret(proxyURL string, secret *corev1.Secret) (*ProxyConfig, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http or https", u.Scheme)
+	}
+
+	config := &ProxyConfig{
+		URL:    u,
+		Header: make(nethttp.Header),
+	}
+	if secret == nil {
+		return config, nil
+	}
+
+	username, hasUsername := secret.Data[corev1.BasicAuthUsernameKey]
+	token, hasToken := secret.Data[ProxyTokenKey]
+	switch {
+	case hasUsername && hasToken:
+		return nil, fmt.Errorf("secret %s/%s has both the %s and %s keys", secret.Namespace, secret.Name, corev1.BasicAuthUsernameKey, ProxyTokenKey)
+	case hasUsername:
+		credentials := string(username) + ":" + string(secret.Data[corev1.BasicAuthPasswordKey])
+		config.Header.Set(proxyAuthorizationHeader, "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case hasToken:
+		config.Header.Set(proxyAuthorizationHeader, "Bearer "+strings.TrimSpace(string(token)))
+	}
+	if caCerts, ok := secret.Data[eventingtls.SecretCACert]; ok {
+		certs := string(caCerts)
+		config.CACerts = &certs
+	}
+	return config, nil
+}
+
+// proxyFor returns the proxy of the addressable, nil without proxy resolver or
+// if the addressable is sent to directly. The caller must hold
+// clients.clientsMu.
+func proxyFor(addressable duckv1.Addressable) (*ProxyConfig, error) {
+	if clients.proxyResolver == nil {
+		return nil, nil
+	}
+	proxy, err := clients.proxyResolver(addressable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve proxy: %w", err)
+	}
+	if proxy != nil && proxy.URL == nil {
+		return nil, fmt.Errorf("proxy of %s has no URL", addressable.URL)
+	}
+	return proxy, nil
+}
+
+// configureTransport sends the requests of the transport through the proxy.
+func (p *ProxyConfig) configureTransport(transport *nethttp.Transport, connectionArgs *ConnectionArgs) error {
+	transport.Proxy = nethttp.ProxyURL(p.URL)
+	transport.ProxyConnectHeader = p.Header.Clone()
+
+	if p.URL.Scheme == "https" {
+		tlsConfig, err := eventingtls.GetTLSClientConfig(eventingtls.ClientConfig{CACerts: p.CACerts})
+		if err != nil {
+			return fmt.Errorf("failed to get TLS config of proxy: %w", err)
+		}
+		// with a proxy, the transport dials TLS connections to the proxy only
+		transport.DialTLSContext = func(ctx context.Context, net, addr string) (net.Conn, error) {
+			return network.DialTLSWithBackOff(ctx, connectionArgs.network(net), addr, tlsConfig)
+		}
+	}
+	return nil
+}
+
+// proxyHeaderTransport adds the header of the proxy to the requests to http
+// destinations, which aren't tunneled, so that they aren't sent with the
+// CONNECT requests.
+type proxyHeaderTransport struct {
+	base   *nethttp.Transport
+	header nethttp.Header
+}
+
+func (t *proxyHeaderTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	if req.URL.Scheme != "http" || len(t.header) == 0 {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for key, values := range t.header {
+		req.Header[key] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *proxyHeaderTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"encoding/pem"
+	"io"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/plugin/ochttp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+// connectProxy is a proxy tunneling CONNECT requests and answering the
+// requests to http destinations itself.
+type connectProxy struct {
+	mu       sync.Mutex
+	requests []*nethttp.Request
+}
+
+func (p *connectProxy) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, r)
+	p.mu.Unlock()
+
+	if r.Header.Get(proxyAuthorizationHeader) != "Bearer secret" {
+		w.WriteHeader(nethttp.StatusProxyAuthRequired)
+		return
+	}
+	if r.Method != nethttp.MethodConnect {
+		w.WriteHeader(nethttp.StatusNoContent)
+		return
+	}
+
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(nethttp.StatusBadGateway)
+		return
+	}
+	w.WriteHeader(nethttp.StatusOK)
+	conn, _, err := w.(nethttp.Hijacker).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	go func() {
+		defer upstream.Close()
+		defer conn.Close()
+		_, _ = io.Copy(upstream, conn)
+	}()
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+	}()
+}
+
+func (p *connectProxy) Requests() []*nethttp.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*nethttp.Request(nil), p.requests...)
+}
+
+func TestConfigureProxyResolver(t *testing.T) {
+	CloseAll()
+	t.Cleanup(func() {
+		ConfigureProxyResolver(nil)
+	})
+
+	destination := httptest.NewTLSServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusAccepted)
+	}))
+	t.Cleanup(destination.Close)
+	caCerts := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: destination.Certificate().Raw}))
+
+	proxy := &connectProxy{}
+	proxyServer := httptest.NewServer(proxy)
+	t.Cleanup(proxyServer.Close)
+	proxyURL, err := url.Parse(proxyServer.URL)
+	require.NoError(t, err)
+
+	direct := duckv1.Addressable{URL: apis.HTTP("direct.example.com")}
+	ConfigureProxyResolver(func(addressable duckv1.Addressable) (*ProxyConfig, error) {
+		if addressable.URL.Host == direct.URL.Host {
+			return nil, nil
+		}
+		return &ProxyConfig{
+			URL:    proxyURL,
+			Header: nethttp.Header{proxyAuthorizationHeader: []string{"Bearer secret"}},
+		}, nil
+	})
+
+	destinationURL, err := apis.ParseURL(destination.URL)
+	require.NoError(t, err)
+	httpsDestination := duckv1.Addressable{URL: destinationURL, CACerts: &caCerts}
+	client, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), httpsDestination)
+	require.NoError(t, err)
+	resp, err := client.Post(destination.URL, "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, nethttp.StatusAccepted, resp.StatusCode)
+
+	requests := proxy.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, nethttp.MethodConnect, requests[0].Method)
+	require.Equal(t, destinationURL.Host, requests[0].Host)
+
+	// http destinations are sent to the proxy in absolute form
+	httpDestination := duckv1.Addressable{URL: apis.HTTP("sink.example.com")}
+	client, err = getClientForAddressable(eventingtls.NewDefaultClientConfig(), httpDestination)
+	require.NoError(t, err)
+	resp, err = client.Post(httpDestination.URL.String(), "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, nethttp.StatusNoContent, resp.StatusCode)
+
+	requests = proxy.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, nethttp.MethodPost, requests[1].Method)
+	require.Equal(t, "http://sink.example.com/", requests[1].RequestURI)
+
+	client, err = getClientForAddressable(eventingtls.NewDefaultClientConfig(), direct)
+	require.NoError(t, err)
+	_, proxied := client.Transport.(*ochttp.Transport).Base.(*proxyHeaderTransport)
+	require.False(t, proxied)
+}
+
+func TestProxyConfigFromSecret(t *testing.T) {
+	caCerts := "-----BEGIN CERTIFICATE-----"
+
+	tests := []struct {
+		name       string
+		proxyURL   string
+		secret     *corev1.Secret
+		wantHeader string
+		wantCA     *string
+		wantErr    bool
+	}{{
+		name:     "without secret",
+		proxyURL: "http://proxy.example.com:3128",
+	}, {
+		name:     "basic auth",
+		proxyURL: "https://proxy.example.com",
+		secret: &corev1.Secret{Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("user"),
+			corev1.BasicAuthPasswordKey: []byte("password"),
+			eventingtls.SecretCACert:    []byte(caCerts),
+		}},
+		wantHeader: "Basic dXNlcjpwYXNzd29yZA==",
+		wantCA:     &caCerts,
+	}, {
+		name:       "token",
+		proxyURL:   "http://proxy.example.com",
+		secret:     &corev1.Secret{Data: map[string][]byte{ProxyTokenKey: []byte("secret\n")}},
+		wantHeader: "Bearer secret",
+	}, {
+		name:     "ambiguous credentials",
+		proxyURL: "http://proxy.example.com",
+		secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "proxy"},
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("user"),
+				ProxyTokenKey:               []byte("secret"),
+			},
+		},
+		wantErr: true,
+	}, {
+		name:     "unsupported scheme",
+		proxyURL: "socks5://proxy.example.com",
+		wantErr:  true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ProxyConfigFromSecret(tc.proxyURL, tc.secret)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.proxyURL, config.URL.String())
+			require.Equal(t, tc.wantHeader, config.Header.Get(proxyAuthorizationHeader))
+			require.Equal(t, tc.wantCA, config.CACerts)
+		})
+	}
+}
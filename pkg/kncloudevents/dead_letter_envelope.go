@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/google/uuid"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+// DeadLetterEnvelopeEventType is the type of the events sent to the dead
+// letter sink with WithDeadLetterEnvelope.
+const DeadLetterEnvelopeEventType = "dev.knative.dls.v1"
+
+// DeadLetterEnvelope is the data of the events sent to the dead letter sink
+// with WithDeadLetterEnvelope.
+type DeadLetterEnvelope struct {
+	// Event is the event which couldn't be delivered, as selected by
+	// WithDeadLetterPayload.
+	Event *event.Event `json:"event"`
+	// Failure describes why the event couldn't be delivered.
+	Failure DeadLetterFailure `json:"failure"`
+}
+
+// DeadLetterFailure describes the failed delivery of the event of a
+// DeadLetterEnvelope.
+type DeadLetterFailure struct {
+	// Destination is the destination the event couldn't be delivered to, the
+	// destination or the reply.
+	Destination string `json:"destination"`
+	// ResponseCode is the status code of the last response of the
+	// destination, if it responded.
+	ResponseCode int `json:"responseCode,omitempty"`
+	// ResponseBody is the body of the last response of the destination.
+	ResponseBody []byte `json:"responseBody,omitempty"`
+	// Reason is the class of the failure, see attributes.KnativeErrorReason.
+	Reason attributes.KnativeErrorReason `json:"reason,omitempty"`
+	// Attempts is the number of requests sent to the destination.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// WithDeadLetterEnvelope sends the events to the dead letter sink wrapped in
+// an event of type DeadLetterEnvelopeEventType, with the failure in its data
+// instead of the knativeerror extensions, so that dead letter sinks get a
+// stable schema.
+func WithDeadLetterEnvelope() SendOption {
+	return func(sc *senderConfig) error {
+		sc.deadLetterEnvelope = true
+
+		return nil
+	}
+}
+
+// deadLetter returns the message to send to the dead letter sink and its
+// transformers, for the message which couldn't be delivered to the
+// destination.
+func (sc *senderConfig) deadLetter(ctx context.Context, message binding.Message, transformers binding.Transformers, destination *apis.URL, dispatchExecutionInfo *DispatchInfo) (binding.Message, binding.Transformers, error) {
+	if !sc.deadLetterEnvelope {
+		return message, withTransformer(transformers, dispatchExecutionInfoTransformers(destination, dispatchExecutionInfo)), nil
+	}
+
+	envelope, err := envelopeDeadLetter(ctx, message, transformers, destination, dispatchExecutionInfo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap event in dead letter envelope: %w", err)
+	}
+	return envelope, nil, nil
+}
+
+func envelopeDeadLetter(ctx context.Context, message binding.Message, transformers binding.Transformers, destination *apis.URL, dispatchExecutionInfo *DispatchInfo) (binding.Message, error) {
+	failed, err := binding.ToEvent(ctx, message, transformers...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert message to event: %w", err)
+	}
+
+	failure := DeadLetterFailure{
+		ResponseCode: dispatchExecutionInfo.ResponseCode,
+		Reason:       dispatchExecutionInfo.ErrorReason,
+		Attempts:     dispatchExecutionInfo.Attempts,
+	}
+	if failedDestination, body, ok := dispatchFailure(destination, dispatchExecutionInfo); ok {
+		failure.Destination = failedDestination.String()
+		failure.ResponseBody = body
+	}
+	if failure.ResponseCode == NoResponse {
+		failure.ResponseCode = 0
+	}
+
+	envelope := event.New()
+	envelope.SetID(uuid.New().String())
+	envelope.SetSource(failed.Source())
+	envelope.SetType(DeadLetterEnvelopeEventType)
+	envelope.SetTime(time.Now())
+	if err := envelope.SetData(event.ApplicationJSON, DeadLetterEnvelope{Event: failed, Failure: failure}); err != nil {
+		return nil, fmt.Errorf("failed to set data: %w", err)
+	}
+	return binding.ToMessage(&envelope), nil
+}
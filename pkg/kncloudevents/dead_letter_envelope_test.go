@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestDispatchWithDeadLetterEnvelope(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+
+	event := test.FullEvent()
+	replyEvent := test.MinEvent()
+	replyEvent.SetID("reply-id")
+
+	tests := []struct {
+		name            string
+		destination     kncloudeventstest.Response
+		options         []kncloudevents.SendOption
+		wantEventID     string
+		wantDestination string
+		wantCode        int
+		wantBody        string
+	}{{
+		name:            "destination fails",
+		destination:     kncloudeventstest.Response{StatusCode: http.StatusServiceUnavailable, Body: []byte("overloaded")},
+		wantEventID:     event.ID(),
+		wantDestination: destination.URL.String(),
+		wantCode:        http.StatusServiceUnavailable,
+		wantBody:        "overloaded",
+	}, {
+		name:            "reply fails",
+		destination:     kncloudeventstest.Reply(replyEvent),
+		wantEventID:     replyEvent.ID(),
+		wantDestination: reply.URL.String(),
+		wantCode:        http.StatusInternalServerError,
+	}, {
+		name:            "reply fails with original payload",
+		destination:     kncloudeventstest.Reply(replyEvent),
+		options:         []kncloudevents.SendOption{kncloudevents.WithDeadLetterPayload(kncloudevents.DeadLetterPayloadOriginal)},
+		wantEventID:     event.ID(),
+		wantDestination: reply.URL.String(),
+		wantCode:        http.StatusInternalServerError,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().
+				On(destination.URL.String(), tc.destination).
+				On(reply.URL.String(), kncloudeventstest.Status(http.StatusInternalServerError)).
+				On(dls.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			options := append([]kncloudevents.SendOption{
+				kncloudevents.WithReply(&reply),
+				kncloudevents.WithDeadLetterSink(&dls),
+				kncloudevents.WithDeadLetterEnvelope(),
+			}, tc.options...)
+			_, err := dispatcher.SendEvent(ctx, event, destination, options...)
+			require.NoError(t, err)
+
+			deadLettered := fakeClient.RequestsTo(dls.URL.String())
+			require.Len(t, deadLettered, 1)
+			require.Equal(t, kncloudevents.DeadLetterEnvelopeEventType, deadLettered[0].Header.Get("Ce-Type"))
+			require.Empty(t, deadLettered[0].Header.Get("Ce-Knativeerrorcode"), "the failure is in the data of the envelope")
+
+			var envelope kncloudevents.DeadLetterEnvelope
+			require.NoError(t, json.Unmarshal(deadLettered[0].Body, &envelope))
+			require.Equal(t, tc.wantEventID, envelope.Event.ID())
+			require.Equal(t, tc.wantDestination, envelope.Failure.Destination)
+			require.Equal(t, tc.wantCode, envelope.Failure.ResponseCode)
+			require.Equal(t, tc.wantBody, string(envelope.Failure.ResponseBody))
+			require.Equal(t, 1, envelope.Failure.Attempts)
+		})
+	}
+}
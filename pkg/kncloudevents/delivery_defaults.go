@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/system"
+
+	v1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/apis/feature"
+)
+
+const (
+	// DeliveryDefaultsConfigName is the name of the config map with the
+	// cluster-wide delivery defaults, see UpdateDeliveryDefaults.
+	DeliveryDefaultsConfigName = "config-delivery"
+
+	deliveryRetryKey               = "retry"
+	deliveryBackoffPolicyKey       = "backoff-policy"
+	deliveryBackoffDelayKey        = "backoff-delay"
+	deliveryTimeoutKey             = "timeout"
+	deliveryRetryAfterMaxKey       = "retry-after-max"
+	deliveryMaxResponseBodySizeKey = "max-response-body-size"
+)
+
+// ErrResponseBodyTooLarge is returned when the body of a response exceeds
+// the max-response-body-size of the delivery defaults.
+var ErrResponseBodyTooLarge = errors.New("response body too large")
+
+// DeliveryDefaults are the cluster-wide delivery defaults of the
+// config-delivery config map.
+type DeliveryDefaults struct {
+	// Delivery are the retries, backoff, timeout and Retry-After behavior of
+	// the requests sent without RetryConfig, see WithRetryConfig.
+	Delivery v1.DeliverySpec
+	// MaxResponseBodySize is the maximum size in bytes of the bodies of
+	// responses, zero for no maximum. The requests with larger responses fail
+	// with ErrResponseBodyTooLarge.
+	MaxResponseBodySize int64
+
+	retryConfig *RetryConfig
+}
+
+var deliveryDefaults atomic.Pointer[DeliveryDefaults]
+
+// NewDeliveryDefaultsFromConfigMap parses the delivery defaults of the config
+// map. The keys are retry, backoff-policy, backoff-delay, timeout and
+// retry-after-max, with the values of the fields of a DeliverySpec, and
+// max-response-body-size.
+func NewDeliveryDefaultsFromConfigMap(cm *corev1.ConfigMap) (*DeliveryDefaults, error) {
+	defaults := &DeliveryDefaults{}
+	for key, value := range cm.Data {
+		value := strings.TrimSpace(value)
+		switch key {
+		case deliveryRetryKey:
+			retry, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", key, err)
+			}
+			retry32 := int32(retry)
+			defaults.Delivery.Retry = &retry32
+		case deliveryBackoffPolicyKey:
+			policy := v1.BackoffPolicyType(value)
+			defaults.Delivery.BackoffPolicy = &policy
+		case deliveryBackoffDelayKey:
+			defaults.Delivery.BackoffDelay = &value
+		case deliveryTimeoutKey:
+			defaults.Delivery.Timeout = &value
+		case deliveryRetryAfterMaxKey:
+			defaults.Delivery.RetryAfterMax = &value
+		case deliveryMaxResponseBodySizeKey:
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative number of bytes, got %q", key, value)
+			}
+			defaults.MaxResponseBodySize = size
+		}
+	}
+	if defaults.Delivery.BackoffDelay != nil && defaults.Delivery.BackoffPolicy == nil {
+		policy := v1.BackoffPolicyExponential
+		defaults.Delivery.BackoffPolicy = &policy
+	}
+
+	// the defaults aren't subject to the feature flags of the DeliverySpecs
+	ctx := feature.ToContext(context.Background(), feature.Flags{
+		feature.DeliveryTimeout:    feature.Enabled,
+		feature.DeliveryRetryAfter: feature.Enabled,
+	})
+	if err := defaults.Delivery.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("invalid delivery defaults: %w", err)
+	}
+
+	// without delivery defaults, requests are sent as before
+	if equality.Semantic.DeepEqual(defaults.Delivery, v1.DeliverySpec{}) {
+		return defaults, nil
+	}
+	retryConfig, err := RetryConfigFromDeliverySpec(defaults.Delivery)
+	if err != nil {
+		return nil, err
+	}
+	defaults.retryConfig = &retryConfig
+	return defaults, nil
+}
+
+// UpdateDeliveryDefaults replaces the delivery defaults with the ones of the
+// config map. The previous defaults are kept if the config map is invalid.
+func UpdateDeliveryDefaults(cm *corev1.ConfigMap) error {
+	defaults, err := NewDeliveryDefaultsFromConfigMap(cm)
+	if err != nil {
+		return err
+	}
+	deliveryDefaults.Store(defaults)
+	return nil
+}
+
+// WatchDeliveryDefaults updates the delivery defaults whenever the
+// config-delivery config map changes. Without the config map, requests
+// without RetryConfig aren't retried.
+func WatchDeliveryDefaults(logger *zap.SugaredLogger, configMapWatcher configmap.Watcher) {
+	update := func(cm *corev1.ConfigMap) {
+		if err := UpdateDeliveryDefaults(cm); err != nil {
+			logger.Errorw("Failed to update delivery defaults", zap.Error(err))
+		}
+	}
+	if dw, ok := configMapWatcher.(configmap.DefaultingWatcher); ok {
+		dw.WatchWithDefault(corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: DeliveryDefaultsConfigName, Namespace: system.Namespace()},
+		}, update)
+		return
+	}
+	configMapWatcher.Watch(DeliveryDefaultsConfigName, update)
+}
+
+// loadDeliveryDefaults returns the delivery defaults, nil without
+// config-delivery config map.
+func loadDeliveryDefaults() *DeliveryDefaults {
+	return deliveryDefaults.Load()
+}
+
+// defaultRetryConfig returns the retry config of the delivery defaults, nil
+// without defaults.
+func defaultRetryConfig() *RetryConfig {
+	if defaults := loadDeliveryDefaults(); defaults != nil {
+		return defaults.retryConfig
+	}
+	return nil
+}
+
+// maxResponseBodySize returns the max-response-body-size of the delivery
+// defaults, zero for no maximum.
+func maxResponseBodySize() int64 {
+	if defaults := loadDeliveryDefaults(); defaults != nil {
+		return defaults.MaxResponseBodySize
+	}
+	return 0
+}
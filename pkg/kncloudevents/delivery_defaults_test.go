@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func resetDeliveryDefaults(t *testing.T) {
+	t.Cleanup(func() {
+		deliveryDefaults.Store(nil)
+	})
+}
+
+func TestUpdateDeliveryDefaults(t *testing.T) {
+	resetDeliveryDefaults(t)
+
+	require.Nil(t, defaultRetryConfig())
+	require.Zero(t, maxResponseBodySize())
+
+	err := UpdateDeliveryDefaults(&corev1.ConfigMap{Data: map[string]string{
+		"retry":                  "3",
+		"backoff-delay":          "PT0.2S",
+		"timeout":                "PT5S",
+		"retry-after-max":        "PT30S",
+		"max-response-body-size": "1024",
+	}})
+	require.Nil(t, err)
+
+	retryConfig := defaultRetryConfig()
+	require.NotNil(t, retryConfig)
+	require.Equal(t, 3, retryConfig.RetryMax)
+	require.Equal(t, 5*time.Second, retryConfig.RequestTimeout)
+	require.NotNil(t, retryConfig.RetryAfterMaxDuration)
+	require.Equal(t, 30*time.Second, *retryConfig.RetryAfterMaxDuration)
+	// the backoff policy defaults to exponential
+	require.Equal(t, 800*time.Millisecond, retryConfig.Backoff(2, nil))
+	require.Equal(t, int64(1024), maxResponseBodySize())
+
+	// invalid config maps keep the previous defaults
+	for key, value := range map[string]string{
+		"retry":                  "three",
+		"backoff-policy":         "random",
+		"backoff-delay":          "200ms",
+		"timeout":                "5s",
+		"max-response-body-size": "-1",
+	} {
+		err = UpdateDeliveryDefaults(&corev1.ConfigMap{Data: map[string]string{key: value}})
+		require.Error(t, err, key)
+		require.Equal(t, retryConfig, defaultRetryConfig())
+	}
+
+	err = UpdateDeliveryDefaults(&corev1.ConfigMap{})
+	require.Nil(t, err)
+	require.Nil(t, defaultRetryConfig())
+	require.Zero(t, maxResponseBodySize())
+}
+
+func TestDispatchWithDeliveryDefaults(t *testing.T) {
+	resetDeliveryDefaults(t)
+
+	ctx, _ := rectesting.SetupFakeContext(t)
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+
+	err := UpdateDeliveryDefaults(&corev1.ConfigMap{Data: map[string]string{
+		"retry":          "2",
+		"backoff-policy": "linear",
+		"backoff-delay":  "PT0.001S",
+	}})
+	require.Nil(t, err)
+
+	// requests without retry config are retried with the defaults
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusServiceUnavailable)
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Error(t, err)
+	require.Len(t, fakeClient.Requests(), 3)
+
+	// an explicit retry config wins
+	fakeClient = kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusServiceUnavailable)
+	dispatcher = NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithRetryConfig(&RetryConfig{RetryMax: 0, CheckRetry: SelectiveRetry}))
+	require.Error(t, err)
+	require.Len(t, fakeClient.Requests(), 1)
+}
+
+func TestDispatchWithMaxResponseBodySize(t *testing.T) {
+	resetDeliveryDefaults(t)
+
+	ctx, _ := rectesting.SetupFakeContext(t)
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+
+	err := UpdateDeliveryDefaults(&corev1.ConfigMap{Data: map[string]string{"max-response-body-size": "16"}})
+	require.Nil(t, err)
+
+	fakeClient := kncloudeventstest.NewFakeClient().Return(kncloudeventstest.Reply(test.FullEvent()))
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.True(t, errors.Is(err, ErrResponseBodyTooLarge), err)
+	require.Equal(t, http.StatusInternalServerError, info.ResponseCode)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/resolver"
+	"knative.dev/pkg/tracker"
+)
+
+// ErrNoDestinationResolver is returned when sending to a destination with a
+// Dispatcher without DestinationResolver.
+var ErrNoDestinationResolver = errors.New("dispatcher has no destination resolver")
+
+// DestinationResolver resolves destinations to the addressables to send to.
+// *resolver.URIResolver implements it.
+type DestinationResolver interface {
+	AddressableFromDestinationV1(ctx context.Context, dest duckv1.Destination, parent interface{}) (*duckv1.Addressable, error)
+}
+
+// NewDestinationResolver returns a DestinationResolver resolving the refs of
+// destinations with informers of the addressables, so that resolving doesn't
+// hit the API server for every send. K8s Services are resolved to their
+// cluster-local hostname, with https if the destination has CA certs. The
+// context must be set up for injection.
+func NewDestinationResolver(ctx context.Context) DestinationResolver {
+	// nothing needs to be reconciled when the addressables change, the
+	// informers only serve as cache
+	return resolver.NewURIResolverFromTracker(ctx, noopTracker{})
+}
+
+// WithDestinationResolver makes the Dispatcher resolve destinations with the
+// given resolver, to support SendEventToDestination and SendMessageToDestination.
+func WithDestinationResolver(r DestinationResolver) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.destinationResolver = r
+	}
+}
+
+// SendEventToDestination resolves the destination, e.g. a ref to a K8s Service
+// or a Broker with an optional URI, and sends the event to it like SendEvent.
+// The CA certs and audience of the resolved address are used, unless set on
+// the destination.
+func (d *Dispatcher) SendEventToDestination(ctx context.Context, event event.Event, destination duckv1.Destination, options ...SendOption) (*DispatchInfo, error) {
+	addressable, err := d.resolveDestination(ctx, destination)
+	if err != nil {
+		return nil, err
+	}
+	return d.SendEvent(ctx, event, *addressable, options...)
+}
+
+// SendMessageToDestination is like SendEventToDestination, but sends a message
+// like SendMessage.
+func (d *Dispatcher) SendMessageToDestination(ctx context.Context, message binding.Message, destination duckv1.Destination, options ...SendOption) (*DispatchInfo, error) {
+	addressable, err := d.resolveDestination(ctx, destination)
+	if err != nil {
+		_ = message.Finish(err)
+		return nil, err
+	}
+	return d.SendMessage(ctx, message, *addressable, options...)
+}
+
+func (d *Dispatcher) resolveDestination(ctx context.Context, destination duckv1.Destination) (*duckv1.Addressable, error) {
+	if d.destinationResolver == nil {
+		return nil, ErrNoDestinationResolver
+	}
+	addressable, err := d.destinationResolver.AddressableFromDestinationV1(ctx, destination, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve destination: %w", err)
+	}
+	return addressable, nil
+}
+
+// noopTracker is a tracker.Interface which doesn't track anything.
+type noopTracker struct{}
+
+var _ tracker.Interface = noopTracker{}
+
+func (noopTracker) Track(corev1.ObjectReference, interface{}) error     { return nil }
+func (noopTracker) TrackReference(tracker.Reference, interface{}) error { return nil }
+func (noopTracker) OnChanged(interface{})                               {}
+func (noopTracker) GetObservers(interface{}) []types.NamespacedName     { return nil }
+func (noopTracker) OnDeletedObserver(interface{})                       {}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+type destinationResolverFunc func(ctx context.Context, dest duckv1.Destination, parent interface{}) (*duckv1.Addressable, error)
+
+func (f destinationResolverFunc) AddressableFromDestinationV1(ctx context.Context, dest duckv1.Destination, parent interface{}) (*duckv1.Addressable, error) {
+	return f(ctx, dest, parent)
+}
+
+func TestSendEventToDestination(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	serviceRef := &duckv1.KReference{Kind: "Service", APIVersion: "v1", Namespace: "ns", Name: "svc"}
+	serviceAddressable := duckv1.Addressable{URL: apis.HTTP("svc.ns.svc.cluster.local")}
+	resolver := destinationResolverFunc(func(_ context.Context, dest duckv1.Destination, _ interface{}) (*duckv1.Addressable, error) {
+		if dest.Ref == nil {
+			return &duckv1.Addressable{URL: dest.URI}, nil
+		}
+		if *dest.Ref != *serviceRef {
+			return nil, errors.New("not found")
+		}
+		addressable := serviceAddressable
+		if dest.URI != nil {
+			addressable.URL = addressable.URL.ResolveReference(dest.URI)
+		}
+		return &addressable, nil
+	})
+
+	tests := []struct {
+		name        string
+		destination duckv1.Destination
+		resolver    kncloudevents.DestinationResolver
+		wantURL     string
+		wantErr     bool
+	}{{
+		name:        "ref",
+		destination: duckv1.Destination{Ref: serviceRef},
+		resolver:    resolver,
+		wantURL:     "http://svc.ns.svc.cluster.local",
+	}, {
+		name:        "ref with path",
+		destination: duckv1.Destination{Ref: serviceRef, URI: &apis.URL{Path: "/path"}},
+		resolver:    resolver,
+		wantURL:     "http://svc.ns.svc.cluster.local/path",
+	}, {
+		name:        "uri",
+		destination: duckv1.Destination{URI: apis.HTTP("destination.example.com")},
+		resolver:    resolver,
+		wantURL:     "http://destination.example.com",
+	}, {
+		name:        "unresolvable ref",
+		destination: duckv1.Destination{Ref: &duckv1.KReference{Kind: "Service", APIVersion: "v1", Namespace: "ns", Name: "other"}},
+		resolver:    resolver,
+		wantErr:     true,
+	}, {
+		name:        "no resolver",
+		destination: duckv1.Destination{Ref: serviceRef},
+		wantErr:     true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+			options := []kncloudevents.DispatcherOption{kncloudevents.WithHTTPClient(fakeClient.Client())}
+			if tc.resolver != nil {
+				options = append(options, kncloudevents.WithDestinationResolver(tc.resolver))
+			}
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), options...)
+
+			info, err := dispatcher.SendEventToDestination(ctx, test.FullEvent(), tc.destination)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Empty(t, fakeClient.Requests())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, http.StatusAccepted, info.ResponseCode)
+			require.Len(t, fakeClient.RequestsTo(tc.wantURL), 1)
+		})
+	}
+}
+
+func TestSendMessageToDestinationWithoutResolver(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	e := test.FullEvent()
+	finished := false
+	message := binding.WithFinish(binding.ToMessage(&e), func(error) { finished = true })
+	_, err := dispatcher.SendMessageToDestination(ctx, message, duckv1.Destination{URI: apis.HTTP("destination.example.com")})
+	require.ErrorIs(t, err, kncloudevents.ErrNoDestinationResolver)
+	require.True(t, finished)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/go-jose/go-jose/v3"
+	"go.uber.org/zap"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// EncryptionKeyIDExtension is the extension with the id of the key the
+	// data of an encrypted event was encrypted with.
+	EncryptionKeyIDExtension = "encryptionkeyid"
+	// EncryptedContentType is the data content type of encrypted events. Their
+	// data is a JWE in compact serialization, with the original data content
+	// type as content type (cty) header.
+	EncryptedContentType = "application/jose"
+
+	// ActiveEncryptionKeyAnnotation is the annotation of the Secret of
+	// NewSecretEncryptionKeyProvider naming the key to encrypt with.
+	ActiveEncryptionKeyAnnotation = "eventing.knative.dev/active-encryption-key"
+)
+
+// EncryptionKey is a key to encrypt the data of events with.
+type EncryptionKey struct {
+	// ID is sent in the EncryptionKeyIDExtension, for the receiver to look
+	// up the decryption key.
+	ID string
+	// Algorithm is the JWE key management algorithm, e.g. jose.A256KW for a
+	// 256 bit AES key or jose.RSA_OAEP_256 for an RSA public key.
+	Algorithm jose.KeyAlgorithm
+	// Key is the key for the algorithm, as accepted by jose.Recipient.
+	Key interface{}
+}
+
+// EncryptionKeyProvider provides the keys to encrypt and decrypt the data of
+// events with, e.g. from a Secret or a KMS.
+type EncryptionKeyProvider interface {
+	// EncryptionKey returns the key to encrypt with.
+	EncryptionKey(ctx context.Context) (*EncryptionKey, error)
+	// DecryptionKey returns the key to decrypt data encrypted with the key
+	// with the given id, as accepted by jose.JSONWebEncryption.Decrypt.
+	DecryptionKey(ctx context.Context, keyID string) (interface{}, error)
+}
+
+// NewSecretEncryptionKeyProvider returns an EncryptionKeyProvider with the AES
+// keys of the Secret with the given name, keyed by their id. The keys must be
+// 16, 24 or 32 bytes long, and are used with AES key wrap. New events are
+// encrypted with the key named by the ActiveEncryptionKeyAnnotation, the other
+// keys can still be decrypted, which allows rotating keys.
+func NewSecretEncryptionKeyProvider(secrets corev1listers.SecretNamespaceLister, name string) EncryptionKeyProvider {
+	return &secretEncryptionKeyProvider{
+		secrets: secrets,
+		name:    name,
+	}
+}
+
+type secretEncryptionKeyProvider struct {
+	secrets corev1listers.SecretNamespaceLister
+	name    string
+}
+
+func (p *secretEncryptionKeyProvider) EncryptionKey(context.Context) (*EncryptionKey, error) {
+	secret, err := p.secrets.Get(p.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key secret: %w", err)
+	}
+	keyID := secret.Annotations[ActiveEncryptionKeyAnnotation]
+	if keyID == "" {
+		return nil, fmt.Errorf("encryption key secret %s has no %s annotation", p.name, ActiveEncryptionKeyAnnotation)
+	}
+	key, ok := secret.Data[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption key secret %s has no key %s", p.name, keyID)
+	}
+	algorithm, err := aesKeyWrapAlgorithm(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %s: %w", keyID, err)
+	}
+	return &EncryptionKey{
+		ID:        keyID,
+		Algorithm: algorithm,
+		Key:       key,
+	}, nil
+}
+
+func (p *secretEncryptionKeyProvider) DecryptionKey(_ context.Context, keyID string) (interface{}, error) {
+	secret, err := p.secrets.Get(p.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key secret: %w", err)
+	}
+	key, ok := secret.Data[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption key secret %s has no key %s", p.name, keyID)
+	}
+	return key, nil
+}
+
+func aesKeyWrapAlgorithm(key []byte) (jose.KeyAlgorithm, error) {
+	switch len(key) {
+	case 16:
+		return jose.A128KW, nil
+	case 24:
+		return jose.A192KW, nil
+	case 32:
+		return jose.A256KW, nil
+	default:
+		return "", fmt.Errorf("AES keys must be 16, 24 or 32 bytes long, got %d bytes", len(key))
+	}
+}
+
+// WithPayloadEncryption encrypts the data of the event as a JWE with the key of
+// the provider. The attributes and extensions of the event stay readable, e.g.
+// for filtering. The message to the dead letter sink is encrypted as well, the
+// replies are sent as they are received.
+func WithPayloadEncryption(provider EncryptionKeyProvider) SendOption {
+	return func(sc *senderConfig) error {
+		if provider == nil {
+			return fmt.Errorf("encryption key provider must not be nil")
+		}
+		sc.encryptionKeyProvider = provider
+		return nil
+	}
+}
+
+// EncryptEvent encrypts the data of the event as a JWE with the key of the
+// provider. Events without data and events which are already encrypted are
+// left unchanged.
+func EncryptEvent(ctx context.Context, e *event.Event, provider EncryptionKeyProvider) error {
+	if len(e.Data()) == 0 || isEncrypted(e) {
+		return nil
+	}
+
+	key, err := provider.EncryptionKey(ctx)
+	if err != nil {
+		return err
+	}
+	encrypter, err := jose.NewEncrypter(
+		jose.A256GCM,
+		jose.Recipient{Algorithm: key.Algorithm, Key: key.Key, KeyID: key.ID},
+		(&jose.EncrypterOptions{}).WithContentType(jose.ContentType(e.DataContentType())),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create encrypter: %w", err)
+	}
+	encrypted, err := encrypter.Encrypt(e.Data())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	serialized, err := encrypted.CompactSerialize()
+	if err != nil {
+		return fmt.Errorf("failed to serialize encrypted data: %w", err)
+	}
+
+	if err := e.SetData(EncryptedContentType, []byte(serialized)); err != nil {
+		return err
+	}
+	e.SetExtension(EncryptionKeyIDExtension, key.ID)
+	return nil
+}
+
+// DecryptEvent decrypts the data of an event encrypted by EncryptEvent, with
+// the key of the provider, and restores its data content type. Events which
+// aren't encrypted are left unchanged.
+func DecryptEvent(ctx context.Context, e *event.Event, provider EncryptionKeyProvider) error {
+	if !isEncrypted(e) {
+		return nil
+	}
+	keyID, _ := e.Extensions()[EncryptionKeyIDExtension].(string)
+
+	encrypted, err := jose.ParseEncrypted(string(e.Data()))
+	if err != nil {
+		return fmt.Errorf("failed to parse encrypted data: %w", err)
+	}
+	key, err := provider.DecryptionKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	data, err := encrypted.Decrypt(key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt data with key %s: %w", keyID, err)
+	}
+
+	contentType, _ := encrypted.Header.ExtraHeaders[jose.HeaderContentType].(string)
+	if err := e.SetData(contentType, data); err != nil {
+		return err
+	}
+	e.SetExtension(EncryptionKeyIDExtension, nil)
+	return nil
+}
+
+func isEncrypted(e *event.Event) bool {
+	_, ok := e.Extensions()[EncryptionKeyIDExtension]
+	return ok
+}
+
+// NewDecryptionMiddleware returns an http.Handler which decrypts the data of
+// the events encrypted with WithPayloadEncryption, before passing the
+// requests to next. Requests with events which can't be decrypted are
+// answered with 400 Bad Request, requests without events are passed as they
+// are.
+func NewDecryptionMiddleware(provider EncryptionKeyProvider, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		message := cehttp.NewMessageFromHttpRequest(r)
+		switch message.ReadEncoding() {
+		case binding.EncodingUnknown:
+			next.ServeHTTP(w, r)
+			return
+		case binding.EncodingBinary:
+			if message.Header.Get("ce-"+EncryptionKeyIDExtension) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		e, err := binding.ToEvent(ctx, message)
+		if err == nil {
+			err = DecryptEvent(ctx, e, provider)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to decrypt event", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		decrypted, err := decryptedRequest(ctx, r, e)
+		if err != nil {
+			logging.FromContext(ctx).Errorw("Failed to write decrypted event to request", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, decrypted)
+	})
+}
+
+// decryptedRequest returns a copy of the request with the event instead of
+// the event of the request.
+func decryptedRequest(ctx context.Context, r *http.Request, e *event.Event) (*http.Request, error) {
+	decrypted := r.Clone(ctx)
+	for name := range decrypted.Header {
+		if strings.HasPrefix(strings.ToLower(name), "ce-") {
+			decrypted.Header.Del(name)
+		}
+	}
+	decrypted.Header.Del(cehttp.ContentType)
+	// the body of the request was read already
+	decrypted.Body = http.NoBody
+	decrypted.ContentLength = 0
+	if err := cehttp.WriteRequest(ctx, binding.ToMessage(e), decrypted); err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
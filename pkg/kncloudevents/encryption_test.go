@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func encryptionKeyProvider(t *testing.T, activeKey string) kncloudevents.EncryptionKeyProvider {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	err := indexer.Add(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "keys",
+			Annotations: map[string]string{kncloudevents.ActiveEncryptionKeyAnnotation: activeKey},
+		},
+		Data: map[string][]byte{
+			"key-1":   bytes.Repeat([]byte{1}, 32),
+			"key-2":   bytes.Repeat([]byte{2}, 16),
+			"invalid": []byte("too short"),
+		},
+	})
+	require.Nil(t, err)
+	return kncloudevents.NewSecretEncryptionKeyProvider(corev1listers.NewSecretLister(indexer).Secrets("ns"), "keys")
+}
+
+func TestEncryptDecryptEvent(t *testing.T) {
+	ctx := context.Background()
+
+	for _, keyID := range []string{"key-1", "key-2"} {
+		t.Run(keyID, func(t *testing.T) {
+			provider := encryptionKeyProvider(t, keyID)
+			original := test.FullEvent()
+			e := original.Clone()
+
+			require.Nil(t, kncloudevents.EncryptEvent(ctx, &e, provider))
+			require.Equal(t, kncloudevents.EncryptedContentType, e.DataContentType())
+			require.Equal(t, keyID, e.Extensions()[kncloudevents.EncryptionKeyIDExtension])
+			require.NotContains(t, string(e.Data()), string(original.Data()))
+			require.Equal(t, original.Type(), e.Type())
+
+			// encrypting twice doesn't change the event
+			encrypted := e.Clone()
+			require.Nil(t, kncloudevents.EncryptEvent(ctx, &e, provider))
+			require.Equal(t, encrypted.Data(), e.Data())
+
+			// the previous key can still be decrypted after rotating keys
+			rotated := encryptionKeyProvider(t, "key-1")
+			require.Nil(t, kncloudevents.DecryptEvent(ctx, &e, rotated))
+			test.AssertEventEquals(t, original, e)
+		})
+	}
+}
+
+func TestEncryptEventErrors(t *testing.T) {
+	ctx := context.Background()
+
+	for _, keyID := range []string{"", "unknown", "invalid"} {
+		e := test.FullEvent()
+		require.Error(t, kncloudevents.EncryptEvent(ctx, &e, encryptionKeyProvider(t, keyID)), keyID)
+	}
+
+	e := test.FullEvent()
+	require.Nil(t, kncloudevents.EncryptEvent(ctx, &e, encryptionKeyProvider(t, "key-1")))
+	e.SetExtension(kncloudevents.EncryptionKeyIDExtension, "key-2")
+	require.Error(t, kncloudevents.DecryptEvent(ctx, &e, encryptionKeyProvider(t, "key-1")))
+}
+
+func TestDispatchWithPayloadEncryption(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Status(http.StatusInternalServerError)).
+		On(dls.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	provider := encryptionKeyProvider(t, "key-1")
+	e := test.FullEvent()
+
+	_, err := dispatcher.SendEvent(ctx, e, destination, kncloudevents.WithPayloadEncryption(provider), kncloudevents.WithDeadLetterSink(&dls))
+	require.Nil(t, err)
+
+	for _, target := range []*duckv1.Addressable{&destination, &dls} {
+		requests := fakeClient.RequestsTo(target.URL.String())
+		require.Len(t, requests, 1)
+		request := requests[0]
+		require.Equal(t, "key-1", request.Header.Get("Ce-Encryptionkeyid"))
+		require.Equal(t, kncloudevents.EncryptedContentType, request.Header.Get("Content-Type"))
+		require.NotContains(t, string(request.Body), string(e.Data()))
+	}
+}
+
+func TestDispatchWithNilEncryptionKeyProvider(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient()
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	// a misconfigured provider must not result in the data being sent in plaintext
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithPayloadEncryption(nil))
+	require.Error(t, err)
+	require.Empty(t, fakeClient.RequestsTo(destination.URL.String()))
+}
+
+func TestDecryptionMiddleware(t *testing.T) {
+	ctx := context.Background()
+	provider := encryptionKeyProvider(t, "key-1")
+	// extensions are received as strings
+	original := test.MinEvent()
+	original.SetExtension("exstring", "exstring")
+	require.Nil(t, original.SetData(event.ApplicationJSON, map[string]string{"hello": "world"}))
+	encrypted := original.Clone()
+	require.Nil(t, kncloudevents.EncryptEvent(ctx, &encrypted, provider))
+
+	tests := []struct {
+		name       string
+		event      event.Event
+		structured bool
+		wantStatus int
+	}{{
+		name:       "binary",
+		event:      encrypted,
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "structured",
+		event:      encrypted,
+		structured: true,
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "not encrypted",
+		event:      original,
+		wantStatus: http.StatusAccepted,
+	}, {
+		name:       "unknown key",
+		event:      withExtension(encrypted, kncloudevents.EncryptionKeyIDExtension, "unknown"),
+		wantStatus: http.StatusBadRequest,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var received *event.Event
+			handler := kncloudevents.NewDecryptionMiddleware(provider, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				received, err = binding.ToEvent(r.Context(), cehttp.NewMessageFromHttpRequest(r))
+				require.Nil(t, err)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+
+			writeCtx := ctx
+			if tc.structured {
+				writeCtx = binding.WithForceStructured(ctx)
+			}
+			request := httptest.NewRequest(http.MethodPost, "/", nil)
+			require.Nil(t, cehttp.WriteRequest(writeCtx, binding.ToMessage(&tc.event), request))
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantStatus == http.StatusAccepted {
+				require.NotNil(t, received)
+				test.AssertEventEquals(t, original, *received)
+			}
+		})
+	}
+}
+
+func withExtension(e event.Event, name string, value interface{}) event.Event {
+	e = e.Clone()
+	e.SetExtension(name, value)
+	return e
+}
@@ -19,14 +19,24 @@ package kncloudevents
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cloudevents/sdk-go/v2/binding/buffering"
+	"github.com/cloudevents/sdk-go/v2/binding/format"
+	"github.com/cloudevents/sdk-go/v2/binding/transformer"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/binding"
@@ -43,6 +53,7 @@ import (
 
 	eventingapis "knative.dev/eventing/pkg/apis"
 	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventfilter"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/eventtype"
 	"knative.dev/eventing/pkg/utils"
@@ -64,6 +75,23 @@ type DispatchInfo struct {
 	ResponseHeader http.Header
 	ResponseBody   []byte
 	Scheme         string
+	// Filtered is true if the event didn't pass the filter of the WithFilter or
+	// WithEventFilter send option, and therefore wasn't dispatched.
+	Filtered bool
+	// SpanContext is the context of the span tracing the request, e.g. to be
+	// attached as exemplar to latency metrics.
+	SpanContext trace.SpanContext
+	// RedirectedTo is the URL the request was finally sent to, if redirects
+	// were followed, otherwise empty.
+	RedirectedTo string
+	// Attempts is the number of requests sent, including retries.
+	Attempts int
+	// ErrorReason is the class of the failure, if the request failed.
+	ErrorReason attributes.KnativeErrorReason
+	// DeadlineExhausted is true if the request wasn't retried anymore, since
+	// the time left until the deadline of the context couldn't cover another
+	// attempt.
+	DeadlineExhausted bool
 }
 
 type SendOption func(*senderConfig) error
@@ -84,6 +112,24 @@ func WithDeadLetterSink(dls *duckv1.Addressable) SendOption {
 	}
 }
 
+// WithFailoverDestinations sends the message to the given destinations, in
+// order, when sending it to the destination failed including its retries,
+// before sending it to the dead letter sink. The reply of the first
+// destination accepting the message is handled like the reply of the
+// destination.
+func WithFailoverDestinations(destinations ...duckv1.Addressable) SendOption {
+	return func(sc *senderConfig) error {
+		for _, destination := range destinations {
+			if destination.URL == nil {
+				return fmt.Errorf("failover destination must have a URL")
+			}
+		}
+		sc.failoverDestinations = destinations
+
+		return nil
+	}
+}
+
 func WithRetryConfig(retryConfig *RetryConfig) SendOption {
 	return func(sc *senderConfig) error {
 		sc.retryConfig = retryConfig
@@ -92,14 +138,77 @@ func WithRetryConfig(retryConfig *RetryConfig) SendOption {
 	}
 }
 
+// WithDeliveryBudget bounds the whole delivery, i.e. the requests to the
+// destination, the reply and the dead letter sink including their retries,
+// while the RetryConfig applies to each of them.
+func WithDeliveryBudget(budget DeliveryBudget) SendOption {
+	return func(sc *senderConfig) error {
+		if budget.MaxAttempts < 0 || budget.Timeout < 0 {
+			return fmt.Errorf("delivery budget must not be negative")
+		}
+		sc.budget = budget
+
+		return nil
+	}
+}
+
+// WithHeader replaces all headers set by previous options with a copy of the
+// given headers. Use SetHeaders to keep the headers set before.
+//
+// The headers are sent with the requests to the destination, the failover
+// destinations and the dead letter sink. Headers rendered from the event, i.e.
+// Content-Type and the Ce- headers, take precedence over them.
 func WithHeader(header http.Header) SendOption {
 	return func(sc *senderConfig) error {
-		sc.additionalHeaders = header
+		sc.additionalHeaders = header.Clone()
 
 		return nil
 	}
 }
 
+// SetHeaders sets the given headers with all their values, replacing the
+// values of these headers set by previous options. See WithHeader.
+func SetHeaders(header http.Header) SendOption {
+	return func(sc *senderConfig) error {
+		for key, values := range header {
+			sc.header().Del(key)
+			for _, value := range values {
+				sc.header().Add(key, value)
+			}
+		}
+
+		return nil
+	}
+}
+
+// SetHeader sets the header to the value, replacing the values of the header
+// set by previous options. See WithHeader.
+func SetHeader(key, value string) SendOption {
+	return func(sc *senderConfig) error {
+		sc.header().Set(key, value)
+
+		return nil
+	}
+}
+
+// AddHeader adds the value to the values of the header set by previous
+// options. See WithHeader.
+func AddHeader(key, value string) SendOption {
+	return func(sc *senderConfig) error {
+		sc.header().Add(key, value)
+
+		return nil
+	}
+}
+
+// header returns the additional headers, creating them if needed.
+func (sc *senderConfig) header() http.Header {
+	if sc.additionalHeaders == nil {
+		sc.additionalHeaders = make(http.Header)
+	}
+	return sc.additionalHeaders
+}
+
 func WithTransformers(transformers ...binding.Transformer) SendOption {
 	return func(sc *senderConfig) error {
 		sc.transformers = transformers
@@ -119,6 +228,29 @@ func WithOIDCAuthentication(serviceAccount *types.NamespacedName) SendOption {
 	}
 }
 
+// RequestOption configures a request sent to the target, e.g. to authenticate it.
+type RequestOption func(req *http.Request, target duckv1.Addressable) error
+
+// WithOIDCToken authenticates requests with a JWT of the service account for the
+// audience of the target. The tokens are provided by the token provider, so that
+// senders sharing it share the tokens and their TokenRequests. Requests to
+// targets without an audience are sent without a JWT.
+func WithOIDCToken(tokenProvider *auth.OIDCTokenProvider, serviceAccount types.NamespacedName) RequestOption {
+	return func(req *http.Request, target duckv1.Addressable) error {
+		if target.Audience == nil || *target.Audience == "" {
+			return nil
+		}
+
+		jwt, err := tokenProvider.GetJWT(serviceAccount, *target.Audience)
+		if err != nil {
+			return fmt.Errorf("could not get JWT: %w", err)
+		}
+		auth.SetAuthHeader(jwt, req.Header)
+
+		return nil
+	}
+}
+
 func WithEventTypeAutoHandler(handler *eventtype.EventTypeAutoHandler, ref *duckv1.KReference, ownerUID types.UID) SendOption {
 	return func(sc *senderConfig) error {
 		if handler != nil && (ref == nil || ownerUID == types.UID("")) {
@@ -132,9 +264,145 @@ func WithEventTypeAutoHandler(handler *eventtype.EventTypeAutoHandler, ref *duck
 	}
 }
 
+// WithFilter evaluates the given filter before dispatching. Events not passing
+// it aren't sent, and a DispatchInfo with Filtered set is returned instead.
+func WithFilter(filter func(event.Event) (bool, error)) SendOption {
+	return func(sc *senderConfig) error {
+		if filter == nil {
+			return fmt.Errorf("filter must not be nil")
+		}
+		sc.filter = func(_ context.Context, e event.Event) (bool, error) {
+			return filter(e)
+		}
+
+		return nil
+	}
+}
+
+// WithEventFilter is like WithFilter, but with a precompiled filter of the
+// eventfilter packages, e.g. the filters of a Trigger. Only events failing the
+// filter are filtered out.
+func WithEventFilter(filter eventfilter.Filter) SendOption {
+	return func(sc *senderConfig) error {
+		if filter == nil {
+			return fmt.Errorf("filter must not be nil")
+		}
+		sc.filter = func(ctx context.Context, e event.Event) (bool, error) {
+			return filter.Filter(ctx, e) != eventfilter.FailFilter, nil
+		}
+
+		return nil
+	}
+}
+
+// WithSequencer stamps the sequence extension on the events sent, using the
+// given Sequencer, so that they can be processed in order downstream.
+func WithSequencer(sequencer *Sequencer) SendOption {
+	return func(sc *senderConfig) error {
+		if sequencer == nil {
+			return fmt.Errorf("sequencer must not be nil")
+		}
+		sc.sequencer = sequencer
+
+		return nil
+	}
+}
+
+// WithPartitionKeyPropagation sets the partition key of the event on its reply,
+// if the reply doesn't have a partition key on its own.
+func WithPartitionKeyPropagation() SendOption {
+	return func(sc *senderConfig) error {
+		sc.propagatePartitionKey = true
+
+		return nil
+	}
+}
+
+// DeadLetterPayload selects what is sent to the dead letter sink when
+// forwarding the reply of the destination fails.
+type DeadLetterPayload string
+
+const (
+	// DeadLetterPayloadFailedMessage sends the reply which couldn't be
+	// forwarded. This is the default.
+	DeadLetterPayloadFailedMessage DeadLetterPayload = "failed-message"
+	// DeadLetterPayloadOriginal sends the original event sent to the destination.
+	DeadLetterPayloadOriginal DeadLetterPayload = "original"
+	// DeadLetterPayloadBothWrapped sends an event of type
+	// DeadLetterWrappedEventType, with the original event and the reply in
+	// its data.
+	DeadLetterPayloadBothWrapped DeadLetterPayload = "both-wrapped"
+)
+
+// DeadLetterWrappedEventType is the type of the events sent to the dead letter
+// sink with DeadLetterPayloadBothWrapped.
+const DeadLetterWrappedEventType = "dev.knative.eventing.deadletter.wrapped"
+
+// DeadLetterWrapper is the data of the events sent to the dead letter sink with
+// DeadLetterPayloadBothWrapped.
+type DeadLetterWrapper struct {
+	// Original is the event sent to the destination.
+	Original *event.Event `json:"original"`
+	// Failed is the reply of the destination, which couldn't be forwarded.
+	Failed *event.Event `json:"failed"`
+}
+
+// WithDeadLetterPayload selects what is sent to the dead letter sink when
+// forwarding the reply fails. If sending to the destination fails, the
+// original event is always sent.
+func WithDeadLetterPayload(payload DeadLetterPayload) SendOption {
+	return func(sc *senderConfig) error {
+		switch payload {
+		case DeadLetterPayloadFailedMessage, DeadLetterPayloadOriginal, DeadLetterPayloadBothWrapped:
+			sc.deadLetterPayload = payload
+		default:
+			return fmt.Errorf("unknown dead letter payload %q", payload)
+		}
+
+		return nil
+	}
+}
+
+// WithReplyDispatchExtensions adds the knativedispatch extensions to the reply
+// forwarded to the reply destination. They describe the request to the
+// destination which produced the reply, so that the timing of multiple hops
+// can be reconstructed downstream.
+func WithReplyDispatchExtensions() SendOption {
+	return func(sc *senderConfig) error {
+		sc.replyDispatchExtensions = true
+
+		return nil
+	}
+}
+
+// WithoutPreferReply omits the `Prefer: reply` header, which is otherwise
+// added to the requests to the destination and the failover destinations, for
+// sinks generating replies only because they see it.
+func WithoutPreferReply() SendOption {
+	return func(sc *senderConfig) error {
+		sc.withoutPreferReply = true
+
+		return nil
+	}
+}
+
+// WithAuditLogger records the delivery with the given AuditLogger, once it
+// completed.
+func WithAuditLogger(logger *AuditLogger) SendOption {
+	return func(sc *senderConfig) error {
+		if logger == nil {
+			return fmt.Errorf("audit logger must not be nil")
+		}
+		sc.auditLogger = logger
+
+		return nil
+	}
+}
+
 type senderConfig struct {
 	reply                *duckv1.Addressable
 	deadLetterSink       *duckv1.Addressable
+	failoverDestinations []duckv1.Addressable
 	additionalHeaders    http.Header
 	retryConfig          *RetryConfig
 	transformers         binding.Transformers
@@ -142,18 +410,77 @@ type senderConfig struct {
 	eventTypeAutoHandler *eventtype.EventTypeAutoHandler
 	eventTypeRef         *duckv1.KReference
 	eventTypeOnwerUID    types.UID
+	filter               func(context.Context, event.Event) (bool, error)
+	budget               DeliveryBudget
+	sequencer            *Sequencer
+	deadLetterPayload    DeadLetterPayload
+	auditLogger          *AuditLogger
+	// flowEdge is the edge of the topology the delivery is recorded for
+	flowEdge *FlowEdge
+	// audit collects the audit record of the delivery, if audited
+	audit *deliveryAudit
+	// propagatePartitionKey sets the partition key of the event on the reply
+	propagatePartitionKey bool
+	// replyDispatchExtensions describes the request to the destination on the reply
+	replyDispatchExtensions bool
+	// withoutPreferReply omits the Prefer: reply header on the requests to the destination
+	withoutPreferReply bool
+	// deadLetterEnvelope wraps the events sent to the dead letter sink in a DeadLetterEnvelope
+	deadLetterEnvelope bool
+	// encryptionKeyProvider encrypts the data of the event, if set
+	encryptionKeyProvider EncryptionKeyProvider
+	// transformations are applied to the event, but not to the reply
+	transformations []Transformation
+	// format is the format of the event sent to the destination, nil for the
+	// binary content mode
+	format format.Format
+	// signatureKeyID and signatureKey sign the requests, if set
+	signatureKeyID string
+	signatureKey   []byte
+	// inFlightLimit limits the deliveries in flight, if set
+	inFlightLimit *inFlightLimit
 }
 
 type Dispatcher struct {
-	oidcTokenProvider *auth.OIDCTokenProvider
-	clientConfig      eventingtls.ClientConfig
+	oidcTokenProvider   *auth.OIDCTokenProvider
+	clientConfig        eventingtls.ClientConfig
+	httpClient          *http.Client
+	destinationResolver DestinationResolver
+	concurrency         *adaptiveConcurrency
+	retryBudget         *retryBudget
+	formats             *formatNegotiator
+	certificateExpiry   *CertificateExpiryMonitor
+	health              *HealthRegistry
+	srv                 *srvDiscovery
+	failureLogger       *DeliveryFailureLogger
+
+	interceptorsMu sync.RWMutex
+	interceptors   []DispatchInterceptor
+}
+
+// DispatcherOption enables further configuration of a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithHTTPClient makes the Dispatcher send all requests with the given client,
+// instead of the shared per addressable clients. This is mainly useful for tests,
+// e.g. with a test.FakeClient.
+func WithHTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.httpClient = client
+	}
 }
 
-func NewDispatcher(clientConfig eventingtls.ClientConfig, oidcTokenProvider *auth.OIDCTokenProvider) *Dispatcher {
-	return &Dispatcher{
+func NewDispatcher(clientConfig eventingtls.ClientConfig, oidcTokenProvider *auth.OIDCTokenProvider, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
 		clientConfig:      clientConfig,
 		oidcTokenProvider: oidcTokenProvider,
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 // SendEvent sends the given event to the given destination.
@@ -169,9 +496,10 @@ func (d *Dispatcher) SendEvent(ctx context.Context, event event.Event, destinati
 
 // SendMessage sends the given message to the given destination.
 // SendMessage is kept for compatibility and SendEvent should be used whenever possible.
-func (d *Dispatcher) SendMessage(ctx context.Context, message binding.Message, destination duckv1.Addressable, options ...SendOption) (*DispatchInfo, error) {
+func (d *Dispatcher) SendMessage(ctx context.Context, message binding.Message, destination duckv1.Addressable, options ...SendOption) (dispatchInfo *DispatchInfo, err error) {
 	config := &senderConfig{
 		additionalHeaders: make(http.Header),
+		deadLetterPayload: DeadLetterPayloadFailedMessage,
 	}
 
 	// apply options
@@ -181,10 +509,35 @@ func (d *Dispatcher) SendMessage(ctx context.Context, message binding.Message, d
 		}
 	}
 
-	return d.send(ctx, message, destination, config)
+	if config.flowEdge != nil {
+		eventType, start := messageEventType(message), time.Now()
+		defer func() {
+			reportFlow(config.flowEdge, eventType, start, dispatchInfo, err)
+		}()
+	}
+
+	if config.auditLogger == nil {
+		return d.send(ctx, message, destination, config)
+	}
+
+	config.audit = newDeliveryAudit(message, destination, config)
+	dispatchInfo, err = d.send(ctx, message, destination, config)
+	config.auditLogger.record(ctx, config.audit.complete(dispatchInfo, err))
+	return dispatchInfo, err
 }
 
-func (d *Dispatcher) send(ctx context.Context, message binding.Message, destination duckv1.Addressable, config *senderConfig) (*DispatchInfo, error) {
+func (d *Dispatcher) send(ctx context.Context, message binding.Message, destination duckv1.Addressable, config *senderConfig) (dispatchInfo *DispatchInfo, err error) {
+	if d.failureLogger != nil {
+		// messages can only be read once, so the attributes are read before sending it
+		metadata := messageMetadata(ctx, message)
+		defer func() {
+			// throttled deliveries and rejected bodies aren't delivery failures
+			if err != nil && !errors.Is(err, ErrConcurrencyLimitExceeded) && !IsBodyTooLarge(err) {
+				d.failureLogger.Log("failed to deliver event", metadata, destination, dispatchInfo, err)
+			}
+		}()
+	}
+
 	dispatchExecutionInfo := &DispatchInfo{}
 
 	// All messages that should be finished at the end of this function
@@ -200,32 +553,158 @@ func (d *Dispatcher) send(ctx context.Context, message binding.Message, destinat
 		return dispatchExecutionInfo, fmt.Errorf("can not dispatch message to nil destination.URL")
 	}
 
+	if config.filter != nil {
+		// messages can only be read once, so the event is dispatched from now on
+		e, err := binding.ToEvent(ctx, message)
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to convert message to event for filtering: %w", err)
+		}
+		pass, err := config.filter(ctx, *e)
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to filter event: %w", err)
+		}
+		if !pass {
+			return &DispatchInfo{
+				Duration:     NoDuration,
+				ResponseCode: NoResponse,
+				Filtered:     true,
+			}, nil
+		}
+		message = binding.ToMessage(e)
+	}
+
+	if config.encryptionKeyProvider != nil {
+		e, err := binding.ToEvent(ctx, message)
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to convert message to event for encryption: %w", err)
+		}
+		if err := EncryptEvent(ctx, e, config.encryptionKeyProvider); err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to encrypt event: %w", err)
+		}
+		message = binding.ToMessage(e)
+	}
+
+	if config.inFlightLimit != nil {
+		release, ok := config.inFlightLimit.acquire()
+		if !ok {
+			return &DispatchInfo{
+				Duration:     NoDuration,
+				ResponseCode: NoResponse,
+				ErrorReason:  attributes.KnativeErrorReasonCircuitOpen,
+			}, ErrConcurrencyLimitExceeded
+		}
+		defer release()
+	}
+
+	if config.retryConfig == nil {
+		config.retryConfig = defaultRetryConfig()
+	}
+
+	if config.budget.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.budget.Timeout)
+		defer cancel()
+	}
+	var attempts *attemptBudget
+	if config.budget.MaxAttempts > 0 {
+		attempts = &attemptBudget{remaining: config.budget.MaxAttempts}
+	}
+
+	// the reply gets its own transformers, since it may need the partition key of the event
+	var replyPartitionKey string
+	if config.propagatePartitionKey {
+		replyPartitionKey, _ = messageExtension(message, DefaultPartitionKeyExtension)
+	}
+	replyTransformers := config.transformers
+	if replyPartitionKey != "" {
+		replyTransformers = withTransformer(replyTransformers, transformer.AddExtension(DefaultPartitionKeyExtension, replyPartitionKey))
+	}
+	if len(config.transformations) > 0 {
+		config.transformers = withTransformer(config.transformers, transformationsTransformer(config.transformations, config.additionalHeaders))
+	}
+	if config.sequencer != nil {
+		replyTransformers = withTransformer(replyTransformers, config.sequencer.transformer(replyPartitionKey))
+		config.transformers = withTransformer(config.transformers, config.sequencer.Transformer())
+	}
+
 	// sanitize eventual host-only URLs
-	destination = *sanitizeAddressable(&destination)
-	config.reply = sanitizeAddressable(config.reply)
-	config.deadLetterSink = sanitizeAddressable(config.deadLetterSink)
+	sanitizedDestination, err := sanitizeAddressable(&destination)
+	if err != nil {
+		return dispatchExecutionInfo, fmt.Errorf("invalid destination: %w", err)
+	}
+	destination = *sanitizedDestination
+	if config.reply, err = sanitizeAddressable(config.reply); err != nil {
+		return dispatchExecutionInfo, fmt.Errorf("invalid reply: %w", err)
+	}
+	if config.deadLetterSink, err = sanitizeAddressable(config.deadLetterSink); err != nil {
+		return dispatchExecutionInfo, fmt.Errorf("invalid dead letter sink: %w", err)
+	}
+	failoverDestinations := make([]duckv1.Addressable, 0, len(config.failoverDestinations))
+	for i := range config.failoverDestinations {
+		failover, err := sanitizeAddressable(&config.failoverDestinations[i])
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("invalid failover destination: %w", err)
+		}
+		failoverDestinations = append(failoverDestinations, *failover)
+	}
+
+	if len(failoverDestinations) > 0 || (config.deadLetterPayload != DeadLetterPayloadFailedMessage && config.reply != nil && config.deadLetterSink != nil) {
+		// messages can only be read once, so we need a copy to send the original message to the failover
+		// destinations, or to dead letter it after the reply
+		message, err = buffering.CopyMessage(ctx, message)
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to buffer message: %w", err)
+		}
+		messagesToFinish = append(messagesToFinish, message)
+	}
 
 	// send to destination
 
-	// Add `Prefer: reply` header no matter if a reply destination is provided. Discussion: https://github.com/knative/eventing/pull/5764
-	additionalHeadersForDestination := http.Header{}
-	if config.additionalHeaders != nil {
-		additionalHeadersForDestination = config.additionalHeaders.Clone()
+	// Add `Prefer: reply` header no matter if a reply destination is provided, unless disabled. Discussion: https://github.com/knative/eventing/pull/5764
+	additionalHeadersForDestination := getHeader()
+	defer putHeader(additionalHeadersForDestination)
+	// the values aren't copied, the requests copy them
+	for key, values := range config.additionalHeaders {
+		additionalHeadersForDestination[key] = values
+	}
+	if !config.withoutPreferReply {
+		additionalHeadersForDestination.Set("Prefer", "reply")
 	}
-	additionalHeadersForDestination.Set("Prefer", "reply")
 
-	ctx, responseMessage, dispatchExecutionInfo, err := d.executeRequest(ctx, destination, message, additionalHeadersForDestination, config.retryConfig, config.oidcServiceAccount, config.transformers)
+	if config.signatureKey != nil {
+		ctx = contextWithSignatureKey(ctx, config.signatureKeyID, config.signatureKey)
+	}
+	sendCtx := ctx
+	if config.format != nil {
+		sendCtx = contextWithFormat(sendCtx, config.format, append([]duckv1.Addressable{destination}, failoverDestinations...)...)
+	}
+	ctx, responseMessage, dispatchExecutionInfo, err := d.executeRequest(sendCtx, destination, message, additionalHeadersForDestination, config.retryConfig, attempts, config.oidcServiceAccount, config.transformers)
+	config.audit.attempted(dispatchExecutionInfo)
+	for _, failover := range failoverDestinations {
+		if err == nil || errors.Is(err, ErrDeliveryBudgetExhausted) {
+			break
+		}
+		// from now on the failover is the destination, e.g. for the error extensions of the dead letter sink
+		destination = failover
+		ctx, responseMessage, dispatchExecutionInfo, err = d.executeRequest(sendCtx, destination, message, additionalHeadersForDestination, config.retryConfig, attempts, config.oidcServiceAccount, config.transformers)
+		config.audit.attempted(dispatchExecutionInfo)
+	}
 	if err != nil {
 		// If DeadLetter is configured, then send original message with knative error extensions
 		if config.deadLetterSink != nil {
-			dispatchTransformers := dispatchExecutionInfoTransformers(destination.URL, dispatchExecutionInfo)
-			_, deadLetterResponse, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, *config.deadLetterSink, message, config.additionalHeaders, config.retryConfig, config.oidcServiceAccount, append(config.transformers, dispatchTransformers))
+			deadLetterMessage, deadLetterTransformers, wrapErr := config.deadLetter(ctx, message, config.transformers, destination.URL, dispatchExecutionInfo)
+			if wrapErr != nil {
+				return dispatchExecutionInfo, fmt.Errorf("unable to complete request to %s (%v) and to dead letter it: %w", destination.URL, err, wrapErr)
+			}
+			_, deadLetterResponse, dispatchExecutionInfo, deadLetterErr := d.executeRequest(contextWithDeadLetter(ctx), *config.deadLetterSink, deadLetterMessage, config.additionalHeaders, config.retryConfig, attempts, config.oidcServiceAccount, deadLetterTransformers...)
+			config.audit.attempted(dispatchExecutionInfo)
 			if deadLetterErr != nil {
 				return dispatchExecutionInfo, fmt.Errorf("unable to complete request to either %s (%v) or %s (%v)", destination.URL, err, config.deadLetterSink.URL, deadLetterErr)
 			}
 			if deadLetterResponse != nil {
 				messagesToFinish = append(messagesToFinish, deadLetterResponse)
 			}
+			config.audit.deadLettered()
 
 			return dispatchExecutionInfo, nil
 		}
@@ -233,12 +712,10 @@ func (d *Dispatcher) send(ctx context.Context, message binding.Message, destinat
 		return dispatchExecutionInfo, fmt.Errorf("unable to complete request to %s: %w", destination.URL, err)
 	}
 
-	responseAdditionalHeaders := utils.PassThroughHeaders(dispatchExecutionInfo.ResponseHeader)
+	responseAdditionalHeaders := utils.AppendPassThroughHeaders(getHeader(), dispatchExecutionInfo.ResponseHeader)
+	defer putHeader(responseAdditionalHeaders)
 
 	if config.additionalHeaders.Get(eventingapis.KnNamespaceHeader) != "" {
-		if responseAdditionalHeaders == nil {
-			responseAdditionalHeaders = make(http.Header)
-		}
 		responseAdditionalHeaders.Set(eventingapis.KnNamespaceHeader, config.additionalHeaders.Get(eventingapis.KnNamespaceHeader))
 	}
 
@@ -261,20 +738,54 @@ func (d *Dispatcher) send(ctx context.Context, message binding.Message, destinat
 		return dispatchExecutionInfo, nil
 	}
 
+	if config.deadLetterSink != nil && config.deadLetterPayload != DeadLetterPayloadOriginal {
+		// messages can only be read once, so we need a copy to dead letter the reply
+		responseMessage, err = buffering.CopyMessage(ctx, responseMessage)
+		if err != nil {
+			return dispatchExecutionInfo, fmt.Errorf("failed to buffer reply: %w", err)
+		}
+		messagesToFinish = append(messagesToFinish, responseMessage)
+	}
+
+	if config.replyDispatchExtensions {
+		// The credentials of the destination must not leak to the reply.
+		dispatched, _ := withoutUserinfo(destination.URL)
+		replyTransformers = withTransformer(replyTransformers, attributes.KnativeDispatchTransformers(*dispatched.URL(), dispatchExecutionInfo.ResponseCode, dispatchExecutionInfo.Duration))
+	}
+
 	// send reply
 
-	ctx, responseResponseMessage, dispatchExecutionInfo, err := d.executeRequest(ctx, *config.reply, responseMessage, responseAdditionalHeaders, config.retryConfig, config.oidcServiceAccount, config.transformers)
+	ctx, responseResponseMessage, dispatchExecutionInfo, err := d.executeRequest(ctx, *config.reply, responseMessage, responseAdditionalHeaders, config.retryConfig, attempts, config.oidcServiceAccount, replyTransformers)
+	config.audit.attempted(dispatchExecutionInfo)
 	if err != nil {
-		// If DeadLetter is configured, then send original message with knative error extensions
+		// If DeadLetter is configured, then send the configured payload with knative error extensions
 		if config.deadLetterSink != nil {
-			dispatchTransformers := dispatchExecutionInfoTransformers(config.reply.URL, dispatchExecutionInfo)
-			_, deadLetterResponse, dispatchExecutionInfo, deadLetterErr := d.executeRequest(ctx, *config.deadLetterSink, message, responseAdditionalHeaders, config.retryConfig, config.oidcServiceAccount, append(config.transformers, dispatchTransformers))
+			deadLetterMessage, deadLetterTransformers := message, config.transformers
+			switch config.deadLetterPayload {
+			case DeadLetterPayloadFailedMessage:
+				// a reply which isn't a valid event can't be dead lettered either
+				if _, convErr := binding.ToEvent(ctx, responseMessage); convErr == nil {
+					deadLetterMessage, deadLetterTransformers = responseMessage, replyTransformers
+				}
+			case DeadLetterPayloadBothWrapped:
+				deadLetterMessage, err = wrapDeadLetter(ctx, message, responseMessage)
+				if err != nil {
+					return dispatchExecutionInfo, fmt.Errorf("failed to forward reply to %s and failed to wrap it for the dead letter sink: %w", config.reply.URL, err)
+				}
+			}
+			deadLetterMessage, deadLetterTransformers, wrapErr := config.deadLetter(ctx, deadLetterMessage, deadLetterTransformers, config.reply.URL, dispatchExecutionInfo)
+			if wrapErr != nil {
+				return dispatchExecutionInfo, fmt.Errorf("failed to forward reply to %s (%v) and to dead letter it: %w", config.reply.URL, err, wrapErr)
+			}
+			_, deadLetterResponse, dispatchExecutionInfo, deadLetterErr := d.executeRequest(contextWithDeadLetter(ctx), *config.deadLetterSink, deadLetterMessage, responseAdditionalHeaders, config.retryConfig, attempts, config.oidcServiceAccount, deadLetterTransformers...)
+			config.audit.attempted(dispatchExecutionInfo)
 			if deadLetterErr != nil {
 				return dispatchExecutionInfo, fmt.Errorf("failed to forward reply to %s (%v) and failed to send it to the dead letter sink %s (%v)", config.reply.URL, err, config.deadLetterSink.URL, deadLetterErr)
 			}
 			if deadLetterResponse != nil {
 				messagesToFinish = append(messagesToFinish, deadLetterResponse)
 			}
+			config.audit.deadLettered()
 
 			return dispatchExecutionInfo, nil
 		}
@@ -288,7 +799,68 @@ func (d *Dispatcher) send(ctx context.Context, message binding.Message, destinat
 	return dispatchExecutionInfo, nil
 }
 
-func (d *Dispatcher) executeRequest(ctx context.Context, target duckv1.Addressable, message cloudevents.Message, additionalHeaders http.Header, retryConfig *RetryConfig, oidcServiceAccount *types.NamespacedName, transformers ...binding.Transformer) (context.Context, cloudevents.Message, *DispatchInfo, error) {
+func (d *Dispatcher) executeRequest(ctx context.Context, target duckv1.Addressable, message cloudevents.Message, additionalHeaders http.Header, retryConfig *RetryConfig, attempts *attemptBudget, oidcServiceAccount *types.NamespacedName, transformers ...binding.Transformer) (context.Context, cloudevents.Message, *DispatchInfo, error) {
+	spanOpts := []trace.StartOption{trace.WithSpanKind(trace.SpanKindClient)}
+	if sampler := samplerForNamespace(additionalHeaders.Get(eventingapis.KnNamespaceHeader)); sampler != nil {
+		spanOpts = append(spanOpts, trace.WithSampler(sampler))
+	}
+	ctx, span := trace.StartSpan(ctx, "knative.dev", spanOpts...)
+	defer span.End()
+
+	// the credentials of the URL are sent in the Authorization header, so that
+	// they don't end up in cache keys, spans and logs
+	if target.URL != nil && target.URL.User != nil {
+		var authorization string
+		target.URL, authorization = withoutUserinfo(target.URL)
+		if additionalHeaders.Get("Authorization") == "" {
+			additionalHeaders = additionalHeaders.Clone()
+			if additionalHeaders == nil {
+				additionalHeaders = make(http.Header)
+			}
+			additionalHeaders.Set("Authorization", authorization)
+		}
+	}
+
+	if span.IsRecordingEvents() {
+		transformers = append(transformers, tracing.PopulateSpan(span, target.URL.String()))
+	}
+
+	if attempts != nil {
+		if attempts.exhausted() {
+			dispatchInfo := newDispatchInfo(target)
+			dispatchInfo.SpanContext = span.SpanContext()
+			return ctx, nil, dispatchInfo, ErrDeliveryBudgetExhausted
+		}
+		retryConfig = attempts.limit(retryConfig)
+	}
+
+	var dispatch DispatchFunc = func(ctx context.Context, request DispatchRequest) (binding.Message, *DispatchInfo, error) {
+		return d.dispatch(ctx, request, retryConfig, oidcServiceAccount, transformers...)
+	}
+	if d.concurrency != nil {
+		dispatch = d.concurrency.intercept(dispatch)
+	}
+	dispatch = d.intercept(dispatch)
+	responseMessage, dispatchInfo, err := dispatch(ctx, DispatchRequest{
+		Target:  target,
+		Message: message,
+		Header:  additionalHeaders,
+	})
+	if dispatchInfo == nil {
+		// the request was handled by an interceptor without dispatch info
+		dispatchInfo = newDispatchInfo(target)
+	}
+	if err != nil {
+		dispatchInfo.ErrorReason = errorReason(err)
+	}
+	if d.health != nil {
+		d.health.observe(target, dispatchInfo.ErrorReason, err)
+	}
+	dispatchInfo.SpanContext = span.SpanContext()
+	return ctx, responseMessage, dispatchInfo, err
+}
+
+func newDispatchInfo(target duckv1.Addressable) *DispatchInfo {
 	var scheme string
 	if target.URL != nil {
 		scheme = target.URL.Scheme
@@ -296,57 +868,81 @@ func (d *Dispatcher) executeRequest(ctx context.Context, target duckv1.Addressab
 		// assume that the scheme is http by default
 		scheme = "http"
 	}
-	dispatchInfo := DispatchInfo{
+	return &DispatchInfo{
 		Duration:       NoDuration,
 		ResponseCode:   NoResponse,
 		ResponseHeader: make(http.Header),
 		Scheme:         scheme,
 	}
+}
 
-	ctx, span := trace.StartSpan(ctx, "knative.dev", trace.WithSpanKind(trace.SpanKindClient))
-	defer span.End()
+// dispatch sends the request, it's the innermost DispatchFunc of the
+// interceptor chain.
+func (d *Dispatcher) dispatch(ctx context.Context, request DispatchRequest, retryConfig *RetryConfig, oidcServiceAccount *types.NamespacedName, transformers ...binding.Transformer) (binding.Message, *DispatchInfo, error) {
+	target, message, additionalHeaders := request.Target, request.Message, request.Header
+	dispatchInfo := newDispatchInfo(target)
 
-	if span.IsRecordingEvents() {
-		transformers = append(transformers, tracing.PopulateSpan(span, target.URL.String()))
+	if d.retryBudget != nil {
+		ctx = contextWithRetryBucket(ctx, d.retryBudget.bucketFor(target.URL.String()))
 	}
 
 	req, err := d.createRequest(ctx, message, target, additionalHeaders, oidcServiceAccount, transformers...)
 	if err != nil {
-		return ctx, nil, &dispatchInfo, fmt.Errorf("failed to create request: %w", err)
+		return nil, dispatchInfo, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if d.srv != nil {
+		if err := d.srv.resolveRequest(req); err != nil {
+			return nil, dispatchInfo, err
+		}
 	}
 
-	client, err := newClient(d.clientConfig, target)
+	client, err := d.newClient(target, additionalHeaders.Get(eventingapis.KnNamespaceHeader))
 	if err != nil {
-		return ctx, nil, &dispatchInfo, fmt.Errorf("failed to create http client: %w", err)
+		return nil, dispatchInfo, fmt.Errorf("failed to create http client: %w", err)
 	}
 
 	start := time.Now()
-	response, err := client.DoWithRetries(req, retryConfig)
+	response, stats, err := client.DoWithRetries(req, retryConfig)
 	dispatchInfo.Duration = time.Since(start)
+	dispatchInfo.Attempts = stats.attempts
+	dispatchInfo.DeadlineExhausted = stats.deadlineExhausted
 	if err != nil {
 		dispatchInfo.ResponseCode = http.StatusInternalServerError
 		dispatchInfo.ResponseBody = []byte(fmt.Sprintf("dispatch error: %s", err.Error()))
 
-		return ctx, nil, &dispatchInfo, err
+		return nil, dispatchInfo, err
+	}
+
+	if d.certificateExpiry != nil && response.TLS != nil {
+		d.certificateExpiry.observe(response.Request.URL.Host, response.TLS)
 	}
 
 	dispatchInfo.ResponseCode = response.StatusCode
 	dispatchInfo.ResponseHeader = response.Header
+	if response.Request != nil && response.Request.URL.String() != req.URL.String() {
+		dispatchInfo.RedirectedTo = response.Request.URL.String()
+	}
 
-	body := new(bytes.Buffer)
-	_, err = body.ReadFrom(response.Body)
+	body, err := readResponseBody(response.Body, maxResponseBodySize())
+	if errors.Is(err, ErrResponseBodyTooLarge) {
+		response.Body.Close()
+		dispatchInfo.ResponseCode = http.StatusInternalServerError
+		dispatchInfo.ResponseBody = []byte(fmt.Sprintf("dispatch error: %s", err.Error()))
+		return nil, dispatchInfo, err
+	}
 
 	if isFailure(response.StatusCode) {
 		// Read response body into dispatchInfo for failures
 		if err != nil && err != io.EOF {
 			dispatchInfo.ResponseBody = []byte(fmt.Sprintf("dispatch resulted in status \"%s\". Could not read response body: error: %s", response.Status, err.Error()))
 		} else {
-			dispatchInfo.ResponseBody = body.Bytes()
+			dispatchInfo.ResponseBody = body
 		}
 		response.Body.Close()
 
 		// Reject non-successful responses.
-		return ctx, nil, &dispatchInfo, fmt.Errorf("unexpected HTTP response, expected 2xx, got %d", response.StatusCode)
+		return nil, dispatchInfo, fmt.Errorf("%w, expected 2xx, got %d", errUnexpectedResponse, response.StatusCode)
 	}
 
 	var responseMessageBody []byte
@@ -354,7 +950,7 @@ func (d *Dispatcher) executeRequest(ctx context.Context, target duckv1.Addressab
 		responseMessageBody = []byte(fmt.Sprintf("Failed to read response body: %s", err.Error()))
 		dispatchInfo.ResponseCode = http.StatusInternalServerError
 	} else {
-		responseMessageBody = body.Bytes()
+		responseMessageBody = body
 		dispatchInfo.ResponseBody = responseMessageBody
 	}
 	responseMessage := cehttp.NewMessage(response.Header, io.NopCloser(bytes.NewReader(responseMessageBody)))
@@ -363,10 +959,91 @@ func (d *Dispatcher) executeRequest(ctx context.Context, target duckv1.Addressab
 		// Response is a non event, discard it
 		response.Body.Close()
 		responseMessage.BodyReader.Close()
-		return ctx, nil, &dispatchInfo, nil
+		return nil, dispatchInfo, nil
 	}
 
-	return ctx, responseMessage, &dispatchInfo, nil
+	return responseMessage, dispatchInfo, nil
+}
+
+// maxPooledBufferSize is the capacity above which the buffers used to read
+// response bodies aren't pooled, so that a few large responses don't pin
+// memory.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// headerPool holds the headers of the requests of a delivery, which are only
+// used while sending it.
+var headerPool = sync.Pool{
+	New: func() interface{} {
+		return make(http.Header)
+	},
+}
+
+// getHeader returns an empty header, to be given back with putHeader once the
+// requests sent with it completed.
+func getHeader() http.Header {
+	return headerPool.Get().(http.Header)
+}
+
+func putHeader(h http.Header) {
+	clear(h)
+	headerPool.Put(h)
+}
+
+// readResponseBody reads the body into a pooled buffer and returns a copy of
+// exactly its size, nil for empty bodies, so that reading the mostly empty
+// response bodies doesn't allocate. Bodies larger than a positive limit fail
+// with ErrResponseBodyTooLarge.
+func readResponseBody(body io.Reader, limit int64) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+
+	var err error
+	if limit > 0 {
+		// read one more byte to tell bodies of exactly the limit apart
+		_, err = buf.ReadFrom(io.LimitReader(body, limit+1))
+		if err == nil && int64(buf.Len()) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrResponseBodyTooLarge, limit)
+		}
+	} else {
+		_, err = buf.ReadFrom(body)
+	}
+	if buf.Len() == 0 {
+		return nil, err
+	}
+	return bytes.Clone(buf.Bytes()), err
+}
+
+// wrapDeadLetter returns an event wrapping the original message and the reply
+// which couldn't be forwarded, to be sent to the dead letter sink.
+func wrapDeadLetter(ctx context.Context, original, failed binding.Message) (binding.Message, error) {
+	originalEvent, err := binding.ToEvent(ctx, original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert original message to event: %w", err)
+	}
+	failedEvent, err := binding.ToEvent(ctx, failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert reply to event: %w", err)
+	}
+
+	wrapped := event.New()
+	wrapped.SetID(failedEvent.ID())
+	wrapped.SetSource(failedEvent.Source())
+	wrapped.SetType(DeadLetterWrappedEventType)
+	if err := wrapped.SetData(event.ApplicationJSON, DeadLetterWrapper{Original: originalEvent, Failed: failedEvent}); err != nil {
+		return nil, fmt.Errorf("failed to set data: %w", err)
+	}
+	return binding.ToMessage(&wrapped), nil
 }
 
 func (d *Dispatcher) handleAutocreate(ctx context.Context, msg binding.Message, config *senderConfig) {
@@ -384,34 +1061,54 @@ func (d *Dispatcher) createRequest(ctx context.Context, message binding.Message,
 		return nil, fmt.Errorf("could not create http request: %w", err)
 	}
 
-	if err := cehttp.WriteRequest(ctx, message, request, transformers...); err != nil {
+	if f := d.formatFor(ctx, target, additionalHeaders.Get(eventingapis.KnNamespaceHeader)); f != nil {
+		if err := writeStructuredRequest(ctx, message, request, f, transformers...); err != nil {
+			return nil, fmt.Errorf("could not write message to request: %w", err)
+		}
+	} else if err := cehttp.WriteRequest(ctx, message, request, transformers...); err != nil {
 		return nil, fmt.Errorf("could not write message to request: %w", err)
 	}
 
-	for key, val := range additionalHeaders {
-		request.Header[key] = val
+	for key, values := range additionalHeaders {
+		if isEventHeader(key) && len(request.Header.Values(key)) > 0 {
+			// the headers of the event take precedence
+			continue
+		}
+		request.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	if err := signRequestFromContext(ctx, request); err != nil {
+		return nil, err
 	}
 
 	if oidcServiceAccount != nil {
-		if target.Audience != nil && *target.Audience != "" {
-			jwt, err := d.oidcTokenProvider.GetJWT(*oidcServiceAccount, *target.Audience)
-			if err != nil {
-				return nil, fmt.Errorf("could not get JWT: %w", err)
-			}
-			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+		if err := WithOIDCToken(d.oidcTokenProvider, *oidcServiceAccount)(request, target); err != nil {
+			return nil, err
 		}
 	}
 
 	return request, nil
 }
 
+// isEventHeader returns whether the header is rendered from the event.
+func isEventHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	return key == "Content-Type" || strings.HasPrefix(key, "Ce-")
+}
+
 // client is a wrapper around the http.Client, which provides methods for retries
 type client struct {
 	http.Client
 }
 
-func newClient(cfg eventingtls.ClientConfig, target duckv1.Addressable) (*client, error) {
-	c, err := getClientForAddressable(cfg, target)
+func (d *Dispatcher) newClient(target duckv1.Addressable, namespace string) (*client, error) {
+	if d.httpClient != nil {
+		return &client{
+			Client: *d.httpClient,
+		}, nil
+	}
+
+	c, err := getClientForAddressableInNamespace(d.clientConfig, target, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get http client for addressable: %w", err)
 	}
@@ -425,9 +1122,24 @@ func (c *client) Do(req *http.Request) (*http.Response, error) {
 	return c.Client.Do(req)
 }
 
-func (c *client) DoWithRetries(req *http.Request, retryConfig *RetryConfig) (*http.Response, error) {
+// retryStats are the statistics of a request sent with DoWithRetries.
+type retryStats struct {
+	// attempts is the number of requests sent.
+	attempts int
+	// deadlineExhausted is true if the retries were stopped by the deadline of
+	// the context of the request.
+	deadlineExhausted bool
+}
+
+// DoWithRetries sends the request, retrying it according to the retry config,
+// and returns the response together with the statistics of the requests sent.
+// If the context of the request has a deadline, the attempts time out at the
+// deadline, and the request isn't retried if the time left can't cover
+// another attempt.
+func (c *client) DoWithRetries(req *http.Request, retryConfig *RetryConfig) (*http.Response, retryStats, error) {
 	if retryConfig == nil {
-		return c.Do(req)
+		resp, err := c.Do(req)
+		return resp, retryStats{attempts: 1}, err
 	}
 
 	client := c.Client
@@ -440,28 +1152,112 @@ func (c *client) DoWithRetries(req *http.Request, retryConfig *RetryConfig) (*ht
 		}
 	}
 
+	backoff := generateBackoffFn(retryConfig)
+	if span := trace.FromContext(req.Context()); span != nil && span.IsRecordingEvents() {
+		backoff = annotateRetries(span, backoff)
+	}
+	backoff = recordBackoff(req.Context(), backoff)
+
+	checkRetry := retryConfig.CheckRetry
+	if !retryConfig.RetryPermanentErrors {
+		isPermanent := IsPermanentError
+		if retryConfig.RetryUnknownHost {
+			isPermanent = func(err error) bool {
+				return IsPermanentError(err) && !IsUnknownHostError(err)
+			}
+		}
+		checkRetry = skipPermanentErrors(checkRetry, isPermanent)
+	}
+	checkRetry = limitRetries(req.Context(), retryConfig.RetryMax, checkRetry)
+	budget := newDeadlineBudget(req.Context())
+	if budget != nil {
+		checkRetry = budget.limitToDeadline(retryConfig, checkRetry)
+	}
+
 	retryableClient := retryablehttp.Client{
 		HTTPClient:   &client,
 		RetryWaitMin: defaultRetryWaitMin,
 		RetryWaitMax: defaultRetryWaitMax,
 		RetryMax:     retryConfig.RetryMax,
-		CheckRetry:   retryablehttp.CheckRetry(retryConfig.CheckRetry),
-		Backoff:      generateBackoffFn(retryConfig),
+		CheckRetry:   retryablehttp.CheckRetry(checkRetry),
+		Backoff:      backoff,
 		ErrorHandler: func(resp *http.Response, err error, numTries int) (*http.Response, error) {
 			return resp, err
 		},
 	}
+	var stats retryStats
+	requestTimeout := client.Timeout
+	retryableClient.RequestLogHook = func(retryablehttp.Logger, *http.Request, int) {
+		stats.attempts++
+		if budget != nil {
+			// the client is a copy, so that the timeout can be set per attempt
+			client.Timeout = budget.startAttempt(requestTimeout)
+		}
+	}
 
 	retryableReq, err := retryablehttp.FromRequest(req)
 	if err != nil {
-		return nil, err
+		return nil, stats, err
 	}
 
-	return retryableClient.Do(retryableReq)
+	resp, err := retryableClient.Do(retryableReq)
+	if budget != nil {
+		stats.deadlineExhausted = budget.exhausted
+	}
+	return resp, stats, err
+}
+
+// annotateRetries adds a span event for every retry, with the status code of the
+// failed attempt, if any, and the backoff chosen.
+func annotateRetries(span *trace.Span, backoff retryablehttp.Backoff) retryablehttp.Backoff {
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		wait := backoff(min, max, attemptNum, resp)
+
+		attributes := []trace.Attribute{
+			trace.Int64Attribute("retry.attempt", int64(attemptNum+1)),
+			trace.Int64Attribute("retry.backoff_ms", wait.Milliseconds()),
+		}
+		if resp != nil {
+			attributes = append(attributes, trace.Int64Attribute("http.status_code", int64(resp.StatusCode)))
+		}
+		span.Annotate(attributes, "Retrying dispatch")
+
+		return wait
+	}
+}
+
+// withTransformer returns the transformers followed by the given transformer,
+// without modifying the transformers.
+func withTransformer(transformers binding.Transformers, t binding.Transformer) binding.Transformers {
+	return append(transformers[:len(transformers):len(transformers)], t)
 }
 
-// dispatchExecutionTransformer returns Transformers based on the specified destination and DispatchExecutionInfo
 func dispatchExecutionInfoTransformers(destination *apis.URL, dispatchExecutionInfo *DispatchInfo) binding.Transformers {
+	destination, httpResponseBody, ok := dispatchFailure(destination, dispatchExecutionInfo)
+	if !ok {
+		return nil
+	}
+
+	// Encodes response body as base64 for the resulting length.
+	bodyLen := len(httpResponseBody)
+	encodedLen := base64.StdEncoding.EncodedLen(bodyLen)
+	if encodedLen > attributes.KnativeErrorDataExtensionMaxLength {
+		encodedLen = attributes.KnativeErrorDataExtensionMaxLength
+	}
+	encodedBuf := make([]byte, encodedLen)
+	base64.StdEncoding.Encode(encodedBuf, httpResponseBody)
+
+	transformers := attributes.KnativeErrorTransformers(*destination.URL(), dispatchExecutionInfo.ResponseCode, string(encodedBuf[:encodedLen]))
+	if dispatchExecutionInfo.ErrorReason != "" {
+		transformers = append(transformers, attributes.KnativeErrorReasonTransformers(dispatchExecutionInfo.ErrorReason, dispatchExecutionInfo.Attempts)...)
+	}
+	return transformers
+}
+
+// dispatchFailure returns the sanitized destination which failed and its
+// response body. For the broker filter, they are the ones of the subscriber of
+// the trigger, and ok is false if they couldn't be read from its response.
+func dispatchFailure(destination *apis.URL, dispatchExecutionInfo *DispatchInfo) (*apis.URL, []byte, bool) {
 	if destination == nil {
 		destination = &apis.URL{}
 	}
@@ -473,24 +1269,49 @@ func dispatchExecutionInfoTransformers(destination *apis.URL, dispatchExecutionI
 
 		err := json.Unmarshal(dispatchExecutionInfo.ResponseBody, &errExtensionInfo)
 		if err != nil {
-			return nil
+			return nil, nil, false
 		}
 		destination = errExtensionInfo.ErrDestination
 		httpResponseBody = errExtensionInfo.ErrResponseBody
 	}
 
-	destination = sanitizeURL(destination)
-
-	// Encodes response body as base64 for the resulting length.
-	bodyLen := len(httpResponseBody)
-	encodedLen := base64.StdEncoding.EncodedLen(bodyLen)
-	if encodedLen > attributes.KnativeErrorDataExtensionMaxLength {
-		encodedLen = attributes.KnativeErrorDataExtensionMaxLength
+	if sanitized, err := sanitizeURL(destination); err == nil {
+		destination = sanitized
 	}
-	encodedBuf := make([]byte, encodedLen)
-	base64.StdEncoding.Encode(encodedBuf, httpResponseBody)
+	destination, _ = withoutUserinfo(destination)
+	return destination, httpResponseBody, true
+}
 
-	return attributes.KnativeErrorTransformers(*destination.URL(), dispatchExecutionInfo.ResponseCode, string(encodedBuf[:encodedLen]))
+// errUnexpectedResponse is returned when the destination responded with a non-2xx status code.
+var errUnexpectedResponse = errors.New("unexpected HTTP response")
+
+// errorReason classifies the error of a failed request.
+func errorReason(err error) attributes.KnativeErrorReason {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordHeaderErr tls.RecordHeaderError
+	var certVerificationErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+
+	switch {
+	case errors.Is(err, errUnexpectedResponse):
+		return attributes.KnativeErrorReasonNon2xx
+	case errors.Is(err, ErrConcurrencyLimitExceeded):
+		return attributes.KnativeErrorReasonCircuitOpen
+	case errors.As(err, &dnsErr):
+		return attributes.KnativeErrorReasonDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return attributes.KnativeErrorReasonConnectionRefused
+	case errors.As(err, &recordHeaderErr), errors.As(err, &certVerificationErr),
+		errors.As(err, &unknownAuthorityErr), errors.As(err, &certInvalidErr), errors.As(err, &hostnameErr):
+		return attributes.KnativeErrorReasonTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return attributes.KnativeErrorReasonTimeout
+	default:
+		return attributes.KnativeErrorReasonUnknown
+	}
 }
 
 // isFailure returns true if the status code is not a successful HTTP status.
@@ -499,29 +1320,114 @@ func isFailure(statusCode int) bool {
 		statusCode >= http.StatusMultipleChoices /* 300 */
 }
 
-func sanitizeAddressable(addressable *duckv1.Addressable) *duckv1.Addressable {
+// sanitizeAddressable returns a copy of the addressable with a sanitized URL,
+// without modifying the addressable.
+func sanitizeAddressable(addressable *duckv1.Addressable) (*duckv1.Addressable, error) {
 	if addressable == nil {
-		return nil
+		return nil, nil
 	}
 
-	addressable.URL = sanitizeURL(addressable.URL)
+	url, err := sanitizeURL(addressable.URL)
+	if err != nil {
+		return nil, err
+	}
 
-	return addressable
+	sanitized := *addressable
+	sanitized.URL = url
+	return &sanitized, nil
 }
 
-func sanitizeURL(url *apis.URL) *apis.URL {
+// sanitizeURL returns the URL with the http scheme, if it has no http or https
+// scheme, e.g. because only the host was given. The path, query and port of the
+// URL are preserved. The URL isn't modified.
+func sanitizeURL(url *apis.URL) (*apis.URL, error) {
 	if url == nil {
-		return nil
+		return nil, nil
 	}
 
-	if url.Scheme == "http" || url.Scheme == "https" {
+	if url.Scheme == "http" || url.Scheme == "https" || url.Scheme == SRVScheme {
 		// Already a URL with a known scheme.
-		return url
+		return url, nil
+	}
+
+	sanitized := *url
+	sanitized.Scheme = "http"
+	if sanitized.Host == "" {
+		// Without a scheme, the host is parsed as the start of the path, e.g.
+		// foo.bar/path, or as the scheme if it has a port, e.g. foo.bar:8080/path.
+		hostAndPath := url.Path
+		if url.Opaque != "" {
+			hostAndPath = url.Scheme + ":" + url.Opaque
+		}
+		host, path, hasPath := strings.Cut(hostAndPath, "/")
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			user, err := parseUserinfo(host[:i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to sanitize URL %q: %w", url.URL().Redacted(), err)
+			}
+			sanitized.User = user
+			host = host[i+1:]
+		}
+		sanitized.Host = host
+		sanitized.Path = ""
+		if hasPath {
+			sanitized.Path = "/" + path
+		}
+		sanitized.Opaque = ""
+		sanitized.RawPath = ""
+	}
+	if sanitized.Host == "" {
+		return nil, fmt.Errorf("URL %q has no host", url.URL().Redacted())
+	}
+	sanitized.Host = bracketIPv6(sanitized.Host)
+	if sanitized.Path == "" {
+		sanitized.Path = "/"
+	}
+
+	if _, err := apis.ParseURL(sanitized.String()); err != nil {
+		return nil, fmt.Errorf("failed to sanitize URL %q: %w", url.URL().Redacted(), err)
+	}
+	return &sanitized, nil
+}
+
+// parseUserinfo parses the userinfo of a URL, username[:password], whose
+// username and password may be escaped.
+func parseUserinfo(userinfo string) (*url.Userinfo, error) {
+	username, password, hasPassword := strings.Cut(userinfo, ":")
+	username, err := url.PathUnescape(username)
+	if err != nil {
+		return nil, fmt.Errorf("invalid userinfo: %w", err)
+	}
+	if !hasPassword {
+		return url.User(username), nil
+	}
+	password, err = url.PathUnescape(password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid userinfo: %w", err)
 	}
+	return url.UserPassword(username, password), nil
+}
+
+// withoutUserinfo returns a copy of the URL without userinfo, together with
+// the value of the Authorization header carrying its credentials with basic
+// authentication, empty if the URL has no userinfo.
+func withoutUserinfo(u *apis.URL) (*apis.URL, string) {
+	if u == nil || u.User == nil {
+		return u, ""
+	}
+	password, _ := u.User.Password()
+	credentials := u.User.Username() + ":" + password
+
+	stripped := *u
+	stripped.User = nil
+	return &stripped, "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
 
-	return &apis.URL{
-		Scheme: "http",
-		Host:   url.Host,
-		Path:   "/",
+// bracketIPv6 brackets a bare IPv6 literal, so that it's not mistaken for a host
+// with a port. Hosts with a port have to be bracketed already, e.g. [::1]:8080.
+func bracketIPv6(host string) string {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "[" + host + "]"
 	}
+	return host
 }
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"golang.org/x/time/rate"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/eventingtls/eventingtlstesting"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+// BenchmarkDispatcherSendMessage measures the dispatch hot path, from writing
+// the message to the request to reading the response, against a local server.
+//
+// Run it with test/microbenchmarks-run.sh, or:
+//
+//	go test -bench=BenchmarkDispatcherSendMessage -benchmem -run='^$' ./pkg/kncloudevents/
+func BenchmarkDispatcherSendMessage(b *testing.B) {
+	encodings := []struct {
+		name string
+		ctx  func(context.Context) context.Context
+	}{
+		{name: "binary", ctx: binding.WithForceBinary},
+		{name: "structured", ctx: binding.WithForceStructured},
+	}
+	payloads := []struct {
+		name string
+		data []byte
+	}{
+		{name: "small", data: []byte(`{"hello":"world"}`)},
+		{name: "1MB", data: largePayload(1 << 20)},
+	}
+	transports := []struct {
+		name  string
+		start func(b *testing.B, handler http.Handler) duckv1.Addressable
+	}{
+		{name: "plaintext", start: startPlaintextServer},
+		{name: "tls", start: startTLSServer},
+	}
+	retries := []struct {
+		name string
+		// failEvery makes the server fail every n-th request, 0 never fails.
+		failEvery   int64
+		retryConfig *kncloudevents.RetryConfig
+	}{
+		{name: "no-retries"},
+		{
+			name:      "one-retry",
+			failEvery: 2,
+			retryConfig: &kncloudevents.RetryConfig{
+				RetryMax:   1,
+				CheckRetry: kncloudevents.SelectiveRetry,
+				Backoff: func(int, *http.Response) time.Duration {
+					return 0
+				},
+			},
+		},
+	}
+
+	for _, encoding := range encodings {
+		for _, payload := range payloads {
+			for _, transport := range transports {
+				for _, retry := range retries {
+					name := encoding.name + "/" + payload.name + "/" + transport.name + "/" + retry.name
+					b.Run(name, func(b *testing.B) {
+						ctx, _ := rectesting.SetupFakeContext(b)
+						dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+						destination := transport.start(b, failingHandler(retry.failEvery))
+
+						event := test.FullEvent()
+						if err := event.SetData(cloudevents.ApplicationJSON, payload.data); err != nil {
+							b.Fatal(err)
+						}
+						message := binding.ToMessage(&event)
+						ctx = encoding.ctx(ctx)
+
+						var options []kncloudevents.SendOption
+						if retry.retryConfig != nil {
+							options = append(options, kncloudevents.WithRetryConfig(retry.retryConfig))
+						}
+
+						b.SetBytes(int64(len(payload.data)))
+						b.ReportAllocs()
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							if _, err := dispatcher.SendMessage(ctx, message, destination, options...); err != nil {
+								b.Fatal(err)
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkDispatcherSendMessageAtRate measures the garbage collection
+// pressure of the dispatch hot path at 10k events/s, with small binary events
+// carrying pass-through headers and answered with pass-through headers.
+//
+//	go test -bench=BenchmarkDispatcherSendMessageAtRate -benchmem -run='^$' ./pkg/kncloudevents/
+func BenchmarkDispatcherSendMessageAtRate(b *testing.B) {
+	const (
+		eventsPerSecond = 10_000
+		// senders per CPU, enough to sustain the rate with the latency of a
+		// local request
+		parallelism = 16
+	)
+
+	ctx, _ := rectesting.SetupFakeContext(b)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+	destination := startPlaintextServer(b, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	event := test.FullEvent()
+	header := http.Header{
+		"Knative-Namespace": {"ns"},
+		"X-Request-Id":      {"1234"},
+	}
+	limiter := rate.NewLimiter(eventsPerSecond, 1)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	b.SetParallelism(parallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := limiter.Wait(ctx); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := dispatcher.SendMessage(ctx, binding.ToMessage(&event), destination, kncloudevents.WithHeader(header)); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	elapsed := time.Since(start)
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "events/s")
+	b.ReportMetric(float64(after.NumGC-before.NumGC)*eventsPerSecond/float64(b.N), "GCs/10k-events")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+// failingHandler drains the request and answers with 202, or with 503 for
+// every n-th request.
+func failingHandler(n int64) http.Handler {
+	var requests atomic.Int64
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if n > 0 && requests.Add(1)%n == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func startPlaintextServer(b *testing.B, handler http.Handler) duckv1.Addressable {
+	server := httptest.NewServer(handler)
+	b.Cleanup(server.Close)
+
+	url, err := apis.ParseURL(server.URL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return duckv1.Addressable{URL: url}
+}
+
+func startTLSServer(b *testing.B, handler http.Handler) duckv1.Addressable {
+	server := eventingtlstesting.StartTLSServer(b, handler)
+	// measure the steady state, not a handshake per request
+	server.Config.SetKeepAlivesEnabled(true)
+
+	url, err := apis.ParseURL(server.URL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ca := server.CA()
+	destination := duckv1.Addressable{URL: url, CACerts: &ca}
+	b.Cleanup(func() { kncloudevents.DeleteAddressableHandler(destination) })
+	return destination
+}
+
+func largePayload(size int) []byte {
+	// a JSON string, so that the payload is valid for structured mode
+	payload := bytes.Repeat([]byte("a"), size)
+	payload[0] = '"'
+	payload[size-1] = '"'
+	return payload
+}
@@ -19,26 +19,40 @@ package kncloudevents_test
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/binding"
 	"github.com/cloudevents/sdk-go/v2/binding/transformer"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
 	"github.com/cloudevents/sdk-go/v2/test"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/rest"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
 	"knative.dev/pkg/injection"
 	rectesting "knative.dev/pkg/reconciler/testing"
 
@@ -49,9 +63,11 @@ import (
 	_ "knative.dev/pkg/system/testing"
 
 	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventfilter/attributes"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/eventingtls/eventingtlstesting"
 	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
 	"knative.dev/eventing/pkg/utils"
 )
 
@@ -376,18 +392,20 @@ func TestSendEvent(t *testing.T) {
 			},
 			expectedDeadLetterRequest: &requestValidation{
 				Headers: map[string][]string{
-					"x-request-id":        {"id123"},
-					"knative-1":           {"knative-1-value"},
-					"knative-2":           {"knative-2-value"},
-					"traceparent":         {"ignored-value-header"},
-					"ce-abc":              {`"ce-abc-value"`},
-					"ce-knativeerrorcode": {strconv.Itoa(http.StatusBadRequest)},
-					"ce-knativeerrordata": {base64.StdEncoding.EncodeToString([]byte("destination-response"))},
-					"ce-id":               {"ignored-value-header"},
-					"ce-time":             {"2002-10-02T15:00:00Z"},
-					"ce-source":           {testCeSource},
-					"ce-type":             {testCeType},
-					"ce-specversion":      {cloudevents.VersionV1},
+					"x-request-id":            {"id123"},
+					"knative-1":               {"knative-1-value"},
+					"knative-2":               {"knative-2-value"},
+					"traceparent":             {"ignored-value-header"},
+					"ce-abc":                  {`"ce-abc-value"`},
+					"ce-knativeerrorcode":     {strconv.Itoa(http.StatusBadRequest)},
+					"ce-knativeerrorreason":   {"non-2xx"},
+					"ce-knativeerrorattempts": {"1"},
+					"ce-knativeerrordata":     {base64.StdEncoding.EncodeToString([]byte("destination-response"))},
+					"ce-id":                   {"ignored-value-header"},
+					"ce-time":                 {"2002-10-02T15:00:00Z"},
+					"ce-source":               {testCeSource},
+					"ce-type":                 {testCeType},
+					"ce-specversion":          {cloudevents.VersionV1},
 				},
 				Body: `"destination"`,
 			},
@@ -444,18 +462,20 @@ func TestSendEvent(t *testing.T) {
 			},
 			expectedDeadLetterRequest: &requestValidation{
 				Headers: map[string][]string{
-					"x-request-id":        {"id123"},
-					"knative-1":           {"knative-1-value"},
-					"knative-2":           {"knative-2-value"},
-					"traceparent":         {"ignored-value-header"},
-					"ce-abc":              {`"ce-abc-value"`},
-					"ce-id":               {"ignored-value-header"},
-					"ce-knativeerrorcode": {strconv.Itoa(http.StatusBadRequest)},
-					"ce-knativeerrordata": {base64.StdEncoding.EncodeToString([]byte("destination-response"))},
-					"ce-time":             {"2002-10-02T15:00:00Z"},
-					"ce-source":           {testCeSource},
-					"ce-type":             {testCeType},
-					"ce-specversion":      {cloudevents.VersionV1},
+					"x-request-id":            {"id123"},
+					"knative-1":               {"knative-1-value"},
+					"knative-2":               {"knative-2-value"},
+					"traceparent":             {"ignored-value-header"},
+					"ce-abc":                  {`"ce-abc-value"`},
+					"ce-id":                   {"ignored-value-header"},
+					"ce-knativeerrorcode":     {strconv.Itoa(http.StatusBadRequest)},
+					"ce-knativeerrorreason":   {"non-2xx"},
+					"ce-knativeerrorattempts": {"1"},
+					"ce-knativeerrordata":     {base64.StdEncoding.EncodeToString([]byte("destination-response"))},
+					"ce-time":                 {"2002-10-02T15:00:00Z"},
+					"ce-source":               {testCeSource},
+					"ce-type":                 {testCeType},
+					"ce-specversion":          {cloudevents.VersionV1},
 				},
 				Body: `"destination"`,
 			},
@@ -535,19 +555,21 @@ func TestSendEvent(t *testing.T) {
 			},
 			expectedDeadLetterRequest: &requestValidation{
 				Headers: map[string][]string{
-					"x-request-id":        {"altered-id"},
-					"knative-1":           {"new-knative-1-value"},
-					"traceparent":         {"ignored-value-header"},
-					"ce-abc":              {`"ce-abc-value"`},
-					"ce-id":               {"ignored-value-header"},
-					"ce-knativeerrorcode": {strconv.Itoa(http.StatusBadRequest)},
-					"ce-knativeerrordata": {base64.StdEncoding.EncodeToString([]byte("reply-response-body"))},
-					"ce-time":             {"2002-10-02T15:00:00Z"},
-					"ce-source":           {testCeSource},
-					"ce-type":             {testCeType},
-					"ce-specversion":      {cloudevents.VersionV1},
+					"x-request-id":            {"altered-id"},
+					"knative-1":               {"new-knative-1-value"},
+					"traceparent":             {"ignored-value-header"},
+					"ce-abc":                  {`"new-ce-abc-value"`},
+					"ce-id":                   {"ignored-value-header"},
+					"ce-knativeerrorcode":     {strconv.Itoa(http.StatusBadRequest)},
+					"ce-knativeerrorreason":   {"non-2xx"},
+					"ce-knativeerrorattempts": {"1"},
+					"ce-knativeerrordata":     {base64.StdEncoding.EncodeToString([]byte("reply-response-body"))},
+					"ce-time":                 {"2002-10-02T15:00:00Z"},
+					"ce-source":               {testCeSource},
+					"ce-type":                 {testCeType},
+					"ce-specversion":          {cloudevents.VersionV1},
 				},
-				Body: `"destination"`,
+				Body: "destination-response",
 			},
 			fakeDeadLetterResponse: &http.Response{
 				StatusCode: http.StatusAccepted,
@@ -602,19 +624,21 @@ func TestSendEvent(t *testing.T) {
 			},
 			expectedDeadLetterRequest: &requestValidation{
 				Headers: map[string][]string{
-					"x-request-id":        {"altered-id"},
-					"knative-1":           {"new-knative-1-value"},
-					"traceparent":         {"ignored-value-header"},
-					"ce-abc":              {`"ce-abc-value"`},
-					"ce-id":               {"ignored-value-header"},
-					"ce-knativeerrorcode": {strconv.Itoa(http.StatusBadRequest)},
-					"ce-knativeerrordata": {base64.StdEncoding.EncodeToString([]byte("reply-response"))},
-					"ce-time":             {"2002-10-02T15:00:00Z"},
-					"ce-source":           {testCeSource},
-					"ce-type":             {testCeType},
-					"ce-specversion":      {cloudevents.VersionV1},
+					"x-request-id":            {"altered-id"},
+					"knative-1":               {"new-knative-1-value"},
+					"traceparent":             {"ignored-value-header"},
+					"ce-abc":                  {`"new-ce-abc-value"`},
+					"ce-id":                   {"ignored-value-header"},
+					"ce-knativeerrorcode":     {strconv.Itoa(http.StatusBadRequest)},
+					"ce-knativeerrorreason":   {"non-2xx"},
+					"ce-knativeerrorattempts": {"1"},
+					"ce-knativeerrordata":     {base64.StdEncoding.EncodeToString([]byte("reply-response"))},
+					"ce-time":                 {"2002-10-02T15:00:00Z"},
+					"ce-source":               {testCeSource},
+					"ce-type":                 {testCeType},
+					"ce-specversion":          {cloudevents.VersionV1},
 				},
-				Body: `"destination"`,
+				Body: "destination-response",
 			},
 			fakeResponse: &http.Response{
 				StatusCode: http.StatusAccepted,
@@ -684,18 +708,20 @@ func TestSendEvent(t *testing.T) {
 			},
 			expectedDeadLetterRequest: &requestValidation{
 				Headers: map[string][]string{
-					"x-request-id":        {"id123"},
-					"knative-1":           {"knative-1-value"},
-					"knative-2":           {"knative-2-value"},
-					"traceparent":         {"ignored-value-header"},
-					"ce-abc":              {`"ce-abc-value"`},
-					"ce-knativeerrorcode": {strconv.Itoa(http.StatusBadRequest)},
-					"ce-knativeerrordata": {base64.StdEncoding.EncodeToString([]byte("destination\n multi-line\n response"))},
-					"ce-id":               {"ignored-value-header"},
-					"ce-time":             {"2002-10-02T15:00:00Z"},
-					"ce-source":           {testCeSource},
-					"ce-type":             {testCeType},
-					"ce-specversion":      {cloudevents.VersionV1},
+					"x-request-id":            {"id123"},
+					"knative-1":               {"knative-1-value"},
+					"knative-2":               {"knative-2-value"},
+					"traceparent":             {"ignored-value-header"},
+					"ce-abc":                  {`"ce-abc-value"`},
+					"ce-knativeerrorcode":     {strconv.Itoa(http.StatusBadRequest)},
+					"ce-knativeerrorreason":   {"non-2xx"},
+					"ce-knativeerrorattempts": {"1"},
+					"ce-knativeerrordata":     {base64.StdEncoding.EncodeToString([]byte("destination\n multi-line\n response"))},
+					"ce-id":                   {"ignored-value-header"},
+					"ce-time":                 {"2002-10-02T15:00:00Z"},
+					"ce-source":               {testCeSource},
+					"ce-type":                 {testCeType},
+					"ce-specversion":          {cloudevents.VersionV1},
 				},
 				Body: `"destination"`,
 			},
@@ -1198,3 +1224,1024 @@ func canonicalizeHeaders(rvs ...requestValidation) {
 		}
 	}
 }
+
+func TestDispatchWithFakeClient(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+
+	replyEvent := test.FullEvent()
+	replyEvent.SetID("reply")
+
+	maxRetryAfter := time.Millisecond
+	retryConfig := &kncloudevents.RetryConfig{
+		RetryMax:              2,
+		CheckRetry:            kncloudevents.SelectiveRetry,
+		Backoff:               func(int, *http.Response) time.Duration { return time.Millisecond },
+		RetryAfterMaxDuration: &maxRetryAfter,
+	}
+
+	tests := []struct {
+		name           string
+		script         func(c *kncloudeventstest.FakeClient)
+		wantErr        bool
+		wantCode       int
+		wantRequests   map[string]int
+		wantReplyEvent bool
+	}{{
+		name: "retried until accepted",
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(),
+				kncloudeventstest.RetryAfter(http.StatusTooManyRequests, time.Second),
+				kncloudeventstest.Error(errors.New("connection reset")),
+				kncloudeventstest.Status(http.StatusAccepted))
+		},
+		wantCode: http.StatusAccepted,
+		wantRequests: map[string]int{
+			destination.URL.String(): 3,
+		},
+	}, {
+		name: "dead lettered after retries",
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+		},
+		wantCode: http.StatusOK,
+		wantRequests: map[string]int{
+			destination.URL.String(): 3,
+			dls.URL.String():         1,
+		},
+	}, {
+		name: "reply forwarded",
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(), kncloudeventstest.Reply(replyEvent))
+		},
+		wantCode: http.StatusOK,
+		wantRequests: map[string]int{
+			destination.URL.String(): 1,
+			reply.URL.String():       1,
+		},
+		wantReplyEvent: true,
+	}, {
+		name: "dead letter sink fails",
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.ReturnStatus(http.StatusBadRequest)
+		},
+		wantErr: true,
+		wantRequests: map[string]int{
+			destination.URL.String(): 1,
+			dls.URL.String():         1,
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient()
+			tc.script(fakeClient)
+
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination,
+				kncloudevents.WithReply(&reply),
+				kncloudevents.WithDeadLetterSink(&dls),
+				kncloudevents.WithRetryConfig(retryConfig))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SendEvent() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && info.ResponseCode != tc.wantCode {
+				t.Errorf("Got response code %d, want %d", info.ResponseCode, tc.wantCode)
+			}
+
+			requests := fakeClient.Requests()
+			for url, want := range tc.wantRequests {
+				if got := len(fakeClient.RequestsTo(url)); got != want {
+					t.Errorf("Got %d requests to %s, want %d", got, url, want)
+				}
+			}
+			if len(requests) == 0 || requests[0].Header.Get("Prefer") != "reply" {
+				t.Error("Want the request to the destination to prefer a reply")
+			}
+
+			if tc.wantReplyEvent {
+				replied := fakeClient.RequestsTo(reply.URL.String())[0]
+				if got := replied.Header.Get("Ce-Id"); got != replyEvent.ID() {
+					t.Errorf("Got reply event with id %q, want %q", got, replyEvent.ID())
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchWithDeliveryBudget(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+
+	replyEvent := test.FullEvent()
+	replyEvent.SetID("reply")
+
+	retryConfig := &kncloudevents.RetryConfig{
+		RetryMax:   3,
+		CheckRetry: kncloudevents.SelectiveRetry,
+		Backoff:    func(int, *http.Response) time.Duration { return time.Millisecond },
+	}
+
+	tests := []struct {
+		name         string
+		budget       kncloudevents.DeliveryBudget
+		script       func(c *kncloudeventstest.FakeClient)
+		wantErr      string
+		wantRequests map[string]int
+	}{{
+		name:   "attempts left for the dead letter sink",
+		budget: kncloudevents.DeliveryBudget{MaxAttempts: 5},
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+		},
+		wantRequests: map[string]int{
+			destination.URL.String(): 4,
+			dls.URL.String():         1,
+		},
+	}, {
+		name:   "attempts exhausted by the destination",
+		budget: kncloudevents.DeliveryBudget{MaxAttempts: 3},
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+		},
+		wantErr: kncloudevents.ErrDeliveryBudgetExhausted.Error(),
+		wantRequests: map[string]int{
+			destination.URL.String(): 3,
+			dls.URL.String():         0,
+		},
+	}, {
+		name:   "attempts shared with the reply",
+		budget: kncloudevents.DeliveryBudget{MaxAttempts: 4},
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(),
+				kncloudeventstest.Status(http.StatusServiceUnavailable),
+				kncloudeventstest.Reply(replyEvent))
+			c.On(reply.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+		},
+		wantErr: kncloudevents.ErrDeliveryBudgetExhausted.Error(),
+		wantRequests: map[string]int{
+			destination.URL.String(): 2,
+			reply.URL.String():       2,
+			dls.URL.String():         0,
+		},
+	}, {
+		name:   "timeout",
+		budget: kncloudevents.DeliveryBudget{Timeout: 50 * time.Millisecond},
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.Return(kncloudeventstest.Status(http.StatusAccepted).Delayed(time.Minute))
+		},
+		wantErr: context.DeadlineExceeded.Error(),
+		wantRequests: map[string]int{
+			destination.URL.String(): 1,
+			dls.URL.String():         1,
+		},
+	}, {
+		name:   "no budget",
+		budget: kncloudevents.DeliveryBudget{},
+		script: func(c *kncloudeventstest.FakeClient) {
+			c.On(destination.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+		},
+		wantRequests: map[string]int{
+			destination.URL.String(): 4,
+			dls.URL.String():         1,
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient()
+			tc.script(fakeClient)
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			start := time.Now()
+			_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination,
+				kncloudevents.WithReply(&reply),
+				kncloudevents.WithDeadLetterSink(&dls),
+				kncloudevents.WithRetryConfig(retryConfig),
+				kncloudevents.WithDeliveryBudget(tc.budget))
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+			} else {
+				require.Nil(t, err)
+			}
+			if tc.budget.Timeout > 0 {
+				require.Less(t, time.Since(start), 10*tc.budget.Timeout)
+			}
+
+			for url, want := range tc.wantRequests {
+				require.Len(t, fakeClient.RequestsTo(url), want, url)
+			}
+		})
+	}
+}
+
+func TestDispatchWithRedirects(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	var otherHostRequests atomic.Int32
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		otherHostRequests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(otherHost.Close)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same-host":
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+		case "/other-host":
+			http.Redirect(w, r, otherHost.URL, http.StatusTemporaryRedirect)
+		case "/final":
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	destination := func(path string) duckv1.Addressable {
+		url, err := apis.ParseURL(server.URL + path)
+		require.Nil(t, err)
+		t.Cleanup(func() { kncloudevents.DeleteAddressableHandler(duckv1.Addressable{URL: url}) })
+		return duckv1.Addressable{URL: url}
+	}
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination("/same-host"))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+	require.Equal(t, server.URL+"/final", info.RedirectedTo)
+
+	info, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination("/other-host"))
+	require.Error(t, err)
+	require.Equal(t, http.StatusTemporaryRedirect, info.ResponseCode)
+	require.Empty(t, info.RedirectedTo)
+	require.Zero(t, otherHostRequests.Load())
+}
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func TestDispatchAnnotatesRetries(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	t.Cleanup(func() { trace.UnregisterExporter(recorder) })
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().On(destination.URL.String(),
+		kncloudeventstest.Status(http.StatusServiceUnavailable),
+		kncloudeventstest.Error(errors.New("connection reset")),
+		kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	ctx, parent := trace.StartSpan(ctx, "parent", trace.WithSampler(trace.AlwaysSample()))
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithRetryConfig(&kncloudevents.RetryConfig{
+		RetryMax:   2,
+		CheckRetry: kncloudevents.SelectiveRetry,
+		Backoff:    func(int, *http.Response) time.Duration { return time.Millisecond },
+	}))
+	parent.End()
+	require.Nil(t, err)
+	require.Equal(t, parent.SpanContext().TraceID, info.SpanContext.TraceID)
+	require.True(t, info.SpanContext.IsSampled())
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var annotations []trace.Annotation
+	for _, s := range recorder.spans {
+		if s.SpanContext == info.SpanContext {
+			annotations = s.Annotations
+		}
+	}
+	require.Len(t, annotations, 2)
+	require.Equal(t, "Retrying dispatch", annotations[0].Message)
+	require.Equal(t, map[string]interface{}{
+		"retry.attempt":    int64(1),
+		"retry.backoff_ms": int64(1),
+		"http.status_code": int64(http.StatusServiceUnavailable),
+	}, annotations[0].Attributes)
+	// the second attempt failed without response
+	require.Equal(t, map[string]interface{}{
+		"retry.attempt":    int64(2),
+		"retry.backoff_ms": int64(1),
+	}, annotations[1].Attributes)
+}
+
+func TestDispatchWithFilter(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	event := test.FullEvent()
+
+	tests := []struct {
+		name         string
+		filter       kncloudevents.SendOption
+		wantErr      bool
+		wantFiltered bool
+	}{{
+		name: "passing filter",
+		filter: kncloudevents.WithFilter(func(e cloudevents.Event) (bool, error) {
+			return e.ID() == event.ID(), nil
+		}),
+	}, {
+		name: "failing filter",
+		filter: kncloudevents.WithFilter(func(cloudevents.Event) (bool, error) {
+			return false, nil
+		}),
+		wantFiltered: true,
+	}, {
+		name: "filter error",
+		filter: kncloudevents.WithFilter(func(cloudevents.Event) (bool, error) {
+			return false, errors.New("boom")
+		}),
+		wantErr: true,
+	}, {
+		name:   "passing event filter",
+		filter: kncloudevents.WithEventFilter(attributes.NewAttributesFilter(map[string]string{"type": event.Type()})),
+	}, {
+		name:         "failing event filter",
+		filter:       kncloudevents.WithEventFilter(attributes.NewAttributesFilter(map[string]string{"type": "other"})),
+		wantFiltered: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			// a message which can only be read once, as received by an ingress
+			body, err := event.MarshalJSON()
+			require.Nil(t, err)
+			header := http.Header{"Content-Type": []string{cloudevents.ApplicationCloudEventsJSON}}
+			message := cehttp.NewMessage(header, io.NopCloser(bytes.NewReader(body)))
+
+			info, err := dispatcher.SendMessage(ctx, message, destination, tc.filter)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SendMessage() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				require.Empty(t, fakeClient.Requests())
+				return
+			}
+
+			require.Equal(t, tc.wantFiltered, info.Filtered)
+			if tc.wantFiltered {
+				require.Equal(t, kncloudevents.NoResponse, info.ResponseCode)
+				require.Empty(t, fakeClient.Requests())
+				return
+			}
+
+			require.Equal(t, http.StatusAccepted, info.ResponseCode)
+			requests := fakeClient.RequestsTo(destination.URL.String())
+			require.Len(t, requests, 1)
+			require.Equal(t, event.ID(), requests[0].Header.Get("Ce-Id"))
+		})
+	}
+}
+
+func TestDispatchWithSequencerAndPartitionKeyPropagation(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+
+	event := test.FullEvent()
+	event.SetExtension(kncloudevents.DefaultPartitionKeyExtension, "key")
+	replyEvent := test.MinEvent()
+	replyEvent.SetSource("reply-source")
+
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Reply(replyEvent), kncloudeventstest.Reply(replyEvent)).
+		On(reply.URL.String(), kncloudeventstest.Status(http.StatusAccepted), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	sequencer := kncloudevents.NewSequencer()
+
+	for i := 0; i < 2; i++ {
+		_, err := dispatcher.SendEvent(ctx, event, destination,
+			kncloudevents.WithReply(&reply),
+			kncloudevents.WithSequencer(sequencer),
+			kncloudevents.WithPartitionKeyPropagation())
+		require.Nil(t, err)
+	}
+
+	sent := fakeClient.RequestsTo(destination.URL.String())
+	require.Len(t, sent, 2)
+	require.Equal(t, "00000000000000000001", sent[0].Header.Get("Ce-Sequence"))
+	require.Equal(t, "00000000000000000002", sent[1].Header.Get("Ce-Sequence"))
+
+	// replies get the partition key of the event, and are sequenced by their own source
+	replies := fakeClient.RequestsTo(reply.URL.String())
+	require.Len(t, replies, 2)
+	for i, r := range replies {
+		require.Equal(t, "key", r.Header.Get("Ce-Partitionkey"))
+		require.Equal(t, fmt.Sprintf("%020d", i+1), r.Header.Get("Ce-Sequence"))
+	}
+}
+
+func TestDispatchWithReplyDispatchExtensions(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().
+				On(destination.URL.String(), kncloudeventstest.Reply(test.MinEvent())).
+				On(reply.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			opts := []kncloudevents.SendOption{kncloudevents.WithReply(&reply)}
+			if enabled {
+				opts = append(opts, kncloudevents.WithReplyDispatchExtensions())
+			}
+			_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, opts...)
+			require.Nil(t, err)
+
+			sent := fakeClient.RequestsTo(destination.URL.String())
+			require.Len(t, sent, 1)
+			require.Empty(t, sent[0].Header.Get("Ce-Knativedispatchdest"))
+
+			replies := fakeClient.RequestsTo(reply.URL.String())
+			require.Len(t, replies, 1)
+			if !enabled {
+				require.Empty(t, replies[0].Header.Get("Ce-Knativedispatchdest"))
+				return
+			}
+			require.Equal(t, destination.URL.String(), replies[0].Header.Get("Ce-Knativedispatchdest"))
+			require.Equal(t, strconv.Itoa(http.StatusOK), replies[0].Header.Get("Ce-Knativedispatchcode"))
+			latency, err := strconv.Atoi(replies[0].Header.Get("Ce-Knativedispatchlatency"))
+			require.Nil(t, err)
+			require.GreaterOrEqual(t, latency, 0)
+		})
+	}
+}
+
+func TestDispatchWithReplyDispatchExtensionsWithoutUserinfo(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	destination.URL.User = url.UserPassword("user", "secret")
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On("http://destination.example.com", kncloudeventstest.Reply(test.MinEvent())).
+		On(reply.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithReply(&reply), kncloudevents.WithReplyDispatchExtensions())
+	require.Nil(t, err)
+
+	replies := fakeClient.RequestsTo(reply.URL.String())
+	require.Len(t, replies, 1)
+	require.Equal(t, "http://destination.example.com", replies[0].Header.Get("Ce-Knativedispatchdest"))
+}
+
+func TestDispatchDoesNotRetryPermanentErrors(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("typo.example.com")}
+	unknownAuthority := x509.UnknownAuthorityError{}
+	nxdomain := &net.DNSError{Name: "typo.example.com", IsNotFound: true}
+
+	tests := []struct {
+		name                 string
+		err                  error
+		retryPermanentErrors bool
+		retryUnknownHost     bool
+		wantAttempts         int
+	}{{
+		name:         "permanent error",
+		err:          unknownAuthority,
+		wantAttempts: 1,
+	}, {
+		name:                 "permanent error retried",
+		err:                  unknownAuthority,
+		retryPermanentErrors: true,
+		wantAttempts:         4,
+	}, {
+		name:         "unknown host",
+		err:          nxdomain,
+		wantAttempts: 1,
+	}, {
+		name:             "unknown host retried",
+		err:              nxdomain,
+		retryUnknownHost: true,
+		wantAttempts:     4,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().On(destination.URL.String(), kncloudeventstest.Error(tc.err))
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			retryConfig := kncloudevents.RetryConfig{
+				RetryMax:   3,
+				CheckRetry: kncloudevents.SelectiveRetry,
+				Backoff: func(int, *http.Response) time.Duration {
+					return time.Millisecond
+				},
+				RetryPermanentErrors: tc.retryPermanentErrors,
+				RetryUnknownHost:     tc.retryUnknownHost,
+			}
+			info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithRetryConfig(&retryConfig))
+			require.NotNil(t, err)
+
+			require.Len(t, fakeClient.RequestsTo(destination.URL.String()), tc.wantAttempts)
+			require.Equal(t, tc.wantAttempts, info.Attempts)
+		})
+	}
+}
+
+func TestDispatchWithDeadLetterPayload(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+
+	event := test.FullEvent()
+	replyEvent := test.MinEvent()
+	replyEvent.SetID("reply-id")
+
+	tests := []struct {
+		name    string
+		options []kncloudevents.SendOption
+		wantID  string
+		wrapped bool
+	}{{
+		name:   "default",
+		wantID: replyEvent.ID(),
+	}, {
+		name:    "failed message",
+		options: []kncloudevents.SendOption{kncloudevents.WithDeadLetterPayload(kncloudevents.DeadLetterPayloadFailedMessage)},
+		wantID:  replyEvent.ID(),
+	}, {
+		name:    "original",
+		options: []kncloudevents.SendOption{kncloudevents.WithDeadLetterPayload(kncloudevents.DeadLetterPayloadOriginal)},
+		wantID:  event.ID(),
+	}, {
+		name:    "both wrapped",
+		options: []kncloudevents.SendOption{kncloudevents.WithDeadLetterPayload(kncloudevents.DeadLetterPayloadBothWrapped)},
+		wantID:  replyEvent.ID(),
+		wrapped: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().
+				On(destination.URL.String(), kncloudeventstest.Reply(replyEvent)).
+				On(reply.URL.String(), kncloudeventstest.Status(http.StatusInternalServerError)).
+				On(dls.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			// a message which can only be read once, as received by an ingress
+			body, err := event.MarshalJSON()
+			require.Nil(t, err)
+			header := http.Header{"Content-Type": []string{cloudevents.ApplicationCloudEventsJSON}}
+			message := cehttp.NewMessage(header, io.NopCloser(bytes.NewReader(body)))
+
+			options := append([]kncloudevents.SendOption{kncloudevents.WithReply(&reply), kncloudevents.WithDeadLetterSink(&dls)}, tc.options...)
+			_, err = dispatcher.SendMessage(ctx, message, destination, options...)
+			require.Nil(t, err)
+
+			deadLettered := fakeClient.RequestsTo(dls.URL.String())
+			require.Len(t, deadLettered, 1)
+			require.Equal(t, tc.wantID, deadLettered[0].Header.Get("Ce-Id"))
+			require.Equal(t, strconv.Itoa(http.StatusInternalServerError), deadLettered[0].Header.Get("Ce-Knativeerrorcode"))
+
+			if !tc.wrapped {
+				return
+			}
+			require.Equal(t, kncloudevents.DeadLetterWrappedEventType, deadLettered[0].Header.Get("Ce-Type"))
+			var wrapper kncloudevents.DeadLetterWrapper
+			require.Nil(t, json.Unmarshal(deadLettered[0].Body, &wrapper))
+			require.Equal(t, event.ID(), wrapper.Original.ID())
+			require.Equal(t, event.Data(), wrapper.Original.Data())
+			require.Equal(t, replyEvent.ID(), wrapper.Failed.ID())
+		})
+	}
+
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(kncloudeventstest.NewFakeClient().Client()))
+	_, err := dispatcher.SendEvent(ctx, event, destination, kncloudevents.WithDeadLetterPayload("unknown"))
+	require.Error(t, err)
+}
+
+func TestDispatchWithFailoverDestinations(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.zone-a.example.com")}
+	failover1 := duckv1.Addressable{URL: apis.HTTP("destination.zone-b.example.com")}
+	failover2 := duckv1.Addressable{URL: apis.HTTP("destination.zone-c.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+
+	replyEvent := test.MinEvent()
+	replyEvent.SetID("reply-id")
+
+	tests := []struct {
+		name         string
+		responses    map[string]kncloudeventstest.Response
+		wantRequests map[string]int
+		wantErrDest  string
+	}{{
+		name: "destination accepts",
+		responses: map[string]kncloudeventstest.Response{
+			destination.URL.String(): kncloudeventstest.Status(http.StatusAccepted),
+		},
+		wantRequests: map[string]int{destination.URL.String(): 1},
+	}, {
+		name: "second failover accepts",
+		responses: map[string]kncloudeventstest.Response{
+			destination.URL.String(): kncloudeventstest.Status(http.StatusServiceUnavailable),
+			failover1.URL.String():   kncloudeventstest.Status(http.StatusServiceUnavailable),
+			failover2.URL.String():   kncloudeventstest.Reply(replyEvent),
+		},
+		wantRequests: map[string]int{
+			destination.URL.String(): 1,
+			failover1.URL.String():   1,
+			failover2.URL.String():   1,
+			reply.URL.String():       1,
+		},
+	}, {
+		name: "all fail",
+		responses: map[string]kncloudeventstest.Response{
+			destination.URL.String(): kncloudeventstest.Status(http.StatusServiceUnavailable),
+			failover1.URL.String():   kncloudeventstest.Status(http.StatusServiceUnavailable),
+			failover2.URL.String():   kncloudeventstest.Status(http.StatusServiceUnavailable),
+		},
+		wantRequests: map[string]int{
+			destination.URL.String(): 1,
+			failover1.URL.String():   1,
+			failover2.URL.String():   1,
+			dls.URL.String():         1,
+		},
+		wantErrDest: failover2.URL.String(),
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient := kncloudeventstest.NewFakeClient().
+				On(reply.URL.String(), kncloudeventstest.Status(http.StatusAccepted)).
+				On(dls.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+			for url, response := range tc.responses {
+				fakeClient.On(url, response)
+			}
+			dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+			// messages can only be read once, so the failover destinations need a copy
+			message := cehttp.NewMessage(http.Header{
+				"Content-Type":   []string{"application/json"},
+				"Ce-Specversion": []string{"1.0"},
+				"Ce-Id":          []string{"id"},
+				"Ce-Type":        []string{"type"},
+				"Ce-Source":      []string{"source"},
+			}, io.NopCloser(bytes.NewBufferString(`{"hello":"world"}`)))
+			_, err := dispatcher.SendMessage(ctx, message, destination,
+				kncloudevents.WithFailoverDestinations(failover1, failover2),
+				kncloudevents.WithReply(&reply),
+				kncloudevents.WithDeadLetterSink(&dls))
+			require.Nil(t, err)
+
+			for _, target := range []duckv1.Addressable{destination, failover1, failover2, reply, dls} {
+				requests := fakeClient.RequestsTo(target.URL.String())
+				require.Len(t, requests, tc.wantRequests[target.URL.String()], target.URL.String())
+				for _, request := range requests {
+					if target.URL.String() != reply.URL.String() {
+						require.Equal(t, `{"hello":"world"}`, string(request.Body))
+					}
+				}
+			}
+			if tc.wantErrDest != "" {
+				require.Equal(t, tc.wantErrDest, fakeClient.RequestsTo(dls.URL.String())[0].Header.Get("Ce-Knativeerrordest"))
+			}
+		})
+	}
+}
+
+func TestDispatchMessageToTLSEndpointWithCARotation(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	server := eventingtlstesting.StartTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	url, err := apis.ParseURL(server.URL)
+	require.Nil(t, err)
+	ca := server.CA()
+	destination := duckv1.Addressable{URL: url, CACerts: &ca}
+	t.Cleanup(func() { kncloudevents.DeleteAddressableHandler(destination) })
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+
+	// a certificate of the same CA is still trusted
+	server.RotateCertificate(t)
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+
+	// the previous CA isn't trusted anymore
+	previousCA := destination.CACerts
+	newCA := server.RotateCA(t).PEM
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.ErrorContains(t, err, "certificate signed by unknown authority")
+
+	// clients are keyed by CA certs, so the new CA is trusted right away
+	destination.CACerts = &newCA
+	info, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+
+	destination.CACerts = previousCA
+	_, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.ErrorContains(t, err, "certificate signed by unknown authority")
+}
+
+func TestDispatchMessageToTLSEndpointWithDivergentCACerts(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	server := eventingtlstesting.StartTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	url, err := apis.ParseURL(server.URL)
+	require.Nil(t, err)
+	ca := server.CA()
+	otherCA := eventingtlstesting.NewCertificateAuthority(t).PEM
+	t.Cleanup(func() { kncloudevents.DeleteAddressableHandler(duckv1.Addressable{URL: url}) })
+
+	// destinations with the same URL not trusting the server CA are used first,
+	// their clients must not be reused
+	for _, caCerts := range []*string{nil, &otherCA} {
+		_, err = dispatcher.SendEvent(ctx, test.FullEvent(), duckv1.Addressable{URL: url, CACerts: caCerts})
+		require.ErrorContains(t, err, "certificate signed by unknown authority")
+	}
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), duckv1.Addressable{URL: url, CACerts: &ca})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+}
+
+func TestDispatchMessageToTLSEndpointRequiringClientCertificate(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	clientCA := eventingtlstesting.NewCertificateAuthority(t)
+	server := eventingtlstesting.StartTLSServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), eventingtlstesting.WithClientCertificates(clientCA))
+	url, err := apis.ParseURL(server.URL)
+	require.Nil(t, err)
+	ca := server.CA()
+	destination := duckv1.Addressable{URL: url, CACerts: &ca}
+	t.Cleanup(func() { kncloudevents.DeleteAddressableHandler(destination) })
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusInternalServerError, info.ResponseCode)
+}
+
+func TestDispatchToTestServer(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	replyEvent := test.FullEvent()
+	replyEvent.SetID("reply")
+
+	maxRetryAfter := time.Second
+	retryConfig := func(timeout time.Duration) *kncloudevents.RetryConfig {
+		return &kncloudevents.RetryConfig{
+			RetryMax:              1,
+			CheckRetry:            kncloudevents.SelectiveRetry,
+			Backoff:               func(int, *http.Response) time.Duration { return time.Millisecond },
+			RequestTimeout:        timeout,
+			RetryAfterMaxDuration: &maxRetryAfter,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		script        func(s *kncloudeventstest.Server)
+		retryConfig   *kncloudevents.RetryConfig
+		wantErr       bool
+		wantCode      int
+		wantTimeline  map[string][]int
+		wantRetryWait time.Duration
+	}{{
+		name: "dropped connection dead lettered",
+		script: func(s *kncloudeventstest.Server) {
+			s.On("/destination", kncloudeventstest.Error(errors.New("drop")))
+		},
+		wantCode: http.StatusOK,
+		wantTimeline: map[string][]int{
+			"/destination": {0},
+			"/dls":         {http.StatusOK},
+		},
+	}, {
+		name: "slow destination times out",
+		script: func(s *kncloudeventstest.Server) {
+			s.On("/destination", kncloudeventstest.Status(http.StatusOK).Delayed(time.Second))
+		},
+		retryConfig: retryConfig(50 * time.Millisecond),
+		wantCode:    http.StatusOK,
+		wantTimeline: map[string][]int{
+			"/destination": {http.StatusOK, http.StatusOK},
+			"/dls":         {http.StatusOK},
+		},
+	}, {
+		name: "retry after is respected",
+		script: func(s *kncloudeventstest.Server) {
+			s.On("/destination",
+				kncloudeventstest.RetryAfter(http.StatusServiceUnavailable, time.Second),
+				kncloudeventstest.Status(http.StatusAccepted))
+		},
+		retryConfig: retryConfig(0),
+		wantCode:    http.StatusAccepted,
+		wantTimeline: map[string][]int{
+			"/destination": {http.StatusServiceUnavailable, http.StatusAccepted},
+		},
+		wantRetryWait: time.Second,
+	}, {
+		name: "reply forwarded",
+		script: func(s *kncloudeventstest.Server) {
+			s.On("/destination", kncloudeventstest.Reply(replyEvent))
+		},
+		wantCode: http.StatusOK,
+		wantTimeline: map[string][]int{
+			"/destination": {http.StatusOK},
+			"/reply":       {http.StatusOK},
+		},
+	}, {
+		name: "garbage reply dead lettered",
+		script: func(s *kncloudeventstest.Server) {
+			s.On("/destination", kncloudeventstest.Garbage())
+		},
+		wantCode: http.StatusOK,
+		wantTimeline: map[string][]int{
+			"/destination": {http.StatusOK},
+			"/dls":         {http.StatusOK},
+		},
+	}, {
+		name: "unauthorized",
+		script: func(s *kncloudeventstest.Server) {
+			s.RequireAuthorization("/destination", "Bearer token")
+			s.On("/dls", kncloudeventstest.Status(http.StatusBadRequest))
+		},
+		wantErr: true,
+		wantTimeline: map[string][]int{
+			"/destination": {http.StatusUnauthorized},
+			"/dls":         {http.StatusBadRequest},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := kncloudeventstest.StartServer(t)
+			tc.script(server)
+
+			addressable := func(path string) *duckv1.Addressable {
+				u, err := apis.ParseURL(server.URLFor(path))
+				require.Nil(t, err)
+				return &duckv1.Addressable{URL: u}
+			}
+
+			options := []kncloudevents.SendOption{
+				kncloudevents.WithReply(addressable("/reply")),
+				kncloudevents.WithDeadLetterSink(addressable("/dls")),
+			}
+			if tc.retryConfig != nil {
+				options = append(options, kncloudevents.WithRetryConfig(tc.retryConfig))
+			}
+
+			info, err := dispatcher.SendEvent(ctx, test.FullEvent(), *addressable("/destination"), options...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SendEvent() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && info.ResponseCode != tc.wantCode {
+				t.Errorf("Got response code %d, want %d", info.ResponseCode, tc.wantCode)
+			}
+
+			timeline := server.Timeline()
+			got := make(map[string][]int)
+			for _, e := range timeline {
+				got[e.Path] = append(got[e.Path], e.StatusCode)
+			}
+			if diff := cmp.Diff(tc.wantTimeline, got); diff != "" {
+				t.Error("Unexpected timeline (-want, +got):", diff)
+			}
+
+			if tc.wantRetryWait > 0 {
+				if wait := timeline[1].Received.Sub(timeline[0].Completed); wait < tc.wantRetryWait {
+					t.Errorf("Got retry after %v, want at least %v", wait, tc.wantRetryWait)
+				}
+			}
+		})
+	}
+}
+
+func TestWithOIDCToken(t *testing.T) {
+	ctx, kubeClient := fakekubeclient.With(context.Background())
+	kubeClient.PrependReactor("create", "serviceaccounts", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		request := action.(clientgotesting.CreateAction).GetObject().(*authv1.TokenRequest)
+		return true, &authv1.TokenRequest{
+			Status: authv1.TokenRequestStatus{
+				Token:               "token-for-" + request.Spec.Audiences[0],
+				ExpirationTimestamp: metav1.NewTime(time.Now().Add(auth.TokenExpirationTime)),
+			},
+		}, nil
+	})
+
+	withToken := kncloudevents.WithOIDCToken(auth.NewOIDCTokenProvider(ctx), types.NamespacedName{Namespace: "ns", Name: "sa"})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, withToken(req, duckv1.Addressable{URL: apis.HTTP("foo.bar"), Audience: pointer.String("aud")}))
+	require.Equal(t, "Bearer token-for-aud", req.Header.Get("Authorization"))
+
+	// targets without an audience don't get a token
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, withToken(req, duckv1.Addressable{URL: apis.HTTP("foo.bar")}))
+	require.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestDispatchWithHeaderOptions(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	header := http.Header{
+		"X-Replaced": []string{"a", "b"},
+		"x-lower":    []string{"lower"},
+	}
+	e := test.FullEvent()
+	_, err := dispatcher.SendEvent(ctx, e, destination,
+		kncloudevents.WithHeader(header),
+		kncloudevents.SetHeaders(http.Header{"X-Replaced": []string{"c", "d"}, "X-Multi": []string{"1", "2"}}),
+		kncloudevents.AddHeader("X-Multi", "3"),
+		kncloudevents.SetHeader("X-Single", "single"),
+		kncloudevents.SetHeader("Ce-Id", "overridden"),
+		kncloudevents.SetHeader("Content-Type", "text/plain"),
+	)
+	require.Nil(t, err)
+
+	requests := fakeClient.RequestsTo(destination.URL.String())
+	require.Len(t, requests, 1)
+	got := requests[0].Header
+	require.Equal(t, []string{"c", "d"}, got.Values("X-Replaced"))
+	require.Equal(t, []string{"1", "2", "3"}, got.Values("X-Multi"))
+	require.Equal(t, []string{"single"}, got.Values("X-Single"))
+	require.Equal(t, []string{"lower"}, got.Values("X-Lower"))
+	// the headers of the event take precedence
+	require.Equal(t, []string{e.ID()}, got.Values("Ce-Id"))
+	require.Equal(t, []string{e.DataContentType()}, got.Values("Content-Type"))
+	// the options don't modify the headers of the caller
+	require.Equal(t, []string{"a", "b"}, header.Values("X-Replaced"))
+}
+
+func TestDispatchWithoutPreferReply(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	failover := duckv1.Addressable{URL: apis.HTTP("failover.example.com")}
+
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Status(http.StatusInternalServerError)).
+		On(failover.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination,
+		kncloudevents.WithFailoverDestinations(failover),
+		kncloudevents.WithoutPreferReply())
+	if err != nil {
+		t.Fatal("SendEvent() =", err)
+	}
+
+	requests := fakeClient.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Got %d requests, want 2", len(requests))
+	}
+	for _, req := range requests {
+		if got := req.Header.Get("Prefer"); got != "" {
+			t.Errorf("Got Prefer header %q on the request to %s, want none", got, req.URL)
+		}
+	}
+}
@@ -41,6 +41,7 @@ type HTTPEventReceiver struct {
 
 	checker          http.HandlerFunc
 	drainQuietPeriod time.Duration
+	maxBodySize      BodySizeLimiter
 
 	// Used to signal when receiver is listening
 	Ready chan interface{}
@@ -129,6 +130,10 @@ func (recv *HTTPEventReceiver) StartListen(ctx context.Context, handler http.Han
 		return err
 	}
 
+	if recv.maxBodySize != nil {
+		handler = NewMaxBodySizeHandler(handler, recv.maxBodySize)
+	}
+
 	drainer := &handlers.Drainer{
 		Inner:       CreateHandler(handler),
 		HealthCheck: recv.checker,
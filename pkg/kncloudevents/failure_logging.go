@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/spec"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/system"
+
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+const (
+	// redacted replaces the values of redacted extensions in the logs.
+	redacted = "[REDACTED]"
+
+	// redactedExtensionsKey is the key of the config-delivery config map
+	// listing the extensions redacted in addition to
+	// DefaultRedactedExtensions, separated by commas.
+	redactedExtensionsKey = "redacted-extensions"
+)
+
+// DefaultRedactedExtensions are the extensions redacted by every
+// DeliveryFailureLogger, as they may carry parts of payloads.
+var DefaultRedactedExtensions = []string{
+	attributes.KnativeErrorDataExtensionKey,
+}
+
+// configuredRedactedExtensions are the extensions of the
+// redacted-extensions key of the config-delivery config map.
+var configuredRedactedExtensions atomic.Pointer[map[string]struct{}]
+
+// UpdateRedactedExtensions replaces the extensions redacted by every
+// DeliveryFailureLogger, in addition to DefaultRedactedExtensions and their
+// own, with the ones of the redacted-extensions key of the config map.
+func UpdateRedactedExtensions(cm *corev1.ConfigMap) {
+	extensions := make(map[string]struct{})
+	for _, name := range strings.Split(cm.Data[redactedExtensionsKey], ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			extensions[name] = struct{}{}
+		}
+	}
+	configuredRedactedExtensions.Store(&extensions)
+}
+
+// WatchRedactedExtensions updates the redacted extensions whenever the
+// config-delivery config map changes, see UpdateRedactedExtensions.
+func WatchRedactedExtensions(configMapWatcher configmap.Watcher) {
+	if dw, ok := configMapWatcher.(configmap.DefaultingWatcher); ok {
+		dw.WatchWithDefault(corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: DeliveryDefaultsConfigName, Namespace: system.Namespace()},
+		}, UpdateRedactedExtensions)
+		return
+	}
+	configMapWatcher.Watch(DeliveryDefaultsConfigName, UpdateRedactedExtensions)
+}
+
+// WithDeliveryFailureLogger makes the Dispatcher log the deliveries which
+// failed with the given logger.
+func WithDeliveryFailureLogger(l *DeliveryFailureLogger) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.failureLogger = l
+	}
+}
+
+// DeliveryFailureLogger logs failed deliveries the same way in all
+// components: with the metadata of the event, the target and the outcome of
+// the delivery, but never the payload of the event or the response body.
+type DeliveryFailureLogger struct {
+	logger   *zap.Logger
+	redacted map[string]struct{}
+}
+
+// NewDeliveryFailureLogger returns a DeliveryFailureLogger logging with the
+// given logger. The values of the given extensions, in addition to
+// DefaultRedactedExtensions and the ones of the config-delivery config map,
+// are redacted.
+func NewDeliveryFailureLogger(logger *zap.Logger, redactedExtensions ...string) *DeliveryFailureLogger {
+	l := &DeliveryFailureLogger{
+		logger:   logger,
+		redacted: make(map[string]struct{}, len(DefaultRedactedExtensions)+len(redactedExtensions)),
+	}
+	for _, name := range DefaultRedactedExtensions {
+		l.redacted[strings.ToLower(name)] = struct{}{}
+	}
+	for _, name := range redactedExtensions {
+		l.redacted[strings.ToLower(name)] = struct{}{}
+	}
+	return l
+}
+
+// Log logs the failed delivery of the event to the target, with the given
+// message and the dispatch info, which may be nil, of the delivery.
+func (l *DeliveryFailureLogger) Log(msg string, e *event.Event, target duckv1.Addressable, info *DispatchInfo, err error) {
+	l.logger.Error(msg, l.Fields(e, target, info, err)...)
+}
+
+// Fields returns the fields logged by Log, e.g. to log them at another level.
+func (l *DeliveryFailureLogger) Fields(e *event.Event, target duckv1.Addressable, info *DispatchInfo, err error) []zap.Field {
+	fields := make([]zap.Field, 0, 10)
+	if e != nil {
+		fields = append(fields,
+			zap.String("event.id", e.ID()),
+			zap.String("event.type", e.Type()),
+			zap.String("event.source", e.Source()),
+		)
+		if subject := e.Subject(); subject != "" {
+			fields = append(fields, zap.String("event.subject", subject))
+		}
+		if len(e.Extensions()) > 0 {
+			fields = append(fields, zap.Object("event.extensions", redactedExtensions{extensions: e.Extensions(), redacted: l.redacted}))
+		}
+	}
+	fields = append(fields, zap.String("target", redactURL(target)))
+	if info != nil {
+		if info.ResponseCode != NoResponse {
+			fields = append(fields, zap.Int("response.code", info.ResponseCode))
+		}
+		if info.Attempts > 0 {
+			fields = append(fields, zap.Int("attempts", info.Attempts))
+		}
+		if info.Duration != NoDuration {
+			fields = append(fields, zap.Duration("duration", info.Duration))
+		}
+		if info.ErrorReason != "" {
+			fields = append(fields, zap.String("error.reason", string(info.ErrorReason)))
+		}
+	}
+	if err != nil {
+		fields = append(fields, zap.String("error", redactError(err, target)))
+	}
+	return fields
+}
+
+// messageMetadata returns an event with the attributes of the message but
+// without its data, as the message can only be read once. Only the
+// attributes, without the extensions, of the messages other than events and
+// binary HTTP messages are returned.
+func messageMetadata(ctx context.Context, message binding.Message) *event.Event {
+	switch m := message.(type) {
+	case *binding.EventMessage:
+		return &event.Event{Context: m.Context.Clone()}
+	case *cehttp.Message:
+		if m.ReadEncoding() == binding.EncodingBinary {
+			// the headers hold all the attributes of binary messages
+			if e, err := binding.ToEvent(ctx, cehttp.NewMessage(m.Header, nil)); err == nil {
+				return e
+			}
+		}
+	}
+	reader, ok := message.(binding.MessageMetadataReader)
+	if !ok {
+		return nil
+	}
+	e := event.New()
+	e.SetID(attributeString(reader, spec.ID))
+	e.SetType(attributeString(reader, spec.Type))
+	e.SetSource(attributeString(reader, spec.Source))
+	e.SetSubject(attributeString(reader, spec.Subject))
+	return &e
+}
+
+// redactURL returns the URL of the target without its credentials and with
+// its query redacted, as they may carry secrets.
+func redactURL(target duckv1.Addressable) string {
+	if target.URL == nil {
+		return ""
+	}
+	return redactedURL(target.URL.URL())
+}
+
+// redactError returns the message of the error with the URLs of the target
+// and of the requests, see url.Error, redacted like redactURL.
+func redactError(err error, target duckv1.Addressable) string {
+	msg := err.Error()
+	if target.URL != nil {
+		msg = strings.ReplaceAll(msg, target.URL.String(), redactURL(target))
+	}
+	for unwrapped := err; unwrapped != nil; {
+		var urlErr *url.Error
+		if !errors.As(unwrapped, &urlErr) {
+			break
+		}
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && urlErr.URL != "" {
+			msg = strings.ReplaceAll(msg, urlErr.URL, redactedURL(u))
+		}
+		unwrapped = urlErr.Err
+	}
+	return msg
+}
+
+func redactedURL(target *url.URL) string {
+	u := *target
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = redacted
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u.String()
+}
+
+type redactedExtensions struct {
+	extensions map[string]interface{}
+	redacted   map[string]struct{}
+}
+
+func (r redactedExtensions) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	names := make([]string, 0, len(r.extensions))
+	for name := range r.extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var configured map[string]struct{}
+	if c := configuredRedactedExtensions.Load(); c != nil {
+		configured = *c
+	}
+	for _, name := range names {
+		_, ok := r.redacted[name]
+		if _, configuredOk := configured[name]; ok || configuredOk {
+			enc.AddString(name, redacted)
+		} else {
+			value, err := types.Format(r.extensions[name])
+			if err != nil {
+				value = fmt.Sprint(r.extensions[name])
+			}
+			enc.AddString(name, value)
+		}
+	}
+	return nil
+}
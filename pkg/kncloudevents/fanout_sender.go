@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// FanoutTarget is a destination of a FanoutSender.
+type FanoutTarget struct {
+	// Key identifies the target across fanouts, e.g. the UID of a
+	// subscription. It is only required for ordered targets.
+	Key string
+	// Destination is where the events are sent to.
+	Destination duckv1.Addressable
+	// Ordered serializes the sends to the target: an event is only sent to
+	// the target once the previous event fanned out to it was delivered,
+	// including its retries.
+	Ordered bool
+	// Options are the options of the sends to the target.
+	Options []SendOption
+}
+
+// FanoutResult is the outcome of sending an event to a FanoutTarget.
+type FanoutResult struct {
+	// Index is the index of the target in the targets of the fanout.
+	Index int
+	Info  *DispatchInfo
+	Err   error
+	// Sent reports whether the event was sent to the target, with the retries
+	// and the dead letter sink of its options, Err being the outcome of the
+	// delivery. Otherwise the event couldn't be sent, e.g. as the context was
+	// done while waiting for the previous sends to an ordered target.
+	Sent bool
+}
+
+// FanoutSender sends events to multiple targets in parallel with a
+// Dispatcher. Unordered targets receive events as soon as they are fanned
+// out, while ordered targets receive them one at a time in the order of the
+// Send calls, so that a slow ordered target doesn't delay the others.
+type FanoutSender struct {
+	dispatcher *Dispatcher
+
+	mu sync.Mutex
+	// tails holds, per ordered target key, a channel which is closed once the
+	// last send queued for it completed.
+	tails map[string]chan struct{}
+}
+
+// NewFanoutSender returns a FanoutSender sending with the given Dispatcher.
+func NewFanoutSender(dispatcher *Dispatcher) *FanoutSender {
+	return &FanoutSender{
+		dispatcher: dispatcher,
+		tails:      make(map[string]chan struct{}),
+	}
+}
+
+// Send sends the event to all targets in parallel and returns a channel
+// receiving one result per target, in completion order. The position of
+// the event in the queues of the ordered targets is reserved before Send
+// returns.
+func (s *FanoutSender) Send(ctx context.Context, event event.Event, targets []FanoutTarget) <-chan FanoutResult {
+	results := make(chan FanoutResult, len(targets))
+	for i := range targets {
+		target := targets[i]
+
+		var previous, done chan struct{}
+		if target.Ordered {
+			previous, done = s.enqueue(target.Key)
+		}
+
+		go func(index int) {
+			if done != nil {
+				defer s.dequeue(target.Key, done)
+				if previous != nil {
+					select {
+					case <-previous:
+					case <-ctx.Done():
+						results <- FanoutResult{Index: index, Err: fmt.Errorf("failed waiting for previous sends to %q: %w", target.Key, ctx.Err())}
+						// Keep the queue ordered for the following events.
+						<-previous
+						return
+					}
+				}
+			}
+
+			info, err := s.dispatcher.SendEvent(ctx, event, target.Destination, target.Options...)
+			results <- FanoutResult{Index: index, Info: info, Err: err, Sent: true}
+		}(i)
+	}
+	return results
+}
+
+// enqueue reserves the next position in the queue of the key, it returns the
+// channel to wait for before sending, nil if the queue was empty, and the
+// channel to close once the send completed.
+func (s *FanoutSender) enqueue(key string) (chan struct{}, chan struct{}) {
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.tails[key]
+	s.tails[key] = done
+	return previous, done
+}
+
+func (s *FanoutSender) dequeue(key string, done chan struct{}) {
+	s.mu.Lock()
+	if s.tails[key] == done {
+		delete(s.tails, key)
+	}
+	s.mu.Unlock()
+	close(done)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestFanoutSender(t *testing.T) {
+	ctx := context.Background()
+	ordered, orderedDestination := newOrderRecorder(t)
+	unordered, unorderedDestination := newOrderRecorder(t)
+	s := NewFanoutSender(NewDispatcher(eventingtls.NewDefaultClientConfig(), nil))
+
+	orderedTarget := FanoutTarget{Key: "ordered", Destination: orderedDestination, Ordered: true}
+	unorderedTarget := FanoutTarget{Key: "unordered", Destination: unorderedDestination}
+
+	first := s.Send(ctx, keyedEvent("blocked", ""), []FanoutTarget{orderedTarget})
+	require.Equal(t, "blocked", <-ordered.received)
+
+	second := s.Send(ctx, keyedEvent("second", ""), []FanoutTarget{orderedTarget, unorderedTarget})
+
+	// the unordered target isn't delayed by the blocked ordered target
+	require.Equal(t, "second", <-unordered.received)
+	result := <-second
+	require.Equal(t, 1, result.Index)
+	require.Nil(t, result.Err)
+	require.Equal(t, http.StatusAccepted, result.Info.ResponseCode)
+
+	select {
+	case id := <-ordered.received:
+		t.Fatalf("ordered target received %q before the previous event was delivered", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(ordered.unblock)
+	require.Nil(t, (<-first).Err)
+	result = <-second
+	require.Equal(t, 0, result.Index)
+	require.Nil(t, result.Err)
+
+	require.Equal(t, []string{"start blocked", "end blocked", "start second", "end second"}, ordered.entries())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.Empty(t, s.tails)
+}
+
+func TestFanoutSenderContextDone(t *testing.T) {
+	ordered, orderedDestination := newOrderRecorder(t)
+	s := NewFanoutSender(NewDispatcher(eventingtls.NewDefaultClientConfig(), nil))
+	target := FanoutTarget{Key: "ordered", Destination: orderedDestination, Ordered: true}
+
+	first := s.Send(context.Background(), keyedEvent("blocked", ""), []FanoutTarget{target})
+	require.Equal(t, "blocked", <-ordered.received)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, (<-s.Send(ctx, keyedEvent("second", ""), []FanoutTarget{target})).Err, context.Canceled)
+
+	// the following events are still queued behind the blocked one
+	third := s.Send(context.Background(), keyedEvent("third", ""), []FanoutTarget{target})
+	close(ordered.unblock)
+	require.Nil(t, (<-first).Err)
+	require.Nil(t, (<-third).Err)
+	require.Equal(t, []string{"start blocked", "end blocked", "start third", "end third"}, ordered.entries())
+}
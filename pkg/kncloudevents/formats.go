@@ -0,0 +1,727 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/format"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"google.golang.org/protobuf/encoding/protowire"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+const (
+	// ProtobufContentType is the media type of the CloudEvents protobuf format.
+	ProtobufContentType = "application/cloudevents+protobuf"
+	// AvroContentType is the media type of the CloudEvents Avro format.
+	AvroContentType = "application/cloudevents+avro"
+)
+
+var (
+	// ProtobufFormat is the CloudEvents protobuf format, see
+	// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/protobuf-format.md.
+	// Data is sent as text_data for text content types, and as binary_data
+	// otherwise.
+	ProtobufFormat format.Format = protobufFormat{}
+	// AvroFormat is the CloudEvents Avro format, see
+	// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/avro-format.md.
+	// Attributes are encoded with the ["null", "boolean", "int", "string",
+	// "bytes"] union of the schema, data as bytes or null.
+	AvroFormat format.Format = avroFormat{}
+)
+
+const (
+	// PreferredFormatsAnnotation is the annotation of an addressable resource
+	// listing the media types of the event formats it prefers, comma
+	// separated, for callers to pass to WithPreferredFormats.
+	PreferredFormatsAnnotation = "eventing.knative.dev/preferred-formats"
+
+	// formatNegotiationTTL is how long the formats a destination advertised
+	// are cached.
+	formatNegotiationTTL = 5 * time.Minute
+)
+
+var errTruncated = errors.New("truncated event")
+
+// WithPreferredFormats sends the events to the destination and the failover
+// destinations in the first of the media types with a supported format, i.e.
+// ProtobufContentType or AvroContentType, instead of the binary content mode.
+// Other media types are skipped.
+func WithPreferredFormats(mediaTypes ...string) SendOption {
+	return func(sc *senderConfig) error {
+		sc.format = formatFor(mediaTypes)
+
+		return nil
+	}
+}
+
+// WithFormatNegotiation probes each target with an OPTIONS request, and sends
+// the events in the first supported format of the media types of the
+// Accept-Post header of the response, see WithPreferredFormats. The formats
+// are cached for 5 minutes. Formats of WithPreferredFormats take precedence.
+func WithFormatNegotiation() DispatcherOption {
+	return func(d *Dispatcher) {
+		d.formats = &formatNegotiator{
+			negotiated: make(map[string]negotiatedFormat),
+		}
+	}
+}
+
+type formatNegotiator struct {
+	mu         sync.Mutex
+	negotiated map[string]negotiatedFormat
+}
+
+type negotiatedFormat struct {
+	format  format.Format
+	expires time.Time
+}
+
+// formatFor returns the format the target advertised, or nil for the binary
+// content mode.
+func (n *formatNegotiator) formatFor(ctx context.Context, client *client, target duckv1.Addressable) format.Format {
+	url := target.URL.String()
+	n.mu.Lock()
+	negotiated, ok := n.negotiated[url]
+	n.mu.Unlock()
+	if ok && time.Now().Before(negotiated.expires) {
+		return negotiated.format
+	}
+
+	negotiated = negotiatedFormat{
+		format:  probeFormat(ctx, client, url),
+		expires: time.Now().Add(formatNegotiationTTL),
+	}
+	n.mu.Lock()
+	n.negotiated[url] = negotiated
+	n.mu.Unlock()
+	return negotiated.format
+}
+
+func probeFormat(ctx context.Context, client *client, url string) format.Format {
+	ctx, cancel := context.WithTimeout(ctx, defaultProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil
+	}
+	return formatFor(strings.Split(resp.Header.Get("Accept-Post"), ","))
+}
+
+type formatsKey struct{}
+
+// contextWithFormat sends the requests to the targets with the given format.
+func contextWithFormat(ctx context.Context, f format.Format, targets ...duckv1.Addressable) context.Context {
+	formats := make(map[string]format.Format, len(targets))
+	for _, target := range targets {
+		formats[target.URL.String()] = f
+	}
+	return context.WithValue(ctx, formatsKey{}, formats)
+}
+
+// formatFor returns the format to send the request to the target in, or nil
+// for the binary content mode.
+func (d *Dispatcher) formatFor(ctx context.Context, target duckv1.Addressable, namespace string) format.Format {
+	if formats, ok := ctx.Value(formatsKey{}).(map[string]format.Format); ok {
+		if f, ok := formats[target.URL.String()]; ok {
+			return f
+		}
+	}
+	if d.formats == nil {
+		return nil
+	}
+	client, err := d.newClient(target, namespace)
+	if err != nil {
+		return nil
+	}
+	return d.formats.formatFor(ctx, client, target)
+}
+
+// writeStructuredRequest writes the message to the request in the structured
+// content mode with the given format.
+func writeStructuredRequest(ctx context.Context, message binding.Message, request *http.Request, f format.Format, transformers ...binding.Transformer) error {
+	e, err := binding.ToEvent(ctx, message, transformers...)
+	if err != nil {
+		return err
+	}
+	body, err := f.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event as %s: %w", f.MediaType(), err)
+	}
+	request.Header.Set("Content-Type", f.MediaType())
+	request.ContentLength = int64(len(body))
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// formatFor returns the first of the media types with an outbound format, or
+// nil if there is none.
+func formatFor(mediaTypes []string) format.Format {
+	for _, mediaType := range mediaTypes {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+		switch mediaType {
+		case ProtobufContentType:
+			return ProtobufFormat
+		case AvroContentType:
+			return AvroFormat
+		}
+	}
+	return nil
+}
+
+// isTextContentType returns whether data of the content type is text.
+func isTextContentType(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return contentType == "" ||
+		strings.HasPrefix(contentType, "text/") ||
+		contentType == event.ApplicationJSON ||
+		contentType == event.ApplicationXML ||
+		strings.HasSuffix(contentType, "+json") ||
+		strings.HasSuffix(contentType, "+xml")
+}
+
+// protobuf field numbers of the CloudEvent message
+const (
+	protoID          protowire.Number = 1
+	protoSource      protowire.Number = 2
+	protoSpecVersion protowire.Number = 3
+	protoType        protowire.Number = 4
+	protoAttributes  protowire.Number = 5
+	protoBinaryData  protowire.Number = 6
+	protoTextData    protowire.Number = 7
+)
+
+// protobuf field numbers of the CloudEventAttributeValue message
+const (
+	protoBoolean   protowire.Number = 1
+	protoInteger   protowire.Number = 2
+	protoString    protowire.Number = 3
+	protoBytes     protowire.Number = 4
+	protoURI       protowire.Number = 5
+	protoURIRef    protowire.Number = 6
+	protoTimestamp protowire.Number = 7
+)
+
+type protobufFormat struct{}
+
+func (protobufFormat) MediaType() string {
+	return ProtobufContentType
+}
+
+func (protobufFormat) Marshal(e *event.Event) ([]byte, error) {
+	if e.SpecVersion() != event.CloudEventsVersionV1 {
+		return nil, fmt.Errorf("unsupported spec version %s", e.SpecVersion())
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, protoID, protowire.BytesType)
+	b = protowire.AppendString(b, e.ID())
+	b = protowire.AppendTag(b, protoSource, protowire.BytesType)
+	b = protowire.AppendString(b, e.Source())
+	b = protowire.AppendTag(b, protoSpecVersion, protowire.BytesType)
+	b = protowire.AppendString(b, e.SpecVersion())
+	b = protowire.AppendTag(b, protoType, protowire.BytesType)
+	b = protowire.AppendString(b, e.Type())
+
+	attributes := make(map[string]interface{}, len(e.Extensions())+4)
+	for name, value := range e.Extensions() {
+		attributes[name] = value
+	}
+	if e.DataContentType() != "" {
+		attributes["datacontenttype"] = e.DataContentType()
+	}
+	if e.DataSchema() != "" {
+		attributes["dataschema"] = types.ParseURI(e.DataSchema())
+	}
+	if e.Subject() != "" {
+		attributes["subject"] = e.Subject()
+	}
+	if !e.Time().IsZero() {
+		attributes["time"] = types.Timestamp{Time: e.Time()}
+	}
+	for name, value := range attributes {
+		v, err := marshalProtobufAttribute(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal attribute %s: %w", name, err)
+		}
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, name)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendBytes(entry, v)
+		b = protowire.AppendTag(b, protoAttributes, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+
+	if data := e.Data(); data != nil {
+		if isTextContentType(e.DataContentType()) {
+			b = protowire.AppendTag(b, protoTextData, protowire.BytesType)
+		} else {
+			b = protowire.AppendTag(b, protoBinaryData, protowire.BytesType)
+		}
+		b = protowire.AppendBytes(b, data)
+	}
+	return b, nil
+}
+
+func marshalProtobufAttribute(value interface{}) ([]byte, error) {
+	value, err := types.Validate(value)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	switch v := value.(type) {
+	case bool:
+		b = protowire.AppendTag(b, protoBoolean, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case int32:
+		b = protowire.AppendTag(b, protoInteger, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(v))
+	case string:
+		b = protowire.AppendTag(b, protoString, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case []byte:
+		b = protowire.AppendTag(b, protoBytes, protowire.BytesType)
+		b = protowire.AppendBytes(b, v)
+	case types.URI:
+		b = protowire.AppendTag(b, protoURI, protowire.BytesType)
+		b = protowire.AppendString(b, v.String())
+	case types.URIRef:
+		b = protowire.AppendTag(b, protoURIRef, protowire.BytesType)
+		b = protowire.AppendString(b, v.String())
+	case types.Timestamp:
+		var ts []byte
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(v.Unix()))
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(v.Nanosecond()))
+		b = protowire.AppendTag(b, protoTimestamp, protowire.BytesType)
+		b = protowire.AppendBytes(b, ts)
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+	return b, nil
+}
+
+func (protobufFormat) Unmarshal(b []byte, e *event.Event) error {
+	*e = event.New()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var err error
+		switch num {
+		case protoID:
+			e.SetID(string(v))
+		case protoSource:
+			e.SetSource(string(v))
+		case protoSpecVersion:
+			if string(v) != event.CloudEventsVersionV1 {
+				return fmt.Errorf("unsupported spec version %s", v)
+			}
+		case protoType:
+			e.SetType(string(v))
+		case protoAttributes:
+			err = unmarshalProtobufAttribute(v, e)
+		case protoBinaryData, protoTextData:
+			e.DataEncoded = v
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.Validate()
+}
+
+func unmarshalProtobufAttribute(b []byte, e *event.Event) error {
+	var name string
+	var value interface{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.BytesType {
+			return errTruncated
+		}
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch num {
+		case 1:
+			name = string(v)
+		case 2:
+			var err error
+			if value, err = unmarshalProtobufAttributeValue(v); err != nil {
+				return fmt.Errorf("failed to unmarshal attribute %s: %w", name, err)
+			}
+		}
+	}
+	return setAttribute(e, name, value)
+}
+
+func unmarshalProtobufAttributeValue(b []byte) (interface{}, error) {
+	num, typ, n := protowire.ConsumeTag(b)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	b = b[n:]
+	if typ == protowire.VarintType {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		switch num {
+		case protoBoolean:
+			return protowire.DecodeBool(v), nil
+		case protoInteger:
+			return int32(v), nil
+		}
+		return nil, fmt.Errorf("unexpected field %d", num)
+	}
+
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	switch num {
+	case protoString:
+		return string(v), nil
+	case protoBytes:
+		return v, nil
+	case protoURI:
+		if uri := types.ParseURI(string(v)); uri != nil {
+			return *uri, nil
+		}
+		return nil, fmt.Errorf("invalid URI %q", v)
+	case protoURIRef:
+		if uriRef := types.ParseURIRef(string(v)); uriRef != nil {
+			return *uriRef, nil
+		}
+		return nil, fmt.Errorf("invalid URI reference %q", v)
+	case protoTimestamp:
+		var seconds, nanos uint64
+		for len(v) > 0 {
+			num, _, n := protowire.ConsumeTag(v)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			v = v[n:]
+			x, n := protowire.ConsumeVarint(v)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			v = v[n:]
+			if num == 1 {
+				seconds = x
+			} else if num == 2 {
+				nanos = x
+			}
+		}
+		return types.Timestamp{Time: time.Unix(int64(seconds), int64(nanos)).UTC()}, nil
+	}
+	return nil, fmt.Errorf("unexpected field %d", num)
+}
+
+// setAttribute sets the context attribute or extension with the given name.
+func setAttribute(e *event.Event, name string, value interface{}) error {
+	switch name {
+	case "id", "source", "specversion", "type":
+		s, err := types.ToString(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		switch name {
+		case "id":
+			e.SetID(s)
+		case "source":
+			e.SetSource(s)
+		case "type":
+			e.SetType(s)
+		case "specversion":
+			if s != event.CloudEventsVersionV1 {
+				return fmt.Errorf("unsupported spec version %s", s)
+			}
+		}
+	case "datacontenttype", "dataschema", "subject":
+		s, err := types.Format(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		switch name {
+		case "datacontenttype":
+			e.SetDataContentType(s)
+		case "dataschema":
+			e.SetDataSchema(s)
+		case "subject":
+			e.SetSubject(s)
+		}
+	case "time":
+		t, err := types.ToTime(value)
+		if err != nil {
+			return fmt.Errorf("invalid time: %w", err)
+		}
+		e.SetTime(t)
+	default:
+		return e.Context.SetExtension(name, value)
+	}
+	return nil
+}
+
+// indexes of the Avro unions of the CloudEvents schema
+const (
+	avroAttributeNull    = 0
+	avroAttributeBoolean = 1
+	avroAttributeInt     = 2
+	avroAttributeString  = 3
+	avroAttributeBytes   = 4
+
+	avroDataBytes = 0
+	avroDataNull  = 1
+)
+
+type avroFormat struct{}
+
+func (avroFormat) MediaType() string {
+	return AvroContentType
+}
+
+func (avroFormat) Marshal(e *event.Event) ([]byte, error) {
+	if e.SpecVersion() != event.CloudEventsVersionV1 {
+		return nil, fmt.Errorf("unsupported spec version %s", e.SpecVersion())
+	}
+
+	attributes := map[string]interface{}{
+		"id":          e.ID(),
+		"source":      e.Source(),
+		"specversion": e.SpecVersion(),
+		"type":        e.Type(),
+	}
+	if e.DataContentType() != "" {
+		attributes["datacontenttype"] = e.DataContentType()
+	}
+	if e.DataSchema() != "" {
+		attributes["dataschema"] = e.DataSchema()
+	}
+	if e.Subject() != "" {
+		attributes["subject"] = e.Subject()
+	}
+	if !e.Time().IsZero() {
+		attributes["time"] = types.Timestamp{Time: e.Time()}
+	}
+	for name, value := range e.Extensions() {
+		attributes[name] = value
+	}
+
+	var b []byte
+	b = appendAvroLong(b, int64(len(attributes)))
+	for name, value := range attributes {
+		b = appendAvroBytes(b, []byte(name))
+		value, err := types.Validate(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal attribute %s: %w", name, err)
+		}
+		switch v := value.(type) {
+		case bool:
+			b = appendAvroLong(b, avroAttributeBoolean)
+			if v {
+				b = append(b, 1)
+			} else {
+				b = append(b, 0)
+			}
+		case int32:
+			b = appendAvroLong(b, avroAttributeInt)
+			b = appendAvroLong(b, int64(v))
+		case []byte:
+			b = appendAvroLong(b, avroAttributeBytes)
+			b = appendAvroBytes(b, v)
+		default:
+			s, err := types.Format(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal attribute %s: %w", name, err)
+			}
+			b = appendAvroLong(b, avroAttributeString)
+			b = appendAvroBytes(b, []byte(s))
+		}
+	}
+	b = appendAvroLong(b, 0)
+
+	if data := e.Data(); data != nil {
+		b = appendAvroLong(b, avroDataBytes)
+		b = appendAvroBytes(b, data)
+	} else {
+		b = appendAvroLong(b, avroDataNull)
+	}
+	return b, nil
+}
+
+func (avroFormat) Unmarshal(b []byte, e *event.Event) error {
+	*e = event.New()
+	for {
+		count, err := consumeAvroLong(&b)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// a negative count is followed by the size of the block
+			count = -count
+			if _, err := consumeAvroLong(&b); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			name, err := consumeAvroBytes(&b)
+			if err != nil {
+				return err
+			}
+			value, err := consumeAvroAttribute(&b)
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal attribute %s: %w", name, err)
+			}
+			if value == nil {
+				continue
+			}
+			if err := setAttribute(e, string(name), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	index, err := consumeAvroLong(&b)
+	if err != nil {
+		return err
+	}
+	switch index {
+	case avroDataBytes:
+		data, err := consumeAvroBytes(&b)
+		if err != nil {
+			return err
+		}
+		e.DataEncoded = data
+	case avroDataNull:
+	default:
+		return fmt.Errorf("unsupported data type %d", index)
+	}
+	return e.Validate()
+}
+
+func consumeAvroAttribute(b *[]byte) (interface{}, error) {
+	index, err := consumeAvroLong(b)
+	if err != nil {
+		return nil, err
+	}
+	switch index {
+	case avroAttributeNull:
+		return nil, nil
+	case avroAttributeBoolean:
+		if len(*b) == 0 {
+			return nil, errTruncated
+		}
+		v := (*b)[0] != 0
+		*b = (*b)[1:]
+		return v, nil
+	case avroAttributeInt:
+		v, err := consumeAvroLong(b)
+		if err != nil {
+			return nil, err
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("int %d out of range", v)
+		}
+		return int32(v), nil
+	case avroAttributeString:
+		v, err := consumeAvroBytes(b)
+		return string(v), err
+	case avroAttributeBytes:
+		return consumeAvroBytes(b)
+	}
+	return nil, fmt.Errorf("unsupported attribute type %d", index)
+}
+
+func appendAvroLong(b []byte, v int64) []byte {
+	return protowire.AppendVarint(b, protowire.EncodeZigZag(v))
+}
+
+func appendAvroBytes(b []byte, v []byte) []byte {
+	b = appendAvroLong(b, int64(len(v)))
+	return append(b, v...)
+}
+
+func consumeAvroLong(b *[]byte) (int64, error) {
+	v, n := protowire.ConsumeVarint(*b)
+	if n < 0 {
+		return 0, errTruncated
+	}
+	*b = (*b)[n:]
+	return protowire.DecodeZigZag(v), nil
+}
+
+func consumeAvroBytes(b *[]byte) ([]byte, error) {
+	length, err := consumeAvroLong(b)
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 || int64(len(*b)) < length {
+		return nil, errTruncated
+	}
+	v := (*b)[:length]
+	*b = (*b)[length:]
+	return v, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding/format"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestFormatsRoundTrip(t *testing.T) {
+	binaryEvent := test.FullEvent()
+	require.Nil(t, binaryEvent.SetData("application/octet-stream", []byte{0, 1, 2}))
+	binaryEvent.SetExtension("exnegative", -5)
+	noDataEvent := test.MinEvent()
+
+	for _, f := range []format.Format{kncloudevents.ProtobufFormat, kncloudevents.AvroFormat} {
+		for _, e := range []event.Event{test.FullEvent(), binaryEvent, noDataEvent} {
+			b, err := f.Marshal(&e)
+			require.Nil(t, err, f.MediaType())
+
+			var got event.Event
+			require.Nil(t, f.Unmarshal(b, &got), f.MediaType())
+			if f == kncloudevents.AvroFormat {
+				e = withStringExtensions(t, e)
+			}
+			test.AssertEventEquals(t, e, got)
+		}
+
+		var got event.Event
+		require.Error(t, f.Unmarshal([]byte{0xff}, &got), f.MediaType())
+	}
+}
+
+func TestDispatchWithPreferredFormats(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	e := test.FullEvent()
+
+	_, err := dispatcher.SendEvent(ctx, e, destination, kncloudevents.WithPreferredFormats("application/unknown", kncloudevents.AvroContentType+"; charset=utf-8"))
+	require.Nil(t, err)
+
+	requests := fakeClient.RequestsTo(destination.URL.String())
+	require.Len(t, requests, 1)
+	require.Equal(t, kncloudevents.AvroContentType, requests[0].Header.Get("Content-Type"))
+	var got event.Event
+	require.Nil(t, kncloudevents.AvroFormat.Unmarshal(requests[0].Body, &got))
+	test.AssertEventEquals(t, withStringExtensions(t, e), got)
+}
+
+// withStringExtensions returns the event with the URI and timestamp extensions
+// as strings, since Avro has no such types.
+func withStringExtensions(t *testing.T, e event.Event) event.Event {
+	e = e.Clone()
+	for name, value := range e.Extensions() {
+		switch value.(type) {
+		case types.URI, types.URIRef, types.Timestamp:
+			s, err := types.Format(value)
+			require.Nil(t, err)
+			e.SetExtension(name, s)
+		}
+	}
+	return e
+}
+
+func TestDispatchWithFormatNegotiation(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	var probes atomic.Int32
+	received := make(chan *http.Request, 2)
+	bodies := make(chan []byte, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			probes.Add(1)
+			w.Header().Set("Accept-Post", "application/json, "+kncloudevents.ProtobufContentType)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	destination := duckv1.Addressable{URL: apis.HTTP(server.Listener.Addr().String())}
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithFormatNegotiation())
+	e := test.FullEvent()
+
+	for i := 0; i < 2; i++ {
+		_, err := dispatcher.SendEvent(ctx, e, destination)
+		require.Nil(t, err)
+
+		r := <-received
+		require.Equal(t, kncloudevents.ProtobufContentType, r.Header.Get("Content-Type"))
+		var got event.Event
+		require.Nil(t, kncloudevents.ProtobufFormat.Unmarshal(<-bodies, &got))
+		test.AssertEventEquals(t, e, got)
+	}
+	// the advertised formats are cached
+	require.Equal(t, int32(1), probes.Load())
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+const (
+	// ReadinessPath is the path of the kubelet probes answered with the
+	// readiness of a HealthRegistry, see HealthRegistry.Checker.
+	ReadinessPath = "/readyz"
+
+	// DefaultHealthFailureThreshold is the number of consecutive connectivity
+	// failures after which a destination is reported as degraded.
+	DefaultHealthFailureThreshold = 5
+)
+
+// DestinationHealth is the health of a destination registered with a
+// HealthRegistry.
+type DestinationHealth struct {
+	// Name is the name the destination was registered with.
+	Name string `json:"name"`
+	// URL is the URL of the destination.
+	URL string `json:"url"`
+	// Healthy is false if the requests to the destination consistently failed
+	// with connectivity problems.
+	Healthy bool `json:"healthy"`
+	// Reason is the reason of the last connectivity failure, if degraded.
+	Reason attributes.KnativeErrorReason `json:"reason,omitempty"`
+	// Since is when the destination was degraded.
+	Since *time.Time `json:"since,omitempty"`
+}
+
+type destinationHealth struct {
+	failures    int
+	reason      attributes.KnativeErrorReason
+	firstFailed time.Time
+}
+
+// HealthRegistry tracks whether the destinations components must reach can
+// be dispatched to. Requests rejected since the circuit of the destination is
+// open, see WithAdaptiveConcurrency, and requests whose TLS handshake failed
+// are connectivity failures. A destination is degraded after threshold
+// consecutive connectivity failures, until a request reaches it again, i.e.
+// any response, even a non-2xx one. Other failures, e.g. timeouts, don't
+// change the health of a destination.
+type HealthRegistry struct {
+	threshold int
+	now       func() time.Time
+
+	mu           sync.RWMutex
+	names        map[string]string
+	destinations map[string]*destinationHealth
+}
+
+// NewHealthRegistry returns a HealthRegistry degrading destinations after
+// threshold consecutive connectivity failures, DefaultHealthFailureThreshold
+// if zero or negative.
+func NewHealthRegistry(threshold int) *HealthRegistry {
+	if threshold <= 0 {
+		threshold = DefaultHealthFailureThreshold
+	}
+	return &HealthRegistry{
+		threshold:    threshold,
+		now:          time.Now,
+		names:        make(map[string]string),
+		destinations: make(map[string]*destinationHealth),
+	}
+}
+
+// WithHealthRegistry records the connectivity failures of the requests of
+// the Dispatcher to the destinations registered with the given registry.
+func WithHealthRegistry(registry *HealthRegistry) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.health = registry
+	}
+}
+
+// Register registers the destination under the given name, replacing the
+// destination registered under the name before. Destinations registered under
+// several names share their health.
+func (h *HealthRegistry) Register(name string, destination duckv1.Addressable) {
+	if destination.URL == nil {
+		return
+	}
+	url := destination.URL.String()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if previous, ok := h.names[name]; ok && previous != url {
+		h.unregister(name)
+	}
+	h.names[name] = url
+	if _, ok := h.destinations[url]; !ok {
+		h.destinations[url] = &destinationHealth{}
+	}
+}
+
+// Unregister removes the destination registered under the given name.
+func (h *HealthRegistry) Unregister(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unregister(name)
+}
+
+// unregister removes the name, and the destination once no name refers to it
+// anymore. The caller must hold mu.
+func (h *HealthRegistry) unregister(name string) {
+	url, ok := h.names[name]
+	if !ok {
+		return
+	}
+	delete(h.names, name)
+	for _, other := range h.names {
+		if other == url {
+			return
+		}
+	}
+	delete(h.destinations, url)
+}
+
+// observe records the outcome of a request to the target.
+func (h *HealthRegistry) observe(target duckv1.Addressable, reason attributes.KnativeErrorReason, err error) {
+	if target.URL == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	destination, ok := h.destinations[target.URL.String()]
+	if !ok {
+		return
+	}
+	switch {
+	case err == nil, reason == attributes.KnativeErrorReasonNon2xx:
+		*destination = destinationHealth{}
+	case reason == attributes.KnativeErrorReasonCircuitOpen, reason == attributes.KnativeErrorReasonTLS:
+		if destination.failures == 0 {
+			destination.firstFailed = h.now()
+		}
+		destination.failures++
+		destination.reason = reason
+	}
+}
+
+// Destinations returns the health of the registered destinations, sorted by
+// name.
+func (h *HealthRegistry) Destinations() []DestinationHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	destinations := make([]DestinationHealth, 0, len(h.names))
+	for name, url := range h.names {
+		health := DestinationHealth{Name: name, URL: url, Healthy: true}
+		if d := h.destinations[url]; d.failures >= h.threshold {
+			since := d.firstFailed
+			health.Healthy = false
+			health.Reason = d.reason
+			health.Since = &since
+		}
+		destinations = append(destinations, health)
+	}
+	sort.Slice(destinations, func(i, j int) bool {
+		return destinations[i].Name < destinations[j].Name
+	})
+	return destinations
+}
+
+// Healthy returns whether none of the registered destinations is degraded.
+func (h *HealthRegistry) Healthy() bool {
+	for _, d := range h.Destinations() {
+		if !d.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP answers with the health of the registered destinations as JSON,
+// with 503 Service Unavailable if any of them is degraded.
+func (h *HealthRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	destinations := h.Destinations()
+	status := http.StatusOK
+	for _, d := range destinations {
+		if !d.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(destinations)
+}
+
+// Checker returns a health check for WithChecker, answering the kubelet
+// probes to ReadinessPath with the health of the registered destinations, see
+// ServeHTTP, and passing the other probes to next. The probes are answered
+// with 200 OK without next. Pointing the readiness probe, but not the liveness
+// probe, to ReadinessPath keeps degraded Pods running, while taking them out
+// of their Services.
+func (h *HealthRegistry) Checker(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == ReadinessPath:
+			h.ServeHTTP(w, r)
+		case next != nil:
+			next(w, r)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
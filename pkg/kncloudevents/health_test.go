@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+func TestHealthRegistry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	registry := NewHealthRegistry(2)
+	registry.now = func() time.Time { return now }
+
+	sink := duckv1.Addressable{URL: apis.HTTP("sink.example.com")}
+	dls := duckv1.Addressable{URL: apis.HTTP("dls.example.com")}
+	other := duckv1.Addressable{URL: apis.HTTP("other.example.com")}
+	registry.Register("sink", sink)
+	registry.Register("dls", dls)
+
+	failed := errors.New("failed")
+	registry.observe(sink, attributes.KnativeErrorReasonTLS, failed)
+	require.True(t, registry.Healthy(), "a single failure must not degrade the destination")
+	registry.observe(sink, attributes.KnativeErrorReasonTimeout, failed)
+	registry.observe(other, attributes.KnativeErrorReasonTLS, failed)
+	require.True(t, registry.Healthy(), "other failures and unregistered destinations must be ignored")
+
+	registry.observe(sink, attributes.KnativeErrorReasonCircuitOpen, failed)
+	require.False(t, registry.Healthy())
+	require.Equal(t, []DestinationHealth{
+		{Name: "dls", URL: "http://dls.example.com", Healthy: true},
+		{Name: "sink", URL: "http://sink.example.com", Reason: attributes.KnativeErrorReasonCircuitOpen, Since: &now},
+	}, registry.Destinations())
+
+	w := httptest.NewRecorder()
+	registry.Checker(nil)(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var destinations []DestinationHealth
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &destinations))
+	require.Len(t, destinations, 2)
+
+	// liveness probes aren't affected
+	w = httptest.NewRecorder()
+	registry.Checker(nil)(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// any response recovers the destination
+	registry.observe(sink, attributes.KnativeErrorReasonNon2xx, failed)
+	require.True(t, registry.Healthy())
+
+	registry.observe(dls, attributes.KnativeErrorReasonTLS, failed)
+	registry.observe(dls, attributes.KnativeErrorReasonTLS, failed)
+	require.False(t, registry.Healthy())
+	registry.Unregister("dls")
+	require.True(t, registry.Healthy())
+	require.Len(t, registry.Destinations(), 1)
+}
+
+func TestDispatcherWithHealthRegistry(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	// the certificate of the server isn't trusted
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	url, err := apis.ParseURL(server.URL)
+	require.NoError(t, err)
+	destination := duckv1.Addressable{URL: url}
+
+	registry := NewHealthRegistry(2)
+	registry.Register("sink", destination)
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHealthRegistry(registry))
+
+	for i := 0; i < 2; i++ {
+		info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+		require.Error(t, err)
+		require.Equal(t, attributes.KnativeErrorReasonTLS, info.ErrorReason)
+	}
+	health := registry.Destinations()
+	require.Len(t, health, 1)
+	require.False(t, health[0].Healthy)
+	require.Equal(t, attributes.KnativeErrorReasonTLS, health[0].Reason)
+}
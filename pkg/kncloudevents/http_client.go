@@ -18,14 +18,20 @@ package kncloudevents
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	nethttp "net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"go.opencensus.io/plugin/ochttp"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/logging"
 	"knative.dev/pkg/network"
 	"knative.dev/pkg/tracing/propagation/tracecontextb3"
 
@@ -44,37 +50,95 @@ var (
 
 type clientsHolder struct {
 	clientsMu       sync.Mutex
-	clients         map[string]*nethttp.Client
+	clients         map[clientKey]*nethttp.Client
 	timerMu         sync.Mutex
 	connectionArgs  *ConnectionArgs
+	redirectPolicy  RedirectPolicy
+	proxyResolver   ProxyResolver
 	cleanupInterval time.Duration
 	cancelCleanup   context.CancelFunc
+	hooks           map[int]ClientLifecycleHooks
+	nextHookID      int
+	// lifecycleEvents are the clients created and closed while clientsMu is
+	// held, which are passed to the hooks once it's released.
+	lifecycleEvents []clientLifecycleEvent
+}
+
+type clientLifecycleEvent struct {
+	info    ClientInfo
+	created bool
 }
 
 func init() {
 	ctx, cancel := context.WithCancel(context.Background())
 	clients = clientsHolder{
-		clients:         make(map[string]*nethttp.Client),
+		clients:         make(map[clientKey]*nethttp.Client),
+		redirectPolicy:  DefaultRedirectPolicy,
 		cancelCleanup:   cancel,
 		cleanupInterval: defaultCleanupInterval,
+		hooks:           make(map[int]ClientLifecycleHooks),
 	}
 	go cleanupClientsMap(ctx)
 }
 
+// clientKey identifies the client of an addressable. Clients trust the CA certs
+// of the addressable, so addressables with the same URL but different CA certs
+// need different clients. The audience isn't part of the key, since tokens are
+// added per request and don't affect the client.
+type clientKey struct {
+	url string
+	// caCertsHash is the hash of the CA certs, empty if there are none.
+	caCertsHash string
+	// namespace is the namespace the client sends for, empty unless
+	// ConnectionArgs.IsolateNamespaces is set.
+	namespace string
+}
+
+func (k clientKey) info() ClientInfo {
+	return ClientInfo{
+		URL:         k.url,
+		Namespace:   k.namespace,
+		CACertsHash: k.caCertsHash,
+	}
+}
+
+func clientKeyFor(addressable duckv1.Addressable) clientKey {
+	return clientKeyForNamespace(addressable, "")
+}
+
+func clientKeyForNamespace(addressable duckv1.Addressable, namespace string) clientKey {
+	key := clientKey{url: clientURL(addressable)}
+	if clients.connectionArgs != nil && clients.connectionArgs.IsolateNamespaces {
+		key.namespace = namespace
+	}
+	if addressable.CACerts != nil && *addressable.CACerts != "" {
+		hash := sha256.Sum256([]byte(*addressable.CACerts))
+		key.caCertsHash = hex.EncodeToString(hash[:])
+	}
+	return key
+}
+
 func getClientForAddressable(cfg eventingtls.ClientConfig, addressable duckv1.Addressable) (*nethttp.Client, error) {
+	return getClientForAddressableInNamespace(cfg, addressable, "")
+}
+
+// getClientForAddressableInNamespace returns the client to send to the
+// addressable for the given namespace. With ConnectionArgs.IsolateNamespaces,
+// each namespace gets its own client, and so its own connection pool.
+func getClientForAddressableInNamespace(cfg eventingtls.ClientConfig, addressable duckv1.Addressable, namespace string) (*nethttp.Client, error) {
 	clients.clientsMu.Lock()
-	defer clients.clientsMu.Unlock()
+	defer unlockClients()
 
-	clientKey := addressable.URL.String()
+	key := clientKeyForNamespace(addressable, namespace)
 
-	client, ok := clients.clients[clientKey]
+	client, ok := clients.clients[key]
 	if !ok {
 		newClient, err := createNewClient(cfg, addressable)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create new client for addressable: %w", err)
 		}
 
-		clients.clients[clientKey] = newClient
+		addClient(key, newClient)
 
 		client = newClient
 	}
@@ -85,54 +149,238 @@ func getClientForAddressable(cfg eventingtls.ClientConfig, addressable duckv1.Ad
 func createNewClient(cfg eventingtls.ClientConfig, addressable duckv1.Addressable) (*nethttp.Client, error) {
 	var base = nethttp.DefaultTransport.(*nethttp.Transport).Clone()
 
+	proxy, err := proxyFor(addressable)
+	if err != nil {
+		return nil, err
+	}
+
 	if eventingtls.IsHttpsSink(addressable.URL.String()) {
 		clientConfig := eventingtls.ClientConfig{
 			CACerts:                    addressable.CACerts,
 			TrustBundleConfigMapLister: cfg.TrustBundleConfigMapLister,
 		}
 
-		base.DialTLSContext = func(ctx context.Context, net, addr string) (net.Conn, error) {
+		if proxy != nil {
+			// the TLS handshake with the destination happens within the
+			// tunnel, with the TLS config of the transport
 			tlsConfig, err := eventingtls.GetTLSClientConfig(clientConfig)
 			if err != nil {
 				return nil, err
 			}
-			return network.DialTLSWithBackOff(ctx, net, addr, tlsConfig)
+			base.TLSClientConfig = tlsConfig
+		} else {
+			connectionArgs := clients.connectionArgs
+			base.DialTLSContext = func(ctx context.Context, net, addr string) (net.Conn, error) {
+				tlsConfig, err := eventingtls.GetTLSClientConfig(clientConfig)
+				if err != nil {
+					return nil, err
+				}
+				return network.DialTLSWithBackOff(ctx, connectionArgs.network(net), addr, tlsConfig)
+			}
 		}
 	}
 
 	clients.connectionArgs.configureTransport(base)
+
+	var transport nethttp.RoundTripper = base
+	if proxy != nil {
+		if err := proxy.configureTransport(base, clients.connectionArgs); err != nil {
+			return nil, err
+		}
+		transport = &proxyHeaderTransport{base: base, header: proxy.Header}
+	}
+
 	client := &nethttp.Client{
 		// Add output tracing.
 		Transport: &ochttp.Transport{
-			Base:        base,
+			Base:        transport,
 			Propagation: tracecontextb3.TraceContextEgress,
 		},
+		CheckRedirect: clients.redirectPolicy.checkRedirect,
 	}
 
 	return client, nil
 }
 
+// AddOrUpdateAddressableHandler replaces the clients for the URL of the
+// addressable with a client for the addressable, e.g. once its CA certs changed.
+// When ConnectionArgs.IsolateNamespaces is set, a client is pre-built for each
+// namespace that had a client for the URL; other namespaces create theirs on
+// first use.
 func AddOrUpdateAddressableHandler(cfg eventingtls.ClientConfig, addressable duckv1.Addressable) {
 	clients.clientsMu.Lock()
-	defer clients.clientsMu.Unlock()
+	defer unlockClients()
+
+	url := clientURL(addressable)
+	namespaces := sets.New[string]()
+	for key := range clients.clients {
+		if key.url == url {
+			namespaces.Insert(key.namespace)
+		}
+	}
+	if namespaces.Len() == 0 {
+		namespaces.Insert("")
+	}
+
+	newClients := make(map[clientKey]*nethttp.Client, namespaces.Len())
+	for namespace := range namespaces {
+		client, err := createNewClient(cfg, addressable)
+		if err != nil {
+			logging.FromContext(context.Background()).Errorw("Failed to create new client",
+				zap.String("url", url), zap.Error(err))
+			return
+		}
+		newClients[clientKeyForNamespace(addressable, namespace)] = client
+	}
+	// clients for previous CA certs of the addressable aren't used anymore
+	deleteClientsForURL(url)
+	for key, client := range newClients {
+		addClient(key, client)
+	}
+}
 
-	clientKey := addressable.URL.String()
+// DeleteAddressableHandler deletes the clients for the URL of the addressable,
+// whichever CA certs they trust.
+func DeleteAddressableHandler(addressable duckv1.Addressable) {
+	clients.clientsMu.Lock()
+	defer unlockClients()
 
-	client, err := createNewClient(cfg, addressable)
-	if err != nil {
-		fmt.Printf("failed to create new client: %v", err)
+	deleteClientsForURL(clientURL(addressable))
+}
+
+// clientURL returns the URL the clients for the addressable are cached for,
+// without the credentials of the URL, so that they don't end up in logs.
+func clientURL(addressable duckv1.Addressable) string {
+	url, _ := withoutUserinfo(addressable.URL)
+	return url.String()
+}
+
+// deleteClientsForURL deletes the clients for the given URL. The caller must hold clients.clientsMu.
+func deleteClientsForURL(url string) {
+	for key := range clients.clients {
+		if key.url == url {
+			removeClient(key)
+		}
+	}
+}
+
+// addClient adds the client to the cache. The caller must hold clients.clientsMu.
+func addClient(key clientKey, client *nethttp.Client) {
+	clients.clients[key] = client
+	if len(clients.hooks) > 0 {
+		clients.lifecycleEvents = append(clients.lifecycleEvents, clientLifecycleEvent{info: key.info(), created: true})
+	}
+}
+
+// removeClient closes the idle connections of the client, and removes it from
+// the cache. The caller must hold clients.clientsMu.
+func removeClient(key clientKey) {
+	client, ok := clients.clients[key]
+	if !ok {
 		return
 	}
-	clients.clients[clientKey] = client
+	client.CloseIdleConnections()
+	delete(clients.clients, key)
+	if len(clients.hooks) > 0 {
+		clients.lifecycleEvents = append(clients.lifecycleEvents, clientLifecycleEvent{info: key.info()})
+	}
 }
 
-func DeleteAddressableHandler(addressable duckv1.Addressable) {
+// unlockClients releases clients.clientsMu, and then calls the lifecycle hooks
+// for the clients created and closed while it was held.
+func unlockClients() {
+	events := clients.lifecycleEvents
+	clients.lifecycleEvents = nil
+	var hooks []ClientLifecycleHooks
+	if len(events) > 0 {
+		for _, h := range clients.hooks {
+			hooks = append(hooks, h)
+		}
+	}
+	clients.clientsMu.Unlock()
+
+	for _, event := range events {
+		for _, h := range hooks {
+			if event.created && h.OnCreate != nil {
+				h.OnCreate(event.info)
+			} else if !event.created && h.OnClose != nil {
+				h.OnClose(event.info)
+			}
+		}
+	}
+}
+
+// ClientInfo describes a cached client.
+type ClientInfo struct {
+	// URL is the URL of the addressable the client sends to.
+	URL string
+	// Namespace is the namespace the client sends for, empty unless
+	// ConnectionArgs.IsolateNamespaces is set.
+	Namespace string
+	// CACertsHash is the hex encoded SHA-256 hash of the CA certs the client
+	// trusts, empty if there are none.
+	CACertsHash string
+}
+
+// Snapshot returns the currently cached clients, sorted by URL, namespace and
+// CA certs hash.
+func Snapshot() []ClientInfo {
+	clients.clientsMu.Lock()
+	defer clients.clientsMu.Unlock()
+
+	infos := make([]ClientInfo, 0, len(clients.clients))
+	for key := range clients.clients {
+		infos = append(infos, key.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].URL != infos[j].URL {
+			return infos[i].URL < infos[j].URL
+		}
+		if infos[i].Namespace != infos[j].Namespace {
+			return infos[i].Namespace < infos[j].Namespace
+		}
+		return infos[i].CACertsHash < infos[j].CACertsHash
+	})
+	return infos
+}
+
+// CloseAll closes the idle connections of all cached clients and removes them,
+// e.g. to tear down the transports after tests or when reloading the config.
+// Active connections stay open until their request finishes, and then go idle
+// in a transport nobody references anymore. Clients are recreated when they're
+// needed again.
+func CloseAll() {
+	clients.clientsMu.Lock()
+	defer unlockClients()
+
+	resetClients()
+}
+
+// ClientLifecycleHooks are called when cached clients are created and closed,
+// e.g. to track the clients of long running components. The hooks are called
+// after the cache is unlocked, so they may call the functions of this package,
+// but may be called concurrently.
+type ClientLifecycleHooks struct {
+	OnCreate func(ClientInfo)
+	OnClose  func(ClientInfo)
+}
+
+// RegisterClientLifecycleHooks registers the hooks, until the returned function
+// is called.
+func RegisterClientLifecycleHooks(hooks ClientLifecycleHooks) (unregister func()) {
 	clients.clientsMu.Lock()
 	defer clients.clientsMu.Unlock()
 
-	clientKey := addressable.URL.String()
+	id := clients.nextHookID
+	clients.nextHookID++
+	clients.hooks[id] = hooks
+
+	return func() {
+		clients.clientsMu.Lock()
+		defer clients.clientsMu.Unlock()
 
-	delete(clients.clients, clientKey)
+		delete(clients.hooks, id)
+	}
 }
 
 // ConfigureConnectionArgs configures the new connection args.
@@ -140,28 +388,73 @@ func DeleteAddressableHandler(addressable duckv1.Addressable) {
 func ConfigureConnectionArgs(ca *ConnectionArgs) {
 
 	clients.clientsMu.Lock()
-	defer clients.clientsMu.Unlock()
+	defer unlockClients()
 
 	// Check if same config
 	if clients.connectionArgs != nil &&
 		ca != nil &&
-		ca.MaxIdleConns == clients.connectionArgs.MaxIdleConns &&
-		ca.MaxIdleConnsPerHost == clients.connectionArgs.MaxIdleConnsPerHost {
+		*ca == *clients.connectionArgs {
 		return
 	}
 
-	if len(clients.clients) > 0 {
-		// Let's try to clean up a bit the existing clients
-		// Note: this won't remove it nor close it
-		for _, clientEntry := range clients.clients {
-			clientEntry.CloseIdleConnections()
-		}
+	resetClients()
+
+	clients.connectionArgs = ca
+}
 
-		// Resetting clients
-		clients.clients = make(map[string]*nethttp.Client)
+// RedirectPolicy controls which redirects the clients used for dispatching
+// follow. Redirects which aren't followed result in the redirect response
+// being returned, so the dispatch fails with its status code.
+type RedirectPolicy struct {
+	// AllowCrossHost allows redirects to another scheme or host than the one of
+	// the original request. Otherwise, events could be re-sent to any Location
+	// a destination answers with.
+	AllowCrossHost bool
+	// MaxRedirects is the maximum number of redirects followed, 0 doesn't follow redirects.
+	MaxRedirects int
+}
+
+// DefaultRedirectPolicy follows up to 10 redirects, like the Go http client,
+// but only to the scheme and host of the original request.
+var DefaultRedirectPolicy = RedirectPolicy{
+	MaxRedirects: 10,
+}
+
+// ConfigureRedirectPolicy configures the redirect policy of the clients.
+// Use sparingly, because it recreates all clients.
+func ConfigureRedirectPolicy(policy RedirectPolicy) {
+	clients.clientsMu.Lock()
+	defer unlockClients()
+
+	if clients.redirectPolicy == policy {
+		return
 	}
 
-	clients.connectionArgs = ca
+	resetClients()
+
+	clients.redirectPolicy = policy
+}
+
+func (p RedirectPolicy) checkRedirect(req *nethttp.Request, via []*nethttp.Request) error {
+	// via holds the requests sent so far, so following this redirect makes len(via) redirects
+	if len(via) > p.MaxRedirects {
+		return nethttp.ErrUseLastResponse
+	}
+	if !p.AllowCrossHost {
+		original := via[0].URL
+		if req.URL.Scheme != original.Scheme || req.URL.Host != original.Host {
+			return nethttp.ErrUseLastResponse
+		}
+	}
+	return nil
+}
+
+// resetClients closes the idle connections of all clients, and removes them, so
+// that they are recreated. The caller must hold clients.clientsMu.
+func resetClients() {
+	for key := range clients.clients {
+		removeClient(key)
+	}
 }
 
 // SetClientCleanupInterval sets the interval before the clients map is re-checked for expired entries.
@@ -187,14 +480,65 @@ type ConnectionArgs struct {
 	MaxIdleConns int
 	// MaxIdleConnsPerHost refers to the max idle connections per host, as in net/http/transport.
 	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections per host, as in net/http/transport.
+	// With IsolateNamespaces, the limit applies per namespace. 0 means no limit.
+	MaxConnsPerHost int
+	// IsolateNamespaces gives each namespace, as sent in the Kn-Namespace header,
+	// its own connection pools, so that a slow sink of one namespace can't use up
+	// the connections available to the other namespaces.
+	IsolateNamespaces bool
+	// DialFallbackDelay is the time to wait for a connection with the preferred
+	// IP family of a dual-stack host, before also trying the other family
+	// (Happy Eyeballs), as in net.Dialer. 0 uses the Go default of 300ms, and a
+	// negative value disables the fallback. It doesn't apply to TLS connections.
+	DialFallbackDelay time.Duration
+	// IPFamily restricts connections to the given IP family, e.g. so that sinks
+	// with broken AAAA records don't delay connections. Empty uses both families.
+	IPFamily IPFamily
 }
 
+// IPFamily is an IP family connections can be restricted to.
+type IPFamily string
+
+const (
+	IPFamilyIPv4 IPFamily = "IPv4"
+	IPFamilyIPv6 IPFamily = "IPv6"
+)
+
 func (ca *ConnectionArgs) configureTransport(transport *nethttp.Transport) {
 	if ca == nil {
 		return
 	}
 	transport.MaxIdleConns = ca.MaxIdleConns
 	transport.MaxIdleConnsPerHost = ca.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = ca.MaxConnsPerHost
+
+	if ca.DialFallbackDelay != 0 || ca.IPFamily != "" {
+		// same as the dialer of the default transport
+		dialer := &net.Dialer{
+			Timeout:       30 * time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: ca.DialFallbackDelay,
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, ca.network(network), addr)
+		}
+	}
+}
+
+// network returns the network to dial, restricted to the configured IP family.
+func (ca *ConnectionArgs) network(network string) string {
+	if ca == nil || network != "tcp" {
+		return network
+	}
+	switch ca.IPFamily {
+	case IPFamilyIPv4:
+		return "tcp4"
+	case IPFamilyIPv6:
+		return "tcp6"
+	default:
+		return network
+	}
 }
 
 func cleanupClientsMap(ctx context.Context) {
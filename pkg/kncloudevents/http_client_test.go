@@ -17,9 +17,13 @@ limitations under the License.
 package kncloudevents
 
 import (
+	"context"
 	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/cloudevents/sdk-go/v2/test"
 	"github.com/stretchr/testify/require"
 	"go.opencensus.io/plugin/ochttp"
 	"knative.dev/pkg/apis"
@@ -91,6 +95,58 @@ func Test_getClientForAddressable(t *testing.T) {
 	}
 }
 
+func Test_getClientForAddressableKeyedByCACerts(t *testing.T) {
+	otherCaCerts := testCaCerts + "\n"
+	url := apis.HTTPS("foo.bar")
+	withoutCACerts := duckv1.Addressable{URL: url}
+	withCACerts := duckv1.Addressable{URL: url, CACerts: &testCaCerts}
+	withOtherCACerts := duckv1.Addressable{URL: url, CACerts: &otherCaCerts}
+	t.Cleanup(func() { DeleteAddressableHandler(withoutCACerts) })
+
+	get := func(addressable duckv1.Addressable) *nethttp.Client {
+		client, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), addressable)
+		require.Nil(t, err)
+		return client
+	}
+
+	clientWithout := get(withoutCACerts)
+	clientWith := get(withCACerts)
+	clientWithOther := get(withOtherCACerts)
+	require.NotSame(t, clientWithout, clientWith)
+	require.NotSame(t, clientWith, clientWithOther)
+	require.Same(t, clientWith, get(duckv1.Addressable{URL: url, CACerts: &testCaCerts}))
+
+	// updating the addressable replaces the clients for its URL
+	AddOrUpdateAddressableHandler(eventingtls.NewDefaultClientConfig(), withOtherCACerts)
+	require.NotSame(t, clientWithOther, get(withOtherCACerts))
+	require.NotSame(t, clientWith, get(withCACerts))
+
+	DeleteAddressableHandler(withoutCACerts)
+	clients.clientsMu.Lock()
+	defer clients.clientsMu.Unlock()
+	for key := range clients.clients {
+		require.NotEqual(t, url.String(), key.url)
+	}
+}
+
+func Test_getClientForAddressableWithUserinfo(t *testing.T) {
+	withUserinfo := duckv1.Addressable{URL: &apis.URL{Scheme: "https", Host: "foo.bar:8443", User: url.UserPassword("user", "pass")}}
+	withoutUserinfo := duckv1.Addressable{URL: &apis.URL{Scheme: "https", Host: "foo.bar:8443"}}
+	t.Cleanup(func() { DeleteAddressableHandler(withoutUserinfo) })
+
+	client, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), withUserinfo)
+	require.Nil(t, err)
+	other, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), withoutUserinfo)
+	require.Nil(t, err)
+	require.Same(t, client, other)
+
+	clients.clientsMu.Lock()
+	defer clients.clientsMu.Unlock()
+	for key := range clients.clients {
+		require.NotContains(t, key.url, "pass")
+	}
+}
+
 func Test_ConfigureConnectionArgs(t *testing.T) {
 	target := duckv1.Addressable{
 		URL: apis.HTTP("foo.bar"),
@@ -140,6 +196,265 @@ func Test_ConfigureConnectionArgs(t *testing.T) {
 	require.NotSame(t, client2, client3)
 }
 
+func Test_ConfigureConnectionArgsIPFamily(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { ConfigureConnectionArgs(nil) })
+
+	// the server only listens on IPv4
+	serverURL, err := url.Parse(server.URL)
+	require.Nil(t, err)
+	target := duckv1.Addressable{URL: apis.HTTP("localhost:" + serverURL.Port())}
+
+	ConfigureConnectionArgs(&ConnectionArgs{IPFamily: IPFamilyIPv4, DialFallbackDelay: -1})
+	client, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), target)
+	require.Nil(t, err)
+	resp, err := client.Get(target.URL.String())
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, nethttp.StatusAccepted, resp.StatusCode)
+
+	ConfigureConnectionArgs(&ConnectionArgs{IPFamily: IPFamilyIPv6})
+	client, err = getClientForAddressable(eventingtls.NewDefaultClientConfig(), target)
+	require.Nil(t, err)
+	_, err = client.Get(target.URL.String())
+	require.Error(t, err)
+}
+
+func Test_ConfigureConnectionArgsIsolateNamespaces(t *testing.T) {
+	t.Cleanup(func() { ConfigureConnectionArgs(nil) })
+	target := duckv1.Addressable{
+		URL: apis.HTTP("foo.bar"),
+	}
+	cfg := eventingtls.NewDefaultClientConfig()
+
+	// without isolation, all namespaces share the client
+	ConfigureConnectionArgs(&ConnectionArgs{MaxConnsPerHost: 10})
+	clientA, err := getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	clientB, err := getClientForAddressableInNamespace(cfg, target, "b")
+	require.Nil(t, err)
+	require.Same(t, clientA, clientB)
+	require.Equal(t, 10, castToTransport(clientA).MaxConnsPerHost)
+
+	ConfigureConnectionArgs(&ConnectionArgs{MaxConnsPerHost: 10, IsolateNamespaces: true})
+	clientA, err = getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	clientB, err = getClientForAddressableInNamespace(cfg, target, "b")
+	require.Nil(t, err)
+	require.NotSame(t, clientA, clientB)
+	require.NotSame(t, castToTransport(clientA), castToTransport(clientB))
+	require.Equal(t, 10, castToTransport(clientB).MaxConnsPerHost)
+
+	clientA2, err := getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	require.Same(t, clientA, clientA2)
+
+	// deleting the addressable deletes the clients of all namespaces
+	DeleteAddressableHandler(target)
+	clientA2, err = getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	require.NotSame(t, clientA, clientA2)
+}
+
+func Test_AddOrUpdateAddressableHandlerIsolateNamespaces(t *testing.T) {
+	t.Cleanup(func() { ConfigureConnectionArgs(nil) })
+	ConfigureConnectionArgs(&ConnectionArgs{IsolateNamespaces: true})
+	target := duckv1.Addressable{
+		URL: apis.HTTP("foo.bar"),
+	}
+	cfg := eventingtls.NewDefaultClientConfig()
+
+	clientA, err := getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	clientB, err := getClientForAddressableInNamespace(cfg, target, "b")
+	require.Nil(t, err)
+
+	// the clients of the namespaces are replaced by pre-built ones
+	AddOrUpdateAddressableHandler(cfg, target)
+	prebuiltA := clients.clients[clientKeyForNamespace(target, "a")]
+	prebuiltB := clients.clients[clientKeyForNamespace(target, "b")]
+	require.NotNil(t, prebuiltA)
+	require.NotNil(t, prebuiltB)
+	require.NotSame(t, prebuiltA, prebuiltB)
+
+	clientA2, err := getClientForAddressableInNamespace(cfg, target, "a")
+	require.Nil(t, err)
+	clientB2, err := getClientForAddressableInNamespace(cfg, target, "b")
+	require.Nil(t, err)
+	require.NotSame(t, clientA, clientA2)
+	require.NotSame(t, clientB, clientB2)
+	require.Same(t, prebuiltA, clientA2)
+	require.Same(t, prebuiltB, clientB2)
+
+	DeleteAddressableHandler(target)
+}
+
+func Test_IsolateNamespacesLimitsConnectionsPerNamespace(t *testing.T) {
+	t.Cleanup(func() { ConfigureConnectionArgs(nil) })
+	ConfigureConnectionArgs(&ConnectionArgs{MaxConnsPerHost: 1, IsolateNamespaces: true})
+
+	unblock := make(chan struct{})
+	received := make(chan string, 3)
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		received <- r.Header.Get("Kn-Namespace")
+		if r.Header.Get("Kn-Namespace") == "slow" {
+			<-unblock
+		}
+		w.WriteHeader(nethttp.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	serverURL, err := apis.ParseURL(server.URL)
+	require.Nil(t, err)
+	target := duckv1.Addressable{URL: serverURL}
+	d := NewDispatcher(eventingtls.NewDefaultClientConfig(), nil)
+	send := func(namespace string) error {
+		_, err := d.SendEvent(context.Background(), test.FullEvent(), target, WithHeader(nethttp.Header{"Kn-Namespace": []string{namespace}}))
+		return err
+	}
+
+	// the slow namespace uses up its only connection
+	go func() { _ = send("slow") }()
+	require.Equal(t, "slow", <-received)
+
+	// other namespaces still get their own connection
+	require.Nil(t, send("other"))
+	require.Equal(t, "other", <-received)
+}
+
+func Test_ConnectionArgsNetwork(t *testing.T) {
+	var unset *ConnectionArgs
+	require.Equal(t, "tcp", unset.network("tcp"))
+	require.Equal(t, "tcp", (&ConnectionArgs{}).network("tcp"))
+	require.Equal(t, "tcp4", (&ConnectionArgs{IPFamily: IPFamilyIPv4}).network("tcp"))
+	require.Equal(t, "tcp6", (&ConnectionArgs{IPFamily: IPFamilyIPv6}).network("tcp"))
+	// explicit families aren't changed
+	require.Equal(t, "tcp6", (&ConnectionArgs{IPFamily: IPFamilyIPv4}).network("tcp6"))
+}
+
+func Test_RedirectPolicy(t *testing.T) {
+	original := &nethttp.Request{URL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/"}}
+
+	tests := []struct {
+		name   string
+		policy RedirectPolicy
+		to     string
+		via    int
+		want   error
+	}{{
+		name:   "same host",
+		policy: DefaultRedirectPolicy,
+		to:     "http://foo.bar/other",
+		via:    1,
+	}, {
+		name:   "other host",
+		policy: DefaultRedirectPolicy,
+		to:     "http://attacker.example.com/",
+		via:    1,
+		want:   nethttp.ErrUseLastResponse,
+	}, {
+		name:   "other scheme",
+		policy: DefaultRedirectPolicy,
+		to:     "https://foo.bar/",
+		via:    1,
+		want:   nethttp.ErrUseLastResponse,
+	}, {
+		name:   "other host allowed",
+		policy: RedirectPolicy{AllowCrossHost: true, MaxRedirects: 1},
+		to:     "http://attacker.example.com/",
+		via:    1,
+	}, {
+		name:   "too many redirects",
+		policy: RedirectPolicy{MaxRedirects: 2},
+		to:     "http://foo.bar/other",
+		via:    3,
+		want:   nethttp.ErrUseLastResponse,
+	}, {
+		name:   "no redirects",
+		policy: RedirectPolicy{},
+		to:     "http://foo.bar/other",
+		via:    1,
+		want:   nethttp.ErrUseLastResponse,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, err := url.Parse(tt.to)
+			require.Nil(t, err)
+			via := make([]*nethttp.Request, tt.via)
+			for i := range via {
+				via[i] = original
+			}
+			require.Equal(t, tt.want, tt.policy.checkRedirect(&nethttp.Request{URL: to}, via))
+		})
+	}
+}
+
+func Test_ConfigureRedirectPolicy(t *testing.T) {
+	target := duckv1.Addressable{
+		URL: apis.HTTP("foo.bar"),
+	}
+	t.Cleanup(func() { ConfigureRedirectPolicy(DefaultRedirectPolicy) })
+
+	client1, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), target)
+	require.Nil(t, err)
+
+	// the same policy keeps the clients
+	ConfigureRedirectPolicy(DefaultRedirectPolicy)
+	client2, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), target)
+	require.Nil(t, err)
+	require.Same(t, client1, client2)
+
+	ConfigureRedirectPolicy(RedirectPolicy{})
+	client3, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), target)
+	require.Nil(t, err)
+	require.NotSame(t, client1, client3)
+}
+
+func Test_SnapshotAndCloseAll(t *testing.T) {
+	CloseAll()
+	var created, closed []ClientInfo
+	unregister := RegisterClientLifecycleHooks(ClientLifecycleHooks{
+		OnCreate: func(info ClientInfo) {
+			// hooks may use the cache
+			require.Contains(t, Snapshot(), info)
+			created = append(created, info)
+		},
+		OnClose: func(info ClientInfo) { closed = append(closed, info) },
+	})
+	t.Cleanup(unregister)
+
+	foo := duckv1.Addressable{URL: apis.HTTP("foo.bar")}
+	bar := duckv1.Addressable{URL: apis.HTTPS("bar.foo"), CACerts: &testCaCerts}
+	for _, addressable := range []duckv1.Addressable{foo, bar} {
+		_, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), addressable)
+		require.Nil(t, err)
+	}
+
+	snapshot := Snapshot()
+	require.Len(t, snapshot, 2)
+	require.Equal(t, "http://foo.bar", snapshot[0].URL)
+	require.Empty(t, snapshot[0].CACertsHash)
+	require.Equal(t, "https://bar.foo", snapshot[1].URL)
+	require.NotEmpty(t, snapshot[1].CACertsHash)
+	require.ElementsMatch(t, snapshot, created)
+
+	CloseAll()
+	require.Empty(t, Snapshot())
+	require.ElementsMatch(t, snapshot, closed)
+
+	// unregistered hooks aren't called anymore
+	unregister()
+	_, err := getClientForAddressable(eventingtls.NewDefaultClientConfig(), foo)
+	require.Nil(t, err)
+	require.Len(t, created, 2)
+	CloseAll()
+	require.Len(t, closed, 2)
+}
+
 func castToTransport(client *nethttp.Client) *nethttp.Transport {
 	return client.Transport.(*ochttp.Transport).Base.(*nethttp.Transport)
 }
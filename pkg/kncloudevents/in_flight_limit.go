@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"sync"
+)
+
+// InFlightLimits limits the deliveries in flight per key, e.g. per Trigger, so
+// that the deliveries of a key with a slow destination can't hold up all the
+// workers of a component. In contrast to the adaptive concurrency limits of
+// WithAdaptiveConcurrency, the limits are fixed, and they bound whole
+// deliveries, including their retries, replies and dead letter sinks.
+type InFlightLimits struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewInFlightLimits returns in-flight limits without deliveries in flight.
+func NewInFlightLimits() *InFlightLimits {
+	return &InFlightLimits{
+		inFlight: make(map[string]int),
+	}
+}
+
+// InFlight returns the number of deliveries in flight for the key.
+func (l *InFlightLimits) InFlight(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight[key]
+}
+
+// acquire counts a delivery in flight for the key, unless the limit is
+// reached. A limit of zero or less doesn't limit the deliveries. It returns
+// the number of deliveries in flight afterwards.
+func (l *InFlightLimits) acquire(key string, limit int) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight[key]
+	if limit > 0 && inFlight >= limit {
+		return inFlight, false
+	}
+	l.inFlight[key] = inFlight + 1
+	return inFlight + 1, true
+}
+
+// release counts a delivery of the key as done, and returns the number of
+// deliveries in flight afterwards.
+func (l *InFlightLimits) release(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight[key] - 1
+	if inFlight <= 0 {
+		delete(l.inFlight, key)
+		return 0
+	}
+	l.inFlight[key] = inFlight
+	return inFlight
+}
+
+// inFlightLimit is the in-flight limit of a delivery, see WithInFlightLimit.
+type inFlightLimit struct {
+	limits  *InFlightLimits
+	key     string
+	limit   int
+	observe func(inFlight int)
+}
+
+// WithInFlightLimit counts the delivery in flight for the key of the limits.
+// If limit deliveries of the key are in flight already, the delivery fails
+// with ErrConcurrencyLimitExceeded right away, without sending any request. A
+// limit of zero or less only counts the delivery. observe, if not nil, is
+// called with the number of deliveries of the key in flight whenever it
+// changes, e.g. to report it as a gauge.
+func WithInFlightLimit(limits *InFlightLimits, key string, limit int, observe func(inFlight int)) SendOption {
+	return func(sc *senderConfig) error {
+		sc.inFlightLimit = &inFlightLimit{
+			limits:  limits,
+			key:     key,
+			limit:   limit,
+			observe: observe,
+		}
+		return nil
+	}
+}
+
+// acquire counts the delivery in flight, and returns the func to call once
+// the delivery is done, or false if the limit is reached.
+func (l *inFlightLimit) acquire() (func(), bool) {
+	inFlight, ok := l.limits.acquire(l.key, l.limit)
+	if !ok {
+		return nil, false
+	}
+	l.report(inFlight)
+	return func() {
+		l.report(l.limits.release(l.key))
+	}, true
+}
+
+func (l *inFlightLimit) report(inFlight int) {
+	if l.observe != nil {
+		l.observe(inFlight)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestInFlightLimits(t *testing.T) {
+	limits := NewInFlightLimits()
+
+	inFlight, ok := limits.acquire("a", 2)
+	require.True(t, ok)
+	require.Equal(t, 1, inFlight)
+	inFlight, ok = limits.acquire("a", 2)
+	require.True(t, ok)
+	require.Equal(t, 2, inFlight)
+	_, ok = limits.acquire("a", 2)
+	require.False(t, ok)
+
+	// keys are limited independently
+	_, ok = limits.acquire("b", 1)
+	require.True(t, ok)
+
+	require.Equal(t, 1, limits.release("a"))
+	_, ok = limits.acquire("a", 2)
+	require.True(t, ok)
+
+	// without limit, deliveries are only counted
+	for i := 0; i < 10; i++ {
+		_, ok = limits.acquire("c", 0)
+		require.True(t, ok)
+	}
+	require.Equal(t, 10, limits.InFlight("c"))
+
+	require.Equal(t, 0, limits.release("b"))
+	require.Equal(t, 0, limits.InFlight("b"))
+	require.NotContains(t, limits.inFlight, "b")
+}
+
+func TestDispatchWithInFlightLimit(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().Return(kncloudeventstest.Status(http.StatusAccepted).Delayed(200 * time.Millisecond))
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+
+	limits := NewInFlightLimits()
+	var observedMu sync.Mutex
+	var observed []int
+	option := WithInFlightLimit(limits, "trigger", 1, func(inFlight int) {
+		observedMu.Lock()
+		defer observedMu.Unlock()
+		observed = append(observed, inFlight)
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, option)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return limits.InFlight("trigger") == 1 }, time.Second, time.Millisecond)
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, option)
+	require.ErrorIs(t, err, ErrConcurrencyLimitExceeded)
+	require.Equal(t, NoResponse, info.ResponseCode)
+
+	require.Nil(t, <-done)
+	require.Len(t, fakeClient.Requests(), 1)
+	require.Equal(t, 0, limits.InFlight("trigger"))
+	require.Equal(t, []int{1, 0}, observed)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// DispatchRequest is a single request of a delivery, i.e. to the destination,
+// the reply or the dead letter sink.
+type DispatchRequest struct {
+	Target  duckv1.Addressable
+	Message binding.Message
+	// Header contains the additional headers of the request. It is reused
+	// once the request completed, so it must not be retained.
+	Header http.Header
+}
+
+// DispatchFunc sends a request, with retries, and returns the response
+// message, if the response is an event, and the DispatchInfo of the request.
+type DispatchFunc func(ctx context.Context, request DispatchRequest) (binding.Message, *DispatchInfo, error)
+
+// DispatchInterceptor wraps the DispatchFunc of the next interceptor, or of
+// the dispatcher, e.g. to change the request, the response or to send the
+// request to another target. An interceptor may handle the request without
+// calling next.
+type DispatchInterceptor func(next DispatchFunc) DispatchFunc
+
+// NoopDispatchInterceptor is a DispatchInterceptor which leaves requests
+// unchanged.
+func NoopDispatchInterceptor(next DispatchFunc) DispatchFunc {
+	return next
+}
+
+// RegisterDispatchInterceptor adds an interceptor around every request the
+// Dispatcher sends. Interceptors run in the order they were registered: the
+// first interceptor gets the request first and the response last. Interceptors
+// run inside the span of the request, after the delivery budget was checked,
+// and before the send option transformers are applied to the message.
+func (d *Dispatcher) RegisterDispatchInterceptor(interceptor DispatchInterceptor) {
+	d.interceptorsMu.Lock()
+	defer d.interceptorsMu.Unlock()
+	d.interceptors = append(d.interceptors, interceptor)
+}
+
+// intercept wraps dispatch with the registered interceptors.
+func (d *Dispatcher) intercept(dispatch DispatchFunc) DispatchFunc {
+	d.interceptorsMu.RLock()
+	defer d.interceptorsMu.RUnlock()
+	for i := len(d.interceptors) - 1; i >= 0; i-- {
+		dispatch = d.interceptors[i](dispatch)
+	}
+	return dispatch
+}
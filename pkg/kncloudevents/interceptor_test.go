@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestDispatchInterceptorOrder(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	var calls []string
+	recording := func(name string) kncloudevents.DispatchInterceptor {
+		return func(next kncloudevents.DispatchFunc) kncloudevents.DispatchFunc {
+			return func(ctx context.Context, request kncloudevents.DispatchRequest) (binding.Message, *kncloudevents.DispatchInfo, error) {
+				calls = append(calls, name+" request")
+				response, info, err := next(ctx, request)
+				calls = append(calls, name+" response")
+				return response, info, err
+			}
+		}
+	}
+	dispatcher.RegisterDispatchInterceptor(recording("first"))
+	dispatcher.RegisterDispatchInterceptor(kncloudevents.NoopDispatchInterceptor)
+	dispatcher.RegisterDispatchInterceptor(recording("second"))
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+	require.Equal(t, []string{"first request", "second request", "second response", "first response"}, calls)
+}
+
+func TestDispatchInterceptorHeader(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	dispatcher.RegisterDispatchInterceptor(func(next kncloudevents.DispatchFunc) kncloudevents.DispatchFunc {
+		return func(ctx context.Context, request kncloudevents.DispatchRequest) (binding.Message, *kncloudevents.DispatchInfo, error) {
+			header := request.Header.Clone()
+			header.Set("Authorization", "Custom token")
+			request.Header = header
+			return next(ctx, request)
+		}
+	})
+
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+	requests := fakeClient.RequestsTo(destination.URL.String())
+	require.Len(t, requests, 1)
+	require.Equal(t, "Custom token", requests[0].Header.Get("Authorization"))
+}
+
+func TestDispatchInterceptorFailover(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.region-a.example.com")}
+	failover := duckv1.Addressable{URL: apis.HTTP("destination.region-b.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable)).
+		On(failover.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	dispatcher.RegisterDispatchInterceptor(func(next kncloudevents.DispatchFunc) kncloudevents.DispatchFunc {
+		return func(ctx context.Context, request kncloudevents.DispatchRequest) (binding.Message, *kncloudevents.DispatchInfo, error) {
+			if request.Target.URL.String() != destination.URL.String() {
+				return next(ctx, request)
+			}
+			// the message is sent twice
+			message, err := binding.ToEvent(ctx, request.Message)
+			if err != nil {
+				return nil, nil, err
+			}
+			request.Message = binding.ToMessage(message)
+			response, info, err := next(ctx, request)
+			if err == nil {
+				return response, info, nil
+			}
+			request.Target = failover
+			request.Message = binding.ToMessage(message)
+			return next(ctx, request)
+		}
+	})
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+	require.Len(t, fakeClient.RequestsTo(destination.URL.String()), 1)
+	require.Len(t, fakeClient.RequestsTo(failover.URL.String()), 1)
+}
+
+func TestDispatchInterceptorShortCircuit(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+	rejected := errors.New("rejected")
+	dispatcher.RegisterDispatchInterceptor(func(kncloudevents.DispatchFunc) kncloudevents.DispatchFunc {
+		return func(context.Context, kncloudevents.DispatchRequest) (binding.Message, *kncloudevents.DispatchInfo, error) {
+			return nil, nil, rejected
+		}
+	})
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.ErrorIs(t, err, rejected)
+	require.Equal(t, kncloudevents.NoResponse, info.ResponseCode)
+	require.Empty(t, fakeClient.Requests())
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+// DefaultPartitionKeyExtension is the extension used by the CloudEvents
+// partitioning extension to carry the partition key of an event.
+const DefaultPartitionKeyExtension = "partitionkey"
+
+// KeyedOrderedSender sends events with a Dispatcher, serializing the sends of
+// events to the same destination sharing the same value of a CloudEvents
+// extension, e.g. the partition key. Events with different keys, and events
+// without the extension, are sent in parallel.
+//
+// Events with the same key are sent in the order the Send methods are called,
+// and a send only starts once the previous send to the same destination with
+// the same key completed, including its retries. Combined with retries this
+// provides ordered at-least-once delivery per key.
+type KeyedOrderedSender struct {
+	dispatcher *Dispatcher
+	extension  string
+
+	mu sync.Mutex
+	// tails holds, per destination and key, a channel which is closed once the
+	// last send queued for it completed.
+	tails map[orderingKey]chan struct{}
+}
+
+type orderingKey struct {
+	destination string
+	key         string
+}
+
+// NewKeyedOrderedSender returns a KeyedOrderedSender sending with the given
+// Dispatcher and ordering events by the given extension.
+func NewKeyedOrderedSender(dispatcher *Dispatcher, extension string) *KeyedOrderedSender {
+	return &KeyedOrderedSender{
+		dispatcher: dispatcher,
+		extension:  extension,
+		tails:      make(map[orderingKey]chan struct{}),
+	}
+}
+
+// SendEvent sends the given event like Dispatcher.SendEvent, after all previous
+// sends of events with the same key to the same destination completed.
+func (s *KeyedOrderedSender) SendEvent(ctx context.Context, event event.Event, destination duckv1.Addressable, options ...SendOption) (*DispatchInfo, error) {
+	key, ok := s.eventKey(event)
+	if !ok {
+		return s.dispatcher.SendEvent(ctx, event, destination, options...)
+	}
+
+	release, err := s.acquire(ctx, orderingKey{destination: destination.URL.String(), key: key})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.dispatcher.SendEvent(ctx, event, destination, options...)
+}
+
+// SendMessage sends the given message like Dispatcher.SendMessage, after all
+// previous sends of messages with the same key to the same destination
+// completed. Messages not exposing their metadata are sent without ordering.
+func (s *KeyedOrderedSender) SendMessage(ctx context.Context, message binding.Message, destination duckv1.Addressable, options ...SendOption) (*DispatchInfo, error) {
+	key, ok := messageExtension(message, s.extension)
+	if !ok {
+		return s.dispatcher.SendMessage(ctx, message, destination, options...)
+	}
+
+	release, err := s.acquire(ctx, orderingKey{destination: destination.URL.String(), key: key})
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.dispatcher.SendMessage(ctx, message, destination, options...)
+}
+
+// acquire waits until all previous sends for the key completed, and returns a
+// function to be called once the send completed. If the context is done before,
+// an error is returned, and sends queued afterwards still wait for the
+// previous sends.
+func (s *KeyedOrderedSender) acquire(ctx context.Context, key orderingKey) (func(), error) {
+	done := make(chan struct{})
+
+	s.mu.Lock()
+	previous := s.tails[key]
+	s.tails[key] = done
+	s.mu.Unlock()
+
+	release := func() {
+		s.mu.Lock()
+		if s.tails[key] == done {
+			delete(s.tails, key)
+		}
+		s.mu.Unlock()
+		close(done)
+	}
+
+	if previous == nil {
+		return release, nil
+	}
+
+	select {
+	case <-previous:
+		return release, nil
+	case <-ctx.Done():
+		go func() {
+			<-previous
+			release()
+		}()
+		return nil, fmt.Errorf("failed waiting for previous sends with key %q: %w", key.key, ctx.Err())
+	}
+}
+
+func (s *KeyedOrderedSender) eventKey(event event.Event) (string, bool) {
+	value, ok := event.Extensions()[s.extension]
+	if !ok {
+		return "", false
+	}
+	key, err := types.ToString(value)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// messageExtension returns the value of the extension of the message, if the
+// message exposes its metadata and has the extension.
+func messageExtension(message binding.Message, extension string) (string, bool) {
+	reader, ok := message.(binding.MessageMetadataReader)
+	if !ok {
+		return "", false
+	}
+	value := reader.GetExtension(extension)
+	// event messages return an empty string for missing extensions
+	if types.IsZero(value) {
+		return "", false
+	}
+	key, err := types.ToString(value)
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+// orderRecorder is a sink recording the start and end of requests by event id,
+// blocking the request of the event with the id "blocked" until unblocked.
+type orderRecorder struct {
+	mu       sync.Mutex
+	log      []string
+	received chan string
+	unblock  chan struct{}
+}
+
+func newOrderRecorder(t *testing.T) (*orderRecorder, duckv1.Addressable) {
+	r := &orderRecorder{
+		received: make(chan string, 10),
+		unblock:  make(chan struct{}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get("Ce-Id")
+		r.record("start " + id)
+		r.received <- id
+		if id == "blocked" {
+			<-r.unblock
+		}
+		r.record("end " + id)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	url, err := apis.ParseURL(server.URL)
+	require.Nil(t, err)
+	return r, duckv1.Addressable{URL: url}
+}
+
+func (r *orderRecorder) record(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, entry)
+}
+
+func (r *orderRecorder) entries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.log...)
+}
+
+func keyedEvent(id, key string) event.Event {
+	e := test.FullEvent()
+	e.SetID(id)
+	if key != "" {
+		e.SetExtension(DefaultPartitionKeyExtension, key)
+	}
+	return e
+}
+
+// sendQueued sends the event in the background, and waits until it's queued
+// behind the current sends with the same key.
+func sendQueued(t *testing.T, ctx context.Context, s *KeyedOrderedSender, e event.Event, destination duckv1.Addressable, errs chan<- error) {
+	key := orderingKey{destination: destination.URL.String(), key: e.Extensions()[DefaultPartitionKeyExtension].(string)}
+	s.mu.Lock()
+	previous := s.tails[key]
+	s.mu.Unlock()
+
+	go func() {
+		_, err := s.SendEvent(ctx, e, destination)
+		errs <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.tails[key] != previous
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestKeyedOrderedSender(t *testing.T) {
+	ctx := context.Background()
+	recorder, destination := newOrderRecorder(t)
+	s := NewKeyedOrderedSender(NewDispatcher(eventingtls.NewDefaultClientConfig(), nil), DefaultPartitionKeyExtension)
+
+	errs := make(chan error, 3)
+	sendQueued(t, ctx, s, keyedEvent("blocked", "a"), destination, errs)
+	require.Equal(t, "blocked", <-recorder.received)
+	sendQueued(t, ctx, s, keyedEvent("a-2", "a"), destination, errs)
+	sendQueued(t, ctx, s, keyedEvent("a-3", "a"), destination, errs)
+
+	// other keys and events without key aren't blocked
+	_, err := s.SendEvent(ctx, keyedEvent("b-1", "b"), destination)
+	require.Nil(t, err)
+	_, err = s.SendEvent(ctx, keyedEvent("no-key", ""), destination)
+	require.Nil(t, err)
+
+	close(recorder.unblock)
+	for i := 0; i < 3; i++ {
+		require.Nil(t, <-errs)
+	}
+
+	require.Equal(t, []string{
+		"start blocked",
+		"start b-1", "end b-1",
+		"start no-key", "end no-key",
+		"end blocked",
+		"start a-2", "end a-2",
+		"start a-3", "end a-3",
+	}, recorder.entries())
+	require.Empty(t, s.tails)
+}
+
+func TestKeyedOrderedSenderContextDone(t *testing.T) {
+	recorder, destination := newOrderRecorder(t)
+	s := NewKeyedOrderedSender(NewDispatcher(eventingtls.NewDefaultClientConfig(), nil), DefaultPartitionKeyExtension)
+
+	errs := make(chan error, 2)
+	sendQueued(t, context.Background(), s, keyedEvent("blocked", "a"), destination, errs)
+	require.Equal(t, "blocked", <-recorder.received)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.SendEvent(ctx, keyedEvent("cancelled", "a"), destination)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// later sends still wait for the blocked send
+	sendQueued(t, context.Background(), s, keyedEvent("a-3", "a"), destination, errs)
+	close(recorder.unblock)
+	require.Nil(t, <-errs)
+	require.Nil(t, <-errs)
+
+	require.Equal(t, []string{"start blocked", "end blocked", "start a-3", "end a-3"}, recorder.entries())
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.tails) == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestMessageExtension(t *testing.T) {
+	withKey := keyedEvent("id", "a")
+	withoutKey := keyedEvent("id", "")
+
+	key, ok := messageExtension(binding.ToMessage(&withKey), DefaultPartitionKeyExtension)
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+
+	_, ok = messageExtension(binding.ToMessage(&withoutKey), DefaultPartitionKeyExtension)
+	require.False(t, ok)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"time"
+
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+const defaultPrewarmTimeout = 5 * time.Second
+
+// PrewarmOptions configure PrewarmAddressable.
+type PrewarmOptions struct {
+	// Namespace is the namespace the client sends for, see
+	// ConnectionArgs.IsolateNamespaces.
+	Namespace string
+	// Handshake opens a connection to the addressable, including the DNS
+	// lookup and, for https addressables, the TLS handshake, by sending an
+	// OPTIONS request. The connection is kept in the pool of the client for
+	// the first event, whatever the response.
+	Handshake bool
+	// Timeout bounds the handshake, 5 seconds if 0.
+	Timeout time.Duration
+}
+
+// PrewarmAddressable creates the client of the addressable ahead of the first
+// event sent to it, e.g. once a sink became ready, to avoid a latency spike
+// for the first event. Clients already cached are kept.
+func PrewarmAddressable(ctx context.Context, cfg eventingtls.ClientConfig, addressable duckv1.Addressable, opts PrewarmOptions) error {
+	if addressable.URL == nil {
+		return fmt.Errorf("can not prewarm addressable with nil URL")
+	}
+
+	client, err := getClientForAddressableInNamespace(cfg, addressable, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	if !opts.Handshake {
+		return nil
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultPrewarmTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodOptions, addressable.URL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create http request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addressable.URL, err)
+	}
+	// the body must be drained, so that the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return resp.Body.Close()
+}
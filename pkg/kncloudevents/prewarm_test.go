@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestPrewarmAddressable(t *testing.T) {
+	CloseAll()
+	t.Cleanup(CloseAll)
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	var connections, options atomic.Int32
+	server := httptest.NewUnstartedServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.Method == nethttp.MethodOptions {
+			options.Add(1)
+		}
+		w.WriteHeader(nethttp.StatusAccepted)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state nethttp.ConnState) {
+		if state == nethttp.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	destination := duckv1.Addressable{URL: apis.HTTP(server.Listener.Addr().String())}
+
+	require.NoError(t, PrewarmAddressable(ctx, eventingtls.NewDefaultClientConfig(), destination, PrewarmOptions{}))
+	require.Len(t, Snapshot(), 1)
+	require.Zero(t, connections.Load())
+
+	require.NoError(t, PrewarmAddressable(ctx, eventingtls.NewDefaultClientConfig(), destination, PrewarmOptions{Handshake: true}))
+	require.Equal(t, int32(1), options.Load())
+	require.Equal(t, int32(1), connections.Load())
+
+	// the first event reuses the connection of the handshake
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), connections.Load())
+}
+
+func TestPrewarmAddressableUnreachable(t *testing.T) {
+	t.Cleanup(CloseAll)
+
+	// a closed port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	destination := duckv1.Addressable{URL: apis.HTTP(addr)}
+	require.Error(t, PrewarmAddressable(context.Background(), eventingtls.NewDefaultClientConfig(), destination, PrewarmOptions{Handshake: true}))
+	require.Error(t, PrewarmAddressable(context.Background(), eventingtls.NewDefaultClientConfig(), duckv1.Addressable{}, PrewarmOptions{}))
+}
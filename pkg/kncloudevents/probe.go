@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+const defaultProbeTimeout = 5 * time.Second
+
+// ErrProbeTLSVerification is wrapped by the error of ProbeAddressable, if the
+// addressable was reachable, but its certificate couldn't be verified.
+var ErrProbeTLSVerification = errors.New("TLS verification failed")
+
+// ProbeResult is the result of probing an addressable.
+type ProbeResult struct {
+	// Reachable is true if the addressable answered the probe, with any status code.
+	Reachable bool
+	// StatusCode is the status code the addressable answered the probe with.
+	StatusCode int
+	// TLS is true if the probe used TLS, and the certificate of the addressable
+	// was verified with its CA certs.
+	TLS bool
+}
+
+type probeConfig struct {
+	clientConfig eventingtls.ClientConfig
+}
+
+// ProbeOption configures ProbeAddressable.
+type ProbeOption func(*probeConfig)
+
+// WithProbeClientConfig probes with the given client config, e.g. to trust the
+// trust bundles of the cluster. By default, only the CA certs of the addressable
+// and the system CAs are trusted.
+func WithProbeClientConfig(cfg eventingtls.ClientConfig) ProbeOption {
+	return func(pc *probeConfig) {
+		pc.clientConfig = cfg
+	}
+}
+
+// ProbeAddressable sends a HEAD request to the addressable with the client used
+// to dispatch events to it, to check whether it's reachable and, for https
+// addressables, whether its certificate is valid. Any response, independent of
+// its status code, means the addressable is reachable. Reconcilers can use it
+// to check a sink before marking a resource ready.
+//
+// If the context has no deadline, the probe times out after 5 seconds.
+func ProbeAddressable(ctx context.Context, addressable duckv1.Addressable, opts ...ProbeOption) (ProbeResult, error) {
+	config := &probeConfig{
+		clientConfig: eventingtls.NewDefaultClientConfig(),
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if addressable.URL == nil {
+		return ProbeResult{}, fmt.Errorf("can not probe addressable with nil URL")
+	}
+	sanitized, err := sanitizeAddressable(&addressable)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("can not probe addressable: %w", err)
+	}
+	addressable = *sanitized
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultProbeTimeout)
+		defer cancel()
+	}
+
+	client, err := getClientForAddressable(config.clientConfig, addressable)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("failed to get http client for addressable: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, addressable.URL.String(), nil)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("could not create probe request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var verificationErr *tls.CertificateVerificationError
+		if errors.As(err, &verificationErr) {
+			return ProbeResult{}, fmt.Errorf("failed to probe %s: %w: %v", addressable.URL, ErrProbeTLSVerification, verificationErr)
+		}
+		return ProbeResult{}, fmt.Errorf("failed to probe %s: %w", addressable.URL, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return ProbeResult{
+		Reachable:  true,
+		StatusCode: resp.StatusCode,
+		TLS:        resp.TLS != nil,
+	}, nil
+}
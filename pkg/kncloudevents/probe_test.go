@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/eventingtls/eventingtlstesting"
+	"knative.dev/eventing/pkg/kncloudevents"
+)
+
+func TestProbeAddressable(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+
+	plaintext := httptest.NewServer(handler)
+	t.Cleanup(plaintext.Close)
+	plaintextURL, err := apis.ParseURL(plaintext.URL)
+	require.Nil(t, err)
+
+	tlsServer := eventingtlstesting.StartTLSServer(t, handler)
+	tlsURL, err := apis.ParseURL(tlsServer.URL)
+	require.Nil(t, err)
+	ca := tlsServer.CA()
+	otherCA := eventingtlstesting.NewCertificateAuthority(t).PEM
+
+	closed := httptest.NewServer(handler)
+	closedURL, err := apis.ParseURL(closed.URL)
+	require.Nil(t, err)
+	closed.Close()
+
+	tests := []struct {
+		name           string
+		addressable    duckv1.Addressable
+		want           kncloudevents.ProbeResult
+		wantErr        bool
+		wantTLSFailure bool
+	}{{
+		name:        "plaintext",
+		addressable: duckv1.Addressable{URL: plaintextURL},
+		want:        kncloudevents.ProbeResult{Reachable: true, StatusCode: http.StatusMethodNotAllowed},
+	}, {
+		name:        "tls",
+		addressable: duckv1.Addressable{URL: tlsURL, CACerts: &ca},
+		want:        kncloudevents.ProbeResult{Reachable: true, StatusCode: http.StatusMethodNotAllowed, TLS: true},
+	}, {
+		name:           "tls with untrusted certificate",
+		addressable:    duckv1.Addressable{URL: tlsURL, CACerts: &otherCA},
+		wantErr:        true,
+		wantTLSFailure: true,
+	}, {
+		name:        "unreachable",
+		addressable: duckv1.Addressable{URL: closedURL},
+		wantErr:     true,
+	}, {
+		name:    "nil URL",
+		wantErr: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.addressable.URL != nil {
+				t.Cleanup(func() { kncloudevents.DeleteAddressableHandler(tc.addressable) })
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			got, err := kncloudevents.ProbeAddressable(ctx, tc.addressable)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ProbeAddressable() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			require.Equal(t, tc.wantTLSFailure, err != nil && errors.Is(err, kncloudevents.ErrProbeTLSVerification), err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
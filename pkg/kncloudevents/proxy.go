@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/utils"
+)
+
+// ErrNotAnEvent is returned by NewRequestFromHTTP for incoming requests which
+// don't carry an event.
+var ErrNotAnEvent = errors.New("request doesn't carry an event")
+
+// Request is a request proxying an incoming request to a target, without
+// decoding the event it carries until Event is called.
+type Request struct {
+	*http.Request
+
+	target duckv1.Addressable
+
+	// event is the event decoded from the request, it is sent in place of the
+	// body once decoded. eventErr is the error decoding it.
+	event    *event.Event
+	eventErr error
+}
+
+// NewRequestFromHTTP returns a Request sending the event of the incoming request
+// to the target. The body of the incoming request is reused, along with its
+// event headers and the headers passed through to destinations, see
+// utils.PassThroughHeaders. The event isn't decoded, so the body can be streamed
+// to the target.
+func NewRequestFromHTTP(r *http.Request, target duckv1.Addressable) (*Request, error) {
+	if target.URL == nil {
+		return nil, fmt.Errorf("can not proxy request to nil target.URL")
+	}
+	if cehttp.NewMessageFromHttpRequest(r).ReadEncoding() == binding.EncodingUnknown {
+		return nil, ErrNotAnEvent
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, target.URL.String(), r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create http request: %w", err)
+	}
+	req.ContentLength = r.ContentLength
+
+	req.Header = utils.PassThroughHeaders(r.Header)
+	for key, values := range r.Header {
+		if isEventHeader(key) {
+			req.Header[key] = values
+		}
+	}
+
+	return &Request{
+		Request: req,
+		target:  target,
+	}, nil
+}
+
+// Target returns the target of the request.
+func (r *Request) Target() duckv1.Addressable {
+	return r.target
+}
+
+// Event returns the event carried by the request. The event is decoded from the
+// body on the first call only, the following calls return the same event, or
+// the same error. Once decoded, the event is sent in place of the body, its
+// event headers are removed from the request and the body mustn't be read.
+func (r *Request) Event() (*event.Event, error) {
+	if r.event != nil || r.eventErr != nil {
+		return r.event, r.eventErr
+	}
+
+	eventHeader := make(http.Header)
+	for key, values := range r.Header {
+		if isEventHeader(key) {
+			eventHeader[key] = values
+			r.Header.Del(key)
+		}
+	}
+	message := cehttp.NewMessage(eventHeader, r.Body)
+	r.event, r.eventErr = binding.ToEvent(r.Context(), message)
+	_ = message.Finish(nil)
+	r.Body = http.NoBody
+	r.ContentLength = 0
+	if r.eventErr != nil {
+		r.eventErr = fmt.Errorf("failed to decode the event of the request: %w", r.eventErr)
+	}
+	return r.event, r.eventErr
+}
+
+// MutateEvent calls mutate with the event carried by the request, decoded once
+// by Event, and rebinds the request to the mutated event. The event is only
+// encoded when the request is sent, so chained mutations neither decode nor
+// encode it again.
+func (r *Request) MutateEvent(mutate func(e *event.Event) error) error {
+	e, err := r.Event()
+	if err != nil {
+		return err
+	}
+	if err := mutate(e); err != nil {
+		return err
+	}
+	return e.Validate()
+}
+
+// SendRequest sends the request to its target, as SendMessage would send its
+// event. The headers of the request which aren't rendered from the event are
+// sent as additional headers, as if given with WithHeader before the options.
+// The event decoded by Event, possibly mutated, is sent in place of the body.
+func (d *Dispatcher) SendRequest(req *Request, options ...SendOption) (*DispatchInfo, error) {
+	if req.eventErr != nil {
+		return nil, req.eventErr
+	}
+	if req.event != nil {
+		options = append([]SendOption{WithHeader(req.Header.Clone())}, options...)
+		return d.SendMessage(req.Context(), binding.ToMessage(req.event), req.target, options...)
+	}
+
+	eventHeader, additionalHeader := make(http.Header), make(http.Header)
+	for key, values := range req.Header {
+		if isEventHeader(key) {
+			eventHeader[key] = values
+		} else {
+			additionalHeader[key] = values
+		}
+	}
+
+	message := cehttp.NewMessage(eventHeader, req.Body)
+	options = append([]SendOption{WithHeader(additionalHeader)}, options...)
+	return d.SendMessage(req.Context(), message, req.target, options...)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestNewRequestFromHTTP(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	target := duckv1.Addressable{URL: apis.HTTP("channel.example.com")}
+
+	incoming := httptest.NewRequest(http.MethodPost, "http://broker-ingress.example.com/ns/default", strings.NewReader(`{"hello":"world"}`))
+	incoming.Header.Set("Content-Type", "application/json")
+	incoming.Header.Set("Ce-Specversion", "1.0")
+	incoming.Header.Set("Ce-Id", "1")
+	incoming.Header.Set("Ce-Type", "type")
+	incoming.Header.Set("Ce-Source", "source")
+	incoming.Header.Set("X-Request-Id", "request")
+	incoming.Header.Set("Authorization", "Bearer secret")
+
+	req, err := kncloudevents.NewRequestFromHTTP(incoming, target)
+	require.NoError(t, err)
+	require.Equal(t, target.URL.String(), req.URL.String())
+	require.Equal(t, target, req.Target())
+	require.Equal(t, "1", req.Header.Get("Ce-Id"))
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.Empty(t, req.Header.Get("Authorization"))
+
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	info, err := dispatcher.SendRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+
+	sent := fakeClient.RequestsTo(target.URL.String())
+	require.Len(t, sent, 1)
+	require.Equal(t, "1", sent[0].Header.Get("Ce-Id"))
+	require.Equal(t, "type", sent[0].Header.Get("Ce-Type"))
+	require.Equal(t, "request", sent[0].Header.Get("X-Request-Id"))
+	require.Equal(t, `{"hello":"world"}`, string(sent[0].Body))
+}
+
+func TestNewRequestFromHTTPWithoutEvent(t *testing.T) {
+	incoming := httptest.NewRequest(http.MethodPost, "http://broker-ingress.example.com/ns/default", strings.NewReader("hello"))
+	incoming.Header.Set("Content-Type", "text/plain")
+
+	_, err := kncloudevents.NewRequestFromHTTP(incoming, duckv1.Addressable{URL: apis.HTTP("channel.example.com")})
+	require.True(t, errors.Is(err, kncloudevents.ErrNotAnEvent), err)
+}
+
+func TestRequestEvent(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+	target := duckv1.Addressable{URL: apis.HTTP("channel.example.com")}
+
+	body := &countingReader{Reader: strings.NewReader(`{"hello":"world"}`)}
+	incoming := httptest.NewRequest(http.MethodPost, "http://broker-ingress.example.com/ns/default", body)
+	incoming.Header.Set("Content-Type", "application/json")
+	incoming.Header.Set("Ce-Specversion", "1.0")
+	incoming.Header.Set("Ce-Id", "1")
+	incoming.Header.Set("Ce-Type", "type")
+	incoming.Header.Set("Ce-Source", "source")
+	incoming.Header.Set("X-Request-Id", "request")
+
+	req, err := kncloudevents.NewRequestFromHTTP(incoming, target)
+	require.NoError(t, err)
+
+	e, err := req.Event()
+	require.NoError(t, err)
+	require.Equal(t, "type", e.Type())
+	require.Equal(t, `{"hello":"world"}`, string(e.Data()))
+	reads := body.reads
+
+	again, err := req.Event()
+	require.NoError(t, err)
+	require.Same(t, e, again)
+	require.Equal(t, reads, body.reads, "the body was read again")
+
+	require.NoError(t, req.MutateEvent(func(e *event.Event) error {
+		e.SetType("mutated")
+		return nil
+	}))
+	require.NoError(t, req.MutateEvent(func(e *event.Event) error {
+		e.SetExtension("mutation", "second")
+		return nil
+	}))
+	require.Equal(t, reads, body.reads, "the body was read again")
+
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	_, err = dispatcher.SendRequest(req)
+	require.NoError(t, err)
+
+	sent := fakeClient.RequestsTo(target.URL.String())
+	require.Len(t, sent, 1)
+	require.Equal(t, "mutated", sent[0].Header.Get("Ce-Type"))
+	require.Equal(t, "second", sent[0].Header.Get("Ce-Mutation"))
+	require.Equal(t, "request", sent[0].Header.Get("X-Request-Id"))
+	require.Equal(t, `{"hello":"world"}`, string(sent[0].Body))
+}
+
+func TestRequestMutateEventInvalid(t *testing.T) {
+	incoming := httptest.NewRequest(http.MethodPost, "http://broker-ingress.example.com/ns/default", strings.NewReader(`{"hello":"world"}`))
+	incoming.Header.Set("Content-Type", "application/json")
+	incoming.Header.Set("Ce-Specversion", "1.0")
+	incoming.Header.Set("Ce-Id", "1")
+	incoming.Header.Set("Ce-Type", "type")
+	incoming.Header.Set("Ce-Source", "source")
+
+	req, err := kncloudevents.NewRequestFromHTTP(incoming, duckv1.Addressable{URL: apis.HTTP("channel.example.com")})
+	require.NoError(t, err)
+
+	err = req.MutateEvent(func(e *event.Event) error {
+		e.SetID("")
+		return nil
+	})
+	require.Error(t, err)
+}
+
+type countingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"knative.dev/eventing/pkg/auth"
+)
+
+// RateLimitKeyFunc returns the key of the client a request is counted for.
+// Requests with an empty key aren't limited.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitBySubject keys the requests by the subject of the verified OIDC
+// token of the sender, see auth.ContextWithIDToken. Requests without a
+// verified token aren't limited, the limit is meant to run after the OIDC
+// verification, e.g. after auth.OIDCTokenVerifier VerifyMiddleware.
+func RateLimitBySubject(r *http.Request) string {
+	token := auth.IDTokenFromContext(r.Context())
+	if token == nil {
+		return ""
+	}
+	return token.Subject
+}
+
+// RateLimitByNamespace keys the requests by the namespace of the
+// ServiceAccount of the sender, so that all the producers of a namespace share
+// a limit. Senders which aren't ServiceAccounts of the cluster are keyed by
+// their subject, see RateLimitBySubject.
+func RateLimitByNamespace(r *http.Request) string {
+	token := auth.IDTokenFromContext(r.Context())
+	if token == nil {
+		return ""
+	}
+	if token.IssuedByCluster {
+		// system:serviceaccount:<namespace>:<name>
+		if parts := strings.Split(token.Subject, ":"); len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+			return "namespace:" + parts[2]
+		}
+	}
+	return "subject:" + token.Subject
+}
+
+// RateLimitConfig configures the per client rate limits of a RateLimiter.
+type RateLimitConfig struct {
+	// Rate is the number of requests per second a client may send sustainably.
+	Rate float64
+	// Burst is the number of requests a client may send at once, Rate rounded
+	// up by default.
+	Burst int
+	// IdleTimeout is how long the limit of a client without requests is kept,
+	// 10 minutes by default. Clients start over with a full burst afterwards.
+	IdleTimeout time.Duration
+}
+
+func (c RateLimitConfig) withDefaults() RateLimitConfig {
+	if c.Burst <= 0 {
+		c.Burst = int(math.Ceil(c.Rate))
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = 10 * time.Minute
+	}
+	return c
+}
+
+// RateLimiter limits the rate of the requests of each client with a token
+// bucket per client.
+type RateLimiter struct {
+	config RateLimitConfig
+
+	mu        sync.Mutex
+	limiters  map[string]*clientLimiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// NewRateLimiter creates a RateLimiter with the given config.
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		config:   config.withDefaults(),
+		limiters: make(map[string]*clientLimiter),
+		now:      time.Now,
+	}
+}
+
+// Allow counts a request of the client with the given key. Requests over the
+// limit of the client are answered with 429 Too Many Requests and a
+// Retry-After header, in which case false is returned.
+func (l *RateLimiter) Allow(w http.ResponseWriter, key string) bool {
+	if key == "" {
+		return true
+	}
+
+	now := l.now()
+	reservation := l.limiterFor(key, now).ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if reservation.OK() && delay == 0 {
+		return true
+	}
+	reservation.CancelAt(now)
+
+	retryAfter := int64(math.Ceil(delay.Seconds()))
+	if !reservation.OK() || delay == rate.InfDuration {
+		retryAfter = int64(math.Ceil(l.config.IdleTimeout.Seconds()))
+	}
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	w.WriteHeader(http.StatusTooManyRequests)
+	return false
+}
+
+func (l *RateLimiter) limiterFor(key string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.config.IdleTimeout {
+		for k, c := range l.limiters {
+			if now.Sub(c.lastUsed) >= l.config.IdleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.limiters[key]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(l.config.Rate), l.config.Burst)}
+		l.limiters[key] = c
+	}
+	c.lastUsed = now
+	return c.limiter
+}
+
+// NewRateLimitHandler returns a handler limiting the rate of the requests of
+// each client, keyed by key, before passing them to next, see
+// RateLimiter.Allow.
+func NewRateLimitHandler(next http.Handler, limiter *RateLimiter, key RateLimitKeyFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limiter.Allow(w, key(r)) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
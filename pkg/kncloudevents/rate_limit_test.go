@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"knative.dev/eventing/pkg/auth"
+)
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := map[string]struct {
+		token         *auth.IDToken
+		wantSubject   string
+		wantNamespace string
+	}{
+		"without token": {},
+		"service account": {
+			token:         &auth.IDToken{Subject: "system:serviceaccount:ns:sa", IssuedByCluster: true},
+			wantSubject:   "system:serviceaccount:ns:sa",
+			wantNamespace: "namespace:ns",
+		},
+		"external issuer": {
+			token:         &auth.IDToken{Subject: "system:serviceaccount:ns:sa"},
+			wantSubject:   "system:serviceaccount:ns:sa",
+			wantNamespace: "subject:system:serviceaccount:ns:sa",
+		},
+		"user": {
+			token:         &auth.IDToken{Subject: "user@example.com", IssuedByCluster: true},
+			wantSubject:   "user@example.com",
+			wantNamespace: "subject:user@example.com",
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.token != nil {
+				r = r.WithContext(auth.ContextWithIDToken(r.Context(), tc.token))
+			}
+			if got := RateLimitBySubject(r); got != tc.wantSubject {
+				t.Errorf("RateLimitBySubject() = %q, want %q", got, tc.wantSubject)
+			}
+			if got := RateLimitByNamespace(r); got != tc.wantNamespace {
+				t.Errorf("RateLimitByNamespace() = %q, want %q", got, tc.wantNamespace)
+			}
+		})
+	}
+}
+
+func TestNewRateLimitHandler(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(RateLimitConfig{Rate: 0.5, Burst: 2, IdleTimeout: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	handler := NewRateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), limiter, func(r *http.Request) string {
+		return r.Header.Get("Client")
+	})
+
+	send := func(client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Client", client)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send("a"); w.Code != http.StatusAccepted {
+			t.Fatalf("request %d within burst: got status %d", i, w.Code)
+		}
+	}
+	w := send("a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over limit: got status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+
+	// the limits are per client, and requests without key aren't limited
+	if w := send("b"); w.Code != http.StatusAccepted {
+		t.Errorf("other client: got status %d", w.Code)
+	}
+	for i := 0; i < 5; i++ {
+		if w := send(""); w.Code != http.StatusAccepted {
+			t.Errorf("request without key: got status %d", w.Code)
+		}
+	}
+
+	// rejected requests don't use up tokens
+	now = now.Add(2 * time.Second)
+	if w := send("a"); w.Code != http.StatusAccepted {
+		t.Errorf("request after Retry-After: got status %d", w.Code)
+	}
+
+	// idle clients are forgotten
+	now = now.Add(2 * time.Minute)
+	send("b")
+	limiter.mu.Lock()
+	_, ok := limiter.limiters["a"]
+	limiter.mu.Unlock()
+	if ok {
+		t.Error("the limiter of the idle client wasn't removed")
+	}
+}
@@ -18,10 +18,15 @@ package kncloudevents
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -75,6 +80,67 @@ type RetryConfig struct {
 	// value indicates no maximum override.  A value of "0" indicates "Retry-After"
 	// headers are to be ignored.
 	RetryAfterMaxDuration *time.Duration
+
+	// RetryPermanentErrors makes requests failing with a permanent error,
+	// see IsPermanentError, subject to CheckRetry as any other error. By
+	// default they aren't retried.
+	RetryPermanentErrors bool
+
+	// RetryUnknownHost makes requests failing since the host of the
+	// destination doesn't exist (NXDOMAIN) subject to CheckRetry, e.g. when
+	// the DNS record of a new destination may not be propagated yet. By
+	// default they are permanent errors.
+	RetryUnknownHost bool
+}
+
+// DeliveryBudget bounds a whole delivery, across the destination, the reply and
+// the dead letter sink, so that the end-to-end latency of a delivery is bounded.
+type DeliveryBudget struct {
+	// MaxAttempts is the maximum number of requests sent for the delivery, 0 for no maximum.
+	MaxAttempts int
+	// Timeout is the maximum duration of the delivery, 0 for no maximum.
+	Timeout time.Duration
+}
+
+// ErrDeliveryBudgetExhausted is returned when a request of a delivery isn't sent,
+// since the delivery used up the attempts of its DeliveryBudget.
+var ErrDeliveryBudgetExhausted = errors.New("delivery budget exhausted")
+
+// attemptBudget tracks the attempts left of a delivery. A delivery sends its
+// requests sequentially, so it's not safe for concurrent use.
+type attemptBudget struct {
+	remaining int
+}
+
+func (b *attemptBudget) exhausted() bool {
+	return b.remaining <= 0
+}
+
+// limit returns a copy of the retry config, which counts the attempts against
+// the budget and stops retrying once it's exhausted. Without retry config, a
+// single attempt is sent.
+func (b *attemptBudget) limit(retryConfig *RetryConfig) *RetryConfig {
+	limited := RetryConfig{
+		CheckRetry: func(context.Context, *http.Response, error) (bool, error) {
+			return false, nil
+		},
+		Backoff: func(int, *http.Response) time.Duration {
+			return 0
+		},
+	}
+	if retryConfig != nil {
+		limited = *retryConfig
+	}
+
+	checkRetry := limited.CheckRetry
+	limited.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		b.remaining--
+		if b.exhausted() {
+			return false, nil
+		}
+		return checkRetry(ctx, resp, err)
+	}
+	return &limited
 }
 
 func NoRetries() RetryConfig {
@@ -121,6 +187,13 @@ func RetryConfigFromDeliverySpec(spec v1.DeliverySpec) (RetryConfig, error) {
 		retryConfig.RequestTimeout, _ = timeout.Duration()
 	}
 
+	if spec.RetryPermanentErrors != nil {
+		retryConfig.RetryPermanentErrors = *spec.RetryPermanentErrors
+	}
+	if spec.FailFastOnUnknownHost != nil {
+		retryConfig.RetryUnknownHost = !*spec.FailFastOnUnknownHost
+	}
+
 	if spec.RetryAfterMax != nil {
 		maxPeriod, err := period.Parse(*spec.RetryAfterMax)
 		if err != nil { // Should never happen based on DeliverySpec validation
@@ -183,58 +256,153 @@ func SelectiveRetry(_ context.Context, response *http.Response, err error) (bool
 	return false, nil
 }
 
+// IsPermanentError reports whether the error of a request is permanent, so
+// that retrying the request is pointless:
+// * the host of the destination doesn't exist (NXDOMAIN)
+// * the certificate of the destination is signed by an unknown authority
+// * the URL of the destination is malformed
+func IsPermanentError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if IsUnknownHostError(err) {
+		return true
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthorityErr) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Op == "parse" {
+		return true
+	}
+	// the http.Client doesn't export the errors of malformed request URLs
+	msg := err.Error()
+	return strings.Contains(msg, "unsupported protocol scheme") || strings.Contains(msg, "no Host in request URL")
+}
+
+// IsUnknownHostError reports whether the error of a request is caused by the
+// host of the destination not existing (NXDOMAIN).
+func IsUnknownHostError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// skipPermanentErrors wraps the CheckRetry, so that requests failing with an
+// error matching isPermanent aren't retried.
+func skipPermanentErrors(checkRetry CheckRetry, isPermanent func(err error) bool) CheckRetry {
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if isPermanent(err) {
+			return false, nil
+		}
+		return checkRetry(ctx, resp, err)
+	}
+}
+
 // generateBackoffFunction returns a valid retryablehttp.Backoff implementation which
-// wraps the provided RetryConfig.Backoff implementation with optional "Retry-After"
-// header support.
+// wraps the provided RetryConfig.Backoff implementation with "Retry-After" header
+// support.
+//
+// Retry-After headers of 429 / 503 responses are respected, bounded by
+// RetryConfig.RetryAfterMaxDuration if set. Setting RetryAfterMaxDuration to 0
+// (DeliverySpec.RetryAfterMax "PT0S") opts out of respecting them:
+//
+//	RetryAfterMaxDuration    Behavior
+//	---------------------    --------
+//	     nil                 Respect Retry-After headers without Max
+//	      0                  Do NOT respect Retry-After headers
+//	     >0                  Respect Retry-After headers with Max
+//
+// See https://github.com/knative/eventing/issues/5811.
 func generateBackoffFn(config *RetryConfig) retryablehttp.Backoff {
 	return func(_, _ time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		wait, retryAfterExtension := backoffFor(config, attemptNum, resp)
+		if retryAfterExtension > 0 {
+			reportRetryAfterExtension(resp, retryAfterExtension)
+		}
+		return wait
+	}
+}
 
-		//
-		// NOTE - The following logic will need to be altered slightly once the "delivery-retryafter"
-		//        experimental-feature graduates from Alpha/Beta to Stable/GA.  This is according to
-		//        plan as described in https://github.com/knative/eventing/issues/5811.
-		//
-		//        During the Alpha/Beta stages the ability to respect Retry-After headers is "opt-in"
-		//        requiring the DeliverySpec.RetryAfterMax to be populated.  The Stable/GA behavior
-		//        will be "opt-out" where Retry-After headers are always respected (in the context of
-		//        calculating backoff durations for 429 / 503 responses) unless the
-		//        DeliverySpec.RetryAfterMax is set to "PT0S".
-		//
-		//        While this might seem unnecessarily complex, it achieves the following design goals...
-		//          - Does not require an explicit "enabled" flag in the DeliverySpec.
-		//          - Does not require implementations calling the message_sender to be aware of experimental-features.
-		//          - Does not modify existing Knative CRs with arbitrary default "max" values.
-		//
-		//        The intended behavior of RetryConfig.RetryAfterMaxDuration is as follows...
-		//
-		//          RetryAfterMaxDuration    Alpha/Beta                              Stable/GA
-		//          ---------------------    ----------                              ---------
-		//               nil                 Do NOT respect Retry-After headers      Respect Retry-After headers without Max
-		//                0                  Do NOT respect Retry-After headers      Do NOT respect Retry-After headers
-		//               >0                  Respect Retry-After headers with Max    Respect Retry-After headers with Max
-		//
-
-		// If Response is 429 / 503, Then Parse Any Retry-After Header Durations & Enforce Optional MaxDuration
-		var retryAfterDuration time.Duration
-		// TODO - Remove this check when experimental-feature moves to Stable/GA to convert behavior from opt-in to opt-out
-		if config.RetryAfterMaxDuration != nil {
-			// TODO - Keep this logic as is (no change required) when experimental-feature is Stable/GA
-			if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
-				retryAfterDuration = parseRetryAfterDuration(resp)
-				if config.RetryAfterMaxDuration != nil && *config.RetryAfterMaxDuration < retryAfterDuration {
-					retryAfterDuration = *config.RetryAfterMaxDuration
-				}
-			}
+// backoffFor returns the backoff after the given attempt, together with the
+// duration the Retry-After header of the response extended it by.
+func backoffFor(config *RetryConfig, attemptNum int, resp *http.Response) (time.Duration, time.Duration) {
+	// If Response is 429 / 503, Then Parse Any Retry-After Header Durations & Enforce Optional MaxDuration
+	var retryAfterDuration time.Duration
+	optedOut := config.RetryAfterMaxDuration != nil && *config.RetryAfterMaxDuration == 0
+	if !optedOut && resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		retryAfterDuration = parseRetryAfterDuration(resp)
+		if config.RetryAfterMaxDuration != nil && *config.RetryAfterMaxDuration < retryAfterDuration {
+			retryAfterDuration = *config.RetryAfterMaxDuration
 		}
+	}
 
-		// Calculate The RetryConfig Backoff Duration
-		backoffDuration := config.Backoff(attemptNum, resp)
+	// Calculate The RetryConfig Backoff Duration
+	backoffDuration := config.Backoff(attemptNum, resp)
 
-		// Return The Larger Of The Two Backoff Durations
-		if retryAfterDuration > backoffDuration {
-			return retryAfterDuration
+	// Return The Larger Of The Two Backoff Durations
+	if retryAfterDuration > backoffDuration {
+		return retryAfterDuration, retryAfterDuration - backoffDuration
+	}
+	return backoffDuration, 0
+}
+
+// deadlineBudget spreads the remaining time until the deadline of a context
+// over the attempts of a request. Every attempt times out at the deadline at
+// the latest, and no retry is attempted if the time left after the backoff is
+// shorter than the previous attempt took. It's not safe for concurrent use.
+type deadlineBudget struct {
+	deadline     time.Time
+	attemptStart time.Time
+	attemptNum   int
+	// exhausted is true if a retry wasn't attempted since the deadline was too close.
+	exhausted bool
+}
+
+// newDeadlineBudget returns the budget of the context, nil if the context has
+// no deadline.
+func newDeadlineBudget(ctx context.Context) *deadlineBudget {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	return &deadlineBudget{deadline: deadline, attemptNum: -1}
+}
+
+// startAttempt returns the timeout of the attempt starting now, given the
+// timeout of the requests, 0 for none.
+func (b *deadlineBudget) startAttempt(requestTimeout time.Duration) time.Duration {
+	b.attemptStart = time.Now()
+	b.attemptNum++
+	remaining := b.deadline.Sub(b.attemptStart)
+	if remaining <= 0 {
+		// let the request fail with the error of the expired context
+		return requestTimeout
+	}
+	if requestTimeout == 0 || remaining < requestTimeout {
+		return remaining
+	}
+	return requestTimeout
+}
+
+// limitToDeadline wraps the CheckRetry, so that no retry is attempted if the
+// time left until the deadline after the backoff can't cover another attempt.
+func (b *deadlineBudget) limitToDeadline(config *RetryConfig, checkRetry CheckRetry) CheckRetry {
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		retry, checkErr := checkRetry(ctx, resp, err)
+		if !retry {
+			return retry, checkErr
+		}
+		now := time.Now()
+		wait, _ := backoffFor(config, b.attemptNum, resp)
+		if b.deadline.Sub(now)-wait < now.Sub(b.attemptStart) {
+			b.exhausted = true
+			return false, checkErr
 		}
-		return backoffDuration
+		return true, checkErr
 	}
 }
 
@@ -18,18 +18,30 @@ package kncloudevents
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/cloudevents/sdk-go/v2/test"
 	"github.com/rickb777/date/period"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/utils/pointer"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/metrics/metricstest"
 	"knative.dev/pkg/ptr"
+	rectesting "knative.dev/pkg/reconciler/testing"
 
 	v1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
 )
 
 // RetryAfterFormat Enum
@@ -312,6 +324,80 @@ func TestSelectiveRetry(t *testing.T) {
 	}
 }
 
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "nil",
+	}, {
+		name: "NXDOMAIN",
+		err:  &url.Error{Op: "Post", URL: "http://typo", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "typo", IsNotFound: true}}},
+		want: true,
+	}, {
+		name: "DNS timeout",
+		err:  &url.Error{Op: "Post", URL: "http://sink", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "sink", IsTimeout: true}}},
+	}, {
+		name: "unknown authority",
+		err:  &url.Error{Op: "Post", URL: "https://sink", Err: x509.UnknownAuthorityError{}},
+		want: true,
+	}, {
+		name: "malformed URL",
+		err:  &url.Error{Op: "parse", URL: "http://[::1", Err: errors.New("missing ']' in host")},
+		want: true,
+	}, {
+		name: "unsupported scheme",
+		err:  &url.Error{Op: "Post", URL: "ftp://sink", Err: fmt.Errorf("unsupported protocol scheme %q", "ftp")},
+		want: true,
+	}, {
+		name: "connection refused",
+		err:  &url.Error{Op: "Post", URL: "http://sink", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsPermanentError(tc.err))
+		})
+	}
+}
+
+func TestIsUnknownHostError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "nil",
+	}, {
+		name: "NXDOMAIN",
+		err:  &url.Error{Op: "Post", URL: "http://typo", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "typo", IsNotFound: true}}},
+		want: true,
+	}, {
+		name: "DNS timeout",
+		err:  &url.Error{Op: "Post", URL: "http://sink", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "sink", IsTimeout: true}}},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsUnknownHostError(tc.err))
+		})
+	}
+}
+
+func TestRetryConfigFromDeliverySpecPermanentErrors(t *testing.T) {
+	got, err := RetryConfigFromDeliverySpec(v1.DeliverySpec{})
+	assert.Nil(t, err)
+	assert.False(t, got.RetryPermanentErrors)
+	assert.False(t, got.RetryUnknownHost)
+
+	got, err = RetryConfigFromDeliverySpec(v1.DeliverySpec{
+		RetryPermanentErrors:  pointer.Bool(true),
+		FailFastOnUnknownHost: pointer.Bool(false),
+	})
+	assert.Nil(t, err)
+	assert.True(t, got.RetryPermanentErrors)
+	assert.True(t, got.RetryUnknownHost)
+}
+
 func TestRetryConfigFromDeliverySpecCheckRetry(t *testing.T) {
 	const retryMax = 10
 	linear := v1.BackoffPolicyLinear
@@ -394,6 +480,7 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 	retryAfterDuration := 30 * time.Second         // The Retry-After header Duration to use in HTTP Response.
 	smallRetryAfterMaxDuration := 10 * time.Second // Value must exceed retryBackoffDuration while being less than retryAfterDuration to force use of retryAfterMax value.
 	largeRetryAfterMaxDuration := 90 * time.Second // Value must exceed retryBackoffDuration and retryAfterDuration so that Retry-After header is used.
+	zeroRetryAfterMaxDuration := time.Duration(0)  // Opts out of respecting Retry-After headers.
 
 	// Define The TestCases
 	testCases := []struct {
@@ -403,7 +490,7 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 		format          RetryAfterFormat
 		expectedBackoff time.Duration
 	}{
-		// Nil Max Tests (opt-in / opt-out)
+		// Nil Max Tests (Retry-After respected without max)
 
 		{
 			name:            "nil max 429 without Retry-After",
@@ -417,14 +504,14 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 			retryAfterMax:   nil,
 			statusCode:      http.StatusTooManyRequests,
 			format:          Seconds,
-			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
+			expectedBackoff: retryAfterDuration, // Respects Retry-After Header
 		},
 		{
 			name:            "nil max 429 with Retry-After date",
 			retryAfterMax:   nil,
 			statusCode:      http.StatusTooManyRequests,
 			format:          Date,
-			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
+			expectedBackoff: retryAfterDuration, // Respects Retry-After Header
 		},
 		{
 			name:            "nil max 429 with invalid Retry-After",
@@ -438,7 +525,7 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 			retryAfterMax:   nil,
 			statusCode:      http.StatusServiceUnavailable,
 			format:          Seconds,
-			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
+			expectedBackoff: retryAfterDuration, // Respects Retry-After Header
 		},
 		{
 			name:            "nil max 500 without Retry-After",
@@ -448,6 +535,23 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
 		},
 
+		// Zero Max Tests (opt-out)
+
+		{
+			name:            "zero max 429 with Retry-After seconds",
+			retryAfterMax:   &zeroRetryAfterMaxDuration,
+			statusCode:      http.StatusTooManyRequests,
+			format:          Seconds,
+			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
+		},
+		{
+			name:            "zero max 503 with Retry-After date",
+			retryAfterMax:   &zeroRetryAfterMaxDuration,
+			statusCode:      http.StatusServiceUnavailable,
+			format:          Date,
+			expectedBackoff: retryBackoffDuration, // Uses Standard Backoff
+		},
+
 		// Large Max Tests (Greater Than Retry-After Value)
 
 		{
@@ -576,7 +680,7 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 				// infrastructure, and so we have to gate the check of the lower-bounds in Date
 				// format tests.  This should perform the comparison most of the time, but will
 				// prevent false-positive failures on slow execution runs.
-				if tc.retryAfterMax != nil && stopTime.Sub(startTime) < retryAfterDuration {
+				if tc.expectedBackoff > retryBackoffDuration && stopTime.Sub(startTime) < retryAfterDuration {
 					assert.Greater(t, actualBackoff, retryBackoffDuration)
 				}
 				assert.LessOrEqual(t, actualBackoff, tc.expectedBackoff)
@@ -587,6 +691,31 @@ func TestGenerateBackoffFnWithRetryAfter(t *testing.T) {
 	}
 }
 
+func TestGenerateBackoffFnReportsRetryAfterExtension(t *testing.T) {
+	metricstest.Unregister("dispatcher_retry_after_extended_count", "dispatcher_retry_after_extension")
+	register()
+
+	retryConfig := &RetryConfig{
+		Backoff: func(int, *http.Response) time.Duration { return 2 * time.Second },
+	}
+	backoffFn := generateBackoffFn(retryConfig)
+	request, err := http.NewRequest(http.MethodPost, "http://destination.example.com", nil)
+	assert.Nil(t, err)
+	wantTags := map[string]string{"destination": "http://destination.example.com"}
+
+	// Retry-After headers shorter than the backoff don't extend it
+	response := generateRetryAfterHttpResponse(t, http.StatusTooManyRequests, Seconds, time.Second)
+	response.Request = request
+	assert.Equal(t, 2*time.Second, backoffFn(0, 999, 1, response))
+	metricstest.AssertNoMetric(t, "dispatcher_retry_after_extended_count")
+
+	response = generateRetryAfterHttpResponse(t, http.StatusTooManyRequests, Seconds, 5*time.Second)
+	response.Request = request
+	assert.Equal(t, 5*time.Second, backoffFn(0, 999, 1, response))
+	metricstest.CheckCountData(t, "dispatcher_retry_after_extended_count", wantTags, 1)
+	metricstest.CheckDistributionData(t, "dispatcher_retry_after_extension", wantTags, 1, 3000, 3000)
+}
+
 // generateRetryAfterHttpResponse is a utility function for generating tst HTTP Responses with Retry-After headers.
 func generateRetryAfterHttpResponse(t *testing.T, statusCode int, format RetryAfterFormat, duration time.Duration) *http.Response {
 
@@ -613,3 +742,51 @@ func generateRetryAfterHttpResponse(t *testing.T, statusCode int, format RetryAf
 	// Return The HTTP Response
 	return response
 }
+
+func TestDeadlineBudgetAttemptTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	budget := newDeadlineBudget(ctx)
+	assert.NotNil(t, budget)
+	assert.Equal(t, 100*time.Millisecond, budget.startAttempt(100*time.Millisecond))
+	assert.LessOrEqual(t, budget.startAttempt(5*time.Second), time.Second)
+	assert.LessOrEqual(t, budget.startAttempt(0), time.Second)
+
+	assert.Nil(t, newDeadlineBudget(context.Background()))
+}
+
+func TestDispatchStopsRetryingAtDeadline(t *testing.T) {
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	retryConfig := &RetryConfig{
+		RetryMax:   10,
+		CheckRetry: SelectiveRetry,
+		Backoff: func(attemptNum int, resp *http.Response) time.Duration {
+			return 50 * time.Millisecond * time.Duration(attemptNum)
+		},
+	}
+
+	ctx, _ := rectesting.SetupFakeContext(t)
+	fakeClient := kncloudeventstest.NewFakeClient().Return(kncloudeventstest.Status(http.StatusServiceUnavailable).Delayed(100 * time.Millisecond))
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+
+	// after the second attempt, 100ms are left, which don't cover the backoff
+	// of 50ms and another attempt of 100ms
+	deadlineCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	info, err := dispatcher.SendEvent(deadlineCtx, test.FullEvent(), destination, WithRetryConfig(retryConfig))
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, info.ResponseCode)
+	assert.Equal(t, 2, info.Attempts)
+	assert.True(t, info.DeadlineExhausted)
+
+	// without deadline, the retries aren't limited
+	fakeClient = kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusServiceUnavailable)
+	dispatcher = NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+	retryConfig.RetryMax = 2
+	retryConfig.Backoff = func(int, *http.Response) time.Duration { return time.Millisecond }
+	info, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithRetryConfig(retryConfig))
+	assert.Error(t, err)
+	assert.Equal(t, 3, info.Attempts)
+	assert.False(t, info.DeadlineExhausted)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// retryBudgetSweepInterval is how often the buckets of the retry budget are
+// swept for full buckets, which are equivalent to new ones.
+const retryBudgetSweepInterval = 10 * time.Minute
+
+// RetryBudgetConfig configures the retry budgets of a Dispatcher. Zero values
+// use the defaults.
+type RetryBudgetConfig struct {
+	// MaxTokens is the number of retries a destination is granted before
+	// requests to it succeed again, 100 by default.
+	MaxTokens float64
+	// TokenRatio is the number of retries a successful request to the
+	// destination grants, 0.1 by default, i.e. a retry per 10 successes.
+	TokenRatio float64
+}
+
+func (c RetryBudgetConfig) withDefaults() RetryBudgetConfig {
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 100
+	}
+	if c.TokenRatio <= 0 {
+		c.TokenRatio = 0.1
+	}
+	return c
+}
+
+// WithRetryBudget bounds the retries to each destination with a token bucket
+// shared by all requests to the destination. Every retry takes a token and
+// every successful request refills the bucket by TokenRatio, up to MaxTokens.
+// Once the bucket is empty, failed requests aren't retried, whatever their
+// retry config, so that many senders retrying against a failing destination
+// don't add up to bursts of retries. The first attempt of a request is always
+// sent.
+func WithRetryBudget(config RetryBudgetConfig) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.retryBudget = &retryBudget{
+			config:  config.withDefaults(),
+			buckets: make(map[string]*retryBucket),
+			now:     time.Now,
+		}
+	}
+}
+
+type retryBudget struct {
+	config RetryBudgetConfig
+
+	bucketsMu sync.Mutex
+	buckets   map[string]*retryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func (b *retryBudget) bucketFor(destination string) *retryBucket {
+	b.bucketsMu.Lock()
+	defer b.bucketsMu.Unlock()
+
+	now := b.now()
+	if now.Sub(b.lastSweep) >= retryBudgetSweepInterval {
+		b.evictFull()
+		b.lastSweep = now
+	}
+
+	bucket, ok := b.buckets[destination]
+	if !ok {
+		bucket = &retryBucket{
+			tokens:     b.config.MaxTokens,
+			maxTokens:  b.config.MaxTokens,
+			tokenRatio: b.config.TokenRatio,
+		}
+		b.buckets[destination] = bucket
+	}
+	return bucket
+}
+
+// evictFull deletes the full buckets, a new bucket starts full anyway. The
+// caller must hold bucketsMu.
+func (b *retryBudget) evictFull() {
+	for destination, bucket := range b.buckets {
+		if bucket.full() {
+			delete(b.buckets, destination)
+		}
+	}
+}
+
+// retryBucket is the token bucket of the retries to a destination.
+type retryBucket struct {
+	maxTokens  float64
+	tokenRatio float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// withdraw takes a token for a retry, it returns false if there is none left.
+func (b *retryBucket) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// deposit refills the bucket for a successful request.
+func (b *retryBucket) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.tokenRatio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+func (b *retryBucket) full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens >= b.maxTokens
+}
+
+type retryBucketKey struct{}
+
+// contextWithRetryBucket makes the retries of the requests sent with the
+// context take their tokens from the given bucket.
+func contextWithRetryBucket(ctx context.Context, bucket *retryBucket) context.Context {
+	return context.WithValue(ctx, retryBucketKey{}, bucket)
+}
+
+// limitRetries makes the retries take a token from the retry bucket of the
+// context, if there is one, and refills it for successful attempts. retryMax
+// is the maximum number of retries, so that no token is taken after the last
+// attempt.
+func limitRetries(ctx context.Context, retryMax int, checkRetry CheckRetry) CheckRetry {
+	bucket, ok := ctx.Value(retryBucketKey{}).(*retryBucket)
+	if !ok {
+		return checkRetry
+	}
+	attempts := 0
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		attempts++
+		if err == nil && resp != nil && !isFailure(resp.StatusCode) {
+			bucket.deposit()
+		}
+
+		retry, checkErr := checkRetry(ctx, resp, err)
+		if !retry || attempts > retryMax {
+			return retry, checkErr
+		}
+		if !bucket.withdraw() {
+			return false, checkErr
+		}
+		return true, checkErr
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestDispatchWithRetryBudget(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	failing := duckv1.Addressable{URL: apis.HTTP("failing.example.com")}
+	other := duckv1.Addressable{URL: apis.HTTP("other.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(failing.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable)).
+		On(other.URL.String(), kncloudeventstest.Status(http.StatusServiceUnavailable))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx),
+		kncloudevents.WithHTTPClient(fakeClient.Client()),
+		kncloudevents.WithRetryBudget(kncloudevents.RetryBudgetConfig{MaxTokens: 3, TokenRatio: 0.5}),
+	)
+
+	retryConfig := &kncloudevents.RetryConfig{
+		RetryMax: 2,
+		CheckRetry: func(_ context.Context, resp *http.Response, err error) (bool, error) {
+			return err != nil || resp.StatusCode >= http.StatusInternalServerError, nil
+		},
+		Backoff: func(int, *http.Response) time.Duration {
+			return 0
+		},
+	}
+	send := func(destination duckv1.Addressable) int {
+		info, _ := dispatcher.SendEvent(ctx, test.MinEvent(), destination, kncloudevents.WithRetryConfig(retryConfig))
+		return info.Attempts
+	}
+
+	// the retries of all requests to the destination share its budget
+	require.Equal(t, 3, send(failing))
+	require.Equal(t, 2, send(failing))
+	require.Equal(t, 1, send(failing))
+
+	// other destinations have a budget of their own
+	require.Equal(t, 3, send(other))
+
+	// successful requests refill the budget
+	fakeClient.On(failing.URL.String(), kncloudeventstest.Status(http.StatusAccepted), kncloudeventstest.Status(http.StatusAccepted), kncloudeventstest.Status(http.StatusServiceUnavailable))
+	require.Equal(t, 1, send(failing)) // the scripted 503 of before
+	require.Equal(t, 1, send(failing))
+	require.Equal(t, 1, send(failing))
+	require.Equal(t, 2, send(failing))
+	require.Equal(t, 1, send(failing))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/configmap"
+)
+
+// NamespaceSampleRateKeyPrefix is the prefix of the keys of the tracing config
+// map overriding the sample rate of the dispatched spans of a namespace, e.g.
+// "sample-rate.my-namespace": "1" samples all deliveries of my-namespace. The
+// other deliveries keep the global sample rate. Deliveries continuing a trace
+// keep the sampling decision of the trace.
+const NamespaceSampleRateKeyPrefix = "sample-rate."
+
+var namespaceSamplers atomic.Pointer[map[string]trace.Sampler]
+
+// UpdateNamespaceSampling replaces the namespace sample rates with the ones of
+// the config map. The previous sample rates are kept if the config map is
+// invalid.
+func UpdateNamespaceSampling(cm *corev1.ConfigMap) error {
+	samplers := make(map[string]trace.Sampler)
+	for key, value := range cm.Data {
+		namespace, ok := strings.CutPrefix(key, NamespaceSampleRateKeyPrefix)
+		if !ok || namespace == "" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", key, rate)
+		}
+		samplers[namespace] = parentOrSampler(trace.ProbabilitySampler(rate))
+	}
+	namespaceSamplers.Store(&samplers)
+	return nil
+}
+
+// parentOrSampler follows the sampling decision of the parent span, if there
+// is one, so that a trace is never partially sampled. The given sampler only
+// decides for root spans.
+func parentOrSampler(sampler trace.Sampler) trace.Sampler {
+	return func(p trace.SamplingParameters) trace.SamplingDecision {
+		if p.ParentContext.TraceID != (trace.TraceID{}) {
+			return trace.SamplingDecision{Sample: p.ParentContext.IsSampled()}
+		}
+		return sampler(p)
+	}
+}
+
+// WatchNamespaceSampling updates the namespace sample rates whenever the
+// config map with the given name changes, usually the tracing config map.
+func WatchNamespaceSampling(logger *zap.SugaredLogger, configMapWatcher configmap.Watcher, name string) {
+	configMapWatcher.Watch(name, func(cm *corev1.ConfigMap) {
+		if err := UpdateNamespaceSampling(cm); err != nil {
+			logger.Errorw("Failed to update namespace sample rates", zap.Error(err))
+		}
+	})
+}
+
+// samplerForNamespace returns the sampler overriding the sample rate of the
+// namespace, or nil to use the global sample rate.
+func samplerForNamespace(namespace string) trace.Sampler {
+	if namespace == "" {
+		return nil
+	}
+	samplers := namespaceSamplers.Load()
+	if samplers == nil {
+		return nil
+	}
+	return (*samplers)[namespace]
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	eventingapis "knative.dev/eventing/pkg/apis"
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func resetNamespaceSampling(t *testing.T) {
+	t.Cleanup(func() {
+		namespaceSamplers.Store(nil)
+	})
+}
+
+func TestUpdateNamespaceSampling(t *testing.T) {
+	resetNamespaceSampling(t)
+
+	require.Nil(t, samplerForNamespace("ns1"))
+
+	err := UpdateNamespaceSampling(&corev1.ConfigMap{Data: map[string]string{
+		"sample-rate":     "0.1",
+		"sample-rate.ns1": "1",
+		"sample-rate.ns2": "0",
+		"sample-rate.":    "1",
+	}})
+	require.Nil(t, err)
+	require.NotNil(t, samplerForNamespace("ns1"))
+	require.NotNil(t, samplerForNamespace("ns2"))
+	require.Nil(t, samplerForNamespace("ns3"))
+	require.Nil(t, samplerForNamespace(""))
+
+	// invalid config maps keep the previous sample rates
+	for _, value := range []string{"all", "-0.5", "2"} {
+		err = UpdateNamespaceSampling(&corev1.ConfigMap{Data: map[string]string{"sample-rate.ns3": value}})
+		require.Error(t, err, value)
+		require.NotNil(t, samplerForNamespace("ns1"))
+		require.Nil(t, samplerForNamespace("ns3"))
+	}
+
+	err = UpdateNamespaceSampling(&corev1.ConfigMap{})
+	require.Nil(t, err)
+	require.Nil(t, samplerForNamespace("ns1"))
+}
+
+func TestNamespaceSamplerFollowsParent(t *testing.T) {
+	resetNamespaceSampling(t)
+
+	err := UpdateNamespaceSampling(&corev1.ConfigMap{Data: map[string]string{
+		"sample-rate.always": "1",
+		"sample-rate.never":  "0",
+	}})
+	require.Nil(t, err)
+
+	parent := func(sampled bool) trace.SpanContext {
+		sc := trace.SpanContext{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}}
+		if sampled {
+			sc.TraceOptions = 1
+		}
+		return sc
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		parent      trace.SpanContext
+		wantSampled bool
+	}{
+		{name: "root span sampled by namespace rate", namespace: "always", wantSampled: true},
+		{name: "root span not sampled by namespace rate", namespace: "never"},
+		{name: "sampled parent wins", namespace: "never", parent: parent(true), wantSampled: true},
+		{name: "unsampled parent wins", namespace: "always", parent: parent(false)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			decision := samplerForNamespace(tc.namespace)(trace.SamplingParameters{
+				ParentContext: tc.parent,
+				TraceID:       trace.TraceID{2},
+				SpanID:        trace.SpanID{2},
+			})
+			require.Equal(t, tc.wantSampled, decision.Sample)
+		})
+	}
+}
+
+func TestDispatchWithNamespaceSampling(t *testing.T) {
+	resetNamespaceSampling(t)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+	t.Cleanup(func() {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+	})
+
+	ctx, _ := rectesting.SetupFakeContext(t)
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient().ReturnStatus(http.StatusAccepted)
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithHTTPClient(fakeClient.Client()))
+
+	err := UpdateNamespaceSampling(&corev1.ConfigMap{Data: map[string]string{"sample-rate.sampled": "1"}})
+	require.Nil(t, err)
+
+	tests := []struct {
+		namespace   string
+		wantSampled bool
+	}{
+		{namespace: "sampled", wantSampled: true},
+		{namespace: "other"},
+		{namespace: ""},
+	}
+	for _, tc := range tests {
+		headers := make(http.Header)
+		if tc.namespace != "" {
+			headers.Set(eventingapis.KnNamespaceHeader, tc.namespace)
+		}
+		info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithHeader(headers))
+		require.Nil(t, err)
+		require.Equal(t, tc.wantSampled, info.SpanContext.IsSampled(), tc.namespace)
+	}
+}
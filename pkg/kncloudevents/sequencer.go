@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/spec"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
+// SequenceExtension is the extension of the CloudEvents sequence extension.
+const SequenceExtension = "sequence"
+
+// Sequencer stamps the CloudEvents sequence extension on the events it
+// transforms, with a monotonically increasing number per source and partition
+// key. Events already carrying a sequence keep it.
+//
+// The sequence is zero-padded to 20 digits, so that it's lexicographically
+// ordered as required by the extension. A Sequencer keeps a counter for every
+// source and partition key it has seen, so it should only be used for a
+// bounded set of them.
+type Sequencer struct {
+	mu       sync.Mutex
+	counters map[sequenceKey]uint64
+}
+
+type sequenceKey struct {
+	source       string
+	partitionKey string
+}
+
+// NewSequencer returns a Sequencer starting all sequences at 1.
+func NewSequencer() *Sequencer {
+	return &Sequencer{
+		counters: make(map[sequenceKey]uint64),
+	}
+}
+
+// Transformer returns a transformer stamping the next sequence on messages
+// without one.
+func (s *Sequencer) Transformer() binding.TransformerFunc {
+	return s.transformer("")
+}
+
+// transformer is like Transformer, but sequences messages without a partition
+// key as if they had the given one.
+func (s *Sequencer) transformer(defaultPartitionKey string) binding.TransformerFunc {
+	return func(reader binding.MessageMetadataReader, writer binding.MessageMetadataWriter) error {
+		// event messages return an empty string for missing extensions
+		if !types.IsZero(reader.GetExtension(SequenceExtension)) {
+			return nil
+		}
+
+		_, source := reader.GetAttribute(spec.Source)
+		if source == nil {
+			return fmt.Errorf("can not sequence message without source")
+		}
+		key := sequenceKey{partitionKey: defaultPartitionKey}
+		var err error
+		if key.source, err = types.ToString(source); err != nil {
+			return fmt.Errorf("can not sequence message with invalid source: %w", err)
+		}
+		if partitionKey := reader.GetExtension(DefaultPartitionKeyExtension); !types.IsZero(partitionKey) {
+			if key.partitionKey, err = types.ToString(partitionKey); err != nil {
+				return fmt.Errorf("can not sequence message with invalid partition key: %w", err)
+			}
+		}
+
+		return writer.SetExtension(SequenceExtension, fmt.Sprintf("%020d", s.next(key)))
+	}
+}
+
+func (s *Sequencer) next(key sequenceKey) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key]++
+	return s.counters[key]
+}
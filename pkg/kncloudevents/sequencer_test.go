@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+)
+
+func sequence(t *testing.T, transformer binding.Transformer, source, partitionKey string, extensions ...string) string {
+	e := test.MinEvent()
+	e.SetSource(source)
+	if partitionKey != "" {
+		e.SetExtension(DefaultPartitionKeyExtension, partitionKey)
+	}
+	for i := 0; i+1 < len(extensions); i += 2 {
+		e.SetExtension(extensions[i], extensions[i+1])
+	}
+
+	got, err := binding.ToEvent(context.Background(), binding.ToMessage(&e), transformer)
+	require.Nil(t, err)
+	return sequenceOf(t, got)
+}
+
+func sequenceOf(t *testing.T, e *event.Event) string {
+	value, ok := e.Extensions()[SequenceExtension]
+	require.True(t, ok, "event has no sequence")
+	return value.(string)
+}
+
+func TestSequencer(t *testing.T) {
+	s := NewSequencer()
+	transformer := s.Transformer()
+
+	require.Equal(t, "00000000000000000001", sequence(t, transformer, "a", "key"))
+	require.Equal(t, "00000000000000000002", sequence(t, transformer, "a", "key"))
+
+	// sequences are per source and partition key
+	require.Equal(t, "00000000000000000001", sequence(t, transformer, "a", "other"))
+	require.Equal(t, "00000000000000000001", sequence(t, transformer, "a", ""))
+	require.Equal(t, "00000000000000000001", sequence(t, transformer, "b", "key"))
+	require.Equal(t, "00000000000000000003", sequence(t, transformer, "a", "key"))
+
+	// events with a sequence keep it, without increasing the sequence
+	require.Equal(t, "custom", sequence(t, transformer, "a", "key", SequenceExtension, "custom"))
+	require.Equal(t, "00000000000000000004", sequence(t, transformer, "a", "key"))
+
+	// the default partition key only applies to events without one
+	require.Equal(t, "00000000000000000005", sequence(t, s.transformer("key"), "a", ""))
+	require.Equal(t, "00000000000000000002", sequence(t, s.transformer("key"), "a", "other"))
+}
+
+func TestSequencerIsLexicographicallyOrdered(t *testing.T) {
+	transformer := NewSequencer().Transformer()
+
+	previous := sequence(t, transformer, "a", "")
+	for i := 0; i < 20; i++ {
+		next := sequence(t, transformer, "a", "")
+		require.Less(t, previous, next)
+		previous = next
+	}
+}
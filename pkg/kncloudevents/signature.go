@@ -0,0 +1,417 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SignatureHeader is the header of the signature of a request, see
+	// SignRequest.
+	SignatureHeader = "Signature"
+	// SignatureInputHeader is the header of the components and parameters of
+	// the signature of a request, see SignRequest.
+	SignatureInputHeader = "Signature-Input"
+	// ContentDigestHeader is the header of the digest of the body of a signed
+	// request, see SignRequest.
+	ContentDigestHeader = "Content-Digest"
+
+	signatureLabel     = "sig1"
+	signatureAlgorithm = "hmac-sha256"
+
+	// signatureClockSkew is how far in the future the creation time of a
+	// signature may be, as the clocks of the sender and the receiver drift.
+	signatureClockSkew = 30 * time.Second
+)
+
+// targetComponents are the derived components identifying the target of a
+// signed request, so that the request can't be replayed to another target.
+var targetComponents = []string{"@method", "@target-uri", "@authority"}
+
+// ErrMissingSignature is returned by SignatureVerifier.Verify for requests
+// which aren't signed.
+var ErrMissingSignature = errors.New("request is not signed")
+
+// SignRequest signs the request with the HMAC key with the given ID, following
+// HTTP Message Signatures (RFC 9421) with the hmac-sha256 algorithm. The
+// signature covers the method, the target URI and the authority of the
+// request, the digest of the body, set in the Content-Digest header, the
+// Content-Type header and all the Ce- headers, i.e. the whole event in both
+// content modes. The body is read and replaced by a buffered body.
+func SignRequest(r *http.Request, keyID string, key []byte) error {
+	return signRequest(r, keyID, key, time.Now())
+}
+
+func signRequest(r *http.Request, keyID string, key []byte, now time.Time) error {
+	if keyID == "" || len(key) == 0 {
+		return fmt.Errorf("signature key and key ID must not be empty")
+	}
+	body, err := bufferBody(r)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	r.Header.Set(ContentDigestHeader, contentDigest(body))
+
+	components := requiredComponents(r)
+	params := signatureParams(components, now.Unix(), keyID)
+	base, err := signatureBase(r, components, params)
+	if err != nil {
+		return err
+	}
+	r.Header.Set(SignatureInputHeader, signatureLabel+"="+params)
+	r.Header.Set(SignatureHeader, signatureLabel+"=:"+base64.StdEncoding.EncodeToString(sign(key, base))+":")
+	return nil
+}
+
+// WithSignature signs the requests of the delivery with the HMAC key with the
+// given ID, see SignRequest. Retries are sent with the signature of the first
+// attempt.
+func WithSignature(keyID string, key []byte) SendOption {
+	return func(sc *senderConfig) error {
+		if keyID == "" || len(key) == 0 {
+			return fmt.Errorf("signature key and key ID must not be empty")
+		}
+		sc.signatureKeyID = keyID
+		sc.signatureKey = key
+
+		return nil
+	}
+}
+
+type signatureKey struct{}
+
+type signingKey struct {
+	id  string
+	key []byte
+}
+
+func contextWithSignatureKey(ctx context.Context, keyID string, key []byte) context.Context {
+	return context.WithValue(ctx, signatureKey{}, signingKey{id: keyID, key: key})
+}
+
+// signRequestFromContext signs the request with the key of the context, if
+// any, see WithSignature.
+func signRequestFromContext(ctx context.Context, r *http.Request) error {
+	key, ok := ctx.Value(signatureKey{}).(signingKey)
+	if !ok {
+		return nil
+	}
+	if err := SignRequest(r, key.id, key.key); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
+
+// SignatureKeysFromSecret returns the HMAC keys of the secret, keyed by their
+// ID, which is the key of the data of the secret.
+func SignatureKeysFromSecret(secret *corev1.Secret) (map[string][]byte, error) {
+	keys := make(map[string][]byte, len(secret.Data))
+	for id, key := range secret.Data {
+		if len(key) == 0 {
+			continue
+		}
+		keys[id] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no signature keys", secret.Namespace, secret.Name)
+	}
+	return keys, nil
+}
+
+// SignatureVerifier verifies the signatures of requests signed by
+// SignRequest, against the keys set with SetKeys.
+type SignatureVerifier struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+// NewSignatureVerifier creates a SignatureVerifier without keys. Signatures
+// created more than maxAge ago are rejected, maxAge must be positive.
+func NewSignatureVerifier(maxAge time.Duration) (*SignatureVerifier, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("signature max age must be positive, got %v", maxAge)
+	}
+	return &SignatureVerifier{
+		maxAge: maxAge,
+		now:    time.Now,
+	}, nil
+}
+
+// SetKeys replaces the keys signatures are verified against, keyed by their
+// ID, e.g. the keys of SignatureKeysFromSecret when the secret changes.
+func (v *SignatureVerifier) SetKeys(keys map[string][]byte) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.keys = keys
+}
+
+func (v *SignatureVerifier) key(id string) ([]byte, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok := v.keys[id]
+	return key, ok
+}
+
+// Verify verifies the signature of the request, see SignRequest. The
+// signature must cover the method, the target URI and the authority of the
+// request, as received, the Content-Digest, the Content-Type and all the Ce-
+// headers. Signatures created more than maxAge ago, or in the future beyond
+// the clock skew, are rejected. The body is read and replaced by a buffered
+// body.
+func (v *SignatureVerifier) Verify(r *http.Request) error {
+	input, signature := r.Header.Get(SignatureInputHeader), r.Header.Get(SignatureHeader)
+	if input == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	label, components, params, err := parseSignatureInput(input)
+	if err != nil {
+		return err
+	}
+	if params["alg"] != signatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm %q", params["alg"])
+	}
+	key, ok := v.key(params["keyid"])
+	if !ok {
+		return fmt.Errorf("unknown signature key %q", params["keyid"])
+	}
+	created, err := strconv.ParseInt(params["created"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid signature creation time %q", params["created"])
+	}
+	age := v.now().Sub(time.Unix(created, 0))
+	if age > v.maxAge {
+		return fmt.Errorf("signature expired")
+	}
+	if age < -signatureClockSkew {
+		return fmt.Errorf("signature created in the future")
+	}
+
+	covered := make(map[string]bool, len(components))
+	for _, c := range components {
+		covered[c] = true
+	}
+	for _, c := range requiredComponents(r) {
+		if !covered[c] {
+			return fmt.Errorf("signature doesn't cover %s", c)
+		}
+	}
+
+	raw, ok := strings.CutPrefix(signature, label+"=:")
+	if !ok || !strings.HasSuffix(raw, ":") {
+		return fmt.Errorf("invalid signature")
+	}
+	mac, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(raw, ":"))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	base, err := signatureBase(r, components, signatureParams(components, created, params["keyid"]))
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, sign(key, base)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	body, err := bufferBody(r)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+	if r.Header.Get(ContentDigestHeader) != contentDigest(body) {
+		return fmt.Errorf("content digest mismatch")
+	}
+	return nil
+}
+
+// NewSignatureVerificationHandler returns a handler rejecting the requests
+// which aren't signed, or whose signature is invalid, with 401 Unauthorized
+// before passing them to next, see SignatureVerifier.Verify. Requests with
+// bodies exceeding the limit of NewMaxBodySizeHandler are rejected with 413
+// Request Entity Too Large.
+func NewSignatureVerificationHandler(next http.Handler, verifier *SignatureVerifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifier.Verify(r); err != nil {
+			if IsBodyTooLarge(err) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bufferBody reads the body of the request and replaces it with a buffered
+// body, which can be read again with GetBody.
+func bufferBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	return body, nil
+}
+
+func contentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// requiredComponents returns the components a signature of the request must
+// cover.
+func requiredComponents(r *http.Request) []string {
+	components := append([]string{}, targetComponents...)
+	components = append(components, "content-digest")
+	if r.Header.Get("Content-Type") != "" {
+		components = append(components, "content-type")
+	}
+	return append(components, eventHeaderComponents(r.Header)...)
+}
+
+// eventHeaderComponents returns the sorted, lower case names of the Ce-
+// headers.
+func eventHeaderComponents(header http.Header) []string {
+	var components []string
+	for key := range header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(key), "Ce-") {
+			components = append(components, strings.ToLower(key))
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+func signatureParams(components []string, created int64, keyID string) string {
+	quoted := make([]string, 0, len(components))
+	for _, c := range components {
+		quoted = append(quoted, strconv.Quote(c))
+	}
+	return fmt.Sprintf("(%s);created=%d;keyid=%s;alg=%q", strings.Join(quoted, " "), created, strconv.Quote(keyID), signatureAlgorithm)
+}
+
+func signatureBase(r *http.Request, components []string, params string) (string, error) {
+	var base strings.Builder
+	for _, c := range components {
+		if strings.HasPrefix(c, "@") {
+			fmt.Fprintf(&base, "%q: %s\n", c, derivedComponent(r, c))
+			continue
+		}
+		values := r.Header.Values(c)
+		if len(values) == 0 {
+			return "", fmt.Errorf("signed header %s is missing", c)
+		}
+		fmt.Fprintf(&base, "%q: %s\n", c, strings.Join(values, ", "))
+	}
+	fmt.Fprintf(&base, "%q: %s", "@signature-params", params)
+	return base.String(), nil
+}
+
+// derivedComponent returns the value of one of the targetComponents, for
+// both outgoing requests and requests received by a server.
+func derivedComponent(r *http.Request, component string) string {
+	authority := r.Host
+	if authority == "" {
+		authority = r.URL.Host
+	}
+	authority = strings.ToLower(authority)
+
+	switch component {
+	case "@method":
+		return strings.ToUpper(r.Method)
+	case "@authority":
+		return authority
+	default: // @target-uri
+		scheme := r.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+		}
+		return scheme + "://" + authority + r.URL.RequestURI()
+	}
+}
+
+func sign(key []byte, base string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(base))
+	return mac.Sum(nil)
+}
+
+// parseSignatureInput parses a Signature-Input header with a single signature
+// as written by SignRequest.
+func parseSignatureInput(input string) (string, []string, map[string]string, error) {
+	label, rest, ok := strings.Cut(input, "=(")
+	if !ok || label == "" {
+		return "", nil, nil, fmt.Errorf("invalid signature input")
+	}
+	list, rest, ok := strings.Cut(rest, ")")
+	if !ok {
+		return "", nil, nil, fmt.Errorf("invalid signature input")
+	}
+
+	var components []string
+	for _, item := range strings.Fields(list) {
+		c, err := strconv.Unquote(item)
+		if err != nil || c != strings.ToLower(c) || (strings.HasPrefix(c, "@") && !slices.Contains(targetComponents, c)) {
+			return "", nil, nil, fmt.Errorf("invalid signature component %s", item)
+		}
+		components = append(components, c)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(rest, ";") {
+		if param == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(param, "=")
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		params[name] = value
+	}
+	return label, components, params, nil
+}
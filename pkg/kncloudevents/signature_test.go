@@ -0,0 +1,227 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	keys := map[string][]byte{"k1": []byte("secret")}
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://broker-ingress.knative-eventing.svc/ns/broker", strings.NewReader(`{"hello":"world"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Ce-Id", "1")
+		r.Header.Set("Ce-Source", "/source")
+		r.Header.Set("Ce-Type", "example")
+		r.Header.Set("Ce-Specversion", "1.0")
+		return r
+	}
+
+	tests := map[string]struct {
+		sign    bool
+		keyID   string
+		key     []byte
+		created time.Time
+		tamper  func(r *http.Request)
+		wantErr string
+	}{
+		"valid": {
+			sign: true,
+		},
+		"unsigned": {
+			wantErr: ErrMissingSignature.Error(),
+		},
+		"unknown key": {
+			sign:    true,
+			keyID:   "k2",
+			wantErr: `unknown signature key "k2"`,
+		},
+		"wrong key": {
+			sign:    true,
+			key:     []byte("other"),
+			wantErr: "signature mismatch",
+		},
+		"expired": {
+			sign:    true,
+			created: now.Add(-10 * time.Minute),
+			wantErr: "signature expired",
+		},
+		"created in the future": {
+			sign:    true,
+			created: now.Add(time.Minute),
+			wantErr: "signature created in the future",
+		},
+		"within the clock skew": {
+			sign:    true,
+			created: now.Add(10 * time.Second),
+		},
+		"replayed to another broker": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.URL.Path = "/other-ns/broker"
+				r.RequestURI = r.URL.Path
+			},
+			wantErr: "signature mismatch",
+		},
+		"replayed to another host": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.Host = "imc-dispatcher.knative-eventing.svc"
+			},
+			wantErr: "signature mismatch",
+		},
+		"replayed with another method": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.Method = http.MethodPut
+			},
+			wantErr: "signature mismatch",
+		},
+		"target not covered": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				input := r.Header.Get(SignatureInputHeader)
+				r.Header.Set(SignatureInputHeader, strings.Replace(input, `"@target-uri" `, "", 1))
+			},
+			wantErr: "signature doesn't cover @target-uri",
+		},
+		"modified attribute": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.Header.Set("Ce-Type", "other")
+			},
+			wantErr: "signature mismatch",
+		},
+		"added attribute": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.Header.Set("Ce-Subject", "other")
+			},
+			wantErr: "signature doesn't cover ce-subject",
+		},
+		"modified body": {
+			sign: true,
+			tamper: func(r *http.Request) {
+				r.Body = io.NopCloser(strings.NewReader(`{"hello":"mallory"}`))
+			},
+			wantErr: "content digest mismatch",
+		},
+	}
+	for n, tc := range tests {
+		t.Run(n, func(t *testing.T) {
+			verifier, err := NewSignatureVerifier(5 * time.Minute)
+			require.NoError(t, err)
+			verifier.now = func() time.Time { return now }
+			verifier.SetKeys(keys)
+
+			r := newRequest()
+			if tc.sign {
+				keyID, key, created := "k1", keys["k1"], now
+				if tc.keyID != "" {
+					keyID = tc.keyID
+				}
+				if tc.key != nil {
+					key = tc.key
+				}
+				if !tc.created.IsZero() {
+					created = tc.created
+				}
+				require.NoError(t, signRequest(r, keyID, key, created))
+			}
+			if tc.tamper != nil {
+				tc.tamper(r)
+			}
+
+			err = verifier.Verify(r)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.Equal(t, `{"hello":"world"}`, string(body))
+		})
+	}
+}
+
+func TestNewSignatureVerifierMaxAge(t *testing.T) {
+	for _, maxAge := range []time.Duration{0, -time.Minute} {
+		_, err := NewSignatureVerifier(maxAge)
+		require.Error(t, err, maxAge)
+	}
+}
+
+func TestSignatureKeysFromSecret(t *testing.T) {
+	keys, err := SignatureKeysFromSecret(&corev1.Secret{Data: map[string][]byte{
+		"k1": []byte("secret"),
+		"k2": nil,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"k1": []byte("secret")}, keys)
+
+	_, err = SignatureKeysFromSecret(&corev1.Secret{})
+	require.Error(t, err)
+}
+
+func TestDispatcherWithSignature(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	verifier, err := NewSignatureVerifier(time.Minute)
+	require.NoError(t, err)
+	verifier.SetKeys(map[string][]byte{"k1": []byte("secret")})
+	var received atomic.Int32
+	server := httptest.NewServer(NewSignatureVerificationHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}), verifier))
+	t.Cleanup(server.Close)
+
+	url, err := apis.ParseURL(server.URL + "/ns/broker")
+	require.NoError(t, err)
+	destination := duckv1.Addressable{URL: url}
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, WithSignature("k1", []byte("secret")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, info.ResponseCode)
+
+	info, err = dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, info.ResponseCode)
+
+	require.Equal(t, int32(1), received.Load())
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SRVScheme is the scheme of the URLs whose host is resolved with DNS SRV
+// records, to send to the targets over http, see WithSRVDiscovery.
+const SRVScheme = "dns+srv"
+
+// SRVDiscoveryConfig configures the DNS SRV discovery of a Dispatcher. Zero
+// values use the defaults.
+type SRVDiscoveryConfig struct {
+	// RefreshInterval is how long the resolved targets of a record are used,
+	// before the record is resolved again, 30 seconds by default. The TTL of
+	// the records isn't exposed by the resolver of the standard library, so
+	// it should be about the TTL of the records.
+	RefreshInterval time.Duration
+	// Resolver resolves the records, net.DefaultResolver by default.
+	Resolver *net.Resolver
+}
+
+func (c SRVDiscoveryConfig) withDefaults() SRVDiscoveryConfig {
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = 30 * time.Second
+	}
+	if c.Resolver == nil {
+		c.Resolver = net.DefaultResolver
+	}
+	return c
+}
+
+// WithSRVDiscovery makes the Dispatcher resolve the hosts of destinations
+// with DNS SRV records, for URLs with the dns+srv scheme, sent over http, and
+// for http and https URLs whose host is a service record name, e.g.
+// _events._tcp.example.com. Each request is sent to one of the targets of the
+// record with the lowest priority, chosen randomly by their weight (RFC 2782),
+// to the host and port of the target and the path of the URL. Records which
+// can't be resolved again are used until they can.
+func WithSRVDiscovery(config SRVDiscoveryConfig) DispatcherOption {
+	return func(d *Dispatcher) {
+		config = config.withDefaults()
+		d.srv = &srvDiscovery{
+			refreshInterval: config.RefreshInterval,
+			lookup: func(ctx context.Context, name string) ([]*net.SRV, error) {
+				_, records, err := config.Resolver.LookupSRV(ctx, "", "", name)
+				return records, err
+			},
+			records: make(map[string]*srvRecords),
+			now:     time.Now,
+			intn:    rand.Intn,
+		}
+	}
+}
+
+type srvDiscovery struct {
+	refreshInterval time.Duration
+	lookup          func(ctx context.Context, name string) ([]*net.SRV, error)
+	now             func() time.Time
+	intn            func(n int) int
+
+	mu      sync.Mutex
+	records map[string]*srvRecords
+}
+
+type srvRecords struct {
+	targets  []*net.SRV
+	resolved time.Time
+}
+
+// isSRVURL returns whether the host of the URL is to be resolved with SRV
+// records.
+func isSRVURL(u *url.URL) bool {
+	if u.Scheme == SRVScheme {
+		return true
+	}
+	labels := strings.SplitN(u.Hostname(), ".", 3)
+	return len(labels) == 3 && strings.HasPrefix(labels[0], "_") && strings.HasPrefix(labels[1], "_")
+}
+
+// resolveRequest sends the request with a SRV URL to one of the targets of the
+// record. The client of the request remains the one of the destination, so
+// that its CA certs are trusted for all its targets.
+func (s *srvDiscovery) resolveRequest(req *http.Request) error {
+	if !isSRVURL(req.URL) {
+		return nil
+	}
+
+	targets, err := s.targets(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return err
+	}
+	srv := s.pick(targets)
+
+	if req.URL.Scheme == SRVScheme {
+		req.URL.Scheme = "http"
+	}
+	req.URL.Host = net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+	req.Host = ""
+	return nil
+}
+
+// targets returns the targets of the record, resolving it if it wasn't
+// resolved within the refresh interval.
+func (s *srvDiscovery) targets(ctx context.Context, name string) ([]*net.SRV, error) {
+	s.mu.Lock()
+	cached, ok := s.records[name]
+	s.mu.Unlock()
+	if ok && s.now().Sub(cached.resolved) < s.refreshInterval {
+		return cached.targets, nil
+	}
+
+	targets, err := s.lookup(ctx, name)
+	if err == nil && len(targets) == 0 {
+		err = fmt.Errorf("SRV record %s has no targets", name)
+	}
+	if err != nil {
+		if ok {
+			// keep using the last targets until the record resolves again
+			return cached.targets, nil
+		}
+		return nil, fmt.Errorf("failed to resolve SRV record %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	s.records[name] = &srvRecords{targets: targets, resolved: s.now()}
+	s.mu.Unlock()
+	return targets, nil
+}
+
+// pick chooses one of the targets with the lowest priority, randomly by their
+// weight. The targets must not be empty.
+func (s *srvDiscovery) pick(targets []*net.SRV) *net.SRV {
+	var candidates []*net.SRV
+	totalWeight := 0
+	for _, srv := range targets {
+		switch {
+		case len(candidates) == 0 || srv.Priority < candidates[0].Priority:
+			candidates = []*net.SRV{srv}
+			totalWeight = int(srv.Weight)
+		case srv.Priority == candidates[0].Priority:
+			candidates = append(candidates, srv)
+			totalWeight += int(srv.Weight)
+		}
+	}
+
+	if totalWeight == 0 {
+		return candidates[s.intn(len(candidates))]
+	}
+	n := s.intn(totalWeight)
+	for _, srv := range candidates {
+		if n < int(srv.Weight) {
+			return srv
+		}
+		n -= int(srv.Weight)
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestIsSRVURL(t *testing.T) {
+	tests := map[string]bool{
+		"dns+srv://sink.example.com/path":    true,
+		"http://_events._tcp.example.com":    true,
+		"https://_events._tcp.example.com/p": true,
+		"http://sink.example.com":            false,
+		"http://_events.example.com":         false,
+		"http://10.0.0.1:8080":               false,
+	}
+	for raw, want := range tests {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		require.Equal(t, want, isSRVURL(u), raw)
+	}
+}
+
+func TestSRVDiscoveryPick(t *testing.T) {
+	s := &srvDiscovery{}
+	targets := []*net.SRV{
+		{Target: "backup.", Priority: 20, Weight: 100},
+		{Target: "a.", Priority: 10, Weight: 1},
+		{Target: "b.", Priority: 10, Weight: 3},
+	}
+
+	picked := make(map[string]int)
+	for n := 0; n < 4; n++ {
+		s.intn = func(int) int { return n }
+		picked[s.pick(targets).Target]++
+	}
+	require.Equal(t, map[string]int{"a.": 1, "b.": 3}, picked)
+
+	// targets without weight are picked uniformly
+	s.intn = func(n int) int { return n - 1 }
+	require.Equal(t, "b.", s.pick([]*net.SRV{{Target: "a."}, {Target: "b."}}).Target)
+}
+
+func TestSRVDiscoveryRefresh(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var lookups atomic.Int32
+	var lookupErr error
+	s := &srvDiscovery{
+		refreshInterval: time.Minute,
+		lookup: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			lookups.Add(1)
+			return []*net.SRV{{Target: "sink.example.com.", Port: 8080}}, lookupErr
+		},
+		records: make(map[string]*srvRecords),
+		now:     func() time.Time { return now },
+		intn:    func(int) int { return 0 },
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "dns+srv://_events._tcp.example.com/path", nil)
+	require.NoError(t, s.resolveRequest(req))
+	require.Equal(t, "http://sink.example.com:8080/path", req.URL.String())
+
+	req = httptest.NewRequest(http.MethodPost, "dns+srv://_events._tcp.example.com/path", nil)
+	require.NoError(t, s.resolveRequest(req))
+	require.Equal(t, int32(1), lookups.Load(), "the targets must be cached")
+
+	// the last targets are used when the record can't be resolved again
+	now = now.Add(2 * time.Minute)
+	lookupErr = errors.New("lookup failed")
+	req = httptest.NewRequest(http.MethodPost, "https://_events._tcp.example.com/path", nil)
+	require.NoError(t, s.resolveRequest(req))
+	require.Equal(t, "https://sink.example.com:8080/path", req.URL.String())
+	require.Equal(t, int32(2), lookups.Load())
+
+	req = httptest.NewRequest(http.MethodPost, "dns+srv://_other._tcp.example.com/path", nil)
+	require.Error(t, s.resolveRequest(req))
+}
+
+func TestDispatcherWithSRVDiscovery(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	var received [2]atomic.Int32
+	var targets []*net.SRV
+	for i := range received {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received[i].Add(1)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		t.Cleanup(server.Close)
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(u.Port())
+		require.NoError(t, err)
+		targets = append(targets, &net.SRV{Target: u.Hostname() + ".", Port: uint16(port), Weight: 1})
+	}
+
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), WithSRVDiscovery(SRVDiscoveryConfig{}))
+	var next atomic.Int32
+	dispatcher.srv.lookup = func(ctx context.Context, name string) ([]*net.SRV, error) {
+		require.Equal(t, "_events._tcp.example.com", name)
+		return targets, nil
+	}
+	dispatcher.srv.intn = func(n int) int {
+		return int(next.Add(1)) % n
+	}
+
+	destination := duckv1.Addressable{URL: &apis.URL{Scheme: SRVScheme, Host: "_events._tcp.example.com", Path: "/"}}
+	for i := 0; i < 4; i++ {
+		info, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, info.ResponseCode)
+	}
+	require.Equal(t, int32(2), received[0].Load())
+	require.Equal(t, int32(2), received[1].Load())
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+var (
+	// concurrencyLimitM records the current adaptive concurrency limit of a
+	// destination.
+	concurrencyLimitM = stats.Int64(
+		"dispatcher_concurrency_limit",
+		"Current adaptive concurrency limit of the requests to a destination",
+		stats.UnitDimensionless,
+	)
+
+	// shedCountM is a counter which records the number of requests not sent,
+	// since the concurrency limit of their destination was reached.
+	shedCountM = stats.Int64(
+		"dispatcher_shed_count",
+		"Number of requests shed since the concurrency limit of the destination was reached",
+		stats.UnitDimensionless,
+	)
+
+	// retryAfterExtensionM records how much a Retry-After header extended the
+	// backoff before a retry.
+	retryAfterExtensionM = stats.Float64(
+		"dispatcher_retry_after_extension",
+		"Duration a Retry-After header extended the backoff before a retry",
+		stats.UnitMilliseconds,
+	)
+
+	destinationKey = tag.MustNewKey("destination")
+)
+
+func init() {
+	register()
+}
+
+func register() {
+	err := metrics.RegisterResourceView(
+		&view.View{
+			Description: concurrencyLimitM.Description(),
+			Measure:     concurrencyLimitM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{destinationKey},
+		},
+		&view.View{
+			Description: shedCountM.Description(),
+			Measure:     shedCountM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{destinationKey},
+		},
+		&view.View{
+			Name:        "dispatcher_retry_after_extended_count",
+			Description: "Number of retries whose backoff was extended by a Retry-After header",
+			Measure:     retryAfterExtensionM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{destinationKey},
+		},
+		&view.View{
+			Description: retryAfterExtensionM.Description(),
+			Measure:     retryAfterExtensionM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 100000)...),
+			TagKeys:     []tag.Key{destinationKey},
+		},
+	)
+	if err != nil {
+		log.Print("failed to register opencensus views, " + err.Error())
+	}
+}
+
+func reportConcurrencyLimit(destination string, limit int) {
+	ctx, err := tag.New(context.Background(), tag.Insert(destinationKey, destination))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, concurrencyLimitM.M(int64(limit)))
+}
+
+func reportShed(destination string) {
+	ctx, err := tag.New(context.Background(), tag.Insert(destinationKey, destination))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, shedCountM.M(1))
+}
+
+func reportRetryAfterExtension(resp *http.Response, extension time.Duration) {
+	var destination string
+	if resp.Request != nil && resp.Request.URL != nil {
+		destination = resp.Request.URL.String()
+	}
+	ctx, err := tag.New(context.Background(), tag.Insert(destinationKey, destination))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, retryAfterExtensionM.M(float64(extension)/float64(time.Millisecond)))
+}
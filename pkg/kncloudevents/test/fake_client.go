@@ -0,0 +1,247 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// Request is a request recorded by the FakeClient or the Server.
+type Request struct {
+	Method string
+	// URL is the target of the request.
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Response is a scripted response of the FakeClient or the Server.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Delay delays the response.
+	Delay time.Duration
+	// Err is returned instead of a response, e.g. to simulate connection failures.
+	// A Server drops the connection instead.
+	Err error
+}
+
+// Status returns a Response with the given status code and no body.
+func Status(code int) Response {
+	return Response{StatusCode: code}
+}
+
+// Error returns a Response failing the request with the given error.
+func Error(err error) Response {
+	return Response{Err: err}
+}
+
+// Garbage returns a 200 Response claiming to carry an event in structured mode,
+// with a body which isn't a valid event.
+func Garbage() Response {
+	header := make(http.Header)
+	header.Set("Content-Type", event.ApplicationCloudEventsJSON)
+	return Response{StatusCode: http.StatusOK, Header: header, Body: []byte("{not an event")}
+}
+
+// Delayed returns the response delayed by d.
+func (r Response) Delayed(d time.Duration) Response {
+	r.Delay = d
+	return r
+}
+
+// RetryAfter returns a Response with the given status code and a Retry-After
+// header of the given duration, in seconds.
+func RetryAfter(code int, d time.Duration) Response {
+	header := make(http.Header)
+	header.Set("Retry-After", strconv.Itoa(int(d.Seconds())))
+	return Response{StatusCode: code, Header: header}
+}
+
+// Reply returns a 200 Response carrying the given event in structured mode.
+func Reply(e event.Event) Response {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return Error(fmt.Errorf("failed to marshal reply event: %w", err))
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", event.ApplicationCloudEventsJSON)
+	return Response{StatusCode: http.StatusOK, Header: header, Body: body}
+}
+
+// FakeClient is an http.RoundTripper returning scripted responses and recording
+// all requests, so dispatching, retries, dead letter sinks and replies can be
+// tested without starting servers. Use Client to get an *http.Client using it.
+//
+// Responses are returned in the order they are scripted. The last scripted
+// response is repeated once the script is exhausted. Without a script, requests
+// are answered with 200 OK.
+type FakeClient struct {
+	lock     sync.Mutex
+	requests []Request
+	script   []Response
+	scripts  map[string][]Response
+}
+
+var _ http.RoundTripper = (*FakeClient)(nil)
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		scripts: make(map[string][]Response),
+	}
+}
+
+// ReturnStatus scripts the status codes returned for requests to any URL without
+// a script of its own.
+func (c *FakeClient) ReturnStatus(codes ...int) *FakeClient {
+	responses := make([]Response, 0, len(codes))
+	for _, code := range codes {
+		responses = append(responses, Status(code))
+	}
+	return c.Return(responses...)
+}
+
+// Return scripts the responses returned for requests to any URL without a script
+// of its own.
+func (c *FakeClient) Return(responses ...Response) *FakeClient {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.script = append(c.script, responses...)
+	return c
+}
+
+// On scripts the responses returned for requests to the given URL.
+func (c *FakeClient) On(url string, responses ...Response) *FakeClient {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.scripts[url] = append(c.scripts[url], responses...)
+	return c
+}
+
+// Client returns an *http.Client sending requests to the FakeClient.
+func (c *FakeClient) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// Requests returns all recorded requests.
+func (c *FakeClient) Requests() []Request {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// RequestsTo returns the recorded requests to the given URL.
+func (c *FakeClient) RequestsTo(url string) []Request {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var requests []Request
+	for _, r := range c.requests {
+		if r.URL == url {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// RoundTrip records the request and returns the next scripted response for its URL.
+func (c *FakeClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := c.record(req, body)
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+
+	header := resp.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record records the request and returns the next scripted response for its URL.
+func (c *FakeClient) record(req *http.Request, body []byte) Response {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	url := req.URL.String()
+	c.requests = append(c.requests, Request{
+		Method: req.Method,
+		URL:    url,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+
+	var resp Response
+	if script, ok := c.scripts[url]; ok {
+		resp, c.scripts[url] = next(script)
+	} else {
+		resp, c.script = next(c.script)
+	}
+	return resp
+}
+
+// next returns the next response of the script and the remaining script,
+// repeating the last response.
+func next(script []Response) (Response, []Response) {
+	switch len(script) {
+	case 0:
+		return Status(http.StatusOK), script
+	case 1:
+		return script[0], script
+	default:
+		return script[0], script[1:]
+	}
+}
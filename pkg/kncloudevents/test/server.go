@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Exchange is a request received by the Server together with its outcome.
+type Exchange struct {
+	Request
+	// Path is the path of the request.
+	Path string
+	// Received is the time the request was received.
+	Received time.Time
+	// Completed is the time the response was sent or the connection was dropped.
+	Completed time.Time
+	// StatusCode is the status code of the response, or 0 if the connection was dropped.
+	StatusCode int
+	// Dropped is true if the connection was dropped instead of sending a response.
+	Dropped bool
+}
+
+// Server is a sink for tests, answering requests with scripted responses per path
+// and recording the timeline of all requests.
+//
+// Responses are returned in the order they are scripted, the last scripted
+// response is repeated once the script is exhausted. Without a script, requests
+// are answered with 200 OK. Responses with an Err drop the connection.
+type Server struct {
+	*httptest.Server
+
+	lock           sync.Mutex
+	script         []Response
+	scripts        map[string][]Response
+	authorizations map[string]string
+	timeline       []Exchange
+}
+
+// StartServer starts a Server, which is closed when the test finishes.
+func StartServer(t *testing.T) *Server {
+	s := &Server{
+		scripts:        make(map[string][]Response),
+		authorizations: make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Return scripts the responses returned for requests to any path without a script
+// of its own.
+func (s *Server) Return(responses ...Response) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.script = append(s.script, responses...)
+	return s
+}
+
+// On scripts the responses returned for requests to the given path.
+func (s *Server) On(path string, responses ...Response) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.scripts[path] = append(s.scripts[path], responses...)
+	return s
+}
+
+// RequireAuthorization answers requests to the given path with 401 Unauthorized,
+// unless they carry the given Authorization header, e.g. "Bearer <token>".
+func (s *Server) RequireAuthorization(path, authorization string) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.authorizations[path] = authorization
+	return s
+}
+
+// URLFor returns the URL of the given path on the server.
+func (s *Server) URLFor(path string) string {
+	return s.URL + path
+}
+
+// Timeline returns all received requests in the order they were received.
+func (s *Server) Timeline() []Exchange {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Exchange(nil), s.timeline...)
+}
+
+// RequestsTo returns the received requests to the given path.
+func (s *Server) RequestsTo(path string) []Exchange {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var exchanges []Exchange
+	for _, e := range s.timeline {
+		if e.Path == path {
+			exchanges = append(exchanges, e)
+		}
+	}
+	return exchanges
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	received := time.Now()
+	body, _ := io.ReadAll(r.Body)
+
+	resp, i := s.receive(r, body, received)
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+		}
+	}
+
+	if resp.Err != nil {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+			}
+		}
+		s.complete(i, 0)
+		return
+	}
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(resp.Body)
+	s.complete(i, resp.StatusCode)
+}
+
+// receive records the request and returns its response and its index in the timeline.
+func (s *Server) receive(r *http.Request, body []byte, received time.Time) (Response, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path := r.URL.Path
+	s.timeline = append(s.timeline, Exchange{
+		Request: Request{
+			Method: r.Method,
+			URL:    s.URL + r.URL.RequestURI(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		},
+		Path:     path,
+		Received: received,
+	})
+
+	if authorization, ok := s.authorizations[path]; ok && r.Header.Get("Authorization") != authorization {
+		return Status(http.StatusUnauthorized), len(s.timeline) - 1
+	}
+
+	var resp Response
+	if script, ok := s.scripts[path]; ok {
+		resp, s.scripts[path] = next(script)
+	} else {
+		resp, s.script = next(s.script)
+	}
+	return resp, len(s.timeline) - 1
+}
+
+func (s *Server) complete(i int, statusCode int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timeline[i].Completed = time.Now()
+	s.timeline[i].StatusCode = statusCode
+	s.timeline[i].Dropped = statusCode == 0
+}
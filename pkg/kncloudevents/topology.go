@@ -0,0 +1,285 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/spec"
+	"go.opencensus.io/metric/metricproducer"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// FlowCountViewName is the name of the view counting the events flowing
+	// along the edges of the eventing topology.
+	FlowCountViewName = "event_flow_count"
+	// FlowLatencyViewName is the name of the view of the dispatch latencies
+	// along the edges of the eventing topology.
+	FlowLatencyViewName = "event_flow_latencies"
+
+	// Components sending events, used as the sender of FlowEdges.
+	FlowComponentBrokerIngress     = "broker-ingress"
+	FlowComponentBrokerFilter      = "broker-filter"
+	FlowComponentChannelDispatcher = "channel-dispatcher"
+)
+
+var (
+	// flowLatencyM records the duration of the dispatch of an event along an
+	// edge of the topology, including retries, failovers and replies.
+	flowLatencyM = stats.Float64(
+		"event_flow",
+		"Dispatch duration of events along an edge of the eventing topology",
+		stats.UnitMilliseconds,
+	)
+
+	flowSenderKey            = tag.MustNewKey("sender_component")
+	flowSenderKindKey        = tag.MustNewKey("sender_kind")
+	flowSenderNamespaceKey   = tag.MustNewKey("sender_namespace")
+	flowSenderNameKey        = tag.MustNewKey("sender_name")
+	flowReceiverKindKey      = tag.MustNewKey("receiver_kind")
+	flowReceiverNamespaceKey = tag.MustNewKey("receiver_namespace")
+	flowReceiverNameKey      = tag.MustNewKey("receiver_name")
+	flowEventTypeKey         = tag.MustNewKey("event_type")
+	flowResponseCodeClassKey = tag.MustNewKey("response_code_class")
+	flowErrorKey             = tag.MustNewKey("error")
+
+	flowTagKeys = []tag.Key{
+		flowSenderKey,
+		flowSenderKindKey,
+		flowSenderNamespaceKey,
+		flowSenderNameKey,
+		flowReceiverKindKey,
+		flowReceiverNamespaceKey,
+		flowReceiverNameKey,
+		flowEventTypeKey,
+		flowResponseCodeClassKey,
+		flowErrorKey,
+	}
+)
+
+func init() {
+	registerFlowViews()
+}
+
+func registerFlowViews() {
+	err := metrics.RegisterResourceView(
+		&view.View{
+			Name:        FlowCountViewName,
+			Description: "Number of events dispatched along an edge of the eventing topology",
+			Measure:     flowLatencyM,
+			Aggregation: view.Count(),
+			TagKeys:     flowTagKeys,
+		},
+		&view.View{
+			Name:        FlowLatencyViewName,
+			Description: flowLatencyM.Description(),
+			Measure:     flowLatencyM,
+			Aggregation: view.Distribution(metrics.Buckets125(1, 10000)...),
+			TagKeys:     flowTagKeys,
+		},
+	)
+	if err != nil {
+		log.Print("failed to register opencensus views, " + err.Error())
+	}
+}
+
+// FlowResource identifies a resource of the eventing topology, e.g. a
+// Broker or a Trigger.
+type FlowResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r FlowResource) String() string {
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// FlowEdge is an edge of the eventing topology: the component dispatching
+// events on behalf of a resource, and the resource receiving them. All
+// dispatcher call sites label their metrics the same way, so that a single
+// dashboard can show the flow of events from sources to sinks.
+type FlowEdge struct {
+	// Sender is the component dispatching the events, e.g.
+	// FlowComponentBrokerFilter.
+	Sender string
+	// SenderResource is the resource the events are dispatched for, e.g.
+	// the Broker of the Trigger.
+	SenderResource FlowResource
+	// Receiver is the resource the events are dispatched to, e.g. the
+	// Trigger.
+	Receiver FlowResource
+}
+
+func (e FlowEdge) String() string {
+	return fmt.Sprintf("%s (%s) -> %s", e.Sender, e.SenderResource, e.Receiver)
+}
+
+// WithFlowEdge records the dispatch along the given edge of the eventing
+// topology, labelled with the type of the event and the outcome.
+func WithFlowEdge(edge FlowEdge) SendOption {
+	return func(sc *senderConfig) error {
+		if edge.Sender == "" {
+			return fmt.Errorf("flow edge sender must not be empty")
+		}
+		sc.flowEdge = &edge
+
+		return nil
+	}
+}
+
+// reportFlow records the dispatch of the message along the edge.
+func reportFlow(edge *FlowEdge, eventType string, start time.Time, info *DispatchInfo, err error) {
+	responseCode := NoResponse
+	if info != nil {
+		responseCode = info.ResponseCode
+	}
+	responseCodeClass := ""
+	if responseCode != NoResponse {
+		responseCodeClass = metrics.ResponseCodeClass(responseCode)
+	}
+
+	ctx, tagErr := tag.New(context.Background(),
+		tag.Insert(flowSenderKey, edge.Sender),
+		tag.Insert(flowSenderKindKey, edge.SenderResource.Kind),
+		tag.Insert(flowSenderNamespaceKey, edge.SenderResource.Namespace),
+		tag.Insert(flowSenderNameKey, edge.SenderResource.Name),
+		tag.Insert(flowReceiverKindKey, edge.Receiver.Kind),
+		tag.Insert(flowReceiverNamespaceKey, edge.Receiver.Namespace),
+		tag.Insert(flowReceiverNameKey, edge.Receiver.Name),
+		tag.Insert(flowEventTypeKey, eventType),
+		tag.Insert(flowResponseCodeClassKey, responseCodeClass),
+		tag.Insert(flowErrorKey, fmt.Sprint(err != nil)),
+	)
+	if tagErr != nil {
+		return
+	}
+	metrics.Record(ctx, flowLatencyM.M(float64(time.Since(start))/float64(time.Millisecond)))
+}
+
+func messageEventType(message binding.Message) string {
+	reader, ok := message.(binding.MessageMetadataReader)
+	if !ok {
+		return ""
+	}
+	return attributeString(reader, spec.Type)
+}
+
+// FlowStats are the aggregated statistics of an edge of the eventing
+// topology.
+type FlowStats struct {
+	Edge FlowEdge
+	// Count is the number of events dispatched along the edge.
+	Count int64
+	// Errors is the number of events which couldn't be delivered.
+	Errors int64
+	// EventTypes are the number of events dispatched along the edge per
+	// event type.
+	EventTypes map[string]int64
+}
+
+// ErrorRate is the ratio of events which couldn't be delivered, it is 0 if
+// no events were dispatched.
+func (s FlowStats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// AggregateFlows aggregates the rows of the FlowCountViewName view per edge
+// of the topology, sorted by edge.
+func AggregateFlows(rows []*view.Row) []FlowStats {
+	byEdge := make(map[FlowEdge]*FlowStats)
+	for _, row := range rows {
+		count, ok := row.Data.(*view.CountData)
+		if !ok {
+			continue
+		}
+
+		edge := FlowEdge{}
+		var eventType string
+		var failed bool
+		for _, t := range row.Tags {
+			switch t.Key {
+			case flowSenderKey:
+				edge.Sender = t.Value
+			case flowSenderKindKey:
+				edge.SenderResource.Kind = t.Value
+			case flowSenderNamespaceKey:
+				edge.SenderResource.Namespace = t.Value
+			case flowSenderNameKey:
+				edge.SenderResource.Name = t.Value
+			case flowReceiverKindKey:
+				edge.Receiver.Kind = t.Value
+			case flowReceiverNamespaceKey:
+				edge.Receiver.Namespace = t.Value
+			case flowReceiverNameKey:
+				edge.Receiver.Name = t.Value
+			case flowEventTypeKey:
+				eventType = t.Value
+			case flowErrorKey:
+				failed = t.Value == "true"
+			}
+		}
+
+		s, ok := byEdge[edge]
+		if !ok {
+			s = &FlowStats{Edge: edge, EventTypes: make(map[string]int64)}
+			byEdge[edge] = s
+		}
+		s.Count += count.Value
+		s.EventTypes[eventType] += count.Value
+		if failed {
+			s.Errors += count.Value
+		}
+	}
+
+	flows := make([]FlowStats, 0, len(byEdge))
+	for _, s := range byEdge {
+		flows = append(flows, *s)
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		return flows[i].Edge.String() < flows[j].Edge.String()
+	})
+	return flows
+}
+
+// RetrieveFlows returns the statistics of all edges of the topology recorded
+// by this process, across the meters of all resources.
+func RetrieveFlows() []FlowStats {
+	var rows []*view.Row
+	for _, producer := range metricproducer.GlobalManager().GetAll() {
+		meter, ok := producer.(view.Meter)
+		if !ok {
+			continue
+		}
+		if r, err := meter.RetrieveData(FlowCountViewName); err == nil {
+			rows = append(rows, r...)
+		}
+	}
+	return AggregateFlows(rows)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/metrics/metricstest"
+	_ "knative.dev/pkg/metrics/testing"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+)
+
+func TestDispatchWithFlowEdge(t *testing.T) {
+	metricstest.Unregister(FlowCountViewName, FlowLatencyViewName)
+	registerFlowViews()
+	t.Cleanup(func() {
+		metricstest.Unregister(FlowCountViewName, FlowLatencyViewName)
+		registerFlowViews()
+	})
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	edge := FlowEdge{
+		Sender:         FlowComponentBrokerFilter,
+		SenderResource: FlowResource{Kind: "Broker", Namespace: "ns", Name: "default"},
+		Receiver:       FlowResource{Kind: "Trigger", Namespace: "ns", Name: "trigger"},
+	}
+	destination := duckv1.Addressable{URL: apis.HTTP(server.Listener.Addr().String())}
+	dispatcher := NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx))
+
+	event := test.FullEvent()
+	_, err := dispatcher.SendEvent(ctx, event, destination, WithFlowEdge(edge))
+	require.NoError(t, err)
+
+	metricstest.CheckCountData(t, FlowCountViewName, map[string]string{
+		"sender_component":    FlowComponentBrokerFilter,
+		"sender_kind":         "Broker",
+		"sender_namespace":    "ns",
+		"sender_name":         "default",
+		"receiver_kind":       "Trigger",
+		"receiver_namespace":  "ns",
+		"receiver_name":       "trigger",
+		"event_type":          event.Type(),
+		"response_code_class": "2xx",
+		"error":               "false",
+	}, 1)
+
+	status = http.StatusInternalServerError
+	_, err = dispatcher.SendEvent(ctx, event, destination, WithFlowEdge(edge))
+	require.Error(t, err)
+
+	flows := RetrieveFlows()
+	require.Len(t, flows, 1)
+	require.Equal(t, edge, flows[0].Edge)
+	require.Equal(t, int64(2), flows[0].Count)
+	require.Equal(t, int64(1), flows[0].Errors)
+	require.Equal(t, 0.5, flows[0].ErrorRate())
+	require.Equal(t, map[string]int64{event.Type(): 2}, flows[0].EventTypes)
+}
+
+func TestWithFlowEdgeRequiresSender(t *testing.T) {
+	require.Error(t, WithFlowEdge(FlowEdge{})(&senderConfig{}))
+}
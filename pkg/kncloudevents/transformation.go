@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/spec"
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// TransformationOperation is the operation of a Transformation.
+type TransformationOperation string
+
+const (
+	// TransformationSet sets the attribute to the value.
+	TransformationSet TransformationOperation = "set"
+	// TransformationRemove removes the attribute.
+	TransformationRemove TransformationOperation = "remove"
+	// TransformationRename moves the value of the attribute to the attribute
+	// named by To.
+	TransformationRename TransformationOperation = "rename"
+	// TransformationExtensionFromHeader sets the attribute to the value of
+	// the header of the request, if the request has the header.
+	TransformationExtensionFromHeader TransformationOperation = "extensionFromHeader"
+)
+
+// Transformation is a simple mutation of the attributes of the dispatched
+// events, so that they don't need a hop to a transformation service. The
+// attribute is either a context attribute like "subject", or an extension.
+type Transformation struct {
+	Operation TransformationOperation `json:"operation"`
+	Attribute string                  `json:"attribute"`
+	// Value is the value of TransformationSet.
+	Value string `json:"value,omitempty"`
+	// To is the new name of the attribute of TransformationRename.
+	To string `json:"to,omitempty"`
+	// Header is the header of TransformationExtensionFromHeader.
+	Header string `json:"header,omitempty"`
+}
+
+// ParseTransformations parses a JSON list of transformations, e.g. of a
+// config map or an annotation.
+func ParseTransformations(data string) ([]Transformation, error) {
+	var transformations []Transformation
+	if err := json.Unmarshal([]byte(data), &transformations); err != nil {
+		return nil, fmt.Errorf("failed to parse transformations: %w", err)
+	}
+	for i, t := range transformations {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("invalid transformation %d: %w", i, err)
+		}
+	}
+	return transformations, nil
+}
+
+// WithTransformations applies the transformations in order to the events sent
+// to the destination, the failover destinations and the dead letter sink.
+// Replies aren't transformed. The headers of TransformationExtensionFromHeader
+// are looked up in the headers of WithHeader.
+func WithTransformations(transformations ...Transformation) SendOption {
+	return func(sc *senderConfig) error {
+		for i, t := range transformations {
+			if err := t.validate(); err != nil {
+				return fmt.Errorf("invalid transformation %d: %w", i, err)
+			}
+		}
+		sc.transformations = append(sc.transformations, transformations...)
+
+		return nil
+	}
+}
+
+func (t Transformation) validate() error {
+	if err := validateTransformedAttribute(t.Attribute); err != nil {
+		return err
+	}
+	switch t.Operation {
+	case TransformationSet:
+		if t.Value == "" {
+			return fmt.Errorf("value of %s must not be empty", t.Attribute)
+		}
+	case TransformationRemove, TransformationRename:
+		if isRequiredAttribute(t.Attribute) {
+			return fmt.Errorf("required attribute %s must not be removed", t.Attribute)
+		}
+		if t.Operation == TransformationRename {
+			return validateTransformedAttribute(t.To)
+		}
+	case TransformationExtensionFromHeader:
+		if t.Header == "" {
+			return fmt.Errorf("header of %s must not be empty", t.Attribute)
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", t.Operation)
+	}
+	return nil
+}
+
+func validateTransformedAttribute(name string) error {
+	if name == "" {
+		return fmt.Errorf("attribute must not be empty")
+	}
+	if a := spec.V1.Attribute(name); a != nil {
+		if a.Kind() == spec.SpecVersion {
+			return fmt.Errorf("attribute %s must not be transformed", name)
+		}
+		return nil
+	}
+	if !event.IsExtensionNameValid(name) {
+		return fmt.Errorf("invalid extension name %q", name)
+	}
+	return nil
+}
+
+func isRequiredAttribute(name string) bool {
+	a := spec.V1.Attribute(name)
+	if a == nil {
+		return false
+	}
+	switch a.Kind() {
+	case spec.ID, spec.Source, spec.SpecVersion, spec.Type:
+		return true
+	}
+	return false
+}
+
+// transformationsTransformer returns the transformer applying the
+// transformations, looking up the headers in the given headers.
+func transformationsTransformer(transformations []Transformation, header http.Header) binding.TransformerFunc {
+	return func(reader binding.MessageMetadataReader, writer binding.MessageMetadataWriter) error {
+		for _, t := range transformations {
+			var err error
+			switch t.Operation {
+			case TransformationSet:
+				err = setTransformedAttribute(reader, writer, t.Attribute, t.Value)
+			case TransformationRemove:
+				err = setTransformedAttribute(reader, writer, t.Attribute, nil)
+			case TransformationRename:
+				if value := transformedAttribute(reader, t.Attribute); value != nil {
+					if err = setTransformedAttribute(reader, writer, t.To, value); err == nil {
+						err = setTransformedAttribute(reader, writer, t.Attribute, nil)
+					}
+				}
+			case TransformationExtensionFromHeader:
+				if value := header.Get(t.Header); value != "" {
+					err = setTransformedAttribute(reader, writer, t.Attribute, value)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to %s %s: %w", t.Operation, t.Attribute, err)
+			}
+		}
+		return nil
+	}
+}
+
+func transformedAttribute(reader binding.MessageMetadataReader, name string) interface{} {
+	if a := spec.V1.Attribute(name); a != nil {
+		_, value := reader.GetAttribute(a.Kind())
+		return value
+	}
+	return reader.GetExtension(name)
+}
+
+func setTransformedAttribute(reader binding.MessageMetadataReader, writer binding.MessageMetadataWriter, name string, value interface{}) error {
+	if a := spec.V1.Attribute(name); a != nil {
+		attribute, _ := reader.GetAttribute(a.Kind())
+		if attribute == nil {
+			// the spec version of the message doesn't have the attribute
+			return nil
+		}
+		return writer.SetAttribute(attribute, value)
+	}
+	return writer.SetExtension(name, value)
+}
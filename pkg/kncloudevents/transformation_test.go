@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kncloudevents_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"github.com/stretchr/testify/require"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	rectesting "knative.dev/pkg/reconciler/testing"
+
+	"knative.dev/eventing/pkg/auth"
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	kncloudeventstest "knative.dev/eventing/pkg/kncloudevents/test"
+)
+
+func TestParseTransformations(t *testing.T) {
+	transformations, err := kncloudevents.ParseTransformations(`[
+		{"operation": "set", "attribute": "subject", "value": "orders"},
+		{"operation": "rename", "attribute": "exta", "to": "renamed"}
+	]`)
+	require.Nil(t, err)
+	require.Equal(t, []kncloudevents.Transformation{
+		{Operation: kncloudevents.TransformationSet, Attribute: "subject", Value: "orders"},
+		{Operation: kncloudevents.TransformationRename, Attribute: "exta", To: "renamed"},
+	}, transformations)
+
+	for _, data := range []string{
+		`{}`,
+		`[{"operation": "upper", "attribute": "subject"}]`,
+		`[{"operation": "remove", "attribute": "type"}]`,
+		`[{"operation": "set", "attribute": "specversion", "value": "0.3"}]`,
+		`[{"operation": "set", "attribute": "not-valid", "value": "x"}]`,
+		`[{"operation": "set", "attribute": "subject"}]`,
+		`[{"operation": "rename", "attribute": "exta"}]`,
+		`[{"operation": "extensionFromHeader", "attribute": "tenant"}]`,
+	} {
+		_, err := kncloudevents.ParseTransformations(data)
+		require.Error(t, err, data)
+	}
+}
+
+func TestDispatchWithTransformations(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	reply := duckv1.Addressable{URL: apis.HTTP("reply.example.com")}
+	replyEvent := test.FullEvent()
+	replyEvent.SetSubject("reply")
+	fakeClient := kncloudeventstest.NewFakeClient().
+		On(destination.URL.String(), kncloudeventstest.Reply(replyEvent)).
+		On(reply.URL.String(), kncloudeventstest.Status(http.StatusAccepted))
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	e := test.FullEvent()
+	e.SetExtension("legacy", "value")
+	headers := http.Header{"X-Tenant": []string{"acme"}}
+	_, err := dispatcher.SendEvent(ctx, e, destination,
+		kncloudevents.WithReply(&reply),
+		kncloudevents.WithHeader(headers),
+		kncloudevents.WithTransformations(
+			kncloudevents.Transformation{Operation: kncloudevents.TransformationSet, Attribute: "subject", Value: "transformed"},
+			kncloudevents.Transformation{Operation: kncloudevents.TransformationRemove, Attribute: "exbool"},
+			kncloudevents.Transformation{Operation: kncloudevents.TransformationRename, Attribute: "legacy", To: "current"},
+			kncloudevents.Transformation{Operation: kncloudevents.TransformationExtensionFromHeader, Attribute: "tenant", Header: "X-Tenant"},
+			kncloudevents.Transformation{Operation: kncloudevents.TransformationExtensionFromHeader, Attribute: "missing", Header: "X-Missing"},
+		))
+	require.Nil(t, err)
+
+	requests := fakeClient.RequestsTo(destination.URL.String())
+	require.Len(t, requests, 1)
+	header := requests[0].Header
+	require.Equal(t, "transformed", header.Get("Ce-Subject"))
+	require.Empty(t, header.Get("Ce-Exbool"))
+	require.Empty(t, header.Get("Ce-Legacy"))
+	require.Equal(t, "value", header.Get("Ce-Current"))
+	require.Equal(t, "acme", header.Get("Ce-Tenant"))
+	require.Empty(t, header.Get("Ce-Missing"))
+
+	// replies aren't transformed
+	requests = fakeClient.RequestsTo(reply.URL.String())
+	require.Len(t, requests, 1)
+	require.Equal(t, "reply", requests[0].Header.Get("Ce-Subject"))
+	require.NotEmpty(t, requests[0].Header.Get("Ce-Exbool"))
+}
+
+func TestDispatchWithInvalidTransformation(t *testing.T) {
+	ctx, _ := rectesting.SetupFakeContext(t)
+
+	destination := duckv1.Addressable{URL: apis.HTTP("destination.example.com")}
+	fakeClient := kncloudeventstest.NewFakeClient()
+	dispatcher := kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), auth.NewOIDCTokenProvider(ctx), kncloudevents.WithHTTPClient(fakeClient.Client()))
+
+	_, err := dispatcher.SendEvent(ctx, test.FullEvent(), destination, kncloudevents.WithTransformations(
+		kncloudevents.Transformation{Operation: kncloudevents.TransformationRemove, Attribute: "id"},
+	))
+	require.Error(t, err)
+	require.Empty(t, fakeClient.Requests())
+}
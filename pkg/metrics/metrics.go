@@ -17,6 +17,9 @@ limitations under the License.
 package metrics
 
 import (
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/trace"
 	"knative.dev/pkg/metrics/metricskey"
 )
 
@@ -39,6 +42,9 @@ const (
 	// LabelTriggerName is the label for the name of the Trigger.
 	LabelTriggerName = "trigger_name"
 
+	// LabelSubscriptionName is the label for the name of the Subscription.
+	LabelSubscriptionName = "subscription_name"
+
 	// LabelBrokerName is the label for the name of the Broker.
 	LabelBrokerName = "broker_name"
 
@@ -54,6 +60,9 @@ const (
 	// LabelFilterType is the label for the Trigger filter attribute "type".
 	LabelFilterType = "filter_type"
 
+	// LabelOverflowPolicy is the label for the policy applied to the events overflowing a buffer.
+	LabelOverflowPolicy = "overflow_policy"
+
 	// LabelResponseCode is the label for the HTTP response status code.
 	LabelResponseCode = metricskey.LabelResponseCode
 
@@ -72,3 +81,18 @@ const (
 	// LabelResponseTimeout is the label timeout.
 	LabelResponseTimeout = metricskey.LabelResponseTimeout
 )
+
+// ExemplarOptions returns the options to attach the given span context to a
+// recorded measurement as exemplar, so that a latency can be linked to the
+// trace of the request. Unsampled spans aren't attached, since their traces
+// aren't exported.
+func ExemplarOptions(spanContext trace.SpanContext) []stats.Options {
+	if !spanContext.IsSampled() {
+		return nil
+	}
+	return []stats.Options{
+		stats.WithAttachments(metricdata.Attachments{
+			metricdata.AttachmentKeySpanContext: spanContext,
+		}),
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"knative.dev/pkg/metrics"
+
+	eventingmetrics "knative.dev/eventing/pkg/metrics"
+)
+
+// otelScope is the instrumentation scope of the meter of the OpenTelemetry
+// StatsReporter.
+const otelScope = "knative.dev/eventing/pkg/metrics/source"
+
+// otelReporter reports the source metrics with OpenTelemetry instruments,
+// with the same names and attributes as the OpenCensus reporter.
+type otelReporter struct {
+	eventCount      metric.Int64Counter
+	retryEventCount metric.Int64Counter
+}
+
+var _ StatsReporter = (*otelReporter)(nil)
+
+// NewOTelStatsReporter creates a reporter recording the source metrics with
+// the meters of the given OpenTelemetry MeterProvider, for components which
+// export their metrics with OpenTelemetry instead of OpenCensus.
+func NewOTelStatsReporter(provider metric.MeterProvider) (StatsReporter, error) {
+	meter := provider.Meter(otelScope)
+
+	eventCount, err := meter.Int64Counter(eventCountM.Name(),
+		metric.WithDescription(eventCountM.Description()),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", eventCountM.Name(), err)
+	}
+	retryEventCount, err := meter.Int64Counter(retryEventCountM.Name(),
+		metric.WithDescription(retryEventCountM.Description()),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s counter: %w", retryEventCountM.Name(), err)
+	}
+
+	return &otelReporter{
+		eventCount:      eventCount,
+		retryEventCount: retryEventCount,
+	}, nil
+}
+
+func (r *otelReporter) ReportEventCount(args *ReportArgs, responseCode int) error {
+	r.eventCount.Add(context.Background(), 1, metric.WithAttributeSet(otelAttributes(args, responseCode)))
+	return nil
+}
+
+func (r *otelReporter) ReportRetryEventCount(args *ReportArgs, responseCode int) error {
+	r.retryEventCount.Add(context.Background(), 1, metric.WithAttributeSet(otelAttributes(args, responseCode)))
+	return nil
+}
+
+func otelAttributes(args *ReportArgs, responseCode int) attribute.Set {
+	attrs := []attribute.KeyValue{
+		attribute.String(eventingmetrics.LabelNamespaceName, args.Namespace),
+		attribute.String(eventingmetrics.LabelEventSource, args.EventSource),
+		attribute.String(eventingmetrics.LabelEventType, args.EventType),
+		attribute.String(eventingmetrics.LabelEventScheme, args.EventScheme),
+		attribute.String(eventingmetrics.LabelName, args.Name),
+		attribute.String(eventingmetrics.LabelResourceGroup, args.ResourceGroup),
+		attribute.String(eventingmetrics.LabelResponseError, args.Error),
+	}
+	if responseCode > 0 {
+		attrs = append(attrs,
+			attribute.Int(eventingmetrics.LabelResponseCode, responseCode),
+			attribute.String(eventingmetrics.LabelResponseCodeClass, metrics.ResponseCodeClass(responseCode)),
+		)
+	}
+	if args.Error != "" {
+		attrs = append(attrs, attribute.Bool(eventingmetrics.LabelResponseTimeout, args.Timeout))
+	}
+	return attribute.NewSet(attrs...)
+}
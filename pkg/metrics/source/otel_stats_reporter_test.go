@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"knative.dev/eventing/pkg/metrics"
+)
+
+type fakeMeterProvider struct {
+	noop.MeterProvider
+	meter *fakeMeter
+}
+
+func (p *fakeMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+type fakeMeter struct {
+	noop.Meter
+	counters map[string]*fakeCounter
+}
+
+func (m *fakeMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	c := &fakeCounter{}
+	m.counters[name] = c
+	return c, nil
+}
+
+type fakeCounter struct {
+	noop.Int64Counter
+	added []map[string]string
+}
+
+func (c *fakeCounter) Add(_ context.Context, _ int64, opts ...metric.AddOption) {
+	attrs := make(map[string]string)
+	set := metric.NewAddConfig(opts).Attributes()
+	for _, kv := range set.ToSlice() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	c.added = append(c.added, attrs)
+}
+
+func TestOTelStatsReporter(t *testing.T) {
+	meter := &fakeMeter{counters: make(map[string]*fakeCounter)}
+	r, err := NewOTelStatsReporter(&fakeMeterProvider{meter: meter})
+	if err != nil {
+		t.Fatal("Failed to create a new reporter:", err)
+	}
+
+	args := &ReportArgs{
+		Namespace:     "testns",
+		EventType:     "dev.knative.event",
+		EventSource:   "unit-test",
+		Name:          "testsource",
+		ResourceGroup: "testresourcegroup",
+		EventScheme:   "http",
+	}
+	expectSuccess(t, func() error {
+		return r.ReportEventCount(args, http.StatusAccepted)
+	})
+	expectSuccess(t, func() error {
+		return r.ReportRetryEventCount(args, http.StatusServiceUnavailable)
+	})
+	failed := *args
+	failed.Error, failed.Timeout = "context deadline exceeded", true
+	expectSuccess(t, func() error {
+		return r.ReportEventCount(&failed, 0)
+	})
+
+	common := map[string]string{
+		metrics.LabelNamespaceName: "testns",
+		metrics.LabelEventType:     "dev.knative.event",
+		metrics.LabelEventSource:   "unit-test",
+		metrics.LabelName:          "testsource",
+		metrics.LabelResourceGroup: "testresourcegroup",
+		metrics.LabelEventScheme:   "http",
+		metrics.LabelResponseError: "",
+	}
+	with := func(attrs map[string]string) map[string]string {
+		merged := make(map[string]string, len(common)+len(attrs))
+		for k, v := range common {
+			merged[k] = v
+		}
+		for k, v := range attrs {
+			merged[k] = v
+		}
+		return merged
+	}
+
+	wantEvents := []map[string]string{
+		with(map[string]string{metrics.LabelResponseCode: "202", metrics.LabelResponseCodeClass: "2xx"}),
+		with(map[string]string{metrics.LabelResponseError: "context deadline exceeded", metrics.LabelResponseTimeout: "true"}),
+	}
+	if diff := cmp.Diff(wantEvents, meter.counters["event_count"].added); diff != "" {
+		t.Error("Unexpected event_count attributes (-want, +got):", diff)
+	}
+	wantRetries := []map[string]string{
+		with(map[string]string{metrics.LabelResponseCode: "503", metrics.LabelResponseCodeClass: "5xx"}),
+	}
+	if diff := cmp.Diff(wantRetries, meter.counters["retry_event_count"].added); diff != "" {
+		t.Error("Unexpected retry_event_count attributes (-want, +got):", diff)
+	}
+}
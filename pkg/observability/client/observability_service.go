@@ -64,12 +64,21 @@ func (k knativeObservabilityService) RecordSendingEvent(ctx context.Context, eve
 	if spanData != nil {
 		spanName = spanData.Name
 		spanKind = spanData.Kind
+		if spanData.NewRoot {
+			// without span in the context, a root span is started
+			ctx = trace.NewContext(ctx, nil)
+		}
 	}
 
 	ctx, span := trace.StartSpan(ctx, spanName, trace.WithSpanKind(spanKind))
 	span.AddAttributes(obsclient.EventTraceAttributes(&event)...)
-	if spanData != nil && len(spanData.Attributes) > 0 {
-		span.AddAttributes(spanData.Attributes...)
+	if spanData != nil {
+		if len(spanData.Attributes) > 0 {
+			span.AddAttributes(spanData.Attributes...)
+		}
+		for _, link := range spanData.Links {
+			span.AddLink(link)
+		}
 	}
 
 	return ctx, func(errOrResult error) {
@@ -63,3 +63,33 @@ func TestKnativeObservabilityServiceRequestSend(t *testing.T) {
 	}
 	require.Equal(t, expectedAttributes, trace.Attributes)
 }
+
+func TestKnativeObservabilityServiceRootSpan(t *testing.T) {
+	mockExp := make(mockExporter, 1)
+	trace.RegisterExporter(mockExp)
+	t.Cleanup(func() {
+		trace.UnregisterExporter(mockExp)
+	})
+
+	trace.ApplyConfig(trace.Config{
+		DefaultSampler: trace.AlwaysSample(),
+	})
+
+	event := event.New(event.CloudEventsVersionV1)
+	event.SetID("aaa")
+	event.SetType("hello.world")
+	event.SetSource("example.com")
+
+	ctx, parent := trace.StartSpan(context.Background(), "parent")
+	link := observability.ResourceLink("uid")
+	ctx = observability.WithRootSpanData(ctx, "spanname", 1, nil, link)
+
+	_, callback := New().RecordSendingEvent(ctx, event)
+	callback(nil)
+
+	span := <-mockExp
+	require.NotEqual(t, parent.SpanContext().TraceID, span.TraceID)
+	require.Equal(t, trace.SpanID{}, span.ParentSpanID)
+	require.Equal(t, []trace.Link{link}, span.Links)
+	require.Equal(t, "aaa", span.Attributes["cloudevents.id"])
+}
@@ -18,8 +18,10 @@ package observability
 
 import (
 	"context"
+	"crypto/sha256"
 
 	"go.opencensus.io/trace"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type spanDataKey struct{}
@@ -34,6 +36,15 @@ type SpanData struct {
 
 	// Attributes is the additional set of span attributes
 	Attributes []trace.Attribute
+
+	// NewRoot starts the span as a new root span, ignoring the span of the
+	// context, e.g. so that the spans of events sent on a schedule don't share
+	// one trace.
+	NewRoot bool
+
+	// Links are added to the span, e.g. to relate root spans to the resource
+	// they were sent for.
+	Links []trace.Link
 }
 
 // WithSpanData extends the given context with the given span values
@@ -45,6 +56,36 @@ func WithSpanData(ctx context.Context, name string, kind int, attributes []trace
 	})
 }
 
+// WithRootSpanData is like WithSpanData, but each span started with the
+// returned context is a new root span with the given links.
+func WithRootSpanData(ctx context.Context, name string, kind int, attributes []trace.Attribute, links ...trace.Link) context.Context {
+	return context.WithValue(ctx, spanDataKey{}, &SpanData{
+		Name:       name,
+		Kind:       kind,
+		Attributes: attributes,
+		NewRoot:    true,
+		Links:      links,
+	})
+}
+
+// ResourceLink returns a link to a span identifying the resource of the given
+// UID. The span is derived from the UID, so that all root spans linked to the
+// same resource can be related in the tracing backend.
+func ResourceLink(uid types.UID, attributes ...trace.Attribute) trace.Link {
+	sum := sha256.Sum256([]byte(uid))
+
+	link := trace.Link{Type: trace.LinkTypeUnspecified}
+	copy(link.TraceID[:], sum[:16])
+	copy(link.SpanID[:], sum[16:24])
+	if len(attributes) > 0 {
+		link.Attributes = make(map[string]interface{}, len(attributes))
+		for _, a := range attributes {
+			link.Attributes[a.Key()] = a.Value()
+		}
+	}
+	return link
+}
+
 // SpanDataFromContext gets the span values from the context
 func SpanDataFromContext(ctx context.Context) *SpanData {
 	val := ctx.Value(spanDataKey{})
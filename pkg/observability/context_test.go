@@ -20,6 +20,8 @@ import (
 	"context"
 	"reflect"
 	"testing"
+
+	"go.opencensus.io/trace"
 )
 
 func TestSpanData(t *testing.T) {
@@ -42,3 +44,35 @@ func TestSpanData(t *testing.T) {
 		t.Errorf("SpanDataFromContext(). got %v, wanted %v", sd, want)
 	}
 }
+
+func TestRootSpanData(t *testing.T) {
+	link := ResourceLink("uid")
+	ctx := WithRootSpanData(context.Background(), "name", 0, nil, link)
+
+	sd := SpanDataFromContext(ctx)
+	if !sd.NewRoot {
+		t.Error("SpanDataFromContext().NewRoot is false, wanted true")
+	}
+	if !reflect.DeepEqual(sd.Links, []trace.Link{link}) {
+		t.Errorf("SpanDataFromContext().Links got %v, wanted %v", sd.Links, []trace.Link{link})
+	}
+}
+
+func TestResourceLink(t *testing.T) {
+	attributes := K8sAttributes("name", "ns", "pingsource.sources.knative.dev")
+	link := ResourceLink("uid", attributes...)
+
+	if !reflect.DeepEqual(link, ResourceLink("uid", attributes...)) {
+		t.Error("ResourceLink() differs for the same UID")
+	}
+	if link.TraceID == ResourceLink("other").TraceID {
+		t.Error("ResourceLink() has the same trace ID for different UIDs")
+	}
+	want := map[string]interface{}{
+		"k8s.pingsource.sources.knative.dev.name": "name",
+		K8sNamespaceName: "ns",
+	}
+	if !reflect.DeepEqual(link.Attributes, want) {
+		t.Errorf("ResourceLink().Attributes got %v, wanted %v", link.Attributes, want)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	rbacv1listers "k8s.io/client-go/listers/rbac/v1"
 
@@ -68,6 +69,16 @@ func newWarningSinkNotFound(sink *duckv1.Destination) pkgreconciler.Event {
 	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "SinkNotFound", "Sink not found: %s", string(b))
 }
 
+func newWarningDeadLetterSinkNotFound(dls *duckv1.Destination) pkgreconciler.Event {
+	b, _ := json.Marshal(dls)
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "DeadLetterSinkNotFound", "Dead letter sink not found: %s", string(b))
+}
+
+func newWarningAdditionalSinkNotFound(sink *duckv1.Destination) pkgreconciler.Event {
+	b, _ := json.Marshal(sink)
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, "AdditionalSinkNotFound", "Additional sink not found: %s", string(b))
+}
+
 // Reconciler reconciles a ApiServerSource object
 type Reconciler struct {
 	kubeClientSet kubernetes.Interface
@@ -84,6 +95,9 @@ type Reconciler struct {
 	roleLister                 rbacv1listers.RoleLister
 	roleBindingLister          rbacv1listers.RoleBindingLister
 	trustBundleConfigMapLister corev1listers.ConfigMapLister
+
+	// heartbeats, when set, are the heartbeats received from the adapters.
+	heartbeats *reconcilersource.Heartbeats
 }
 
 var _ apiserversourcereconciler.Interface = (*Reconciler)(nil)
@@ -138,6 +152,34 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, source *v1.ApiServerSour
 	}
 	source.Status.MarkSink(sinkAddr)
 
+	var deadLetterSinkAddr *duckv1.Addressable
+	if source.Spec.Delivery != nil && source.Spec.Delivery.DeadLetterSink != nil {
+		dls := source.Spec.Delivery.DeadLetterSink.DeepCopy()
+		if dls.Ref != nil && dls.Ref.Namespace == "" {
+			dls.Ref.Namespace = source.GetNamespace()
+		}
+		dlsAddr, err := r.sinkResolver.AddressableFromDestinationV1(ctx, *dls, source)
+		if err != nil {
+			source.Status.MarkNoSink("DeadLetterSinkNotFound", "")
+			return newWarningDeadLetterSinkNotFound(dls)
+		}
+		deadLetterSinkAddr = dlsAddr
+	}
+
+	additionalSinkAddrs := make([]duckv1.Addressable, 0, len(source.Spec.AdditionalSinks))
+	for _, sink := range source.Spec.AdditionalSinks {
+		dest := sink.DeepCopy()
+		if dest.Ref != nil && dest.Ref.Namespace == "" {
+			dest.Ref.Namespace = source.GetNamespace()
+		}
+		addr, err := r.sinkResolver.AddressableFromDestinationV1(ctx, *dest, source)
+		if err != nil {
+			source.Status.MarkNoSink("AdditionalSinkNotFound", "")
+			return newWarningAdditionalSinkNotFound(dest)
+		}
+		additionalSinkAddrs = append(additionalSinkAddrs, *addr)
+	}
+
 	// resolve namespaces to watch
 	namespaces, err := r.namespacesFromSelector(source)
 	if err != nil {
@@ -158,13 +200,16 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, source *v1.ApiServerSour
 
 	// An empty selector targets all namespaces.
 	allNamespaces := isEmptySelector(source.Spec.NamespaceSelector)
-	ra, err := r.createReceiveAdapter(ctx, source, sinkAddr, namespaces, allNamespaces)
+	ra, err := r.createReceiveAdapter(ctx, source, sinkAddr, deadLetterSinkAddr, additionalSinkAddrs, namespaces, allNamespaces)
 	if err != nil {
 		logging.FromContext(ctx).Errorw("Unable to create the receive adapter", zap.Error(err))
 		return err
 	}
 
 	source.Status.PropagateDeploymentAvailability(ra)
+	if r.heartbeats != nil {
+		r.heartbeats.PropagateDataPlaneHealth(&source.Status, component, source.Namespace, source.Name)
+	}
 
 	cloudEventAttributes, err := r.createCloudEventAttributes(source)
 	if err != nil {
@@ -218,7 +263,25 @@ func isEmptySelector(selector *metav1.LabelSelector) bool {
 	return false
 }
 
-func (r *Reconciler) createReceiveAdapter(ctx context.Context, src *v1.ApiServerSource, sinkAddr *duckv1.Addressable, namespaces []string, allNamespaces bool) (*appsv1.Deployment, error) {
+// joinCACerts returns the CA certificates of the sink and of the additional
+// sinks, the adapter trusts all of them.
+func joinCACerts(sinkAddr *duckv1.Addressable, additionalSinkAddrs []duckv1.Addressable) *string {
+	caCerts := sinkAddr.CACerts
+	for _, addr := range additionalSinkAddrs {
+		if addr.CACerts == nil {
+			continue
+		}
+		if caCerts == nil {
+			caCerts = addr.CACerts
+			continue
+		}
+		joined := strings.TrimSpace(*caCerts) + "\n" + *addr.CACerts
+		caCerts = &joined
+	}
+	return caCerts
+}
+
+func (r *Reconciler) createReceiveAdapter(ctx context.Context, src *v1.ApiServerSource, sinkAddr *duckv1.Addressable, deadLetterSinkAddr *duckv1.Addressable, additionalSinkAddrs []duckv1.Addressable, namespaces []string, allNamespaces bool) (*appsv1.Deployment, error) {
 	// TODO: missing.
 	// if err := checkResourcesStatus(src); err != nil {
 	// 	return nil, err
@@ -227,16 +290,34 @@ func (r *Reconciler) createReceiveAdapter(ctx context.Context, src *v1.ApiServer
 	featureFlags := feature.FromContext(ctx)
 
 	adapterArgs := resources.ReceiveAdapterArgs{
-		Image:         r.receiveAdapterImage,
-		Source:        src,
-		Labels:        resources.Labels(src.Name),
-		CACerts:       sinkAddr.CACerts,
-		SinkURI:       sinkAddr.URL.String(),
-		Audience:      sinkAddr.Audience,
-		Configs:       r.configs,
-		Namespaces:    namespaces,
-		AllNamespaces: allNamespaces,
-		NodeSelector:  featureFlags.NodeSelector(),
+		Image:           r.receiveAdapterImage,
+		Source:          src,
+		Labels:          resources.Labels(src.Name),
+		CACerts:         joinCACerts(sinkAddr, additionalSinkAddrs),
+		SinkURI:         sinkAddr.URL.String(),
+		Audience:        sinkAddr.Audience,
+		Configs:         r.configs,
+		Namespaces:      namespaces,
+		AllNamespaces:   allNamespaces,
+		NodeSelector:    featureFlags.NodeSelector(),
+		AdditionalSinks: additionalSinkAddrs,
+	}
+	if r.heartbeats != nil {
+		adapterArgs.HeartbeatSink = reconcilersource.HeartbeatSink()
+	}
+	// The adapter watches the namespaces matching a non-empty selector, so that
+	// it isn't redeployed as the namespaces come and go.
+	if src.Spec.NamespaceSelector != nil && !allNamespaces {
+		selector, err := metav1.LabelSelectorAsSelector(src.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		adapterArgs.NamespaceSelector = selector.String()
+		adapterArgs.Namespaces = nil
+	}
+	if deadLetterSinkAddr != nil {
+		adapterArgs.DeadLetterSinkURI = deadLetterSinkAddr.URL.String()
+		adapterArgs.DeadLetterSinkAudience = deadLetterSinkAddr.Audience
 	}
 
 	expected, err := resources.MakeReceiveAdapter(&adapterArgs)
@@ -352,6 +433,37 @@ func (r *Reconciler) runAccessCheck(ctx context.Context, src *v1.ApiServerSource
 			}
 		}
 	}
+	// The adapter watches the namespaces matching a non-empty selector.
+	if src.Spec.NamespaceSelector != nil && !isEmptySelector(src.Spec.NamespaceSelector) {
+		missingVerbs := ""
+		sep1 := ""
+		for _, verb := range []string{"list", "watch"} {
+			sar := &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Verb:     verb,
+						Resource: "namespaces",
+					},
+					User: user,
+				},
+			}
+
+			response, err := r.kubeClientSet.AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+
+			if !response.Status.Allowed {
+				missingVerbs += sep1 + verb
+				sep1 = ", "
+			}
+		}
+
+		if missingVerbs != "" {
+			missing += sep + missingVerbs + ` resource "namespaces" in API group ""`
+		}
+	}
+
 	if missing == "" {
 		src.Status.MarkSufficientPermissions()
 		return nil
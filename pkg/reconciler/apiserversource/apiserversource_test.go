@@ -665,9 +665,11 @@ func TestReconcile(t *testing.T) {
 			makeNamespacedSubjectAccessReview("namespaces", "get", "default", "test-b"),
 			makeNamespacedSubjectAccessReview("namespaces", "list", "default", "test-b"),
 			makeNamespacedSubjectAccessReview("namespaces", "watch", "default", "test-b"),
+			makeNamespacedSubjectAccessReview("namespaces", "list", "default", ""),
+			makeNamespacedSubjectAccessReview("namespaces", "watch", "default", ""),
 		},
 		WantUpdates: []clientgotesting.UpdateActionImpl{{
-			Object: makeAvailableReceiveAdapterWithNamespaces(t, []string{"test-a", "test-b"}, false),
+			Object: makeAvailableReceiveAdapterWithNamespaceSelector(t, "target=yes"),
 		}},
 		WantEvents: []string{
 			Eventf(corev1.EventTypeNormal, "FinalizerUpdate", "Updated %q finalizers", sourceName),
@@ -1780,6 +1782,40 @@ func makeAvailableReceiveAdapterWithNamespaces(t *testing.T, namespaces []string
 	return ra
 }
 
+func makeAvailableReceiveAdapterWithNamespaceSelector(t *testing.T, selector string) *appsv1.Deployment {
+	t.Helper()
+
+	src := rttestingv1.NewApiServerSource(sourceName, testNS,
+		rttestingv1.WithApiServerSourceSpec(sourcesv1.ApiServerSourceSpec{
+			Resources: []sourcesv1.APIVersionKindSelector{{
+				APIVersion: "v1",
+				Kind:       "Namespace",
+			}},
+			SourceSpec: duckv1.SourceSpec{Sink: sinkDest},
+		}),
+		rttestingv1.WithApiServerSourceUID(sourceUID),
+		// Status Update:
+		rttestingv1.WithInitApiServerSourceConditions,
+		rttestingv1.WithApiServerSourceDeployed,
+		rttestingv1.WithApiServerSourceSink(sinkURI),
+	)
+
+	args := resources.ReceiveAdapterArgs{
+		Image:             image,
+		Source:            src,
+		Labels:            resources.Labels(sourceName),
+		SinkURI:           sinkURI.String(),
+		Configs:           &reconcilersource.EmptyVarsGenerator{},
+		NamespaceSelector: selector,
+	}
+
+	ra, err := resources.MakeReceiveAdapter(&args)
+	require.NoError(t, err)
+
+	rttesting.WithDeploymentAvailable()(ra)
+	return ra
+}
+
 func makeReceiveAdapterWithDifferentEnv(t *testing.T) *appsv1.Deployment {
 	ra := makeReceiveAdapter(t)
 	ra.Spec.Template.Spec.Containers[0].Env = append(ra.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{
@@ -1955,3 +1991,20 @@ func makeAvailableReceiveAdapterWithNodeSelector(t *testing.T, selector map[stri
 	rttesting.WithDeploymentAvailable()(ra)
 	return ra
 }
+
+func TestJoinCACerts(t *testing.T) {
+	sinkCACerts := "sink-ca"
+	auditCACerts := "audit-ca"
+
+	got := joinCACerts(&duckv1.Addressable{CACerts: &sinkCACerts}, []duckv1.Addressable{
+		{CACerts: &auditCACerts},
+		{},
+	})
+	require.NotNil(t, got)
+	require.Equal(t, "sink-ca\naudit-ca", *got)
+
+	got = joinCACerts(&duckv1.Addressable{}, []duckv1.Addressable{{CACerts: &auditCACerts}})
+	require.Equal(t, &auditCACerts, got)
+
+	require.Nil(t, joinCACerts(&duckv1.Addressable{}, nil))
+}
@@ -95,6 +95,7 @@ func NewController(
 		roleLister:                 roleInformer.Lister(),
 		roleBindingLister:          rolebindingInformer.Lister(),
 		trustBundleConfigMapLister: trustBundleConfigMapInformer.Lister(),
+		heartbeats:                 reconcilersource.GetHeartbeats(ctx),
 	}
 
 	env := &envConfig{}
@@ -117,6 +118,12 @@ func NewController(
 
 	apiServerSourceInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
+	if r.heartbeats != nil {
+		r.heartbeats.OnHealthChanged(component, func(namespace, name string) {
+			impl.EnqueueKey(types.NamespacedName{Namespace: namespace, Name: name})
+		})
+	}
+
 	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: controller.FilterController(&v1.ApiServerSource{}),
 		Handler:    controller.HandleAll(impl.EnqueueControllerOf),
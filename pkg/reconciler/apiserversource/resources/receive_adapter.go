@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"knative.dev/eventing/pkg/adapter/v2"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 	"knative.dev/pkg/kmeta"
 	"knative.dev/pkg/ptr"
 	"knative.dev/pkg/system"
@@ -48,7 +49,19 @@ type ReceiveAdapterArgs struct {
 	Configs       reconcilersource.ConfigAccessor
 	Namespaces    []string
 	AllNamespaces bool
-	NodeSelector  map[string]string
+	// NamespaceSelector is the label selector of the namespaces watched by the
+	// adapter, Namespaces is ignored when set.
+	NamespaceSelector string
+	NodeSelector      map[string]string
+	// DeadLetterSinkURI is the resolved dead letter sink of Source.Spec.Delivery.
+	DeadLetterSinkURI string
+	// DeadLetterSinkAudience is the OIDC audience of the dead letter sink.
+	DeadLetterSinkAudience *string
+	// AdditionalSinks are the resolved additional sinks of Source.
+	AdditionalSinks []duckv1.Addressable
+	// HeartbeatSink is the URI the adapter sends its heartbeats to, none are
+	// sent when empty.
+	HeartbeatSink string
 }
 
 // MakeReceiveAdapter generates (but does not insert into K8s) the Receive Adapter Deployment for
@@ -122,12 +135,57 @@ func MakeReceiveAdapter(args *ReceiveAdapterArgs) (*appsv1.Deployment, error) {
 
 func makeEnv(args *ReceiveAdapterArgs) ([]corev1.EnvVar, error) {
 	cfg := &apiserver.Config{
-		Namespaces:    args.Namespaces,
-		Resources:     make([]apiserver.ResourceWatch, 0, len(args.Source.Spec.Resources)),
-		ResourceOwner: args.Source.Spec.ResourceOwner,
-		EventMode:     args.Source.Spec.EventMode,
-		AllNamespaces: args.AllNamespaces,
-		Filters:       args.Source.Spec.Filters,
+		Namespaces:        args.Namespaces,
+		Resources:         make([]apiserver.ResourceWatch, 0, len(args.Source.Spec.Resources)),
+		ResourceOwner:     args.Source.Spec.ResourceOwner,
+		EventMode:         args.Source.Spec.EventMode,
+		PayloadMode:       args.Source.Spec.PayloadMode,
+		Projection:        args.Source.Spec.Projection,
+		EventTemplate:     args.Source.Spec.EventTemplate,
+		AllNamespaces:     args.AllNamespaces,
+		NamespaceSelector: args.NamespaceSelector,
+		Filters:           args.Source.Spec.Filters,
+		ReplayToken:       args.Source.Annotations[v1.ApiServerSourceReplayAnnotation],
+	}
+
+	if args.Source.Spec.SendInitialEvents != nil {
+		cfg.SendInitialEvents = *args.Source.Spec.SendInitialEvents
+	}
+
+	if args.Source.Spec.DebounceWindowMillis != nil {
+		cfg.DebounceWindowMillis = *args.Source.Spec.DebounceWindowMillis
+	}
+
+	if args.Source.Spec.Workers != nil {
+		cfg.Workers = int(*args.Source.Spec.Workers)
+	}
+
+	if dedup := args.Source.Spec.Dedup; dedup != nil {
+		cfg.Dedup = &apiserver.DedupConfig{
+			ConfigMapName: dedup.ConfigMapName,
+		}
+		if dedup.Size != nil {
+			cfg.Dedup.Size = int(*dedup.Size)
+		}
+	}
+
+	if delivery := args.Source.Spec.Delivery; delivery != nil {
+		cfg.Delivery = &apiserver.DeliveryConfig{
+			BackoffPolicy:          delivery.BackoffPolicy,
+			BackoffDelay:           delivery.BackoffDelay,
+			DeadLetterSink:         args.DeadLetterSinkURI,
+			DeadLetterSinkAudience: args.DeadLetterSinkAudience,
+		}
+		if delivery.Retry != nil {
+			cfg.Delivery.Retry = *delivery.Retry
+		}
+	}
+
+	for _, sink := range args.AdditionalSinks {
+		cfg.AdditionalSinks = append(cfg.AdditionalSinks, apiserver.SinkConfig{
+			URI:      sink.URL.String(),
+			Audience: sink.Audience,
+		})
 	}
 
 	for _, r := range args.Source.Spec.Resources {
@@ -185,6 +243,13 @@ func makeEnv(args *ReceiveAdapterArgs) ([]corev1.EnvVar, error) {
 		})
 	}
 
+	if args.HeartbeatSink != "" {
+		envs = append(envs, corev1.EnvVar{
+			Name:  adapter.EnvConfigHeartbeatSink,
+			Value: args.HeartbeatSink,
+		})
+	}
+
 	if args.Audience != nil {
 		envs = append(envs, corev1.EnvVar{
 			Name:  adapter.EnvConfigAudience,
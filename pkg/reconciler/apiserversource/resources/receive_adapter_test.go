@@ -212,6 +212,24 @@ O2dgzikq8iSy1BlRsVw=
 		Value: `{"extensions":{"1":"one"}}`,
 	})
 
+	debounceSrc := src.DeepCopy()
+	debounceSrc.Spec.DebounceWindowMillis = ptr.Int64(500)
+	debounceWant := want.DeepCopy()
+	debounceWant.Spec.Template.Spec.Containers[0].Env[1].Value = `{"namespaces":["source-namespace"],"allNamespaces":false,"resources":[{"gvr":{"Group":"","Version":"","Resource":"namespaces"}},{"gvr":{"Group":"batch","Version":"v1","Resource":"jobs"}},{"gvr":{"Group":"","Version":"","Resource":"pods"},"selector":"test-key1=test-value1"}],"owner":{"apiVersion":"custom/v1","kind":"Parent"},"mode":"Resource","debounceWindowMillis":500}`
+
+	workersSrc := src.DeepCopy()
+	workersSrc.Spec.Workers = ptr.Int32(4)
+	workersWant := want.DeepCopy()
+	workersWant.Spec.Template.Spec.Containers[0].Env[1].Value = `{"namespaces":["source-namespace"],"allNamespaces":false,"resources":[{"gvr":{"Group":"","Version":"","Resource":"namespaces"}},{"gvr":{"Group":"batch","Version":"v1","Resource":"jobs"}},{"gvr":{"Group":"","Version":"","Resource":"pods"},"selector":"test-key1=test-value1"}],"owner":{"apiVersion":"custom/v1","kind":"Parent"},"mode":"Resource","workers":4}`
+
+	dedupSrc := src.DeepCopy()
+	dedupSrc.Spec.Dedup = &v1.ApiServerSourceDedup{
+		Size:          ptr.Int32(100),
+		ConfigMapName: "dedup",
+	}
+	dedupWant := want.DeepCopy()
+	dedupWant.Spec.Template.Spec.Containers[0].Env[1].Value = `{"namespaces":["source-namespace"],"allNamespaces":false,"resources":[{"gvr":{"Group":"","Version":"","Resource":"namespaces"}},{"gvr":{"Group":"batch","Version":"v1","Resource":"jobs"}},{"gvr":{"Group":"","Version":"","Resource":"pods"},"selector":"test-key1=test-value1"}],"owner":{"apiVersion":"custom/v1","kind":"Parent"},"mode":"Resource","dedup":{"size":100,"configMapName":"dedup"}}`
+
 	testCases := map[string]struct {
 		want *appsv1.Deployment
 		src  *v1.ApiServerSource
@@ -223,6 +241,15 @@ O2dgzikq8iSy1BlRsVw=
 		}, "TestMakeReceiveAdapterWithExtensionOverride": {
 			src:  ceSrc,
 			want: ceWant,
+		}, "TestMakeReceiveAdapterWithDebounceWindow": {
+			src:  debounceSrc,
+			want: debounceWant,
+		}, "TestMakeReceiveAdapterWithWorkers": {
+			src:  workersSrc,
+			want: workersWant,
+		}, "TestMakeReceiveAdapterWithDedup": {
+			src:  dedupSrc,
+			want: dedupWant,
 		},
 	}
 	for n, tc := range testCases {
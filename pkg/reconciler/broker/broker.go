@@ -52,6 +52,7 @@ import (
 	clientset "knative.dev/eventing/pkg/client/clientset/versioned"
 	brokerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
 	messaginglisters "knative.dev/eventing/pkg/client/listers/messaging/v1"
+	"knative.dev/eventing/pkg/defaults"
 	ducklib "knative.dev/eventing/pkg/duck"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/reconciler/broker/resources"
@@ -77,6 +78,9 @@ type Reconciler struct {
 
 	uriResolver *resolver.URIResolver
 
+	// defaults resolves the config and delivery of the Brokers not setting them.
+	defaults *defaults.Store
+
 	// If specified, only reconcile brokers with these labels
 	brokerClass string
 }
@@ -97,14 +101,15 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, b *eventingv1.Broker) pk
 
 	// 1. Trigger Channel is created for all events. Triggers will Subscribe to this Channel.
 	// 2. Check that Filter / Ingress deployment (shared within cluster are there)
-	chanMan, err := r.getChannelTemplate(ctx, b)
+	settings := r.defaults.Load().Broker(b)
+	chanMan, err := r.getChannelTemplate(ctx, b, settings.Config)
 	if err != nil {
 		b.Status.MarkTriggerChannelFailed("ChannelTemplateFailed", "Error on setting up the ChannelTemplate: %s", err)
 		return err
 	}
 
 	var tmpChannelableSpec duckv1.ChannelableSpec = duckv1.ChannelableSpec{
-		Delivery: b.Spec.Delivery,
+		Delivery: settings.Delivery,
 	}
 
 	logging.FromContext(ctx).Infow("Reconciling the trigger channel")
@@ -188,9 +193,9 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, b *eventingv1.Broker) pk
 	}
 	b.Status.PropagateIngressAvailability(ingressEndpoints)
 
-	if b.Spec.Delivery != nil && b.Spec.Delivery.DeadLetterSink != nil {
-		deadLetterSinkAddr, err := r.uriResolver.AddressableFromDestinationV1(ctx, *b.Spec.Delivery.DeadLetterSink, b)
-		logging.FromContext(ctx).Errorw("broker has deliver spec set. Will use it to mark dls status", zap.Any("dls-addr", deadLetterSinkAddr), zap.Any("broker.spec.delivery", b.Spec.Delivery))
+	if settings.Delivery != nil && settings.Delivery.DeadLetterSink != nil {
+		deadLetterSinkAddr, err := r.uriResolver.AddressableFromDestinationV1(ctx, *settings.Delivery.DeadLetterSink, b)
+		logging.FromContext(ctx).Errorw("broker has deliver spec set. Will use it to mark dls status", zap.Any("dls-addr", deadLetterSinkAddr), zap.Any("broker.spec.delivery", settings.Delivery))
 		if err != nil {
 			b.Status.DeliveryStatus = duckv1.DeliveryStatus{}
 			logging.FromContext(ctx).Errorw("Unable to get the dead letter sink's URI", zap.Error(err))
@@ -267,7 +272,9 @@ type channelTemplate struct {
 	template messagingv1.ChannelTemplateSpec
 }
 
-func (r *Reconciler) getChannelTemplate(ctx context.Context, b *eventingv1.Broker) (*channelTemplate, error) {
+// getChannelTemplate returns the template of the trigger channel of the Broker, read from the
+// ConfigMap referenced by config.
+func (r *Reconciler) getChannelTemplate(ctx context.Context, b *eventingv1.Broker, config *pkgduckv1.KReference) (*channelTemplate, error) {
 	triggerChannelName := resources.BrokerChannelName(b.Name, "trigger")
 	ref := corev1.ObjectReference{
 		Name:      triggerChannelName,
@@ -275,15 +282,15 @@ func (r *Reconciler) getChannelTemplate(ctx context.Context, b *eventingv1.Broke
 	}
 	var template *messagingv1.ChannelTemplateSpec
 
-	if b.Spec.Config != nil {
-		if b.Spec.Config.Kind == "ConfigMap" {
-			if b.Spec.Config.Namespace == "" || b.Spec.Config.Name == "" {
+	if config != nil {
+		if config.Kind == "ConfigMap" {
+			if config.Namespace == "" || config.Name == "" {
 				logging.FromContext(ctx).Errorw("Broker.Spec.Config name and namespace are required",
 					zap.String("namespace", b.Namespace), zap.String("name", b.Name))
 				return nil, errors.New("Broker.Spec.Config name and namespace are required")
 			}
 
-			cm, err := r.configmapLister.ConfigMaps(b.Spec.Config.Namespace).Get(b.Spec.Config.Name)
+			cm, err := r.configmapLister.ConfigMaps(config.Namespace).Get(config.Name)
 			if err != nil {
 				return nil, err
 			}
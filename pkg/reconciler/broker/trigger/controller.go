@@ -47,6 +47,7 @@ import (
 	brokerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/broker"
 	triggerreconciler "knative.dev/eventing/pkg/client/injection/reconciler/eventing/v1/trigger"
 	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1"
+	"knative.dev/eventing/pkg/defaults"
 	"knative.dev/eventing/pkg/duck"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 
@@ -70,6 +71,16 @@ func NewController(
 	featureStore := feature.NewStore(logging.FromContext(ctx).Named("feature-config-store"))
 	featureStore.WatchConfigs(cmw)
 
+	var globalResync func()
+
+	// The dead letter sink of the Triggers falls back to the delivery defaults of their Broker.
+	defaultsStore := defaults.NewStore(logging.FromContext(ctx).Named("defaults-config-store"), func(name string, value interface{}) {
+		if globalResync != nil {
+			globalResync()
+		}
+	})
+	defaultsStore.WatchConfigs(cmw)
+
 	triggerLister := triggerInformer.Lister()
 	r := &Reconciler{
 		eventingClientSet:    eventingclient.Get(ctx),
@@ -81,6 +92,7 @@ func NewController(
 		configmapLister:      configmapInformer.Lister(),
 		secretLister:         secretInformer.Lister(),
 		serviceAccountLister: oidcServiceaccountInformer.Lister(),
+		defaults:             defaultsStore,
 	}
 	impl := triggerreconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
 		return controller.Options{
@@ -90,6 +102,10 @@ func NewController(
 	})
 	r.impl = impl
 
+	globalResync = func() {
+		impl.FilteredGlobalResync(filterTriggers(featureStore, r.brokerLister), triggerInformer.Informer())
+	}
+
 	r.sourceTracker = duck.NewListableTrackerFromTracker(ctx, source.Get, impl.Tracker)
 	r.uriResolver = resolver.NewURIResolverFromTracker(ctx, impl.Tracker)
 
@@ -37,9 +37,11 @@ import (
 	"knative.dev/pkg/configmap"
 	logtesting "knative.dev/pkg/logging/testing"
 
+	apisconfig "knative.dev/eventing/pkg/apis/config"
 	apiseventing "knative.dev/eventing/pkg/apis/eventing"
 	eventing "knative.dev/eventing/pkg/apis/eventing/v1"
 	"knative.dev/eventing/pkg/apis/feature"
+	messagingconfig "knative.dev/eventing/pkg/apis/messaging/config"
 	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
 	v1lister "knative.dev/eventing/pkg/client/listers/eventing/v1"
 	testingv1 "knative.dev/eventing/pkg/reconciler/testing/v1"
@@ -61,7 +63,17 @@ import (
 func TestNew(t *testing.T) {
 	ctx, _ := SetupFakeContext(t, SetUpInformerSelector)
 
-	c := NewController(ctx, configmap.NewStaticWatcher(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config-features"}}))
+	c := NewController(ctx, configmap.NewStaticWatcher(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config-features"}},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: apisconfig.DefaultsConfigName},
+			Data:       map[string]string{apisconfig.BrokerDefaultsKey: "clusterDefault: {}"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: messagingconfig.ChannelDefaultsConfigName},
+			Data:       map[string]string{messagingconfig.ChannelDefaulterKey: "clusterDefault: {}"},
+		},
+	))
 
 	if c == nil {
 		t.Fatal("Expected NewController to return a non-nil value")
@@ -50,6 +50,7 @@ import (
 	clientset "knative.dev/eventing/pkg/client/clientset/versioned"
 	eventinglisters "knative.dev/eventing/pkg/client/listers/eventing/v1"
 	messaginglisters "knative.dev/eventing/pkg/client/listers/messaging/v1"
+	"knative.dev/eventing/pkg/defaults"
 	"knative.dev/eventing/pkg/duck"
 	"knative.dev/eventing/pkg/eventingtls"
 	"knative.dev/eventing/pkg/reconciler/broker/resources"
@@ -86,6 +87,9 @@ type Reconciler struct {
 	// Dynamic tracker to track AddressableTypes. In particular, it tracks Trigger subscribers.
 	uriResolver *resolver.URIResolver
 	impl        *controller.Impl
+
+	// defaults resolves the delivery of the Brokers not setting it.
+	defaults *defaults.Store
 }
 
 func (r *Reconciler) ReconcileKind(ctx context.Context, t *eventingv1.Trigger) pkgreconciler.Event {
@@ -189,7 +193,7 @@ func (r *Reconciler) resolveDeadLetterSink(ctx context.Context, b *eventingv1.Br
 		t.Status.DeliveryStatus = eventingduckv1.NewDeliveryStatusFromAddressable(deadLetterSinkAddr)
 		t.Status.MarkDeadLetterSinkResolvedSucceeded()
 		// In case there is no DLS defined in the Trigger Spec, fallback to Broker's
-	} else if b.Spec.Delivery != nil && b.Spec.Delivery.DeadLetterSink != nil {
+	} else if delivery := r.defaults.Load().Broker(b).Delivery; delivery != nil && delivery.DeadLetterSink != nil {
 		if b.Status.DeliveryStatus.IsSet() {
 			t.Status.DeliveryStatus = b.Status.DeliveryStatus
 			t.Status.MarkDeadLetterSinkResolvedSucceeded()
@@ -271,7 +275,7 @@ func (r *Reconciler) subscribeToBrokerChannel(ctx context.Context, b *eventingv1
 
 	delivery := t.Spec.Delivery.DeepCopy() // copy object to avoid in-place update bugs
 	if delivery == nil {
-		delivery = b.Spec.Delivery.DeepCopy() // copy object to avoid in-place update bugs
+		delivery = r.defaults.Load().Broker(b).Delivery.DeepCopy() // copy object to avoid in-place update bugs
 	}
 
 	recorder := controller.GetEventRecorder(ctx)
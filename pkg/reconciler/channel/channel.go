@@ -18,6 +18,7 @@ package channel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"knative.dev/eventing/pkg/reconciler/channel/resources"
@@ -46,6 +47,7 @@ import (
 	channelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/channel"
 	eventingv1alpha1listers "knative.dev/eventing/pkg/client/listers/eventing/v1alpha1"
 	listers "knative.dev/eventing/pkg/client/listers/messaging/v1"
+	"knative.dev/eventing/pkg/defaults"
 	ducklib "knative.dev/eventing/pkg/duck"
 	eventingduck "knative.dev/eventing/pkg/duck"
 )
@@ -61,6 +63,9 @@ type Reconciler struct {
 	eventPolicyLister eventingv1alpha1listers.EventPolicyLister
 
 	eventingClientSet eventingclientset.Interface
+
+	// defaults resolves the template of the Channels not setting it.
+	defaults *defaults.Store
 }
 
 // Check that our Reconciler implements Interface
@@ -73,17 +78,24 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, c *v1.Channel) pkgreconc
 	// 1. Create the backing Channel CRD, if it doesn't exist.
 	// 2. Propagate the backing Channel CRD Status, Address, and SubscribableStatus into this Channel.
 
-	gvr, _ := meta.UnsafeGuessKindToResource(c.Spec.ChannelTemplate.GetObjectKind().GroupVersionKind())
+	settings := r.defaults.Load().Channel(c)
+	if settings.Template == nil {
+		err := errors.New("failed to find channelTemplate")
+		c.Status.MarkBackingChannelFailed("ChannelTemplateFailed", "Error on setting up the ChannelTemplate: %s", err)
+		return err
+	}
+
+	gvr, _ := meta.UnsafeGuessKindToResource(settings.Template.GetObjectKind().GroupVersionKind())
 	channelResourceInterface := r.dynamicClientSet.Resource(gvr).Namespace(c.Namespace)
 	if channelResourceInterface == nil {
-		return fmt.Errorf("unable to create dynamic client for: %+v", c.Spec.ChannelTemplate)
+		return fmt.Errorf("unable to create dynamic client for: %+v", settings.Template)
 	}
 
 	track := r.channelableTracker.TrackInNamespaceKReference(ctx, c)
 
 	backingChannelObjRef := duckv1.KReference{
-		Kind:       c.Spec.ChannelTemplate.Kind,
-		APIVersion: c.Spec.ChannelTemplate.APIVersion,
+		Kind:       settings.Template.Kind,
+		APIVersion: settings.Template.APIVersion,
 		Name:       c.Name,
 		Namespace:  c.Namespace,
 	}
@@ -92,7 +104,7 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, c *v1.Channel) pkgreconc
 		return fmt.Errorf("unable to track changes to the backing Channel: %v", err)
 	}
 
-	backingChannel, err := r.reconcileBackingChannel(ctx, channelResourceInterface, c, backingChannelObjRef)
+	backingChannel, err := r.reconcileBackingChannel(ctx, channelResourceInterface, c, settings, backingChannelObjRef)
 	if err != nil {
 		c.Status.MarkBackingChannelFailed("ChannelFailure", "%v", err)
 		return fmt.Errorf("problem reconciling the backing channel: %v", err)
@@ -102,7 +114,7 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, c *v1.Channel) pkgreconc
 	c.Status.PropagateStatuses(&backingChannel.Status)
 
 	// If a DeadLetterSink is defined in Spec.Delivery then whe resolve its URI and update the stauts
-	if c.Spec.Delivery != nil && c.Spec.Delivery.DeadLetterSink != nil {
+	if settings.Delivery != nil && settings.Delivery.DeadLetterSink != nil {
 		if backingChannel.Status.DeliveryStatus.IsSet() {
 			c.Status.MarkDeadLetterSinkResolvedSucceeded(backingChannel.Status.DeliveryStatus)
 		} else {
@@ -203,8 +215,9 @@ func (r *Reconciler) policyNeedsUpdate(foundEP, expected *eventingv1alpha1.Event
 	return !equality.Semantic.DeepDerivative(expected, foundEP)
 }
 
-// reconcileBackingChannel reconciles Channel's 'c' underlying CRD channel.
-func (r *Reconciler) reconcileBackingChannel(ctx context.Context, channelResourceInterface dynamic.ResourceInterface, c *v1.Channel, backingChannelObjRef duckv1.KReference) (*eventingduckv1.Channelable, error) {
+// reconcileBackingChannel reconciles Channel's 'c' underlying CRD channel, created with the
+// resolved settings of 'c'.
+func (r *Reconciler) reconcileBackingChannel(ctx context.Context, channelResourceInterface dynamic.ResourceInterface, c *v1.Channel, settings defaults.ChannelSettings, backingChannelObjRef duckv1.KReference) (*eventingduckv1.Channelable, error) {
 	logger := logging.FromContext(ctx)
 	lister, err := r.channelableTracker.ListerForKReference(backingChannelObjRef)
 	if err != nil {
@@ -215,8 +228,10 @@ func (r *Reconciler) reconcileBackingChannel(ctx context.Context, channelResourc
 	// If the resource doesn't exist, we'll create it
 	if err != nil {
 		if apierrs.IsNotFound(err) {
+			channelableSpec := *c.Spec.ChannelableSpec.DeepCopy()
+			channelableSpec.Delivery = settings.Delivery
 			newBackingChannel, err := ducklib.NewPhysicalChannel(
-				c.Spec.ChannelTemplate.TypeMeta,
+				settings.Template.TypeMeta,
 				metav1.ObjectMeta{
 					Name:      c.Name,
 					Namespace: c.Namespace,
@@ -224,11 +239,11 @@ func (r *Reconciler) reconcileBackingChannel(ctx context.Context, channelResourc
 						*kmeta.NewControllerRef(c),
 					},
 				},
-				ducklib.WithChannelableSpec(c.Spec.ChannelableSpec),
-				ducklib.WithPhysicalChannelSpec(c.Spec.ChannelTemplate.Spec),
+				ducklib.WithChannelableSpec(channelableSpec),
+				ducklib.WithPhysicalChannelSpec(settings.Template.Spec),
 			)
 			if err != nil {
-				logger.Errorw("Failed to create Channel from ChannelTemplate", zap.Any("channelTemplate", c.Spec.ChannelTemplate), zap.Error(err))
+				logger.Errorw("Failed to create Channel from ChannelTemplate", zap.Any("channelTemplate", settings.Template), zap.Error(err))
 				return nil, err
 			}
 			logger.Debugf("Creating Channel Object: %+v", newBackingChannel)
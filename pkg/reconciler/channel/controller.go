@@ -34,6 +34,7 @@ import (
 	"knative.dev/eventing/pkg/client/injection/informers/eventing/v1alpha1/eventpolicy"
 	channelinformer "knative.dev/eventing/pkg/client/injection/informers/messaging/v1/channel"
 	channelreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/channel"
+	"knative.dev/eventing/pkg/defaults"
 	"knative.dev/eventing/pkg/duck"
 )
 
@@ -61,6 +62,13 @@ func NewController(
 	})
 	featureStore.WatchConfigs(cmw)
 
+	r.defaults = defaults.NewStore(logging.FromContext(ctx).Named("defaults-config-store"), func(name string, value interface{}) {
+		if globalResync != nil {
+			globalResync()
+		}
+	})
+	r.defaults.WatchConfigs(cmw)
+
 	impl := channelreconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
 		return controller.Options{
 			ConfigStore: featureStore,
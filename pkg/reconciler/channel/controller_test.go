@@ -21,7 +21,9 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apisconfig "knative.dev/eventing/pkg/apis/config"
 	"knative.dev/eventing/pkg/apis/feature"
+	messagingconfig "knative.dev/eventing/pkg/apis/messaging/config"
 
 	"knative.dev/pkg/configmap"
 
@@ -43,6 +45,22 @@ func TestNew(t *testing.T) {
 			Name:      feature.FlagsConfigName,
 			Namespace: "knative-eventing",
 		},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      apisconfig.DefaultsConfigName,
+			Namespace: "knative-eventing",
+		},
+		Data: map[string]string{
+			apisconfig.BrokerDefaultsKey: "clusterDefault: {}",
+		},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      messagingconfig.ChannelDefaultsConfigName,
+			Namespace: "knative-eventing",
+		},
+		Data: map[string]string{
+			messagingconfig.ChannelDefaulterKey: "clusterDefault: {}",
+		},
 	}))
 
 	if c == nil {
@@ -18,6 +18,7 @@ package dispatcher
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"knative.dev/pkg/injection"
@@ -95,6 +96,9 @@ func NewController(
 	if err != nil {
 		logger.Panicw("Error setting up trace publishing", zap.Error(err))
 	}
+	kncloudevents.WatchNamespaceSampling(logger, iw, tracingconfig.ConfigName)
+	kncloudevents.WatchDeliveryDefaults(logger, iw)
+	kncloudevents.WatchRedactedExtensions(iw)
 	var env envConfig
 	if err := envconfig.Process("", &env); err != nil {
 		logger.Panicw("Failed to process env var", zap.Error(err))
@@ -129,14 +133,29 @@ func NewController(
 		TrustBundleConfigMapLister: trustBundleConfigMapInformer.Lister().ConfigMaps(system.Namespace()),
 	}
 
+	// The channels are served on the same ports, the router selects their
+	// handler, and verifies the OIDC tokens of the requests for their audience.
+	secret := types.NamespacedName{
+		Namespace: system.Namespace(),
+		Name:      eventingtls.IMCDispatcherServerTLSSecretName,
+	}
+	router := kncloudevents.NewAddressableRouter(
+		auth.NewOIDCTokenVerifier(ctx),
+		eventingtls.GetCertificateFromSecret(ctx, secretinformer.Get(ctx), kubeclient.Get(ctx), secret),
+	)
+
+	eventDispatcher := kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider,
+		kncloudevents.WithDeliveryFailureLogger(kncloudevents.NewDeliveryFailureLogger(logger.Desugar())),
+	)
+
 	r := &Reconciler{
 		multiChannelEventHandler: sh,
 		reporter:                 reporter,
 		messagingClientSet:       eventingclient.Get(ctx).MessagingV1(),
 		eventingClient:           eventingclient.Get(ctx).EventingV1beta2(),
 		eventTypeLister:          eventtypeinformer.Get(ctx).Lister(),
-		eventDispatcher:          kncloudevents.NewDispatcher(clientConfig, oidcTokenProvider),
-		tokenVerifier:            auth.NewOIDCTokenVerifier(ctx),
+		eventDispatcher:          eventDispatcher,
+		router:                   router,
 		clientConfig:             clientConfig,
 	}
 
@@ -183,13 +202,7 @@ func NewController(
 	httpDispatcher := inmemorychannel.NewEventDispatcher(httpArgs)
 	httpReceiver := httpDispatcher.GetReceiver()
 
-	secret := types.NamespacedName{
-		Namespace: system.Namespace(),
-		Name:      eventingtls.IMCDispatcherServerTLSSecretName,
-	}
-	serverTLSConfig := eventingtls.NewDefaultServerConfig()
-	serverTLSConfig.GetCertificate = eventingtls.GetCertificateFromSecret(ctx, secretinformer.Get(ctx), kubeclient.Get(ctx), secret)
-	tlsConfig, err := eventingtls.GetTLSServerConfig(serverTLSConfig)
+	tlsConfig, err := router.TLSConfig()
 	if err != nil {
 		logger.Panicf("unable to get tls config: %s", err)
 	}
@@ -205,7 +218,11 @@ func NewController(
 	httpsDispatcher := inmemorychannel.NewEventDispatcher(httpsArgs)
 	httpsReceiver := httpsDispatcher.GetReceiver()
 
-	s, err := eventingtls.NewServerManager(ctx, &httpReceiver, &httpsReceiver, httpDispatcher.GetHandler(ctx), cmw)
+	// The features are needed in the context of the requests to verify them.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		router.ServeHTTP(w, req.WithContext(featureStore.ToContext(req.Context())))
+	})
+	s, err := eventingtls.NewServerManager(ctx, &httpReceiver, &httpsReceiver, handler, cmw)
 	if err != nil {
 		logger.Panicf("unable to initialize server manager: %s", err)
 	}
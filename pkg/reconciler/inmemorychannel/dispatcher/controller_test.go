@@ -30,8 +30,11 @@ import (
 
 	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 
+	kubeclient "knative.dev/pkg/client/injection/kube/client/fake"
 	configmap "knative.dev/pkg/configmap/informer"
 	. "knative.dev/pkg/reconciler/testing"
+	"knative.dev/pkg/system"
+	_ "knative.dev/pkg/system/testing"
 
 	// Fake injection client
 	_ "knative.dev/eventing/pkg/client/injection/client/fake"
@@ -57,7 +60,7 @@ func TestNew(t *testing.T) {
 	os.Setenv("CONTAINER_NAME", "testcontainer")
 	os.Setenv("MAX_IDLE_CONNS", "2000")
 	os.Setenv("MAX_IDLE_CONNS_PER_HOST", "200")
-	c := NewController(ctx, &configmap.InformedWatcher{})
+	c := NewController(ctx, configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace()))
 
 	if c == nil {
 		t.Fatal("Expected NewController to return a non-nil value")
@@ -76,7 +79,7 @@ func TestNewInNamespace(t *testing.T) {
 	os.Setenv("CONTAINER_NAME", "testcontainer")
 	os.Setenv("MAX_IDLE_CONNS", "2000")
 	os.Setenv("MAX_IDLE_CONNS_PER_HOST", "200")
-	c := NewController(ctx, &configmap.InformedWatcher{})
+	c := NewController(ctx, configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace()))
 
 	if c == nil {
 		t.Fatal("Expected NewController to return a non-nil value")
@@ -97,7 +100,7 @@ func TestMaxIdleConnsEqualToZero(t *testing.T) {
 	os.Setenv("MAX_IDLE_CONNS_PER_HOST", "200")
 
 	require.Panics(t, func() {
-		NewController(ctx, &configmap.InformedWatcher{})
+		NewController(ctx, configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace()))
 	})
 }
 
@@ -115,7 +118,7 @@ func TestMaxIdleConnsPerHostEqualToZero(t *testing.T) {
 	os.Setenv("MAX_IDLE_CONNS_PER_HOST", "0")
 
 	require.Panics(t, func() {
-		NewController(ctx, &configmap.InformedWatcher{})
+		NewController(ctx, configmap.NewInformedWatcher(kubeclient.Get(ctx), system.Namespace()))
 	})
 }
 
@@ -19,6 +19,7 @@ package dispatcher
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -35,6 +36,7 @@ import (
 
 	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/apis/feature"
+	"knative.dev/eventing/pkg/apis/messaging"
 	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	"knative.dev/eventing/pkg/auth"
 	"knative.dev/eventing/pkg/channel"
@@ -58,7 +60,9 @@ type Reconciler struct {
 	eventingClient           eventingv1beta2.EventingV1beta2Interface
 	featureStore             *feature.Store
 	eventDispatcher          *kncloudevents.Dispatcher
-	tokenVerifier            *auth.OIDCTokenVerifier
+	// router routes the requests to the handlers of the channels, verifying
+	// their OIDC token for the audience of the channel.
+	router *kncloudevents.AddressableRouter
 
 	clientConfig eventingtls.ClientConfig
 }
@@ -119,40 +123,11 @@ func (r *Reconciler) reconcile(ctx context.Context, imc *v1.InMemoryChannel) rec
 		return r.featureStore.ToContext(ctx)
 	}
 
-	// First grab the host based MultiChannelFanoutMessage httpHandler
-	httpHandler := r.multiChannelEventHandler.GetChannelHandler(config.HostName)
-	if httpHandler == nil {
-		// No handler yet, create one.
-		fanoutHandler, err := fanout.NewFanoutEventHandler(
-			logging.FromContext(ctx).Desugar(),
-			config.FanoutConfig,
-			r.reporter,
-			eventTypeAutoHandler,
-			channelRef,
-			UID,
-			r.eventDispatcher,
-			channel.OIDCTokenVerification(r.tokenVerifier, audience(imc)),
-			channel.ReceiverWithContextFunc(wc),
-		)
-		if err != nil {
-			logging.FromContext(ctx).Error("Failed to create a new fanout.EventHandler", err)
-			return err
-		}
-		r.multiChannelEventHandler.SetChannelHandler(config.HostName, fanoutHandler)
-	} else {
-		// Just update the config if necessary.
-		haveSubs := httpHandler.GetSubscriptions(ctx)
-
-		// Ignore the closures, we stash the values that we can tell from if the values have actually changed.
-		if diff := cmp.Diff(config.FanoutConfig.Subscriptions, haveSubs, cmpopts.IgnoreFields(kncloudevents.RetryConfig{}, "Backoff", "CheckRetry")); diff != "" {
-			logging.FromContext(ctx).Info("Updating fanout config: ", zap.String("Diff", diff))
-			httpHandler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
-		}
-	}
-
-	// Look for an https handler that's configured to use paths
-	httpsHandler := r.multiChannelEventHandler.GetChannelHandler(config.Path)
-	if httpsHandler == nil {
+	// The same handler serves the host based references of the http address
+	// and the path based references of the https address, so that the
+	// channel has a single buffer.
+	handler := r.multiChannelEventHandler.GetChannelHandler(config.Path)
+	if handler == nil {
 		// No handler yet, create one.
 		fanoutHandler, err := fanout.NewFanoutEventHandler(
 			logging.FromContext(ctx).Desugar(),
@@ -163,24 +138,39 @@ func (r *Reconciler) reconcile(ctx context.Context, imc *v1.InMemoryChannel) rec
 			UID,
 			r.eventDispatcher,
 			channel.ResolveChannelFromPath(channel.ParseChannelFromPath),
-			channel.OIDCTokenVerification(r.tokenVerifier, audience(imc)),
 			channel.ReceiverWithContextFunc(wc),
 		)
 		if err != nil {
 			logging.FromContext(ctx).Error("Failed to create a new fanout.EventHandler", err)
 			return err
 		}
-		r.multiChannelEventHandler.SetChannelHandler(config.Path, fanoutHandler)
+		handler = fanoutHandler
 	} else {
 		// Just update the config if necessary.
-		haveSubs := httpsHandler.GetSubscriptions(ctx)
+		haveSubs := handler.GetSubscriptions(ctx)
 
 		// Ignore the closures, we stash the values that we can tell from if the values have actually changed.
 		if diff := cmp.Diff(config.FanoutConfig.Subscriptions, haveSubs, cmpopts.IgnoreFields(kncloudevents.RetryConfig{}, "Backoff", "CheckRetry")); diff != "" {
 			logging.FromContext(ctx).Info("Updating fanout config: ", zap.String("Diff", diff))
-			httpsHandler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
+			handler.SetSubscriptions(ctx, config.FanoutConfig.Subscriptions)
+		}
+		if diff := cmp.Diff(config.FanoutConfig.Buffer, handler.GetBufferConfig(ctx)); diff != "" {
+			logging.FromContext(ctx).Info("Updating fanout buffer: ", zap.String("Diff", diff))
+			handler.SetBufferConfig(ctx, config.FanoutConfig.Buffer)
 		}
 	}
+	r.multiChannelEventHandler.SetChannelHandler(config.HostName, handler)
+	r.multiChannelEventHandler.SetChannelHandler(config.Path, handler)
+	err = r.router.Register(kncloudevents.AddressableRoute{
+		Hosts:     []string{config.HostName},
+		Paths:     []string{config.Path},
+		Handler:   handler,
+		Audiences: auth.NewAudienceMatcher(audience(imc)),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to register the route of the channel", zap.Error(err))
+		return err
+	}
 
 	handleSubscribers(imc.Spec.Subscribers, func(addressable duckv1.Addressable) {
 		kncloudevents.AddOrUpdateAddressableHandler(r.clientConfig, addressable)
@@ -253,10 +243,28 @@ func newConfigForInMemoryChannel(ctx context.Context, imc *v1.InMemoryChannel) (
 		FanoutConfig: fanout.Config{
 			AsyncHandler:  false,
 			Subscriptions: subs,
+			Buffer:        bufferConfig(imc),
 		},
 	}, nil
 }
 
+// bufferConfig returns the buffer of the channel set by its annotations, nil if the channel isn't
+// buffered.
+func bufferConfig(imc *v1.InMemoryChannel) *fanout.BufferConfig {
+	size, err := strconv.Atoi(imc.Annotations[messaging.BufferSizeAnnotation])
+	if err != nil || size < 1 {
+		return nil
+	}
+	policy := fanout.OverflowPolicy(imc.Annotations[messaging.BufferOverflowPolicyAnnotation])
+	if policy == "" {
+		policy = fanout.OverflowBlock
+	}
+	return &fanout.BufferConfig{
+		Size:           size,
+		OverflowPolicy: policy,
+	}
+}
+
 func (r *Reconciler) deleteFunc(obj interface{}) {
 	if obj == nil {
 		return
@@ -271,9 +279,14 @@ func (r *Reconciler) deleteFunc(obj interface{}) {
 	}
 	if imc.Status.Address != nil && imc.Status.Address.URL != nil {
 		if hostName := imc.Status.Address.URL.Host; hostName != "" {
+			r.router.Unregister(hostName)
 			r.multiChannelEventHandler.DeleteChannelHandler(hostName)
 		}
 	}
+	// The handler is closed, dispatching its buffered events, once deleted for both keys.
+	path := fmt.Sprintf("%s/%s", imc.Namespace, imc.Name)
+	r.router.Unregister(path)
+	r.multiChannelEventHandler.DeleteChannelHandler(path)
 
 	handleSubscribers(imc.Spec.Subscribers, kncloudevents.DeleteAddressableHandler)
 }
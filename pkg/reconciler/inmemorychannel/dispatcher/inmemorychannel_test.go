@@ -19,6 +19,7 @@ package dispatcher
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -315,6 +316,7 @@ func TestAllCases(t *testing.T) {
 		r := &Reconciler{
 			multiChannelEventHandler: newFakeMultiChannelHandler(),
 			messagingClientSet:       fakeeventingclient.Get(ctx).MessagingV1(),
+			router:                   kncloudevents.NewAddressableRouter(&auth.OIDCTokenVerifier{}, nil),
 		}
 		return inmemorychannel.NewReconciler(ctx, logger,
 			fakeeventingclient.Get(ctx), listers.GetInMemoryChannelLister(),
@@ -336,6 +338,7 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 		imc        *v1.InMemoryChannel
 		subs       []fanout.Subscription
 		wantSubs   []fanout.Subscription
+		wantBuffer *fanout.BufferConfig
 		wantResult reconciler.Event
 	}{
 		"with no existing subscribers, 2 added": {
@@ -510,6 +513,31 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 					RetryConfig: &kncloudevents.RetryConfig{RetryMax: 3, BackoffPolicy: &linear}},
 			},
 		},
+		"with buffer": {
+			imc: NewInMemoryChannel(imcName, testNS,
+				WithInitInMemoryChannelConditions,
+				WithInMemoryChannelDeploymentReady(),
+				WithInMemoryChannelServiceReady(),
+				WithInMemoryChannelEndpointsReady(),
+				WithInMemoryChannelChannelServiceReady(),
+				WithInMemoryChannelSubscribers([]eventingduckv1.SubscriberSpec{subscriber1}),
+				WithInMemoryChannelAddress(channelServiceAddress),
+				WithInMemoryChannelDLSUnknown(),
+				WithInMemoryChannelEventPoliciesReady(),
+				WithInMemoryChannelBuffer("10", "drop-oldest")),
+			subs: []fanout.Subscription{*subscription1},
+			wantSubs: []fanout.Subscription{
+				{
+					Namespace: testNS,
+					Subscriber: duckv1.Addressable{
+						URL: apis.HTTP("call1"),
+					},
+					Reply: &duckv1.Addressable{
+						URL: apis.HTTP("sink2"),
+					}},
+			},
+			wantBuffer: &fanout.BufferConfig{Size: 10, OverflowPolicy: fanout.OverflowDropOldest},
+		},
 	}
 	for n, tc := range testCases {
 		ctx, _ := SetupFakeContext(t, SetUpInformerSelector)
@@ -533,10 +561,12 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 					fanoutHandler.SetSubscriptions(context.TODO(), tc.subs)
 					handler.SetChannelHandler(channelServiceAddress.URL.String(), fanoutHandler)
 				}
+				router := kncloudevents.NewAddressableRouter(auth.NewOIDCTokenVerifier(ctx), nil)
 				r := &Reconciler{
 					multiChannelEventHandler: handler,
 					messagingClientSet:       fakeEventingClient.MessagingV1(),
 					featureStore:             feature.NewStore(logtesting.TestLogger(t)),
+					router:                   router,
 				}
 				e := r.ReconcileKind(ctx, tc.imc)
 				if e != tc.wantResult {
@@ -549,6 +579,21 @@ func TestReconciler_ReconcileKind(t *testing.T) {
 				if diff := cmp.Diff(tc.wantSubs, channelHandler.GetSubscriptions(context.TODO()), cmpopts.IgnoreFields(kncloudevents.RetryConfig{}, "Backoff", "CheckRetry"), cmpopts.IgnoreFields(fanout.Subscription{}, "UID")); diff != "" {
 					t.Error("unexpected subs (+want/-got)", diff)
 				}
+				if diff := cmp.Diff(tc.wantBuffer, channelHandler.GetBufferConfig(context.TODO())); diff != "" {
+					t.Error("unexpected buffer (+want/-got)", diff)
+				}
+				// The http and https addresses share the handler, and its buffer.
+				if got := handler.GetChannelHandler(testNS + "/" + imcName); got != channelHandler {
+					t.Error("Expected the path of the channel to share the handler of its host")
+				}
+				// The router serves the http and https addresses of the channel.
+				for _, target := range []string{channelServiceAddress.URL.String(), "https://imc-dispatcher.knative-eventing.svc/" + testNS + "/" + imcName} {
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+					if w.Code == http.StatusNotFound {
+						t.Errorf("Expected a route for %s", target)
+					}
+				}
 			})
 		}
 	}
@@ -583,6 +628,7 @@ func TestReconciler_InvalidInputs(t *testing.T) {
 				}
 				r := &Reconciler{
 					multiChannelEventHandler: handler,
+					router:                   kncloudevents.NewAddressableRouter(nil, nil),
 				}
 				r.deleteFunc(tc.imc)
 			})
@@ -614,14 +660,19 @@ func TestReconciler_Deletion(t *testing.T) {
 				handler := newFakeMultiChannelHandler()
 				if fanoutHandler != nil {
 					handler.SetChannelHandler(channelServiceAddress.URL.Host, fanoutHandler)
+					handler.SetChannelHandler(testNS+"/"+imcName, fanoutHandler)
 				}
 				r := &Reconciler{
 					multiChannelEventHandler: handler,
+					router:                   kncloudevents.NewAddressableRouter(nil, nil),
 				}
 				r.deleteFunc(tc.imc)
 				if handler.GetChannelHandler(channelServiceAddress.URL.Host) != nil {
 					t.Error("Got handler")
 				}
+				if handler.GetChannelHandler(testNS+"/"+imcName) != nil {
+					t.Error("Got path handler")
+				}
 			})
 		}
 	}
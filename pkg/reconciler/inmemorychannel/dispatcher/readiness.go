@@ -76,7 +76,7 @@ func (c *DispatcherReadyChecker) IsReady() (bool, error) {
 		}
 	}
 
-	// Each channel creates two handler (http/https).
+	// Each channel sets its handler for two keys (http/https).
 	c.isReady = c.chMsgHandler.CountChannelHandlers() >= 2*len(readyChannels)
 	return c.isReady, nil
 }
@@ -24,6 +24,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	v1 "knative.dev/eventing/pkg/apis/flows/v1"
 	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 )
@@ -56,6 +57,7 @@ func NewFilterSubscription(branchNumber int, p *v1.Parallel) *messagingv1.Subscr
 				Kind:       p.Spec.ChannelTemplate.Kind,
 				Name:       ParallelChannelName(p.Name),
 			},
+			Delivery: p.Spec.Delivery.DeepCopy(),
 		},
 	}
 	// if filter is not defined, use the branch-channel as the subscriber.
@@ -105,7 +107,7 @@ func NewSubscription(branchNumber int, p *v1.Parallel) *messagingv1.Subscription
 				Name:       ParallelBranchChannelName(p.Name, branchNumber),
 			},
 			Subscriber: p.Spec.Branches[branchNumber].Subscriber.DeepCopy(),
-			Delivery:   p.Spec.Branches[branchNumber].Delivery.DeepCopy(),
+			Delivery:   branchDelivery(branchNumber, p),
 		},
 	}
 
@@ -116,3 +118,12 @@ func NewSubscription(branchNumber int, p *v1.Parallel) *messagingv1.Subscription
 	}
 	return r
 }
+
+// branchDelivery returns the delivery of the branch, or of the Parallel if the
+// branch doesn't specify one.
+func branchDelivery(branchNumber int, p *v1.Parallel) *eventingduckv1.DeliverySpec {
+	if p.Spec.Branches[branchNumber].Delivery != nil {
+		return p.Spec.Branches[branchNumber].Delivery.DeepCopy()
+	}
+	return p.Spec.Delivery.DeepCopy()
+}
@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	flowsv1 "knative.dev/eventing/pkg/apis/flows/v1"
 	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	"knative.dev/pkg/apis"
@@ -175,3 +176,63 @@ func TestNewFilterSubscription(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriptionDelivery(t *testing.T) {
+	flowDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(3)}
+	branchDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(5)}
+
+	tests := []struct {
+		name               string
+		flowDelivery       *eventingduckv1.DeliverySpec
+		branchDelivery     *eventingduckv1.DeliverySpec
+		wantFilterDelivery *eventingduckv1.DeliverySpec
+		wantDelivery       *eventingduckv1.DeliverySpec
+	}{{
+		name: "no delivery",
+	}, {
+		name:               "branch inherits the parallel delivery",
+		flowDelivery:       flowDelivery,
+		wantFilterDelivery: flowDelivery,
+		wantDelivery:       flowDelivery,
+	}, {
+		name:               "branch overrides the parallel delivery",
+		flowDelivery:       flowDelivery,
+		branchDelivery:     branchDelivery,
+		wantFilterDelivery: flowDelivery,
+		wantDelivery:       branchDelivery,
+	}, {
+		name:           "branch delivery only",
+		branchDelivery: branchDelivery,
+		wantDelivery:   branchDelivery,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &flowsv1.Parallel{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-parallel",
+					Namespace: "test-ns",
+				},
+				Spec: flowsv1.ParallelSpec{
+					ChannelTemplate: &messagingv1.ChannelTemplateSpec{
+						TypeMeta: metav1.TypeMeta{
+							APIVersion: "messaging.knative.dev/v1",
+							Kind:       "InMemoryChannel",
+						},
+					},
+					Branches: []flowsv1.ParallelBranch{{
+						Subscriber: duckv1.Destination{URI: apis.HTTP("example.com/subscriber")},
+						Filter:     &duckv1.Destination{URI: apis.HTTP("example.com/filter")},
+						Delivery:   tt.branchDelivery,
+					}},
+					Delivery: tt.flowDelivery,
+				},
+			}
+			if diff := cmp.Diff(tt.wantFilterDelivery, NewFilterSubscription(0, p).Spec.Delivery); diff != "" {
+				t.Error("unexpected filter subscription delivery (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(tt.wantDelivery, NewSubscription(0, p).Spec.Delivery); diff != "" {
+				t.Error("unexpected subscription delivery (-want, +got):", diff)
+			}
+		})
+	}
+}
@@ -85,6 +85,7 @@ func NewController(
 		leConfig:             leConfig,
 		configAcc:            reconcilersource.WatchConfigurations(ctx, component, cmw),
 		serviceAccountLister: oidcServiceaccountInformer.Lister(),
+		heartbeats:           reconcilersource.GetHeartbeats(ctx),
 	}
 
 	impl := pingsourcereconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
@@ -101,6 +102,13 @@ func NewController(
 
 	pingSourceInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
+	if r.heartbeats != nil {
+		// All the PingSources are served by the mt adapter.
+		r.heartbeats.OnHealthChanged(mtadapterName, func(string, string) {
+			globalResync(nil)
+		})
+	}
+
 	// Tracker is used to notify us that the pingsource-mt-adapter Deployment has changed so that
 	// we can reconcile PingSources that depend on it
 	r.tracker = impl.Tracker
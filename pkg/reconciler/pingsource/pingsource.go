@@ -58,6 +58,11 @@ const (
 	component     = "pingsource"
 	mtadapterName = "pingsource-mt-adapter"
 	containerName = "dispatcher"
+
+	// mtadapterHeartbeatName is the name the mt adapter sends its heartbeats
+	// with, the default NAME of the adapters as it doesn't set one. The
+	// component of its heartbeats is mtadapterName.
+	mtadapterHeartbeatName = "adapter"
 )
 
 func newWarningSinkNotFound(sink *duckv1.Destination) pkgreconciler.Event {
@@ -80,6 +85,9 @@ type Reconciler struct {
 	leConfig string
 
 	serviceAccountLister v1.ServiceAccountLister
+
+	// heartbeats, when set, are the heartbeats received from the adapters.
+	heartbeats *reconcilersource.Heartbeats
 }
 
 // Check that our Reconciler implements ReconcileKind
@@ -127,6 +135,9 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, source *sourcesv1.PingSo
 		return err
 	}
 	source.Status.PropagateDeploymentAvailability(d)
+	if r.heartbeats != nil {
+		r.heartbeats.PropagateDataPlaneHealth(&source.Status, mtadapterName, system.Namespace(), mtadapterHeartbeatName)
+	}
 
 	// Tell tracker to reconcile this PingSource whenever the deployment changes
 	err = r.tracker.TrackReference(tracker.Reference{
@@ -142,7 +153,7 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, source *sourcesv1.PingSo
 	}
 
 	source.Status.CloudEventAttributes = []duckv1.CloudEventAttributes{{
-		Type:   sourcesv1.PingSourceEventType,
+		Type:   sourcesv1.PingSourceType(source.Spec.TypeSuffix),
 		Source: sourcesv1.PingSourceSource(source.Namespace, source.Name),
 	}}
 
@@ -163,6 +174,9 @@ func (r *Reconciler) reconcileReceiveAdapter(ctx context.Context, source *source
 		NoShutdownAfter: mtping.GetNoShutDownAfterValue(),
 		SinkTimeout:     adapter.GetSinkTimeout(logging.FromContext(ctx)),
 	}
+	if r.heartbeats != nil {
+		args.HeartbeatSink = reconcilersource.HeartbeatSink()
+	}
 	expected := resources.MakeReceiveAdapterEnvVar(args)
 
 	d, err := r.kubeClientSet.AppsV1().Deployments(system.Namespace()).Get(ctx, mtadapterName, metav1.GetOptions{})
@@ -30,6 +30,9 @@ type Args struct {
 	LeConfig        string
 	NoShutdownAfter int
 	SinkTimeout     int
+	// HeartbeatSink is the URI the adapter sends its heartbeats to, none are
+	// sent when empty.
+	HeartbeatSink string
 }
 
 // MakeReceiveAdapterEnvVar generates the environment variables for the pingsources
@@ -60,5 +63,12 @@ func MakeReceiveAdapterEnvVar(args Args) []corev1.EnvVar {
 		Value: strconv.Itoa(args.SinkTimeout),
 	}}
 
+	if args.HeartbeatSink != "" {
+		envs = append(envs, corev1.EnvVar{
+			Name:  adapter.EnvConfigHeartbeatSink,
+			Value: args.HeartbeatSink,
+		})
+	}
+
 	return append(envs, args.ConfigEnvVars...)
 }
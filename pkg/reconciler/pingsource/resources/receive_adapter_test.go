@@ -75,3 +75,19 @@ func TestMakePingAdapter(t *testing.T) {
 		t.Error("unexpected condition (-want, +got) =", diff)
 	}
 }
+
+func TestMakePingAdapterWithHeartbeatSink(t *testing.T) {
+	args := Args{
+		HeartbeatSink: "http://eventing-controller.knative-eventing.svc.cluster.local/heartbeats",
+	}
+
+	got := MakeReceiveAdapterEnvVar(args)
+
+	want := corev1.EnvVar{
+		Name:  adapter.EnvConfigHeartbeatSink,
+		Value: args.HeartbeatSink,
+	}
+	if diff := cmp.Diff(want, got[len(got)-1]); diff != "" {
+		t.Error("unexpected heartbeat sink (-want, +got) =", diff)
+	}
+}
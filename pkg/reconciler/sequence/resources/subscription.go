@@ -22,6 +22,7 @@ import (
 	"knative.dev/pkg/kmeta"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	v1 "knative.dev/eventing/pkg/apis/flows/v1"
 	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -57,7 +58,7 @@ func NewSubscription(stepNumber int, s *v1.Sequence) *messagingv1.Subscription {
 				Audience: s.Spec.Steps[stepNumber].Destination.Audience,
 				CACerts:  s.Spec.Steps[stepNumber].Destination.CACerts,
 			},
-			Delivery: s.Spec.Steps[stepNumber].Delivery,
+			Delivery: stepDelivery(stepNumber, s),
 		},
 	}
 	// If it's not the last step, use the next channel as the reply to, if it's the very
@@ -81,3 +82,12 @@ func NewSubscription(stepNumber int, s *v1.Sequence) *messagingv1.Subscription {
 	}
 	return r
 }
+
+// stepDelivery returns the delivery of the step, or of the Sequence if the
+// step doesn't specify one.
+func stepDelivery(stepNumber int, s *v1.Sequence) *eventingduckv1.DeliverySpec {
+	if s.Spec.Steps[stepNumber].Delivery != nil {
+		return s.Spec.Steps[stepNumber].Delivery.DeepCopy()
+	}
+	return s.Spec.Delivery.DeepCopy()
+}
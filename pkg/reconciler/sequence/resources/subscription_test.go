@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	flowsv1 "knative.dev/eventing/pkg/apis/flows/v1"
+	messagingv1 "knative.dev/eventing/pkg/apis/messaging/v1"
+)
+
+func TestSubscriptionDelivery(t *testing.T) {
+	flowDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(3)}
+	stepDelivery := &eventingduckv1.DeliverySpec{Retry: pointer.Int32(5)}
+
+	s := &flowsv1.Sequence{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-sequence",
+			Namespace: "test-ns",
+		},
+		Spec: flowsv1.SequenceSpec{
+			ChannelTemplate: &messagingv1.ChannelTemplateSpec{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "messaging.knative.dev/v1",
+					Kind:       "InMemoryChannel",
+				},
+			},
+			Steps: []flowsv1.SequenceStep{{
+				Destination: duckv1.Destination{URI: apis.HTTP("example.com/step-0")},
+			}, {
+				Destination: duckv1.Destination{URI: apis.HTTP("example.com/step-1")},
+				Delivery:    stepDelivery,
+			}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		flowDelivery *eventingduckv1.DeliverySpec
+		wantDelivery []*eventingduckv1.DeliverySpec
+	}{{
+		name:         "no sequence delivery",
+		wantDelivery: []*eventingduckv1.DeliverySpec{nil, stepDelivery},
+	}, {
+		name:         "steps inherit the sequence delivery unless overridden",
+		flowDelivery: flowDelivery,
+		wantDelivery: []*eventingduckv1.DeliverySpec{flowDelivery, stepDelivery},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := s.DeepCopy()
+			s.Spec.Delivery = tt.flowDelivery
+			for i, want := range tt.wantDelivery {
+				if diff := cmp.Diff(want, NewSubscription(i, s).Spec.Delivery); diff != "" {
+					t.Errorf("unexpected delivery of step %d (-want, +got): %s", i, diff)
+				}
+			}
+		})
+	}
+}
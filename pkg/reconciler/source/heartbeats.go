@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"knative.dev/pkg/network"
+	"knative.dev/pkg/system"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+const (
+	// HeartbeatsPath is the path of the controller endpoint receiving the
+	// heartbeats of the source adapters.
+	HeartbeatsPath = "/heartbeats"
+
+	// heartbeatsServiceName is the name of the Service of the controller.
+	heartbeatsServiceName = "eventing-controller"
+
+	// heartbeatStaleAfter is the age after which the latest heartbeat of an
+	// adapter no longer tells the health of its data plane, three times the
+	// default heartbeat interval of the adapters.
+	heartbeatStaleAfter = 90 * time.Second
+)
+
+// HeartbeatSink returns the URI the source adapters send their heartbeats to,
+// the K_HEARTBEAT_SINK of their environment.
+func HeartbeatSink() string {
+	return "http://" + network.GetServiceHostname(heartbeatsServiceName, system.Namespace()) + HeartbeatsPath
+}
+
+// DataPlaneHealthStatus is implemented by the status of the sources reporting
+// the health of their data plane.
+type DataPlaneHealthStatus interface {
+	MarkDataPlaneHealthy()
+	MarkDataPlaneUnhealthy(reason, messageFormat string, messageA ...interface{})
+	MarkDataPlaneHealthUnknown(reason, messageFormat string, messageA ...interface{})
+}
+
+type heartbeatKey struct {
+	component string
+	namespace string
+	name      string
+}
+
+type receivedHeartbeat struct {
+	data     adapter.HeartbeatData
+	received time.Time
+}
+
+// Heartbeats keeps the latest heartbeats sent by the source adapters to the
+// controller. The heartbeats are received by the replica the Service picks, so
+// the data plane health is only reported reliably by a single replica.
+type Heartbeats struct {
+	mu     sync.RWMutex
+	latest map[heartbeatKey]receivedHeartbeat
+	// changed are called with the namespace and name of the adapters of a
+	// component whose health changed, e.g. to enqueue their sources.
+	changed map[string][]func(namespace, name string)
+
+	now func() time.Time
+}
+
+// NewHeartbeats creates a Heartbeats with no received heartbeats.
+func NewHeartbeats() *Heartbeats {
+	return &Heartbeats{
+		latest:  make(map[heartbeatKey]receivedHeartbeat),
+		changed: make(map[string][]func(namespace, name string)),
+		now:     time.Now,
+	}
+}
+
+// OnHealthChanged calls f with the namespace and name of the adapters of the
+// component whose data plane health changed.
+func (h *Heartbeats) OnHealthChanged(component string, f func(namespace, name string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changed[component] = append(h.changed[component], f)
+}
+
+// Latest returns the latest heartbeat of the adapter, if it was received
+// recently enough to tell the health of its data plane.
+func (h *Heartbeats) Latest(component, namespace, name string) (adapter.HeartbeatData, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	hb, ok := h.latest[heartbeatKey{component: component, namespace: namespace, name: name}]
+	if !ok || h.now().Sub(hb.received) > heartbeatStaleAfter {
+		return adapter.HeartbeatData{}, false
+	}
+	return hb.data, true
+}
+
+// PropagateDataPlaneHealth marks the data plane health of status from the
+// latest heartbeat of the adapter.
+func (h *Heartbeats) PropagateDataPlaneHealth(status DataPlaneHealthStatus, component, namespace, name string) {
+	hb, ok := h.Latest(component, namespace, name)
+	switch {
+	case !ok:
+		status.MarkDataPlaneHealthUnknown("NoHeartbeat", "No recent heartbeat was received from the adapter")
+	case !hb.Healthy():
+		status.MarkDataPlaneUnhealthy("EventsFailing", "%d of the %d events sent since the previous heartbeat failed", hb.Failed, hb.Sent+hb.Failed)
+	default:
+		status.MarkDataPlaneHealthy()
+	}
+}
+
+// ServeHTTP receives the heartbeat events of the adapters.
+func (h *Heartbeats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	event, err := cehttp.NewEventFromHTTPRequest(r)
+	if err != nil || event.Type() != adapter.HeartbeatEventType {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var data adapter.HeartbeatData
+	if err := event.DataAs(&data); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.record(data)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Heartbeats) record(data adapter.HeartbeatData) {
+	key := heartbeatKey{component: data.Component, namespace: data.Namespace, name: data.Name}
+
+	h.mu.Lock()
+	previous, ok := h.latest[key]
+	changed := !ok || h.now().Sub(previous.received) > heartbeatStaleAfter || previous.data.Healthy() != data.Healthy()
+	h.latest[key] = receivedHeartbeat{data: data, received: h.now()}
+	callbacks := h.changed[data.Component]
+	h.mu.Unlock()
+
+	if changed {
+		for _, f := range callbacks {
+			f(data.Namespace, data.Name)
+		}
+	}
+}
+
+// Run forgets the heartbeats which became stale every interval, notifying
+// that the health of their adapters changed, until the context is done.
+func (h *Heartbeats) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.expire()
+		}
+	}
+}
+
+func (h *Heartbeats) expire() {
+	var stale []heartbeatKey
+	h.mu.Lock()
+	for key, hb := range h.latest {
+		if h.now().Sub(hb.received) > heartbeatStaleAfter {
+			stale = append(stale, key)
+			delete(h.latest, key)
+		}
+	}
+	changed := make(map[string][]func(namespace, name string), len(h.changed))
+	for component, callbacks := range h.changed {
+		changed[component] = callbacks
+	}
+	h.mu.Unlock()
+
+	for _, key := range stale {
+		for _, f := range changed[key.component] {
+			f(key.namespace, key.name)
+		}
+	}
+}
+
+type heartbeatsKey struct{}
+
+// WithHeartbeats returns a copy of the context holding the heartbeats.
+func WithHeartbeats(ctx context.Context, h *Heartbeats) context.Context {
+	return context.WithValue(ctx, heartbeatsKey{}, h)
+}
+
+// GetHeartbeats returns the heartbeats held by the context, nil when the
+// controller doesn't receive heartbeats.
+func GetHeartbeats(ctx context.Context) *Heartbeats {
+	h, _ := ctx.Value(heartbeatsKey{}).(*Heartbeats)
+	return h
+}
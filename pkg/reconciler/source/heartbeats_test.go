@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"knative.dev/eventing/pkg/adapter/v2"
+	sourcesv1 "knative.dev/eventing/pkg/apis/sources/v1"
+)
+
+func TestHeartbeats(t *testing.T) {
+	now := time.Now()
+	h := NewHeartbeats()
+	h.now = func() time.Time { return now }
+
+	var changed []string
+	h.OnHealthChanged("ping", func(namespace, name string) {
+		changed = append(changed, namespace+"/"+name)
+	})
+
+	status := &sourcesv1.PingSourceStatus{}
+	assertHealth := func(want string) {
+		t.Helper()
+		h.PropagateDataPlaneHealth(status, "ping", "ns", "adapter")
+		if got := status.GetCondition(sourcesv1.PingSourceConditionDataPlaneHealthy); got == nil || string(got.Status) != want {
+			t.Errorf("Expected DataPlaneHealthy %s, got %+v", want, got)
+		}
+	}
+
+	assertHealth("Unknown")
+
+	if code := sendHeartbeat(h, adapter.HeartbeatData{Component: "ping", Namespace: "ns", Name: "adapter", Sent: 1, Failed: 9}); code != http.StatusAccepted {
+		t.Fatalf("Expected status code %d, got %d", http.StatusAccepted, code)
+	}
+	assertHealth("False")
+
+	sendHeartbeat(h, adapter.HeartbeatData{Component: "ping", Namespace: "ns", Name: "adapter", Sent: 9, Failed: 1})
+	assertHealth("True")
+	// The health didn't change.
+	sendHeartbeat(h, adapter.HeartbeatData{Component: "ping", Namespace: "ns", Name: "adapter", Sent: 10})
+
+	now = now.Add(2 * heartbeatStaleAfter)
+	assertHealth("Unknown")
+	h.expire()
+
+	if want := []string{"ns/adapter", "ns/adapter", "ns/adapter"}; len(changed) != len(want) {
+		t.Errorf("Expected the health to change %d times, got %v", len(want), changed)
+	}
+}
+
+func TestHeartbeatsBadRequest(t *testing.T) {
+	h := NewHeartbeats()
+
+	req := httptest.NewRequest(http.MethodPost, HeartbeatsPath, bytes.NewBufferString("{}"))
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, resp.Code)
+	}
+}
+
+func sendHeartbeat(h *Heartbeats, data adapter.HeartbeatData) int {
+	b, _ := json.Marshal(data)
+	req := httptest.NewRequest(http.MethodPost, HeartbeatsPath, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Id", "1")
+	req.Header.Set("Ce-Source", "/apis/v1/namespaces/ns/adapters/adapter")
+	req.Header.Set("Ce-Type", adapter.HeartbeatEventType)
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+var (
+	_ DataPlaneHealthStatus = (*sourcesv1.PingSourceStatus)(nil)
+	_ DataPlaneHealthStatus = (*sourcesv1.ApiServerSourceStatus)(nil)
+)
@@ -43,6 +43,7 @@ import (
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	eventingduckv1 "knative.dev/eventing/pkg/apis/duck/v1"
 	"knative.dev/eventing/pkg/apis/feature"
+	"knative.dev/eventing/pkg/apis/messaging"
 	v1 "knative.dev/eventing/pkg/apis/messaging/v1"
 	"knative.dev/eventing/pkg/auth"
 	subscriptionreconciler "knative.dev/eventing/pkg/client/injection/reconciler/messaging/v1/subscription"
@@ -534,6 +535,7 @@ func (r *Reconciler) updateChannelAddSubscriptionSpec(channel *eventingduckv1.Ch
 			channel.Spec.Subscribers[i].ReplyAudience = sub.Status.PhysicalSubscription.ReplyAudience
 			channel.Spec.Subscribers[i].Delivery = deliverySpec(sub, channel)
 			channel.Spec.Subscribers[i].Auth = sub.Status.Auth
+			channel.Spec.Subscribers[i].Ordered = isOrdered(sub)
 			return
 		}
 	}
@@ -550,12 +552,18 @@ func (r *Reconciler) updateChannelAddSubscriptionSpec(channel *eventingduckv1.Ch
 		ReplyAudience:      sub.Status.PhysicalSubscription.ReplyAudience,
 		Delivery:           deliverySpec(sub, channel),
 		Auth:               sub.Status.Auth,
+		Ordered:            isOrdered(sub),
 	}
 
 	// Must not have been found. Add it.
 	channel.Spec.Subscribers = append(channel.Spec.Subscribers, toAdd)
 }
 
+// isOrdered returns whether the subscription requests ordered delivery.
+func isOrdered(sub *v1.Subscription) bool {
+	return sub.GetAnnotations()[messaging.DeliveryOrderAnnotation] == messaging.DeliveryOrderOrdered
+}
+
 func deliverySpec(sub *v1.Subscription, channel *eventingduckv1.Channelable) (delivery *eventingduckv1.DeliverySpec) {
 	if sub.Spec.Delivery == nil && channel.Spec.Delivery != nil {
 		// Default to the channel spec
@@ -569,7 +577,10 @@ func deliverySpec(sub *v1.Subscription, channel *eventingduckv1.Channelable) (de
 			channel.Spec.Delivery.Retry != nil ||
 			channel.Spec.Delivery.BackoffPolicy != nil ||
 			channel.Spec.Delivery.Timeout != nil ||
-			channel.Spec.Delivery.RetryAfterMax != nil {
+			channel.Spec.Delivery.RetryAfterMax != nil ||
+			channel.Spec.Delivery.PreferReply != nil ||
+			channel.Spec.Delivery.RetryPermanentErrors != nil ||
+			channel.Spec.Delivery.FailFastOnUnknownHost != nil {
 			if delivery == nil {
 				delivery = &eventingduckv1.DeliverySpec{}
 			}
@@ -578,6 +589,9 @@ func deliverySpec(sub *v1.Subscription, channel *eventingduckv1.Channelable) (de
 			delivery.BackoffDelay = channel.Spec.Delivery.BackoffDelay
 			delivery.Timeout = channel.Spec.Delivery.Timeout
 			delivery.RetryAfterMax = channel.Spec.Delivery.RetryAfterMax
+			delivery.PreferReply = channel.Spec.Delivery.PreferReply
+			delivery.RetryPermanentErrors = channel.Spec.Delivery.RetryPermanentErrors
+			delivery.FailFastOnUnknownHost = channel.Spec.Delivery.FailFastOnUnknownHost
 		}
 		return
 	}
@@ -595,7 +609,10 @@ func deliverySpec(sub *v1.Subscription, channel *eventingduckv1.Channelable) (de
 			sub.Spec.Delivery.Retry != nil ||
 			sub.Spec.Delivery.BackoffPolicy != nil ||
 			sub.Spec.Delivery.Timeout != nil ||
-			sub.Spec.Delivery.RetryAfterMax != nil) {
+			sub.Spec.Delivery.RetryAfterMax != nil ||
+			sub.Spec.Delivery.PreferReply != nil ||
+			sub.Spec.Delivery.RetryPermanentErrors != nil ||
+			sub.Spec.Delivery.FailFastOnUnknownHost != nil) {
 		if delivery == nil {
 			delivery = &eventingduckv1.DeliverySpec{}
 		}
@@ -604,6 +621,9 @@ func deliverySpec(sub *v1.Subscription, channel *eventingduckv1.Channelable) (de
 		delivery.BackoffDelay = sub.Spec.Delivery.BackoffDelay
 		delivery.Timeout = sub.Spec.Delivery.Timeout
 		delivery.RetryAfterMax = sub.Spec.Delivery.RetryAfterMax
+		delivery.PreferReply = sub.Spec.Delivery.PreferReply
+		delivery.RetryPermanentErrors = sub.Spec.Delivery.RetryPermanentErrors
+		delivery.FailFastOnUnknownHost = sub.Spec.Delivery.FailFastOnUnknownHost
 	}
 	return
 }
@@ -249,6 +249,16 @@ func WithInMemoryScopeAnnotation(value string) InMemoryChannelOption {
 	}
 }
 
+func WithInMemoryChannelBuffer(size, overflowPolicy string) InMemoryChannelOption {
+	return func(imc *v1.InMemoryChannel) {
+		if imc.Annotations == nil {
+			imc.Annotations = make(map[string]string)
+		}
+		imc.Annotations[messaging.BufferSizeAnnotation] = size
+		imc.Annotations[messaging.BufferOverflowPolicyAnnotation] = overflowPolicy
+	}
+}
+
 func WithInMemoryChannelStatusDLS(dls *duckv1.Addressable) InMemoryChannelOption {
 	return func(imc *v1.InMemoryChannel) {
 		if dls == nil {
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replay redelivers the events stored by a dead letter sink to the
+// destinations they couldn't be delivered to, for instance once the outage
+// of a subscriber is over.
+//
+// The dead letter sink exposes the events it stored through the list/fetch
+// contract implemented by Client:
+//
+//   - GET <url>?limit=<n>&continue=<token> lists the stored events. It
+//     responds with a JSON List, whose Continue token, if not empty, is sent
+//     back to get the next page.
+//   - GET <url>/<id> fetches the stored event with the given id of the list,
+//     as a CloudEvent in the binary or the structured content mode.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"knative.dev/pkg/apis"
+)
+
+// Entry is an event stored by the dead letter sink.
+type Entry struct {
+	// ID identifies the entry in the dead letter sink. It isn't the id of
+	// the event, as the same event may be dead lettered several times.
+	ID string `json:"id"`
+}
+
+// List is a page of the events stored by the dead letter sink.
+type List struct {
+	Items []Entry `json:"items"`
+	// Continue is the token to get the next page, empty on the last page.
+	Continue string `json:"continue,omitempty"`
+}
+
+// Client reads the events stored by a dead letter sink implementing the
+// list/fetch contract.
+type Client struct {
+	url        *apis.URL
+	httpClient *http.Client
+}
+
+// NewClient returns a Client reading the events of the dead letter sink at
+// the given URL. The http.DefaultClient is used if httpClient is nil.
+func NewClient(url *apis.URL, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+// List returns the page of events starting at the continue token, the first
+// one if the token is empty. The dead letter sink picks the size of the page
+// if limit isn't positive.
+func (c *Client) List(ctx context.Context, continueToken string, limit int) (*List, error) {
+	u := c.url.URL()
+	query := u.Query()
+	if continueToken != "" {
+		query.Set("continue", continueToken)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	u.RawQuery = query.Encode()
+
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	list := &List{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, fmt.Errorf("failed to decode the list of events: %w", err)
+	}
+	return list, nil
+}
+
+// Fetch returns the event of the entry with the given id.
+func (c *Client) Fetch(ctx context.Context, id string) (*event.Event, error) {
+	u := c.url.URL().JoinPath(id)
+
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch event %q: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	e, err := binding.ToEvent(ctx, cehttp.NewMessageFromHttpResponse(resp))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event %q: %w", id, err)
+	}
+	return e, nil
+}
+
+func (c *Client) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing/pkg/kncloudevents"
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+// knativeErrorExtensionPrefix is the prefix of the extensions added to the
+// events sent to the dead letter sink, see attributes.KnativeErrorTransformers.
+const knativeErrorExtensionPrefix = "knativeerror"
+
+// Options configures a replay.
+type Options struct {
+	// PageSize is the number of events listed at once, the dead letter sink
+	// picks it if it isn't positive.
+	PageSize int
+	// Limit is the maximum number of events replayed, all the events are
+	// replayed if it isn't positive.
+	Limit int
+	// DryRun only logs the events which would be replayed.
+	DryRun bool
+	// SendOptions are applied to the sending of each event.
+	SendOptions []kncloudevents.SendOption
+}
+
+// Result counts the events of a replay.
+type Result struct {
+	// Replayed is the number of events delivered to their destination, or
+	// which would have been with DryRun.
+	Replayed int
+	// Failed is the number of events which couldn't be fetched or delivered.
+	Failed int
+	// Skipped is the number of events without a destination to replay them
+	// to.
+	Skipped int
+}
+
+// Replayer redelivers the events of a dead letter sink.
+type Replayer struct {
+	client     *Client
+	dispatcher *kncloudevents.Dispatcher
+	logger     *zap.Logger
+}
+
+// NewReplayer returns a Replayer redelivering the events read by the client
+// with the dispatcher.
+func NewReplayer(client *Client, dispatcher *kncloudevents.Dispatcher, logger *zap.Logger) *Replayer {
+	return &Replayer{
+		client:     client,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// Replay sends each event of the dead letter sink to the destination it
+// couldn't be delivered to. The events failing to be fetched or sent are
+// counted in the Result and don't stop the replay, which only fails if the
+// events can't be listed.
+func (r *Replayer) Replay(ctx context.Context, opts Options) (Result, error) {
+	var result Result
+	continueToken := ""
+	for {
+		list, err := r.client.List(ctx, continueToken, opts.PageSize)
+		if err != nil {
+			return result, err
+		}
+
+		for _, entry := range list.Items {
+			if opts.Limit > 0 && result.Replayed+result.Failed+result.Skipped >= opts.Limit {
+				return result, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			r.replay(ctx, entry, opts, &result)
+		}
+
+		if list.Continue == "" {
+			return result, nil
+		}
+		continueToken = list.Continue
+	}
+}
+
+func (r *Replayer) replay(ctx context.Context, entry Entry, opts Options, result *Result) {
+	logger := r.logger.With(zap.String("entry", entry.ID))
+
+	deadLettered, err := r.client.Fetch(ctx, entry.ID)
+	if err != nil {
+		logger.Warn("Failed to fetch dead lettered event", zap.Error(err))
+		result.Failed++
+		return
+	}
+
+	original, destination, err := Unwrap(deadLettered)
+	if err != nil {
+		logger.Info("Skipping dead lettered event", zap.String("id", deadLettered.ID()), zap.Error(err))
+		result.Skipped++
+		return
+	}
+
+	logger = logger.With(zap.String("id", original.ID()), zap.Stringer("destination", destination))
+	if opts.DryRun {
+		logger.Info("Would replay dead lettered event")
+		result.Replayed++
+		return
+	}
+
+	if _, err := r.dispatcher.SendMessage(ctx, binding.ToMessage(original), duckv1.Addressable{URL: destination}, opts.SendOptions...); err != nil {
+		logger.Warn("Failed to replay dead lettered event", zap.Error(err))
+		result.Failed++
+		return
+	}
+	logger.Debug("Replayed dead lettered event")
+	result.Replayed++
+}
+
+// Unwrap returns the event which couldn't be delivered and its destination,
+// from the event sent to the dead letter sink. It accepts the events carrying
+// the knativeerror extensions, which are removed from the returned event, and
+// the events wrapped by kncloudevents.WithDeadLetterEnvelope.
+func Unwrap(deadLettered *event.Event) (*event.Event, *apis.URL, error) {
+	if deadLettered.Type() == kncloudevents.DeadLetterEnvelopeEventType {
+		return unwrapEnvelope(deadLettered)
+	}
+
+	dest, ok := deadLettered.Extensions()[attributes.KnativeErrorDestExtensionKey]
+	if !ok {
+		return nil, nil, fmt.Errorf("missing %s extension", attributes.KnativeErrorDestExtensionKey)
+	}
+	formatted, err := types.Format(dest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s extension: %w", attributes.KnativeErrorDestExtensionKey, err)
+	}
+	destination, err := parseDestination(formatted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	original := deadLettered.Clone()
+	for name := range original.Extensions() {
+		if strings.HasPrefix(name, knativeErrorExtensionPrefix) {
+			original.SetExtension(name, nil)
+		}
+	}
+	return &original, destination, nil
+}
+
+func unwrapEnvelope(deadLettered *event.Event) (*event.Event, *apis.URL, error) {
+	envelope := &kncloudevents.DeadLetterEnvelope{}
+	if err := deadLettered.DataAs(envelope); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode dead letter envelope: %w", err)
+	}
+	if envelope.Event == nil {
+		return nil, nil, errors.New("missing event in dead letter envelope")
+	}
+	destination, err := parseDestination(envelope.Failure.Destination)
+	if err != nil {
+		return nil, nil, err
+	}
+	return envelope.Event, destination, nil
+}
+
+func parseDestination(dest string) (*apis.URL, error) {
+	if dest == "" {
+		return nil, errors.New("empty destination")
+	}
+	destination, err := apis.ParseURL(dest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination %q: %w", dest, err)
+	}
+	if !destination.URL().IsAbs() {
+		return nil, fmt.Errorf("destination %q isn't an absolute URL", dest)
+	}
+	return destination, nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/event"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing/pkg/eventingtls"
+	"knative.dev/eventing/pkg/kncloudevents"
+	"knative.dev/eventing/pkg/kncloudevents/attributes"
+)
+
+func TestUnwrap(t *testing.T) {
+	original := makeEvent("original")
+
+	withExtensions := original.Clone()
+	withExtensions.SetExtension(attributes.KnativeErrorDestExtensionKey, "http://subscriber.example.com/path")
+	withExtensions.SetExtension(attributes.KnativeErrorCodeExtensionKey, 500)
+	withExtensions.SetExtension(attributes.KnativeErrorDataExtensionKey, "ZXJyb3I=")
+	withExtensions.SetExtension(attributes.KnativeErrorReasonExtensionKey, string(attributes.KnativeErrorReasonNon2xx))
+
+	envelope := event.New()
+	envelope.SetID("envelope")
+	envelope.SetSource("/source")
+	envelope.SetType(kncloudevents.DeadLetterEnvelopeEventType)
+	if err := envelope.SetData(event.ApplicationJSON, kncloudevents.DeadLetterEnvelope{
+		Event:   &original,
+		Failure: kncloudevents.DeadLetterFailure{Destination: "http://subscriber.example.com/path", ResponseCode: 500},
+	}); err != nil {
+		t.Fatal("SetData =", err)
+	}
+
+	withoutDestination := original.Clone()
+	withoutDestination.SetExtension(attributes.KnativeErrorCodeExtensionKey, 500)
+
+	withRelativeDestination := original.Clone()
+	withRelativeDestination.SetExtension(attributes.KnativeErrorDestExtensionKey, "/path")
+
+	testCases := map[string]struct {
+		deadLettered    event.Event
+		wantDestination string
+		wantErr         bool
+	}{
+		"knativeerror extensions": {
+			deadLettered:    withExtensions,
+			wantDestination: "http://subscriber.example.com/path",
+		},
+		"dead letter envelope": {
+			deadLettered:    envelope,
+			wantDestination: "http://subscriber.example.com/path",
+		},
+		"missing destination": {
+			deadLettered: withoutDestination,
+			wantErr:      true,
+		},
+		"relative destination": {
+			deadLettered: withRelativeDestination,
+			wantErr:      true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			got, destination, err := Unwrap(&tc.deadLettered)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unwrap() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if destination.String() != tc.wantDestination {
+				t.Errorf("Unwrap() destination = %s, want %s", destination, tc.wantDestination)
+			}
+			if diff := cmp.Diff(original.String(), got.String()); diff != "" {
+				t.Error("Unexpected event (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	target := newRecordingServer()
+	defer target.Close()
+
+	stored := make([]event.Event, 0, 5)
+	for i := 0; i < 4; i++ {
+		e := makeEvent(strconv.Itoa(i))
+		e.SetExtension(attributes.KnativeErrorDestExtensionKey, target.URL)
+		e.SetExtension(attributes.KnativeErrorCodeExtensionKey, 503)
+		stored = append(stored, e)
+	}
+	// Events without destination are skipped.
+	stored = append(stored, makeEvent("no-destination"))
+
+	testCases := map[string]struct {
+		opts         Options
+		wantResult   Result
+		wantReceived []string
+	}{
+		"replays all the events": {
+			opts:         Options{PageSize: 2},
+			wantResult:   Result{Replayed: 4, Skipped: 1},
+			wantReceived: []string{"0", "1", "2", "3"},
+		},
+		"limit": {
+			opts:         Options{PageSize: 2, Limit: 3},
+			wantResult:   Result{Replayed: 3},
+			wantReceived: []string{"0", "1", "2"},
+		},
+		"dry run": {
+			opts:       Options{DryRun: true},
+			wantResult: Result{Replayed: 4, Skipped: 1},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			target.reset()
+
+			dls := httptest.NewServer(newDeadLetterSink(t, stored))
+			defer dls.Close()
+
+			dlsURL, err := apis.ParseURL(dls.URL + "/events")
+			if err != nil {
+				t.Fatal("ParseURL =", err)
+			}
+			r := NewReplayer(
+				NewClient(dlsURL, nil),
+				kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), nil),
+				zap.NewNop(),
+			)
+			result, err := r.Replay(context.Background(), tc.opts)
+			if err != nil {
+				t.Fatal("Replay() =", err)
+			}
+			if diff := cmp.Diff(tc.wantResult, result); diff != "" {
+				t.Error("Unexpected result (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(tc.wantReceived, target.ids()); diff != "" {
+				t.Error("Unexpected replayed events (-want, +got):", diff)
+			}
+			for _, e := range target.events() {
+				if _, ok := e.Extensions()[attributes.KnativeErrorCodeExtensionKey]; ok {
+					t.Errorf("Replayed event %s has the %s extension", e.ID(), attributes.KnativeErrorCodeExtensionKey)
+				}
+			}
+		})
+	}
+}
+
+func TestReplayListError(t *testing.T) {
+	dls := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer dls.Close()
+
+	dlsURL, err := apis.ParseURL(dls.URL)
+	if err != nil {
+		t.Fatal("ParseURL =", err)
+	}
+	r := NewReplayer(
+		NewClient(dlsURL, nil),
+		kncloudevents.NewDispatcher(eventingtls.NewDefaultClientConfig(), nil),
+		zap.NewNop(),
+	)
+	if _, err := r.Replay(context.Background(), Options{}); err == nil {
+		t.Error("Replay() succeeded, want error")
+	}
+}
+
+// newDeadLetterSink returns a handler implementing the list/fetch contract
+// for the stored events, at the /events path. The continue token is the
+// index of the first event of the page.
+func newDeadLetterSink(t *testing.T, stored []event.Event) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = len(stored)
+		}
+		end := start + limit
+		if end > len(stored) {
+			end = len(stored)
+		}
+
+		list := List{Items: []Entry{}}
+		for i := start; i < end; i++ {
+			list.Items = append(list.Items, Entry{ID: "entry-" + strconv.Itoa(i)})
+		}
+		if end < len(stored) {
+			list.Continue = strconv.Itoa(end)
+		}
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			t.Error("Encode =", err)
+		}
+	})
+	mux.HandleFunc("/events/", func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/events/entry-"))
+		if err != nil || i < 0 || i >= len(stored) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := cehttp.WriteResponseWriter(r.Context(), binding.ToMessage(&stored[i]), http.StatusOK, w); err != nil {
+			t.Error("WriteResponseWriter =", err)
+		}
+	})
+	return mux
+}
+
+func makeEvent(id string) event.Event {
+	e := event.New()
+	e.SetID(id)
+	e.SetSource("/source")
+	e.SetType("dev.knative.test")
+	_ = e.SetData(event.ApplicationJSON, map[string]string{"id": id})
+	return e
+}
+
+// recordingServer records the events it receives.
+type recordingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []event.Event
+}
+
+func newRecordingServer() *recordingServer {
+	s := &recordingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e, err := cehttp.NewEventFromHTTPRequest(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.received = append(s.received, *e)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return s
+}
+
+func (s *recordingServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received = nil
+}
+
+func (s *recordingServer) events() []event.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]event.Event(nil), s.received...)
+}
+
+func (s *recordingServer) ids() []string {
+	var ids []string
+	for _, e := range s.events() {
+		ids = append(ids, e.ID())
+	}
+	return ids
+}
@@ -19,19 +19,17 @@ package utils
 import (
 	"net/http"
 	"strings"
-
-	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // TODO make propagated headers/prefixes configurable (configmap?)
 
 var (
-	// These MUST be lowercase strings, as they will be compared against lowercase strings.
-	forwardHeaders = sets.NewString(
+	// These are matched case-insensitively against the header names.
+	forwardHeaders = []string{
 		"x-request-id", // tracing
 		"retry-after",
-	)
-	// These MUST be lowercase strings, as they will be compared against lowercase strings.
+	}
+	// These are matched case-insensitively against the header names.
 	// Removing CloudEvents ce- prefixes on purpose as they should be set in the CloudEvent itself as extensions.
 	// Then the SDK will set them as ce- headers when sending them through HTTP. Otherwise, when using replies we would
 	// duplicate ce- headers.
@@ -44,20 +42,32 @@ var (
 // PassThroughHeaders extracts the headers from headers that are in the `forwardHeaders` set
 // or has any of the prefixes in `forwardPrefixes`.
 func PassThroughHeaders(headers http.Header) http.Header {
-	h := http.Header{}
+	return AppendPassThroughHeaders(http.Header{}, headers)
+}
 
+// AppendPassThroughHeaders adds the headers of headers passed through, see
+// PassThroughHeaders, to dst and returns it. The values of the headers aren't
+// copied, and the names are matched without allocating, since this is on the
+// path of every dispatch.
+func AppendPassThroughHeaders(dst, headers http.Header) http.Header {
 	for n, v := range headers {
-		lower := strings.ToLower(n)
-		if forwardHeaders.Has(lower) {
-			h[n] = v
-			continue
+		if isPassThroughHeader(n) {
+			dst[n] = v
+		}
+	}
+	return dst
+}
+
+func isPassThroughHeader(name string) bool {
+	for _, header := range forwardHeaders {
+		if strings.EqualFold(name, header) {
+			return true
 		}
-		for _, prefix := range forwardPrefixes {
-			if strings.HasPrefix(lower, prefix) {
-				h[n] = v
-				break
-			}
+	}
+	for _, prefix := range forwardPrefixes {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return true
 		}
 	}
-	return h
+	return false
 }
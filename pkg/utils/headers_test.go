@@ -60,3 +60,40 @@ func TestPassThroughHeaders(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendPassThroughHeaders(t *testing.T) {
+	dst := http.Header{"Existing": {"value"}}
+	headers := PassThroughHeaders(http.Header{})
+	assert.Empty(t, headers)
+
+	headers = AppendPassThroughHeaders(dst, http.Header{
+		"Retry-After":   {"10"},
+		"Knative":       {"too-short"},
+		"Content-Type":  {"application/json"},
+		"Knative-Extra": {"extra"},
+	})
+	assert.Equal(t, http.Header{
+		"Existing":      {"value"},
+		"Retry-After":   {"10"},
+		"Knative-Extra": {"extra"},
+	}, headers)
+}
+
+func BenchmarkPassThroughHeaders(b *testing.B) {
+	headers := http.Header{
+		"Content-Type":      {"application/json"},
+		"Content-Length":    {"17"},
+		"Ce-Id":             {"1"},
+		"Ce-Type":           {"type"},
+		"Ce-Source":         {"source"},
+		"Ce-Specversion":    {"1.0"},
+		"Traceparent":       {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"X-Request-Id":      {"1234"},
+		"Knative-Namespace": {"ns"},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = PassThroughHeaders(headers)
+	}
+}
@@ -8,8 +8,11 @@ else
   OUTPUT_FILE="${ARTIFACTS:-$(mktemp -d)}/bench-result.txt"
 fi
 
+# BENCHMARK_PACKAGES restricts the run, e.g. to ./pkg/kncloudevents/ for the dispatcher hot path
+BENCHMARK_PACKAGES="${BENCHMARK_PACKAGES:-./...}"
+
 echo "Output will be at $OUTPUT_FILE"
 
 go clean
-go test -bench=. -benchmem -run="^$" -v ./...   >> "$OUTPUT_FILE" || exit
+go test -bench=. -benchmem -run="^$" -v $BENCHMARK_PACKAGES   >> "$OUTPUT_FILE" || exit
 
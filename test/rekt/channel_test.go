@@ -37,7 +37,6 @@ import (
 	"knative.dev/reconciler-test/pkg/tracing"
 
 	"knative.dev/eventing/test/rekt/features/channel"
-	"knative.dev/eventing/test/rekt/features/oidc"
 	ch "knative.dev/eventing/test/rekt/resources/channel"
 	"knative.dev/eventing/test/rekt/resources/channel_impl"
 	"knative.dev/eventing/test/rekt/resources/subscription"
@@ -360,21 +359,6 @@ func TestInMemoryChannelTLS(t *testing.T) {
 	env.ParallelTest(ctx, t, channel.SubscriptionTLSTrustBundle())
 }
 
-func TestChannelImplDispatcherAuthenticatesWithOIDC(t *testing.T) {
-	t.Parallel()
-
-	ctx, env := global.Environment(
-		knative.WithKnativeNamespace(system.Namespace()),
-		knative.WithLoggingConfig,
-		knative.WithTracingConfig,
-		k8s.WithEventListener,
-		environment.Managed(t),
-		eventshub.WithTLS(t),
-	)
-
-	env.Test(ctx, t, channel.DispatcherAuthenticatesRequestsWithOIDC())
-}
-
 func TestChannelImplSupportsOIDC(t *testing.T) {
 	t.Parallel()
 
@@ -385,10 +369,11 @@ func TestChannelImplSupportsOIDC(t *testing.T) {
 		k8s.WithEventListener,
 		environment.Managed(t),
 		environment.WithPollTimings(4*time.Second, 12*time.Minute),
+		eventshub.WithTLS(t),
 	)
 
 	name := feature.MakeRandomK8sName("channelimpl")
 	env.Prerequisite(ctx, t, channel.ImplGoesReady(name))
 
-	env.TestSet(ctx, t, oidc.AddressableOIDCConformance(channel_impl.GVR(), channel_impl.GVK().Kind, name, env.Namespace()))
+	env.TestSet(ctx, t, channel.ChannelOIDCConformance(name, env.Namespace()))
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/cloudevents/sdk-go/v2/test"
 	"knative.dev/eventing/test/rekt/features/featureflags"
+	"knative.dev/eventing/test/rekt/features/oidc"
 	"knative.dev/eventing/test/rekt/resources/channel_impl"
 	"knative.dev/eventing/test/rekt/resources/subscription"
 	"knative.dev/reconciler-test/pkg/eventshub"
@@ -29,6 +30,29 @@ import (
 	"knative.dev/reconciler-test/pkg/resources/service"
 )
 
+// ChannelOIDCConformance returns the OIDC conformance features of the channel
+// implementation, covering its address, the tokens it accepts and the identity
+// its dispatcher uses.
+func ChannelOIDCConformance(name, namespace string) *feature.FeatureSet {
+	fs := oidc.AddressableOIDCTokenConformance(channel_impl.GVR(), channel_impl.GVK().Kind, name)
+	fs.Name = "Channel handles requests with OIDC tokens correctly"
+	fs.Features = append(fs.Features,
+		ChannelGetsAudiencePopulated(name, namespace),
+		DispatcherAuthenticatesRequestsWithOIDC(),
+	)
+	return fs
+}
+
+// ChannelGetsAudiencePopulated checks that the channel exposes its audience in its address.
+func ChannelGetsAudiencePopulated(name, namespace string) *feature.Feature {
+	return oidc.AddressableHasAudiencePopulated(channel_impl.GVR(), channel_impl.GVK().Kind, name, namespace)
+}
+
+// ChannelRejectsWrongAudience checks that the channel rejects tokens issued for another audience.
+func ChannelRejectsWrongAudience(name string) *feature.Feature {
+	return oidc.AddressableRejectsInvalidAudience(channel_impl.GVR(), channel_impl.GVK().Kind, name)
+}
+
 func DispatcherAuthenticatesRequestsWithOIDC() *feature.Feature {
 	f := feature.NewFeatureNamed("Channel dispatcher authenticates requests with OIDC")
 
@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/test"
+	"knative.dev/pkg/ptr"
+	"knative.dev/reconciler-test/pkg/eventshub"
+	"knative.dev/reconciler-test/pkg/eventshub/assert"
+	"knative.dev/reconciler-test/pkg/feature"
+	"knative.dev/reconciler-test/pkg/manifest"
+	"knative.dev/reconciler-test/pkg/resources/service"
+
+	duckv1 "knative.dev/eventing/pkg/apis/duck/v1"
+	"knative.dev/eventing/test/rekt/features/featureflags"
+	"knative.dev/eventing/test/rekt/resources/broker"
+	"knative.dev/eventing/test/rekt/resources/channel_impl"
+	"knative.dev/eventing/test/rekt/resources/subscription"
+	"knative.dev/eventing/test/rekt/resources/trigger"
+)
+
+const (
+	// retryAfter is sent by the sink with the rejected attempts. It is much larger
+	// than the configured backoff, so the spacing of the attempts shows that it is respected.
+	retryAfter = 3 * time.Second
+	// rejectedAttempts is the number of attempts the sink rejects before accepting the event.
+	rejectedAttempts = 2
+	retries          = 3
+	backoffDelay     = "PT0.2S"
+	retryAfterMax    = "PT10S"
+)
+
+// RetryAfterConformance returns features checking that Triggers and Subscriptions
+// retry deliveries rejected with 429 Too Many Requests, waiting for the duration
+// of the Retry-After header of the response.
+func RetryAfterConformance() *feature.FeatureSet {
+	return &feature.FeatureSet{
+		Name: "Delivery respects Retry-After",
+		Features: []*feature.Feature{
+			TriggerRespectsRetryAfter(),
+			SubscriptionRespectsRetryAfter(),
+		},
+	}
+}
+
+// TriggerRespectsRetryAfter checks that a Trigger waits for the Retry-After duration between retries.
+func TriggerRespectsRetryAfter() *feature.Feature {
+	f := feature.NewFeatureNamed("Trigger respects Retry-After")
+
+	f.Prerequisite("Retry-After is enabled", featureflags.DeliveryRetryAfterEnabled())
+
+	source := feature.MakeRandomK8sName("source")
+	sink := feature.MakeRandomK8sName("sink")
+	brokerName := feature.MakeRandomK8sName("broker")
+	triggerName := feature.MakeRandomK8sName("trigger")
+
+	event := test.FullEvent()
+
+	f.Setup("install sink", eventshub.Install(sink, retryAfterSinkOptions()...))
+	f.Setup("install broker", broker.Install(brokerName, broker.WithEnvConfig()...))
+	f.Setup("broker is ready", broker.IsReady(brokerName))
+	f.Setup("install trigger", trigger.Install(triggerName, brokerName,
+		append(retryConfig(trigger.WithRetry, trigger.WithRetryAfterMax),
+			trigger.WithSubscriber(service.AsKReference(sink), ""))...))
+	f.Setup("trigger is ready", trigger.IsReady(triggerName))
+
+	f.Requirement("install source", eventshub.Install(source,
+		eventshub.StartSenderToResource(broker.GVR(), brokerName),
+		eventshub.InputEvent(event)))
+
+	f.Stable("Trigger delivery").
+		Must("retries until the event is accepted", assertRetriedAfter(sink, event.ID()))
+
+	return f
+}
+
+// SubscriptionRespectsRetryAfter checks that a Subscription waits for the Retry-After duration between retries.
+func SubscriptionRespectsRetryAfter() *feature.Feature {
+	f := feature.NewFeatureNamed("Subscription respects Retry-After")
+
+	f.Prerequisite("Retry-After is enabled", featureflags.DeliveryRetryAfterEnabled())
+
+	source := feature.MakeRandomK8sName("source")
+	sink := feature.MakeRandomK8sName("sink")
+	channelName := feature.MakeRandomK8sName("channel")
+	subscriptionName := feature.MakeRandomK8sName("subscription")
+
+	event := test.FullEvent()
+
+	f.Setup("install sink", eventshub.Install(sink, retryAfterSinkOptions()...))
+	f.Setup("install channel", channel_impl.Install(channelName))
+	f.Setup("channel is ready", channel_impl.IsReady(channelName))
+	f.Setup("install subscription", subscription.Install(subscriptionName,
+		append(retryConfig(subscription.WithRetry, subscription.WithRetryAfterMax),
+			subscription.WithChannel(channel_impl.AsRef(channelName)),
+			subscription.WithSubscriber(service.AsKReference(sink), "", ""))...))
+	f.Setup("subscription is ready", subscription.IsReady(subscriptionName))
+
+	f.Requirement("install source", eventshub.Install(source,
+		eventshub.StartSenderToResource(channel_impl.GVR(), channelName),
+		eventshub.InputEvent(event)))
+
+	f.Stable("Subscription delivery").
+		Must("retries until the event is accepted", assertRetriedAfter(sink, event.ID()))
+
+	return f
+}
+
+func retryAfterSinkOptions() []eventshub.EventsHubOption {
+	return []eventshub.EventsHubOption{
+		eventshub.StartReceiver,
+		eventshub.DropFirstN(rejectedAttempts),
+		eventshub.DropEventsResponseCode(http.StatusTooManyRequests),
+		eventshub.DropEventsResponseHeaders(map[string]string{
+			"Retry-After": strconv.Itoa(int(retryAfter.Seconds())),
+		}),
+	}
+}
+
+func retryConfig(withRetry func(int32, *duckv1.BackoffPolicyType, *string) manifest.CfgFn, withRetryAfterMax func(string) manifest.CfgFn) []manifest.CfgFn {
+	linear := duckv1.BackoffPolicyLinear
+	return []manifest.CfgFn{
+		withRetry(retries, &linear, ptr.String(backoffDelay)),
+		withRetryAfterMax(retryAfterMax),
+	}
+}
+
+// assertRetriedAfter asserts that the sink rejected the event rejectedAttempts times
+// before accepting it, and that the attempts are spaced by at least retryAfter.
+func assertRetriedAfter(sink, id string) feature.StepFn {
+	return func(ctx context.Context, t feature.T) {
+		store := eventshub.StoreFromContext(ctx, sink)
+
+		received := store.AssertExact(ctx, t, 1,
+			assert.MatchKind(eventshub.EventReceived),
+			assert.MatchEvent(test.HasId(id)))
+		rejected := store.AssertExact(ctx, t, rejectedAttempts,
+			assert.MatchKind(eventshub.EventRejected),
+			assert.MatchEvent(test.HasId(id)))
+
+		attempts := append(rejected, received...)
+		sort.Slice(attempts, func(i, j int) bool {
+			return attempts[i].Time.Before(attempts[j].Time)
+		})
+
+		for i := 1; i < len(attempts); i++ {
+			if wait := attempts[i].Time.Sub(attempts[i-1].Time); wait < retryAfter {
+				t.Errorf("Attempt %d was sent %v after the previous one, want at least %v", i+1, wait, retryAfter)
+			}
+		}
+	}
+}
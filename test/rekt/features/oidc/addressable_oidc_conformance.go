@@ -49,7 +49,7 @@ func AddressableOIDCTokenConformance(gvr schema.GroupVersionResource, kind, name
 	fs := feature.FeatureSet{
 		Name: fmt.Sprintf("%s handles requests with OIDC tokens correctly", kind),
 		Features: []*feature.Feature{
-			addressableRejectInvalidAudience(gvr, kind, name),
+			AddressableRejectsInvalidAudience(gvr, kind, name),
 			addressableRejectCorruptedSignature(gvr, kind, name),
 			addressableRejectExpiredToken(gvr, kind, name),
 			addressableAllowsValidRequest(gvr, kind, name),
@@ -77,7 +77,8 @@ func AddressableHasAudiencePopulated(gvr schema.GroupVersionResource, kind, name
 	return f
 }
 
-func addressableRejectInvalidAudience(gvr schema.GroupVersionResource, kind, name string) *feature.Feature {
+// AddressableRejectsInvalidAudience checks that the addressable rejects tokens issued for another audience.
+func AddressableRejectsInvalidAudience(gvr schema.GroupVersionResource, kind, name string) *feature.Feature {
 	f := feature.NewFeatureNamed(fmt.Sprintf("%s reject event for wrong OIDC audience", kind))
 
 	f.Prerequisite("OIDC authentication is enabled", featureflags.AuthenticationOIDCEnabled())
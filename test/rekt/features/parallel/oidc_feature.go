@@ -38,7 +38,9 @@ import (
 	"knative.dev/reconciler-test/pkg/resources/service"
 )
 
-func ParallelWithTwoBranchesOIDC(channelTemplate channel_template.ChannelTemplate) *feature.Feature {
+// ParallelBranchesPropagateIdentity checks that the Parallel authenticates with its own
+// identity at the filters, subscribers and replies of its branches.
+func ParallelBranchesPropagateIdentity(channelTemplate channel_template.ChannelTemplate) *feature.Feature {
 	f := feature.NewFeatureNamed("Parallel test.")
 
 	f.Prerequisite("OIDC Authentication is enabled", featureflags.AuthenticationOIDCEnabled())
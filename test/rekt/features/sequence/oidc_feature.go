@@ -59,13 +59,15 @@ func SequenceSendsEventWithOIDC() *feature.FeatureSet {
 	return &feature.FeatureSet{
 		Name: "Sequence send events with OIDC support",
 		Features: []*feature.Feature{
-			SequenceSendsEventWithOIDCTokenToSteps(),
+			SequenceStepsPropagateIdentity(),
 			SequenceSendsEventWithOIDCTokenToReply(),
 		},
 	}
 }
 
-func SequenceSendsEventWithOIDCTokenToSteps() *feature.Feature {
+// SequenceStepsPropagateIdentity checks that the Sequence authenticates with its own
+// identity at all of its steps.
+func SequenceStepsPropagateIdentity() *feature.Feature {
 	f := feature.NewFeatureNamed("Sequence supports OIDC in internal flow between steps")
 
 	f.Prerequisite("OIDC Authentication is enabled", featureflags.AuthenticationOIDCEnabled())
@@ -100,5 +100,5 @@ func TestParallelTwoBranchesWithOIDC(t *testing.T) {
 		eventshub.WithTLS(t),
 	)
 
-	env.Test(ctx, t, parallel.ParallelWithTwoBranchesOIDC(channel_template.ImmemoryChannelTemplate()))
+	env.Test(ctx, t, parallel.ParallelBranchesPropagateIdentity(channel_template.ImmemoryChannelTemplate()))
 }
@@ -117,6 +117,9 @@ var WithDeadLetterSink = delivery.WithDeadLetterSink
 // WithRetry adds the retry related config to a Broker spec.
 var WithRetry = delivery.WithRetry
 
+// WithRetryAfterMax adds the maximum duration to respect Retry-After headers to a Broker spec.
+var WithRetryAfterMax = delivery.WithRetryAfterMax
+
 // WithTimeout adds the timeout related config to the config.
 var WithTimeout = delivery.WithTimeout
 
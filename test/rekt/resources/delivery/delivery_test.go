@@ -158,3 +158,23 @@ func ExampleWithRetry_onlyCount() {
 	//   delivery:
 	//     retry: 42
 }
+
+func ExampleWithRetryAfterMax() {
+	ctx := testlog.NewContext()
+	images := map[string]string{}
+	cfg := map[string]interface{}{}
+	broker.WithRetry(3, nil, nil)(cfg)
+	broker.WithRetryAfterMax("PT10S")(cfg)
+
+	files, err := manifest.ExecuteYAML(ctx, yaml, images, cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	manifest.OutputYAML(os.Stdout, files)
+	// Output:
+	// spec:
+	//   delivery:
+	//     retry: 3
+	//     retryAfterMax: "PT10S"
+}
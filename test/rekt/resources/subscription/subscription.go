@@ -119,6 +119,9 @@ var WithDeadLetterSinkFromDestination = delivery.WithDeadLetterSinkFromDestinati
 // WithRetry adds the retry related config to a Subscription spec.
 var WithRetry = delivery.WithRetry
 
+// WithRetryAfterMax adds the maximum duration to respect Retry-After headers to a Subscription spec.
+var WithRetryAfterMax = delivery.WithRetryAfterMax
+
 // Install will create a Subscription resource, augmented with the config fn options.
 func Install(name string, opts ...manifest.CfgFn) feature.StepFn {
 	cfg := map[string]interface{}{
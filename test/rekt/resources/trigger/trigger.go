@@ -166,6 +166,9 @@ var WithDeadLetterSinkFromDestination = delivery.WithDeadLetterSinkFromDestinati
 // WithRetry adds the retry related config to a Trigger spec.
 var WithRetry = delivery.WithRetry
 
+// WithRetryAfterMax adds the maximum duration to respect Retry-After headers to a Trigger spec.
+var WithRetryAfterMax = delivery.WithRetryAfterMax
+
 // WithTimeout adds the timeout related config to the config.
 var WithTimeout = delivery.WithTimeout
 